@@ -105,9 +105,16 @@ type InternalRaftRequest struct {
 	ClusterVersionSet        *membershippb.ClusterVersionSetRequest    `protobuf:"bytes,1300,opt,name=cluster_version_set,json=clusterVersionSet,proto3" json:"cluster_version_set,omitempty"`
 	ClusterMemberAttrSet     *membershippb.ClusterMemberAttrSetRequest `protobuf:"bytes,1301,opt,name=cluster_member_attr_set,json=clusterMemberAttrSet,proto3" json:"cluster_member_attr_set,omitempty"`
 	DowngradeInfoSet         *membershippb.DowngradeInfoSetRequest     `protobuf:"bytes,1302,opt,name=downgrade_info_set,json=downgradeInfoSet,proto3" json:"downgrade_info_set,omitempty"`
-	XXX_NoUnkeyedLiteral     struct{}                                  `json:"-"`
-	XXX_unrecognized         []byte                                    `json:"-"`
-	XXX_sizecache            int32                                     `json:"-"`
+	// soft_delete, if set, makes the deletions of delete_range or txn move the deleted keys to a
+	// trash, as configured on the member proposing the request.
+	SoftDelete *SoftDelete `protobuf:"bytes,12,opt,name=soft_delete,json=softDelete,proto3" json:"soft_delete,omitempty"`
+	// range_tombstone_threshold, if not zero, is the number of keys above which the deletions of
+	// delete_range or txn record a single range tombstone, as configured on the member proposing
+	// the request.
+	RangeTombstoneThreshold int64    `protobuf:"varint,13,opt,name=range_tombstone_threshold,json=rangeTombstoneThreshold,proto3" json:"range_tombstone_threshold,omitempty"`
+	XXX_NoUnkeyedLiteral    struct{} `json:"-"`
+	XXX_unrecognized        []byte   `json:"-"`
+	XXX_sizecache           int32    `json:"-"`
 }
 
 func (m *InternalRaftRequest) Reset()         { *m = InternalRaftRequest{} }
@@ -228,84 +235,138 @@ func (m *InternalAuthenticateRequest) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_InternalAuthenticateRequest proto.InternalMessageInfo
 
+// SoftDelete configures the deletions moving the deleted keys to a trash.
+type SoftDelete struct {
+	// threshold, if not zero, is the number of keys above which a deletion moves the keys to the trash.
+	Threshold int64 `protobuf:"varint,1,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	// prefixes are the key prefixes whose keys are always moved to the trash when deleted.
+	Prefixes []string `protobuf:"bytes,2,rep,name=prefixes,proto3" json:"prefixes,omitempty"`
+	// trash_prefix is the prefix of the keys in the trash.
+	TrashPrefix          string   `protobuf:"bytes,3,opt,name=trash_prefix,json=trashPrefix,proto3" json:"trash_prefix,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SoftDelete) Reset()         { *m = SoftDelete{} }
+func (m *SoftDelete) String() string { return proto.CompactTextString(m) }
+func (*SoftDelete) ProtoMessage()    {}
+func (*SoftDelete) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b4c9a9be0cfca103, []int{4}
+}
+func (m *SoftDelete) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SoftDelete) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SoftDelete.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *SoftDelete) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SoftDelete.Merge(m, src)
+}
+func (m *SoftDelete) XXX_Size() int {
+	return m.Size()
+}
+func (m *SoftDelete) XXX_DiscardUnknown() {
+	xxx_messageInfo_SoftDelete.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SoftDelete proto.InternalMessageInfo
+
 func init() {
 	proto.RegisterType((*RequestHeader)(nil), "etcdserverpb.RequestHeader")
 	proto.RegisterType((*InternalRaftRequest)(nil), "etcdserverpb.InternalRaftRequest")
 	proto.RegisterType((*EmptyResponse)(nil), "etcdserverpb.EmptyResponse")
 	proto.RegisterType((*InternalAuthenticateRequest)(nil), "etcdserverpb.InternalAuthenticateRequest")
+	proto.RegisterType((*SoftDelete)(nil), "etcdserverpb.SoftDelete")
 }
 
 func init() { proto.RegisterFile("raft_internal.proto", fileDescriptor_b4c9a9be0cfca103) }
 
 var fileDescriptor_b4c9a9be0cfca103 = []byte{
-	// 1072 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x56, 0x4b, 0x73, 0x1b, 0x45,
-	0x17, 0x8d, 0x6c, 0xc7, 0xb6, 0x5a, 0xb6, 0xe3, 0xb4, 0x9d, 0x2f, 0xfd, 0xd9, 0x55, 0xc6, 0x71,
-	0x48, 0x30, 0x10, 0xe4, 0x20, 0xc3, 0x02, 0x36, 0xa0, 0x48, 0x2e, 0xc7, 0x54, 0x48, 0xb9, 0x26,
-	0x81, 0x4a, 0x41, 0x51, 0x43, 0x6b, 0xe6, 0x5a, 0x9a, 0x78, 0x34, 0x33, 0x74, 0xb7, 0x14, 0x67,
-	0xcb, 0x92, 0x35, 0x50, 0xfc, 0x0c, 0x9e, 0xff, 0x21, 0x45, 0xf1, 0x08, 0xf0, 0x07, 0xc0, 0x6c,
-	0xd8, 0x03, 0x7b, 0xaa, 0x1f, 0xf3, 0x92, 0x5a, 0xde, 0x8d, 0xee, 0x3d, 0xf7, 0x9c, 0xd3, 0xdd,
-	0xb7, 0x5b, 0x17, 0xad, 0x30, 0x7a, 0x24, 0xdc, 0x20, 0x12, 0xc0, 0x22, 0x1a, 0xd6, 0x13, 0x16,
-	0x8b, 0x18, 0x2f, 0x80, 0xf0, 0x7c, 0x0e, 0x6c, 0x08, 0x2c, 0xe9, 0xac, 0xad, 0x76, 0xe3, 0x6e,
-	0xac, 0x12, 0x3b, 0xf2, 0x4b, 0x63, 0xd6, 0x96, 0x73, 0x8c, 0x89, 0x54, 0x59, 0xe2, 0x99, 0xcf,
-	0x4d, 0x99, 0xdc, 0xa1, 0x49, 0xb0, 0x33, 0x04, 0xc6, 0x83, 0x38, 0x4a, 0x3a, 0xe9, 0x97, 0x41,
-	0x5c, 0xcf, 0x10, 0x7d, 0xe8, 0x77, 0x80, 0xf1, 0x5e, 0x90, 0x24, 0x9d, 0xc2, 0x0f, 0x8d, 0xdb,
-	0x62, 0x68, 0xd1, 0x81, 0x8f, 0x06, 0xc0, 0xc5, 0x6d, 0xa0, 0x3e, 0x30, 0xbc, 0x84, 0xa6, 0x0e,
-	0xda, 0xa4, 0xb2, 0x59, 0xd9, 0x9e, 0x71, 0xa6, 0x0e, 0xda, 0x78, 0x0d, 0xcd, 0x0f, 0xb8, 0x34,
-	0xdf, 0x07, 0x32, 0xb5, 0x59, 0xd9, 0xae, 0x3a, 0xd9, 0x6f, 0x7c, 0x03, 0x2d, 0xd2, 0x81, 0xe8,
-	0xb9, 0x0c, 0x86, 0x81, 0xd4, 0x26, 0xd3, 0xb2, 0xec, 0xd6, 0xdc, 0x27, 0xdf, 0x91, 0xe9, 0xdd,
-	0xfa, 0xcb, 0xce, 0x82, 0xcc, 0x3a, 0x26, 0xf9, 0xfa, 0xdc, 0xc7, 0x2a, 0x7c, 0x73, 0xeb, 0x7b,
-	0x8c, 0x56, 0x0e, 0xcc, 0x8e, 0x38, 0xf4, 0x48, 0x18, 0x03, 0x78, 0x17, 0xcd, 0xf6, 0x94, 0x09,
-	0xe2, 0x6f, 0x56, 0xb6, 0x6b, 0x8d, 0xf5, 0x7a, 0x71, 0x9f, 0xea, 0x25, 0x9f, 0x8e, 0x81, 0x8e,
-	0xf9, 0xbd, 0x86, 0xa6, 0x86, 0x0d, 0xe5, 0xb4, 0xd6, 0xb8, 0x64, 0x25, 0x70, 0xa6, 0x86, 0x0d,
-	0x7c, 0x13, 0x9d, 0x67, 0x34, 0xea, 0x82, 0xb2, 0x5c, 0x6b, 0xac, 0x8d, 0x20, 0x65, 0x2a, 0x85,
-	0x6b, 0x20, 0x7e, 0x01, 0x4d, 0x27, 0x03, 0x41, 0x66, 0x14, 0x9e, 0x94, 0xf1, 0x87, 0x83, 0x74,
-	0x11, 0x8e, 0x04, 0xe1, 0x16, 0x5a, 0xf0, 0x21, 0x04, 0x01, 0xae, 0x16, 0x39, 0xaf, 0x8a, 0x36,
-	0xcb, 0x45, 0x6d, 0x85, 0x28, 0x49, 0xd5, 0xfc, 0x3c, 0x26, 0x05, 0xc5, 0x49, 0x44, 0x66, 0x6d,
-	0x82, 0xf7, 0x4f, 0xa2, 0x4c, 0x50, 0x9c, 0x44, 0xf8, 0x0d, 0x84, 0xbc, 0xb8, 0x9f, 0x50, 0x4f,
-	0xc8, 0x63, 0x98, 0x53, 0x25, 0xcf, 0x94, 0x4b, 0x5a, 0x59, 0x3e, 0xad, 0x2c, 0x94, 0xe0, 0x37,
-	0x51, 0x2d, 0x04, 0xca, 0xc1, 0xed, 0x32, 0x1a, 0x09, 0x32, 0x6f, 0x63, 0xb8, 0x23, 0x01, 0xfb,
-	0x32, 0x9f, 0x31, 0x84, 0x59, 0x48, 0xae, 0x59, 0x33, 0x30, 0x18, 0xc6, 0xc7, 0x40, 0xaa, 0xb6,
-	0x35, 0x2b, 0x0a, 0x47, 0x01, 0xb2, 0x35, 0x87, 0x79, 0x4c, 0x1e, 0x0b, 0x0d, 0x29, 0xeb, 0x13,
-	0x64, 0x3b, 0x96, 0xa6, 0x4c, 0x65, 0xc7, 0xa2, 0x80, 0xf8, 0x01, 0x5a, 0xd6, 0xb2, 0x5e, 0x0f,
-	0xbc, 0xe3, 0x24, 0x0e, 0x22, 0x41, 0x6a, 0xaa, 0xf8, 0x59, 0x8b, 0x74, 0x2b, 0x03, 0x19, 0x9a,
-	0xb4, 0x59, 0x5f, 0x71, 0x2e, 0x84, 0x65, 0x00, 0x6e, 0xa2, 0x9a, 0xea, 0x6e, 0x88, 0x68, 0x27,
-	0x04, 0xf2, 0x97, 0x75, 0x57, 0x9b, 0x03, 0xd1, 0xdb, 0x53, 0x80, 0x6c, 0x4f, 0x68, 0x16, 0xc2,
-	0x6d, 0xa4, 0xae, 0x80, 0xeb, 0x07, 0x5c, 0x71, 0xfc, 0x3d, 0x67, 0xdb, 0x14, 0xc9, 0xd1, 0xd6,
-	0x88, 0x6c, 0x53, 0x68, 0x1e, 0xc3, 0x6f, 0x19, 0x23, 0x5c, 0x50, 0x31, 0xe0, 0xe4, 0xdf, 0x89,
-	0x46, 0xee, 0x29, 0xc0, 0xc8, 0xca, 0x5e, 0xd5, 0x8e, 0x74, 0x0e, 0xdf, 0xd5, 0x8e, 0x20, 0x12,
-	0x81, 0x47, 0x05, 0x90, 0x7f, 0x34, 0xd9, 0xf3, 0x65, 0xb2, 0xf4, 0x76, 0x36, 0x0b, 0xd0, 0xd4,
-	0x5a, 0xa9, 0x1e, 0xef, 0x99, 0x27, 0x40, 0xbe, 0x09, 0x2e, 0xf5, 0x7d, 0xf2, 0xc3, 0xfc, 0xa4,
-	0x25, 0xbe, 0xc3, 0x81, 0x35, 0x7d, 0xbf, 0xb4, 0x44, 0x13, 0xc3, 0x77, 0xd1, 0x72, 0x4e, 0xa3,
-	0x2f, 0x01, 0xf9, 0x51, 0x33, 0x5d, 0xb5, 0x33, 0x99, 0xdb, 0x63, 0xc8, 0x96, 0x68, 0x29, 0x5c,
-	0xb6, 0xd5, 0x05, 0x41, 0x7e, 0x3a, 0xd3, 0xd6, 0x3e, 0x88, 0x31, 0x5b, 0xfb, 0x20, 0x70, 0x17,
-	0xfd, 0x3f, 0xa7, 0xf1, 0x7a, 0xf2, 0x5a, 0xba, 0x09, 0xe5, 0xfc, 0x51, 0xcc, 0x7c, 0xf2, 0xb3,
-	0xa6, 0x7c, 0xd1, 0x4e, 0xd9, 0x52, 0xe8, 0x43, 0x03, 0x4e, 0xd9, 0xff, 0x47, 0xad, 0x69, 0xfc,
-	0x00, 0xad, 0x16, 0xfc, 0xca, 0xfb, 0xe4, 0xb2, 0x38, 0x04, 0xf2, 0x54, 0x6b, 0x5c, 0x9f, 0x60,
-	0x5b, 0xdd, 0xc5, 0x38, 0x6f, 0x9b, 0x8b, 0x74, 0x34, 0x83, 0xdf, 0x47, 0x97, 0x72, 0x66, 0x7d,
-	0x35, 0x35, 0xf5, 0x2f, 0x9a, 0xfa, 0x39, 0x3b, 0xb5, 0xb9, 0xa3, 0x05, 0x6e, 0x4c, 0xc7, 0x52,
-	0xf8, 0x36, 0x5a, 0xca, 0xc9, 0xc3, 0x80, 0x0b, 0xf2, 0xab, 0x66, 0xbd, 0x62, 0x67, 0xbd, 0x13,
-	0x70, 0x51, 0xea, 0xa3, 0x34, 0x98, 0x31, 0x49, 0x6b, 0x9a, 0xe9, 0xb7, 0x89, 0x4c, 0x52, 0x7a,
-	0x8c, 0x29, 0x0d, 0x66, 0x47, 0xaf, 0x98, 0x64, 0x47, 0x7e, 0x59, 0x9d, 0x74, 0xf4, 0xb2, 0x66,
-	0xb4, 0x23, 0x4d, 0x2c, 0xeb, 0x48, 0x45, 0x63, 0x3a, 0xf2, 0xab, 0xea, 0xa4, 0x8e, 0x94, 0x55,
-	0x96, 0x8e, 0xcc, 0xc3, 0x65, 0x5b, 0xb2, 0x23, 0xbf, 0x3e, 0xd3, 0xd6, 0x68, 0x47, 0x9a, 0x18,
-	0x7e, 0x88, 0xd6, 0x0a, 0x34, 0xaa, 0x51, 0x12, 0x60, 0xfd, 0x80, 0xab, 0xff, 0xdf, 0x6f, 0x34,
-	0xe7, 0x8d, 0x09, 0x9c, 0x12, 0x7e, 0x98, 0xa1, 0x53, 0xfe, 0xcb, 0xd4, 0x9e, 0xc7, 0x7d, 0xb4,
-	0x9e, 0x6b, 0x99, 0xd6, 0x29, 0x88, 0x7d, 0xab, 0xc5, 0x5e, 0xb2, 0x8b, 0xe9, 0x2e, 0x19, 0x57,
-	0x23, 0x74, 0x02, 0x00, 0x7f, 0x88, 0x56, 0xbc, 0x70, 0xc0, 0x05, 0x30, 0xd7, 0xcc, 0x32, 0x2e,
-	0x07, 0x41, 0x3e, 0x45, 0xe6, 0x0a, 0x14, 0x07, 0x99, 0x7a, 0x4b, 0x23, 0xdf, 0xd5, 0xc0, 0x7b,
-	0x20, 0xc6, 0x5e, 0xbd, 0x8b, 0xde, 0x28, 0x04, 0x3f, 0x44, 0x97, 0x53, 0x05, 0x4d, 0xe6, 0x52,
-	0x21, 0x98, 0x52, 0xf9, 0x0c, 0x99, 0x77, 0xd0, 0xa6, 0xf2, 0xb6, 0x8a, 0x35, 0x85, 0x60, 0x36,
-	0xa1, 0x55, 0xcf, 0x82, 0xc2, 0x1f, 0x20, 0xec, 0xc7, 0x8f, 0xa2, 0x2e, 0xa3, 0x3e, 0xb8, 0x41,
-	0x74, 0x14, 0x2b, 0x99, 0xcf, 0xb5, 0xcc, 0xb5, 0xb2, 0x4c, 0x3b, 0x05, 0x1e, 0x44, 0x47, 0xb1,
-	0x4d, 0x62, 0xd9, 0x1f, 0x41, 0xe4, 0xc3, 0xd4, 0x05, 0xb4, 0xb8, 0xd7, 0x4f, 0xc4, 0x63, 0x07,
-	0x78, 0x12, 0x47, 0x1c, 0xb6, 0x1e, 0xa3, 0xf5, 0x33, 0x9e, 0x6f, 0x8c, 0xd1, 0x8c, 0x9a, 0xe5,
-	0x2a, 0x6a, 0x96, 0x53, 0xdf, 0x72, 0xc6, 0xcb, 0x5e, 0x35, 0x33, 0xe3, 0xa5, 0xbf, 0xf1, 0x15,
-	0xb4, 0xc0, 0x83, 0x7e, 0x12, 0x82, 0x2b, 0xe2, 0x63, 0xd0, 0x23, 0x5e, 0xd5, 0xa9, 0xe9, 0xd8,
-	0x7d, 0x19, 0xca, 0xbc, 0xdc, 0x7a, 0xed, 0xc9, 0x1f, 0x1b, 0xe7, 0x9e, 0x9c, 0x6e, 0x54, 0x9e,
-	0x9e, 0x6e, 0x54, 0x7e, 0x3f, 0xdd, 0xa8, 0x7c, 0xf1, 0xe7, 0xc6, 0xb9, 0xf7, 0xae, 0x76, 0x63,
-	0xd5, 0x22, 0xf5, 0x20, 0xde, 0xc9, 0xe7, 0xd6, 0xdd, 0x9d, 0x62, 0xdb, 0x74, 0x66, 0xd5, 0x38,
-	0xba, 0xfb, 0x5f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x06, 0x59, 0x70, 0x10, 0x30, 0x0b, 0x00, 0x00,
+	// 1173 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x56, 0x5d, 0x73, 0xdb, 0x44,
+	0x17, 0xae, 0xed, 0x36, 0xa9, 0xd7, 0x49, 0x9a, 0x6e, 0xd2, 0x37, 0xdb, 0xe4, 0x9d, 0xe0, 0xa6,
+	0xb4, 0x04, 0x28, 0x4e, 0x49, 0xa0, 0x33, 0x70, 0x03, 0x8e, 0x9d, 0x49, 0xc3, 0x94, 0x4e, 0x46,
+	0x09, 0x4c, 0x07, 0x86, 0x11, 0x6b, 0xeb, 0xd8, 0x56, 0x23, 0x4b, 0x62, 0x77, 0xed, 0xa6, 0xb7,
+	0x5c, 0x72, 0x0d, 0x0c, 0x3f, 0x83, 0xcf, 0xff, 0xd0, 0x0b, 0x3e, 0x0a, 0xfd, 0x03, 0x10, 0x6e,
+	0xb8, 0x07, 0xee, 0x99, 0xfd, 0x90, 0x64, 0x39, 0xeb, 0xdc, 0x49, 0xe7, 0x3c, 0xe7, 0x79, 0x9e,
+	0xdd, 0x3d, 0x2b, 0x1d, 0xb4, 0xc0, 0x68, 0x47, 0xb8, 0x7e, 0x28, 0x80, 0x85, 0x34, 0xa8, 0xc5,
+	0x2c, 0x12, 0x11, 0x9e, 0x01, 0xd1, 0xf6, 0x38, 0xb0, 0x21, 0xb0, 0xb8, 0xb5, 0xbc, 0xd8, 0x8d,
+	0xba, 0x91, 0x4a, 0x6c, 0xc8, 0x27, 0x8d, 0x59, 0x9e, 0xcf, 0x30, 0x26, 0x52, 0x66, 0x71, 0xdb,
+	0x3c, 0x56, 0x65, 0x72, 0x83, 0xc6, 0xfe, 0xc6, 0x10, 0x18, 0xf7, 0xa3, 0x30, 0x6e, 0x25, 0x4f,
+	0x06, 0x71, 0x33, 0x45, 0xf4, 0xa1, 0xdf, 0x02, 0xc6, 0x7b, 0x7e, 0x1c, 0xb7, 0x46, 0x5e, 0x34,
+	0x6e, 0x8d, 0xa1, 0x59, 0x07, 0x3e, 0x19, 0x00, 0x17, 0x77, 0x81, 0x7a, 0xc0, 0xf0, 0x1c, 0x2a,
+	0xee, 0x35, 0x49, 0xa1, 0x5a, 0x58, 0x3f, 0xef, 0x14, 0xf7, 0x9a, 0x78, 0x19, 0x5d, 0x1c, 0x70,
+	0x69, 0xbe, 0x0f, 0xa4, 0x58, 0x2d, 0xac, 0x97, 0x9d, 0xf4, 0x1d, 0xdf, 0x42, 0xb3, 0x74, 0x20,
+	0x7a, 0x2e, 0x83, 0xa1, 0x2f, 0xb5, 0x49, 0x49, 0x96, 0x6d, 0x4f, 0x7f, 0xf6, 0x03, 0x29, 0x6d,
+	0xd5, 0x5e, 0x75, 0x66, 0x64, 0xd6, 0x31, 0xc9, 0x37, 0xa7, 0x3f, 0x55, 0xe1, 0xdb, 0x6b, 0xcf,
+	0x16, 0xd0, 0xc2, 0x9e, 0xd9, 0x11, 0x87, 0x76, 0x84, 0x31, 0x80, 0xb7, 0xd0, 0x54, 0x4f, 0x99,
+	0x20, 0x5e, 0xb5, 0xb0, 0x5e, 0xd9, 0x5c, 0xa9, 0x8d, 0xee, 0x53, 0x2d, 0xe7, 0xd3, 0x99, 0xea,
+	0xd9, 0xfd, 0xde, 0x40, 0xc5, 0xe1, 0xa6, 0x72, 0x5a, 0xd9, 0xbc, 0x62, 0x25, 0x70, 0x8a, 0xc3,
+	0x4d, 0x7c, 0x1b, 0x5d, 0x60, 0x34, 0xec, 0x82, 0xb2, 0x5c, 0xd9, 0x5c, 0x1e, 0x43, 0xca, 0x54,
+	0x02, 0xd7, 0x40, 0xfc, 0x12, 0x2a, 0xc5, 0x03, 0x41, 0xce, 0x2b, 0x3c, 0xc9, 0xe3, 0xf7, 0x07,
+	0xc9, 0x22, 0x1c, 0x09, 0xc2, 0x0d, 0x34, 0xe3, 0x41, 0x00, 0x02, 0x5c, 0x2d, 0x72, 0x41, 0x15,
+	0x55, 0xf3, 0x45, 0x4d, 0x85, 0xc8, 0x49, 0x55, 0xbc, 0x2c, 0x26, 0x05, 0xc5, 0x71, 0x48, 0xa6,
+	0x6c, 0x82, 0x87, 0xc7, 0x61, 0x2a, 0x28, 0x8e, 0x43, 0xfc, 0x16, 0x42, 0xed, 0xa8, 0x1f, 0xd3,
+	0xb6, 0x90, 0xc7, 0x30, 0xad, 0x4a, 0x9e, 0xcb, 0x97, 0x34, 0xd2, 0x7c, 0x52, 0x39, 0x52, 0x82,
+	0xdf, 0x46, 0x95, 0x00, 0x28, 0x07, 0xb7, 0xcb, 0x68, 0x28, 0xc8, 0x45, 0x1b, 0xc3, 0x3d, 0x09,
+	0xd8, 0x95, 0xf9, 0x94, 0x21, 0x48, 0x43, 0x72, 0xcd, 0x9a, 0x81, 0xc1, 0x30, 0x3a, 0x02, 0x52,
+	0xb6, 0xad, 0x59, 0x51, 0x38, 0x0a, 0x90, 0xae, 0x39, 0xc8, 0x62, 0xf2, 0x58, 0x68, 0x40, 0x59,
+	0x9f, 0x20, 0xdb, 0xb1, 0xd4, 0x65, 0x2a, 0x3d, 0x16, 0x05, 0xc4, 0x0f, 0xd0, 0xbc, 0x96, 0x6d,
+	0xf7, 0xa0, 0x7d, 0x14, 0x47, 0x7e, 0x28, 0x48, 0x45, 0x15, 0x3f, 0x6f, 0x91, 0x6e, 0xa4, 0x20,
+	0x43, 0x93, 0x34, 0xeb, 0x6b, 0xce, 0xa5, 0x20, 0x0f, 0xc0, 0x75, 0x54, 0x51, 0xdd, 0x0d, 0x21,
+	0x6d, 0x05, 0x40, 0xfe, 0xb2, 0xee, 0x6a, 0x7d, 0x20, 0x7a, 0x3b, 0x0a, 0x90, 0xee, 0x09, 0x4d,
+	0x43, 0xb8, 0x89, 0xd4, 0x15, 0x70, 0x3d, 0x9f, 0x2b, 0x8e, 0xbf, 0xa7, 0x6d, 0x9b, 0x22, 0x39,
+	0x9a, 0x3e, 0x1f, 0x25, 0xa9, 0xd0, 0x2c, 0x86, 0xdf, 0x31, 0x46, 0xb8, 0xa0, 0x62, 0xc0, 0xc9,
+	0xbf, 0x13, 0x8d, 0x1c, 0x28, 0xc0, 0xd8, 0xca, 0x5e, 0xd7, 0x8e, 0x74, 0x0e, 0xdf, 0xd7, 0x8e,
+	0x20, 0x14, 0x7e, 0x9b, 0x0a, 0x20, 0xff, 0x68, 0xb2, 0x17, 0xf3, 0x64, 0xc9, 0xed, 0xac, 0x8f,
+	0x40, 0x13, 0x6b, 0xb9, 0x7a, 0xbc, 0x63, 0x3e, 0x01, 0x03, 0x0e, 0xcc, 0xa5, 0x9e, 0x47, 0x7e,
+	0xbc, 0x38, 0x69, 0x89, 0xef, 0x71, 0x60, 0x75, 0xcf, 0xcb, 0x2d, 0xd1, 0xc4, 0xf0, 0x7d, 0x34,
+	0x9f, 0xd1, 0xe8, 0x4b, 0x40, 0x7e, 0xd2, 0x4c, 0xd7, 0xed, 0x4c, 0xe6, 0xf6, 0x18, 0xb2, 0x39,
+	0x9a, 0x0b, 0xe7, 0x6d, 0x75, 0x41, 0x90, 0x9f, 0xcf, 0xb4, 0xb5, 0x0b, 0xe2, 0x94, 0xad, 0x5d,
+	0x10, 0xb8, 0x8b, 0xae, 0x66, 0x34, 0xed, 0x9e, 0xbc, 0x96, 0x6e, 0x4c, 0x39, 0x7f, 0x14, 0x31,
+	0x8f, 0xfc, 0xa2, 0x29, 0x5f, 0xb6, 0x53, 0x36, 0x14, 0x7a, 0xdf, 0x80, 0x13, 0xf6, 0xff, 0x51,
+	0x6b, 0x1a, 0x3f, 0x40, 0x8b, 0x23, 0x7e, 0xe5, 0x7d, 0x72, 0x59, 0x14, 0x00, 0x79, 0xaa, 0x35,
+	0x6e, 0x4e, 0xb0, 0xad, 0xee, 0x62, 0x94, 0xb5, 0xcd, 0x65, 0x3a, 0x9e, 0xc1, 0x1f, 0xa2, 0x2b,
+	0x19, 0xb3, 0xbe, 0x9a, 0x9a, 0xfa, 0x57, 0x4d, 0xfd, 0x82, 0x9d, 0xda, 0xdc, 0xd1, 0x11, 0x6e,
+	0x4c, 0x4f, 0xa5, 0xf0, 0x5d, 0x34, 0x97, 0x91, 0x07, 0x3e, 0x17, 0xe4, 0x37, 0xcd, 0x7a, 0xcd,
+	0xce, 0x7a, 0xcf, 0xe7, 0x22, 0xd7, 0x47, 0x49, 0x30, 0x65, 0x92, 0xd6, 0x34, 0xd3, 0xb3, 0x89,
+	0x4c, 0x52, 0xfa, 0x14, 0x53, 0x12, 0x4c, 0x8f, 0x5e, 0x31, 0xc9, 0x8e, 0xfc, 0xba, 0x3c, 0xe9,
+	0xe8, 0x65, 0xcd, 0x78, 0x47, 0x9a, 0x58, 0xda, 0x91, 0x8a, 0xc6, 0x74, 0xe4, 0x37, 0xe5, 0x49,
+	0x1d, 0x29, 0xab, 0x2c, 0x1d, 0x99, 0x85, 0xf3, 0xb6, 0x64, 0x47, 0x7e, 0x7b, 0xa6, 0xad, 0xf1,
+	0x8e, 0x34, 0x31, 0xfc, 0x10, 0x2d, 0x8f, 0xd0, 0xa8, 0x46, 0x89, 0x81, 0xf5, 0x7d, 0xae, 0xfe,
+	0xbf, 0xdf, 0x69, 0xce, 0x5b, 0x13, 0x38, 0x25, 0x7c, 0x3f, 0x45, 0x27, 0xfc, 0x4b, 0xd4, 0x9e,
+	0xc7, 0x7d, 0xb4, 0x92, 0x69, 0x99, 0xd6, 0x19, 0x11, 0xfb, 0x5e, 0x8b, 0xbd, 0x62, 0x17, 0xd3,
+	0x5d, 0x72, 0x5a, 0x8d, 0xd0, 0x09, 0x00, 0xfc, 0x31, 0x5a, 0x68, 0x07, 0x03, 0x2e, 0x80, 0xb9,
+	0x66, 0x96, 0x71, 0x39, 0x08, 0xf2, 0x39, 0x32, 0x57, 0x60, 0x74, 0x90, 0xa9, 0x35, 0x34, 0xf2,
+	0x7d, 0x0d, 0x3c, 0x00, 0x71, 0xea, 0xab, 0x77, 0xb9, 0x3d, 0x0e, 0xc1, 0x0f, 0xd1, 0x52, 0xa2,
+	0xa0, 0xc9, 0x5c, 0x2a, 0x04, 0x53, 0x2a, 0x5f, 0x20, 0xf3, 0x1d, 0xb4, 0xa9, 0xbc, 0xab, 0x62,
+	0x75, 0x21, 0x98, 0x4d, 0x68, 0xb1, 0x6d, 0x41, 0xe1, 0x8f, 0x10, 0xf6, 0xa2, 0x47, 0x61, 0x97,
+	0x51, 0x0f, 0x5c, 0x3f, 0xec, 0x44, 0x4a, 0xe6, 0x4b, 0x2d, 0x73, 0x23, 0x2f, 0xd3, 0x4c, 0x80,
+	0x7b, 0x61, 0x27, 0xb2, 0x49, 0xcc, 0x7b, 0x63, 0x08, 0xbc, 0x8d, 0x2a, 0x3c, 0xea, 0x88, 0xa4,
+	0x33, 0x67, 0x6c, 0x43, 0xc2, 0x41, 0xd4, 0x11, 0xba, 0xfb, 0x12, 0xa6, 0x3b, 0x0e, 0xe2, 0x69,
+	0x10, 0x37, 0xd0, 0x55, 0x35, 0x9e, 0xb8, 0x22, 0xea, 0xb7, 0xb8, 0x88, 0x42, 0x70, 0x45, 0x8f,
+	0x01, 0xef, 0x45, 0x81, 0x47, 0x66, 0xab, 0x85, 0xf5, 0x52, 0x56, 0xb7, 0xa4, 0x90, 0x87, 0x09,
+	0xf0, 0x30, 0xc1, 0x65, 0x53, 0xdd, 0x25, 0x34, 0xbb, 0xd3, 0x8f, 0xc5, 0x63, 0x07, 0x78, 0x1c,
+	0x85, 0x1c, 0xd6, 0x1e, 0xa3, 0x95, 0x33, 0xfe, 0x23, 0x18, 0xa3, 0xf3, 0x6a, 0xa8, 0x2c, 0xa8,
+	0xa1, 0x52, 0x3d, 0xcb, 0x61, 0x33, 0xfd, 0xbc, 0x9a, 0x61, 0x33, 0x79, 0xc7, 0xd7, 0xd0, 0x0c,
+	0xf7, 0xfb, 0x71, 0x20, 0xed, 0x1e, 0x81, 0x9e, 0x35, 0xcb, 0x4e, 0x45, 0xc7, 0x0e, 0x65, 0x28,
+	0xf3, 0xc2, 0x10, 0xca, 0x16, 0x8f, 0xff, 0x8f, 0xca, 0xd9, 0xba, 0xa4, 0x5c, 0xc9, 0xc9, 0x02,
+	0x4a, 0x93, 0x41, 0xc7, 0x3f, 0x06, 0x4e, 0x8a, 0xd5, 0x92, 0xd2, 0x34, 0xef, 0x52, 0x53, 0x30,
+	0xca, 0x7b, 0xae, 0x8e, 0x24, 0x9a, 0x2a, 0xb6, 0xaf, 0x42, 0x89, 0xe6, 0x9d, 0xed, 0x37, 0x9e,
+	0xfc, 0xb1, 0x7a, 0xee, 0xc9, 0xc9, 0x6a, 0xe1, 0xe9, 0xc9, 0x6a, 0xe1, 0xf7, 0x93, 0xd5, 0xc2,
+	0x57, 0x7f, 0xae, 0x9e, 0xfb, 0xe0, 0x7a, 0x37, 0x52, 0x87, 0x52, 0xf3, 0xa3, 0x8d, 0x6c, 0x68,
+	0xdf, 0xda, 0x18, 0x3d, 0xa8, 0xd6, 0x94, 0x9a, 0xc5, 0xb7, 0xfe, 0x1b, 0x00, 0x1d, 0x9e, 0x5b,
+	0x97, 0x2d, 0x0c, 0x00, 0x00,
 }
 
 func (m *RequestHeader) Marshal() (dAtA []byte, err error) {
@@ -670,6 +731,23 @@ func (m *InternalRaftRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i--
 		dAtA[i] = 0xa2
 	}
+	if m.RangeTombstoneThreshold != 0 {
+		i = encodeVarintRaftInternal(dAtA, i, uint64(m.RangeTombstoneThreshold))
+		i--
+		dAtA[i] = 0x68
+	}
+	if m.SoftDelete != nil {
+		{
+			size, err := m.SoftDelete.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRaftInternal(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x62
+	}
 	if m.LeaseCheckpoint != nil {
 		{
 			size, err := m.LeaseCheckpoint.MarshalToSizedBuffer(dAtA[:i])
@@ -873,6 +951,54 @@ func (m *InternalAuthenticateRequest) MarshalToSizedBuffer(dAtA []byte) (int, er
 	return len(dAtA) - i, nil
 }
 
+func (m *SoftDelete) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SoftDelete) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SoftDelete) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.TrashPrefix) > 0 {
+		i -= len(m.TrashPrefix)
+		copy(dAtA[i:], m.TrashPrefix)
+		i = encodeVarintRaftInternal(dAtA, i, uint64(len(m.TrashPrefix)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Prefixes) > 0 {
+		for iNdEx := len(m.Prefixes) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Prefixes[iNdEx])
+			copy(dAtA[i:], m.Prefixes[iNdEx])
+			i = encodeVarintRaftInternal(dAtA, i, uint64(len(m.Prefixes[iNdEx])))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.Threshold != 0 {
+		i = encodeVarintRaftInternal(dAtA, i, uint64(m.Threshold))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintRaftInternal(dAtA []byte, offset int, v uint64) int {
 	offset -= sovRaftInternal(v)
 	base := offset
@@ -955,6 +1081,13 @@ func (m *InternalRaftRequest) Size() (n int) {
 		l = m.LeaseCheckpoint.Size()
 		n += 1 + l + sovRaftInternal(uint64(l))
 	}
+	if m.SoftDelete != nil {
+		l = m.SoftDelete.Size()
+		n += 1 + l + sovRaftInternal(uint64(l))
+	}
+	if m.RangeTombstoneThreshold != 0 {
+		n += 1 + sovRaftInternal(uint64(m.RangeTombstoneThreshold))
+	}
 	if m.Header != nil {
 		l = m.Header.Size()
 		n += 2 + l + sovRaftInternal(uint64(l))
@@ -1081,6 +1214,31 @@ func (m *InternalAuthenticateRequest) Size() (n int) {
 	return n
 }
 
+func (m *SoftDelete) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Threshold != 0 {
+		n += 1 + sovRaftInternal(uint64(m.Threshold))
+	}
+	if len(m.Prefixes) > 0 {
+		for _, s := range m.Prefixes {
+			l = len(s)
+			n += 1 + l + sovRaftInternal(uint64(l))
+		}
+	}
+	l = len(m.TrashPrefix)
+	if l > 0 {
+		n += 1 + l + sovRaftInternal(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
 func sovRaftInternal(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -1616,6 +1774,61 @@ func (m *InternalRaftRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 12:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SoftDelete", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.SoftDelete == nil {
+				m.SoftDelete = &SoftDelete{}
+			}
+			if err := m.SoftDelete.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 13:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RangeTombstoneThreshold", wireType)
+			}
+			m.RangeTombstoneThreshold = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.RangeTombstoneThreshold |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		case 100:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Header", wireType)
@@ -2592,6 +2805,140 @@ func (m *InternalAuthenticateRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *SoftDelete) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRaftInternal
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SoftDelete: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SoftDelete: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Threshold", wireType)
+			}
+			m.Threshold = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Threshold |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Prefixes", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Prefixes = append(m.Prefixes, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TrashPrefix", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.TrashPrefix = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRaftInternal(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipRaftInternal(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
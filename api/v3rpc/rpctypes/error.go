@@ -43,10 +43,13 @@ var (
 	ErrGRPCPeerURLExist           = status.Error(codes.FailedPrecondition, "etcdserver: Peer URLs already exists")
 	ErrGRPCMemberNotEnoughStarted = status.Error(codes.FailedPrecondition, "etcdserver: re-configuration failed due to not enough started members")
 	ErrGRPCMemberBadURLs          = status.Error(codes.InvalidArgument, "etcdserver: given member URLs are invalid")
+	ErrGRPCMemberBadLabels        = status.Error(codes.InvalidArgument, "etcdserver: given member labels are invalid")
+	ErrGRPCLabelsNotSupported     = status.Error(codes.FailedPrecondition, "etcdserver: member labels require cluster version 3.6")
 	ErrGRPCMemberNotFound         = status.Error(codes.NotFound, "etcdserver: member not found")
 	ErrGRPCMemberNotLearner       = status.Error(codes.FailedPrecondition, "etcdserver: can only promote a learner member")
 	ErrGRPCLearnerNotReady        = status.Error(codes.FailedPrecondition, "etcdserver: can only promote a learner member which is in sync with leader")
 	ErrGRPCTooManyLearners        = status.Error(codes.FailedPrecondition, "etcdserver: too many learner members in cluster")
+	ErrGRPCMemberReadReplica      = status.Error(codes.FailedPrecondition, "etcdserver: cannot promote a read replica member")
 	ErrGRPCClusterIDMismatch      = status.Error(codes.FailedPrecondition, "etcdserver: cluster ID mismatch")
 	//revive:disable:var-naming
 	// Deprecated: Please use ErrGRPCClusterIDMismatch.
@@ -55,6 +58,7 @@ var (
 
 	ErrGRPCRequestTooLarge        = status.Error(codes.InvalidArgument, "etcdserver: request is too large")
 	ErrGRPCRequestTooManyRequests = status.Error(codes.ResourceExhausted, "etcdserver: too many requests")
+	ErrGRPCTooManyClientStreams   = status.Error(codes.ResourceExhausted, "etcdserver: too many streams from client address")
 
 	ErrGRPCRootUserNotExist     = status.Error(codes.FailedPrecondition, "etcdserver: root user does not exist")
 	ErrGRPCRootRoleNotExist     = status.Error(codes.FailedPrecondition, "etcdserver: root user does not have root role")
@@ -86,13 +90,24 @@ var (
 	ErrGRPCUnhealthy                  = status.Error(codes.Unavailable, "etcdserver: unhealthy cluster")
 	ErrGRPCCorrupt                    = status.Error(codes.DataLoss, "etcdserver: corrupt cluster")
 	ErrGRPCNotSupportedForLearner     = status.Error(codes.FailedPrecondition, "etcdserver: rpc not supported for learner")
+	ErrGRPCNotSupportedForWitness     = status.Error(codes.FailedPrecondition, "etcdserver: rpc not supported for witness")
 	ErrGRPCBadLeaderTransferee        = status.Error(codes.FailedPrecondition, "etcdserver: bad leader transferee")
+	ErrGRPCDraining                   = status.Error(codes.Unavailable, "etcdserver: member is draining")
+	ErrGRPCQuarantined                = status.Error(codes.Unavailable, "etcdserver: member is quarantined")
+	ErrGRPCAdmissionDenied            = status.Error(codes.InvalidArgument, "etcdserver: request denied by admission control")
+	ErrGRPCRevisionPinned             = status.Error(codes.FailedPrecondition, "etcdserver: compaction revision is pinned by a lease")
+	ErrGRPCEncryptionNotEnabled       = status.Error(codes.FailedPrecondition, "etcdserver: encryption at rest is not enabled")
+	ErrGRPCClusterEventsLagging       = status.Error(codes.ResourceExhausted, "etcdserver: cluster events stream is too slow to keep up")
+	ErrGRPCReadOnly                   = status.Error(codes.FailedPrecondition, "etcdserver: cluster is read-only")
+	ErrGRPCProtectedPrefix            = status.Error(codes.PermissionDenied, "etcdserver: key is under a protected prefix")
+	ErrGRPCHotKeysNotEnabled          = status.Error(codes.FailedPrecondition, "etcdserver: hot key tracking is not enabled")
 
 	ErrGRPCWrongDowngradeVersionFormat   = status.Error(codes.InvalidArgument, "etcdserver: wrong downgrade target version format")
 	ErrGRPCInvalidDowngradeTargetVersion = status.Error(codes.InvalidArgument, "etcdserver: invalid downgrade target version")
 	ErrGRPCClusterVersionUnavailable     = status.Error(codes.FailedPrecondition, "etcdserver: cluster version not found during downgrade")
 	ErrGRPCDowngradeInProcess            = status.Error(codes.FailedPrecondition, "etcdserver: cluster has a downgrade job in progress")
 	ErrGRPCNoInflightDowngrade           = status.Error(codes.FailedPrecondition, "etcdserver: no inflight downgrade job")
+	ErrGRPCRangeTombstonesPresent        = status.Error(codes.FailedPrecondition, "etcdserver: range tombstones are not compacted yet")
 
 	ErrGRPCCanceled         = status.Error(codes.Canceled, "etcdserver: request canceled")
 	ErrGRPCDeadlineExceeded = status.Error(codes.DeadlineExceeded, "etcdserver: context deadline exceeded")
@@ -118,14 +133,18 @@ var (
 		ErrorDesc(ErrGRPCPeerURLExist):           ErrGRPCPeerURLExist,
 		ErrorDesc(ErrGRPCMemberNotEnoughStarted): ErrGRPCMemberNotEnoughStarted,
 		ErrorDesc(ErrGRPCMemberBadURLs):          ErrGRPCMemberBadURLs,
+		ErrorDesc(ErrGRPCMemberBadLabels):        ErrGRPCMemberBadLabels,
+		ErrorDesc(ErrGRPCLabelsNotSupported):     ErrGRPCLabelsNotSupported,
 		ErrorDesc(ErrGRPCMemberNotFound):         ErrGRPCMemberNotFound,
 		ErrorDesc(ErrGRPCMemberNotLearner):       ErrGRPCMemberNotLearner,
 		ErrorDesc(ErrGRPCLearnerNotReady):        ErrGRPCLearnerNotReady,
 		ErrorDesc(ErrGRPCTooManyLearners):        ErrGRPCTooManyLearners,
+		ErrorDesc(ErrGRPCMemberReadReplica):      ErrGRPCMemberReadReplica,
 		ErrorDesc(ErrGRPCClusterIDMismatch):      ErrGRPCClusterIDMismatch,
 
 		ErrorDesc(ErrGRPCRequestTooLarge):        ErrGRPCRequestTooLarge,
 		ErrorDesc(ErrGRPCRequestTooManyRequests): ErrGRPCRequestTooManyRequests,
+		ErrorDesc(ErrGRPCTooManyClientStreams):   ErrGRPCTooManyClientStreams,
 
 		ErrorDesc(ErrGRPCRootUserNotExist):     ErrGRPCRootUserNotExist,
 		ErrorDesc(ErrGRPCRootRoleNotExist):     ErrGRPCRootRoleNotExist,
@@ -155,13 +174,24 @@ var (
 		ErrorDesc(ErrGRPCUnhealthy):                  ErrGRPCUnhealthy,
 		ErrorDesc(ErrGRPCCorrupt):                    ErrGRPCCorrupt,
 		ErrorDesc(ErrGRPCNotSupportedForLearner):     ErrGRPCNotSupportedForLearner,
+		ErrorDesc(ErrGRPCNotSupportedForWitness):     ErrGRPCNotSupportedForWitness,
 		ErrorDesc(ErrGRPCBadLeaderTransferee):        ErrGRPCBadLeaderTransferee,
+		ErrorDesc(ErrGRPCDraining):                   ErrGRPCDraining,
+		ErrorDesc(ErrGRPCQuarantined):                ErrGRPCQuarantined,
+		ErrorDesc(ErrGRPCAdmissionDenied):            ErrGRPCAdmissionDenied,
+		ErrorDesc(ErrGRPCRevisionPinned):             ErrGRPCRevisionPinned,
+		ErrorDesc(ErrGRPCEncryptionNotEnabled):       ErrGRPCEncryptionNotEnabled,
+		ErrorDesc(ErrGRPCClusterEventsLagging):       ErrGRPCClusterEventsLagging,
+		ErrorDesc(ErrGRPCReadOnly):                   ErrGRPCReadOnly,
+		ErrorDesc(ErrGRPCProtectedPrefix):            ErrGRPCProtectedPrefix,
+		ErrorDesc(ErrGRPCHotKeysNotEnabled):          ErrGRPCHotKeysNotEnabled,
 
 		ErrorDesc(ErrGRPCClusterVersionUnavailable):     ErrGRPCClusterVersionUnavailable,
 		ErrorDesc(ErrGRPCWrongDowngradeVersionFormat):   ErrGRPCWrongDowngradeVersionFormat,
 		ErrorDesc(ErrGRPCInvalidDowngradeTargetVersion): ErrGRPCInvalidDowngradeTargetVersion,
 		ErrorDesc(ErrGRPCDowngradeInProcess):            ErrGRPCDowngradeInProcess,
 		ErrorDesc(ErrGRPCNoInflightDowngrade):           ErrGRPCNoInflightDowngrade,
+		ErrorDesc(ErrGRPCRangeTombstonesPresent):        ErrGRPCRangeTombstonesPresent,
 	}
 )
 
@@ -186,13 +216,17 @@ var (
 	ErrPeerURLExist           = Error(ErrGRPCPeerURLExist)
 	ErrMemberNotEnoughStarted = Error(ErrGRPCMemberNotEnoughStarted)
 	ErrMemberBadURLs          = Error(ErrGRPCMemberBadURLs)
+	ErrMemberBadLabels        = Error(ErrGRPCMemberBadLabels)
+	ErrLabelsNotSupported     = Error(ErrGRPCLabelsNotSupported)
 	ErrMemberNotFound         = Error(ErrGRPCMemberNotFound)
 	ErrMemberNotLearner       = Error(ErrGRPCMemberNotLearner)
 	ErrMemberLearnerNotReady  = Error(ErrGRPCLearnerNotReady)
 	ErrTooManyLearners        = Error(ErrGRPCTooManyLearners)
+	ErrMemberReadReplica      = Error(ErrGRPCMemberReadReplica)
 
-	ErrRequestTooLarge = Error(ErrGRPCRequestTooLarge)
-	ErrTooManyRequests = Error(ErrGRPCRequestTooManyRequests)
+	ErrRequestTooLarge      = Error(ErrGRPCRequestTooLarge)
+	ErrTooManyRequests      = Error(ErrGRPCRequestTooManyRequests)
+	ErrTooManyClientStreams = Error(ErrGRPCTooManyClientStreams)
 
 	ErrRootUserNotExist     = Error(ErrGRPCRootUserNotExist)
 	ErrRootRoleNotExist     = Error(ErrGRPCRootRoleNotExist)
@@ -228,12 +262,22 @@ var (
 	ErrUnhealthy                  = Error(ErrGRPCUnhealthy)
 	ErrCorrupt                    = Error(ErrGRPCCorrupt)
 	ErrBadLeaderTransferee        = Error(ErrGRPCBadLeaderTransferee)
+	ErrDraining                   = Error(ErrGRPCDraining)
+	ErrQuarantined                = Error(ErrGRPCQuarantined)
+	ErrAdmissionDenied            = Error(ErrGRPCAdmissionDenied)
+	ErrRevisionPinned             = Error(ErrGRPCRevisionPinned)
+	ErrEncryptionNotEnabled       = Error(ErrGRPCEncryptionNotEnabled)
+	ErrClusterEventsLagging       = Error(ErrGRPCClusterEventsLagging)
+	ErrReadOnly                   = Error(ErrGRPCReadOnly)
+	ErrProtectedPrefix            = Error(ErrGRPCProtectedPrefix)
+	ErrHotKeysNotEnabled          = Error(ErrGRPCHotKeysNotEnabled)
 
 	ErrClusterVersionUnavailable     = Error(ErrGRPCClusterVersionUnavailable)
 	ErrWrongDowngradeVersionFormat   = Error(ErrGRPCWrongDowngradeVersionFormat)
 	ErrInvalidDowngradeTargetVersion = Error(ErrGRPCInvalidDowngradeTargetVersion)
 	ErrDowngradeInProcess            = Error(ErrGRPCDowngradeInProcess)
 	ErrNoInflightDowngrade           = Error(ErrGRPCNoInflightDowngrade)
+	ErrRangeTombstonesPresent        = Error(ErrGRPCRangeTombstonesPresent)
 )
 
 // EtcdError defines gRPC server errors.
@@ -19,4 +19,12 @@ var (
 	MetadataHasLeader        = "true"
 
 	MetadataClientAPIVersionKey = "client-api-version"
+
+	// MetadataForwardedCommonNameKey carries the client certificate common
+	// name of a request forwarded by a trusted proxy.
+	MetadataForwardedCommonNameKey = "forwarded-common-name"
+
+	MetadataPriorityKey  = "priority"
+	MetadataPriorityHigh = "high"
+	MetadataPriorityLow  = "low"
 )
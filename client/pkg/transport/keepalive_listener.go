@@ -47,7 +47,14 @@ func NewKeepAliveListener(l net.Listener, scheme string, tlscfg *tls.Config) (ne
 	return kal, nil
 }
 
-type keepaliveListener struct{ net.Listener }
+// defaultKeepAlivePeriod is the TCP keepalive period of the accepted
+// connections, if not set on the listener.
+const defaultKeepAlivePeriod = 30 * time.Second
+
+type keepaliveListener struct {
+	net.Listener
+	period time.Duration
+}
 
 func (kln *keepaliveListener) Accept() (net.Conn, error) {
 	c, err := kln.Listener.Accept()
@@ -65,7 +72,11 @@ func (kln *keepaliveListener) Accept() (net.Conn, error) {
 	if err := kac.SetKeepAlive(true); err != nil {
 		return nil, fmt.Errorf("SetKeepAlive failed, %w", err)
 	}
-	if err := kac.SetKeepAlivePeriod(30 * time.Second); err != nil {
+	period := kln.period
+	if period == 0 {
+		period = defaultKeepAlivePeriod
+	}
+	if err := kac.SetKeepAlivePeriod(period); err != nil {
 		return nil, fmt.Errorf("SetKeepAlivePeriod failed, %w", err)
 	}
 	return kac, nil
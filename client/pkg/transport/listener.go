@@ -65,7 +65,7 @@ func newListener(addr, scheme string, opts ...ListenerOption) (net.Listener, err
 		fallthrough
 	case lnOpts.IsTimeout(), lnOpts.IsSocketOpts():
 		// timeout listener with socket options.
-		ln, err := newKeepAliveListener(&lnOpts.ListenConfig, addr)
+		ln, err := newKeepAliveListener(&lnOpts.ListenConfig, addr, lnOpts.keepAlivePeriod)
 		if err != nil {
 			return nil, err
 		}
@@ -75,7 +75,7 @@ func newListener(addr, scheme string, opts ...ListenerOption) (net.Listener, err
 			writeTimeout: lnOpts.writeTimeout,
 		}
 	case lnOpts.IsTimeout():
-		ln, err := newKeepAliveListener(nil, addr)
+		ln, err := newKeepAliveListener(nil, addr, lnOpts.keepAlivePeriod)
 		if err != nil {
 			return nil, err
 		}
@@ -85,7 +85,7 @@ func newListener(addr, scheme string, opts ...ListenerOption) (net.Listener, err
 			writeTimeout: lnOpts.writeTimeout,
 		}
 	default:
-		ln, err := newKeepAliveListener(nil, addr)
+		ln, err := newKeepAliveListener(nil, addr, lnOpts.keepAlivePeriod)
 		if err != nil {
 			return nil, err
 		}
@@ -99,7 +99,7 @@ func newListener(addr, scheme string, opts ...ListenerOption) (net.Listener, err
 	return wrapTLS(scheme, lnOpts.tlsInfo, lnOpts.Listener)
 }
 
-func newKeepAliveListener(cfg *net.ListenConfig, addr string) (net.Listener, error) {
+func newKeepAliveListener(cfg *net.ListenConfig, addr string, period time.Duration) (net.Listener, error) {
 	var ln net.Listener
 	var err error
 
@@ -112,7 +112,7 @@ func newKeepAliveListener(cfg *net.ListenConfig, addr string) (net.Listener, err
 		return nil, err
 	}
 
-	return NewKeepAliveListener(ln, "tcp", nil)
+	return &keepaliveListener{Listener: ln, period: period}, nil
 }
 
 func wrapTLS(scheme string, tlsinfo *TLSInfo, l net.Listener) (net.Listener, error) {
@@ -28,6 +28,7 @@ type ListenerOptions struct {
 	skipTLSInfoCheck bool
 	writeTimeout     time.Duration
 	readTimeout      time.Duration
+	keepAlivePeriod  time.Duration
 }
 
 func newListenOpts(opts ...ListenerOption) *ListenerOptions {
@@ -72,6 +73,12 @@ func WithTimeout(read, write time.Duration) ListenerOption {
 	}
 }
 
+// WithKeepAlivePeriod sets the TCP keepalive period of the accepted
+// connections. Zero means the default of 30 seconds.
+func WithKeepAlivePeriod(d time.Duration) ListenerOption {
+	return func(lo *ListenerOptions) { lo.keepAlivePeriod = d }
+}
+
 // WithSocketOpts defines socket options that will be applied to the listener.
 func WithSocketOpts(s *SocketOpts) ListenerOption {
 	return func(lo *ListenerOptions) { lo.socketOpts = s }
@@ -0,0 +1,158 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// DefaultAsyncMaxInFlight is the default number of operations an AsyncKV has
+// in flight.
+const DefaultAsyncMaxInFlight = 128
+
+// ErrAsyncKVClosed is the error of the operations submitted to a closed
+// AsyncKV.
+var ErrAsyncKVClosed = errors.New("etcdclient: async kv closed")
+
+// AsyncOptions configures an AsyncKV.
+type AsyncOptions struct {
+	// MaxInFlight is the maximum number of submitted operations whose future
+	// is not completed yet. Submitting an operation blocks while the window
+	// is full.
+	MaxInFlight int
+	// Ordered, if set, completes the futures in the order the operations
+	// were submitted. The operations are still sent concurrently, so the
+	// server may apply them in any order; operations that must be applied in
+	// order belong in one transaction.
+	Ordered bool
+}
+
+// Future is the result of an operation submitted to an AsyncKV.
+type Future struct {
+	done chan struct{}
+	resp OpResponse
+	err  error
+}
+
+// Done returns a channel closed once the operation has completed.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Result waits for the operation to complete and returns its response.
+func (f *Future) Result() (OpResponse, error) {
+	<-f.done
+	return f.resp, f.err
+}
+
+// AsyncKV sends KV operations without waiting for their completion, keeping up
+// to MaxInFlight of them in flight, so a single goroutine can load many
+// independent keys. It is safe for concurrent use.
+type AsyncKV struct {
+	kv     KV
+	opts   AsyncOptions
+	window chan struct{}
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	last   *Future
+	closed bool
+}
+
+// NewAsyncKV returns an AsyncKV sending the operations with the given KV.
+func NewAsyncKV(kv KV, opts AsyncOptions) *AsyncKV {
+	if opts.MaxInFlight <= 0 {
+		opts.MaxInFlight = DefaultAsyncMaxInFlight
+	}
+	return &AsyncKV{kv: kv, opts: opts, window: make(chan struct{}, opts.MaxInFlight)}
+}
+
+// Put submits a put of the key-value pair.
+func (a *AsyncKV) Put(ctx context.Context, key, val string, opts ...OpOption) *Future {
+	return a.Do(ctx, OpPut(key, val, opts...))
+}
+
+// Delete submits a delete of the key, or of the keys selected by the options.
+func (a *AsyncKV) Delete(ctx context.Context, key string, opts ...OpOption) *Future {
+	return a.Do(ctx, OpDelete(key, opts...))
+}
+
+// Txn submits a transaction applying thenOps if all the comparisons succeed,
+// and elseOps otherwise.
+func (a *AsyncKV) Txn(ctx context.Context, cmps []Cmp, thenOps []Op, elseOps []Op) *Future {
+	return a.Do(ctx, OpTxn(cmps, thenOps, elseOps))
+}
+
+// Do submits the op, waiting for room in the window of operations in flight.
+// If ctx is done first, the returned future completes with its error.
+func (a *AsyncKV) Do(ctx context.Context, op Op) *Future {
+	f := &Future{done: make(chan struct{})}
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		f.err = ErrAsyncKVClosed
+		close(f.done)
+		return f
+	}
+	var prev *Future
+	if a.opts.Ordered {
+		prev, a.last = a.last, f
+	}
+	a.wg.Add(1)
+	a.mu.Unlock()
+
+	select {
+	case a.window <- struct{}{}:
+	case <-ctx.Done():
+		go a.complete(f, prev, false, OpResponse{}, ctx.Err())
+		return f
+	}
+	go func() {
+		resp, err := a.kv.Do(ctx, op)
+		a.complete(f, prev, true, resp, err)
+	}()
+	return f
+}
+
+// complete completes the future once prev, the future submitted before it
+// when ordered, has completed. Until then the future keeps its room in the
+// window, which bounds the completed operations waiting for their turn.
+func (a *AsyncKV) complete(f, prev *Future, sent bool, resp OpResponse, err error) {
+	if prev != nil {
+		<-prev.done
+	}
+	f.resp, f.err = resp, err
+	close(f.done)
+	if sent {
+		<-a.window
+	}
+	a.wg.Done()
+}
+
+// Wait waits for the futures of all the submitted operations to complete.
+func (a *AsyncKV) Wait() {
+	a.wg.Wait()
+}
+
+// Close rejects the operations submitted from now on with ErrAsyncKVClosed
+// and waits for the pending ones to complete.
+func (a *AsyncKV) Close() {
+	a.mu.Lock()
+	a.closed = true
+	a.mu.Unlock()
+	a.wg.Wait()
+}
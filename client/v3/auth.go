@@ -43,6 +43,7 @@ type (
 	AuthRoleDeleteResponse           pb.AuthRoleDeleteResponse
 	AuthUserListResponse             pb.AuthUserListResponse
 	AuthRoleListResponse             pb.AuthRoleListResponse
+	AuthCheckPermissionResponse      pb.AuthCheckPermissionResponse
 
 	PermissionType authpb.Permission_Type
 	Permission     authpb.Permission
@@ -110,6 +111,10 @@ type Auth interface {
 
 	// RoleDelete deletes a role.
 	RoleDelete(ctx context.Context, role string) (*AuthRoleDeleteResponse, error)
+
+	// CheckPermission checks whether the roles of a user permit an operation
+	// requiring the permission on a key or range.
+	CheckPermission(ctx context.Context, user string, key, rangeEnd string, permType PermissionType) (*AuthCheckPermissionResponse, error)
 }
 
 type authClient struct {
@@ -228,6 +233,12 @@ func (auth *authClient) RoleDelete(ctx context.Context, role string) (*AuthRoleD
 	return (*AuthRoleDeleteResponse)(resp), toErr(ctx, err)
 }
 
+func (auth *authClient) CheckPermission(ctx context.Context, user string, key, rangeEnd string, permType PermissionType) (*AuthCheckPermissionResponse, error) {
+	req := &pb.AuthCheckPermissionRequest{Name: user, PermType: authpb.Permission_Type(permType), Key: []byte(key), RangeEnd: []byte(rangeEnd)}
+	resp, err := auth.remote.CheckPermission(ctx, req, auth.callOpts...)
+	return (*AuthCheckPermissionResponse)(resp), toErr(ctx, err)
+}
+
 func StrToPermissionType(s string) (PermissionType, error) {
 	val, ok := authpb.Permission_Type_value[strings.ToUpper(s)]
 	if ok {
@@ -0,0 +1,221 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBatchMaxOps is the default number of operations per transaction
+	// of a BatchWriter, which is the default --max-txn-ops of the server.
+	DefaultBatchMaxOps = 128
+	// DefaultBatchMaxBytes is the default size in bytes of the keys and
+	// values per transaction of a BatchWriter, below the default
+	// --max-request-bytes of the server.
+	DefaultBatchMaxBytes = 1024 * 1024
+)
+
+// ErrBatchWriterClosed is returned by the operations on a closed BatchWriter.
+var ErrBatchWriterClosed = errors.New("etcdclient: batch writer closed")
+
+// BatchOptions configures a BatchWriter.
+type BatchOptions struct {
+	// MaxOps is the maximum number of operations per transaction. It must not
+	// exceed the --max-txn-ops of the server.
+	MaxOps int
+	// MaxBytes is the maximum size in bytes of the keys and values per
+	// transaction. A single operation larger than MaxBytes is sent alone.
+	MaxBytes int
+	// FlushInterval, if set, is the maximum time an operation waits for its
+	// transaction to fill up before it is sent.
+	FlushInterval time.Duration
+}
+
+// BatchWriter coalesces Put and Delete operations into transactions of up to
+// MaxOps operations and MaxBytes bytes. The operations are applied in order:
+// an operation on a key written by a pending operation is sent in the next
+// transaction. Responses, e.g. the previous key-values, are discarded.
+//
+// Like a bufio.Writer, once a transaction fails its error is returned by
+// every subsequent operation, and the operations of the failed and pending
+// transactions are dropped. It is safe for concurrent use.
+type BatchWriter struct {
+	kv   KV
+	opts BatchOptions
+
+	mu      sync.Mutex
+	ops     []Op
+	bytes   int
+	puts    map[string]struct{}
+	deletes []Op
+	timer   *time.Timer
+	err     error
+	closed  bool
+}
+
+// NewBatchWriter returns a BatchWriter writing with the given KV.
+func NewBatchWriter(kv KV, opts BatchOptions) *BatchWriter {
+	if opts.MaxOps <= 0 {
+		opts.MaxOps = DefaultBatchMaxOps
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultBatchMaxBytes
+	}
+	return &BatchWriter{kv: kv, opts: opts, puts: make(map[string]struct{})}
+}
+
+// Put adds a put of the key-value pair to the pending transaction, sending it
+// first if it is full or the key is written by one of its operations.
+func (w *BatchWriter) Put(ctx context.Context, key, val string, opts ...OpOption) error {
+	return w.add(ctx, OpPut(key, val, opts...))
+}
+
+// Delete adds a delete of the key, or of the keys selected by the options,
+// e.g. WithPrefix, to the pending transaction, sending it first if it is
+// full or one of its puts is deleted.
+func (w *BatchWriter) Delete(ctx context.Context, key string, opts ...OpOption) error {
+	return w.add(ctx, OpDelete(key, opts...))
+}
+
+// Flush sends the pending transaction.
+func (w *BatchWriter) Flush(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrBatchWriterClosed
+	}
+	return w.flush(ctx)
+}
+
+// Close sends the pending transaction and closes the writer.
+func (w *BatchWriter) Close(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrBatchWriterClosed
+	}
+	err := w.flush(ctx)
+	w.closed = true
+	return err
+}
+
+func (w *BatchWriter) add(ctx context.Context, op Op) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrBatchWriterClosed
+	}
+	if w.err != nil {
+		return w.err
+	}
+
+	size := len(op.key) + len(op.end) + len(op.val)
+	if len(w.ops) == w.opts.MaxOps || (len(w.ops) != 0 && w.bytes+size > w.opts.MaxBytes) || w.conflicts(op) {
+		if err := w.flush(ctx); err != nil {
+			return err
+		}
+	}
+
+	w.ops = append(w.ops, op)
+	w.bytes += size
+	if op.IsPut() {
+		w.puts[string(op.key)] = struct{}{}
+	} else {
+		w.deletes = append(w.deletes, op)
+	}
+
+	switch {
+	case len(w.ops) == w.opts.MaxOps || w.bytes >= w.opts.MaxBytes:
+		return w.flush(ctx)
+	case len(w.ops) == 1 && w.opts.FlushInterval > 0:
+		w.timer = time.AfterFunc(w.opts.FlushInterval, w.flushPending)
+	}
+	return nil
+}
+
+// conflicts returns whether the transaction would be rejected, or its
+// outcome would differ from applying the operations in order, if the op was
+// added to it. Puts must not overlap other puts or deletes.
+func (w *BatchWriter) conflicts(op Op) bool {
+	if op.IsPut() {
+		if _, ok := w.puts[string(op.key)]; ok {
+			return true
+		}
+		for _, d := range w.deletes {
+			if deletes(d, op.key) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(op.end) == 0 {
+		_, ok := w.puts[string(op.key)]
+		return ok
+	}
+	for key := range w.puts {
+		if deletes(op, []byte(key)) {
+			return true
+		}
+	}
+	return false
+}
+
+// deletes returns whether the delete op deletes the key.
+func deletes(op Op, key []byte) bool {
+	switch {
+	case len(op.end) == 0:
+		return bytes.Equal(op.key, key)
+	case len(op.end) == 1 && op.end[0] == 0:
+		return bytes.Compare(key, op.key) >= 0
+	default:
+		return bytes.Compare(key, op.key) >= 0 && bytes.Compare(key, op.end) < 0
+	}
+}
+
+// flushPending sends the pending transaction once the flush interval has
+// elapsed.
+func (w *BatchWriter) flushPending() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.closed {
+		w.flush(context.Background())
+	}
+}
+
+func (w *BatchWriter) flush(ctx context.Context) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if len(w.ops) == 0 {
+		return nil
+	}
+	ops := w.ops
+	w.ops, w.bytes, w.deletes = nil, 0, nil
+	clear(w.puts)
+	if _, err := w.kv.Txn(ctx).Then(ops...).Commit(); err != nil {
+		w.err = err
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package chunking is a clientv3 wrapper that stores large values as blob
+// references, so occasional large payloads don't reach the cluster as single
+// requests near the maximum request size.
+//
+// First, wrap the KV of a client:
+//
+//	cli.KV = chunking.NewKV(cli.KV, chunking.Config{})
+//
+// A put of a value larger than Config.ChunkSize first writes the value in
+// chunks of at most Config.ChunkSize bytes, one request each, under
+// Config.ChunkPrefix. It then writes to the key a small manifest referencing
+// the chunks. The chunks are attached to the lease of the key, if any:
+//
+//	cli.Put(context.TODO(), "abc", largeValue)
+//
+// Reads of the key reassemble the value from the chunks, at the revision of
+// the read, so they return the value as written:
+//
+//	resp, _ := cli.Get(context.TODO(), "abc")
+//	// resp.Kvs[0].Value == largeValue
+//
+// Overwriting or deleting the key through the wrapper deletes the chunks of
+// its previous value.
+//
+// The manifest is stored as is by the cluster, so clients which don't wrap
+// their KV read the manifest instead of the value. Transactions, watches and
+// compare operations also see the manifest: values written in a transaction
+// are not chunked. The chunk records are hidden from the ranges read through
+// the wrapper but are included in their count.
+//
+// A put which fails after writing chunks may leave them behind. Attaching
+// large values to leases bounds the lifetime of such chunks.
+package chunking
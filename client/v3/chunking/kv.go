@@ -0,0 +1,362 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunking
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const (
+	// DefaultChunkSize is the default size above which values are chunked,
+	// and the size of their chunks.
+	DefaultChunkSize = 512 * 1024
+	// DefaultMaxValueSize is the default maximum size of a value.
+	DefaultMaxValueSize = 64 * 1024 * 1024
+	// DefaultChunkPrefix is the default prefix of the chunk records.
+	DefaultChunkPrefix = "\x00chunks/"
+
+	// manifestMagic starts the manifests written in place of chunked values.
+	manifestMagic = "\x00etcd-chunked\x00"
+	// readPageBytes bounds the size of the chunks read by each request.
+	readPageBytes = 4 * 1024 * 1024
+)
+
+var (
+	ErrValueTooLarge = errors.New("chunking: value exceeds the maximum value size")
+	ErrMissingChunks = errors.New("chunking: chunks of the value are missing")
+)
+
+// Config configures the chunking of values.
+type Config struct {
+	// ChunkSize is the size above which values are written in chunks, and
+	// the maximum size of the chunks. Defaults to DefaultChunkSize.
+	ChunkSize int
+	// MaxValueSize is the maximum size of a value put through the wrapper.
+	// Defaults to DefaultMaxValueSize.
+	MaxValueSize int
+	// ChunkPrefix is the prefix of the chunk records. It should be outside
+	// of the ranges read by the clients. Defaults to DefaultChunkPrefix.
+	ChunkPrefix string
+}
+
+// manifest references the chunks of a value.
+type manifest struct {
+	ID     string `json:"id"`
+	Size   int    `json:"size"`
+	Chunks int    `json:"chunks"`
+}
+
+func (m *manifest) encode() []byte {
+	b, _ := json.Marshal(m)
+	return append([]byte(manifestMagic), b...)
+}
+
+func decodeManifest(v []byte) (*manifest, bool) {
+	if !bytes.HasPrefix(v, []byte(manifestMagic)) {
+		return nil, false
+	}
+	var m manifest
+	if err := json.Unmarshal(v[len(manifestMagic):], &m); err != nil || m.ID == "" {
+		return nil, false
+	}
+	return &m, true
+}
+
+type chunkingKV struct {
+	clientv3.KV
+	cfg Config
+}
+
+// NewKV wraps a KV instance so that values larger than the chunk size are
+// stored in chunks, referenced by a manifest stored in the key.
+func NewKV(kv clientv3.KV, cfg Config) clientv3.KV {
+	if cfg.ChunkSize <= 0 {
+		cfg.ChunkSize = DefaultChunkSize
+	}
+	if cfg.MaxValueSize <= 0 {
+		cfg.MaxValueSize = DefaultMaxValueSize
+	}
+	if cfg.ChunkPrefix == "" {
+		cfg.ChunkPrefix = DefaultChunkPrefix
+	}
+	return &chunkingKV{KV: kv, cfg: cfg}
+}
+
+func (kv *chunkingKV) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	r, err := kv.Do(ctx, clientv3.OpPut(key, val, opts...))
+	if err != nil {
+		return nil, err
+	}
+	return r.Put(), nil
+}
+
+func (kv *chunkingKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	r, err := kv.Do(ctx, clientv3.OpGet(key, opts...))
+	if err != nil {
+		return nil, err
+	}
+	return r.Get(), nil
+}
+
+func (kv *chunkingKV) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	r, err := kv.Do(ctx, clientv3.OpDelete(key, opts...))
+	if err != nil {
+		return nil, err
+	}
+	return r.Del(), nil
+}
+
+func (kv *chunkingKV) Do(ctx context.Context, op clientv3.Op) (clientv3.OpResponse, error) {
+	switch {
+	case op.IsPut():
+		resp, err := kv.put(ctx, op)
+		if err != nil {
+			return clientv3.OpResponse{}, err
+		}
+		return resp.OpResponse(), nil
+	case op.IsGet():
+		resp, err := kv.get(ctx, op)
+		if err != nil {
+			return clientv3.OpResponse{}, err
+		}
+		return resp.OpResponse(), nil
+	case op.IsDelete():
+		resp, err := kv.del(ctx, op)
+		if err != nil {
+			return clientv3.OpResponse{}, err
+		}
+		return resp.OpResponse(), nil
+	}
+	return kv.KV.Do(ctx, op)
+}
+
+func (kv *chunkingKV) put(ctx context.Context, op clientv3.Op) (*clientv3.PutResponse, error) {
+	if op.IsIgnoreValue() {
+		// the key keeps its value, and its chunks if any
+		r, err := kv.KV.Do(ctx, op)
+		if err != nil {
+			return nil, err
+		}
+		return r.Put(), nil
+	}
+	val := op.ValueBytes()
+	if len(val) > kv.cfg.MaxValueSize {
+		return nil, ErrValueTooLarge
+	}
+	var m *manifest
+	if len(val) > kv.cfg.ChunkSize {
+		var err error
+		if m, err = kv.writeChunks(ctx, op); err != nil {
+			return nil, err
+		}
+		op.WithValueBytes(m.encode())
+	}
+
+	prevKV := op.IsPrevKV()
+	clientv3.WithPrevKV()(&op)
+	r, err := kv.KV.Do(ctx, op)
+	if err != nil {
+		if m != nil {
+			// the put may have been applied, so its chunks can't be
+			// deleted safely
+			orphanedValues.Inc()
+		}
+		return nil, err
+	}
+	put := r.Put()
+	if m != nil {
+		valuesChunked.Inc()
+		chunkedValueBytes.Observe(float64(m.Size))
+	}
+	if put.PrevKv != nil {
+		if pm, ok := decodeManifest(put.PrevKv.Value); ok {
+			if prevKV {
+				if put.PrevKv, err = kv.reassemble(ctx, put.PrevKv, pm); err != nil {
+					return nil, err
+				}
+			}
+			kv.deleteChunks(ctx, pm)
+		}
+	}
+	if !prevKV {
+		put.PrevKv = nil
+	}
+	return put, nil
+}
+
+// writeChunks writes the value of a put in chunks, attached to the lease of
+// the key, and returns their manifest.
+func (kv *chunkingKV) writeChunks(ctx context.Context, op clientv3.Op) (*manifest, error) {
+	lease := op.LeaseID()
+	if op.IsIgnoreLease() {
+		resp, err := kv.KV.Get(ctx, string(op.KeyBytes()), clientv3.WithKeysOnly())
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Kvs) == 0 {
+			return nil, fmt.Errorf("chunking: ignore lease on a missing key %q", op.KeyBytes())
+		}
+		lease = clientv3.LeaseID(resp.Kvs[0].Lease)
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	val := op.ValueBytes()
+	m := &manifest{ID: hex.EncodeToString(id), Size: len(val)}
+	for off := 0; off < len(val); off += kv.cfg.ChunkSize {
+		chunk := val[off:min(off+kv.cfg.ChunkSize, len(val))]
+		if _, err := kv.KV.Put(ctx, kv.chunkKey(m.ID, m.Chunks), string(chunk), clientv3.WithLease(lease)); err != nil {
+			if m.Chunks > 0 {
+				kv.deleteChunks(ctx, m)
+			}
+			return nil, err
+		}
+		chunksWritten.Inc()
+		m.Chunks++
+	}
+	return m, nil
+}
+
+func (kv *chunkingKV) get(ctx context.Context, op clientv3.Op) (*clientv3.GetResponse, error) {
+	r, err := kv.KV.Do(ctx, op)
+	if err != nil {
+		return nil, err
+	}
+	get := r.Get()
+	if op.IsCountOnly() {
+		return get, nil
+	}
+
+	rev := op.Rev()
+	if rev <= 0 {
+		rev = get.Header.Revision
+	}
+	kvs := get.Kvs[:0]
+	for _, ev := range get.Kvs {
+		if strings.HasPrefix(string(ev.Key), kv.cfg.ChunkPrefix) {
+			continue
+		}
+		if !op.IsKeysOnly() {
+			if m, ok := decodeManifest(ev.Value); ok {
+				// read the chunks at the revision of the read, so they
+				// match the manifest even if the key was overwritten
+				opts := []clientv3.OpOption{clientv3.WithRev(rev)}
+				if op.IsSerializable() {
+					opts = append(opts, clientv3.WithSerializable())
+				}
+				if ev, err = kv.reassemble(ctx, ev, m, opts...); err != nil {
+					return nil, err
+				}
+			}
+		}
+		kvs = append(kvs, ev)
+	}
+	get.Kvs = kvs
+	return get, nil
+}
+
+func (kv *chunkingKV) del(ctx context.Context, op clientv3.Op) (*clientv3.DeleteResponse, error) {
+	prevKV := op.IsPrevKV()
+	clientv3.WithPrevKV()(&op)
+	r, err := kv.KV.Do(ctx, op)
+	if err != nil {
+		return nil, err
+	}
+	del := r.Del()
+	for i, ev := range del.PrevKvs {
+		m, ok := decodeManifest(ev.Value)
+		if !ok {
+			continue
+		}
+		if prevKV {
+			if del.PrevKvs[i], err = kv.reassemble(ctx, ev, m); err != nil {
+				return nil, err
+			}
+		}
+		kv.deleteChunks(ctx, m)
+	}
+	if !prevKV {
+		del.PrevKvs = nil
+	}
+	return del, nil
+}
+
+// reassemble returns a copy of the key-value pair with the value read from
+// the chunks referenced by its manifest.
+func (kv *chunkingKV) reassemble(ctx context.Context, ev *mvccpb.KeyValue, m *manifest, opts ...clientv3.OpOption) (*mvccpb.KeyValue, error) {
+	val, err := kv.readChunks(ctx, m, opts...)
+	if err != nil {
+		return nil, err
+	}
+	valuesReassembled.Inc()
+	cp := *ev
+	cp.Value = val
+	return &cp, nil
+}
+
+func (kv *chunkingKV) readChunks(ctx context.Context, m *manifest, opts ...clientv3.OpOption) ([]byte, error) {
+	pfx := kv.chunkKey(m.ID, -1)
+	end := clientv3.GetPrefixRangeEnd(pfx)
+	limit := int64(max(readPageBytes/kv.cfg.ChunkSize, 1))
+	opts = append(opts, clientv3.WithRange(end), clientv3.WithLimit(limit))
+
+	val := make([]byte, 0, m.Size)
+	chunks := 0
+	for key := pfx; ; {
+		resp, err := kv.KV.Get(ctx, key, opts...)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range resp.Kvs {
+			val = append(val, c.Value...)
+		}
+		chunks += len(resp.Kvs)
+		if !resp.More || len(resp.Kvs) == 0 {
+			break
+		}
+		key = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+	}
+	if chunks != m.Chunks || len(val) != m.Size {
+		return nil, ErrMissingChunks
+	}
+	return val, nil
+}
+
+func (kv *chunkingKV) deleteChunks(ctx context.Context, m *manifest) {
+	if _, err := kv.KV.Delete(ctx, kv.chunkKey(m.ID, -1), clientv3.WithPrefix()); err != nil {
+		orphanedValues.Inc()
+	}
+}
+
+// chunkKey returns the key of the i-th chunk of a value, or the prefix of
+// its chunks if i is negative.
+func (kv *chunkingKV) chunkKey(id string, i int) string {
+	if i < 0 {
+		return kv.cfg.ChunkPrefix + id + "/"
+	}
+	return fmt.Sprintf("%s%s/%08d", kv.cfg.ChunkPrefix, id, i)
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunking
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	valuesChunked = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "chunking",
+		Name:      "values_chunked_total",
+		Help:      "Total number of values written in chunks.",
+	})
+	chunksWritten = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "chunking",
+		Name:      "chunks_written_total",
+		Help:      "Total number of chunks written.",
+	})
+	valuesReassembled = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "chunking",
+		Name:      "values_reassembled_total",
+		Help:      "Total number of values read from chunks.",
+	})
+	chunkedValueBytes = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "etcd",
+		Subsystem: "chunking",
+		Name:      "chunked_value_size_bytes",
+		Help:      "The size distributions of the values written in chunks.",
+
+		// lowest bucket start of upper bound 64 KiB with factor 2
+		// highest bucket start of 64 KiB * 2^11 == 128 MiB
+		Buckets: prometheus.ExponentialBuckets(64*1024, 2, 12),
+	})
+	orphanedValues = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "chunking",
+		Name:      "orphaned_values_total",
+		Help:      "Total number of values whose chunks may have been left behind by a failed put or cleanup.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(valuesChunked)
+	prometheus.MustRegister(chunksWritten)
+	prometheus.MustRegister(valuesReassembled)
+	prometheus.MustRegister(chunkedValueBytes)
+	prometheus.MustRegister(orphanedValues)
+}
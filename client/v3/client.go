@@ -23,7 +23,9 @@ import (
 	"time"
 
 	"github.com/coreos/go-semver/semver"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	grpccredentials "google.golang.org/grpc/credentials"
@@ -31,11 +33,13 @@ import (
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/status"
 
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/api/v3/version"
 	"go.etcd.io/etcd/client/pkg/v3/logutil"
 	"go.etcd.io/etcd/client/pkg/v3/verify"
 	"go.etcd.io/etcd/client/v3/credentials"
+	"go.etcd.io/etcd/client/v3/internal/balancer"
 	"go.etcd.io/etcd/client/v3/internal/endpoint"
 	"go.etcd.io/etcd/client/v3/internal/resolver"
 )
@@ -74,6 +78,15 @@ type Client struct {
 
 	callOpts []grpc.CallOption
 
+	// memberAddrs caches member client addresses for endpoint affinity,
+	// including nil addresses for the unknown members. memberGen counts the
+	// endpoint updates, which drop the cache.
+	memberMu    *sync.Mutex
+	memberAddrs map[uint64][]string
+	memberGen   uint64
+	// memberFetch fetches the member list once for concurrent lookups.
+	memberFetch *singleflight.Group
+
 	lgMu *sync.RWMutex
 	lg   *zap.Logger
 }
@@ -92,7 +105,7 @@ func New(cfg Config) (*Client, error) {
 // service interface implementations and do not need connection management.
 func NewCtxClient(ctx context.Context, opts ...Option) *Client {
 	cctx, cancel := context.WithCancel(ctx)
-	c := &Client{ctx: cctx, cancel: cancel, lgMu: new(sync.RWMutex), epMu: new(sync.RWMutex)}
+	c := &Client{ctx: cctx, cancel: cancel, lgMu: new(sync.RWMutex), epMu: new(sync.RWMutex), memberMu: new(sync.Mutex), memberFetch: new(singleflight.Group)}
 	for _, opt := range opts {
 		opt(c)
 	}
@@ -164,6 +177,13 @@ func (c *Client) Close() error {
 // canceled on client Close().
 func (c *Client) Ctx() context.Context { return c.ctx }
 
+// ConnectionMetrics returns the collector of the requests started and in
+// flight on each connection of the clients to their endpoints. The metrics
+// are not exported unless it is registered, e.g.
+//
+//	prometheus.MustRegister(clientv3.ConnectionMetrics())
+func ConnectionMetrics() prometheus.Collector { return balancer.Metrics }
+
 // Endpoints lists the registered endpoints for the client.
 func (c *Client) Endpoints() []string {
 	// copy the slice; protect original endpoints from being changed
@@ -181,6 +201,12 @@ func (c *Client) SetEndpoints(eps ...string) {
 	c.endpoints = eps
 
 	c.resolver.SetEndpoints(eps)
+
+	// the members may have changed along with the endpoints
+	c.memberMu.Lock()
+	c.memberAddrs = nil
+	c.memberGen++
+	c.memberMu.Unlock()
 }
 
 // Sync synchronizes client's endpoints with the known endpoints from the etcd membership.
@@ -191,7 +217,7 @@ func (c *Client) Sync(ctx context.Context) error {
 	}
 	var eps []string
 	for _, m := range mresp.Members {
-		if len(m.Name) != 0 && !m.IsLearner {
+		if len(m.Name) != 0 && !m.IsLearner && !m.IsWitness {
 			eps = append(eps, m.ClientURLs...)
 		}
 	}
@@ -203,6 +229,9 @@ func (c *Client) Sync(ctx context.Context) error {
 		}
 	})
 	c.SetEndpoints(eps...)
+	c.memberMu.Lock()
+	c.setMemberAddrs(mresp.Members)
+	c.memberMu.Unlock()
 	c.lg.Debug("set etcd endpoints by autoSync", zap.Strings("endpoints", eps))
 	return nil
 }
@@ -288,11 +317,23 @@ func (c *Client) Dial(ep string) (*grpc.ClientConn, error) {
 func (c *Client) getToken(ctx context.Context) error {
 	var err error // return last error in a case of fail
 
-	if c.Username == "" || c.Password == "" {
+	username, password := c.Username, c.Password
+	if c.cfg.CredentialsProvider != nil {
+		creds, err := c.cfg.CredentialsProvider(ctx)
+		if err != nil {
+			return fmt.Errorf("etcdclient: failed to get credentials: %w", err)
+		}
+		if creds.Token != "" {
+			c.authTokenBundle.UpdateAuthToken(creds.Token)
+			return nil
+		}
+		username, password = creds.Username, creds.Password
+	}
+	if username == "" || password == "" {
 		return nil
 	}
 
-	resp, err := c.Auth.Authenticate(ctx, c.Username, c.Password)
+	resp, err := c.Auth.Authenticate(ctx, username, password)
 	if err != nil {
 		if err == rpctypes.ErrAuthNotEnabled {
 			c.authTokenBundle.UpdateAuthToken("")
@@ -384,14 +425,16 @@ func newClient(cfg *Config) (*Client, error) {
 
 	ctx, cancel := context.WithCancel(baseCtx)
 	client := &Client{
-		conn:     nil,
-		cfg:      *cfg,
-		creds:    creds,
-		ctx:      ctx,
-		cancel:   cancel,
-		epMu:     new(sync.RWMutex),
-		callOpts: defaultCallOpts,
-		lgMu:     new(sync.RWMutex),
+		conn:        nil,
+		cfg:         *cfg,
+		creds:       creds,
+		ctx:         ctx,
+		cancel:      cancel,
+		epMu:        new(sync.RWMutex),
+		callOpts:    defaultCallOpts,
+		memberMu:    new(sync.Mutex),
+		memberFetch: new(singleflight.Group),
+		lgMu:        new(sync.RWMutex),
 	}
 
 	var err error
@@ -414,6 +457,9 @@ func newClient(cfg *Config) (*Client, error) {
 		client.Password = cfg.Password
 		client.authTokenBundle = credentials.NewPerRPCCredentialBundle()
 	}
+	if cfg.CredentialsProvider != nil {
+		client.authTokenBundle = credentials.NewPerRPCCredentialBundle()
+	}
 	if cfg.MaxCallSendMsgSize > 0 || cfg.MaxCallRecvMsgSize > 0 {
 		if cfg.MaxCallRecvMsgSize > 0 && cfg.MaxCallSendMsgSize > cfg.MaxCallRecvMsgSize {
 			return nil, fmt.Errorf("gRPC message recv limit (%d bytes) must be greater than send limit (%d bytes)", cfg.MaxCallRecvMsgSize, cfg.MaxCallSendMsgSize)
@@ -433,6 +479,7 @@ func newClient(cfg *Config) (*Client, error) {
 	}
 
 	client.resolver = resolver.New(cfg.Endpoints...)
+	client.resolver.SetSubConnsPerEndpoint(cfg.SubConnsPerEndpoint)
 
 	if len(cfg.Endpoints) < 1 {
 		client.cancel()
@@ -499,6 +546,75 @@ func (c *Client) roundRobinQuorumBackoff(waitBetween time.Duration, jitterFracti
 	}
 }
 
+// withEndpointAffinity translates the member ID requested with
+// WithEndpointAffinity into the addresses the balancer should prefer.
+func (c *Client) withEndpointAffinity(ctx context.Context) context.Context {
+	id, ok := endpointAffinityFromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	// the member lookup must not be subject to affinity itself
+	addrs := c.getMemberAddrs(context.WithValue(ctx, endpointAffinityKey{}, nil), id)
+	if len(addrs) == 0 {
+		return ctx
+	}
+	return balancer.WithAffinity(ctx, addrs)
+}
+
+// getMemberAddrs returns the addresses of the client URLs of the given member.
+// The member list is fetched again if the member is not cached; unknown
+// members are cached too, and the cache is dropped whenever the endpoints are
+// updated.
+func (c *Client) getMemberAddrs(ctx context.Context, id uint64) []string {
+	c.memberMu.Lock()
+	addrs, ok := c.memberAddrs[id]
+	gen := c.memberGen
+	c.memberMu.Unlock()
+	if ok || c.Cluster == nil {
+		return addrs
+	}
+	// fetch outside of the lock, so that the cached members are still served
+	// meanwhile
+	_, err, _ := c.memberFetch.Do("", func() (any, error) {
+		resp, err := c.MemberList(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.memberMu.Lock()
+		defer c.memberMu.Unlock()
+		// drop the members fetched before the endpoints were updated
+		if c.memberGen == gen {
+			c.setMemberAddrs(resp.Members)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		c.GetLogger().Warn("failed to fetch member list for endpoint affinity", zap.Error(err))
+		return nil
+	}
+	c.memberMu.Lock()
+	defer c.memberMu.Unlock()
+	addrs, ok = c.memberAddrs[id]
+	if !ok && c.memberAddrs != nil && c.memberGen == gen {
+		c.memberAddrs[id] = nil
+	}
+	return addrs
+}
+
+// setMemberAddrs caches the addresses of the client URLs of the members. It
+// must be called with memberMu held.
+func (c *Client) setMemberAddrs(members []*pb.Member) {
+	c.memberAddrs = make(map[uint64][]string, len(members))
+	for _, m := range members {
+		addrs := make([]string, 0, len(m.ClientURLs))
+		for _, u := range m.ClientURLs {
+			addr, _ := endpoint.Interpret(u)
+			addrs = append(addrs, addr)
+		}
+		c.memberAddrs[m.ID] = addrs
+	}
+}
+
 // minSupportedVersion returns the minimum version supported, which is the previous minor release.
 func minSupportedVersion() *semver.Version {
 	ver := semver.Must(semver.NewVersion(version.Version))
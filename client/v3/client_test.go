@@ -21,6 +21,7 @@ import (
 	"io"
 	"net"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -355,6 +356,51 @@ func TestSyncFiltersMembers(t *testing.T) {
 	}
 }
 
+func TestGetMemberAddrs(t *testing.T) {
+	c, _ := NewClient(t, Config{Endpoints: []string{"http://254.0.0.1:12345"}})
+	defer c.Close()
+	mc := &blockingCluster{mockCluster: mockCluster{
+		[]*etcdserverpb.Member{{ID: 1, Name: "m1", ClientURLs: []string{"http://254.0.0.2:12345"}}},
+	}}
+	c.Cluster = mc
+	ctx := context.Background()
+
+	if addrs := c.getMemberAddrs(ctx, 1); len(addrs) != 1 || addrs[0] != "254.0.0.2:12345" {
+		t.Fatalf("expected the addresses of member 1, got %v", addrs)
+	}
+	// the unknown members are cached too
+	for i := 0; i < 3; i++ {
+		if addrs := c.getMemberAddrs(ctx, 2); len(addrs) != 0 {
+			t.Fatalf("expected no addresses for the unknown member 2, got %v", addrs)
+		}
+	}
+	if n := mc.calls.Load(); n != 2 {
+		t.Fatalf("expected 2 member list calls, got %d", n)
+	}
+
+	// the cached members are served while the member list is fetched
+	mc.calledc, mc.releasec = make(chan struct{}), make(chan struct{})
+	donec := make(chan struct{})
+	go func() {
+		defer close(donec)
+		c.getMemberAddrs(ctx, 3)
+	}()
+	<-mc.calledc
+	if addrs := c.getMemberAddrs(ctx, 1); len(addrs) != 1 {
+		t.Fatalf("expected the cached addresses of member 1, got %v", addrs)
+	}
+	close(mc.releasec)
+	<-donec
+	mc.calledc, mc.releasec = nil, nil
+
+	// updating the endpoints drops the unknown members
+	c.SetEndpoints("http://254.0.0.1:12345")
+	c.getMemberAddrs(ctx, 2)
+	if n := mc.calls.Load(); n != 4 {
+		t.Fatalf("expected 4 member list calls, got %d", n)
+	}
+}
+
 func TestMinSupportedVersion(t *testing.T) {
 	testutil.BeforeTest(t)
 	var tests = []struct {
@@ -474,6 +520,10 @@ func (mm mockMaintenance) AlarmDisarm(ctx context.Context, m *AlarmMember) (*Ala
 	return nil, nil
 }
 
+func (mm mockMaintenance) SetReadOnly(ctx context.Context, readOnly bool) (*AlarmResponse, error) {
+	return nil, nil
+}
+
 func (mm mockMaintenance) Defragment(ctx context.Context, endpoint string) (*DefragmentResponse, error) {
 	return nil, nil
 }
@@ -482,6 +532,14 @@ func (mm mockMaintenance) HashKV(ctx context.Context, endpoint string, rev int64
 	return nil, nil
 }
 
+func (mm mockMaintenance) HashKVRange(ctx context.Context, endpoint string, key, end string, rev int64, stripPrefix bool) (*HashKVRangeResponse, error) {
+	return nil, nil
+}
+
+func (mm mockMaintenance) HotKeys(ctx context.Context, endpoint string, limit int64) (*HotKeysResponse, error) {
+	return nil, nil
+}
+
 func (mm mockMaintenance) SnapshotWithVersion(ctx context.Context) (*SnapshotResponse, error) {
 	return nil, nil
 }
@@ -498,6 +556,22 @@ func (mm mockMaintenance) Downgrade(ctx context.Context, action DowngradeAction,
 	return nil, nil
 }
 
+func (mm mockMaintenance) Drain(ctx context.Context, endpoint string) (*DrainResponse, error) {
+	return nil, nil
+}
+
+func (mm mockMaintenance) Undrain(ctx context.Context, endpoint string) (*DrainResponse, error) {
+	return nil, nil
+}
+
+func (mm mockMaintenance) RotateEncryptionKey(ctx context.Context, endpoint string) (*RotateEncryptionKeyResponse, error) {
+	return nil, nil
+}
+
+func (mm mockMaintenance) ClusterEvents(ctx context.Context, endpoint string, watch bool) (ClusterEventsStream, error) {
+	return nil, nil
+}
+
 type mockAuthServer struct {
 	*etcdserverpb.UnimplementedAuthServer
 }
@@ -514,6 +588,24 @@ func (mc *mockCluster) MemberList(ctx context.Context, opts ...OpOption) (*Membe
 	return &MemberListResponse{Members: mc.members}, nil
 }
 
+// blockingCluster counts the member list calls, and blocks them until
+// releasec is closed if it is set.
+type blockingCluster struct {
+	mockCluster
+	calls    atomic.Int32
+	calledc  chan struct{}
+	releasec chan struct{}
+}
+
+func (bc *blockingCluster) MemberList(ctx context.Context, opts ...OpOption) (*MemberListResponse, error) {
+	bc.calls.Add(1)
+	if bc.releasec != nil {
+		close(bc.calledc)
+		<-bc.releasec
+	}
+	return bc.mockCluster.MemberList(ctx, opts...)
+}
+
 func (mc *mockCluster) MemberAdd(ctx context.Context, peerAddrs []string) (*MemberAddResponse, error) {
 	return nil, nil
 }
@@ -530,6 +622,10 @@ func (mc *mockCluster) MemberUpdate(ctx context.Context, id uint64, peerAddrs []
 	return nil, nil
 }
 
+func (mc *mockCluster) MemberUpdateLabels(ctx context.Context, id uint64, labels map[string]string) (*MemberUpdateResponse, error) {
+	return nil, nil
+}
+
 func (mc *mockCluster) MemberPromote(ctx context.Context, id uint64) (*MemberPromoteResponse, error) {
 	return nil, nil
 }
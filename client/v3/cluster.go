@@ -16,6 +16,7 @@ package clientv3
 
 import (
 	"context"
+	"errors"
 
 	"google.golang.org/grpc"
 
@@ -48,6 +49,10 @@ type Cluster interface {
 	// MemberUpdate updates the peer addresses of the member.
 	MemberUpdate(ctx context.Context, id uint64, peerAddrs []string) (*MemberUpdateResponse, error)
 
+	// MemberUpdateLabels merges the labels into the labels of the member, such as
+	// its zone or region. A label with an empty value is removed.
+	MemberUpdateLabels(ctx context.Context, id uint64, labels map[string]string) (*MemberUpdateResponse, error)
+
 	// MemberPromote promotes a member from raft learner (non-voting) to raft voting member.
 	MemberPromote(ctx context.Context, id uint64) (*MemberPromoteResponse, error)
 }
@@ -122,6 +127,18 @@ func (c *cluster) MemberUpdate(ctx context.Context, id uint64, peerAddrs []strin
 	return nil, toErr(ctx, err)
 }
 
+func (c *cluster) MemberUpdateLabels(ctx context.Context, id uint64, labels map[string]string) (*MemberUpdateResponse, error) {
+	if len(labels) == 0 {
+		return nil, errors.New("etcdclient: no member labels given")
+	}
+	r := &pb.MemberUpdateRequest{ID: id, Labels: labels}
+	resp, err := c.remote.MemberUpdate(ctx, r, c.callOpts...)
+	if err == nil {
+		return (*MemberUpdateResponse)(resp), nil
+	}
+	return nil, toErr(ctx, err)
+}
+
 func (c *cluster) MemberList(ctx context.Context, opts ...OpOption) (*MemberListResponse, error) {
 	opt := OpGet("", opts...)
 	resp, err := c.remote.MemberList(ctx, &pb.MemberListRequest{Linearizable: !opt.serializable}, c.callOpts...)
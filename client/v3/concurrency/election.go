@@ -18,6 +18,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/mvccpb"
@@ -25,8 +26,9 @@ import (
 )
 
 var (
-	ErrElectionNotLeader = errors.New("election: not leader")
-	ErrElectionNoLeader  = errors.New("election: no leader")
+	ErrElectionNotLeader        = errors.New("election: not leader")
+	ErrElectionNoLeader         = errors.New("election: no leader")
+	ErrElectionInvalidSuccessor = errors.New("election: successor is not campaigning")
 )
 
 type Election struct {
@@ -90,7 +92,7 @@ func (e *Election) Campaign(ctx context.Context, val string) error {
 		}
 	}
 
-	err = waitDeletes(ctx, client, e.keyPrefix, e.leaderRev-1)
+	err = e.waitLeadership(ctx, resp.Header.Revision+1)
 	if err != nil {
 		// clean up in case of context cancel
 		select {
@@ -144,6 +146,114 @@ func (e *Election) Resign(ctx context.Context) (err error) {
 	return err
 }
 
+// Handoff transfers leadership to the campaigner holding the successor key,
+// which is typically the Key() of another Election on the same prefix that
+// is blocked in Campaign. The leader key keeps its creation revision and is
+// rebound to the successor's lease in a single transaction, so no other
+// campaigner can win the election in between. The successor's Campaign
+// returns once it observes the transfer.
+func (e *Election) Handoff(ctx context.Context, successor string) error {
+	if e.leaderSession == nil {
+		return ErrElectionNotLeader
+	}
+	if successor == e.leaderKey || !strings.HasPrefix(successor, e.keyPrefix) {
+		return ErrElectionInvalidSuccessor
+	}
+	client := e.session.Client()
+	gresp, err := client.Get(ctx, successor)
+	if err != nil {
+		return err
+	}
+	if len(gresp.Kvs) == 0 || gresp.Kvs[0].Lease == int64(v3.NoLease) {
+		return ErrElectionInvalidSuccessor
+	}
+	skv := gresp.Kvs[0]
+
+	cmps := []v3.Cmp{
+		v3.Compare(v3.CreateRevision(e.leaderKey), "=", e.leaderRev),
+		v3.Compare(v3.ModRevision(successor), "=", skv.ModRevision),
+	}
+	txn := client.Txn(ctx).If(cmps...)
+	txn = txn.Then(
+		v3.OpPut(e.leaderKey, string(skv.Value), v3.WithLease(v3.LeaseID(skv.Lease))),
+		v3.OpDelete(successor),
+	)
+	txn = txn.Else(v3.OpGet(e.leaderKey))
+	tresp, terr := txn.Commit()
+	if terr != nil {
+		return terr
+	}
+	if !tresp.Succeeded {
+		if kvs := tresp.Responses[0].GetResponseRange().Kvs; len(kvs) == 0 || kvs[0].CreateRevision != e.leaderRev {
+			e.leaderKey = ""
+			e.leaderSession = nil
+			return ErrElectionNotLeader
+		}
+		return ErrElectionInvalidSuccessor
+	}
+
+	e.hdr = tresp.Header
+	e.leaderKey = ""
+	e.leaderSession = nil
+	return nil
+}
+
+// waitLeadership waits until all campaign keys created before the election's
+// key are deleted, or until leadership is handed off to the election's session.
+// Events on the prefix are examined starting at the given revision.
+func (e *Election) waitLeadership(ctx context.Context, rev int64) error {
+	client := e.session.Client()
+	getOpts := append(v3.WithLastCreate(), v3.WithMaxCreateRev(e.leaderRev-1))
+	for {
+		resp, err := client.Get(ctx, e.keyPrefix, getOpts...)
+		if err != nil {
+			return err
+		}
+		if len(resp.Kvs) == 0 {
+			return nil
+		}
+		var handedOff bool
+		handedOff, rev, err = e.waitDeleteOrHandoff(ctx, string(resp.Kvs[0].Key), rev)
+		if err != nil || handedOff {
+			return err
+		}
+	}
+}
+
+// waitDeleteOrHandoff watches the election prefix from the given revision until
+// the key is deleted or a leader key is rebound to the election's lease. It
+// returns the revision from which to resume watching.
+func (e *Election) waitDeleteOrHandoff(ctx context.Context, key string, rev int64) (bool, int64, error) {
+	client := e.session.Client()
+	lease := int64(e.session.Lease())
+
+	cctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wr v3.WatchResponse
+	wch := client.Watch(cctx, e.keyPrefix, v3.WithPrefix(), v3.WithRev(rev))
+	for wr = range wch {
+		for _, ev := range wr.Events {
+			k := string(ev.Kv.Key)
+			switch {
+			case ev.Type == mvccpb.PUT && ev.Kv.Lease == lease && k != e.leaderKey &&
+				ev.Kv.CreateRevision < e.leaderRev:
+				e.leaderKey, e.leaderRev = k, ev.Kv.CreateRevision
+				return true, 0, nil
+			case ev.Type == mvccpb.DELETE && k == key:
+				return false, ev.Kv.ModRevision + 1, nil
+			}
+		}
+	}
+	if err := wr.Err(); err != nil {
+		return false, 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return false, 0, err
+	}
+	return false, 0, errors.New("lost watcher waiting for delete")
+}
+
 // Leader returns the leader value for the current election.
 func (e *Election) Leader(ctx context.Context) (*v3.GetResponse, error) {
 	client := e.session.Client()
@@ -244,6 +354,89 @@ func (e *Election) observe(ctx context.Context, ch chan<- v3.GetResponse) {
 	}
 }
 
+// ObserveFromRev returns a channel that replays every leadership change on the
+// election prefix starting at the given revision, followed by changes as they
+// happen. Each GetResponse carries the leader key-value and a header whose
+// revision is the revision of the change. A value is not posted while there
+// is no leader.
+//
+// The channel closes when the context is canceled, the revision has been
+// compacted, or the underlying watcher is otherwise disrupted.
+func (e *Election) ObserveFromRev(ctx context.Context, rev int64) <-chan v3.GetResponse {
+	retc := make(chan v3.GetResponse)
+	go e.observeFromRev(ctx, rev, retc)
+	return retc
+}
+
+func (e *Election) observeFromRev(ctx context.Context, rev int64, ch chan<- v3.GetResponse) {
+	client := e.session.Client()
+
+	defer close(ch)
+	resp, err := client.Get(ctx, e.keyPrefix, v3.WithPrefix(), v3.WithRev(rev))
+	if err != nil {
+		return
+	}
+	if rev <= 0 {
+		rev = resp.Header.Revision
+	}
+
+	candidates := make(map[string]*mvccpb.KeyValue, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		candidates[string(kv.Key)] = kv
+	}
+
+	var leader *mvccpb.KeyValue
+	// post sends the current leader if it changed since the last post.
+	post := func(hdr pb.ResponseHeader, rev int64) bool {
+		var cur *mvccpb.KeyValue
+		for _, kv := range candidates {
+			if cur == nil || kv.CreateRevision < cur.CreateRevision {
+				cur = kv
+			}
+		}
+		if cur == nil || (leader != nil && leader.CreateRevision == cur.CreateRevision &&
+			leader.ModRevision == cur.ModRevision) {
+			leader = cur
+			return true
+		}
+		leader = cur
+		hdr.Revision = rev
+		select {
+		case ch <- v3.GetResponse{Header: &hdr, Kvs: []*mvccpb.KeyValue{cur}, Count: 1}:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	if !post(*resp.Header, rev) {
+		return
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	wch := client.Watch(cctx, e.keyPrefix, v3.WithPrefix(), v3.WithRev(rev+1))
+	for wr := range wch {
+		if wr.Err() != nil {
+			return
+		}
+		for i, ev := range wr.Events {
+			if ev.Type == mvccpb.DELETE {
+				delete(candidates, string(ev.Kv.Key))
+			} else {
+				candidates[string(ev.Kv.Key)] = ev.Kv
+			}
+			// post once all events of a revision have been applied
+			modRev := ev.Kv.ModRevision
+			if i+1 < len(wr.Events) && wr.Events[i+1].Kv.ModRevision == modRev {
+				continue
+			}
+			if !post(wr.Header, modRev) {
+				return
+			}
+		}
+	}
+}
+
 // Key returns the leader key if elected, empty string otherwise.
 func (e *Election) Key() string { return e.leaderKey }
 
@@ -66,6 +66,15 @@ type Config struct {
 	// Password is a password for authentication.
 	Password string `json:"password"`
 
+	// CredentialsProvider, if set, supplies the credentials to authenticate
+	// with in place of Username and Password. It is called every time the
+	// client needs a token: initially, whenever a stream is opened and
+	// whenever the server rejects the token, e.g. because it expired or the
+	// roles of the user changed, so rotating passwords or tokens issued by
+	// an external identity provider are picked up without recreating the
+	// client.
+	CredentialsProvider CredentialsProvider `json:"-"`
+
 	// RejectOldCluster when set will refuse to create a client against an outdated cluster.
 	RejectOldCluster bool `json:"reject-old-cluster"`
 
@@ -99,20 +108,42 @@ type Config struct {
 	// BackoffJitterFraction is the jitter fraction to randomize backoff wait time.
 	BackoffJitterFraction float64 `json:"backoff-jitter-fraction"`
 
+	// SubConnsPerEndpoint is the number of gRPC connections opened to each
+	// endpoint. Requests and streams are spread over the connections round
+	// robin, so that bulk writers are not limited by the concurrent streams
+	// of a single HTTP/2 connection. 0 or 1 opens a single connection. The
+	// requests on each connection are exported once ConnectionMetrics is
+	// registered.
+	SubConnsPerEndpoint int `json:"sub-conns-per-endpoint"`
+
 	// TODO: support custom balancer picker
 }
 
+// Credentials are the credentials a client authenticates with.
+type Credentials struct {
+	// Username and Password are authenticated with to get a token.
+	Username string
+	Password string
+	// Token, if set, is used as the auth token as is, e.g. a JWT issued by
+	// an external identity provider, and Username and Password are ignored.
+	Token string
+}
+
+// CredentialsProvider returns the current credentials of a client.
+type CredentialsProvider func(ctx context.Context) (Credentials, error)
+
 // ConfigSpec is the configuration from users, which comes from command-line flags,
 // environment variables or config file. It is a fully declarative configuration,
 // and can be serialized & deserialized to/from JSON.
 type ConfigSpec struct {
-	Endpoints        []string      `json:"endpoints"`
-	RequestTimeout   time.Duration `json:"request-timeout"`
-	DialTimeout      time.Duration `json:"dial-timeout"`
-	KeepAliveTime    time.Duration `json:"keepalive-time"`
-	KeepAliveTimeout time.Duration `json:"keepalive-timeout"`
-	Secure           *SecureConfig `json:"secure"`
-	Auth             *AuthConfig   `json:"auth"`
+	Endpoints           []string      `json:"endpoints"`
+	RequestTimeout      time.Duration `json:"request-timeout"`
+	DialTimeout         time.Duration `json:"dial-timeout"`
+	KeepAliveTime       time.Duration `json:"keepalive-time"`
+	KeepAliveTimeout    time.Duration `json:"keepalive-timeout"`
+	SubConnsPerEndpoint int           `json:"sub-conns-per-endpoint"`
+	Secure              *SecureConfig `json:"secure"`
+	Auth                *AuthConfig   `json:"auth"`
 }
 
 type SecureConfig struct {
@@ -146,6 +177,7 @@ func NewClientConfig(confSpec *ConfigSpec, lg *zap.Logger) (*Config, error) {
 		DialTimeout:          confSpec.DialTimeout,
 		DialKeepAliveTime:    confSpec.KeepAliveTime,
 		DialKeepAliveTimeout: confSpec.KeepAliveTimeout,
+		SubConnsPerEndpoint:  confSpec.SubConnsPerEndpoint,
 		TLS:                  tlsCfg,
 	}
 
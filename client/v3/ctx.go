@@ -16,11 +16,13 @@ package clientv3
 
 import (
 	"context"
+	"time"
 
 	"google.golang.org/grpc/metadata"
 
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/api/v3/version"
+	"go.etcd.io/etcd/client/v3/internal/balancer"
 )
 
 // WithRequireLeader requires client requests to only succeed
@@ -49,3 +51,95 @@ func withVersion(ctx context.Context) context.Context {
 	copied.Set(rpctypes.MetadataClientAPIVersionKey, version.APIVersion)
 	return metadata.NewOutgoingContext(ctx, copied)
 }
+
+type requestTimeoutKey struct{}
+
+// WithRequestTimeout bounds each unary request issued with the returned
+// context, including its retries, by the given timeout. Unlike
+// context.WithTimeout the timer starts when the request is issued, so the
+// context may be reused across requests. Streams such as Watch are not
+// affected.
+func WithRequestTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, requestTimeoutKey{}, timeout)
+}
+
+func requestTimeoutFromContext(ctx context.Context) (time.Duration, bool) {
+	timeout, ok := ctx.Value(requestTimeoutKey{}).(time.Duration)
+	return timeout, ok && timeout > 0
+}
+
+// RequestPriority is the service level of a request.
+type RequestPriority int
+
+const (
+	PriorityNormal RequestPriority = iota
+	// PriorityHigh marks latency-critical requests.
+	PriorityHigh
+	// PriorityLow marks background requests, for example bulk jobs. Retries
+	// of low priority requests back off longer to leave room for other
+	// requests on a shared client.
+	PriorityLow
+)
+
+// WithPriority attaches a priority to client requests. High priority requests
+// are sent to the endpoint with the fewest requests in flight from the client,
+// and low priority requests back off longer between retries. The priority is
+// carried as request metadata, where the server can see it too.
+func WithPriority(ctx context.Context, p RequestPriority) context.Context {
+	var v string
+	switch p {
+	case PriorityHigh:
+		v = rpctypes.MetadataPriorityHigh
+	case PriorityLow:
+		v = rpctypes.MetadataPriorityLow
+	default:
+		return ctx
+	}
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok { // no outgoing metadata ctx key, create one
+		md = metadata.Pairs(rpctypes.MetadataPriorityKey, v)
+		return metadata.NewOutgoingContext(ctx, md)
+	}
+	copied := md.Copy() // avoid racey updates
+	// overwrite/add priority key/value
+	copied.Set(rpctypes.MetadataPriorityKey, v)
+	return metadata.NewOutgoingContext(ctx, copied)
+}
+
+func priorityFromContext(ctx context.Context) RequestPriority {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		return PriorityNormal
+	}
+	switch vs := md.Get(rpctypes.MetadataPriorityKey); {
+	case len(vs) == 0:
+		return PriorityNormal
+	case vs[0] == rpctypes.MetadataPriorityHigh:
+		return PriorityHigh
+	case vs[0] == rpctypes.MetadataPriorityLow:
+		return PriorityLow
+	}
+	return PriorityNormal
+}
+
+// withBalancerPriority makes the balancer honor the priority of the request.
+func withBalancerPriority(ctx context.Context) context.Context {
+	if priorityFromContext(ctx) == PriorityHigh {
+		return balancer.WithLeastLoaded(ctx)
+	}
+	return ctx
+}
+
+type endpointAffinityKey struct{}
+
+// WithEndpointAffinity makes client requests prefer the member with the
+// given ID, as long as one of its client URLs is among the client's
+// endpoints and is connected. Otherwise requests are load balanced as usual.
+func WithEndpointAffinity(ctx context.Context, memberID uint64) context.Context {
+	return context.WithValue(ctx, endpointAffinityKey{}, memberID)
+}
+
+func endpointAffinityFromContext(ctx context.Context) (uint64, bool) {
+	id, ok := ctx.Value(endpointAffinityKey{}).(uint64)
+	return id, ok
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"google.golang.org/grpc/metadata"
 
@@ -66,3 +67,48 @@ func TestMetadataWithClientAPIVersion(t *testing.T) {
 		t.Fatalf("unexpected metadata for %q %v", rpctypes.MetadataClientAPIVersionKey, ss)
 	}
 }
+
+func TestMetadataWithPriority(t *testing.T) {
+	ctx := WithPriority(WithRequireLeader(context.TODO()), PriorityLow)
+
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		t.Fatal("expected outgoing metadata ctx key")
+	}
+	if ss := md.Get(rpctypes.MetadataRequireLeaderKey); !reflect.DeepEqual(ss, []string{rpctypes.MetadataHasLeader}) {
+		t.Fatalf("unexpected metadata for %q %v", rpctypes.MetadataRequireLeaderKey, ss)
+	}
+	if ss := md.Get(rpctypes.MetadataPriorityKey); !reflect.DeepEqual(ss, []string{rpctypes.MetadataPriorityLow}) {
+		t.Fatalf("unexpected metadata for %q %v", rpctypes.MetadataPriorityKey, ss)
+	}
+	if p := priorityFromContext(ctx); p != PriorityLow {
+		t.Fatalf("expected priority %v, got %v", PriorityLow, p)
+	}
+
+	// overwrite the previous priority
+	ctx = WithPriority(ctx, PriorityHigh)
+	if p := priorityFromContext(ctx); p != PriorityHigh {
+		t.Fatalf("expected priority %v, got %v", PriorityHigh, p)
+	}
+	if p := priorityFromContext(context.TODO()); p != PriorityNormal {
+		t.Fatalf("expected priority %v, got %v", PriorityNormal, p)
+	}
+}
+
+func TestRequestOptionsFromContext(t *testing.T) {
+	ctx := context.TODO()
+	if _, ok := requestTimeoutFromContext(ctx); ok {
+		t.Fatal("expected no request timeout")
+	}
+	if _, ok := endpointAffinityFromContext(ctx); ok {
+		t.Fatal("expected no endpoint affinity")
+	}
+
+	ctx = WithEndpointAffinity(WithRequestTimeout(ctx, time.Second), 42)
+	if timeout, ok := requestTimeoutFromContext(ctx); !ok || timeout != time.Second {
+		t.Fatalf("expected request timeout %v, got %v", time.Second, timeout)
+	}
+	if id, ok := endpointAffinityFromContext(ctx); !ok || id != 42 {
+		t.Fatalf("expected endpoint affinity %d, got %d", 42, id)
+	}
+}
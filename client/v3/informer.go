@@ -0,0 +1,268 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	v3rpc "go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+// ErrInformerWatchClosed is returned by Informer.Run when its watch is closed
+// before its context is done, e.g. because the watcher has been closed.
+var ErrInformerWatchClosed = errors.New("etcdclient: informer watch closed")
+
+// InformerHandler is notified by an Informer of the changes to its cache.
+// The functions are called one at a time from the goroutine running the
+// Informer, after the cache has been updated. Any of them may be nil.
+type InformerHandler struct {
+	// OnAdd is called with a key added to the cache.
+	OnAdd func(kv *mvccpb.KeyValue)
+	// OnUpdate is called with the previous and the new value of a key
+	// updated in the cache.
+	OnUpdate func(prev, kv *mvccpb.KeyValue)
+	// OnDelete is called with the last value of a key deleted from the cache.
+	OnDelete func(kv *mvccpb.KeyValue)
+	// OnResync is called with the revision of the cache once the keys have
+	// been listed, initially and every time the watch could not be resumed,
+	// after the differences with the cache have been notified.
+	OnResync func(rev int64)
+}
+
+// Informer maintains a local cache of the keys under a prefix: the keys are
+// listed page by page, then watched from the revision they were listed at.
+// Whenever the watch cannot be resumed because the revisions it missed were
+// compacted, or were dropped by the server because the watch was too slow,
+// the keys are listed again and the differences with the cache notified, so
+// the handler sees every key reaching its latest value. The cache accessors
+// are safe for concurrent use.
+type Informer struct {
+	// PageSize is the number of keys fetched per Range request when listing
+	// the keys.
+	PageSize int64
+
+	kv      KV
+	w       Watcher
+	prefix  string
+	handler InformerHandler
+
+	syncedOnce sync.Once
+	synced     chan struct{}
+
+	mu    sync.RWMutex
+	cache map[string]*mvccpb.KeyValue
+	rev   int64
+}
+
+// NewInformer returns an Informer caching the keys under prefix, listed with
+// the given KV and watched with the given Watcher, e.g. both a Client.
+func NewInformer(kv KV, w Watcher, prefix string, handler InformerHandler) *Informer {
+	return &Informer{
+		PageSize: DefaultListPageSize,
+		kv:       kv,
+		w:        w,
+		prefix:   prefix,
+		handler:  handler,
+		synced:   make(chan struct{}),
+		cache:    make(map[string]*mvccpb.KeyValue),
+	}
+}
+
+// Run lists and watches the keys until ctx is done or the keys can no longer
+// be listed or watched, and returns the error.
+func (inf *Informer) Run(ctx context.Context) error {
+	if err := inf.relist(ctx, 0); err != nil {
+		return err
+	}
+	for {
+		resync, err := inf.watch(ctx)
+		if err != nil {
+			return err
+		}
+		if resync != 0 {
+			if err = inf.relist(ctx, resync); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Synced returns a channel closed once the keys have been listed the first
+// time.
+func (inf *Informer) Synced() <-chan struct{} {
+	return inf.synced
+}
+
+// Get returns the cached value of the key.
+func (inf *Informer) Get(key string) (*mvccpb.KeyValue, bool) {
+	inf.mu.RLock()
+	defer inf.mu.RUnlock()
+	kv, ok := inf.cache[key]
+	return kv, ok
+}
+
+// List returns the cached key-value pairs, sorted by key.
+func (inf *Informer) List() []*mvccpb.KeyValue {
+	inf.mu.RLock()
+	kvs := make([]*mvccpb.KeyValue, 0, len(inf.cache))
+	for _, kv := range inf.cache {
+		kvs = append(kvs, kv)
+	}
+	inf.mu.RUnlock()
+	sort.Slice(kvs, func(i, j int) bool { return string(kvs[i].Key) < string(kvs[j].Key) })
+	return kvs
+}
+
+// Len returns the number of cached keys.
+func (inf *Informer) Len() int {
+	inf.mu.RLock()
+	defer inf.mu.RUnlock()
+	return len(inf.cache)
+}
+
+// Revision returns the revision the cache is up to date with.
+func (inf *Informer) Revision() int64 {
+	inf.mu.RLock()
+	defer inf.mu.RUnlock()
+	return inf.rev
+}
+
+// relist lists the keys at rev, or at the current revision if rev is 0 or
+// has been compacted, replaces the cache with them and notifies the
+// differences.
+func (inf *Informer) relist(ctx context.Context, rev int64) error {
+	p := NewListPager(inf.kv)
+	p.PageSize = inf.PageSize
+	var opts []OpOption
+	if rev != 0 {
+		opts = append(opts, WithRev(rev))
+	}
+	opts = append(opts, WithPrefix())
+	kvs, rev, err := p.GetAll(ctx, inf.prefix, opts...)
+	if errors.Is(err, v3rpc.ErrCompacted) {
+		kvs, rev, err = p.GetAll(ctx, inf.prefix, WithPrefix())
+	}
+	if err != nil {
+		return err
+	}
+
+	cache := make(map[string]*mvccpb.KeyValue, len(kvs))
+	for _, kv := range kvs {
+		cache[string(kv.Key)] = kv
+	}
+	inf.mu.Lock()
+	prev := inf.cache
+	inf.cache, inf.rev = cache, rev
+	inf.mu.Unlock()
+
+	for _, kv := range kvs {
+		old, ok := prev[string(kv.Key)]
+		switch {
+		case !ok:
+			inf.onAdd(kv)
+		case old.ModRevision != kv.ModRevision:
+			inf.onUpdate(old, kv)
+		}
+	}
+	for key, old := range prev {
+		if _, ok := cache[key]; !ok {
+			inf.onDelete(old)
+		}
+	}
+	if inf.handler.OnResync != nil {
+		inf.handler.OnResync(rev)
+	}
+	inf.syncedOnce.Do(func() { close(inf.synced) })
+	return nil
+}
+
+// watch applies the events watched from the revision after the cache's to
+// the cache, until ctx is done or the watch is canceled. It returns the
+// revision to list the keys at again if the watch cannot be resumed.
+func (inf *Informer) watch(ctx context.Context) (int64, error) {
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	wch := inf.w.Watch(wctx, inf.prefix, WithPrefix(), WithRev(inf.Revision()+1), WithAllowResync())
+	for wresp := range wch {
+		if wresp.ResyncRevision != 0 {
+			return wresp.ResyncRevision, nil
+		}
+		if err := wresp.Err(); err != nil {
+			return 0, err
+		}
+		if bm := wresp.Bookmark(); bm != nil {
+			inf.mu.Lock()
+			if bm.Revision > inf.rev {
+				inf.rev = bm.Revision
+			}
+			inf.mu.Unlock()
+			continue
+		}
+		for _, ev := range wresp.Events {
+			inf.apply(ev)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return 0, ErrInformerWatchClosed
+}
+
+// apply updates the cache with the event and notifies the change.
+func (inf *Informer) apply(ev *Event) {
+	key := string(ev.Kv.Key)
+	inf.mu.Lock()
+	old, ok := inf.cache[key]
+	if ev.Type == EventTypeDelete {
+		delete(inf.cache, key)
+	} else {
+		inf.cache[key] = ev.Kv
+	}
+	inf.rev = ev.Kv.ModRevision
+	inf.mu.Unlock()
+
+	switch {
+	case ev.Type == EventTypeDelete:
+		if ok {
+			inf.onDelete(old)
+		}
+	case ok:
+		inf.onUpdate(old, ev.Kv)
+	default:
+		inf.onAdd(ev.Kv)
+	}
+}
+
+func (inf *Informer) onAdd(kv *mvccpb.KeyValue) {
+	if inf.handler.OnAdd != nil {
+		inf.handler.OnAdd(kv)
+	}
+}
+
+func (inf *Informer) onUpdate(prev, kv *mvccpb.KeyValue) {
+	if inf.handler.OnUpdate != nil {
+		inf.handler.OnUpdate(prev, kv)
+	}
+}
+
+func (inf *Informer) onDelete(kv *mvccpb.KeyValue) {
+	if inf.handler.OnDelete != nil {
+		inf.handler.OnDelete(kv)
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package balancer implements a round robin load balancer that honors
+// per-request endpoint affinity and least loaded picks, and spreads the
+// requests to an endpoint over its sub connections when there are several.
+package balancer
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"sync/atomic"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+// Name is the name of the load balancing policy.
+const Name = "etcd_affinity_round_robin"
+
+func init() {
+	balancer.Register(builder{})
+}
+
+// builder builds base balancers, each with a picker builder of its own that
+// keeps the state of its sub connections across the pickers it builds.
+type builder struct{}
+
+func (builder) Name() string { return Name }
+
+func (builder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	pb := newPickerBuilder()
+	return &affinityBalancer{
+		Balancer: base.NewBalancerBuilder(Name, pb, base.Config{HealthCheck: true}).Build(cc, opts),
+		pb:       pb,
+	}
+}
+
+type affinityBalancer struct {
+	balancer.Balancer
+	pb *pickerBuilder
+}
+
+func (b *affinityBalancer) ExitIdle() {
+	if ei, ok := b.Balancer.(balancer.ExitIdler); ok {
+		ei.ExitIdle()
+	}
+}
+
+func (b *affinityBalancer) Close() {
+	b.Balancer.Close()
+	b.pb.close()
+}
+
+type affinityKey struct{}
+
+// WithAffinity returns a context that makes the balancer prefer a ready
+// connection to one of the given addresses. Requests fall back to round robin
+// if none of the addresses has a ready connection.
+func WithAffinity(ctx context.Context, addrs []string) context.Context {
+	return context.WithValue(ctx, affinityKey{}, addrs)
+}
+
+func affinityFromContext(ctx context.Context) []string {
+	if ctx == nil {
+		return nil
+	}
+	addrs, _ := ctx.Value(affinityKey{}).([]string)
+	return addrs
+}
+
+type leastLoadedKey struct{}
+
+// WithLeastLoaded returns a context that makes the balancer pick the ready
+// connection with the fewest requests in flight rather than the next one in
+// round robin order, e.g. for latency-critical requests.
+func WithLeastLoaded(ctx context.Context) context.Context {
+	return context.WithValue(ctx, leastLoadedKey{}, true)
+}
+
+func leastLoadedFromContext(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	ll, _ := ctx.Value(leastLoadedKey{}).(bool)
+	return ll
+}
+
+type subConnIndexKey struct{}
+
+// WithSubConnIndex returns the address with the given index of a sub
+// connection to it. The balancer opens a separate connection for each index
+// of the same address.
+func WithSubConnIndex(addr resolver.Address, i int) resolver.Address {
+	addr.Attributes = addr.Attributes.WithValue(subConnIndexKey{}, i)
+	return addr
+}
+
+func subConnIndex(addr resolver.Address) int {
+	i, _ := addr.Attributes.Value(subConnIndexKey{}).(int)
+	return i
+}
+
+// pickerBuilder keeps the state of the ready sub connections across the
+// pickers, so that the least loaded picks account for the requests started
+// under the previous pickers. The state of a sub connection is dropped, and
+// its metrics deleted, once it is no longer ready.
+type pickerBuilder struct {
+	subConns map[balancer.SubConn]*subConnState
+}
+
+type subConnState struct {
+	addr       string
+	connection string
+	metrics    *subConnMetrics
+	inflight   atomic.Int64
+}
+
+func newPickerBuilder() *pickerBuilder {
+	return &pickerBuilder{subConns: make(map[balancer.SubConn]*subConnState)}
+}
+
+func (pb *pickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	for sc, st := range pb.subConns {
+		if _, ok := info.ReadySCs[sc]; !ok {
+			Metrics.release(st.addr, st.connection)
+			delete(pb.subConns, sc)
+		}
+	}
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	p := &picker{
+		subConns: make([]balancer.SubConn, 0, len(info.ReadySCs)),
+		states:   make([]*subConnState, 0, len(info.ReadySCs)),
+	}
+	for sc, sci := range info.ReadySCs {
+		st, ok := pb.subConns[sc]
+		if !ok {
+			st = &subConnState{addr: sci.Address.Addr, connection: strconv.Itoa(subConnIndex(sci.Address))}
+			st.metrics = Metrics.acquire(st.addr, st.connection)
+			pb.subConns[sc] = st
+		}
+		p.subConns = append(p.subConns, sc)
+		p.states = append(p.states, st)
+	}
+	// start at a random index, as round_robin does, so that clients do not
+	// all pick the same first endpoint
+	p.next = uint32(rand.Intn(len(p.subConns)))
+	return p
+}
+
+// close deletes the metrics of the sub connections.
+func (pb *pickerBuilder) close() {
+	for sc, st := range pb.subConns {
+		Metrics.release(st.addr, st.connection)
+		delete(pb.subConns, sc)
+	}
+}
+
+type picker struct {
+	subConns []balancer.SubConn
+	states   []*subConnState
+	next     uint32
+}
+
+func (p *picker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	n := int(atomic.AddUint32(&p.next, 1) % uint32(len(p.subConns)))
+	// scan from the round robin index, so that the requests with affinity to
+	// an endpoint are spread over its sub connections too
+	for _, want := range affinityFromContext(info.Ctx) {
+		for j := range p.states {
+			if i := (n + j) % len(p.states); p.states[i].addr == want {
+				return p.result(i), nil
+			}
+		}
+	}
+	if leastLoadedFromContext(info.Ctx) {
+		// scan from the round robin index too, so that ties are spread
+		least := n
+		for j := 1; j < len(p.subConns); j++ {
+			if i := (n + j) % len(p.subConns); p.states[i].inflight.Load() < p.states[least].inflight.Load() {
+				least = i
+			}
+		}
+		return p.result(least), nil
+	}
+	return p.result(n), nil
+}
+
+func (p *picker) result(i int) balancer.PickResult {
+	st := p.states[i]
+	st.metrics.picks.Inc()
+	st.metrics.inflight.Inc()
+	st.inflight.Add(1)
+	return balancer.PickResult{
+		SubConn: p.subConns[i],
+		Done: func(balancer.DoneInfo) {
+			st.metrics.inflight.Dec()
+			st.inflight.Add(-1)
+		},
+	}
+}
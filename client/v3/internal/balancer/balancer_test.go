@@ -0,0 +1,218 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package balancer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+type fakeSubConn struct {
+	balancer.SubConn
+	addr string
+}
+
+// buildPicker builds a picker whose sub connections are removed once the test
+// is done.
+func buildPicker(t *testing.T, info base.PickerBuildInfo) balancer.Picker {
+	pb := newPickerBuilder()
+	t.Cleanup(pb.close)
+	return pb.Build(info)
+}
+
+func TestPickerAffinity(t *testing.T) {
+	info := base.PickerBuildInfo{ReadySCs: map[balancer.SubConn]base.SubConnInfo{}}
+	for _, addr := range []string{"a:2379", "b:2379", "c:2379"} {
+		info.ReadySCs[&fakeSubConn{addr: addr}] = base.SubConnInfo{Address: resolver.Address{Addr: addr}}
+	}
+	p := buildPicker(t, info)
+
+	pick := func(ctx context.Context) string {
+		res, err := p.Pick(balancer.PickInfo{Ctx: ctx})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.SubConn.(*fakeSubConn).addr
+	}
+
+	for i := 0; i < 6; i++ {
+		if addr := pick(WithAffinity(context.TODO(), []string{"x:2379", "b:2379"})); addr != "b:2379" {
+			t.Fatalf("expected affinity to %q, got %q", "b:2379", addr)
+		}
+	}
+
+	// unknown addresses fall back to round robin
+	seen := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		seen[pick(WithAffinity(context.TODO(), []string{"x:2379"}))] = true
+	}
+	if len(seen) != 3 {
+		t.Fatalf("expected round robin over all sub connections, got %v", seen)
+	}
+}
+
+func TestPickerNoSubConn(t *testing.T) {
+	p := buildPicker(t, base.PickerBuildInfo{})
+	if _, err := p.Pick(balancer.PickInfo{Ctx: context.TODO()}); err != balancer.ErrNoSubConnAvailable {
+		t.Fatalf("expected %v, got %v", balancer.ErrNoSubConnAvailable, err)
+	}
+}
+
+func TestPickerSubConns(t *testing.T) {
+	info := base.PickerBuildInfo{ReadySCs: map[balancer.SubConn]base.SubConnInfo{}}
+	for _, addr := range []string{"a:2379", "b:2379"} {
+		for i := 0; i < 2; i++ {
+			a := WithSubConnIndex(resolver.Address{Addr: addr}, i)
+			info.ReadySCs[&fakeSubConn{addr: addr}] = base.SubConnInfo{Address: a}
+		}
+	}
+	p := buildPicker(t, info)
+
+	// requests with affinity are spread over the sub connections of the
+	// endpoint
+	seen := make(map[balancer.SubConn]bool)
+	for i := 0; i < 4; i++ {
+		res, err := p.Pick(balancer.PickInfo{Ctx: WithAffinity(context.TODO(), []string{"b:2379"})})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if addr := res.SubConn.(*fakeSubConn).addr; addr != "b:2379" {
+			t.Fatalf("expected affinity to %q, got %q", "b:2379", addr)
+		}
+		seen[res.SubConn] = true
+		res.Done(balancer.DoneInfo{})
+	}
+	if len(seen) != 2 {
+		t.Fatalf("expected requests over 2 sub connections, got %d", len(seen))
+	}
+
+	seen = make(map[balancer.SubConn]bool)
+	for i := 0; i < 4; i++ {
+		res, err := p.Pick(balancer.PickInfo{Ctx: context.TODO()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[res.SubConn] = true
+	}
+	if len(seen) != 4 {
+		t.Fatalf("expected round robin over all sub connections, got %d", len(seen))
+	}
+}
+
+func TestPickerLeastLoaded(t *testing.T) {
+	info := base.PickerBuildInfo{ReadySCs: map[balancer.SubConn]base.SubConnInfo{}}
+	for _, addr := range []string{"a:2379", "b:2379", "c:2379"} {
+		info.ReadySCs[&fakeSubConn{addr: addr}] = base.SubConnInfo{Address: resolver.Address{Addr: addr}}
+	}
+	p := buildPicker(t, info)
+
+	// keep two requests in flight on two of the sub connections
+	var busy []balancer.PickResult
+	for len(busy) < 4 {
+		res, err := p.Pick(balancer.PickInfo{Ctx: context.TODO()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.SubConn.(*fakeSubConn).addr == "c:2379" {
+			res.Done(balancer.DoneInfo{})
+			continue
+		}
+		busy = append(busy, res)
+	}
+
+	for i := 0; i < 3; i++ {
+		res, err := p.Pick(balancer.PickInfo{Ctx: WithLeastLoaded(context.TODO())})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if addr := res.SubConn.(*fakeSubConn).addr; addr != "c:2379" {
+			t.Fatalf("expected the least loaded sub connection %q, got %q", "c:2379", addr)
+		}
+		res.Done(balancer.DoneInfo{})
+	}
+}
+
+func TestPickerLeastLoadedAcrossPickers(t *testing.T) {
+	info := base.PickerBuildInfo{ReadySCs: map[balancer.SubConn]base.SubConnInfo{}}
+	for _, addr := range []string{"a:2379", "b:2379"} {
+		info.ReadySCs[&fakeSubConn{addr: addr}] = base.SubConnInfo{Address: resolver.Address{Addr: addr}}
+	}
+	pb := newPickerBuilder()
+	defer pb.close()
+	p := pb.Build(info)
+
+	// keep a request in flight on a:2379
+	for {
+		res, err := p.Pick(balancer.PickInfo{Ctx: context.TODO()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.SubConn.(*fakeSubConn).addr == "a:2379" {
+			break
+		}
+		res.Done(balancer.DoneInfo{})
+	}
+
+	// a new picker, e.g. after a state change, still accounts for it
+	info.ReadySCs[&fakeSubConn{addr: "c:2379"}] = base.SubConnInfo{Address: resolver.Address{Addr: "c:2379"}}
+	p = pb.Build(info)
+	for i := 0; i < 6; i++ {
+		res, err := p.Pick(balancer.PickInfo{Ctx: WithLeastLoaded(context.TODO())})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if addr := res.SubConn.(*fakeSubConn).addr; addr == "a:2379" {
+			t.Fatalf("expected a sub connection other than the loaded %q", addr)
+		}
+		res.Done(balancer.DoneInfo{})
+	}
+}
+
+func TestPickerBuilderDeletesMetrics(t *testing.T) {
+	a, b := &fakeSubConn{addr: "a:2379"}, &fakeSubConn{addr: "b:2379"}
+	info := base.PickerBuildInfo{ReadySCs: map[balancer.SubConn]base.SubConnInfo{
+		a: {Address: resolver.Address{Addr: "a:2379"}},
+		b: {Address: resolver.Address{Addr: "b:2379"}},
+	}}
+	pb := newPickerBuilder()
+	p := pb.Build(info)
+	for i := 0; i < 2; i++ {
+		res, err := p.Pick(balancer.PickInfo{Ctx: context.TODO()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Done(balancer.DoneInfo{})
+	}
+	if n := testutil.CollectAndCount(Metrics, "etcd_client_connection_requests_total"); n != 2 {
+		t.Fatalf("expected the series of 2 sub connections, got %d", n)
+	}
+
+	// the endpoint of b was removed
+	delete(info.ReadySCs, b)
+	pb.Build(info)
+	if n := testutil.CollectAndCount(Metrics, "etcd_client_connection_requests_total"); n != 1 {
+		t.Fatalf("expected the series of 1 sub connection, got %d", n)
+	}
+
+	pb.close()
+	if n := testutil.CollectAndCount(Metrics); n != 0 {
+		t.Fatalf("expected no series once the balancer is closed, got %d", n)
+	}
+}
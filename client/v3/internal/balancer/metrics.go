@@ -0,0 +1,94 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package balancer
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics collects the metrics of the connections of the clients of the
+// process to their endpoints. It is not registered by default.
+var Metrics = newConnMetrics()
+
+// connMetrics counts the sub connections using each series, so that the
+// series of the sub connections removed from all the clients are deleted.
+type connMetrics struct {
+	picks    *prometheus.CounterVec
+	inflight *prometheus.GaugeVec
+
+	mu   sync.Mutex
+	refs map[[2]string]int
+}
+
+func newConnMetrics() *connMetrics {
+	return &connMetrics{
+		picks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "etcd",
+			Subsystem: "client",
+			Name:      "connection_requests_total",
+			Help:      "Total number of requests and streams started on each connection to an endpoint.",
+		}, []string{"endpoint", "connection"}),
+		inflight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "etcd",
+			Subsystem: "client",
+			Name:      "connection_inflight_requests",
+			Help:      "Number of requests and streams in progress on each connection to an endpoint.",
+		}, []string{"endpoint", "connection"}),
+		refs: make(map[[2]string]int),
+	}
+}
+
+func (m *connMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.picks.Describe(ch)
+	m.inflight.Describe(ch)
+}
+
+func (m *connMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.picks.Collect(ch)
+	m.inflight.Collect(ch)
+}
+
+// subConnMetrics holds the metrics of a sub connection, looked up once
+// rather than on every pick.
+type subConnMetrics struct {
+	picks    prometheus.Counter
+	inflight prometheus.Gauge
+}
+
+// acquire returns the metrics of a sub connection; release must be called
+// once the sub connection is removed.
+func (m *connMetrics) acquire(endpoint, connection string) *subConnMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.refs[[2]string{endpoint, connection}]++
+	return &subConnMetrics{
+		picks:    m.picks.WithLabelValues(endpoint, connection),
+		inflight: m.inflight.WithLabelValues(endpoint, connection),
+	}
+}
+
+func (m *connMetrics) release(endpoint, connection string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := [2]string{endpoint, connection}
+	if m.refs[key]--; m.refs[key] > 0 {
+		return
+	}
+	delete(m.refs, key)
+	m.picks.DeleteLabelValues(endpoint, connection)
+	m.inflight.DeleteLabelValues(endpoint, connection)
+}
@@ -15,10 +15,13 @@
 package resolver
 
 import (
+	"fmt"
+
 	"google.golang.org/grpc/resolver"
 	"google.golang.org/grpc/resolver/manual"
 	"google.golang.org/grpc/serviceconfig"
 
+	"go.etcd.io/etcd/client/v3/internal/balancer"
 	"go.etcd.io/etcd/client/v3/internal/endpoint"
 )
 
@@ -31,6 +34,7 @@ const (
 type EtcdManualResolver struct {
 	*manual.Resolver
 	endpoints     []string
+	subConns      int
 	serviceConfig *serviceconfig.ParseResult
 }
 
@@ -41,7 +45,7 @@ func New(endpoints ...string) *EtcdManualResolver {
 
 // Build returns itself for Resolver, because it's both a builder and a resolver.
 func (r *EtcdManualResolver) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
-	r.serviceConfig = cc.ParseServiceConfig(`{"loadBalancingPolicy": "round_robin"}`)
+	r.serviceConfig = cc.ParseServiceConfig(fmt.Sprintf(`{"loadBalancingPolicy": %q}`, balancer.Name))
 	if r.serviceConfig.Err != nil {
 		return nil, r.serviceConfig.Err
 	}
@@ -59,12 +63,25 @@ func (r *EtcdManualResolver) SetEndpoints(endpoints []string) {
 	r.updateState()
 }
 
+// SetSubConnsPerEndpoint sets the number of connections the balancer opens to
+// each endpoint. It must be called before the resolver is built.
+func (r *EtcdManualResolver) SetSubConnsPerEndpoint(n int) {
+	r.subConns = n
+}
+
 func (r EtcdManualResolver) updateState() {
 	if r.CC != nil {
-		addresses := make([]resolver.Address, len(r.endpoints))
-		for i, ep := range r.endpoints {
+		addresses := make([]resolver.Address, 0, len(r.endpoints)*max(r.subConns, 1))
+		for _, ep := range r.endpoints {
 			addr, serverName := endpoint.Interpret(ep)
-			addresses[i] = resolver.Address{Addr: addr, ServerName: serverName}
+			a := resolver.Address{Addr: addr, ServerName: serverName}
+			if r.subConns <= 1 {
+				addresses = append(addresses, a)
+				continue
+			}
+			for i := 0; i < r.subConns; i++ {
+				addresses = append(addresses, balancer.WithSubConnIndex(a, i))
+			}
 		}
 		state := resolver.State{
 			Addresses:     addresses,
@@ -38,6 +38,9 @@ type LeaseGrantResponse struct {
 	ID    LeaseID
 	TTL   int64
 	Error string
+	// PinnedRevision is the revision pinned against compaction by the
+	// lease, if any.
+	PinnedRevision int64
 }
 
 // LeaseKeepAliveResponse wraps the protobuf message LeaseKeepAliveResponse.
@@ -107,7 +110,7 @@ func (e ErrKeepAliveHalted) Error() string {
 
 type Lease interface {
 	// Grant creates a new lease.
-	Grant(ctx context.Context, ttl int64) (*LeaseGrantResponse, error)
+	Grant(ctx context.Context, ttl int64, opts ...LeaseOption) (*LeaseGrantResponse, error)
 
 	// Revoke revokes the given lease.
 	Revoke(ctx context.Context, id LeaseID) (*LeaseRevokeResponse, error)
@@ -211,8 +214,8 @@ func NewLeaseFromLeaseClient(remote pb.LeaseClient, c *Client, keepAliveTimeout
 	return l
 }
 
-func (l *lessor) Grant(ctx context.Context, ttl int64) (*LeaseGrantResponse, error) {
-	r := &pb.LeaseGrantRequest{TTL: ttl}
+func (l *lessor) Grant(ctx context.Context, ttl int64, opts ...LeaseOption) (*LeaseGrantResponse, error) {
+	r := toLeaseGrantRequest(ttl, opts...)
 	resp, err := l.remote.LeaseGrant(ctx, r, l.callOpts...)
 	if err == nil {
 		gresp := &LeaseGrantResponse{
@@ -220,6 +223,7 @@ func (l *lessor) Grant(ctx context.Context, ttl int64) (*LeaseGrantResponse, err
 			ID:             LeaseID(resp.ID),
 			TTL:            resp.TTL,
 			Error:          resp.Error,
+			PinnedRevision: resp.PinnedRevision,
 		}
 		return gresp, nil
 	}
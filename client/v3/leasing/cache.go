@@ -18,6 +18,7 @@ import (
 	"context"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	v3pb "go.etcd.io/etcd/api/v3/etcdserverpb"
@@ -25,13 +26,33 @@ import (
 	v3 "go.etcd.io/etcd/client/v3"
 )
 
-const revokeBackoff = 2 * time.Second
+const (
+	revokeBackoff = 2 * time.Second
+	// denyBackoff is how long keys are not acquired after the cluster
+	// denied to acquire a leasing key.
+	denyBackoff = 30 * time.Second
+	// releaseBackoffMin and releaseBackoffMax bound the wait between
+	// attempts to release the leasing key of an evicted key.
+	releaseBackoffMin = 100 * time.Millisecond
+	releaseBackoffMax = 5 * time.Second
+)
 
 type leaseCache struct {
 	mu      sync.RWMutex
 	entries map[string]*leaseKey
 	revokes map[string]time.Time
 	header  *v3pb.ResponseHeader
+
+	// maxKeys is the maximum number of cached keys, if positive.
+	maxKeys int
+	// releasing records the keys evicted from the cache whose leasing
+	// keys are not released yet.
+	releasing map[string]struct{}
+	// deniedUntil is when keys may be acquired again after the cluster
+	// denied to acquire a leasing key.
+	deniedUntil time.Time
+	// clock orders the uses of the cached keys.
+	clock int64
 }
 
 type leaseKey struct {
@@ -39,6 +60,10 @@ type leaseKey struct {
 	// rev is the leasing key revision.
 	rev   int64
 	waitc chan struct{}
+	// lastUsed is the clock of the last read of the key, accessed atomically.
+	lastUsed int64
+	// releasec is closed when the key is evicted because the cache is full.
+	releasec chan struct{}
 }
 
 func (lc *leaseCache) Rev(key string) int64 {
@@ -119,21 +144,77 @@ func (lc *leaseCache) NotifyOps(ops []v3.Op) (wcs []<-chan struct{}) {
 
 func (lc *leaseCache) MayAcquire(key string) bool {
 	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	if time.Now().Before(lc.deniedUntil) {
+		return false
+	}
+	if _, ok := lc.releasing[key]; ok {
+		return false
+	}
 	lr, ok := lc.revokes[key]
-	lc.mu.RUnlock()
 	return !ok || time.Since(lr) > revokeBackoff
 }
 
-func (lc *leaseCache) Add(key string, resp *v3.GetResponse, op v3.Op) *v3.GetResponse {
-	lk := &leaseKey{resp, resp.Header.Revision, closedCh}
+// Deny stops acquiring keys for a while after the cluster denied to acquire
+// a leasing key.
+func (lc *leaseCache) Deny() {
 	lc.mu.Lock()
+	lc.deniedUntil = time.Now().Add(denyBackoff)
+	lc.mu.Unlock()
+}
+
+// Add caches the key, evicting the least recently used key if the cache is
+// full. It returns the key response and the evicted key, if any, whose
+// leasing key must be released.
+func (lc *leaseCache) Add(key string, resp *v3.GetResponse, op v3.Op) (*v3.GetResponse, *leaseKey, string) {
+	lk := &leaseKey{
+		response: resp,
+		rev:      resp.Header.Revision,
+		waitc:    closedCh,
+		lastUsed: atomic.AddInt64(&lc.clock, 1),
+		releasec: make(chan struct{}),
+	}
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
 	if lc.header == nil || lc.header.Revision < resp.Header.Revision {
 		lc.header = resp.Header
 	}
+	evicted := ""
+	if _, ok := lc.entries[key]; !ok && lc.maxKeys > 0 && len(lc.entries) >= lc.maxKeys {
+		evicted = lc.evictLRU()
+	}
 	lc.entries[key] = lk
-	ret := lk.get(op)
+	return lk.get(op), lk, evicted
+}
+
+// evictLRU evicts the least recently used key and returns it. The key stays
+// unacquirable until its leasing key is released.
+func (lc *leaseCache) evictLRU() string {
+	var (
+		lru     string
+		lruUsed int64
+		lruKey  *leaseKey
+	)
+	for k, li := range lc.entries {
+		if used := atomic.LoadInt64(&li.lastUsed); lruKey == nil || used < lruUsed {
+			lru, lruUsed, lruKey = k, used, li
+		}
+	}
+	if lruKey == nil {
+		return ""
+	}
+	delete(lc.entries, lru)
+	close(lruKey.releasec)
+	lc.releasing[lru] = struct{}{}
+	cacheEvictions.Inc()
+	return lru
+}
+
+// Released marks the leasing key of an evicted key as released.
+func (lc *leaseCache) Released(key string) {
+	lc.mu.Lock()
+	delete(lc.releasing, key)
 	lc.mu.Unlock()
-	return ret
 }
 
 func (lc *leaseCache) Update(key, val []byte, respHeader *v3pb.ResponseHeader) {
@@ -187,13 +268,15 @@ func (lc *leaseCache) EvictRange(key, end string) {
 	defer lc.mu.Unlock()
 	for k := range lc.entries {
 		if inRange(k, key, end) {
-			delete(lc.entries, key)
-			lc.revokes[key] = time.Now()
+			delete(lc.entries, k)
+			lc.revokes[k] = time.Now()
 		}
 	}
 }
 
-func isBadOp(op v3.Op) bool { return op.Rev() > 0 || len(op.RangeBytes()) > 0 }
+func isBadOp(op v3.Op) bool {
+	return op.Rev() > 0 || len(op.RangeBytes()) > 0 || len(op.GroupBySeparator()) > 0
+}
 
 func (lc *leaseCache) Get(ctx context.Context, op v3.Op) (*v3.GetResponse, bool) {
 	if isBadOp(op) {
@@ -209,6 +292,7 @@ func (lc *leaseCache) Get(ctx context.Context, op v3.Op) (*v3.GetResponse, bool)
 	case <-ctx.Done():
 		return nil, true
 	}
+	atomic.StoreInt64(&li.lastUsed, atomic.AddInt64(&lc.clock, 1))
 	lc.mu.RLock()
 	lk := *li
 	ret := lk.get(op)
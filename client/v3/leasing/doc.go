@@ -42,4 +42,19 @@
 //	}
 //	lkv2.Put(context.TODO(), "abc", "456")
 //	resp, err = lkv.Get("abc")
+//
+// The number of cached keys is bounded. Use New to configure the bound; once
+// the cache is full, the least recently read key is evicted and its leasing
+// key released:
+//
+//	lkv, closeLKV, err := leasing.New(cli, leasing.Config{Prefix: "leasing-prefix", MaxKeys: 1000})
+//
+// If the cluster denies to acquire leasing keys for lack of permission
+// on the leasing prefix, reads are served by the cluster without caching for
+// a while before acquiring keys is retried. Writes still revoke the leases
+// of other clients, so they fail with the error of the cluster.
+//
+// The leasing KV exports Prometheus metrics under "etcd_leasing_": the cache
+// hits and misses of linearizable reads, the evicted keys, the denied leasing
+// keys and the latency of revoking the leases of other clients on writes.
 package leasing
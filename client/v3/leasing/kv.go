@@ -16,6 +16,7 @@ package leasing
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"sync"
 	"time"
@@ -52,17 +53,47 @@ func init() {
 	close(closedCh)
 }
 
+// DefaultMaxKeys is the default maximum number of keys cached by a leasing KV.
+const DefaultMaxKeys = 10000
+
+// Config is the configuration of a leasing KV.
+type Config struct {
+	// Prefix is the prefix of the leasing keys stored on the cluster.
+	Prefix string
+	// MaxKeys is the maximum number of keys cached. Once the cache is full,
+	// the least recently read key is evicted and its leasing key released.
+	// It defaults to DefaultMaxKeys, and the cache is unbounded if negative.
+	MaxKeys int
+	// SessionOptions are the options of the session whose lease is attached
+	// to the leasing keys.
+	SessionOptions []concurrency.SessionOption
+}
+
 // NewKV wraps a KV instance so that all requests are wired through a leasing protocol.
 func NewKV(cl *v3.Client, pfx string, opts ...concurrency.SessionOption) (v3.KV, func(), error) {
+	return New(cl, Config{Prefix: pfx, SessionOptions: opts})
+}
+
+// New wraps a KV instance so that all requests are wired through a leasing
+// protocol configured by cfg. It returns the KV and a function closing it.
+func New(cl *v3.Client, cfg Config) (v3.KV, func(), error) {
+	maxKeys := cfg.MaxKeys
+	if maxKeys == 0 {
+		maxKeys = DefaultMaxKeys
+	}
 	cctx, cancel := context.WithCancel(cl.Ctx())
 	lkv := &leasingKV{
-		cl:          cl,
-		kv:          cl.KV,
-		pfx:         pfx,
-		leases:      leaseCache{revokes: make(map[string]time.Time)},
+		cl:  cl,
+		kv:  cl.KV,
+		pfx: cfg.Prefix,
+		leases: leaseCache{
+			revokes:   make(map[string]time.Time),
+			releasing: make(map[string]struct{}),
+			maxKeys:   maxKeys,
+		},
 		ctx:         cctx,
 		cancel:      cancel,
-		sessionOpts: opts,
+		sessionOpts: cfg.SessionOptions,
 		sessionc:    make(chan struct{}),
 	}
 	lkv.wg.Add(2)
@@ -151,7 +182,7 @@ func (lkv *leasingKV) monitorSession() {
 	}
 }
 
-func (lkv *leasingKV) monitorLease(ctx context.Context, key string, rev int64) {
+func (lkv *leasingKV) monitorLease(ctx context.Context, key string, rev int64, releasec <-chan struct{}) {
 	cctx, cancel := context.WithCancel(lkv.ctx)
 	defer cancel()
 	for cctx.Err() == nil {
@@ -167,21 +198,51 @@ func (lkv *leasingKV) monitorLease(ctx context.Context, key string, rev int64) {
 			}
 		}
 		wch := lkv.cl.Watch(cctx, lkv.pfx+key, v3.WithRev(rev+1))
-		for resp := range wch {
-			for _, ev := range resp.Events {
-				if string(ev.Kv.Value) != "REVOKE" {
-					continue
+		for watching := true; watching; {
+			select {
+			case <-releasec:
+				// evicted from the cache, the leasing key is released
+				return
+			case resp, ok := <-wch:
+				if !ok {
+					watching = false
+					break
 				}
-				if v3.LeaseID(ev.Kv.Lease) == lkv.leaseID() {
-					lkv.rescind(cctx, key, ev.Kv.ModRevision)
+				for _, ev := range resp.Events {
+					if string(ev.Kv.Value) != "REVOKE" {
+						continue
+					}
+					if v3.LeaseID(ev.Kv.Lease) == lkv.leaseID() {
+						lkv.rescind(cctx, key, ev.Kv.ModRevision)
+					}
+					return
 				}
-				return
 			}
 		}
 		rev = 0
 	}
 }
 
+// release deletes the leasing key of a key evicted from the cache, so that
+// writers need not revoke it.
+func (lkv *leasingKV) release(key string) {
+	defer lkv.leases.Released(key)
+	cmp := v3.Compare(v3.LeaseValue(lkv.pfx+key), "=", lkv.leaseID())
+	op := v3.OpDelete(lkv.pfx + key)
+	for backoff := releaseBackoffMin; ; backoff = min(2*backoff, releaseBackoffMax) {
+		_, err := lkv.kv.Txn(lkv.ctx).If(cmp).Then(op).Commit()
+		if err == nil || isDenied(err) {
+			// a denied leasing key is left to expire with the session
+			return
+		}
+		select {
+		case <-time.After(backoff):
+		case <-lkv.ctx.Done():
+			return
+		}
+	}
+}
+
 // rescind releases a lease from this client.
 func (lkv *leasingKV) rescind(ctx context.Context, key string, rev int64) {
 	if lkv.leases.Evict(key) > rev {
@@ -302,35 +363,59 @@ func (lkv *leasingKV) get(ctx context.Context, op v3.Op) (*v3.GetResponse, error
 	}
 
 	if resp, ok := lkv.leases.Get(ctx, op); resp != nil {
+		cacheHits.Inc()
 		return resp, nil
 	} else if !ok || op.IsSerializable() {
 		// must be handled by server or can skip linearization
 		return do()
 	}
 
+	cacheMisses.Inc()
 	key := string(op.KeyBytes())
 	if !lkv.leases.MayAcquire(key) {
-		resp, err := lkv.kv.Do(ctx, op)
-		return resp.Get(), err
+		return do()
 	}
 
 	resp, err := lkv.acquire(ctx, key, v3.OpGet(key))
 	if err != nil {
-		return nil, err
+		if !isDenied(err) {
+			return nil, err
+		}
+		// degrade to reads served by the cluster
+		acquireDenied.Inc()
+		lkv.leases.Deny()
+		return do()
 	}
 	getResp := (*v3.GetResponse)(resp.Responses[0].GetResponseRange())
 	getResp.Header = resp.Header
 	if resp.Succeeded {
-		getResp = lkv.leases.Add(key, getResp, op)
+		var (
+			lk      *leaseKey
+			evicted string
+		)
+		getResp, lk, evicted = lkv.leases.Add(key, getResp, op)
 		lkv.wg.Add(1)
 		go func() {
 			defer lkv.wg.Done()
-			lkv.monitorLease(ctx, key, resp.Header.Revision)
+			lkv.monitorLease(ctx, key, resp.Header.Revision, lk.releasec)
 		}()
+		if evicted != "" {
+			lkv.wg.Add(1)
+			go func() {
+				defer lkv.wg.Done()
+				lkv.release(evicted)
+			}()
+		}
 	}
 	return getResp, nil
 }
 
+// isDenied returns true if the cluster denied the request for lack of
+// permission on the leasing keys.
+func isDenied(err error) bool {
+	return errors.Is(err, rpctypes.ErrPermissionDenied)
+}
+
 func (lkv *leasingKV) deleteRangeRPC(ctx context.Context, maxLeaseRev int64, key, end string) (*v3.DeleteResponse, error) {
 	lkey, lend := lkv.pfx+key, lkv.pfx+end
 	resp, err := lkv.kv.Txn(ctx).If(
@@ -405,13 +490,18 @@ func (lkv *leasingKV) delete(ctx context.Context, op v3.Op) (dr *v3.DeleteRespon
 }
 
 func (lkv *leasingKV) revoke(ctx context.Context, key string, op v3.Op) (*v3.TxnResponse, error) {
+	start := time.Now()
 	rev := lkv.leases.Rev(key)
 	txn := lkv.kv.Txn(ctx).If(v3.Compare(v3.CreateRevision(lkv.pfx+key), "<", rev+1)).Then(op)
 	resp, err := txn.Else(v3.OpPut(lkv.pfx+key, "REVOKE", v3.WithIgnoreLease())).Commit()
 	if err != nil || resp.Succeeded {
 		return resp, err
 	}
-	return resp, lkv.waitRescind(ctx, key, resp.Header.Revision)
+	if err = lkv.waitRescind(ctx, key, resp.Header.Revision); err != nil {
+		return resp, err
+	}
+	invalidationSec.Observe(time.Since(start).Seconds())
+	return resp, nil
 }
 
 func (lkv *leasingKV) revokeRange(ctx context.Context, begin, end string) (int64, error) {
@@ -0,0 +1,64 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leasing
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	cacheHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "leasing",
+		Name:      "cache_hits_total",
+		Help:      "Total number of linearizable reads served from the leasing cache.",
+	})
+	cacheMisses = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "leasing",
+		Name:      "cache_misses_total",
+		Help:      "Total number of linearizable reads of a single key served by the cluster.",
+	})
+	cacheEvictions = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "leasing",
+		Name:      "cache_evictions_total",
+		Help:      "Total number of keys evicted from the leasing cache because it is full.",
+	})
+	invalidationSec = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "etcd",
+		Subsystem: "leasing",
+		Name:      "invalidation_duration_seconds",
+		Help:      "The latency distributions of revoking the lease of another client on a written key.",
+
+		// lowest bucket start of upper bound 0.001 sec (1 ms) with factor 2
+		// highest bucket start of 0.001 sec * 2^13 == 8.192 sec
+		Buckets: prometheus.ExponentialBuckets(0.001, 2, 14),
+	})
+	acquireDenied = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "leasing",
+		Name:      "acquire_denied_total",
+		Help:      "Total number of leasing keys the cluster denied to acquire.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(cacheHits)
+	prometheus.MustRegister(cacheMisses)
+	prometheus.MustRegister(cacheEvictions)
+	prometheus.MustRegister(invalidationSec)
+	prometheus.MustRegister(acquireDenied)
+}
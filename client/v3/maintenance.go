@@ -27,13 +27,19 @@ import (
 )
 
 type (
-	DefragmentResponse pb.DefragmentResponse
-	AlarmResponse      pb.AlarmResponse
-	AlarmMember        pb.AlarmMember
-	StatusResponse     pb.StatusResponse
-	HashKVResponse     pb.HashKVResponse
-	MoveLeaderResponse pb.MoveLeaderResponse
-	DowngradeResponse  pb.DowngradeResponse
+	DefragmentResponse  pb.DefragmentResponse
+	AlarmResponse       pb.AlarmResponse
+	AlarmMember         pb.AlarmMember
+	StatusResponse      pb.StatusResponse
+	HashKVResponse      pb.HashKVResponse
+	HashKVRangeResponse pb.HashKVRangeResponse
+	MoveLeaderResponse  pb.MoveLeaderResponse
+	DowngradeResponse   pb.DowngradeResponse
+	DrainResponse       pb.DrainResponse
+	HotKeysResponse     pb.HotKeysResponse
+
+	RotateEncryptionKeyResponse pb.RotateEncryptionKeyResponse
+	ClusterEventsResponse       pb.ClusterEventsResponse
 
 	DowngradeAction pb.DowngradeRequest_DowngradeAction
 )
@@ -51,6 +57,12 @@ type Maintenance interface {
 	// AlarmDisarm disarms a given alarm.
 	AlarmDisarm(ctx context.Context, m *AlarmMember) (*AlarmResponse, error)
 
+	// SetReadOnly makes the cluster read-only or read-write. A read-only
+	// cluster rejects all mutations with rpctypes.ErrReadOnly, while reads
+	// and watches are still served.
+	// Supported since etcd 3.6.
+	SetReadOnly(ctx context.Context, readOnly bool) (*AlarmResponse, error)
+
 	// Defragment releases wasted space from internal fragmentation on a given etcd member.
 	// Defragment is only needed when deleting a large number of keys and want to reclaim
 	// the resources.
@@ -68,6 +80,22 @@ type Maintenance interface {
 	// is non-zero, the hash is computed on all keys at or below the given revision.
 	HashKV(ctx context.Context, endpoint string, rev int64) (*HashKVResponse, error)
 
+	// HashKVRange returns a hash of the keys and values in [key, end) at the
+	// given revision, or at the latest one if rev is zero. The hash does not
+	// depend on revisions, so that it can be compared with the hash of a copy
+	// of the range in another cluster. If stripPrefix is set, keys are hashed
+	// without key as their prefix, so that a prefix can be compared with a copy
+	// of it stored under another prefix.
+	// Supported since etcd 3.6.
+	HashKVRange(ctx context.Context, endpoint string, key, end string, rev int64, stripPrefix bool) (*HashKVRangeResponse, error)
+
+	// HotKeys returns the keys and prefixes most frequently read and written
+	// through the given member, up to limit each or all the tracked keys if
+	// limit is zero, as estimated from a sample of its requests. It fails
+	// unless the member has hot key tracking enabled.
+	// Supported since etcd 3.6.
+	HotKeys(ctx context.Context, endpoint string, limit int64) (*HotKeysResponse, error)
+
 	// SnapshotWithVersion returns a reader for a point-in-time snapshot and version of etcd that created it.
 	// If the context "ctx" is canceled or timed out, reading from returned
 	// "io.ReadCloser" would error out (e.g. context.Canceled, context.DeadlineExceeded).
@@ -87,6 +115,38 @@ type Maintenance interface {
 	// on the cluster version.
 	// Supported since etcd 3.5.
 	Downgrade(ctx context.Context, action DowngradeAction, version string) (*DowngradeResponse, error)
+
+	// Drain marks the given member as draining. A draining member transfers
+	// its leadership away, fails its readiness checks and rejects new watch
+	// streams, while requests already in flight are allowed to finish.
+	// Supported since etcd 3.6.
+	Drain(ctx context.Context, endpoint string) (*DrainResponse, error)
+
+	// Undrain makes a draining member serve client traffic again.
+	// Supported since etcd 3.6.
+	Undrain(ctx context.Context, endpoint string) (*DrainResponse, error)
+
+	// RotateEncryptionKey creates a new data encryption key on the given member,
+	// used to encrypt key-value records from now on. It fails unless the member
+	// has encryption at rest enabled.
+	// Supported since etcd 3.6.
+	RotateEncryptionKey(ctx context.Context, endpoint string) (*RotateEncryptionKeyResponse, error)
+
+	// ClusterEvents returns a stream of the cluster events observed by the given
+	// member: alarms raised and cleared, leader changes, membership changes,
+	// compactions and defragmentations. The first response holds the latest
+	// events; if watch is set, the stream then receives events as they happen
+	// until the context is canceled.
+	// Supported since etcd 3.6.
+	ClusterEvents(ctx context.Context, endpoint string, watch bool) (ClusterEventsStream, error)
+}
+
+// ClusterEventsStream receives cluster events from a member.
+type ClusterEventsStream interface {
+	// Recv returns the next response, or io.EOF once the stream ends.
+	Recv() (*ClusterEventsResponse, error)
+	// Close releases the connection to the member.
+	Close() error
 }
 
 // SnapshotResponse is aggregated response from the snapshot stream.
@@ -172,6 +232,10 @@ func (m *maintenance) AlarmDisarm(ctx context.Context, am *AlarmMember) (*AlarmR
 		}
 		ret := AlarmResponse{}
 		for _, am := range ar.Alarms {
+			// the read-only mode is only lifted explicitly, see SetReadOnly.
+			if am.Alarm == pb.AlarmType_READONLY {
+				continue
+			}
 			dresp, derr := m.AlarmDisarm(ctx, (*AlarmMember)(am))
 			if derr != nil {
 				return nil, toErr(ctx, derr)
@@ -188,6 +252,22 @@ func (m *maintenance) AlarmDisarm(ctx context.Context, am *AlarmMember) (*AlarmR
 	return nil, toErr(ctx, err)
 }
 
+func (m *maintenance) SetReadOnly(ctx context.Context, readOnly bool) (*AlarmResponse, error) {
+	req := &pb.AlarmRequest{
+		Action:   pb.AlarmRequest_DEACTIVATE,
+		MemberID: 0, // cluster-wide
+		Alarm:    pb.AlarmType_READONLY,
+	}
+	if readOnly {
+		req.Action = pb.AlarmRequest_ACTIVATE
+	}
+	resp, err := m.remote.Alarm(ctx, req, m.callOpts...)
+	if err == nil {
+		return (*AlarmResponse)(resp), nil
+	}
+	return nil, toErr(ctx, err)
+}
+
 func (m *maintenance) Defragment(ctx context.Context, endpoint string) (*DefragmentResponse, error) {
 	remote, cancel, err := m.dial(endpoint)
 	if err != nil {
@@ -201,6 +281,75 @@ func (m *maintenance) Defragment(ctx context.Context, endpoint string) (*Defragm
 	return (*DefragmentResponse)(resp), nil
 }
 
+func (m *maintenance) Drain(ctx context.Context, endpoint string) (*DrainResponse, error) {
+	return m.drain(ctx, endpoint, false)
+}
+
+func (m *maintenance) Undrain(ctx context.Context, endpoint string) (*DrainResponse, error) {
+	return m.drain(ctx, endpoint, true)
+}
+
+func (m *maintenance) drain(ctx context.Context, endpoint string, cancelDrain bool) (*DrainResponse, error) {
+	remote, cancel, err := m.dial(endpoint)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	defer cancel()
+	resp, err := remote.Drain(ctx, &pb.DrainRequest{Cancel: cancelDrain}, m.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	return (*DrainResponse)(resp), nil
+}
+
+func (m *maintenance) RotateEncryptionKey(ctx context.Context, endpoint string) (*RotateEncryptionKeyResponse, error) {
+	remote, cancel, err := m.dial(endpoint)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	defer cancel()
+	resp, err := remote.RotateEncryptionKey(ctx, &pb.RotateEncryptionKeyRequest{}, m.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	return (*RotateEncryptionKeyResponse)(resp), nil
+}
+
+func (m *maintenance) ClusterEvents(ctx context.Context, endpoint string, watch bool) (ClusterEventsStream, error) {
+	remote, cancel, err := m.dial(endpoint)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	cs, err := remote.ClusterEvents(ctx, &pb.ClusterEventsRequest{Watch: watch}, m.callOpts...)
+	if err != nil {
+		cancel()
+		return nil, toErr(ctx, err)
+	}
+	return &clusterEventsStream{ctx: ctx, cs: cs, cancel: cancel}, nil
+}
+
+type clusterEventsStream struct {
+	ctx    context.Context
+	cs     pb.Maintenance_ClusterEventsClient
+	cancel func()
+}
+
+func (s *clusterEventsStream) Recv() (*ClusterEventsResponse, error) {
+	resp, err := s.cs.Recv()
+	if err == io.EOF {
+		return nil, err
+	}
+	if err != nil {
+		return nil, toErr(s.ctx, err)
+	}
+	return (*ClusterEventsResponse)(resp), nil
+}
+
+func (s *clusterEventsStream) Close() error {
+	s.cancel()
+	return nil
+}
+
 func (m *maintenance) Status(ctx context.Context, endpoint string) (*StatusResponse, error) {
 	remote, cancel, err := m.dial(endpoint)
 	if err != nil {
@@ -228,6 +377,33 @@ func (m *maintenance) HashKV(ctx context.Context, endpoint string, rev int64) (*
 	return (*HashKVResponse)(resp), nil
 }
 
+func (m *maintenance) HashKVRange(ctx context.Context, endpoint string, key, end string, rev int64, stripPrefix bool) (*HashKVRangeResponse, error) {
+	remote, cancel, err := m.dial(endpoint)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	defer cancel()
+	req := &pb.HashKVRangeRequest{Key: []byte(key), RangeEnd: []byte(end), Revision: rev, StripPrefix: stripPrefix}
+	resp, err := remote.HashKVRange(ctx, req, m.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	return (*HashKVRangeResponse)(resp), nil
+}
+
+func (m *maintenance) HotKeys(ctx context.Context, endpoint string, limit int64) (*HotKeysResponse, error) {
+	remote, cancel, err := m.dial(endpoint)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	defer cancel()
+	resp, err := remote.HotKeys(ctx, &pb.HotKeysRequest{Limit: limit}, m.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	return (*HotKeysResponse)(resp), nil
+}
+
 func (m *maintenance) SnapshotWithVersion(ctx context.Context) (*SnapshotResponse, error) {
 	ss, err := m.remote.Snapshot(ctx, &pb.SnapshotRequest{}, append(m.callOpts, withMax(defaultStreamMaxRetries))...)
 	if err != nil {
@@ -50,32 +50,18 @@ func (s *syncer) SyncBase(ctx context.Context) (<-chan clientv3.GetResponse, cha
 	respchan := make(chan clientv3.GetResponse, 1024)
 	errchan := make(chan error, 1)
 
-	// if rev is not specified, we will choose the most recent revision.
-	if s.rev == 0 {
-		// If len(s.prefix) == 0, we will check a random key to fetch the most recent
-		// revision (foo), otherwise we use the provided prefix.
-		checkPath := "foo"
-		if len(s.prefix) != 0 {
-			checkPath = s.prefix
-		}
-		resp, err := s.c.Get(ctx, checkPath)
-		if err != nil {
-			errchan <- err
-			close(respchan)
-			close(errchan)
-			return respchan, errchan
-		}
-		s.rev = resp.Header.Revision
-	}
+	// the revision is pinned against compaction while the base state is synced
+	pager := clientv3.NewListPager(s.c)
+	pager.PageSize = batchLimit
+	pager.Lease = s.c
 
 	go func() {
 		defer close(respchan)
 		defer close(errchan)
 
 		var key string
-
-		opts := []clientv3.OpOption{clientv3.WithLimit(batchLimit), clientv3.WithRev(s.rev),
-			clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend)}
+		// if rev is not specified, we will choose the most recent revision.
+		opts := []clientv3.OpOption{clientv3.WithRev(s.rev)}
 
 		if len(s.prefix) == 0 {
 			// If len(s.prefix) == 0, we will sync the entire key-value space.
@@ -90,21 +76,15 @@ func (s *syncer) SyncBase(ctx context.Context) (<-chan clientv3.GetResponse, cha
 			key = s.prefix
 		}
 
-		for {
-			resp, err := s.c.Get(ctx, key, opts...)
-			if err != nil {
-				errchan <- err
-				return
-			}
-
+		rev, err := pager.EachPage(ctx, key, func(resp *clientv3.GetResponse) error {
 			respchan <- *resp
-
-			if !resp.More {
-				return
-			}
-			// move to next key
-			key = string(append(resp.Kvs[len(resp.Kvs)-1].Key, 0))
+			return nil
+		}, opts...)
+		if err != nil {
+			errchan <- err
+			return
 		}
+		s.rev = rev
 	}()
 
 	return respchan, errchan
@@ -39,4 +39,14 @@
 //	resp, _ = cli.Get(context.TODO(), "abc")
 //	fmt.Printf("%s\n", resp.Kvs[0].Value)
 //	// Output: 456
+//
+// Clients handed to untrusted code can additionally be sandboxed. NewPrefixesKV
+// restricts requests to a set of allowed prefixes without translating keys,
+// and NewReadOnlyKV rejects all mutations client-side:
+//
+//	cli.KV = namespace.NewReadOnlyKV(namespace.NewPrefixesKV(unprefixedKV, "app1/", "app2/"))
+//	_, err := cli.Put(context.TODO(), "app1/abc", "123")
+//	// err == namespace.ErrReadOnly
+//	_, err = cli.Get(context.TODO(), "app3/abc")
+//	// err == namespace.ErrKeyNotAllowed
 package namespace
@@ -136,6 +136,9 @@ func (kv *kvPrefix) prefixOp(op clientv3.Op) clientv3.Op {
 		begin, end := kv.prefixInterval(op.KeyBytes(), op.RangeBytes())
 		op.WithKeyBytes(begin)
 		op.WithRangeBytes(end)
+		if from := op.GroupFromKey(); len(from) != 0 {
+			op.WithGroupFromKeyBytes(append([]byte(kv.pfx), from...))
+		}
 		return op
 	}
 	cmps, thenOps, elseOps := op.Txn()
@@ -146,6 +149,12 @@ func (kv *kvPrefix) unprefixGetResponse(resp *clientv3.GetResponse) {
 	for i := range resp.Kvs {
 		resp.Kvs[i].Key = resp.Kvs[i].Key[len(kv.pfx):]
 	}
+	for _, g := range resp.GroupCounts {
+		g.Prefix = g.Prefix[len(kv.pfx):]
+	}
+	if len(resp.NextGroupKey) != 0 {
+		resp.NextGroupKey = resp.NextGroupKey[len(kv.pfx):]
+	}
 }
 
 func (kv *kvPrefix) unprefixPutResponse(resp *clientv3.PutResponse) {
@@ -0,0 +1,246 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package namespace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+var (
+	// ErrReadOnly is returned when a mutation is issued through a read-only KV.
+	ErrReadOnly = errors.New("namespace: mutation on read-only KV")
+	// ErrKeyNotAllowed is returned when a request touches keys outside of the
+	// allowed prefixes.
+	ErrKeyNotAllowed = errors.New("namespace: key outside of allowed prefixes")
+)
+
+// The restricting wrappers hold the wrapped KV in a field rather than
+// embedding it, so that every request goes through their checks.
+
+type kvReadOnly struct {
+	kv clientv3.KV
+}
+
+// NewReadOnlyKV wraps a KV instance so that all mutations, including
+// transactions containing a mutation and compaction, are rejected with
+// ErrReadOnly before reaching the server.
+func NewReadOnlyKV(kv clientv3.KV) clientv3.KV {
+	return &kvReadOnly{kv}
+}
+
+func (kv *kvReadOnly) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	return kv.kv.Get(ctx, key, opts...)
+}
+
+func (kv *kvReadOnly) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	return nil, ErrReadOnly
+}
+
+func (kv *kvReadOnly) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	return nil, ErrReadOnly
+}
+
+func (kv *kvReadOnly) Compact(ctx context.Context, rev int64, opts ...clientv3.CompactOption) (*clientv3.CompactResponse, error) {
+	return nil, ErrReadOnly
+}
+
+func (kv *kvReadOnly) Do(ctx context.Context, op clientv3.Op) (clientv3.OpResponse, error) {
+	if isMutation(op) {
+		return clientv3.OpResponse{}, ErrReadOnly
+	}
+	return kv.kv.Do(ctx, op)
+}
+
+func (kv *kvReadOnly) Txn(ctx context.Context) clientv3.Txn {
+	return &txnRestrict{Txn: kv.kv.Txn(ctx), check: func(op clientv3.Op) error {
+		if isMutation(op) {
+			return ErrReadOnly
+		}
+		return nil
+	}}
+}
+
+func isMutation(op clientv3.Op) bool {
+	if !op.IsTxn() {
+		return op.IsPut() || op.IsDelete()
+	}
+	_, thenOps, elseOps := op.Txn()
+	for _, ops := range [][]clientv3.Op{thenOps, elseOps} {
+		for _, o := range ops {
+			if isMutation(o) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type kvPrefixes struct {
+	kv   clientv3.KV
+	pfxs []string
+}
+
+// NewPrefixesKV wraps a KV instance so that only requests whose keys and
+// ranges fall entirely within one of the given prefixes are permitted. Keys
+// are not translated; other requests fail with ErrKeyNotAllowed before
+// reaching the server. Compaction, which spans the whole keyspace, is
+// rejected as well. It may be composed with NewKV and NewReadOnlyKV.
+func NewPrefixesKV(kv clientv3.KV, prefixes ...string) clientv3.KV {
+	return &kvPrefixes{kv: kv, pfxs: prefixes}
+}
+
+func (kv *kvPrefixes) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	r, err := kv.Do(ctx, clientv3.OpPut(key, val, opts...))
+	if err != nil {
+		return nil, err
+	}
+	return r.Put(), nil
+}
+
+func (kv *kvPrefixes) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	r, err := kv.Do(ctx, clientv3.OpGet(key, opts...))
+	if err != nil {
+		return nil, err
+	}
+	return r.Get(), nil
+}
+
+func (kv *kvPrefixes) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	r, err := kv.Do(ctx, clientv3.OpDelete(key, opts...))
+	if err != nil {
+		return nil, err
+	}
+	return r.Del(), nil
+}
+
+func (kv *kvPrefixes) Compact(ctx context.Context, rev int64, opts ...clientv3.CompactOption) (*clientv3.CompactResponse, error) {
+	return nil, ErrKeyNotAllowed
+}
+
+func (kv *kvPrefixes) Do(ctx context.Context, op clientv3.Op) (clientv3.OpResponse, error) {
+	if err := kv.checkOp(op); err != nil {
+		return clientv3.OpResponse{}, err
+	}
+	return kv.kv.Do(ctx, op)
+}
+
+func (kv *kvPrefixes) Txn(ctx context.Context) clientv3.Txn {
+	return &txnRestrict{Txn: kv.kv.Txn(ctx), check: kv.checkOp, checkCmp: func(c clientv3.Cmp) error {
+		if !kv.allows(c.KeyBytes(), c.RangeEnd) {
+			return ErrKeyNotAllowed
+		}
+		return nil
+	}}
+}
+
+func (kv *kvPrefixes) checkOp(op clientv3.Op) error {
+	if !op.IsTxn() {
+		if !kv.allows(op.KeyBytes(), op.RangeBytes()) {
+			return ErrKeyNotAllowed
+		}
+		return nil
+	}
+	cmps, thenOps, elseOps := op.Txn()
+	for _, c := range cmps {
+		if !kv.allows(c.KeyBytes(), c.RangeEnd) {
+			return ErrKeyNotAllowed
+		}
+	}
+	for _, ops := range [][]clientv3.Op{thenOps, elseOps} {
+		for _, o := range ops {
+			if err := kv.checkOp(o); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// allows checks whether the interval [key, end) is contained in one of the
+// allowed prefixes.
+func (kv *kvPrefixes) allows(key, end []byte) bool {
+	for _, pfx := range kv.pfxs {
+		if !bytes.HasPrefix(key, []byte(pfx)) {
+			continue
+		}
+		if len(end) == 0 {
+			return true
+		}
+		pfxEnd := []byte(clientv3.GetPrefixRangeEnd(pfx))
+		if len(pfxEnd) == 1 && pfxEnd[0] == 0 {
+			// prefix extends to the edge of the keyspace
+			return true
+		}
+		if len(end) == 1 && end[0] == 0 {
+			continue
+		}
+		if bytes.Compare(end, pfxEnd) <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// txnRestrict defers any rejected comparison or operation to Commit so the
+// Txn builder chain stays usable.
+type txnRestrict struct {
+	clientv3.Txn
+	check    func(clientv3.Op) error
+	checkCmp func(clientv3.Cmp) error
+	err      error
+}
+
+func (txn *txnRestrict) If(cs ...clientv3.Cmp) clientv3.Txn {
+	if txn.checkCmp != nil {
+		for _, c := range cs {
+			if err := txn.checkCmp(c); err != nil && txn.err == nil {
+				txn.err = err
+			}
+		}
+	}
+	txn.Txn = txn.Txn.If(cs...)
+	return txn
+}
+
+func (txn *txnRestrict) Then(ops ...clientv3.Op) clientv3.Txn {
+	txn.checkOps(ops)
+	txn.Txn = txn.Txn.Then(ops...)
+	return txn
+}
+
+func (txn *txnRestrict) Else(ops ...clientv3.Op) clientv3.Txn {
+	txn.checkOps(ops)
+	txn.Txn = txn.Txn.Else(ops...)
+	return txn
+}
+
+func (txn *txnRestrict) Commit() (*clientv3.TxnResponse, error) {
+	if txn.err != nil {
+		return nil, txn.err
+	}
+	return txn.Txn.Commit()
+}
+
+func (txn *txnRestrict) checkOps(ops []clientv3.Op) {
+	for _, op := range ops {
+		if err := txn.check(op); err != nil && txn.err == nil {
+			txn.err = err
+		}
+	}
+}
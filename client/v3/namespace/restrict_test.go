@@ -0,0 +1,130 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package namespace
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+type fakeKV struct {
+	clientv3.KV
+	ops int
+}
+
+func (kv *fakeKV) Do(ctx context.Context, op clientv3.Op) (clientv3.OpResponse, error) {
+	kv.ops++
+	return clientv3.OpResponse{}, nil
+}
+
+func (kv *fakeKV) Txn(ctx context.Context) clientv3.Txn { return &fakeTxn{kv: kv} }
+
+type fakeTxn struct {
+	clientv3.Txn
+	kv *fakeKV
+}
+
+func (txn *fakeTxn) If(cs ...clientv3.Cmp) clientv3.Txn     { return txn }
+func (txn *fakeTxn) Then(ops ...clientv3.Op) clientv3.Txn   { return txn }
+func (txn *fakeTxn) Else(ops ...clientv3.Op) clientv3.Txn   { return txn }
+func (txn *fakeTxn) Commit() (*clientv3.TxnResponse, error) { txn.kv.ops++; return nil, nil }
+
+func TestReadOnlyKV(t *testing.T) {
+	tests := []struct {
+		op   clientv3.Op
+		wErr error
+	}{
+		{op: clientv3.OpGet("a")},
+		{op: clientv3.OpPut("a", "b"), wErr: ErrReadOnly},
+		{op: clientv3.OpDelete("a", clientv3.WithPrefix()), wErr: ErrReadOnly},
+		{op: clientv3.OpTxn(nil, []clientv3.Op{clientv3.OpGet("a")}, nil)},
+		{op: clientv3.OpTxn(nil, []clientv3.Op{clientv3.OpGet("a")}, []clientv3.Op{clientv3.OpPut("a", "b")}), wErr: ErrReadOnly},
+	}
+	for i, tt := range tests {
+		fkv := &fakeKV{}
+		if _, err := NewReadOnlyKV(fkv).Do(context.TODO(), tt.op); !errors.Is(err, tt.wErr) {
+			t.Errorf("#%d: expected error %v, got %v", i, tt.wErr, err)
+		}
+		if tt.wErr != nil && fkv.ops != 0 {
+			t.Errorf("#%d: expected request to be rejected client-side", i)
+		}
+	}
+
+	kv := NewReadOnlyKV(&fakeKV{})
+	if _, err := kv.Txn(context.TODO()).Then(clientv3.OpGet("a")).Else(clientv3.OpDelete("a")).Commit(); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected %v on mutating txn, got %v", ErrReadOnly, err)
+	}
+	if _, err := kv.Compact(context.TODO(), 1); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected %v on compact, got %v", ErrReadOnly, err)
+	}
+}
+
+func TestPrefixesKV(t *testing.T) {
+	tests := []struct {
+		pfxs []string
+		op   clientv3.Op
+		wErr error
+	}{
+		{pfxs: []string{"a/", "b/"}, op: clientv3.OpGet("a/x")},
+		{pfxs: []string{"a/", "b/"}, op: clientv3.OpPut("b/x", "v")},
+		{pfxs: []string{"a/", "b/"}, op: clientv3.OpGet("c/x"), wErr: ErrKeyNotAllowed},
+		{pfxs: []string{"a/", "b/"}, op: clientv3.OpGet("a/", clientv3.WithPrefix())},
+		{pfxs: []string{"a/", "b/"}, op: clientv3.OpGet("a/x", clientv3.WithRange("a/z"))},
+		// range spans two allowed prefixes and the gap between them
+		{pfxs: []string{"a/", "b/"}, op: clientv3.OpGet("a/x", clientv3.WithRange("b/z")), wErr: ErrKeyNotAllowed},
+		{pfxs: []string{"a/"}, op: clientv3.OpDelete("a/x", clientv3.WithFromKey()), wErr: ErrKeyNotAllowed},
+		{pfxs: []string{""}, op: clientv3.OpDelete("a/x", clientv3.WithFromKey())},
+		{
+			pfxs: []string{"a/"},
+			op: clientv3.OpTxn(
+				[]clientv3.Cmp{clientv3.Compare(clientv3.Version("b/x"), "=", 0)},
+				[]clientv3.Op{clientv3.OpGet("a/x")}, nil),
+			wErr: ErrKeyNotAllowed,
+		},
+		{
+			pfxs: []string{"a/"},
+			op:   clientv3.OpTxn(nil, []clientv3.Op{clientv3.OpGet("a/x")}, []clientv3.Op{clientv3.OpPut("c/x", "v")}),
+			wErr: ErrKeyNotAllowed,
+		},
+	}
+	for i, tt := range tests {
+		fkv := &fakeKV{}
+		if _, err := NewPrefixesKV(fkv, tt.pfxs...).Do(context.TODO(), tt.op); !errors.Is(err, tt.wErr) {
+			t.Errorf("#%d: expected error %v, got %v", i, tt.wErr, err)
+		}
+		if tt.wErr != nil && fkv.ops != 0 {
+			t.Errorf("#%d: expected request to be rejected client-side", i)
+		}
+	}
+
+	if _, err := NewPrefixesKV(&fakeKV{}, "a/").Compact(context.TODO(), 1); !errors.Is(err, ErrKeyNotAllowed) {
+		t.Errorf("expected %v on compact, got %v", ErrKeyNotAllowed, err)
+	}
+
+	kv := NewReadOnlyKV(NewPrefixesKV(&fakeKV{}, "a/"))
+	if _, err := kv.Get(context.TODO(), "b/x"); !errors.Is(err, ErrKeyNotAllowed) {
+		t.Errorf("expected %v, got %v", ErrKeyNotAllowed, err)
+	}
+	if _, err := kv.Put(context.TODO(), "a/x", "v"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected %v, got %v", ErrReadOnly, err)
+	}
+	txn := kv.Txn(context.TODO()).If(clientv3.Compare(clientv3.Value("b/x"), "=", "v")).Then(clientv3.OpGet("a/x"))
+	if _, err := txn.Commit(); !errors.Is(err, ErrKeyNotAllowed) {
+		t.Errorf("expected %v on txn, got %v", ErrKeyNotAllowed, err)
+	}
+}
@@ -14,7 +14,11 @@
 
 package clientv3
 
-import pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+import (
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
 
 type opType int
 
@@ -38,8 +42,12 @@ type Op struct {
 	limit        int64
 	sort         *SortOption
 	serializable bool
+	maxStaleness time.Duration
+	maxStaleRevs int64
 	keysOnly     bool
 	countOnly    bool
+	groupBy      []byte
+	groupFrom    []byte
 	minModRev    int64
 	maxModRev    int64
 	minCreateRev int64
@@ -63,6 +71,10 @@ type Op struct {
 
 	// progressNotify is for progress updates.
 	progressNotify bool
+	// progressNotifyInterval is the maximum interval between progress updates.
+	progressNotifyInterval time.Duration
+	// allowResync lets the server resynchronize the watcher if it's too slow.
+	allowResync bool
 	// createdNotify is for created event
 	createdNotify bool
 	// filters for watchers
@@ -124,6 +136,28 @@ func (op Op) IsKeysOnly() bool { return op.keysOnly }
 // IsCountOnly returns whether countOnly is set.
 func (op Op) IsCountOnly() bool { return op.countOnly }
 
+// GroupBySeparator returns the separator grouping the counted keys, if any.
+func (op Op) GroupBySeparator() []byte { return op.groupBy }
+
+// GroupFromKey returns the key the counted groups start from, if any.
+func (op Op) GroupFromKey() []byte { return op.groupFrom }
+
+// WithGroupFromKeyBytes sets the byte slice for the key the Op's counted
+// groups start from.
+func (op *Op) WithGroupFromKeyBytes(key []byte) { op.groupFrom = key }
+
+// IsPrevKV returns whether the previous key-value pairs are requested.
+func (op Op) IsPrevKV() bool { return op.prevKV }
+
+// IsIgnoreValue returns whether a put keeps the key's current value.
+func (op Op) IsIgnoreValue() bool { return op.ignoreValue }
+
+// IsIgnoreLease returns whether a put keeps the key's current lease.
+func (op Op) IsIgnoreLease() bool { return op.ignoreLease }
+
+// LeaseID returns the lease attached to the key by a put.
+func (op Op) LeaseID() LeaseID { return op.leaseID }
+
 func (op Op) IsOptsWithFromKey() bool { return op.isOptsWithFromKey }
 
 func (op Op) IsOptsWithPrefix() bool { return op.isOptsWithPrefix }
@@ -154,17 +188,21 @@ func (op Op) toRangeRequest() *pb.RangeRequest {
 		panic("op.t != tRange")
 	}
 	r := &pb.RangeRequest{
-		Key:               op.key,
-		RangeEnd:          op.end,
-		Limit:             op.limit,
-		Revision:          op.rev,
-		Serializable:      op.serializable,
-		KeysOnly:          op.keysOnly,
-		CountOnly:         op.countOnly,
-		MinModRevision:    op.minModRev,
-		MaxModRevision:    op.maxModRev,
-		MinCreateRevision: op.minCreateRev,
-		MaxCreateRevision: op.maxCreateRev,
+		Key:                   op.key,
+		RangeEnd:              op.end,
+		Limit:                 op.limit,
+		Revision:              op.rev,
+		Serializable:          op.serializable,
+		KeysOnly:              op.keysOnly,
+		CountOnly:             op.countOnly,
+		MinModRevision:        op.minModRev,
+		MaxModRevision:        op.maxModRev,
+		MinCreateRevision:     op.minCreateRev,
+		MaxCreateRevision:     op.maxCreateRev,
+		MaxStalenessMs:        op.maxStaleness.Milliseconds(),
+		MaxStalenessRevisions: op.maxStaleRevs,
+		GroupBySeparator:      op.groupBy,
+		GroupFromKey:          op.groupFrom,
 	}
 	if op.sort != nil {
 		r.SortOrder = pb.RangeRequest_SortOrder(op.sort.Order)
@@ -435,6 +473,25 @@ func WithSerializable() OpOption {
 	return func(op *Op) { op.serializable = true }
 }
 
+// WithMaxStaleness lets a linearizable `Get` request be served from the
+// local state of the contacted member, provided that state was confirmed
+// up to date with the leader no longer than d ago. Otherwise the request
+// falls back to a regular linearizable read. It has no effect on
+// serializable requests.
+func WithMaxStaleness(d time.Duration) OpOption {
+	return func(op *Op) { op.maxStaleness = d }
+}
+
+// WithMaxStalenessRevisions lets a linearizable `Get` request be served from
+// the local state of the contacted member, provided that member has a leader
+// and at most n committed revisions, as last reported by the leader, are yet
+// to be applied locally. Otherwise the request falls back to a regular
+// linearizable read. Combined with WithMaxStaleness, both bounds must hold.
+// It has no effect on serializable requests.
+func WithMaxStalenessRevisions(n int64) OpOption {
+	return func(op *Op) { op.maxStaleRevs = n }
+}
+
 // WithKeysOnly makes the 'Get' request return only the keys and the corresponding
 // values will be omitted.
 func WithKeysOnly() OpOption {
@@ -446,6 +503,23 @@ func WithCountOnly() OpOption {
 	return func(op *Op) { op.countOnly = true }
 }
 
+// WithGroupBySeparator makes the 'Get' request return only the count of keys,
+// along with the count of keys per group in GroupCounts. The keys of a group
+// share their prefix up to and including the first separator following the
+// requested key, e.g. the count of keys per namespace under "/registry/pods/"
+// with separator "/". WithLimit limits the number of groups returned; the
+// following groups are then fetched with WithGroupFromKey.
+func WithGroupBySeparator(sep string) OpOption {
+	return func(op *Op) { op.groupBy = []byte(sep) }
+}
+
+// WithGroupFromKey makes a 'Get' request with WithGroupBySeparator count the
+// keys from the given key on, in the groups of the whole range. Pass it the
+// NextGroupKey of a response with More set to get the following groups.
+func WithGroupFromKey(key string) OpOption {
+	return func(op *Op) { op.groupFrom = []byte(key) }
+}
+
 // WithMinModRev filters out keys for Get with modification revisions less than the given revision.
 func WithMinModRev(rev int64) OpOption { return func(op *Op) { op.minModRev = rev } }
 
@@ -490,6 +564,18 @@ func WithProgressNotify() OpOption {
 	}
 }
 
+// WithProgressNotifyInterval makes watch server send a progress update to
+// the watcher when no response was sent to it for the given interval, so
+// that the watcher is told at least every interval up to which revision it
+// has observed all events. The interval is raised to the server minimum.
+// Progress updates are surfaced as bookmarks, see WatchResponse.Bookmark.
+func WithProgressNotifyInterval(interval time.Duration) OpOption {
+	return func(op *Op) {
+		op.progressNotify = true
+		op.progressNotifyInterval = interval
+	}
+}
+
 // WithCreatedNotify makes watch server sends the created event.
 func WithCreatedNotify() OpOption {
 	return func(op *Op) {
@@ -515,6 +601,15 @@ func WithPrevKV() OpOption {
 	}
 }
 
+// WithAllowResync lets the server resynchronize the watcher instead of
+// canceling it if it's too slow to receive the events, under the "resync"
+// slow watcher policy: the events it missed are dropped and it is sent a
+// response with ResyncRevision set, after which it keeps receiving the
+// events. The caller must then get the watched keys at ResyncRevision.
+func WithAllowResync() OpOption {
+	return func(op *Op) { op.allowResync = true }
+}
+
 // WithFragment to receive raw watch response with fragmentation.
 // Fragmentation is disabled by default. If fragmentation is enabled,
 // etcd watch server will split watch response before sending to clients
@@ -550,6 +645,9 @@ type LeaseOp struct {
 
 	// for TimeToLive
 	attachedKeys bool
+
+	// for Grant
+	pinnedRev int64
 }
 
 // LeaseOption configures lease operations.
@@ -566,6 +664,20 @@ func WithAttachedKeys() LeaseOption {
 	return func(op *LeaseOp) { op.attachedKeys = true }
 }
 
+// WithPinnedRevision makes Grant pin the given revision against compaction
+// until the lease is revoked or expires, so that the keyspace can be read at
+// that revision meanwhile. The current revision is pinned if rev is negative;
+// it is returned in LeaseGrantResponse.PinnedRevision.
+func WithPinnedRevision(rev int64) LeaseOption {
+	return func(op *LeaseOp) { op.pinnedRev = rev }
+}
+
+func toLeaseGrantRequest(ttl int64, opts ...LeaseOption) *pb.LeaseGrantRequest {
+	ret := &LeaseOp{}
+	ret.applyOpts(opts)
+	return &pb.LeaseGrantRequest{TTL: ttl, PinnedRevision: ret.pinnedRev}
+}
+
 func toLeaseTimeToLiveRequest(id LeaseID, opts ...LeaseOption) *pb.LeaseTimeToLiveRequest {
 	ret := &LeaseOp{id: id}
 	ret.applyOpts(opts)
@@ -37,5 +37,16 @@
 //	}
 //	cli.KV = ordering.NewKV(cli.KV, vf)
 //
-// Now calls using 'cli' will reject order violations with an error.
+// Now calls using 'cli' will reject order violations with an error. Get and
+// Txn requests, including those issued through Do, are checked.
+//
+// Watch responses can be checked against the same revision history:
+//
+//	wvf := func(key string, wr clientv3.WatchResponse, prevRev int64) error {
+//		return fmt.Errorf("ordering: watch on %q got rev=%v, expected rev=%v", key, wr.Header.Revision, prevRev)
+//	}
+//	cli.Watcher = ordering.NewWatcher(cli.Watcher, cli.KV, wvf)
+//
+// A watch whose violation func returns an error is canceled and its channel
+// is closed.
 package ordering
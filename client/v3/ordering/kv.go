@@ -50,27 +50,43 @@ func (kv *kvOrdering) setPrevRev(currRev int64) {
 }
 
 func (kv *kvOrdering) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	r, err := kv.Do(ctx, clientv3.OpGet(key, opts...))
+	if err != nil {
+		return nil, err
+	}
+	return r.Get(), nil
+}
+
+// Do ensures that Get and Txn operations issued through Do are subject to the
+// same ordering guarantees as Get and Txn.
+func (kv *kvOrdering) Do(ctx context.Context, op clientv3.Op) (clientv3.OpResponse, error) {
+	if !op.IsGet() && !op.IsTxn() {
+		r, err := kv.KV.Do(ctx, op)
+		if err == nil {
+			kv.setPrevRev(opResponseRev(r))
+		}
+		return r, err
+	}
 	// prevRev is stored in a local variable in order to record the prevRev
-	// at the beginning of the Get operation, because concurrent
+	// at the beginning of the Do operation, because concurrent
 	// access to kvOrdering could change the prevRev field in the
-	// middle of the Get operation.
+	// middle of the Do operation.
 	prevRev := kv.getPrevRev()
-	op := clientv3.OpGet(key, opts...)
 	for {
 		r, err := kv.KV.Do(ctx, op)
 		if err != nil {
-			return nil, err
+			return r, err
 		}
-		resp := r.Get()
-		if resp.Header.Revision == prevRev {
-			return resp, nil
-		} else if resp.Header.Revision > prevRev {
-			kv.setPrevRev(resp.Header.Revision)
-			return resp, nil
+		rev := opResponseRev(r)
+		if rev == prevRev {
+			return r, nil
+		} else if rev > prevRev {
+			kv.setPrevRev(rev)
+			return r, nil
 		}
 		err = kv.orderViolationFunc(op, r, prevRev)
 		if err != nil {
-			return nil, err
+			return clientv3.OpResponse{}, err
 		}
 	}
 }
@@ -125,25 +141,25 @@ func (txn *txnOrdering) Else(ops ...clientv3.Op) clientv3.Txn {
 }
 
 func (txn *txnOrdering) Commit() (*clientv3.TxnResponse, error) {
-	// prevRev is stored in a local variable in order to record the prevRev
-	// at the beginning of the Commit operation, because concurrent
-	// access to txnOrdering could change the prevRev field in the
-	// middle of the Commit operation.
-	prevRev := txn.getPrevRev()
 	opTxn := clientv3.OpTxn(txn.cmps, txn.thenOps, txn.elseOps)
-	for {
-		opResp, err := txn.KV.Do(txn.ctx, opTxn)
-		if err != nil {
-			return nil, err
-		}
-		txnResp := opResp.Txn()
-		if txnResp.Header.Revision >= prevRev {
-			txn.setPrevRev(txnResp.Header.Revision)
-			return txnResp, nil
-		}
-		err = txn.orderViolationFunc(opTxn, opResp, prevRev)
-		if err != nil {
-			return nil, err
-		}
+	opResp, err := txn.kvOrdering.Do(txn.ctx, opTxn)
+	if err != nil {
+		return nil, err
+	}
+	return opResp.Txn(), nil
+}
+
+// opResponseRev returns the header revision of the response to an operation.
+func opResponseRev(r clientv3.OpResponse) int64 {
+	switch {
+	case r.Get() != nil:
+		return r.Get().Header.Revision
+	case r.Put() != nil:
+		return r.Put().Header.Revision
+	case r.Del() != nil:
+		return r.Del().Header.Revision
+	case r.Txn() != nil:
+		return r.Txn().Header.Revision
 	}
+	return 0
 }
@@ -0,0 +1,97 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ordering
+
+import (
+	"context"
+	"sync"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// WatchOrderViolationFunc is called when a watch response on key carries a
+// header revision less than the previously returned revision. If it returns
+// nil the response is delivered; otherwise the watch is canceled and its
+// channel is closed.
+type WatchOrderViolationFunc func(key string, wr clientv3.WatchResponse, prevRev int64) error
+
+// watcherOrdering ensures that watch responses do not carry
+// header revisions less than the previous returned revision.
+type watcherOrdering struct {
+	clientv3.Watcher
+	revs                    *kvOrdering
+	watchOrderViolationFunc WatchOrderViolationFunc
+
+	wg       sync.WaitGroup
+	stopc    chan struct{}
+	stopOnce sync.Once
+}
+
+// NewWatcher wraps a Watcher instance so that stale watch responses are
+// reported to the given violation func. If kv was returned by NewKV, the
+// previous revision is shared with it so reads and watches are ordered
+// with respect to each other.
+func NewWatcher(w clientv3.Watcher, kv clientv3.KV, watchOrderViolationFunc WatchOrderViolationFunc) clientv3.Watcher {
+	revs, ok := kv.(*kvOrdering)
+	if !ok {
+		revs = &kvOrdering{}
+	}
+	return &watcherOrdering{
+		Watcher:                 w,
+		revs:                    revs,
+		watchOrderViolationFunc: watchOrderViolationFunc,
+		stopc:                   make(chan struct{}),
+	}
+}
+
+func (w *watcherOrdering) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	cctx, cancel := context.WithCancel(ctx)
+	wch := w.Watcher.Watch(cctx, key, opts...)
+
+	orderedWch := make(chan clientv3.WatchResponse)
+	w.wg.Add(1)
+	go func() {
+		defer func() {
+			cancel()
+			close(orderedWch)
+			w.wg.Done()
+		}()
+		for wr := range wch {
+			prevRev := w.revs.getPrevRev()
+			if rev := wr.Header.Revision; rev != 0 && rev < prevRev {
+				if err := w.watchOrderViolationFunc(key, wr, prevRev); err != nil {
+					return
+				}
+			} else {
+				w.revs.setPrevRev(rev)
+			}
+			select {
+			case orderedWch <- wr:
+			case <-ctx.Done():
+				return
+			case <-w.stopc:
+				return
+			}
+		}
+	}()
+	return orderedWch
+}
+
+func (w *watcherOrdering) Close() error {
+	err := w.Watcher.Close()
+	w.stopOnce.Do(func() { close(w.stopc) })
+	w.wg.Wait()
+	return err
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ordering
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+type mockWatcher struct {
+	clientv3.Watcher
+	revs []int64
+}
+
+func (w *mockWatcher) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	wch := make(chan clientv3.WatchResponse, len(w.revs))
+	for _, rev := range w.revs {
+		wch <- clientv3.WatchResponse{Header: pb.ResponseHeader{Revision: rev}}
+	}
+	close(wch)
+	return wch
+}
+
+func (w *mockWatcher) Close() error { return nil }
+
+func TestWatcherOrdering(t *testing.T) {
+	errStale := errors.New("stale")
+	tests := []struct {
+		prevRev int64
+		revs    []int64
+		vf      WatchOrderViolationFunc
+
+		wRevs       []int64
+		wViolations int
+		wPrevRev    int64
+	}{
+		{
+			prevRev:  5,
+			revs:     []int64{5, 6, 7},
+			wRevs:    []int64{5, 6, 7},
+			wPrevRev: 7,
+		},
+		{
+			// stale responses are delivered when the violation func allows it
+			prevRev:     5,
+			revs:        []int64{4, 6},
+			wRevs:       []int64{4, 6},
+			wViolations: 1,
+			wPrevRev:    6,
+		},
+		{
+			// stale responses cancel the watch when the violation func fails
+			prevRev:     5,
+			revs:        []int64{6, 4, 7},
+			vf:          func(string, clientv3.WatchResponse, int64) error { return errStale },
+			wRevs:       []int64{6},
+			wViolations: 1,
+			wPrevRev:    6,
+		},
+	}
+	for i, tt := range tests {
+		kv := &kvOrdering{prevRev: tt.prevRev}
+		violations := 0
+		vf := func(key string, wr clientv3.WatchResponse, prevRev int64) error {
+			violations++
+			if tt.vf != nil {
+				return tt.vf(key, wr, prevRev)
+			}
+			return nil
+		}
+		w := NewWatcher(&mockWatcher{revs: tt.revs}, kv, vf)
+
+		var revs []int64
+		for wr := range w.Watch(context.TODO(), "foo") {
+			revs = append(revs, wr.Header.Revision)
+		}
+		w.Close()
+
+		if len(revs) != len(tt.wRevs) {
+			t.Fatalf("#%d: expected revisions %v, got %v", i, tt.wRevs, revs)
+		}
+		for j := range revs {
+			if revs[j] != tt.wRevs[j] {
+				t.Errorf("#%d: expected revisions %v, got %v", i, tt.wRevs, revs)
+			}
+		}
+		if violations != tt.wViolations {
+			t.Errorf("#%d: expected %d violations, got %d", i, tt.wViolations, violations)
+		}
+		if rev := kv.getPrevRev(); rev != tt.wPrevRev {
+			t.Errorf("#%d: expected shared prevRev %d, got %d", i, tt.wPrevRev, rev)
+		}
+	}
+}
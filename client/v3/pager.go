@@ -0,0 +1,162 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"errors"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+const (
+	// DefaultListPageSize is the default number of keys fetched per Range
+	// request by a ListPager.
+	DefaultListPageSize = 500
+	// DefaultListPinTTL is the default TTL in seconds of the lease pinning
+	// the revision paged by a ListPager.
+	DefaultListPinTTL = 60
+)
+
+// ErrListPagerOption is returned by ListPager when the Get options cannot be
+// paged: the keys must be sorted in ascending order, and neither the limit
+// nor the count only options can be set.
+var ErrListPagerOption = errors.New("etcdclient: unsupported option for paged list")
+
+// ListPager pages through a range of keys with Range requests of at most
+// PageSize keys each, all at the same revision, so that the keys are seen as
+// they were at a single point in time.
+type ListPager struct {
+	// PageSize is the number of keys fetched per Range request.
+	PageSize int64
+	// Lease, if set, is used to pin the revision against compaction while the
+	// range is paged, so that paging a large range does not race compaction.
+	// The range is paged unpinned if the revision cannot be pinned, e.g. by
+	// servers not supporting it.
+	Lease Lease
+	// PinTTL is the TTL in seconds of the lease pinning the revision, which
+	// is kept alive while the range is paged.
+	PinTTL int64
+
+	kv KV
+}
+
+// NewListPager returns a ListPager paging through the keys with the given KV.
+func NewListPager(kv KV) *ListPager {
+	return &ListPager{PageSize: DefaultListPageSize, PinTTL: DefaultListPinTTL, kv: kv}
+}
+
+// EachPage calls fn with the response to each Range request paging through
+// the keys selected by the key and the Get options, e.g. WithPrefix. The
+// keys are read at the revision given by WithRev, or at the revision of the
+// first response if not set, which is returned. Paging stops at the first
+// error returned by fn, which is returned.
+func (p *ListPager) EachPage(ctx context.Context, key string, fn func(*GetResponse) error, opts ...OpOption) (int64, error) {
+	op := OpGet(key, opts...)
+	if op.limit != 0 || op.countOnly || (op.sort != nil && (op.sort.Target != SortByKey || op.sort.Order == SortDescend)) {
+		return 0, ErrListPagerOption
+	}
+	pageSize := p.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultListPageSize
+	}
+	op.limit = pageSize
+
+	if p.Lease != nil {
+		if pinned, release := p.pin(ctx, op.rev); pinned != 0 {
+			defer release()
+			op.rev = pinned
+		}
+	}
+
+	for {
+		resp, err := p.kv.Do(ctx, op)
+		if err != nil {
+			return op.rev, err
+		}
+		gresp := resp.Get()
+		if op.rev == 0 {
+			op.rev = gresp.Header.Revision
+		}
+		if err = fn(gresp); err != nil {
+			return op.rev, err
+		}
+		if !gresp.More || len(gresp.Kvs) == 0 || len(op.end) == 0 {
+			return op.rev, nil
+		}
+		// continue after the last key
+		op.key = append(append([]byte{}, gresp.Kvs[len(gresp.Kvs)-1].Key...), 0)
+	}
+}
+
+// EachListItem is like EachPage, calling fn with each key-value pair.
+func (p *ListPager) EachListItem(ctx context.Context, key string, fn func(*mvccpb.KeyValue) error, opts ...OpOption) (int64, error) {
+	return p.EachPage(ctx, key, func(resp *GetResponse) error {
+		for _, kv := range resp.Kvs {
+			if err := fn(kv); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, opts...)
+}
+
+// GetAll returns all the key-value pairs selected by the key and the Get
+// options, fetched page by page at the returned revision.
+func (p *ListPager) GetAll(ctx context.Context, key string, opts ...OpOption) ([]*mvccpb.KeyValue, int64, error) {
+	var kvs []*mvccpb.KeyValue
+	rev, err := p.EachPage(ctx, key, func(resp *GetResponse) error {
+		kvs = append(kvs, resp.Kvs...)
+		return nil
+	}, opts...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return kvs, rev, nil
+}
+
+// pin pins the revision, or the current one if rev is 0, with a lease kept
+// alive until release is called. It returns 0 if the revision cannot be
+// pinned.
+func (p *ListPager) pin(ctx context.Context, rev int64) (int64, func()) {
+	pinRev := rev
+	if pinRev == 0 {
+		pinRev = -1
+	}
+	ttl := p.PinTTL
+	if ttl <= 0 {
+		ttl = DefaultListPinTTL
+	}
+	resp, err := p.Lease.Grant(ctx, ttl, WithPinnedRevision(pinRev))
+	if err != nil {
+		return 0, nil
+	}
+	if resp.PinnedRevision == 0 {
+		p.Lease.Revoke(ctx, resp.ID)
+		return 0, nil
+	}
+
+	kctx, kcancel := context.WithCancel(ctx)
+	if kch, kerr := p.Lease.KeepAlive(kctx, resp.ID); kerr == nil {
+		go func() {
+			for range kch {
+			}
+		}()
+	}
+	return resp.PinnedRevision, func() {
+		kcancel()
+		p.Lease.Revoke(ctx, resp.ID)
+	}
+}
@@ -210,6 +210,14 @@ func (rmc *retryMaintenanceClient) HashKV(ctx context.Context, in *pb.HashKVRequ
 	return rmc.mc.HashKV(ctx, in, append(opts, withRepeatablePolicy())...)
 }
 
+func (rmc *retryMaintenanceClient) HashKVRange(ctx context.Context, in *pb.HashKVRangeRequest, opts ...grpc.CallOption) (resp *pb.HashKVRangeResponse, err error) {
+	return rmc.mc.HashKVRange(ctx, in, append(opts, withRepeatablePolicy())...)
+}
+
+func (rmc *retryMaintenanceClient) HotKeys(ctx context.Context, in *pb.HotKeysRequest, opts ...grpc.CallOption) (resp *pb.HotKeysResponse, err error) {
+	return rmc.mc.HotKeys(ctx, in, append(opts, withRepeatablePolicy())...)
+}
+
 func (rmc *retryMaintenanceClient) Snapshot(ctx context.Context, in *pb.SnapshotRequest, opts ...grpc.CallOption) (stream pb.Maintenance_SnapshotClient, err error) {
 	return rmc.mc.Snapshot(ctx, in, append(opts, withRepeatablePolicy())...)
 }
@@ -226,6 +234,18 @@ func (rmc *retryMaintenanceClient) Downgrade(ctx context.Context, in *pb.Downgra
 	return rmc.mc.Downgrade(ctx, in, opts...)
 }
 
+func (rmc *retryMaintenanceClient) Drain(ctx context.Context, in *pb.DrainRequest, opts ...grpc.CallOption) (resp *pb.DrainResponse, err error) {
+	return rmc.mc.Drain(ctx, in, append(opts, withRepeatablePolicy())...)
+}
+
+func (rmc *retryMaintenanceClient) RotateEncryptionKey(ctx context.Context, in *pb.RotateEncryptionKeyRequest, opts ...grpc.CallOption) (resp *pb.RotateEncryptionKeyResponse, err error) {
+	return rmc.mc.RotateEncryptionKey(ctx, in, opts...)
+}
+
+func (rmc *retryMaintenanceClient) ClusterEvents(ctx context.Context, in *pb.ClusterEventsRequest, opts ...grpc.CallOption) (stream pb.Maintenance_ClusterEventsClient, err error) {
+	return rmc.mc.ClusterEvents(ctx, in, append(opts, withRepeatablePolicy())...)
+}
+
 type retryAuthClient struct {
 	ac pb.AuthClient
 }
@@ -253,6 +273,10 @@ func (rac *retryAuthClient) RoleList(ctx context.Context, in *pb.AuthRoleListReq
 	return rac.ac.RoleList(ctx, in, append(opts, withRepeatablePolicy())...)
 }
 
+func (rac *retryAuthClient) CheckPermission(ctx context.Context, in *pb.AuthCheckPermissionRequest, opts ...grpc.CallOption) (resp *pb.AuthCheckPermissionResponse, err error) {
+	return rac.ac.CheckPermission(ctx, in, append(opts, withRepeatablePolicy())...)
+}
+
 func (rac *retryAuthClient) AuthEnable(ctx context.Context, in *pb.AuthEnableRequest, opts ...grpc.CallOption) (resp *pb.AuthEnableResponse, err error) {
 	return rac.ac.AuthEnable(ctx, in, opts...)
 }
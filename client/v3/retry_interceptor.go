@@ -41,6 +41,13 @@ func (c *Client) unaryClientInterceptor(optFuncs ...retryOption) grpc.UnaryClien
 	intOpts := reuseOrNewWithCallOptions(defaultOptions, optFuncs)
 	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
 		ctx = withVersion(ctx)
+		if timeout, ok := requestTimeoutFromContext(ctx); ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		ctx = c.withEndpointAffinity(ctx)
+		ctx = withBalancerPriority(ctx)
 		grpcOpts, retryOpts := filterCallOptions(opts)
 		callOpts := reuseOrNewWithCallOptions(intOpts, retryOpts)
 		// short circuit for simplicity, and avoiding allocations.
@@ -109,6 +116,8 @@ func (c *Client) streamClientInterceptor(optFuncs ...retryOption) grpc.StreamCli
 	intOpts := reuseOrNewWithCallOptions(defaultOptions, optFuncs)
 	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
 		ctx = withVersion(ctx)
+		ctx = c.withEndpointAffinity(ctx)
+		ctx = withBalancerPriority(ctx)
 		// getToken automatically. Otherwise, auth token may be invalid after watch reconnection because the token has expired
 		// (see https://github.com/etcd-io/etcd/issues/11954 for more).
 		err := c.getToken(ctx)
@@ -149,7 +158,7 @@ func (c *Client) shouldRefreshToken(err error, callOpts *options) bool {
 	if rpctypes.Error(err) == rpctypes.ErrUserEmpty {
 		// refresh the token when username, password is present but the server returns ErrUserEmpty
 		// which is possible when the client token is cleared somehow
-		return c.authTokenBundle != nil // equal to c.Username != "" && c.Password != ""
+		return c.authTokenBundle != nil // credentials or a credentials provider are set
 	}
 
 	return callOpts.retryAuth &&
@@ -159,7 +168,8 @@ func (c *Client) shouldRefreshToken(err error, callOpts *options) bool {
 func (c *Client) refreshToken(ctx context.Context) error {
 	if c.authTokenBundle == nil {
 		// c.authTokenBundle will be initialized only when
-		// c.Username != "" && c.Password != "".
+		// c.Username != "" && c.Password != "", or a credentials
+		// provider is set.
 		//
 		// When users use the TLS CommonName based authentication, the
 		// authTokenBundle is always nil. But it's possible for the clients
@@ -305,6 +315,9 @@ func waitRetryBackoff(ctx context.Context, attempt uint, callOpts *options) erro
 	waitTime := time.Duration(0)
 	if attempt > 0 {
 		waitTime = callOpts.backoffFunc(attempt)
+		if priorityFromContext(ctx) == PriorityLow {
+			waitTime *= 2
+		}
 	}
 	if waitTime > 0 {
 		timer := time.NewTimer(waitTime)
@@ -329,8 +342,9 @@ func isSafeRetry(c *Client, err error, callOpts *options) bool {
 	// But for backward-compatibility reasons we need  to support situation that
 	// customer provides mix of learners (not yet voters) and voters with an
 	// expectation to pick voter in the next attempt.
+	// The same holds for a witness, which serves no client requests at all.
 	// TODO: Ideally client should be 'aware' which endpoint represents: leader/voter/learner with high probability.
-	if errors.Is(err, rpctypes.ErrGRPCNotSupportedForLearner) && len(c.Endpoints()) > 1 {
+	if (errors.Is(err, rpctypes.ErrGRPCNotSupportedForLearner) || errors.Is(err, rpctypes.ErrGRPCNotSupportedForWitness)) && len(c.Endpoints()) > 1 {
 		return true
 	}
 
@@ -0,0 +1,341 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/client/pkg/v3/fileutil"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// An incremental snapshot holds the changes of the keyspace between two
+// revisions, as read from the watch history of the server, and the leases
+// alive once it was taken. It is laid out as:
+//
+//	magic | from revision | revision | record... | sha256
+//
+// where the revisions are big-endian int64s, each record is a type byte, an
+// uvarint length and a payload, and the sha256 covers all the bytes before it.
+const incrementMagic = "etcdinc1"
+
+const (
+	recordEvent byte = 'e'
+	recordLease byte = 'l'
+)
+
+// maxIncrementRecordSize bounds the size of a record, well above the request
+// size limit of the server, so that a corrupted length fails fast.
+const maxIncrementRecordSize = 64 * 1024 * 1024
+
+// Increment is an incremental snapshot.
+type Increment struct {
+	// FromRevision is the revision of the snapshot the increment applies to.
+	FromRevision int64
+	// Revision is the revision of the keyspace once the increment is applied.
+	Revision int64
+	// Events are the changes of the revisions (FromRevision, Revision], in
+	// revision order.
+	Events []*mvccpb.Event
+	// Leases are the leases granted on the server when the increment was
+	// taken.
+	Leases []IncrementLease
+}
+
+// IncrementLease is a lease of an incremental snapshot.
+type IncrementLease struct {
+	ID  int64
+	TTL int64
+}
+
+// SaveIncremental fetches the changes made on the remote etcd server after
+// revision "fromRev", typically the revision of a previous full or incremental
+// snapshot, saves them to target path and returns the revision they reach.
+// The changes are read from the watch history, so "fromRev" must not have
+// been compacted on the server. As for SaveWithVersion, make sure to specify
+// only one endpoint in client configuration.
+func SaveIncremental(ctx context.Context, lg *zap.Logger, cfg clientv3.Config, fromRev int64, path string) (int64, error) {
+	cfg.Logger = lg.Named("client")
+	if len(cfg.Endpoints) != 1 {
+		return 0, fmt.Errorf("snapshot must be requested to one selected node, not multiple %v", cfg.Endpoints)
+	}
+	if fromRev < 1 {
+		return 0, fmt.Errorf("invalid revision %d to save an incremental snapshot from", fromRev)
+	}
+	cli, err := clientv3.New(cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer cli.Close()
+
+	sresp, err := cli.Status(ctx, cfg.Endpoints[0])
+	if err != nil {
+		return 0, err
+	}
+	rev := sresp.Header.Revision
+	if fromRev > rev {
+		return 0, fmt.Errorf("revision %d to save an incremental snapshot from is ahead of the server revision %d", fromRev, rev)
+	}
+
+	partpath := path + ".part"
+	defer os.RemoveAll(partpath)
+
+	f, err := os.OpenFile(partpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileutil.PrivateFileMode)
+	if err != nil {
+		return 0, fmt.Errorf("could not open %s (%v)", partpath, err)
+	}
+	defer f.Close()
+	lg.Info("created temporary increment file", zap.String("path", partpath))
+
+	start := time.Now()
+	iw := newIncrementWriter(f)
+	if err = iw.writeHeader(fromRev, rev); err != nil {
+		return 0, err
+	}
+	events, err := fetchEvents(ctx, cli, fromRev, rev, iw)
+	if err != nil {
+		return 0, err
+	}
+	leases, err := fetchLeases(ctx, cli, iw)
+	if err != nil {
+		return 0, err
+	}
+	if err = iw.close(); err != nil {
+		return 0, err
+	}
+	if err = fileutil.Fsync(f); err != nil {
+		return 0, err
+	}
+	if err = f.Close(); err != nil {
+		return 0, err
+	}
+	lg.Info("fetched incremental snapshot",
+		zap.String("endpoint", cfg.Endpoints[0]),
+		zap.Int64("from-revision", fromRev),
+		zap.Int64("revision", rev),
+		zap.Int("events", events),
+		zap.Int("leases", leases),
+		zap.String("size", humanize.Bytes(uint64(iw.size))),
+		zap.Duration("took", time.Since(start)),
+	)
+
+	if err = os.Rename(partpath, path); err != nil {
+		return 0, fmt.Errorf("could not rename %s to %s (%v)", partpath, path, err)
+	}
+	lg.Info("saved", zap.String("path", path))
+	return rev, nil
+}
+
+// fetchEvents writes the events of the revisions (fromRev, rev] and returns
+// how many there are.
+func fetchEvents(ctx context.Context, cli *clientv3.Client, fromRev, rev int64, iw *incrementWriter) (int, error) {
+	if fromRev == rev {
+		return 0, nil
+	}
+	ctx, cancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+	defer cancel()
+
+	n := 0
+	wch := cli.Watch(ctx, "", clientv3.WithPrefix(), clientv3.WithRev(fromRev+1))
+	for wresp := range wch {
+		if wresp.CompactRevision != 0 {
+			return n, fmt.Errorf("revision %d is compacted on the server (compacted revision %d), save a full snapshot instead", fromRev, wresp.CompactRevision)
+		}
+		if err := wresp.Err(); err != nil {
+			return n, err
+		}
+		// the server sends all the events of a revision in the same response,
+		// so the events up to rev are all written once one of rev or after it
+		// is received.
+		done := false
+		for _, ev := range wresp.Events {
+			if ev.Kv.ModRevision > rev {
+				done = true
+				break
+			}
+			if err := iw.writeEvent((*mvccpb.Event)(ev)); err != nil {
+				return n, err
+			}
+			n++
+			done = ev.Kv.ModRevision == rev
+		}
+		if done {
+			return n, nil
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return n, err
+	}
+	return n, errors.New("watch closed before reaching the revision of the incremental snapshot")
+}
+
+// fetchLeases writes the leases granted on the server and returns how many
+// there are.
+func fetchLeases(ctx context.Context, cli *clientv3.Client, iw *incrementWriter) (int, error) {
+	lresp, err := cli.Leases(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, l := range lresp.Leases {
+		tresp, err := cli.TimeToLive(ctx, l.ID)
+		if err != nil {
+			return n, err
+		}
+		// revoked or expired since listed
+		if tresp.TTL == -1 {
+			continue
+		}
+		if err = iw.writeLease(IncrementLease{ID: int64(l.ID), TTL: tresp.GrantedTTL}); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+type incrementWriter struct {
+	w    *bufio.Writer
+	h    hash.Hash
+	buf  [binary.MaxVarintLen64]byte
+	size int64
+}
+
+func newIncrementWriter(w io.Writer) *incrementWriter {
+	h := sha256.New()
+	return &incrementWriter{w: bufio.NewWriter(io.MultiWriter(w, h)), h: h}
+}
+
+func (iw *incrementWriter) write(b []byte) error {
+	n, err := iw.w.Write(b)
+	iw.size += int64(n)
+	return err
+}
+
+func (iw *incrementWriter) writeHeader(fromRev, rev int64) error {
+	var b [len(incrementMagic) + 16]byte
+	copy(b[:], incrementMagic)
+	binary.BigEndian.PutUint64(b[len(incrementMagic):], uint64(fromRev))
+	binary.BigEndian.PutUint64(b[len(incrementMagic)+8:], uint64(rev))
+	return iw.write(b[:])
+}
+
+func (iw *incrementWriter) writeRecord(typ byte, data []byte) error {
+	iw.buf[0] = typ
+	if err := iw.write(iw.buf[:1]); err != nil {
+		return err
+	}
+	n := binary.PutUvarint(iw.buf[:], uint64(len(data)))
+	if err := iw.write(iw.buf[:n]); err != nil {
+		return err
+	}
+	return iw.write(data)
+}
+
+func (iw *incrementWriter) writeEvent(ev *mvccpb.Event) error {
+	data, err := ev.Marshal()
+	if err != nil {
+		return err
+	}
+	return iw.writeRecord(recordEvent, data)
+}
+
+func (iw *incrementWriter) writeLease(l IncrementLease) error {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:], uint64(l.ID))
+	binary.BigEndian.PutUint64(b[8:], uint64(l.TTL))
+	return iw.writeRecord(recordLease, b[:])
+}
+
+// close appends the sha256 of the increment and flushes it.
+func (iw *incrementWriter) close() error {
+	if err := iw.w.Flush(); err != nil {
+		return err
+	}
+	sum := iw.h.Sum(nil)
+	if err := iw.write(sum); err != nil {
+		return err
+	}
+	return iw.w.Flush()
+}
+
+// ReadIncrement reads an incremental snapshot saved by SaveIncremental and
+// verifies its integrity.
+func ReadIncrement(r io.Reader) (*Increment, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < len(incrementMagic)+16+sha256.Size || !bytes.HasPrefix(data, []byte(incrementMagic)) {
+		return nil, errors.New("not an incremental snapshot")
+	}
+	body, sum := data[:len(data)-sha256.Size], data[len(data)-sha256.Size:]
+	if dsum := sha256.Sum256(body); !bytes.Equal(sum, dsum[:]) {
+		return nil, fmt.Errorf("expected sha256 %x, got %x", sum, dsum)
+	}
+
+	body = body[len(incrementMagic):]
+	inc := &Increment{
+		FromRevision: int64(binary.BigEndian.Uint64(body)),
+		Revision:     int64(binary.BigEndian.Uint64(body[8:])),
+	}
+	body = body[16:]
+	for len(body) > 0 {
+		typ := body[0]
+		size, n := binary.Uvarint(body[1:])
+		if n <= 0 || size > maxIncrementRecordSize || uint64(len(body)-1-n) < size {
+			return nil, errors.New("corrupted incremental snapshot record")
+		}
+		rec := body[1+n : 1+n+int(size)]
+		body = body[1+n+int(size):]
+
+		switch typ {
+		case recordEvent:
+			ev := &mvccpb.Event{}
+			if err = ev.Unmarshal(rec); err != nil {
+				return nil, fmt.Errorf("cannot unmarshal incremental snapshot event: %w", err)
+			}
+			if ev.Kv == nil || ev.Kv.ModRevision <= inc.FromRevision || ev.Kv.ModRevision > inc.Revision {
+				return nil, errors.New("incremental snapshot event out of its revision range")
+			}
+			inc.Events = append(inc.Events, ev)
+		case recordLease:
+			if len(rec) != 16 {
+				return nil, errors.New("corrupted incremental snapshot lease")
+			}
+			inc.Leases = append(inc.Leases, IncrementLease{
+				ID:  int64(binary.BigEndian.Uint64(rec)),
+				TTL: int64(binary.BigEndian.Uint64(rec[8:])),
+			})
+		default:
+			return nil, fmt.Errorf("unknown incremental snapshot record type %q", typ)
+		}
+	}
+	return inc, nil
+}
@@ -95,6 +95,18 @@ type WatchResponse struct {
 	// CompactRevision is the minimum revision the watcher may receive.
 	CompactRevision int64
 
+	// ResyncRevision is set with CompactRevision to the smallest revision the
+	// watched keys can be resynchronized from: get them at ResyncRevision,
+	// then watch them again from ResyncRevision + 1. It's the compaction
+	// revision of the store when the watcher was canceled, or CompactRevision
+	// if the server does not report it.
+	//
+	// It's also set alone, for the watchers created WithAllowResync, when the
+	// server dropped the events the watcher was too slow to receive: the
+	// watched keys need to be resynchronized from ResyncRevision, after which
+	// the watcher keeps receiving the events.
+	ResyncRevision int64
+
 	// Canceled is used to indicate watch failure.
 	// If the watch failed and the stream was about to close, before the channel is closed,
 	// the channel sends a final response that has Canceled set to true with a non-nil Err().
@@ -137,7 +149,25 @@ func (wr *WatchResponse) Err() error {
 
 // IsProgressNotify returns true if the WatchResponse is progress notification.
 func (wr *WatchResponse) IsProgressNotify() bool {
-	return len(wr.Events) == 0 && !wr.Canceled && !wr.Created && wr.CompactRevision == 0 && wr.Header.Revision != 0
+	return len(wr.Events) == 0 && !wr.Canceled && !wr.Created && wr.CompactRevision == 0 && wr.ResyncRevision == 0 && wr.Header.Revision != 0
+}
+
+// Bookmark marks the revision up to which a watcher has observed all events.
+// It is safe to resume watching from the next revision, so it can be used to
+// checkpoint the progress of the watcher.
+type Bookmark struct {
+	// Revision is the revision up to which all events have been observed.
+	Revision int64
+}
+
+// Bookmark returns the bookmark of the WatchResponse if it is a progress
+// notification, or nil otherwise. Watchers created with
+// WithProgressNotifyInterval are sent bookmarks at least every interval.
+func (wr *WatchResponse) Bookmark() *Bookmark {
+	if !wr.IsProgressNotify() {
+		return nil
+	}
+	return &Bookmark{Revision: wr.Header.Revision}
 }
 
 // watcher implements the Watcher interface
@@ -151,6 +181,12 @@ type watcher struct {
 	// streams holds all the active grpc streams keyed by ctx value.
 	streams map[string]*watchGRPCStream
 	lg      *zap.Logger
+
+	// reauth is set if the client has credentials to authenticate with
+	// again, in which case the watchers failing to be created because the
+	// auth token of their stream is invalid or outdated are resumed on a new
+	// stream, which gets a new token.
+	reauth bool
 }
 
 // watchGRPCStream tracks all watch resources attached to a single grpc stream.
@@ -164,6 +200,14 @@ type watchGRPCStream struct {
 	// ctxKey is the key used when looking up this stream's context
 	ctxKey string
 	cancel context.CancelFunc
+	// streamCancel cancels the current grpc stream
+	streamCancel context.CancelFunc
+	// reauthenticating is set while the current grpc stream is canceled to
+	// open a new one with a new auth token
+	reauthenticating bool
+	// reauthed is set once the grpc stream has been reopened with a new auth
+	// token, until a watcher is created; the watcher failing again is closed
+	reauthed bool
 
 	// substreams holds all active watchers on this grpc stream
 	substreams map[int64]*watcherStream
@@ -207,6 +251,10 @@ type watchRequest struct {
 	createdNotify bool
 	// progressNotify is for progress updates
 	progressNotify bool
+	// progressNotifyInterval is the maximum interval between progress updates
+	progressNotifyInterval time.Duration
+	// allowResync lets the server resynchronize the watcher if it's too slow
+	allowResync bool
 	// fragmentation should be disabled by default
 	// if true, split watch events when total exceeds
 	// "--max-request-bytes" flag value + 512-byte
@@ -256,6 +304,7 @@ func NewWatchFromWatchClient(wc pb.WatchClient, c *Client) Watcher {
 	if c != nil {
 		w.callOpts = c.callOpts
 		w.lg = c.lg
+		w.reauth = c.authTokenBundle != nil
 	}
 	return w
 }
@@ -306,16 +355,18 @@ func (w *watcher) Watch(ctx context.Context, key string, opts ...OpOption) Watch
 	}
 
 	wr := &watchRequest{
-		ctx:            ctx,
-		createdNotify:  ow.createdNotify,
-		key:            string(ow.key),
-		end:            string(ow.end),
-		rev:            ow.rev,
-		progressNotify: ow.progressNotify,
-		fragment:       ow.fragment,
-		filters:        filters,
-		prevKV:         ow.prevKV,
-		retc:           make(chan chan WatchResponse, 1),
+		ctx:                    ctx,
+		createdNotify:          ow.createdNotify,
+		key:                    string(ow.key),
+		end:                    string(ow.end),
+		rev:                    ow.rev,
+		progressNotify:         ow.progressNotify,
+		progressNotifyInterval: ow.progressNotifyInterval,
+		allowResync:            ow.allowResync,
+		fragment:               ow.fragment,
+		filters:                filters,
+		prevKV:                 ow.prevKV,
+		retc:                   make(chan chan WatchResponse, 1),
 	}
 
 	ok := false
@@ -587,7 +638,18 @@ func (w *watchGRPCStream) run() {
 			}
 
 			switch {
+			case pbresp.Created && pbresp.Canceled && w.shouldReauth(pbresp.CancelReason):
+				// the auth token of the stream was rejected; keep the watcher
+				// at the head of the queue and resume it on a new stream
+				w.lg.Debug("reopening watch stream to refresh auth token", zap.String("reason", pbresp.CancelReason))
+				w.reauthenticating, w.reauthed = true, true
+				w.streamCancel()
+				cur = nil
+
 			case pbresp.Created:
+				if !pbresp.Canceled {
+					w.reauthed = false
+				}
 				// response to head of queue creation
 				if len(w.resuming) != 0 {
 					if ws := w.resuming[0]; ws != nil {
@@ -653,11 +715,16 @@ func (w *watchGRPCStream) run() {
 
 		// watch client failed on Recv; spawn another if possible
 		case err := <-w.errc:
-			if isHaltErr(w.ctx, err) || toErr(w.ctx, err) == v3rpc.ErrNoLeader {
-				closeErr = err
-				return
+			if w.reauthenticating {
+				// the stream was canceled to get a new auth token
+				w.reauthenticating = false
+			} else {
+				if isHaltErr(w.ctx, err) || toErr(w.ctx, err) == v3rpc.ErrNoLeader {
+					closeErr = err
+					return
+				}
+				backoff = w.backoffIfUnavailable(backoff, err)
 			}
-			backoff = w.backoffIfUnavailable(backoff, err)
 			if wc, closeErr = w.newWatchClient(); closeErr != nil {
 				return
 			}
@@ -720,10 +787,14 @@ func (w *watchGRPCStream) dispatchEvent(pbresp *pb.WatchResponse) bool {
 		Header:          *pbresp.Header,
 		Events:          events,
 		CompactRevision: pbresp.CompactRevision,
+		ResyncRevision:  pbresp.ResyncRevision,
 		Created:         pbresp.Created,
 		Canceled:        pbresp.Canceled,
 		cancelReason:    pbresp.CancelReason,
 	}
+	if wr.ResyncRevision == 0 {
+		wr.ResyncRevision = wr.CompactRevision
+	}
 
 	// watch IDs are zero indexed, so request notify watch responses are assigned a watch ID of InvalidWatchID to
 	// indicate they should be broadcast.
@@ -1002,9 +1073,15 @@ func (w *watchGRPCStream) openWatchClient() (ws pb.Watch_WatchClient, err error)
 			return nil, err
 		default:
 		}
-		if ws, err = w.remote.Watch(w.ctx, w.callOpts...); ws != nil && err == nil {
+		sctx, scancel := context.WithCancel(w.ctx)
+		if ws, err = w.remote.Watch(sctx, w.callOpts...); ws != nil && err == nil {
+			if w.streamCancel != nil {
+				w.streamCancel()
+			}
+			w.streamCancel = scancel
 			break
 		}
+		scancel()
 		if isHaltErr(w.ctx, err) {
 			return nil, v3rpc.Error(err)
 		}
@@ -1013,6 +1090,19 @@ func (w *watchGRPCStream) openWatchClient() (ws pb.Watch_WatchClient, err error)
 	return ws, nil
 }
 
+// shouldReauth returns whether a watcher failing to be created for the given
+// reason is resumed on a new stream with a new auth token.
+func (w *watchGRPCStream) shouldReauth(reason string) bool {
+	if !w.owner.reauth || w.reauthenticating || w.reauthed {
+		return false
+	}
+	switch reason {
+	case v3rpc.ErrGRPCInvalidAuthToken.Error(), v3rpc.ErrGRPCAuthOldRevision.Error(), v3rpc.ErrGRPCUserEmpty.Error():
+		return true
+	}
+	return false
+}
+
 // toPB converts an internal watch request structure to its protobuf WatchRequest structure.
 func (wr *watchRequest) toPB() *pb.WatchRequest {
 	req := &pb.WatchCreateRequest{
@@ -1023,6 +1113,9 @@ func (wr *watchRequest) toPB() *pb.WatchRequest {
 		Filters:        wr.filters,
 		PrevKv:         wr.prevKV,
 		Fragment:       wr.fragment,
+
+		ProgressNotifyIntervalMs: wr.progressNotifyInterval.Milliseconds(),
+		AllowResync:              wr.allowResync,
 	}
 	cr := &pb.WatchRequest_CreateRequest{CreateRequest: req}
 	return &pb.WatchRequest{RequestUnion: cr}
@@ -0,0 +1,385 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"go.etcd.io/etcd/api/v3/authpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+)
+
+// rootName is the name of the root user and of the root role.
+const rootName = "root"
+
+var (
+	authApplyFile   string
+	authApplyDryRun bool
+	authApplyPrune  bool
+)
+
+// authConfig is the declarative configuration of the users and roles of a
+// cluster, read and written as YAML.
+type authConfig struct {
+	Users []authUser `json:"users,omitempty"`
+	Roles []authRole `json:"roles,omitempty"`
+}
+
+type authUser struct {
+	Name  string   `json:"name"`
+	Roles []string `json:"roles,omitempty"`
+	// Password and NoPassword are only used to create the user; passwords
+	// are never exported.
+	Password   string `json:"password,omitempty"`
+	NoPassword bool   `json:"noPassword,omitempty"`
+}
+
+type authRole struct {
+	Name        string           `json:"name"`
+	Permissions []authPermission `json:"permissions,omitempty"`
+}
+
+type authPermission struct {
+	// Type is one of READ, WRITE and READWRITE.
+	Type     string `json:"type"`
+	Key      string `json:"key"`
+	RangeEnd string `json:"rangeEnd,omitempty"`
+}
+
+// authChange is a single step reconciling the users and roles of a cluster
+// with an authConfig.
+type authChange struct {
+	desc  string
+	apply func(ctx context.Context, c *clientv3.Client) error
+}
+
+func newAuthExportCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "export",
+		Short: "Prints the users, their roles and the role permissions as YAML",
+		Run:   authExportCommandFunc,
+	}
+}
+
+func newAuthApplyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply -f <file>",
+		Short: "Reconciles the users, their roles and the role permissions with a YAML file",
+		Run:   authApplyCommandFunc,
+	}
+	cmd.Flags().StringVarP(&authApplyFile, "file", "f", "", "YAML file with the users and roles, as printed by 'auth export'")
+	cmd.Flags().BoolVar(&authApplyDryRun, "dry-run", false, "Only print the changes that would be applied")
+	cmd.Flags().BoolVar(&authApplyPrune, "prune", false, "Delete the users and roles missing from the file, except root")
+	return cmd
+}
+
+// authExportCommandFunc executes the "auth export" command.
+func authExportCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("auth export command does not accept any arguments"))
+	}
+
+	ctx, cancel := commandCtx(cmd)
+	ac, err := getAuthConfig(ctx, mustClientFromCmd(cmd))
+	cancel()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	b, err := yaml.Marshal(ac)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	fmt.Print(string(b))
+}
+
+// authApplyCommandFunc executes the "auth apply" command.
+func authApplyCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("auth apply command does not accept any arguments"))
+	}
+	if authApplyFile == "" {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("auth apply command requires --file"))
+	}
+	b, err := os.ReadFile(authApplyFile)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+	var want authConfig
+	if err = yaml.UnmarshalStrict(b, &want); err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("failed to parse %s: %w", authApplyFile, err))
+	}
+
+	c := mustClientFromCmd(cmd)
+	ctx, cancel := commandCtx(cmd)
+	defer cancel()
+	got, err := getAuthConfig(ctx, c)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	changes, err := planAuthChanges(got, &want, authApplyPrune)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+	if len(changes) == 0 {
+		fmt.Println("Users and roles are up to date")
+		return
+	}
+	for _, ch := range changes {
+		fmt.Println(ch.desc)
+		if authApplyDryRun {
+			continue
+		}
+		if err = ch.apply(ctx, c); err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("%s: %w", ch.desc, err))
+		}
+	}
+}
+
+// getAuthConfig returns the users and roles of the cluster.
+func getAuthConfig(ctx context.Context, c *clientv3.Client) (*authConfig, error) {
+	ac := &authConfig{}
+	roles, err := c.RoleList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range roles.Roles {
+		resp, err := c.RoleGet(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		r := authRole{Name: name}
+		for _, p := range resp.Perm {
+			r.Permissions = append(r.Permissions, authPermission{
+				Type:     p.PermType.String(),
+				Key:      string(p.Key),
+				RangeEnd: string(p.RangeEnd),
+			})
+		}
+		ac.Roles = append(ac.Roles, r)
+	}
+	users, err := c.UserList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range users.Users {
+		resp, err := c.UserGet(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		ac.Users = append(ac.Users, authUser{Name: name, Roles: resp.Roles})
+	}
+	return ac, nil
+}
+
+// planAuthChanges returns the changes turning the users and roles got into
+// the ones wanted: the roles are created and their permissions granted and
+// revoked first, then the users are created and their roles granted and
+// revoked. If prune is set, the users and then the roles missing from want,
+// except root, are deleted last.
+func planAuthChanges(got, want *authConfig, prune bool) ([]authChange, error) {
+	if err := want.validate(); err != nil {
+		return nil, err
+	}
+	var changes []authChange
+
+	gotRoles := make(map[string]authRole)
+	for _, r := range got.Roles {
+		gotRoles[r.Name] = r
+	}
+	for _, r := range want.Roles {
+		cur, ok := gotRoles[r.Name]
+		if !ok {
+			changes = append(changes, authChange{
+				desc: fmt.Sprintf("+ role %s", r.Name),
+				apply: func(ctx context.Context, c *clientv3.Client) error {
+					_, err := c.RoleAdd(ctx, r.Name)
+					return err
+				},
+			})
+		}
+		changes = append(changes, planPermissionChanges(r.Name, cur.Permissions, r.Permissions)...)
+	}
+
+	gotUsers := make(map[string]authUser)
+	for _, u := range got.Users {
+		gotUsers[u.Name] = u
+	}
+	for _, u := range want.Users {
+		cur, ok := gotUsers[u.Name]
+		if !ok {
+			if u.Password == "" && !u.NoPassword {
+				return nil, fmt.Errorf("user %s does not exist and has neither a password nor noPassword set", u.Name)
+			}
+			changes = append(changes, authChange{
+				desc: fmt.Sprintf("+ user %s", u.Name),
+				apply: func(ctx context.Context, c *clientv3.Client) error {
+					_, err := c.UserAddWithOptions(ctx, u.Name, u.Password, &clientv3.UserAddOptions{NoPassword: u.NoPassword})
+					return err
+				},
+			})
+		}
+		curRoles := make(map[string]bool)
+		for _, r := range cur.Roles {
+			curRoles[r] = true
+		}
+		for _, r := range u.Roles {
+			if curRoles[r] {
+				delete(curRoles, r)
+				continue
+			}
+			changes = append(changes, authChange{
+				desc: fmt.Sprintf("+ user %s role %s", u.Name, r),
+				apply: func(ctx context.Context, c *clientv3.Client) error {
+					_, err := c.UserGrantRole(ctx, u.Name, r)
+					return err
+				},
+			})
+		}
+		for _, r := range sortedKeys(curRoles) {
+			changes = append(changes, authChange{
+				desc: fmt.Sprintf("- user %s role %s", u.Name, r),
+				apply: func(ctx context.Context, c *clientv3.Client) error {
+					_, err := c.UserRevokeRole(ctx, u.Name, r)
+					return err
+				},
+			})
+		}
+	}
+
+	if !prune {
+		return changes, nil
+	}
+	wantNames := make(map[string]bool)
+	for _, u := range want.Users {
+		wantNames[u.Name] = true
+	}
+	for _, u := range got.Users {
+		if wantNames[u.Name] || u.Name == rootName {
+			continue
+		}
+		changes = append(changes, authChange{
+			desc: fmt.Sprintf("- user %s", u.Name),
+			apply: func(ctx context.Context, c *clientv3.Client) error {
+				_, err := c.UserDelete(ctx, u.Name)
+				return err
+			},
+		})
+	}
+	wantNames = make(map[string]bool)
+	for _, r := range want.Roles {
+		wantNames[r.Name] = true
+	}
+	for _, r := range got.Roles {
+		if wantNames[r.Name] || r.Name == rootName {
+			continue
+		}
+		changes = append(changes, authChange{
+			desc: fmt.Sprintf("- role %s", r.Name),
+			apply: func(ctx context.Context, c *clientv3.Client) error {
+				_, err := c.RoleDelete(ctx, r.Name)
+				return err
+			},
+		})
+	}
+	return changes, nil
+}
+
+// planPermissionChanges returns the changes granting the permissions wanted
+// by a role and revoking the others. Granting a permission on a range the
+// role already has a permission on replaces its type.
+func planPermissionChanges(role string, got, want []authPermission) []authChange {
+	type keyRange struct{ key, end string }
+	cur := make(map[keyRange]string)
+	for _, p := range got {
+		cur[keyRange{p.Key, p.RangeEnd}] = p.Type
+	}
+
+	var changes []authChange
+	for _, p := range want {
+		kr := keyRange{p.Key, p.RangeEnd}
+		t, ok := cur[kr]
+		delete(cur, kr)
+		if ok && t == p.Type {
+			continue
+		}
+		perm := clientv3.PermissionType(authpb.Permission_Type_value[p.Type])
+		changes = append(changes, authChange{
+			desc: fmt.Sprintf("+ role %s permission %s [%q, %q)", role, p.Type, p.Key, p.RangeEnd),
+			apply: func(ctx context.Context, c *clientv3.Client) error {
+				_, err := c.RoleGrantPermission(ctx, role, p.Key, p.RangeEnd, perm)
+				return err
+			},
+		})
+	}
+	for _, p := range got {
+		kr := keyRange{p.Key, p.RangeEnd}
+		if _, ok := cur[kr]; !ok {
+			continue
+		}
+		changes = append(changes, authChange{
+			desc: fmt.Sprintf("- role %s permission %s [%q, %q)", role, p.Type, p.Key, p.RangeEnd),
+			apply: func(ctx context.Context, c *clientv3.Client) error {
+				_, err := c.RoleRevokePermission(ctx, role, p.Key, p.RangeEnd)
+				return err
+			},
+		})
+	}
+	return changes
+}
+
+func (ac *authConfig) validate() error {
+	roles := make(map[string]bool)
+	for _, r := range ac.Roles {
+		if r.Name == "" {
+			return fmt.Errorf("role with an empty name")
+		}
+		if roles[r.Name] {
+			return fmt.Errorf("role %s is given more than once", r.Name)
+		}
+		roles[r.Name] = true
+		for _, p := range r.Permissions {
+			if _, ok := authpb.Permission_Type_value[p.Type]; !ok {
+				return fmt.Errorf("role %s has a permission with invalid type %q", r.Name, p.Type)
+			}
+		}
+	}
+	users := make(map[string]bool)
+	for _, u := range ac.Users {
+		if u.Name == "" {
+			return fmt.Errorf("user with an empty name")
+		}
+		if users[u.Name] {
+			return fmt.Errorf("user %s is given more than once", u.Name)
+		}
+		users[u.Name] = true
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,134 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func Test_planAuthChanges(t *testing.T) {
+	got := &authConfig{
+		Users: []authUser{
+			{Name: "root", Roles: []string{"root"}},
+			{Name: "alice", Roles: []string{"reader", "writer"}},
+			{Name: "bob", Roles: []string{"reader"}},
+		},
+		Roles: []authRole{
+			{Name: "root"},
+			{Name: "reader", Permissions: []authPermission{
+				{Type: "READ", Key: "/a", RangeEnd: "/b"},
+				{Type: "READ", Key: "/old"},
+			}},
+			{Name: "writer", Permissions: []authPermission{{Type: "WRITE", Key: "/a", RangeEnd: "/b"}}},
+		},
+	}
+
+	tt := []struct {
+		name  string
+		want  string
+		prune bool
+
+		changes []string
+		wantErr bool
+	}{
+		{
+			name: "up to date",
+			want: `
+users:
+- name: alice
+  roles: [reader, writer]
+roles:
+- name: reader
+  permissions:
+  - {type: READ, key: /a, rangeEnd: /b}
+  - {type: READ, key: /old}
+`,
+		},
+		{
+			name: "reconcile",
+			want: `
+users:
+- name: alice
+  roles: [reader, admin]
+- name: carol
+  password: secret
+  roles: [admin]
+roles:
+- name: reader
+  permissions:
+  - {type: READWRITE, key: /a, rangeEnd: /b}
+- name: admin
+  permissions:
+  - {type: READWRITE, key: "\0", rangeEnd: "\0"}
+`,
+			changes: []string{
+				`+ role reader permission READWRITE ["/a", "/b")`,
+				`- role reader permission READ ["/old", "")`,
+				`+ role admin`,
+				`+ role admin permission READWRITE ["\x00", "\x00")`,
+				`+ user alice role admin`,
+				`- user alice role writer`,
+				`+ user carol`,
+				`+ user carol role admin`,
+			},
+		},
+		{
+			name:  "prune",
+			want:  "users: [{name: alice, roles: [reader, writer]}]\nroles: [{name: writer, permissions: [{type: WRITE, key: /a, rangeEnd: /b}]}]",
+			prune: true,
+			changes: []string{
+				`- user bob`,
+				`- role reader`,
+			},
+		},
+		{
+			name:    "new user without password",
+			want:    "users: [{name: carol}]",
+			wantErr: true,
+		},
+		{
+			name:    "invalid permission type",
+			want:    "roles: [{name: reader, permissions: [{type: ADMIN, key: /a}]}]",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate role",
+			want:    "roles: [{name: reader}, {name: reader}]",
+			wantErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var want authConfig
+			if err := yaml.UnmarshalStrict([]byte(tc.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			changes, err := planAuthChanges(got, &want, tc.prune)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var descs []string
+			for _, ch := range changes {
+				descs = append(descs, ch.desc)
+			}
+			if !reflect.DeepEqual(descs, tc.changes) {
+				t.Errorf("changes = %q, want %q", descs, tc.changes)
+			}
+		})
+	}
+}
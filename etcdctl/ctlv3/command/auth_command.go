@@ -16,23 +16,29 @@ package command
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/pkg/v3/cobrautl"
 )
 
 // NewAuthCommand returns the cobra command for "auth".
 func NewAuthCommand() *cobra.Command {
 	ac := &cobra.Command{
-		Use:   "auth <enable or disable>",
-		Short: "Enable or disable authentication",
+		Use:   "auth <subcommand>",
+		Short: "Authentication related commands",
 	}
 
 	ac.AddCommand(newAuthEnableCommand())
 	ac.AddCommand(newAuthDisableCommand())
 	ac.AddCommand(newAuthStatusCommand())
+	ac.AddCommand(newAuthExportCommand())
+	ac.AddCommand(newAuthApplyCommand())
+	ac.AddCommand(newAuthCanICommand())
 
 	return ac
 }
@@ -61,6 +67,69 @@ func authStatusCommandFunc(cmd *cobra.Command, args []string) {
 	display.AuthStatus(*result)
 }
 
+var authCanIAs string
+
+func newAuthCanICommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "can-i <get|put|del|read|write|readwrite> <key> [endkey]",
+		Short: "Checks whether a user is permitted an operation on a key or range",
+		Long: `Checks against the roles configured on the server whether a user is permitted an operation on a key or range,
+and which of its roles grant the permission. The user is the one given by --as, or else the authenticated user.
+Exits with a non-zero status if the operation is not permitted.`,
+		Run: authCanICommandFunc,
+	}
+	cmd.Flags().StringVar(&authCanIAs, "as", "", "user to check the permission of, instead of the authenticated user")
+	cmd.Flags().BoolVar(&rolePermPrefix, "prefix", false, "check the permission on a prefix")
+	cmd.Flags().BoolVar(&rolePermFromKey, "from-key", false, "check the permission on the keys that are greater than or equal to the given key using byte compare")
+	return cmd
+}
+
+// authCanICommandFunc executes the "auth can-i" command.
+func authCanICommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) < 2 || len(args) > 3 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("auth can-i command requires an operation and a key as its arguments"))
+	}
+
+	permType, err := permTypeFromOperation(args[0])
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+	key, rangeEnd := permRange(args[1:])
+
+	user := authCanIAs
+	if user == "" {
+		userFlag, err := cmd.Flags().GetString("user")
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+		}
+		user, _, _ = strings.Cut(userFlag, ":")
+	}
+
+	ctx, cancel := commandCtx(cmd)
+	resp, err := mustClientFromCmd(cmd).Auth.CheckPermission(ctx, user, key, rangeEnd, permType)
+	cancel()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+
+	display.AuthCheckPermission(user, *resp)
+	if !resp.Permitted {
+		os.Exit(cobrautl.ExitError)
+	}
+}
+
+// permTypeFromOperation returns the permission required by an operation, or
+// the permission type itself.
+func permTypeFromOperation(op string) (clientv3.PermissionType, error) {
+	switch strings.ToLower(op) {
+	case "get":
+		return clientv3.PermissionType(clientv3.PermRead), nil
+	case "put", "del":
+		return clientv3.PermissionType(clientv3.PermWrite), nil
+	}
+	return clientv3.StrToPermissionType(op)
+}
+
 func newAuthEnableCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "enable",
@@ -0,0 +1,413 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+)
+
+const (
+	certTypeServer = "server"
+	certTypePeer   = "peer"
+	certTypeClient = "client"
+)
+
+var (
+	certDir        string
+	certMembers    []string
+	certClientCN   string
+	certValidity   time.Duration
+	certCAValidity time.Duration
+	certCACert     string
+	certCAKey      string
+
+	certCheckType          string
+	certCheckHosts         []string
+	certCheckExpiryWarning time.Duration
+)
+
+// NewCertCommand returns the cobra command for "cert".
+func NewCertCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cert <subcommand>",
+		Short: "Generates and checks TLS certificates",
+	}
+	cmd.AddCommand(NewCertGenerateCommand())
+	cmd.AddCommand(NewCertCheckCommand())
+	return cmd
+}
+
+// NewCertGenerateCommand returns the cobra command for "cert generate".
+func NewCertGenerateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "generate --member <name>=<host>[,<host>...] [options]",
+		Short: "Generates a CA and the server, peer and client certificates of the given members",
+		Long: `Generates a CA, unless one is given, and signs with it, for each member, a
+server and a peer certificate whose SANs are the hosts of the member, and a
+client certificate. The server certificates also have localhost and 127.0.0.1
+as SANs. The files are written to the output directory:
+
+  ca.crt, ca.key                          the CA, if generated
+  <name>-server.crt, <name>-server.key    --cert-file and --key-file of the member
+  <name>-peer.crt, <name>-peer.key        --peer-cert-file and --peer-key-file of the member
+  client.crt, client.key                  --cert and --key of etcdctl
+
+Existing files are never overwritten.`,
+		Args: cobra.NoArgs,
+		Run:  certGenerateCommandFunc,
+	}
+	cmd.Flags().StringVar(&certDir, "dir", ".", "Output directory")
+	cmd.Flags().StringArrayVar(&certMembers, "member", nil, "Member name and its comma-separated host names and IP addresses, e.g. infra0=10.0.1.10,infra0.example.com (can be repeated)")
+	cmd.Flags().StringVar(&certClientCN, "client-cn", "root", "Common name of the client certificate, the user name with --client-cert-auth")
+	cmd.Flags().DurationVar(&certValidity, "validity", 365*24*time.Hour, "Validity of the server, peer and client certificates")
+	cmd.Flags().DurationVar(&certCAValidity, "ca-validity", 10*365*24*time.Hour, "Validity of the generated CA")
+	cmd.Flags().StringVar(&certCACert, "ca-cert", "", "Existing CA certificate to sign the certificates with, instead of generating one")
+	cmd.Flags().StringVar(&certCAKey, "ca-key", "", "Key of the existing CA certificate")
+	cmd.MarkFlagRequired("member")
+	return cmd
+}
+
+// NewCertCheckCommand returns the cobra command for "cert check".
+func NewCertCheckCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check --cacert <file> --cert <file> --key <file> [options]",
+		Short: "Checks a certificate against its CA, key and the hosts it's used for",
+		Long: `Checks that the certificate given with --cert:
+
+  - matches the key given with --key,
+  - is signed by the CA given with --cacert,
+  - is valid now and does not expire within --expiry-warning,
+  - has the extended key usages its type needs: server authentication for
+    server certificates, client authentication for client certificates and
+    both for peer certificates,
+  - has SANs for all the hosts, e.g. the --advertise-client-urls of a server
+    certificate or the --initial-advertise-peer-urls of a peer certificate.
+
+Exits with an error if any check fails.`,
+		Args: cobra.NoArgs,
+		Run:  certCheckCommandFunc,
+	}
+	cmd.Flags().StringVar(&certCheckType, "type", certTypeServer, "Type of the certificate: 'server', 'peer' or 'client'")
+	cmd.Flags().StringSliceVar(&certCheckHosts, "hosts", nil, "Comma-separated host names, IP addresses or URLs the certificate must be valid for")
+	cmd.Flags().DurationVar(&certCheckExpiryWarning, "expiry-warning", 30*24*time.Hour, "Warn if the certificate expires within this duration")
+	return cmd
+}
+
+func certGenerateCommandFunc(cmd *cobra.Command, args []string) {
+	members, err := parseCertMembers(certMembers)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+	if (certCACert == "") != (certCAKey == "") {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("--ca-cert and --ca-key must be set together"))
+	}
+	if err = os.MkdirAll(certDir, 0o700); err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	if err = generateCerts(os.Stdout, certDir, members, time.Now()); err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+}
+
+// certMember is a member to generate certificates for.
+type certMember struct {
+	name  string
+	hosts []string
+}
+
+func parseCertMembers(flags []string) ([]certMember, error) {
+	var members []certMember
+	for _, f := range flags {
+		name, hosts, ok := strings.Cut(f, "=")
+		if !ok || name == "" || hosts == "" {
+			return nil, fmt.Errorf("invalid --member %q, expecting '<name>=<host>[,<host>...]'", f)
+		}
+		members = append(members, certMember{name: name, hosts: strings.Split(hosts, ",")})
+	}
+	return members, nil
+}
+
+// generateCerts writes the CA, unless --ca-cert is set, and the server, peer
+// and client certificates of the members to dir.
+func generateCerts(w io.Writer, dir string, members []certMember, now time.Time) error {
+	var ca *x509.Certificate
+	var caKey crypto.Signer
+	var err error
+	if certCACert != "" {
+		ca, caKey, err = loadCA(certCACert, certCAKey)
+	} else {
+		ca, caKey, err = writeCert(w, dir, "ca", &x509.Certificate{
+			Subject:               pkix.Name{Organization: []string{"etcd"}, CommonName: "etcd-ca"},
+			NotBefore:             now,
+			NotAfter:              now.Add(certCAValidity),
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}, nil, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	leaf := func(cn string, hosts []string, usages ...x509.ExtKeyUsage) *x509.Certificate {
+		tmpl := &x509.Certificate{
+			Subject:     pkix.Name{Organization: []string{"etcd"}, CommonName: cn},
+			NotBefore:   now,
+			NotAfter:    now.Add(certValidity),
+			KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+			ExtKeyUsage: usages,
+		}
+		for _, h := range hosts {
+			if ip := net.ParseIP(h); ip != nil {
+				tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+			} else {
+				tmpl.DNSNames = append(tmpl.DNSNames, h)
+			}
+		}
+		return tmpl
+	}
+	for _, m := range members {
+		// servers also authenticate as clients, e.g. the gRPC gateway
+		serverHosts := append(slices.Clone(m.hosts), "localhost", "127.0.0.1")
+		if _, _, err = writeCert(w, dir, m.name+"-server", leaf(m.name, serverHosts, x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth), ca, caKey); err != nil {
+			return err
+		}
+		if _, _, err = writeCert(w, dir, m.name+"-peer", leaf(m.name, m.hosts, x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth), ca, caKey); err != nil {
+			return err
+		}
+	}
+	_, _, err = writeCert(w, dir, "client", leaf(certClientCN, nil, x509.ExtKeyUsageClientAuth), ca, caKey)
+	return err
+}
+
+// writeCert generates a key and a certificate from the template, signed by
+// the parent or self-signed if nil, and writes them to <name>.crt and
+// <name>.key in dir.
+func writeCert(w io.Writer, dir, name string, tmpl, parent *x509.Certificate, parentKey crypto.Signer) (*x509.Certificate, crypto.Signer, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tmpl.SerialNumber, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128)); err != nil {
+		return nil, nil, err
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), parentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	if err = writePEMFile(certFile, "CERTIFICATE", der, 0o644); err != nil {
+		return nil, nil, err
+	}
+	if err = writePEMFile(keyFile, "EC PRIVATE KEY", keyDER, 0o600); err != nil {
+		return nil, nil, err
+	}
+	fmt.Fprintf(w, "wrote %s and %s\n", certFile, keyFile)
+	cert, err := x509.ParseCertificate(der)
+	return cert, key, err
+}
+
+func writePEMFile(path, typ string, der []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if err = pem.Encode(f, &pem.Block{Type: typ, Bytes: der}); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func loadCA(certFile, keyFile string) (*x509.Certificate, crypto.Signer, error) {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load CA: %w", err)
+	}
+	signer, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported CA key type %T", pair.PrivateKey)
+	}
+	ca, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ca.IsCA {
+		return nil, nil, fmt.Errorf("%s is not a CA certificate", certFile)
+	}
+	return ca, signer, nil
+}
+
+func certCheckCommandFunc(cmd *cobra.Command, args []string) {
+	certFile, keyFile, caFile := keyAndCertFromCmd(cmd)
+	if certFile == "" || keyFile == "" || caFile == "" {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("--cacert, --cert and --key are required"))
+	}
+	if certCheckType != certTypeServer && certCheckType != certTypePeer && certCheckType != certTypeClient {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("invalid --type %q, expecting 'server', 'peer' or 'client'", certCheckType))
+	}
+	results, err := checkCert(caFile, certFile, keyFile, certCheckType, certCheckHosts, certCheckExpiryWarning, time.Now())
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	failed := false
+	for _, r := range results {
+		fmt.Printf("%s\t%s: %s\n", r.status, r.check, r.detail)
+		failed = failed || r.status == certCheckFail
+	}
+	if failed {
+		cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("certificate %s failed the checks", certFile))
+	}
+}
+
+const (
+	certCheckPass = "PASS"
+	certCheckWarn = "WARN"
+	certCheckFail = "FAIL"
+)
+
+// certCheckResult is the result of a check of a certificate.
+type certCheckResult struct {
+	check  string
+	status string
+	detail string
+}
+
+// checkCert checks the certificate against its CA, key, type and hosts.
+// It returns an error only if the files cannot be read.
+func checkCert(caFile, certFile, keyFile, typ string, hosts []string, expiryWarning time.Duration, now time.Time) ([]certCheckResult, error) {
+	var results []certCheckResult
+	result := func(check string, err error, detail string) {
+		r := certCheckResult{check: check, status: certCheckPass, detail: detail}
+		if err != nil {
+			r.status, r.detail = certCheckFail, err.Error()
+		}
+		results = append(results, r)
+	}
+
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no certificate found in %s", certFile)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tls.X509KeyPair(certPEM, keyPEM)
+	result("key", err, "certificate matches the key")
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no CA certificate found in %s", caFile)
+	}
+	_, err = cert.Verify(x509.VerifyOptions{Roots: roots, CurrentTime: now, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+	result("chain", err, "certificate is signed by the CA")
+
+	switch {
+	case now.Before(cert.NotBefore):
+		result("expiry", fmt.Errorf("certificate is not valid before %s", cert.NotBefore.Format(time.RFC3339)), "")
+	case now.After(cert.NotAfter):
+		result("expiry", fmt.Errorf("certificate expired at %s", cert.NotAfter.Format(time.RFC3339)), "")
+	case now.Add(expiryWarning).After(cert.NotAfter):
+		results = append(results, certCheckResult{check: "expiry", status: certCheckWarn, detail: fmt.Sprintf("certificate expires soon, at %s", cert.NotAfter.Format(time.RFC3339))})
+	default:
+		result("expiry", nil, fmt.Sprintf("certificate expires at %s", cert.NotAfter.Format(time.RFC3339)))
+	}
+
+	var usages []x509.ExtKeyUsage
+	switch typ {
+	case certTypeServer:
+		usages = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	case certTypePeer:
+		usages = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	case certTypeClient:
+		usages = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+	err = nil
+	for _, u := range usages {
+		if len(cert.ExtKeyUsage) != 0 && !slices.Contains(cert.ExtKeyUsage, u) && !slices.Contains(cert.ExtKeyUsage, x509.ExtKeyUsageAny) {
+			err = fmt.Errorf("%s certificate lacks the %s extended key usage", typ, extKeyUsageName(u))
+			break
+		}
+	}
+	if err == nil && cert.KeyUsage != 0 && cert.KeyUsage&x509.KeyUsageDigitalSignature == 0 {
+		err = errors.New("certificate lacks the digital signature key usage")
+	}
+	result("key-usage", err, fmt.Sprintf("certificate can be used as a %s certificate", typ))
+
+	for _, h := range hosts {
+		host := h
+		if u, uerr := url.Parse(h); uerr == nil && u.Host != "" {
+			host = u.Hostname()
+		} else if hh, _, serr := net.SplitHostPort(h); serr == nil {
+			host = hh
+		}
+		result("san", cert.VerifyHostname(host), fmt.Sprintf("certificate is valid for %s", host))
+	}
+	return results, nil
+}
+
+func extKeyUsageName(u x509.ExtKeyUsage) string {
+	switch u {
+	case x509.ExtKeyUsageServerAuth:
+		return "server authentication"
+	case x509.ExtKeyUsageClientAuth:
+		return "client authentication"
+	default:
+		return fmt.Sprintf("%d", u)
+	}
+}
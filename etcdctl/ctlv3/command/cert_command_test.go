@@ -0,0 +1,135 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"io"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGenerateAndCheckCerts(t *testing.T) {
+	dir := t.TempDir()
+	certClientCN, certValidity, certCAValidity = "root", 24*time.Hour, 48*time.Hour
+	members, err := parseCertMembers([]string{"infra0=10.0.1.10,infra0.example.com", "infra1=10.0.1.11"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if err = generateCerts(io.Discard, dir, members, now); err != nil {
+		t.Fatal(err)
+	}
+	if err = generateCerts(io.Discard, dir, members, now); err == nil {
+		t.Fatal("expected an error overwriting the certificates")
+	}
+
+	file := func(name string) string { return filepath.Join(dir, name) }
+	tt := []struct {
+		name          string
+		cert          string
+		key           string
+		typ           string
+		hosts         []string
+		expiryWarning time.Duration
+
+		failed []string
+		warned []string
+	}{
+		{
+			name:  "server",
+			cert:  "infra0-server.crt",
+			key:   "infra0-server.key",
+			typ:   certTypeServer,
+			hosts: []string{"https://10.0.1.10:2379", "infra0.example.com", "localhost:2379"},
+		},
+		{
+			name:  "peer",
+			cert:  "infra1-peer.crt",
+			key:   "infra1-peer.key",
+			typ:   certTypePeer,
+			hosts: []string{"https://10.0.1.11:2380"},
+		},
+		{
+			name: "client",
+			cert: "client.crt",
+			key:  "client.key",
+			typ:  certTypeClient,
+		},
+		{
+			name:   "other member's host",
+			cert:   "infra1-peer.crt",
+			key:    "infra1-peer.key",
+			typ:    certTypePeer,
+			hosts:  []string{"https://10.0.1.10:2380"},
+			failed: []string{"san"},
+		},
+		{
+			name:   "mismatched key",
+			cert:   "infra0-server.crt",
+			key:    "infra1-server.key",
+			typ:    certTypeServer,
+			failed: []string{"key"},
+		},
+		{
+			name:   "client certificate used as server",
+			cert:   "client.crt",
+			key:    "client.key",
+			typ:    certTypeServer,
+			failed: []string{"key-usage"},
+		},
+		{
+			name:          "expiring soon",
+			cert:          "client.crt",
+			key:           "client.key",
+			typ:           certTypeClient,
+			expiryWarning: 48 * time.Hour,
+			warned:        []string{"expiry"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			results, err := checkCert(file("ca.crt"), file(tc.cert), file(tc.key), tc.typ, tc.hosts, tc.expiryWarning, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var failed, warned []string
+			for _, r := range results {
+				switch r.status {
+				case certCheckFail:
+					failed = append(failed, r.check)
+				case certCheckWarn:
+					warned = append(warned, r.check)
+				}
+			}
+			if !slices.Equal(failed, tc.failed) || !slices.Equal(warned, tc.warned) {
+				t.Errorf("expected failed %v and warned %v checks, got %v and %v: %+v", tc.failed, tc.warned, failed, warned, results)
+			}
+		})
+	}
+
+	t.Run("expired", func(t *testing.T) {
+		results, err := checkCert(file("ca.crt"), file("client.crt"), file("client.key"), certTypeClient, nil, 0, now.Add(25*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range results {
+			if r.check == "expiry" && r.status != certCheckFail {
+				t.Errorf("expected the expiry check to fail, got %+v", r)
+			}
+		}
+	})
+}
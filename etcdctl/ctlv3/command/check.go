@@ -18,15 +18,19 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
+	"io"
 	"math"
 	"math/rand"
 	"os"
 	"os/signal"
+	"sort"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cheggaaa/pb/v3"
+	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
 	"golang.org/x/time/rate"
 
@@ -42,6 +46,14 @@ var (
 	checkDatascalePrefix string
 	autoCompact          bool
 	autoDefrag           bool
+	checkHashKVPrefix    bool
+	checkHashKVRev       int64
+
+	checkDatascaleProgressInterval time.Duration
+	checkDatascaleCleanupOnly      bool
+
+	checkWatchLatencyLoad   string
+	checkWatchLatencyPrefix string
 )
 
 type checkPerfCfg struct {
@@ -104,6 +116,44 @@ var checkDatascaleCfgMap = map[string]checkDatascaleCfg{
 	},
 }
 
+type checkWatchLatencyCfg struct {
+	rate     int
+	watchers int
+	clients  int
+	duration int
+}
+
+var checkWatchLatencyCfgMap = map[string]checkWatchLatencyCfg{
+	"s": {
+		rate:     100,
+		watchers: 10,
+		clients:  1,
+		duration: 30,
+	},
+	"m": {
+		rate:     200,
+		watchers: 100,
+		clients:  10,
+		duration: 30,
+	},
+	"l": {
+		rate:     200,
+		watchers: 500,
+		clients:  50,
+		duration: 30,
+	},
+	"xl": {
+		rate:     200,
+		watchers: 1000,
+		clients:  100,
+		duration: 30,
+	},
+}
+
+// checkWatchLatencyMaxP99 is the highest 99th percentile latency of the watch
+// events for the watch latency check to pass.
+const checkWatchLatencyMaxP99 = 100 * time.Millisecond
+
 // NewCheckCommand returns the cobra command for "check".
 func NewCheckCommand() *cobra.Command {
 	cc := &cobra.Command{
@@ -113,6 +163,8 @@ func NewCheckCommand() *cobra.Command {
 
 	cc.AddCommand(NewCheckPerfCommand())
 	cc.AddCommand(NewCheckDatascaleCommand())
+	cc.AddCommand(NewCheckHashKVCommand())
+	cc.AddCommand(NewCheckWatchLatencyCommand())
 
 	return cc
 }
@@ -163,7 +215,7 @@ func newCheckPerfCommand(cmd *cobra.Command, args []string) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.duration)*time.Second)
 	defer cancel()
-	ctx, icancel := interruptableContext(ctx, func() { attemptCleanup(clients[0], false) })
+	ctx, icancel := interruptableContext(ctx, func() { attemptCleanup(clients[0], checkPerfPrefix, false) })
 	defer icancel()
 
 	gctx, gcancel := context.WithCancel(ctx)
@@ -221,11 +273,11 @@ func newCheckPerfCommand(cmd *cobra.Command, args []string) {
 
 	s := <-sc
 
-	attemptCleanup(clients[0], autoCompact)
+	attemptCleanup(clients[0], checkPerfPrefix, autoCompact)
 
 	if autoDefrag {
 		for _, ep := range clients[0].Endpoints() {
-			defrag(clients[0], ep)
+			defrag(os.Stdout, clients[0], ep)
 		}
 	}
 
@@ -265,16 +317,16 @@ func newCheckPerfCommand(cmd *cobra.Command, args []string) {
 	}
 }
 
-func attemptCleanup(client *v3.Client, autoCompact bool) {
+func attemptCleanup(client *v3.Client, prefix string, autoCompact bool) {
 	dctx, dcancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer dcancel()
-	dresp, err := client.Delete(dctx, checkPerfPrefix, v3.WithPrefix())
+	dresp, err := client.Delete(dctx, prefix, v3.WithPrefix())
 	if err != nil {
 		fmt.Printf("FAIL: Cleanup failed during key deletion: ERROR(%v)\n", err)
 		return
 	}
 	if autoCompact {
-		compact(client, dresp.Header.Revision)
+		compact(os.Stdout, client, dresp.Header.Revision)
 	}
 }
 
@@ -298,18 +350,46 @@ func NewCheckDatascaleCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "datascale [options]",
 		Short: "Check the memory usage of holding data for different workloads on a given server endpoint.",
-		Long:  "If no endpoint is provided, localhost will be used. If multiple endpoints are provided, first endpoint will be used.",
-		Run:   newCheckDatascaleCommand,
+		Long: `If no endpoint is provided, localhost will be used. The keys are written through the first endpoint,
+and the db size and memory growth are reported for every endpoint, or every member with --cluster.
+`,
+		Run: newCheckDatascaleCommand,
 	}
 
 	cmd.Flags().StringVar(&checkDatascaleLoad, "load", "s", "The datascale check's workload model. Accepted workloads: s(small), m(medium), l(large), xl(xLarge)")
 	cmd.Flags().StringVar(&checkDatascalePrefix, "prefix", "/etcdctl-check-datascale/", "The prefix for writing the datascale check's keys.")
 	cmd.Flags().BoolVar(&autoCompact, "auto-compact", false, "Compact storage with last revision after test is finished.")
 	cmd.Flags().BoolVar(&autoDefrag, "auto-defrag", false, "Defragment storage after test is finished.")
+	cmd.Flags().DurationVar(&checkDatascaleProgressInterval, "progress-interval", 10*time.Second, "How often to print the progress when stderr is not a terminal; 0 disables it.")
+	cmd.Flags().BoolVar(&checkDatascaleCleanupOnly, "cleanup-only", false, "Only delete the keys left under the prefix by an interrupted check.")
+	cmd.Flags().BoolVar(&epClusterEndpoints, "cluster", false, "Report the growth of all the members from the cluster member list")
 
 	return cmd
 }
 
+// checkDatascaleReport is the result of the "check datascale" command.
+type checkDatascaleReport struct {
+	Load            string                       `json:"load"`
+	Keys            int                          `json:"keys"`
+	KeysWritten     int                          `json:"keys_written"`
+	Errors          map[string]int               `json:"errors,omitempty"`
+	DurationSeconds float64                      `json:"duration_seconds"`
+	Members         []checkDatascaleMemberReport `json:"members"`
+}
+
+// checkDatascaleMemberReport is the growth of a member during the check,
+// before the keys written are deleted. Sizes are in bytes.
+type checkDatascaleMemberReport struct {
+	Endpoint     string  `json:"endpoint"`
+	DBSizeBefore int64   `json:"db_size_before"`
+	DBSizeAfter  int64   `json:"db_size_after"`
+	DBGrowth     int64   `json:"db_growth"`
+	MemoryBefore float64 `json:"memory_before"`
+	MemoryAfter  float64 `json:"memory_after"`
+	MemoryGrowth float64 `json:"memory_growth"`
+	Error        string  `json:"error,omitempty"`
+}
+
 // newCheckDatascaleCommand executes the "check datascale" command.
 func newCheckDatascaleCommand(cmd *cobra.Command, args []string) {
 	var checkDatascaleAlias = map[string]string{
@@ -325,20 +405,29 @@ func newCheckDatascaleCommand(cmd *cobra.Command, args []string) {
 	}
 	cfg := checkDatascaleCfgMap[model]
 
+	cc := clientConfigFromCmd(cmd)
+
+	// the JSON report is the only output on stdout
+	out := io.Writer(os.Stdout)
+	if _, ok := display.(*jsonPrinter); ok {
+		out = os.Stderr
+	}
+
+	if checkDatascaleCleanupOnly {
+		c := mustClient(cc)
+		defer c.Close()
+		cleanupDatascale(out, c)
+		return
+	}
+
 	requests := make(chan v3.Op, cfg.clients)
 
-	cc := clientConfigFromCmd(cmd)
 	clients := make([]*v3.Client, cfg.clients)
 	for i := 0; i < cfg.clients; i++ {
 		clients[i] = mustClient(cc)
 	}
 
-	// get endpoints
-	eps, errEndpoints := endpointsFromCmd(cmd)
-	if errEndpoints != nil {
-		cobrautl.ExitWithError(cobrautl.ExitError, errEndpoints)
-	}
-
+	eps := endpointsFromCluster(cmd)
 	sec := secureCfgFromCmd(cmd)
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -348,7 +437,7 @@ func newCheckDatascaleCommand(cmd *cobra.Command, args []string) {
 		cobrautl.ExitWithError(cobrautl.ExitError, err)
 	}
 	if len(resp.Kvs) > 0 {
-		cobrautl.ExitWithError(cobrautl.ExitInvalidInput, fmt.Errorf("prefix %q has keys. Delete with etcdctl del --prefix %s first", checkDatascalePrefix, checkDatascalePrefix))
+		cobrautl.ExitWithError(cobrautl.ExitInvalidInput, fmt.Errorf("prefix %q has keys. Delete with 'etcdctl check datascale --cleanup-only --prefix %s' first", checkDatascalePrefix, checkDatascalePrefix))
 	}
 
 	ksize, vsize := 512, 512
@@ -358,16 +447,19 @@ func newCheckDatascaleCommand(cmd *cobra.Command, args []string) {
 	var wg sync.WaitGroup
 	wg.Add(len(clients))
 
-	// get the process_resident_memory_bytes and process_virtual_memory_bytes before the put operations
-	bytesBefore := endpointMemoryMetrics(eps[0], sec)
-	if bytesBefore == 0 {
-		fmt.Println("FAIL: Could not read process_resident_memory_bytes before the put operations.")
+	// get the db sizes, process_resident_memory_bytes and process_virtual_memory_bytes before the put operations
+	members := make([]checkDatascaleMemberReport, len(eps))
+	for i, ep := range eps {
+		members[i].Endpoint = ep
+		members[i].DBSizeBefore, members[i].MemoryBefore = memberDatascaleUsage(clients[0], ep, sec)
+	}
+	if members[0].MemoryBefore == 0 {
+		fmt.Fprintln(out, "FAIL: Could not read process_resident_memory_bytes before the put operations.")
 		os.Exit(cobrautl.ExitError)
 	}
 
-	fmt.Printf("Start data scale check for work load [%v key-value pairs, %v bytes per key-value, %v concurrent clients].\n", cfg.limit, cfg.kvSize, cfg.clients)
-	bar := pb.New(cfg.limit)
-	bar.Start()
+	fmt.Fprintf(out, "Start data scale check for work load [%v key-value pairs, %v bytes per key-value, %v concurrent clients].\n", cfg.limit, cfg.kvSize, cfg.clients)
+	progress := newDatascaleProgress(cfg.limit, checkDatascaleProgressInterval)
 
 	for i := range clients {
 		go func(c *v3.Client) {
@@ -376,7 +468,7 @@ func newCheckDatascaleCommand(cmd *cobra.Command, args []string) {
 				st := time.Now()
 				_, derr := c.Do(context.Background(), op)
 				r.Results() <- report.Result{Err: derr, Start: st, End: time.Now()}
-				bar.Increment()
+				progress.increment(derr)
 			}
 		}(clients[i])
 	}
@@ -389,18 +481,38 @@ func newCheckDatascaleCommand(cmd *cobra.Command, args []string) {
 		close(requests)
 	}()
 
+	start := time.Now()
 	sc := r.Stats()
 	wg.Wait()
 	close(r.Results())
-	bar.Finish()
+	progress.finish()
 	s := <-sc
 
-	// get the process_resident_memory_bytes after the put operations
-	bytesAfter := endpointMemoryMetrics(eps[0], sec)
-	if bytesAfter == 0 {
-		fmt.Println("FAIL: Could not read process_resident_memory_bytes after the put operations.")
-		os.Exit(cobrautl.ExitError)
+	rep := checkDatascaleReport{
+		Load:            model,
+		Keys:            cfg.limit,
+		Errors:          s.ErrorDist,
+		DurationSeconds: time.Since(start).Seconds(),
+	}
+	rep.KeysWritten = cfg.limit
+	for _, n := range s.ErrorDist {
+		rep.KeysWritten -= n
+	}
+
+	// get the db sizes and process_resident_memory_bytes after the put operations
+	for i := range members {
+		m := &members[i]
+		m.DBSizeAfter, m.MemoryAfter = memberDatascaleUsage(clients[0], m.Endpoint, sec)
+		switch {
+		case m.MemoryBefore == 0 || m.MemoryAfter == 0:
+			m.Error = "could not read process_resident_memory_bytes"
+		case m.DBSizeBefore == 0 || m.DBSizeAfter == 0:
+			m.Error = "could not read the db size"
+		}
+		m.DBGrowth = m.DBSizeAfter - m.DBSizeBefore
+		m.MemoryGrowth = m.MemoryAfter - m.MemoryBefore
 	}
+	rep.Members = members
 
 	// delete the created kv pairs
 	ctx, cancel = context.WithCancel(context.Background())
@@ -411,30 +523,411 @@ func newCheckDatascaleCommand(cmd *cobra.Command, args []string) {
 	}
 
 	if autoCompact {
-		compact(clients[0], dresp.Header.Revision)
+		compact(out, clients[0], dresp.Header.Revision)
 	}
 
 	if autoDefrag {
 		for _, ep := range clients[0].Endpoints() {
-			defrag(clients[0], ep)
+			defrag(out, clients[0], ep)
 		}
 	}
 
-	if bytesAfter == 0 {
-		fmt.Println("FAIL: Could not read process_resident_memory_bytes after the put operations.")
+	display.CheckDatascale(rep)
+
+	if members[0].MemoryAfter == 0 {
+		fmt.Fprintln(out, "FAIL: Could not read process_resident_memory_bytes after the put operations.")
 		os.Exit(cobrautl.ExitError)
 	}
 
-	bytesUsed := bytesAfter - bytesBefore
-	mbUsed := bytesUsed / (1024 * 1024)
+	mbUsed := members[0].MemoryGrowth / (1024 * 1024)
 
 	if len(s.ErrorDist) != 0 {
-		fmt.Println("FAIL: too many errors")
+		fmt.Fprintln(out, "FAIL: too many errors")
 		for k, v := range s.ErrorDist {
-			fmt.Printf("FAIL: ERROR(%v) -> %d\n", k, v)
+			fmt.Fprintf(out, "FAIL: ERROR(%v) -> %d\n", k, v)
+		}
+		os.Exit(cobrautl.ExitError)
+	} else {
+		fmt.Fprintf(out, "PASS: Approximate system memory used : %v MB.\n", strconv.FormatFloat(mbUsed, 'f', 2, 64))
+	}
+}
+
+// memberDatascaleUsage returns the db size and the resident memory of the
+// member serving the given endpoint, or zeros if they can't be read.
+func memberDatascaleUsage(c *v3.Client, ep string, sec *v3.SecureConfig) (int64, float64) {
+	var dbSize int64
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	resp, err := c.Status(ctx, ep)
+	cancel()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to get the status of endpoint %s (%v)\n", ep, err)
+	} else {
+		dbSize = resp.DbSize
+	}
+	return dbSize, endpointMemoryMetrics(ep, sec)
+}
+
+// cleanupDatascale deletes the keys left under the prefix by an interrupted
+// datascale check.
+func cleanupDatascale(out io.Writer, c *v3.Client) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	dresp, err := c.Delete(ctx, checkDatascalePrefix, v3.WithPrefix())
+	cancel()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	fmt.Fprintf(out, "Deleted %d key-value pairs under prefix %q\n", dresp.Deleted, checkDatascalePrefix)
+	if autoCompact {
+		compact(out, c, dresp.Header.Revision)
+	}
+	if autoDefrag {
+		for _, ep := range c.Endpoints() {
+			defrag(out, c, ep)
+		}
+	}
+}
+
+// datascaleProgress reports the progress of the writes of a datascale check:
+// with a progress bar if stderr is a terminal, otherwise with a line every
+// interval, so that the progress shows in logs.
+type datascaleProgress struct {
+	total   int
+	bar     *pb.ProgressBar
+	done    atomic.Int64
+	errs    atomic.Int64
+	stopc   chan struct{}
+	stopped chan struct{}
+}
+
+func newDatascaleProgress(total int, interval time.Duration) *datascaleProgress {
+	p := &datascaleProgress{total: total, stopc: make(chan struct{}), stopped: make(chan struct{})}
+	if isatty.IsTerminal(os.Stderr.Fd()) {
+		p.bar = pb.New(total)
+		p.bar.Start()
+		close(p.stopped)
+		return p
+	}
+	if interval <= 0 {
+		close(p.stopped)
+		return p
+	}
+	go func() {
+		defer close(p.stopped)
+		start := time.Now()
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				p.print(time.Since(start))
+			case <-p.stopc:
+				return
+			}
+		}
+	}()
+	return p
+}
+
+func (p *datascaleProgress) increment(err error) {
+	p.done.Add(1)
+	if err != nil {
+		p.errs.Add(1)
+	}
+	if p.bar != nil {
+		p.bar.Increment()
+	}
+}
+
+func (p *datascaleProgress) print(elapsed time.Duration) {
+	done := p.done.Load()
+	fmt.Fprintf(os.Stderr, "%v: %d/%d key-value pairs written (%.1f%%), %d errors\n",
+		elapsed.Round(time.Second), done, p.total, float64(done)*100/float64(p.total), p.errs.Load())
+}
+
+func (p *datascaleProgress) finish() {
+	if p.bar != nil {
+		p.bar.Finish()
+		return
+	}
+	close(p.stopc)
+	<-p.stopped
+}
+
+// NewCheckHashKVCommand returns the cobra command for "check hashkv".
+func NewCheckHashKVCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hashkv [options] <key> [range_end]",
+		Short: "Check that a key range hashes to the same value on every endpoint",
+		Long: `Hashes the given key range on each endpoint at the same revision and reports an
+error if any two endpoints disagree. Unless --rev is given, the revision is the
+latest revision of the first endpoint.
+`,
+		Run: newCheckHashKVCommand,
+	}
+
+	cmd.Flags().BoolVar(&checkHashKVPrefix, "prefix", false, "Check the keys with matching prefix")
+	cmd.Flags().Int64Var(&checkHashKVRev, "rev", 0, "Revision to hash the range at (default: latest revision)")
+	cmd.Flags().BoolVar(&epClusterEndpoints, "cluster", false, "use all endpoints from the cluster member list")
+
+	return cmd
+}
+
+// newCheckHashKVCommand executes the "check hashkv" command.
+func newCheckHashKVCommand(cmd *cobra.Command, args []string) {
+	if len(args) == 0 || len(args) > 2 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("check hashkv command needs one argument as key and an optional argument as range_end"))
+	}
+	key, end := args[0], ""
+	if len(args) == 2 {
+		if checkHashKVPrefix {
+			cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("too many arguments, only accept one argument when `--prefix` is set"))
+		}
+		end = args[1]
+	}
+	if checkHashKVPrefix {
+		end = v3.GetPrefixRangeEnd(key)
+		if key == "" {
+			key, end = "\x00", "\x00"
+		}
+	}
+
+	cfg := clientConfigFromCmd(cmd)
+	rev := checkHashKVRev
+	var first *v3.HashKVRangeResponse
+	var firstEp string
+	mismatch := false
+	for _, ep := range endpointsFromCluster(cmd) {
+		cfg.Endpoints = []string{ep}
+		c := mustClient(cfg)
+		ctx, cancel := commandCtx(cmd)
+		resp, err := c.HashKVRange(ctx, ep, key, end, rev, false)
+		cancel()
+		c.Close()
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("failed to hash range on endpoint %s: %w", ep, err))
+		}
+		fmt.Printf("%s: hash %d, revision %d, keys %d\n", ep, resp.Hash, resp.HashRevision, resp.Count)
+
+		if first == nil {
+			first, firstEp = resp, ep
+			// pin the remaining endpoints to the revision hashed on the first one
+			rev = resp.HashRevision
+			continue
+		}
+		if resp.Hash != first.Hash || resp.Count != first.Count {
+			fmt.Printf("FAIL: %s does not match %s at revision %d\n", ep, firstEp, rev)
+			mismatch = true
+		}
+	}
+
+	if mismatch {
+		cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("hashes of range differ between endpoints"))
+	}
+	fmt.Println("PASS: hashes of range match on all endpoints")
+}
+
+// NewCheckWatchLatencyCommand returns the cobra command for "check watch-latency".
+func NewCheckWatchLatencyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watch-latency [options]",
+		Short: "Check the latency of delivering watch events on the etcd cluster",
+		Long: `Writes keys at a steady rate while watchers watch them, and measures the time from each write
+to the delivery of its event to every watcher.
+`,
+		Run: newCheckWatchLatencyCommand,
+	}
+
+	cmd.Flags().StringVar(&checkWatchLatencyLoad, "load", "s", "The watch latency check's workload model. Accepted workloads: s(small), m(medium), l(large), xl(xLarge). Different workload models use different write rates, numbers of watchers and numbers of clients.")
+	cmd.Flags().StringVar(&checkWatchLatencyPrefix, "prefix", "/etcdctl-check-watch-latency/", "The prefix for writing the watch latency check's keys.")
+	cmd.Flags().BoolVar(&autoCompact, "auto-compact", false, "Compact storage with last revision after test is finished.")
+	cmd.Flags().BoolVar(&autoDefrag, "auto-defrag", false, "Defragment storage after test is finished.")
+	cmd.RegisterFlagCompletionFunc("load", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return []string{"small", "medium", "large", "xLarge"}, cobra.ShellCompDirectiveDefault
+	})
+
+	return cmd
+}
+
+// newCheckWatchLatencyCommand executes the "check watch-latency" command.
+func newCheckWatchLatencyCommand(cmd *cobra.Command, args []string) {
+	var checkWatchLatencyAlias = map[string]string{
+		"s": "s", "small": "s",
+		"m": "m", "medium": "m",
+		"l": "l", "large": "l",
+		"xl": "xl", "xLarge": "xl",
+	}
+
+	model, ok := checkWatchLatencyAlias[checkWatchLatencyLoad]
+	if !ok {
+		cobrautl.ExitWithError(cobrautl.ExitBadFeature, fmt.Errorf("unknown load option %v", checkWatchLatencyLoad))
+	}
+	cfg := checkWatchLatencyCfgMap[model]
+
+	cc := clientConfigFromCmd(cmd)
+	clients := make([]*v3.Client, cfg.clients)
+	for i := 0; i < cfg.clients; i++ {
+		clients[i] = mustClient(cc)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx, icancel := interruptableContext(ctx, func() { attemptCleanup(clients[0], checkWatchLatencyPrefix, false) })
+	defer icancel()
+
+	gctx, gcancel := context.WithCancel(ctx)
+	resp, err := clients[0].Get(gctx, checkWatchLatencyPrefix, v3.WithPrefix(), v3.WithLimit(1))
+	gcancel()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	if len(resp.Kvs) > 0 {
+		cobrautl.ExitWithError(cobrautl.ExitInvalidInput, fmt.Errorf("prefix %q has keys. Delete with 'etcdctl del --prefix %s' first", checkWatchLatencyPrefix, checkWatchLatencyPrefix))
+	}
+
+	r := report.NewReport("%4.4f")
+	sc := r.Stats()
+
+	// the value of each key is the time it's written at, so that every
+	// watcher measures the latency of the event delivered to it.
+	var (
+		watchWg   sync.WaitGroup
+		delivered atomic.Int64
+	)
+	wctx, wcancel := context.WithCancel(ctx)
+	defer wcancel()
+	for i := 0; i < cfg.watchers; i++ {
+		wch := clients[i%len(clients)].Watch(wctx, checkWatchLatencyPrefix, v3.WithPrefix(), v3.WithCreatedNotify())
+		if wresp, ok := <-wch; !ok || wresp.Err() != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("failed to create watcher (%v)", wresp.Err()))
+		}
+		watchWg.Add(1)
+		go func() {
+			defer watchWg.Done()
+			for wresp := range wch {
+				now := time.Now()
+				if werr := wresp.Err(); werr != nil {
+					if wctx.Err() == nil {
+						r.Results() <- report.Result{Err: werr, Start: now, End: now}
+					}
+					continue
+				}
+				for _, ev := range wresp.Events {
+					if ev.Type != v3.EventTypePut {
+						continue
+					}
+					ts, perr := strconv.ParseInt(string(ev.Kv.Value), 10, 64)
+					if perr != nil {
+						continue
+					}
+					r.Results() <- report.Result{Start: time.Unix(0, ts), End: now}
+					delivered.Add(1)
+				}
+			}
+		}()
+	}
+
+	fmt.Printf("Start watch latency check for work load [%v writes/s, %v watchers, %v concurrent clients, %vs].\n", cfg.rate, cfg.watchers, cfg.clients, cfg.duration)
+	bar := pb.New(cfg.duration)
+	bar.Start()
+	go func() {
+		for i := 0; i < cfg.duration; i++ {
+			time.Sleep(time.Second)
+			bar.Add(1)
 		}
+		bar.Finish()
+	}()
+
+	var (
+		putWg   sync.WaitGroup
+		written atomic.Int64
+	)
+	limit := rate.NewLimiter(rate.Limit(cfg.rate), 1)
+	dctx, dcancel := context.WithTimeout(ctx, time.Duration(cfg.duration)*time.Second)
+	for i := 0; limit.Wait(dctx) == nil; i++ {
+		putWg.Add(1)
+		go func(c *v3.Client) {
+			defer putWg.Done()
+			st := time.Now()
+			_, perr := c.Put(ctx, fmt.Sprintf("%s%08d", checkWatchLatencyPrefix, i), strconv.FormatInt(st.UnixNano(), 10))
+			if perr != nil {
+				r.Results() <- report.Result{Err: perr, Start: st, End: time.Now()}
+				return
+			}
+			written.Add(1)
+		}(clients[i%len(clients)])
+	}
+	dcancel()
+	putWg.Wait()
+
+	// give the watchers some time to receive the last events
+	expected := written.Load() * int64(cfg.watchers)
+	deadline := time.Now().Add(5 * time.Second)
+	for delivered.Load() < expected && time.Now().Before(deadline) && ctx.Err() == nil {
+		time.Sleep(10 * time.Millisecond)
+	}
+	wcancel()
+	watchWg.Wait()
+	close(r.Results())
+	s := <-sc
+
+	attemptCleanup(clients[0], checkWatchLatencyPrefix, autoCompact)
+
+	if autoDefrag {
+		for _, ep := range clients[0].Endpoints() {
+			defrag(os.Stdout, clients[0], ep)
+		}
+	}
+
+	if checkWatchLatencyResult(os.Stdout, s, delivered.Load(), expected) {
+		fmt.Println("PASS")
+	} else {
+		fmt.Println("FAIL")
 		os.Exit(cobrautl.ExitError)
+	}
+}
+
+// checkWatchLatencyResult prints the result of the watch latency check, given
+// the latencies of the delivered events and the number of events expected to
+// be delivered, and returns whether the check passed.
+func checkWatchLatencyResult(w io.Writer, s report.Stats, delivered, expected int64) bool {
+	if len(s.Lats) > 0 {
+		fmt.Fprintf(w, "Watch event latency: fastest %.4fs, average %.4fs, p50 %.4fs, p90 %.4fs, p99 %.4fs, slowest %.4fs\n",
+			s.Fastest, s.Average, percentile(s.Lats, 50), percentile(s.Lats, 90), percentile(s.Lats, 99), s.Slowest)
+	}
+
+	ok := true
+	if len(s.ErrorDist) != 0 {
+		fmt.Fprintln(w, "FAIL: too many errors")
+		errs := make([]string, 0, len(s.ErrorDist))
+		for k := range s.ErrorDist {
+			errs = append(errs, k)
+		}
+		sort.Strings(errs)
+		for _, k := range errs {
+			fmt.Fprintf(w, "FAIL: ERROR(%v) -> %d\n", k, s.ErrorDist[k])
+		}
+		ok = false
+	}
+	if delivered < expected {
+		fmt.Fprintf(w, "FAIL: %d of %d watch events were not delivered\n", expected-delivered, expected)
+		ok = false
 	} else {
-		fmt.Printf("PASS: Approximate system memory used : %v MB.\n", strconv.FormatFloat(mbUsed, 'f', 2, 64))
+		fmt.Fprintf(w, "PASS: All %d watch events were delivered\n", expected)
+	}
+	if p99 := percentile(s.Lats, 99); p99 > checkWatchLatencyMaxP99.Seconds() {
+		fmt.Fprintf(w, "FAIL: 99th percentile latency too high: %fs\n", p99)
+		ok = false
+	} else {
+		fmt.Fprintf(w, "PASS: 99th percentile latency is %fs\n", p99)
+	}
+	return ok
+}
+
+// percentile returns the p-th percentile of the sorted latencies.
+func percentile(lats []float64, p float64) float64 {
+	if len(lats) == 0 {
+		return 0
 	}
+	i := int(math.Ceil(p/100*float64(len(lats)))) - 1
+	return lats[max(i, 0)]
 }
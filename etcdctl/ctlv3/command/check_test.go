@@ -0,0 +1,107 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"testing"
+
+	"go.etcd.io/etcd/pkg/v3/report"
+)
+
+func Test_percentile(t *testing.T) {
+	lats := []float64{0.01, 0.02, 0.03, 0.04, 0.05, 0.06, 0.07, 0.08, 0.09, 0.1}
+	for _, tc := range []struct {
+		p    float64
+		want float64
+	}{
+		{p: 0, want: 0.01},
+		{p: 50, want: 0.05},
+		{p: 90, want: 0.09},
+		{p: 99, want: 0.1},
+		{p: 100, want: 0.1},
+	} {
+		if got := percentile(lats, tc.p); got != tc.want {
+			t.Errorf("percentile(%v) = %v, want %v", tc.p, got, tc.want)
+		}
+	}
+	if got := percentile(nil, 99); got != 0 {
+		t.Errorf("percentile of no latencies = %v, want 0", got)
+	}
+}
+
+func Test_checkWatchLatencyResult(t *testing.T) {
+	fast := report.Stats{Fastest: 0.001, Average: 0.002, Slowest: 0.01, Lats: []float64{0.001, 0.002, 0.01}}
+
+	tt := []struct {
+		name      string
+		stats     report.Stats
+		delivered int64
+		expected  int64
+
+		ok   bool
+		want string
+	}{
+		{
+			name:      "pass",
+			stats:     fast,
+			delivered: 3,
+			expected:  3,
+			ok:        true,
+			want: "Watch event latency: fastest 0.0010s, average 0.0020s, p50 0.0020s, p90 0.0100s, p99 0.0100s, slowest 0.0100s\n" +
+				"PASS: All 3 watch events were delivered\n" +
+				"PASS: 99th percentile latency is 0.010000s\n",
+		},
+		{
+			name:      "undelivered events",
+			stats:     fast,
+			delivered: 2,
+			expected:  3,
+			want: "Watch event latency: fastest 0.0010s, average 0.0020s, p50 0.0020s, p90 0.0100s, p99 0.0100s, slowest 0.0100s\n" +
+				"FAIL: 1 of 3 watch events were not delivered\n" +
+				"PASS: 99th percentile latency is 0.010000s\n",
+		},
+		{
+			name:      "slow events",
+			stats:     report.Stats{Fastest: 0.001, Average: 0.1, Slowest: 0.2, Lats: []float64{0.001, 0.1, 0.2}},
+			delivered: 3,
+			expected:  3,
+			want: "Watch event latency: fastest 0.0010s, average 0.1000s, p50 0.1000s, p90 0.2000s, p99 0.2000s, slowest 0.2000s\n" +
+				"PASS: All 3 watch events were delivered\n" +
+				"FAIL: 99th percentile latency too high: 0.200000s\n",
+		},
+		{
+			name:     "errors",
+			stats:    report.Stats{ErrorDist: map[string]int{"timeout": 2, "no leader": 1}},
+			expected: 0,
+			want: "FAIL: too many errors\n" +
+				"FAIL: ERROR(no leader) -> 1\n" +
+				"FAIL: ERROR(timeout) -> 2\n" +
+				"PASS: All 0 watch events were delivered\n" +
+				"PASS: 99th percentile latency is 0.000000s\n",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if ok := checkWatchLatencyResult(&b, tc.stats, tc.delivered, tc.expected); ok != tc.ok {
+				t.Errorf("check passed = %v, want %v", ok, tc.ok)
+			}
+			if b.String() != tc.want {
+				t.Errorf("output = %q, want %q", b.String(), tc.want)
+			}
+		})
+	}
+}
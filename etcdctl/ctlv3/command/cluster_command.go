@@ -0,0 +1,138 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+)
+
+var clusterEventsWatch bool
+
+// NewClusterCommand returns the cobra command for "cluster".
+func NewClusterCommand() *cobra.Command {
+	cc := &cobra.Command{
+		Use:   "cluster <subcommand>",
+		Short: "Cluster related commands",
+	}
+
+	cc.AddCommand(NewClusterEventsCommand())
+	cc.AddCommand(NewClusterStatusCommand())
+	cc.AddCommand(NewClusterUpgradeCommand())
+	cc.AddCommand(NewClusterDowngradeCommand())
+	cc.AddCommand(NewClusterSetReadOnlyCommand())
+	cc.AddCommand(NewClusterSetReadWriteCommand())
+
+	return cc
+}
+
+func NewClusterEventsCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "events",
+		Short: "Lists the latest cluster events observed by the member, optionally watching for new ones",
+		Run:   clusterEventsCommandFunc,
+	}
+	cmd.Flags().BoolVarP(&clusterEventsWatch, "watch", "w", false, "Keep watching for new cluster events")
+	return &cmd
+}
+
+// clusterEventsCommandFunc executes the "cluster events" command.
+func clusterEventsCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("cluster events command accepts no arguments"))
+	}
+	c := mustClientFromCmd(cmd)
+	defer c.Close()
+	eps := c.Endpoints()
+	if len(eps) == 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("no endpoint"))
+	}
+
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if clusterEventsWatch {
+		ctx, cancel = context.WithCancel(context.Background())
+	} else {
+		ctx, cancel = commandCtx(cmd)
+	}
+	defer cancel()
+
+	stream, err := c.ClusterEvents(ctx, eps[0], clusterEventsWatch)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	defer stream.Close()
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, err)
+		}
+		display.ClusterEvents(*resp)
+	}
+}
+
+func NewClusterSetReadOnlyCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set-read-only",
+		Short: "Makes the cluster reject all mutations while still serving reads and watches",
+		Run:   clusterSetReadOnlyCommandFunc,
+	}
+}
+
+func NewClusterSetReadWriteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set-read-write",
+		Short: "Makes a read-only cluster accept mutations again",
+		Run:   clusterSetReadWriteCommandFunc,
+	}
+}
+
+// clusterSetReadOnlyCommandFunc executes the "cluster set-read-only" command.
+func clusterSetReadOnlyCommandFunc(cmd *cobra.Command, args []string) {
+	setClusterReadOnly(cmd, args, true)
+}
+
+// clusterSetReadWriteCommandFunc executes the "cluster set-read-write" command.
+func clusterSetReadWriteCommandFunc(cmd *cobra.Command, args []string) {
+	setClusterReadOnly(cmd, args, false)
+}
+
+func setClusterReadOnly(cmd *cobra.Command, args []string, readOnly bool) {
+	if len(args) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("%s command accepts no arguments", cmd.Name()))
+	}
+	ctx, cancel := commandCtx(cmd)
+	_, err := mustClientFromCmd(cmd).SetReadOnly(ctx, readOnly)
+	cancel()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	if readOnly {
+		fmt.Println("Cluster is read-only")
+	} else {
+		fmt.Println("Cluster is read-write")
+	}
+}
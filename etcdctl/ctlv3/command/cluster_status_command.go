@@ -0,0 +1,205 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/client/pkg/v3/logutil"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+)
+
+// clusterStatus is the aggregated status of all the members of a cluster.
+type clusterStatus struct {
+	ClusterID uint64 `json:"cluster_id"`
+	Leader    uint64 `json:"leader"`
+	// RaftIndex is the raft index of the leader.
+	RaftIndex uint64                `json:"raft_index"`
+	Members   []clusterMemberStatus `json:"members"`
+	Alarms    []*pb.AlarmMember     `json:"alarms,omitempty"`
+	// HashRevision is the revision the members hashed their key-value store at.
+	HashRevision int64 `json:"hash_revision"`
+	// HashMismatch is set if members with the same compact revision have
+	// different hashes at HashRevision.
+	HashMismatch bool `json:"hash_mismatch"`
+}
+
+// clusterMemberStatus is the status of a member of a cluster.
+type clusterMemberStatus struct {
+	ID       uint64 `json:"id"`
+	Name     string `json:"name"`
+	Endpoint string `json:"endpoint"`
+	// Lag is the number of raft entries the member has to apply to catch up
+	// with the raft index of the leader.
+	Lag    uint64                   `json:"lag"`
+	Status *clientv3.StatusResponse `json:"status,omitempty"`
+	HashKV *clientv3.HashKVResponse `json:"hash_kv,omitempty"`
+	Error  string                   `json:"error,omitempty"`
+}
+
+// NewClusterStatusCommand returns the cobra command for "cluster status".
+func NewClusterStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Reports the status of all the members of the cluster: raft indexes and lag, db size and quota, leader, alarms, storage versions and hash mismatches",
+		Run:   clusterStatusCommandFunc,
+	}
+}
+
+// clusterStatusCommandFunc executes the "cluster status" command.
+func clusterStatusCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("cluster status command accepts no arguments"))
+	}
+
+	c := mustClientFromCmd(cmd)
+	ctx, cancel := commandCtx(cmd)
+	mresp, err := c.MemberList(ctx)
+	if err != nil {
+		cancel()
+		c.Close()
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	aresp, err := c.AlarmList(ctx)
+	cancel()
+	c.Close()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+
+	cs := clusterStatus{ClusterID: mresp.Header.ClusterId, Alarms: aresp.Alarms}
+	for _, m := range mresp.Members {
+		ms := clusterMemberStatus{ID: m.ID, Name: m.Name}
+		if len(m.ClientURLs) == 0 {
+			ms.Error = "member has not started"
+		} else {
+			ms.Endpoint = m.ClientURLs[0]
+		}
+		cs.Members = append(cs.Members, ms)
+	}
+
+	cc := clientConfigFromCmd(cmd)
+	forEachMember(cs.Members, func(ms *clusterMemberStatus, c *clientv3.Client) error {
+		ctx, cancel := commandCtx(cmd)
+		defer cancel()
+		resp, err := c.Status(ctx, ms.Endpoint)
+		ms.Status = resp
+		return err
+	}, cc)
+
+	// hash all the members at the lowest revision they all reached
+	for _, ms := range cs.Members {
+		if ms.Status == nil {
+			continue
+		}
+		if ms.Status.Leader == ms.ID {
+			cs.Leader, cs.RaftIndex = ms.ID, ms.Status.RaftIndex
+		}
+		if rev := ms.Status.Header.Revision; cs.HashRevision == 0 || rev < cs.HashRevision {
+			cs.HashRevision = rev
+		}
+	}
+	forEachMember(cs.Members, func(ms *clusterMemberStatus, c *clientv3.Client) error {
+		if ms.Status == nil {
+			return nil
+		}
+		ctx, cancel := commandCtx(cmd)
+		defer cancel()
+		resp, err := c.HashKV(ctx, ms.Endpoint, cs.HashRevision)
+		ms.HashKV = resp
+		return err
+	}, cc)
+
+	checkClusterStatus(&cs)
+	display.ClusterStatus(cs)
+
+	if !cs.healthy() {
+		cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("unhealthy cluster"))
+	}
+}
+
+// forEachMember calls f concurrently with a client of each member with an
+// endpoint and no error, recording the error returned by f.
+func forEachMember(members []clusterMemberStatus, f func(*clusterMemberStatus, *clientv3.Client) error, cc *clientv3.ConfigSpec) {
+	lg, _ := logutil.CreateDefaultZapLogger(zap.InfoLevel)
+	var wg sync.WaitGroup
+	for i := range members {
+		ms := &members[i]
+		if ms.Endpoint == "" || ms.Error != "" {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			spec := *cc
+			spec.Endpoints = []string{ms.Endpoint}
+			cfg, err := clientv3.NewClientConfig(&spec, lg)
+			if err != nil {
+				ms.Error = err.Error()
+				return
+			}
+			c, err := clientv3.New(*cfg)
+			if err != nil {
+				ms.Error = err.Error()
+				return
+			}
+			defer c.Close()
+			if err = f(ms, c); err != nil {
+				ms.Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// checkClusterStatus computes the lag of the members behind the leader and
+// whether the hashes of the members mismatch.
+func checkClusterStatus(cs *clusterStatus) {
+	hashes := make(map[int64]uint32)
+	for i := range cs.Members {
+		ms := &cs.Members[i]
+		if ms.Status != nil && cs.RaftIndex > ms.Status.RaftAppliedIndex {
+			ms.Lag = cs.RaftIndex - ms.Status.RaftAppliedIndex
+		}
+		if ms.HashKV == nil {
+			continue
+		}
+		// members only have the same hash if they compacted the same revisions
+		if h, ok := hashes[ms.HashKV.CompactRevision]; ok && h != ms.HashKV.Hash {
+			cs.HashMismatch = true
+		}
+		hashes[ms.HashKV.CompactRevision] = ms.HashKV.Hash
+	}
+}
+
+// healthy returns true if all the members are reachable, the cluster has a
+// leader and no alarm, and the hashes of the members match.
+func (cs clusterStatus) healthy() bool {
+	if cs.Leader == 0 || len(cs.Alarms) != 0 || cs.HashMismatch {
+		return false
+	}
+	for _, ms := range cs.Members {
+		if ms.Error != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"testing"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func Test_checkClusterStatus(t *testing.T) {
+	member := func(id uint64, applied uint64, compactRev int64, hash uint32) clusterMemberStatus {
+		return clusterMemberStatus{
+			ID:     id,
+			Status: &clientv3.StatusResponse{Leader: 1, RaftAppliedIndex: applied},
+			HashKV: &clientv3.HashKVResponse{CompactRevision: compactRev, Hash: hash},
+		}
+	}
+
+	tt := []struct {
+		name    string
+		members []clusterMemberStatus
+		alarms  []*pb.AlarmMember
+
+		lags     []uint64
+		mismatch bool
+		healthy  bool
+	}{
+		{
+			name:    "healthy cluster",
+			members: []clusterMemberStatus{member(1, 10, 5, 42), member(2, 8, 5, 42)},
+			lags:    []uint64{0, 2},
+			healthy: true,
+		},
+		{
+			name:     "hash mismatch",
+			members:  []clusterMemberStatus{member(1, 10, 5, 42), member(2, 10, 5, 43)},
+			lags:     []uint64{0, 0},
+			mismatch: true,
+		},
+		{
+			name:    "different compact revisions are not compared",
+			members: []clusterMemberStatus{member(1, 10, 5, 42), member(2, 10, 6, 43)},
+			lags:    []uint64{0, 0},
+			healthy: true,
+		},
+		{
+			name:    "alarm",
+			members: []clusterMemberStatus{member(1, 10, 5, 42)},
+			alarms:  []*pb.AlarmMember{{MemberID: 1, Alarm: pb.AlarmType_NOSPACE}},
+			lags:    []uint64{0},
+		},
+		{
+			name:    "unreachable member",
+			members: []clusterMemberStatus{member(1, 10, 5, 42), {ID: 2, Error: "context deadline exceeded"}},
+			lags:    []uint64{0, 0},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			cs := clusterStatus{Leader: 1, RaftIndex: 10, Members: tc.members, Alarms: tc.alarms}
+			checkClusterStatus(&cs)
+			for i, ms := range cs.Members {
+				if ms.Lag != tc.lags[i] {
+					t.Errorf("member %x: expected lag %d, got %d", ms.ID, tc.lags[i], ms.Lag)
+				}
+			}
+			if cs.HashMismatch != tc.mismatch {
+				t.Errorf("expected hash mismatch %v, got %v", tc.mismatch, cs.HashMismatch)
+			}
+			if cs.healthy() != tc.healthy {
+				t.Errorf("expected healthy %v, got %v", tc.healthy, cs.healthy())
+			}
+		})
+	}
+}
@@ -0,0 +1,348 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"go.etcd.io/etcd/client/pkg/v3/logutil"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+)
+
+var (
+	clusterVersionTarget         string
+	clusterVersionRestartCommand string
+	clusterVersionMemberTimeout  time.Duration
+)
+
+// NewClusterUpgradeCommand returns the cobra command for "cluster upgrade".
+func NewClusterUpgradeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "upgrade --target <version>",
+		Short: "Upgrades the cluster to the next minor version, one member at a time",
+		Long: `Checks that every member runs either the current or the target minor version,
+then goes through the members that still run the current version, followers
+first and the leader last. Each member is restarted, either by the command given
+with --restart-command or by the operator, and the next member is only restarted
+once it has rejoined the cluster healthy at the target version.
+`,
+		Run: clusterUpgradeCommandFunc,
+	}
+	addClusterVersionFlags(cmd)
+	return cmd
+}
+
+// NewClusterDowngradeCommand returns the cobra command for "cluster downgrade".
+func NewClusterDowngradeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "downgrade --target <version>",
+		Short: "Downgrades the cluster to the previous minor version, one member at a time",
+		Long: `Checks that every member runs either the current or the target minor version,
+enables the downgrade and waits for the storage version of every member to be
+downgraded. It then restarts the members one at a time as "cluster upgrade" does.
+`,
+		Run: clusterDowngradeCommandFunc,
+	}
+	addClusterVersionFlags(cmd)
+	return cmd
+}
+
+func addClusterVersionFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&clusterVersionTarget, "target", "", "Minor version to move the cluster to, e.g. 3.6")
+	cmd.Flags().StringVar(&clusterVersionRestartCommand, "restart-command", "", "Shell command restarting a member with the target version. The member is passed in the ETCD_MEMBER_NAME, ETCD_MEMBER_ID and ETCD_MEMBER_CLIENT_URLS environment variables. If not set, the operator is expected to restart the members")
+	cmd.Flags().DurationVar(&clusterVersionMemberTimeout, "member-timeout", 5*time.Minute, "Time to wait for a member to be restarted and healthy at the target version")
+}
+
+// clusterMember is a member of the cluster with the version it runs.
+type clusterMember struct {
+	*pb.Member
+	version *semver.Version
+	leader  bool
+}
+
+// clusterUpgradeCommandFunc executes the "cluster upgrade" command.
+func clusterUpgradeCommandFunc(cmd *cobra.Command, args []string) {
+	changeClusterVersion(cmd, args, false)
+}
+
+// clusterDowngradeCommandFunc executes the "cluster downgrade" command.
+func clusterDowngradeCommandFunc(cmd *cobra.Command, args []string) {
+	changeClusterVersion(cmd, args, true)
+}
+
+func changeClusterVersion(cmd *cobra.Command, args []string, downgrade bool) {
+	if len(args) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("%s command accepts no arguments", cmd.Name()))
+	}
+	target, err := parseTargetVersion(clusterVersionTarget)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+
+	members, err := clusterMembers(cmd)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	pending, err := pendingMembers(members, target, downgrade)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadFeature, err)
+	}
+	if len(pending) == 0 {
+		fmt.Printf("All members already run etcd %d.%d\n", target.Major, target.Minor)
+		return
+	}
+
+	if downgrade {
+		if err = enableDowngrade(cmd, members, target); err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, err)
+		}
+	}
+	for _, m := range pending {
+		if err = restartMember(cmd, m, target); err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, err)
+		}
+	}
+	fmt.Printf("PASS: all members run etcd %d.%d\n", target.Major, target.Minor)
+}
+
+// parseTargetVersion parses a minor version, e.g. "3.6" or "3.6.0".
+func parseTargetVersion(v string) (*semver.Version, error) {
+	if v == "" {
+		return nil, errors.New("--target is required")
+	}
+	if strings.Count(v, ".") == 1 {
+		v += ".0"
+	}
+	ver, err := semver.NewVersion(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target version %q: %w", clusterVersionTarget, err)
+	}
+	return ver, nil
+}
+
+// clusterMembers returns the members of the cluster with the versions they run.
+func clusterMembers(cmd *cobra.Command) ([]clusterMember, error) {
+	c := mustClientFromCmd(cmd)
+	ctx, cancel := commandCtx(cmd)
+	resp, err := c.MemberList(ctx)
+	cancel()
+	c.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var members []clusterMember
+	for _, m := range resp.Members {
+		if len(m.ClientURLs) == 0 {
+			return nil, fmt.Errorf("member %x has not started", m.ID)
+		}
+		st, err := memberStatus(cmd, m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the status of member %s: %w", m.Name, err)
+		}
+		ver, err := semver.NewVersion(st.Version)
+		if err != nil {
+			return nil, fmt.Errorf("member %s reported invalid version %q: %w", m.Name, st.Version, err)
+		}
+		members = append(members, clusterMember{Member: m, version: ver, leader: st.Leader == m.ID})
+	}
+	return members, nil
+}
+
+// pendingMembers returns the members which still have to be restarted with
+// the target version, in the order to restart them: learners, followers, and
+// the leader last, so that the leadership changes at most once. It fails if
+// a member runs neither the target version nor the version the cluster is
+// moved from.
+func pendingMembers(members []clusterMember, target *semver.Version, downgrade bool) ([]clusterMember, error) {
+	from, direction := target.Minor-1, "upgraded"
+	if downgrade {
+		from, direction = target.Minor+1, "downgraded"
+	}
+
+	var pending []clusterMember
+	for _, m := range members {
+		if m.version.Major != target.Major || (m.version.Minor != target.Minor && m.version.Minor != from) {
+			return nil, fmt.Errorf("member %s runs etcd %s, which cannot be %s to %d.%d", m.Name, m.version, direction, target.Major, target.Minor)
+		}
+		if m.version.Minor == from {
+			pending = append(pending, m)
+		}
+	}
+
+	sortRestartOrder(pending)
+	return pending, nil
+}
+
+// sortRestartOrder sorts the members in the order to take them down one at a
+// time: learners, followers, and the leader last, so that the leadership
+// changes at most once.
+func sortRestartOrder(members []clusterMember) {
+	rank := func(m clusterMember) int {
+		switch {
+		case m.IsLearner:
+			return 0
+		case m.leader:
+			return 2
+		default:
+			return 1
+		}
+	}
+	sort.SliceStable(members, func(i, j int) bool { return rank(members[i]) < rank(members[j]) })
+}
+
+// enableDowngrade enables the downgrade of the cluster, unless it is already
+// in progress, and waits for the storage version of all members to be
+// downgraded to the target version.
+func enableDowngrade(cmd *cobra.Command, members []clusterMember, target *semver.Version) error {
+	ver := fmt.Sprintf("%d.%d", target.Major, target.Minor)
+	c := mustClientFromCmd(cmd)
+	ctx, cancel := commandCtx(cmd)
+	_, err := c.Downgrade(ctx, clientv3.DowngradeEnable, ver)
+	cancel()
+	c.Close()
+	switch {
+	case err == nil:
+		fmt.Printf("Enabled downgrade to etcd %s\n", ver)
+	case err == rpctypes.ErrDowngradeInProcess:
+		fmt.Printf("Downgrade is already in progress\n")
+	default:
+		return fmt.Errorf("failed to enable downgrade to %s: %w", ver, err)
+	}
+
+	for _, m := range members {
+		err = waitMember(cmd, m.Member, clusterVersionMemberTimeout, func(st *clientv3.StatusResponse) error {
+			sv, err := semver.NewVersion(st.StorageVersion)
+			if err != nil {
+				return fmt.Errorf("invalid storage version %q: %w", st.StorageVersion, err)
+			}
+			if sv.Major != target.Major || sv.Minor != target.Minor {
+				return fmt.Errorf("storage version is %s", st.StorageVersion)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Member %s storage version is downgraded to %s\n", m.Name, ver)
+	}
+	return nil
+}
+
+// restartMember restarts the member by running the restart command, if any,
+// and waits for it to be healthy at the target version.
+func restartMember(cmd *cobra.Command, m clusterMember, target *semver.Version) error {
+	ver := fmt.Sprintf("%d.%d", target.Major, target.Minor)
+	if clusterVersionRestartCommand != "" {
+		fmt.Printf("Restarting member %s with etcd %s\n", m.Name, ver)
+		c := exec.Command("sh", "-c", clusterVersionRestartCommand)
+		c.Env = append(os.Environ(),
+			"ETCD_MEMBER_NAME="+m.Name,
+			fmt.Sprintf("ETCD_MEMBER_ID=%x", m.ID),
+			"ETCD_MEMBER_CLIENT_URLS="+strings.Join(m.ClientURLs, ","),
+		)
+		c.Stdout, c.Stderr = os.Stdout, os.Stderr
+		if err := c.Run(); err != nil {
+			return fmt.Errorf("failed to restart member %s: %w", m.Name, err)
+		}
+	} else {
+		fmt.Printf("Restart member %s with etcd %s; waiting up to %v for it to rejoin the cluster\n", m.Name, ver, clusterVersionMemberTimeout)
+	}
+
+	err := waitMember(cmd, m.Member, clusterVersionMemberTimeout, func(st *clientv3.StatusResponse) error {
+		v, err := semver.NewVersion(st.Version)
+		if err != nil {
+			return fmt.Errorf("invalid version %q: %w", st.Version, err)
+		}
+		if v.Major != target.Major || v.Minor != target.Minor {
+			return fmt.Errorf("member runs etcd %s", st.Version)
+		}
+		return checkMemberHealthy(st)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Member %s is healthy at etcd %s\n", m.Name, ver)
+	return nil
+}
+
+// checkMemberHealthy returns an error if the member reports errors or has no
+// leader.
+func checkMemberHealthy(st *clientv3.StatusResponse) error {
+	if len(st.Errors) != 0 {
+		return errors.New(strings.Join(st.Errors, "; "))
+	}
+	if st.Leader == 0 {
+		return errors.New("member has no leader")
+	}
+	return nil
+}
+
+// waitMember polls the status of the member until check passes or the
+// timeout expires.
+func waitMember(cmd *cobra.Command, m *pb.Member, timeout time.Duration, check func(*clientv3.StatusResponse) error) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		st, err := memberStatus(cmd, m)
+		if err == nil {
+			err = check(st)
+		}
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("member %s is not ready after %v: %w", m.Name, timeout, err)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// memberClient returns a client of the member, through its client URLs.
+func memberClient(cmd *cobra.Command, m *pb.Member) (*clientv3.Client, error) {
+	cc := clientConfigFromCmd(cmd)
+	cc.Endpoints = m.ClientURLs
+	lg, _ := logutil.CreateDefaultZapLogger(zap.InfoLevel)
+	cfg, err := clientv3.NewClientConfig(cc, lg)
+	if err != nil {
+		return nil, err
+	}
+	return clientv3.New(*cfg)
+}
+
+// memberStatus returns the status of the member, through its client URLs.
+func memberStatus(cmd *cobra.Command, m *pb.Member) (*clientv3.StatusResponse, error) {
+	c, err := memberClient(cmd, m)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	ctx, cancel := commandCtx(cmd)
+	defer cancel()
+	return c.Status(ctx, m.ClientURLs[0])
+}
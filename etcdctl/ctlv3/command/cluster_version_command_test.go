@@ -0,0 +1,114 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/coreos/go-semver/semver"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+func Test_pendingMembers(t *testing.T) {
+	member := func(name, ver string, learner, leader bool) clusterMember {
+		return clusterMember{
+			Member:  &pb.Member{Name: name, IsLearner: learner},
+			version: semver.New(ver),
+			leader:  leader,
+		}
+	}
+	members := []clusterMember{
+		member("leader", "3.5.12", false, true),
+		member("done", "3.6.0", false, false),
+		member("follower", "3.5.12", false, false),
+		member("learner", "3.5.12", true, false),
+	}
+
+	tt := []struct {
+		name      string
+		members   []clusterMember
+		target    string
+		downgrade bool
+
+		pending []string
+		wantErr bool
+	}{
+		{
+			name:    "upgrade restarts the leader last",
+			members: members,
+			target:  "3.6.0",
+			pending: []string{"learner", "follower", "leader"},
+		},
+		{
+			name:      "downgrade restarts the members at the newer version",
+			members:   members,
+			target:    "3.5.0",
+			downgrade: true,
+			pending:   []string{"done"},
+		},
+		{
+			name:    "all members at target",
+			members: members[1:2],
+			target:  "3.6.0",
+		},
+		{
+			name:    "unsupported skew",
+			members: members,
+			target:  "3.7.0",
+			wantErr: true,
+		},
+		{
+			name:      "downgrade in the wrong direction",
+			members:   members[:1],
+			target:    "3.6.0",
+			downgrade: true,
+			wantErr:   true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			pending, err := pendingMembers(tc.members, semver.New(tc.target), tc.downgrade)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var names []string
+			for _, m := range pending {
+				names = append(names, m.Name)
+			}
+			if !reflect.DeepEqual(names, tc.pending) {
+				t.Errorf("pending members = %v, want %v", names, tc.pending)
+			}
+		})
+	}
+}
+
+func Test_parseTargetVersion(t *testing.T) {
+	for v, want := range map[string]string{"3.6": "3.6.0", "3.6.1": "3.6.1"} {
+		got, err := parseTargetVersion(v)
+		if err != nil {
+			t.Fatalf("parseTargetVersion(%q): %v", v, err)
+		}
+		if got.String() != want {
+			t.Errorf("parseTargetVersion(%q) = %s, want %s", v, got, want)
+		}
+	}
+	for _, v := range []string{"", "three"} {
+		if _, err := parseTargetVersion(v); err == nil {
+			t.Errorf("parseTargetVersion(%q) expected error", v)
+		}
+	}
+}
@@ -15,15 +15,22 @@
 package command
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/pkg/v3/cobrautl"
 )
 
+var (
+	defragRolling       bool
+	defragMemberTimeout time.Duration
+)
+
 // NewDefragCommand returns the cobra command for "Defrag".
 func NewDefragCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -32,10 +39,22 @@ func NewDefragCommand() *cobra.Command {
 		Run:   defragCommandFunc,
 	}
 	cmd.PersistentFlags().BoolVar(&epClusterEndpoints, "cluster", false, "use all endpoints from the cluster member list")
+	cmd.Flags().BoolVar(&defragRolling, "rolling", false, "defragment the cluster members one at a time, followers first, waiting for each member to be healthy, and the leader last after transferring its leadership; requires --cluster")
+	cmd.Flags().DurationVar(&defragMemberTimeout, "member-timeout", time.Minute, "time to wait for a member to be healthy, or to transfer its leadership, with --rolling")
 	return cmd
 }
 
 func defragCommandFunc(cmd *cobra.Command, args []string) {
+	if defragRolling {
+		if !epClusterEndpoints {
+			cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("--rolling requires --cluster"))
+		}
+		if err := rollingDefrag(cmd); err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, err)
+		}
+		return
+	}
+
 	failures := 0
 	cfg := clientConfigFromCmd(cmd)
 	for _, ep := range endpointsFromCluster(cmd) {
@@ -59,3 +78,127 @@ func defragCommandFunc(cmd *cobra.Command, args []string) {
 		os.Exit(cobrautl.ExitError)
 	}
 }
+
+// rollingDefrag defragments the members of the cluster one at a time,
+// learners and followers first and the leader last, after transferring its
+// leadership to a defragmented follower. It waits for each member to be
+// healthy before moving on to the next one, and aborts on the first error.
+func rollingDefrag(cmd *cobra.Command) error {
+	members, err := clusterMembers(cmd)
+	if err != nil {
+		return err
+	}
+	return defragMembers(&defragCluster{cmd: cmd}, members)
+}
+
+// defragOps are the operations on the cluster members of a rolling defrag.
+type defragOps interface {
+	// waitHealthy waits for the member to be healthy.
+	waitHealthy(m clusterMember) error
+	// defragment defragments the member.
+	defragment(m clusterMember) error
+	// moveLeader transfers the leadership of the leader to the target
+	// member, and waits for the leader to step down.
+	moveLeader(leader, target clusterMember) error
+}
+
+func defragMembers(ops defragOps, members []clusterMember) error {
+	for _, m := range members {
+		if err := ops.waitHealthy(m); err != nil {
+			return fmt.Errorf("cluster is not healthy, aborting: %w", err)
+		}
+	}
+	sortRestartOrder(members)
+
+	for _, m := range members {
+		if m.IsWitness {
+			// a witness has no key-value store to defragment
+			continue
+		}
+		if m.leader {
+			if err := transferLeadership(ops, m, members); err != nil {
+				return err
+			}
+		}
+		if err := ops.defragment(m); err != nil {
+			return err
+		}
+		if err := ops.waitHealthy(m); err != nil {
+			return fmt.Errorf("aborting: %w", err)
+		}
+	}
+	return nil
+}
+
+// transferLeadership transfers the leadership of the leader to a voting
+// member. The leader keeps its leadership if it is the only voting member.
+func transferLeadership(ops defragOps, leader clusterMember, members []clusterMember) error {
+	var target *clusterMember
+	for i := range members {
+		if !members[i].IsLearner && !members[i].IsWitness && members[i].ID != leader.ID {
+			target = &members[i]
+			break
+		}
+	}
+	if target == nil {
+		fmt.Printf("No member to transfer the leadership of etcd member[%s] to, defragmenting the leader\n", leader.Name)
+		return nil
+	}
+	if err := ops.moveLeader(leader, *target); err != nil {
+		return err
+	}
+	fmt.Printf("Transferred the leadership of etcd member[%s] to etcd member[%s]\n", leader.Name, target.Name)
+	return nil
+}
+
+// defragCluster runs the operations of a rolling defrag on the cluster
+// members, through their client URLs.
+type defragCluster struct {
+	cmd *cobra.Command
+}
+
+func (dc *defragCluster) waitHealthy(m clusterMember) error {
+	return waitMember(dc.cmd, m.Member, defragMemberTimeout, checkMemberHealthy)
+}
+
+func (dc *defragCluster) defragment(m clusterMember) error {
+	c, err := memberClient(dc.cmd, m.Member)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := commandCtx(dc.cmd)
+	start := time.Now()
+	_, err = c.Defragment(ctx, m.ClientURLs[0])
+	d := time.Since(start)
+	cancel()
+	c.Close()
+	if err != nil {
+		return fmt.Errorf("failed to defragment etcd member[%s]. took %s. aborting: %w", m.Name, d, err)
+	}
+	fmt.Printf("Finished defragmenting etcd member[%s]. took %s\n", m.Name, d)
+	return nil
+}
+
+func (dc *defragCluster) moveLeader(leader, target clusterMember) error {
+	c, err := memberClient(dc.cmd, leader.Member)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := commandCtx(dc.cmd)
+	_, err = c.MoveLeader(ctx, target.ID)
+	cancel()
+	c.Close()
+	if err != nil {
+		return fmt.Errorf("failed to transfer the leadership of etcd member[%s] to etcd member[%s]. aborting: %w", leader.Name, target.Name, err)
+	}
+	err = waitMember(dc.cmd, leader.Member, defragMemberTimeout, func(st *clientv3.StatusResponse) error {
+		if st.Leader == leader.ID {
+			return errors.New("member is still the leader")
+		}
+		return checkMemberHealthy(st)
+	})
+	if err != nil {
+		return fmt.Errorf("aborting: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+// fakeDefragOps records the operations of a rolling defrag.
+type fakeDefragOps struct {
+	ops []string
+	// failDefrag is the name of the member failing to defragment.
+	failDefrag string
+	// failMove fails to transfer the leadership.
+	failMove bool
+}
+
+func (f *fakeDefragOps) waitHealthy(m clusterMember) error {
+	f.ops = append(f.ops, "wait "+m.Name)
+	return nil
+}
+
+func (f *fakeDefragOps) defragment(m clusterMember) error {
+	f.ops = append(f.ops, "defrag "+m.Name)
+	if m.Name == f.failDefrag {
+		return errors.New("defrag failed")
+	}
+	return nil
+}
+
+func (f *fakeDefragOps) moveLeader(leader, target clusterMember) error {
+	f.ops = append(f.ops, "move "+leader.Name+" to "+target.Name)
+	if f.failMove {
+		return errors.New("move leader failed")
+	}
+	return nil
+}
+
+func Test_defragMembers(t *testing.T) {
+	member := func(id uint64, name string, learner, witness, leader bool) clusterMember {
+		return clusterMember{
+			Member: &pb.Member{ID: id, Name: name, IsLearner: learner, IsWitness: witness},
+			leader: leader,
+		}
+	}
+
+	tt := []struct {
+		name    string
+		members []clusterMember
+		ops     fakeDefragOps
+
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "leader last after transferring its leadership",
+			members: []clusterMember{
+				member(1, "leader", false, false, true),
+				member(2, "witness", false, true, false),
+				member(3, "follower", false, false, false),
+				member(4, "learner", true, false, false),
+			},
+			want: []string{
+				"wait leader", "wait witness", "wait follower", "wait learner",
+				"defrag learner", "wait learner",
+				"defrag follower", "wait follower",
+				"move leader to follower", "defrag leader", "wait leader",
+			},
+		},
+		{
+			name: "leadership not transferred to a learner or a witness",
+			members: []clusterMember{
+				member(1, "leader", false, false, true),
+				member(2, "witness", false, true, false),
+				member(3, "learner", true, false, false),
+			},
+			want: []string{
+				"wait leader", "wait witness", "wait learner",
+				"defrag learner", "wait learner",
+				"defrag leader", "wait leader",
+			},
+		},
+		{
+			name: "abort on a failed defrag",
+			members: []clusterMember{
+				member(1, "leader", false, false, true),
+				member(2, "follower", false, false, false),
+			},
+			ops: fakeDefragOps{failDefrag: "follower"},
+			want: []string{
+				"wait leader", "wait follower",
+				"defrag follower",
+			},
+			wantErr: true,
+		},
+		{
+			name: "leader not defragmented if its leadership is not transferred",
+			members: []clusterMember{
+				member(1, "leader", false, false, true),
+				member(2, "follower", false, false, false),
+			},
+			ops: fakeDefragOps{failMove: true},
+			want: []string{
+				"wait leader", "wait follower",
+				"defrag follower", "wait follower",
+				"move leader to follower",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ops := tc.ops
+			err := defragMembers(&ops, tc.members)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(ops.ops, tc.want) {
+				t.Errorf("operations = %q, want %q", ops.ops, tc.want)
+			}
+		})
+	}
+}
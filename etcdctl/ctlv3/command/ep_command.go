@@ -33,6 +33,8 @@ import (
 
 var epClusterEndpoints bool
 var epHashKVRev int64
+var epDrainCancel bool
+var epHotKeysLimit int64
 
 // NewEndpointCommand returns the cobra command for "endpoint".
 func NewEndpointCommand() *cobra.Command {
@@ -45,6 +47,9 @@ func NewEndpointCommand() *cobra.Command {
 	ec.AddCommand(newEpHealthCommand())
 	ec.AddCommand(newEpStatusCommand())
 	ec.AddCommand(newEpHashKVCommand())
+	ec.AddCommand(newEpDrainCommand())
+	ec.AddCommand(newEpRotateEncryptionKeyCommand())
+	ec.AddCommand(newEpHotKeysCommand())
 
 	return ec
 }
@@ -80,6 +85,46 @@ func newEpHashKVCommand() *cobra.Command {
 	return hc
 }
 
+func newEpHotKeysCommand() *cobra.Command {
+	hc := &cobra.Command{
+		Use:   "hot-keys",
+		Short: "Prints the most frequently read and written keys of each endpoint in --endpoints",
+		Long: `Prints the keys, ranges and prefixes most frequently read and written through each endpoint,
+as estimated from a sample of the requests it served. The counts are halved every minute, so that
+the keys of the recent workload come first. The members must be started with
+--experimental-hot-keys-sample-rate set.
+`,
+		Run: epHotKeysCommandFunc,
+	}
+	hc.Flags().Int64Var(&epHotKeysLimit, "limit", 10, "maximum number of keys printed for reads and for writes each, 0 for all the tracked keys")
+	return hc
+}
+
+func newEpDrainCommand() *cobra.Command {
+	dc := &cobra.Command{
+		Use:   "drain",
+		Short: "Marks the endpoints specified in `--endpoints` flag as draining",
+		Long: `Marks each endpoint as draining: the member transfers its leadership away,
+fails its readiness checks and rejects new watch streams, while requests already
+in flight are allowed to finish. Use --cancel to serve client traffic again.
+`,
+		Run: epDrainCommandFunc,
+	}
+	dc.Flags().BoolVar(&epDrainCancel, "cancel", false, "stop draining the endpoints")
+	return dc
+}
+
+func newEpRotateEncryptionKeyCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rotate-encryption-key",
+		Short: "Rotates the data encryption key of the endpoints specified in `--endpoints` flag",
+		Long: `Creates a new data encryption key on each endpoint, used to encrypt key-value
+records from now on. Records encrypted with previous keys remain readable.
+`,
+		Run: epRotateEncryptionKeyCommandFunc,
+	}
+}
+
 type epHealth struct {
 	Ep     string `json:"endpoint"`
 	Health bool   `json:"health"`
@@ -145,15 +190,21 @@ func epHealthCommandFunc(cmd *cobra.Command, args []string) {
 
 			if eh.Health {
 				resp, err := cli.AlarmList(ctx)
-				if err == nil && len(resp.Alarms) > 0 {
+				if err == nil && hasHealthAlarm(resp.Alarms) {
 					eh.Health = false
 					eh.Error = "Active Alarm(s): "
 					for _, v := range resp.Alarms {
 						switch v.Alarm {
+						case etcdserverpb.AlarmType_READONLY:
+							continue
 						case etcdserverpb.AlarmType_NOSPACE:
 							eh.Error = eh.Error + "NOSPACE "
 						case etcdserverpb.AlarmType_CORRUPT:
 							eh.Error = eh.Error + "CORRUPT "
+						case etcdserverpb.AlarmType_SLOWDISK:
+							eh.Error = eh.Error + "SLOWDISK "
+						case etcdserverpb.AlarmType_CORRUPT_RANGE:
+							eh.Error = eh.Error + "CORRUPT_RANGE "
 						default:
 							eh.Error = eh.Error + "UNKNOWN "
 						}
@@ -185,6 +236,17 @@ func epHealthCommandFunc(cmd *cobra.Command, args []string) {
 	}
 }
 
+// hasHealthAlarm returns true if an alarm failing the health check is active.
+// A read-only cluster is still healthy.
+func hasHealthAlarm(alarms []*etcdserverpb.AlarmMember) bool {
+	for _, a := range alarms {
+		if a.Alarm != etcdserverpb.AlarmType_READONLY {
+			return true
+		}
+	}
+	return false
+}
+
 type epStatus struct {
 	Ep   string                   `json:"Endpoint"`
 	Resp *clientv3.StatusResponse `json:"Status"`
@@ -249,6 +311,92 @@ func epHashKVCommandFunc(cmd *cobra.Command, args []string) {
 	}
 }
 
+type epHotKeys struct {
+	Ep   string                    `json:"Endpoint"`
+	Resp *clientv3.HotKeysResponse `json:"HotKeys"`
+}
+
+func epHotKeysCommandFunc(cmd *cobra.Command, args []string) {
+	cfg := clientConfigFromCmd(cmd)
+
+	var hotKeysList []epHotKeys
+	var err error
+	for _, ep := range endpointsFromCluster(cmd) {
+		cfg.Endpoints = []string{ep}
+		c := mustClient(cfg)
+		ctx, cancel := commandCtx(cmd)
+		resp, serr := c.HotKeys(ctx, ep, epHotKeysLimit)
+		cancel()
+		c.Close()
+		if serr != nil {
+			err = serr
+			fmt.Fprintf(os.Stderr, "Failed to get the hot keys of endpoint %s (%v)\n", ep, serr)
+			continue
+		}
+		hotKeysList = append(hotKeysList, epHotKeys{Ep: ep, Resp: resp})
+	}
+
+	display.EndpointHotKeys(hotKeysList)
+
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+}
+
+func epDrainCommandFunc(cmd *cobra.Command, args []string) {
+	failures := 0
+	cfg := clientConfigFromCmd(cmd)
+	for _, ep := range endpointsFromCluster(cmd) {
+		cfg.Endpoints = []string{ep}
+		c := mustClient(cfg)
+		ctx, cancel := commandCtx(cmd)
+		var err error
+		if epDrainCancel {
+			_, err = c.Undrain(ctx, ep)
+		} else {
+			_, err = c.Drain(ctx, ep)
+		}
+		cancel()
+		c.Close()
+		switch {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Failed to update drain state of etcd member[%s] (%v)\n", ep, err)
+			failures++
+		case epDrainCancel:
+			fmt.Printf("etcd member[%s] is no longer draining\n", ep)
+		default:
+			fmt.Printf("etcd member[%s] is draining\n", ep)
+		}
+	}
+
+	if failures != 0 {
+		os.Exit(cobrautl.ExitError)
+	}
+}
+
+func epRotateEncryptionKeyCommandFunc(cmd *cobra.Command, args []string) {
+	failures := 0
+	cfg := clientConfigFromCmd(cmd)
+	for _, ep := range endpointsFromCluster(cmd) {
+		cfg.Endpoints = []string{ep}
+		c := mustClient(cfg)
+		ctx, cancel := commandCtx(cmd)
+		resp, err := c.RotateEncryptionKey(ctx, ep)
+		cancel()
+		c.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to rotate the encryption key of etcd member[%s] (%v)\n", ep, err)
+			failures++
+			continue
+		}
+		fmt.Printf("Rotated the encryption key of etcd member[%s] to key %d\n", ep, resp.KeyId)
+	}
+
+	if failures != 0 {
+		os.Exit(cobrautl.ExitError)
+	}
+}
+
 func endpointsFromCluster(cmd *cobra.Command) []string {
 	if !epClusterEndpoints {
 		endpoints, err := cmd.Flags().GetStringSlice("endpoints")
@@ -17,6 +17,7 @@ package command
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -34,7 +35,10 @@ var (
 	getRev         int64
 	getKeysOnly    bool
 	getCountOnly   bool
+	getGroupBy     string
 	printValueOnly bool
+	getMaxStale    time.Duration
+	getMaxStaleRev int64
 )
 
 // NewGetCommand returns the cobra command for "get".
@@ -46,6 +50,8 @@ func NewGetCommand() *cobra.Command {
 	}
 
 	cmd.Flags().StringVar(&getConsistency, "consistency", "l", "Linearizable(l) or Serializable(s)")
+	cmd.Flags().DurationVar(&getMaxStale, "max-staleness", 0, "Serve a linearizable read locally if the member confirmed its state with the leader within this duration")
+	cmd.Flags().Int64Var(&getMaxStaleRev, "max-staleness-revisions", 0, "Serve a linearizable read locally if the member is at most this many committed revisions behind the leader")
 	cmd.Flags().StringVar(&getSortOrder, "order", "", "Order of results; ASCEND or DESCEND (ASCEND by default)")
 	cmd.Flags().StringVar(&getSortTarget, "sort-by", "", "Sort target; CREATE, KEY, MODIFY, VALUE, or VERSION")
 	cmd.Flags().Int64Var(&getLimit, "limit", 0, "Maximum number of results")
@@ -54,6 +60,7 @@ func NewGetCommand() *cobra.Command {
 	cmd.Flags().Int64Var(&getRev, "rev", 0, "Specify the kv revision")
 	cmd.Flags().BoolVar(&getKeysOnly, "keys-only", false, "Get only the keys")
 	cmd.Flags().BoolVar(&getCountOnly, "count-only", false, "Get only the count")
+	cmd.Flags().StringVar(&getGroupBy, "group-by", "", "Get only the count of keys per group of keys sharing their prefix up to this separator following the key (e.g. '/')")
 	cmd.Flags().BoolVar(&printValueOnly, "print-value-only", false, `Only write values when using the "simple" output format`)
 
 	cmd.RegisterFlagCompletionFunc("consistency", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
@@ -108,9 +115,20 @@ func getGetOp(args []string) (string, []clientv3.OpOption) {
 		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("`--keys-only` and `--count-only` cannot be set at the same time, choose one"))
 	}
 
+	if getKeysOnly && getGroupBy != "" {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("`--keys-only` and `--group-by` cannot be set at the same time, choose one"))
+	}
+
 	var opts []clientv3.OpOption
 	if IsSerializable(getConsistency) {
 		opts = append(opts, clientv3.WithSerializable())
+	} else {
+		if getMaxStale > 0 {
+			opts = append(opts, clientv3.WithMaxStaleness(getMaxStale))
+		}
+		if getMaxStaleRev > 0 {
+			opts = append(opts, clientv3.WithMaxStalenessRevisions(getMaxStaleRev))
+		}
 	}
 
 	key := args[0]
@@ -184,5 +202,9 @@ func getGetOp(args []string) (string, []clientv3.OpOption) {
 		opts = append(opts, clientv3.WithCountOnly())
 	}
 
+	if getGroupBy != "" {
+		opts = append(opts, clientv3.WithGroupBySeparator(getGroupBy))
+	}
+
 	return key, opts
 }
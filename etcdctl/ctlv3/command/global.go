@@ -26,6 +26,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/grpclog"
 
 	"go.etcd.io/etcd/client/pkg/v3/logutil"
@@ -151,12 +152,13 @@ func mustClientFromCmd(cmd *cobra.Command) *clientv3.Client {
 	return mustClient(cfg)
 }
 
-func mustClient(cc *clientv3.ConfigSpec) *clientv3.Client {
+func mustClient(cc *clientv3.ConfigSpec, opts ...grpc.DialOption) *clientv3.Client {
 	lg, _ := logutil.CreateDefaultZapLogger(zap.InfoLevel)
 	cfg, err := clientv3.NewClientConfig(cc, lg)
 	if err != nil {
 		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
 	}
+	cfg.DialOptions = append(cfg.DialOptions, opts...)
 
 	client, err := clientv3.New(*cfg)
 	if err != nil {
@@ -41,14 +41,17 @@ func NewLeaseCommand() *cobra.Command {
 	return lc
 }
 
+var grantPinnedRevision int64
+
 // NewLeaseGrantCommand returns the cobra command for "lease grant".
 func NewLeaseGrantCommand() *cobra.Command {
 	lc := &cobra.Command{
-		Use:   "grant <ttl>",
+		Use:   "grant <ttl> [options]",
 		Short: "Creates leases",
 
 		Run: leaseGrantCommandFunc,
 	}
+	lc.Flags().Int64Var(&grantPinnedRevision, "pinned-revision", 0, "Revision to pin against compaction until the lease is revoked or expires. -1 pins the current revision")
 
 	return lc
 }
@@ -64,8 +67,13 @@ func leaseGrantCommandFunc(cmd *cobra.Command, args []string) {
 		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("bad TTL (%v)", err))
 	}
 
+	var opts []v3.LeaseOption
+	if grantPinnedRevision != 0 {
+		opts = append(opts, v3.WithPinnedRevision(grantPinnedRevision))
+	}
+
 	ctx, cancel := commandCtx(cmd)
-	resp, err := mustClientFromCmd(cmd).Grant(ctx, ttl)
+	resp, err := mustClientFromCmd(cmd).Grant(ctx, ttl, opts...)
 	cancel()
 	if err != nil {
 		cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("failed to grant lease (%v)", err))
@@ -0,0 +1,115 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveConnectProxy serves a CONNECT proxy on ln, tunneling to any address
+// for the clients authenticating with auth.
+func serveConnectProxy(ln net.Listener, auth string) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			req, err := http.ReadRequest(bufio.NewReader(conn))
+			if err != nil {
+				return
+			}
+			if req.Method != http.MethodConnect || req.Header.Get("Proxy-Authorization") != auth {
+				io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+				return
+			}
+			dst, err := net.Dial("tcp", req.Host)
+			if err != nil {
+				io.WriteString(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+				return
+			}
+			defer dst.Close()
+			io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+			go io.Copy(dst, conn)
+			io.Copy(conn, dst)
+		}()
+	}
+}
+
+func TestHTTPProxyDialer(t *testing.T) {
+	dst, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+	go func() {
+		conn, err := dst.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.WriteString(conn, "hello")
+	}()
+
+	proxy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proxy.Close()
+	// base64 of "user:secret"
+	go serveConnectProxy(proxy, "Basic dXNlcjpzZWNyZXQ=")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	dial, err := newHTTPProxyDialer("http://user:secret@" + proxy.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := dial(ctx, dst.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	got, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello" {
+		t.Errorf("read %q through the proxy, expected %q", got, "hello")
+	}
+
+	dial, err = newHTTPProxyDialer("http://user:wrong@" + proxy.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = dial(ctx, dst.Addr().String()); err == nil || !strings.Contains(err.Error(), "407") {
+		t.Errorf("expected the proxy to refuse the wrong credentials, got %v", err)
+	}
+
+	for _, proxy := range []string{"socks5://127.0.0.1:1080", "127.0.0.1:3128", "http://"} {
+		if _, err = newHTTPProxyDialer(proxy); err == nil {
+			t.Errorf("expected an error for --dest-proxy %q", proxy)
+		}
+	}
+}
@@ -30,6 +30,7 @@ var (
 	memberPeerURLs    string
 	isLearner         bool
 	memberConsistency string
+	memberLabels      []string
 )
 
 // NewMemberCommand returns the cobra command for "member".
@@ -85,6 +86,7 @@ func NewMemberUpdateCommand() *cobra.Command {
 	}
 
 	cc.Flags().StringVar(&memberPeerURLs, "peer-urls", "", "comma separated peer URLs for the updated member.")
+	cc.Flags().StringArrayVar(&memberLabels, "label", nil, "label of the member in key=value format, an empty value removes the label (can be repeated)")
 
 	return cc
 }
@@ -211,22 +213,55 @@ func memberUpdateCommandFunc(cmd *cobra.Command, args []string) {
 		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("bad member ID arg (%v), expecting ID in Hex", err))
 	}
 
-	if len(memberPeerURLs) == 0 {
-		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("member peer urls not provided"))
+	if len(memberPeerURLs) == 0 && len(memberLabels) == 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("member peer urls or labels not provided"))
 	}
 
-	urls := strings.Split(memberPeerURLs, ",")
-
-	ctx, cancel := commandCtx(cmd)
-	resp, err := mustClientFromCmd(cmd).MemberUpdate(ctx, id, urls)
-	cancel()
+	labels, err := parseMemberLabels(memberLabels)
 	if err != nil {
-		cobrautl.ExitWithError(cobrautl.ExitError, err)
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+
+	c := mustClientFromCmd(cmd)
+	var resp *clientv3.MemberUpdateResponse
+	if len(memberPeerURLs) != 0 {
+		urls := strings.Split(memberPeerURLs, ",")
+
+		ctx, cancel := commandCtx(cmd)
+		resp, err = c.MemberUpdate(ctx, id, urls)
+		cancel()
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, err)
+		}
+	}
+	if len(labels) != 0 {
+		ctx, cancel := commandCtx(cmd)
+		resp, err = c.MemberUpdateLabels(ctx, id, labels)
+		cancel()
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, err)
+		}
 	}
 
 	display.MemberUpdate(id, *resp)
 }
 
+// parseMemberLabels parses labels given in key=value format.
+func parseMemberLabels(labels []string) (map[string]string, error) {
+	if len(labels) == 0 {
+		return nil, nil
+	}
+	m := make(map[string]string, len(labels))
+	for _, l := range labels {
+		k, v, ok := strings.Cut(l, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("bad member label %q, expecting key=value", l)
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
 // memberListCommandFunc executes the "member list" command.
 func memberListCommandFunc(cmd *cobra.Command, args []string) {
 	var opts []clientv3.OpOption
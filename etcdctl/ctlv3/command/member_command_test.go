@@ -0,0 +1,43 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseMemberLabels(t *testing.T) {
+	got, err := parseMemberLabels([]string{"zone=us-east-1a", "region=", "rack=a=b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"zone": "us-east-1a", "region": "", "rack": "a=b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMemberLabels = %v, want %v", got, want)
+	}
+	for _, l := range []string{"zone", "=us-east-1a"} {
+		if _, err := parseMemberLabels([]string{l}); err == nil {
+			t.Errorf("parseMemberLabels(%q) expected error", l)
+		}
+	}
+}
+
+func Test_formatMemberLabels(t *testing.T) {
+	got := formatMemberLabels(map[string]string{"zone": "us-east-1a", "region": "us-east-1"})
+	if want := "region=us-east-1,zone=us-east-1a"; got != want {
+		t.Errorf("formatMemberLabels = %q, want %q", got, want)
+	}
+}
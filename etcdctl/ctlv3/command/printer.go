@@ -17,6 +17,7 @@ package command
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/dustin/go-humanize"
@@ -48,6 +49,7 @@ type printer interface {
 	EndpointHealth([]epHealth)
 	EndpointStatus([]epStatus)
 	EndpointHashKV([]epHashKV)
+	EndpointHotKeys([]epHotKeys)
 	MoveLeader(leader, target uint64, r v3.MoveLeaderResponse)
 
 	DowngradeValidate(r v3.DowngradeResponse)
@@ -55,6 +57,10 @@ type printer interface {
 	DowngradeCancel(r v3.DowngradeResponse)
 
 	Alarm(v3.AlarmResponse)
+	ClusterEvents(v3.ClusterEventsResponse)
+	ClusterStatus(clusterStatus)
+
+	CheckDatascale(checkDatascaleReport)
 
 	RoleAdd(role string, r v3.AuthRoleAddResponse)
 	RoleGet(role string, r v3.AuthRoleGetResponse)
@@ -72,6 +78,7 @@ type printer interface {
 	UserDelete(user string, r v3.AuthUserDeleteResponse)
 
 	AuthStatus(r v3.AuthStatusResponse)
+	AuthCheckPermission(user string, r v3.AuthCheckPermissionResponse)
 }
 
 func NewPrinter(printerType string, isHex bool) printer {
@@ -119,6 +126,9 @@ func (p *printerRPC) MemberPromote(id uint64, r v3.MemberPromoteResponse) {
 }
 func (p *printerRPC) MemberList(r v3.MemberListResponse) { p.p((*pb.MemberListResponse)(&r)) }
 func (p *printerRPC) Alarm(r v3.AlarmResponse)           { p.p((*pb.AlarmResponse)(&r)) }
+func (p *printerRPC) ClusterEvents(r v3.ClusterEventsResponse) {
+	p.p((*pb.ClusterEventsResponse)(&r))
+}
 func (p *printerRPC) MoveLeader(leader, target uint64, r v3.MoveLeaderResponse) {
 	p.p((*pb.MoveLeaderResponse)(&r))
 }
@@ -155,6 +165,9 @@ func (p *printerRPC) UserDelete(_ string, r v3.AuthUserDeleteResponse) {
 func (p *printerRPC) AuthStatus(r v3.AuthStatusResponse) {
 	p.p((*pb.AuthStatusResponse)(&r))
 }
+func (p *printerRPC) AuthCheckPermission(_ string, r v3.AuthCheckPermissionResponse) {
+	p.p((*pb.AuthCheckPermissionResponse)(&r))
+}
 
 type printerUnsupported struct{ printerRPC }
 
@@ -165,9 +178,13 @@ func newPrinterUnsupported(n string) printer {
 	return &printerUnsupported{printerRPC{nil, f}}
 }
 
-func (p *printerUnsupported) EndpointHealth([]epHealth) { p.p(nil) }
-func (p *printerUnsupported) EndpointStatus([]epStatus) { p.p(nil) }
-func (p *printerUnsupported) EndpointHashKV([]epHashKV) { p.p(nil) }
+func (p *printerUnsupported) EndpointHealth([]epHealth)   { p.p(nil) }
+func (p *printerUnsupported) EndpointStatus([]epStatus)   { p.p(nil) }
+func (p *printerUnsupported) EndpointHashKV([]epHashKV)   { p.p(nil) }
+func (p *printerUnsupported) EndpointHotKeys([]epHotKeys) { p.p(nil) }
+func (p *printerUnsupported) ClusterStatus(clusterStatus) { p.p(nil) }
+
+func (p *printerUnsupported) CheckDatascale(checkDatascaleReport) { p.p(nil) }
 
 func (p *printerUnsupported) MoveLeader(leader, target uint64, r v3.MoveLeaderResponse) { p.p(nil) }
 func (p *printerUnsupported) DowngradeValidate(r v3.DowngradeResponse)                  { p.p(nil) }
@@ -176,6 +193,19 @@ func (p *printerUnsupported) DowngradeCancel(r v3.DowngradeResponse)
 
 func makeMemberListTable(r v3.MemberListResponse) (hdr []string, rows [][]string) {
 	hdr = []string{"ID", "Status", "Name", "Peer Addrs", "Client Addrs", "Is Learner"}
+	// the witness and labels columns are only shown once witnesses or labels
+	// are in use, keeping the output of the other clusters unchanged
+	withWitnesses, withLabels := false, false
+	for _, m := range r.Members {
+		withWitnesses = withWitnesses || m.IsWitness
+		withLabels = withLabels || len(m.Labels) > 0
+	}
+	if withWitnesses {
+		hdr = append(hdr, "Is Witness")
+	}
+	if withLabels {
+		hdr = append(hdr, "Labels")
+	}
 	for _, m := range r.Members {
 		status := "started"
 		if len(m.Name) == 0 {
@@ -185,18 +215,40 @@ func makeMemberListTable(r v3.MemberListResponse) (hdr []string, rows [][]string
 		if m.IsLearner {
 			isLearner = "true"
 		}
-		rows = append(rows, []string{
+		row := []string{
 			fmt.Sprintf("%x", m.ID),
 			status,
 			m.Name,
 			strings.Join(m.PeerURLs, ","),
 			strings.Join(m.ClientURLs, ","),
 			isLearner,
-		})
+		}
+		if withWitnesses {
+			row = append(row, fmt.Sprint(m.IsWitness))
+		}
+		if withLabels {
+			row = append(row, formatMemberLabels(m.Labels))
+		}
+		rows = append(rows, row)
 	}
 	return hdr, rows
 }
 
+// formatMemberLabels formats the labels as comma separated key=value pairs
+// sorted by key.
+func formatMemberLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + labels[k]
+	}
+	return strings.Join(pairs, ",")
+}
+
 func makeEndpointHealthTable(healthList []epHealth) (hdr []string, rows [][]string) {
 	hdr = []string{"endpoint", "health", "took", "error"}
 	for _, h := range healthList {
@@ -234,6 +286,75 @@ func makeEndpointStatusTable(statusList []epStatus) (hdr []string, rows [][]stri
 	return hdr, rows
 }
 
+func makeClusterStatusTable(cs clusterStatus) (hdr []string, rows [][]string) {
+	hdr = []string{"ID", "name", "endpoint", "version", "storage version", "is leader", "is learner", "raft term", "raft index",
+		"raft applied index", "lag", "db size", "quota", "hash", "errors"}
+	for _, ms := range cs.Members {
+		row := []string{fmt.Sprintf("%x", ms.ID), ms.Name, ms.Endpoint}
+		if st := ms.Status; st != nil {
+			row = append(row,
+				st.Version,
+				st.StorageVersion,
+				fmt.Sprint(st.Leader == ms.ID),
+				fmt.Sprint(st.IsLearner),
+				fmt.Sprint(st.RaftTerm),
+				fmt.Sprint(st.RaftIndex),
+				fmt.Sprint(st.RaftAppliedIndex),
+				fmt.Sprint(ms.Lag),
+				humanize.Bytes(uint64(st.DbSize)),
+				humanize.Bytes(uint64(st.DbSizeQuota)),
+			)
+		} else {
+			row = append(row, make([]string, 10)...)
+		}
+		if ms.HashKV != nil {
+			row = append(row, fmt.Sprint(ms.HashKV.Hash))
+		} else {
+			row = append(row, "")
+		}
+		errs := ms.Error
+		if ms.Status != nil && len(ms.Status.Errors) > 0 {
+			if errs != "" {
+				errs += ", "
+			}
+			errs += strings.Join(ms.Status.Errors, ", ")
+		}
+		rows = append(rows, append(row, errs))
+	}
+	return hdr, rows
+}
+
+// makeClusterStatusSummary returns the lines summarizing the cluster status.
+func makeClusterStatusSummary(cs clusterStatus) []string {
+	leader := "no leader"
+	if cs.Leader != 0 {
+		leader = fmt.Sprintf("leader %x", cs.Leader)
+	}
+	reachable := 0
+	for _, ms := range cs.Members {
+		if ms.Error == "" {
+			reachable++
+		}
+	}
+	lines := []string{fmt.Sprintf("cluster %x: %s, %d/%d members reachable", cs.ClusterID, leader, reachable, len(cs.Members))}
+
+	alarms := "alarms: none"
+	if len(cs.Alarms) > 0 {
+		var as []string
+		for _, a := range cs.Alarms {
+			as = append(as, fmt.Sprintf("%s on member %x", a.Alarm, a.MemberID))
+		}
+		alarms = "alarms: " + strings.Join(as, ", ")
+	}
+	lines = append(lines, alarms)
+
+	hash := fmt.Sprintf("hash: consistent at revision %d", cs.HashRevision)
+	if cs.HashMismatch {
+		hash = fmt.Sprintf("hash: MISMATCH at revision %d", cs.HashRevision)
+	}
+	return append(lines, hash)
+}
+
 func makeEndpointHashKVTable(hashList []epHashKV) (hdr []string, rows [][]string) {
 	hdr = []string{"endpoint", "hash", "hash_revision"}
 	for _, h := range hashList {
@@ -245,3 +366,24 @@ func makeEndpointHashKVTable(hashList []epHashKV) (hdr []string, rows [][]string
 	}
 	return hdr, rows
 }
+
+func makeEndpointHotKeysTable(hotKeysList []epHotKeys) (hdr []string, rows [][]string) {
+	hdr = []string{"endpoint", "op", "key", "range end", "count"}
+	for _, h := range hotKeysList {
+		for _, op := range []struct {
+			name string
+			keys []*pb.HotKey
+		}{{"read", h.Resp.Reads}, {"write", h.Resp.Writes}} {
+			for _, k := range op.keys {
+				rows = append(rows, []string{
+					h.Ep,
+					op.name,
+					string(k.Key),
+					string(k.RangeEnd),
+					fmt.Sprint(k.Count),
+				})
+			}
+		}
+	}
+	return hdr, rows
+}
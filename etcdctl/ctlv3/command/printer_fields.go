@@ -71,6 +71,10 @@ func (p *fieldsPrinter) Get(r v3.GetResponse) {
 	for _, kv := range r.Kvs {
 		p.kv("", kv)
 	}
+	for _, g := range r.GroupCounts {
+		fmt.Printf("\"GroupPrefix\" : %q\n", string(g.Prefix))
+		fmt.Printf("\"GroupCount\" : %d\n", g.Count)
+	}
 	fmt.Println(`"More" :`, r.More)
 	fmt.Println(`"Count" :`, r.Count)
 }
@@ -118,6 +122,9 @@ func (p *fieldsPrinter) Grant(r v3.LeaseGrantResponse) {
 		fmt.Println(`"ID" :`, r.ID)
 	}
 	fmt.Println(`"TTL" :`, r.TTL)
+	if r.PinnedRevision != 0 {
+		fmt.Println(`"PinnedRevision" :`, r.PinnedRevision)
+	}
 }
 
 func (p *fieldsPrinter) Revoke(id v3.LeaseID, r v3.LeaseRevokeResponse) {
@@ -175,6 +182,12 @@ func (p *fieldsPrinter) MemberList(r v3.MemberListResponse) {
 			fmt.Printf("\"ClientURL\" : %q\n", u)
 		}
 		fmt.Println(`"IsLearner" :`, m.IsLearner)
+		if m.IsWitness {
+			fmt.Println(`"IsWitness" :`, m.IsWitness)
+		}
+		if len(m.Labels) > 0 {
+			fmt.Printf("\"Labels\" : %q\n", formatMemberLabels(m.Labels))
+		}
 		fmt.Println()
 	}
 }
@@ -217,6 +230,25 @@ func (p *fieldsPrinter) EndpointHashKV(hs []epHashKV) {
 	}
 }
 
+func (p *fieldsPrinter) EndpointHotKeys(hs []epHotKeys) {
+	for _, h := range hs {
+		p.hdr(h.Resp.Header)
+		fmt.Printf("\"Endpoint\" : %q\n", h.Ep)
+		fmt.Println(`"SampleRate" :`, h.Resp.SampleRate)
+		for _, op := range []struct {
+			name string
+			keys []*pb.HotKey
+		}{{"Read", h.Resp.Reads}, {"Write", h.Resp.Writes}} {
+			for _, k := range op.keys {
+				fmt.Printf("\"%sKey\" : %q\n", op.name, string(k.Key))
+				fmt.Printf("\"%sRangeEnd\" : %q\n", op.name, string(k.RangeEnd))
+				fmt.Printf("\"%sCount\" : %d\n", op.name, k.Count)
+			}
+		}
+		fmt.Println()
+	}
+}
+
 func (p *fieldsPrinter) Alarm(r v3.AlarmResponse) {
 	p.hdr(r.Header)
 	for _, a := range r.Alarms {
@@ -230,6 +262,22 @@ func (p *fieldsPrinter) Alarm(r v3.AlarmResponse) {
 	}
 }
 
+func (p *fieldsPrinter) ClusterEvents(r v3.ClusterEventsResponse) {
+	p.hdr(r.Header)
+	for _, e := range r.Events {
+		fmt.Println(`"Type" :`, e.Type)
+		fmt.Println(`"Time" :`, e.Time)
+		if p.isHex {
+			fmt.Println(`"MemberID" :`, types.ID(e.MemberId))
+		} else {
+			fmt.Println(`"MemberID" :`, e.MemberId)
+		}
+		fmt.Println(`"AlarmType" :`, e.Alarm)
+		fmt.Println(`"CompactRevision" :`, e.CompactRevision)
+		fmt.Println()
+	}
+}
+
 func (p *fieldsPrinter) RoleAdd(role string, r v3.AuthRoleAddResponse) { p.hdr(r.Header) }
 func (p *fieldsPrinter) RoleGet(role string, r v3.AuthRoleGetResponse) {
 	p.hdr(r.Header)
@@ -263,3 +311,13 @@ func (p *fieldsPrinter) UserRevokeRole(user string, role string, r v3.AuthUserRe
 	p.hdr(r.Header)
 }
 func (p *fieldsPrinter) UserDelete(user string, r v3.AuthUserDeleteResponse) { p.hdr(r.Header) }
+func (p *fieldsPrinter) AuthCheckPermission(user string, r v3.AuthCheckPermissionResponse) {
+	p.hdr(r.Header)
+	fmt.Println(`"Permitted" :`, r.Permitted)
+	fmt.Print(`"Roles" :`)
+	for _, role := range r.Roles {
+		fmt.Printf(" %q", role)
+	}
+	fmt.Println()
+	fmt.Printf("\"Reason\" : %q\n", r.Reason)
+}
@@ -36,9 +36,13 @@ func newJSONPrinter(isHex bool) printer {
 	}
 }
 
-func (p *jsonPrinter) EndpointHealth(r []epHealth) { printJSON(r) }
-func (p *jsonPrinter) EndpointStatus(r []epStatus) { printJSON(r) }
-func (p *jsonPrinter) EndpointHashKV(r []epHashKV) { printJSON(r) }
+func (p *jsonPrinter) EndpointHealth(r []epHealth)   { printJSON(r) }
+func (p *jsonPrinter) EndpointStatus(r []epStatus)   { printJSON(r) }
+func (p *jsonPrinter) EndpointHashKV(r []epHashKV)   { printJSON(r) }
+func (p *jsonPrinter) EndpointHotKeys(r []epHotKeys) { printJSON(r) }
+func (p *jsonPrinter) ClusterStatus(r clusterStatus) { printJSON(r) }
+
+func (p *jsonPrinter) CheckDatascale(r checkDatascaleReport) { printJSON(r) }
 
 func (p *jsonPrinter) MemberList(r clientv3.MemberListResponse) {
 	if p.isHex {
@@ -90,6 +94,14 @@ func printMemberListWithHexJSON(r clientv3.MemberListResponse) {
 			return
 		}
 		buffer.Write(b)
+		if len(r.Members[i].Labels) > 0 {
+			buffer.WriteString(",\"labels\":")
+			b, err = json.Marshal(r.Members[i].Labels)
+			if err != nil {
+				return
+			}
+			buffer.Write(b)
+		}
 		buffer.WriteByte('}')
 		if i == len(r.Members)-1 {
 			buffer.WriteString("]")
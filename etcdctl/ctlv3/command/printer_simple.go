@@ -15,9 +15,13 @@
 package command
 
 import (
+	"encoding/hex"
 	"fmt"
 	"os"
 	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/client/pkg/v3/types"
@@ -42,6 +46,14 @@ func (s *simplePrinter) Get(resp v3.GetResponse) {
 	for _, kv := range resp.Kvs {
 		printKV(s.isHex, s.valueOnly, kv)
 	}
+	for _, g := range resp.GroupCounts {
+		prefix := string(g.Prefix)
+		if s.isHex {
+			prefix = addHexPrefix(hex.EncodeToString(g.Prefix))
+		}
+		fmt.Println(prefix)
+		fmt.Println(g.Count)
+	}
 }
 
 func (s *simplePrinter) Put(r v3.PutResponse) {
@@ -84,6 +96,10 @@ func (s *simplePrinter) Watch(resp v3.WatchResponse) {
 }
 
 func (s *simplePrinter) Grant(resp v3.LeaseGrantResponse) {
+	if resp.PinnedRevision != 0 {
+		fmt.Printf("lease %016x granted with TTL(%ds), pinned revision %d\n", resp.ID, resp.TTL, resp.PinnedRevision)
+		return
+	}
 	fmt.Printf("lease %016x granted with TTL(%ds)\n", resp.ID, resp.TTL)
 }
 
@@ -125,6 +141,19 @@ func (s *simplePrinter) Alarm(resp v3.AlarmResponse) {
 	}
 }
 
+func (s *simplePrinter) ClusterEvents(resp v3.ClusterEventsResponse) {
+	for _, e := range resp.Events {
+		txt := fmt.Sprintf("%s %s member:%s", time.Unix(0, e.Time).UTC().Format(time.RFC3339Nano), e.Type, types.ID(e.MemberId))
+		switch e.Type {
+		case pb.ClusterEvent_ALARM_RAISED, pb.ClusterEvent_ALARM_CLEARED:
+			txt += fmt.Sprintf(" alarm:%s", e.Alarm)
+		case pb.ClusterEvent_COMPACTED:
+			txt += fmt.Sprintf(" revision:%d", e.CompactRevision)
+		}
+		fmt.Println(txt)
+	}
+}
+
 func (s *simplePrinter) MemberAdd(r v3.MemberAddResponse) {
 	asLearner := " "
 	if r.Member.IsLearner {
@@ -169,6 +198,29 @@ func (s *simplePrinter) EndpointStatus(statusList []epStatus) {
 	}
 }
 
+func (s *simplePrinter) ClusterStatus(cs clusterStatus) {
+	for _, line := range makeClusterStatusSummary(cs) {
+		fmt.Println(line)
+	}
+	_, rows := makeClusterStatusTable(cs)
+	for _, row := range rows {
+		fmt.Println(strings.Join(row, ", "))
+	}
+}
+
+func (s *simplePrinter) CheckDatascale(r checkDatascaleReport) {
+	fmt.Printf("Wrote %d of %d key-value pairs in %.1fs\n", r.KeysWritten, r.Keys, r.DurationSeconds)
+	for _, m := range r.Members {
+		if m.Error != "" {
+			fmt.Printf("%s: %s\n", m.Endpoint, m.Error)
+			continue
+		}
+		fmt.Printf("%s: db size %s -> %s, memory %s -> %s\n", m.Endpoint,
+			humanize.Bytes(uint64(m.DBSizeBefore)), humanize.Bytes(uint64(m.DBSizeAfter)),
+			humanize.Bytes(uint64(m.MemoryBefore)), humanize.Bytes(uint64(m.MemoryAfter)))
+	}
+}
+
 func (s *simplePrinter) EndpointHashKV(hashList []epHashKV) {
 	_, rows := makeEndpointHashKVTable(hashList)
 	for _, row := range rows {
@@ -176,6 +228,13 @@ func (s *simplePrinter) EndpointHashKV(hashList []epHashKV) {
 	}
 }
 
+func (s *simplePrinter) EndpointHotKeys(hotKeysList []epHotKeys) {
+	_, rows := makeEndpointHotKeysTable(hotKeysList)
+	for _, row := range rows {
+		fmt.Println(strings.Join(row, ", "))
+	}
+}
+
 func (s *simplePrinter) MoveLeader(leader, target uint64, r v3.MoveLeaderResponse) {
 	fmt.Printf("Leadership transferred from %s to %s\n", types.ID(leader), types.ID(target))
 }
@@ -306,3 +365,20 @@ func (s *simplePrinter) AuthStatus(r v3.AuthStatusResponse) {
 	fmt.Println("Authentication Status:", r.Enabled)
 	fmt.Println("AuthRevision:", r.AuthRevision)
 }
+
+func (s *simplePrinter) AuthCheckPermission(user string, r v3.AuthCheckPermissionResponse) {
+	if r.Permitted {
+		fmt.Println("yes")
+	} else {
+		fmt.Println("no")
+	}
+	if user != "" {
+		fmt.Printf("User: %s\n", user)
+	}
+	fmt.Print("Roles:")
+	for _, role := range r.Roles {
+		fmt.Printf(" %s", role)
+	}
+	fmt.Print("\n")
+	fmt.Println("Reason:", r.Reason)
+}
@@ -0,0 +1,148 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+)
+
+const defaultTrashPrefix = "/etcd-trash/"
+
+var (
+	restoreDeletedPrefix      bool
+	restoreDeletedRev         int64
+	restoreDeletedTrashPrefix string
+	restoreDeletedDryRun      bool
+)
+
+// NewRestoreDeletedCommand returns the cobra command for "restore-deleted".
+func NewRestoreDeletedCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore-deleted [options] <key> [range_end]",
+		Short: "Restores the specified key or range of keys [key, range_end) from the trash",
+		Long: `Restores the specified key or range of keys [key, range_end) moved to the
+trash when deleted by a server started with --experimental-soft-delete-threshold
+or --experimental-soft-delete-prefixes.
+
+Each key is restored with the value it had when it was last deleted, or at the
+given revision, without its lease. The keys that exist are skipped.`,
+		Run: restoreDeletedCommandFunc,
+	}
+
+	cmd.Flags().BoolVar(&restoreDeletedPrefix, "prefix", false, "restore keys with matching prefix")
+	cmd.Flags().Int64Var(&restoreDeletedRev, "rev", 0, "restore the keys deleted at this revision instead of their last deletion")
+	cmd.Flags().StringVar(&restoreDeletedTrashPrefix, "trash-prefix", defaultTrashPrefix, "the --experimental-soft-delete-trash-prefix of the server")
+	cmd.Flags().BoolVar(&restoreDeletedDryRun, "dry-run", false, "print the keys that would be restored without restoring them")
+	return cmd
+}
+
+// trashedKV is a key-value pair in the trash.
+type trashedKV struct {
+	trashKey string
+	key      string
+	value    []byte
+	rev      int64
+}
+
+// restoreDeletedCommandFunc executes the "restore-deleted" command.
+func restoreDeletedCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) == 0 || len(args) > 2 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("restore-deleted command needs one argument as key and an optional argument as range_end"))
+	}
+	if restoreDeletedPrefix && len(args) > 1 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("too many arguments, only accept one argument when `--prefix` is set"))
+	}
+	match := func(k string) bool { return k == args[0] }
+	switch {
+	case restoreDeletedPrefix:
+		match = func(k string) bool { return strings.HasPrefix(k, args[0]) }
+	case len(args) > 1:
+		match = func(k string) bool { return k >= args[0] && (args[1] == "\x00" || k < args[1]) }
+	}
+
+	cli := mustClientFromCmd(cmd)
+	ctx, cancel := commandCtx(cmd)
+	resp, err := cli.Get(ctx, restoreDeletedTrashPrefix, clientv3.WithPrefix())
+	cancel()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+
+	latest := make(map[string]trashedKV)
+	for _, kv := range resp.Kvs {
+		t, ok := parseTrashedKV(restoreDeletedTrashPrefix, kv)
+		if !ok || !match(t.key) || restoreDeletedRev != 0 && t.rev != restoreDeletedRev {
+			continue
+		}
+		if l, ok := latest[t.key]; !ok || t.rev > l.rev {
+			latest[t.key] = t
+		}
+	}
+	kvs := make([]trashedKV, 0, len(latest))
+	for _, t := range latest {
+		kvs = append(kvs, t)
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].key < kvs[j].key })
+
+	restored, skipped := 0, 0
+	for _, t := range kvs {
+		if restoreDeletedDryRun {
+			fmt.Printf("would restore %s deleted at revision %d\n", t.key, t.rev)
+			continue
+		}
+		ctx, cancel = commandCtx(cmd)
+		tresp, err := cli.Txn(ctx).
+			If(clientv3.Compare(clientv3.CreateRevision(t.key), "=", 0)).
+			Then(clientv3.OpPut(t.key, string(t.value)), clientv3.OpDelete(t.trashKey)).
+			Commit()
+		cancel()
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, err)
+		}
+		if !tresp.Succeeded {
+			fmt.Printf("skipped %s: key exists\n", t.key)
+			skipped++
+			continue
+		}
+		fmt.Printf("restored %s deleted at revision %d\n", t.key, t.rev)
+		restored++
+	}
+	if !restoreDeletedDryRun {
+		fmt.Printf("restored %d keys, skipped %d keys\n", restored, skipped)
+	}
+}
+
+// parseTrashedKV parses a key in the trash, '<prefix><revision>/<key>' with
+// the revision as 16 hexadecimal digits.
+func parseTrashedKV(prefix string, kv *mvccpb.KeyValue) (trashedKV, bool) {
+	s := strings.TrimPrefix(string(kv.Key), prefix)
+	if len(s) < 17 || s[16] != '/' {
+		return trashedKV{}, false
+	}
+	rev, err := strconv.ParseInt(s[:16], 16, 64)
+	if err != nil {
+		return trashedKV{}, false
+	}
+	return trashedKV{trashKey: string(kv.Key), key: s[17:], value: kv.Value, rev: rev}, true
+}
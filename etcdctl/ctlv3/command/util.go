@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"regexp"
 	"strconv"
 	"strings"
@@ -105,7 +106,7 @@ func endpointMemoryMetrics(host string, scfg *clientv3.SecureConfig) float64 {
 		// load client certificate
 		cert, err := tls.LoadX509KeyPair(scfg.Cert, scfg.Key)
 		if err != nil {
-			fmt.Printf("client certificate error: %v\n", err)
+			fmt.Fprintf(os.Stderr, "client certificate error: %v\n", err)
 			return 0.0
 		}
 		http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{
@@ -115,13 +116,13 @@ func endpointMemoryMetrics(host string, scfg *clientv3.SecureConfig) float64 {
 	}
 	resp, err := http.Get(url)
 	if err != nil {
-		fmt.Printf("fetch error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "fetch error: %v\n", err)
 		return 0.0
 	}
 	byts, readerr := io.ReadAll(resp.Body)
 	resp.Body.Close()
 	if readerr != nil {
-		fmt.Printf("fetch error: reading %s: %v\n", url, readerr)
+		fmt.Fprintf(os.Stderr, "fetch error: reading %s: %v\n", url, readerr)
 		return 0.0
 	}
 
@@ -132,12 +133,12 @@ func endpointMemoryMetrics(host string, scfg *clientv3.SecureConfig) float64 {
 		}
 	}
 	if residentMemoryValue == "" {
-		fmt.Printf("could not find: %v\n", residentMemoryKey)
+		fmt.Fprintf(os.Stderr, "could not find: %v\n", residentMemoryKey)
 		return 0.0
 	}
 	residentMemoryBytes, parseErr := strconv.ParseFloat(residentMemoryValue, 64)
 	if parseErr != nil {
-		fmt.Printf("parse error: %v\n", parseErr)
+		fmt.Fprintf(os.Stderr, "parse error: %v\n", parseErr)
 		return 0.0
 	}
 
@@ -145,27 +146,27 @@ func endpointMemoryMetrics(host string, scfg *clientv3.SecureConfig) float64 {
 }
 
 // compact keyspace history to a provided revision
-func compact(c *clientv3.Client, rev int64) {
-	fmt.Printf("Compacting with revision %d\n", rev)
+func compact(w io.Writer, c *clientv3.Client, rev int64) {
+	fmt.Fprintf(w, "Compacting with revision %d\n", rev)
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	_, err := c.Compact(ctx, rev, clientv3.WithCompactPhysical())
 	cancel()
 	if err != nil {
 		cobrautl.ExitWithError(cobrautl.ExitError, err)
 	}
-	fmt.Printf("Compacted with revision %d\n", rev)
+	fmt.Fprintf(w, "Compacted with revision %d\n", rev)
 }
 
 // defrag a given endpoint
-func defrag(c *clientv3.Client, ep string) {
-	fmt.Printf("Defragmenting %q\n", ep)
+func defrag(w io.Writer, c *clientv3.Client, ep string) {
+	fmt.Fprintf(w, "Defragmenting %q\n", ep)
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	_, err := c.Defragment(ctx, ep)
 	cancel()
 	if err != nil {
 		cobrautl.ExitWithError(cobrautl.ExitError, err)
 	}
-	fmt.Printf("Defragmented %q\n", ep)
+	fmt.Fprintf(w, "Defragmented %q\n", ep)
 }
 
 func IsSerializable(option string) bool {
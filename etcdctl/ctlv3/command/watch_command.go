@@ -22,6 +22,7 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -42,6 +43,8 @@ var (
 	watchInteractive bool
 	watchPrevKey     bool
 	progressNotify   bool
+
+	progressNotifyInterval time.Duration
 )
 
 // NewWatchCommand returns the cobra command for "watch".
@@ -57,6 +60,7 @@ func NewWatchCommand() *cobra.Command {
 	cmd.Flags().Int64Var(&watchRev, "rev", 0, "Revision to start watching")
 	cmd.Flags().BoolVar(&watchPrevKey, "prev-kv", false, "get the previous key-value pair before the event happens")
 	cmd.Flags().BoolVar(&progressNotify, "progress-notify", false, "get periodic watch progress notification from server")
+	cmd.Flags().DurationVar(&progressNotifyInterval, "progress-notify-interval", 0, "get watch progress notification from server whenever no event was received for the given interval")
 
 	return cmd
 }
@@ -160,6 +164,9 @@ func getWatchChan(c *clientv3.Client, args []string) (clientv3.WatchChan, error)
 	if progressNotify {
 		opts = append(opts, clientv3.WithProgressNotify())
 	}
+	if progressNotifyInterval > 0 {
+		opts = append(opts, clientv3.WithProgressNotifyInterval(progressNotifyInterval))
+	}
 	return c.Watch(clientv3.WithRequireLeader(context.Background()), key, opts...), nil
 }
 
@@ -79,9 +79,11 @@ func init() {
 		command.NewGetCommand(),
 		command.NewPutCommand(),
 		command.NewDelCommand(),
+		command.NewRestoreDeletedCommand(),
 		command.NewTxnCommand(),
 		command.NewCompactionCommand(),
 		command.NewAlarmCommand(),
+		command.NewClusterCommand(),
 		command.NewDefragCommand(),
 		command.NewEndpointCommand(),
 		command.NewMoveLeaderCommand(),
@@ -97,6 +99,7 @@ func init() {
 		command.NewUserCommand(),
 		command.NewRoleCommand(),
 		command.NewCheckCommand(),
+		command.NewCertCommand(),
 		command.NewCompletionCommand(),
 		command.NewDowngradeCommand(),
 	)
@@ -15,16 +15,23 @@
 package etcdutl
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 
 	"go.etcd.io/etcd/pkg/v3/cobrautl"
 	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/encryption"
 	"go.etcd.io/etcd/server/v3/storage/mvcc"
 )
 
 var (
 	hashKVRevision int64
+	hashKVKEKFile  string
 )
 
 // NewHashKVCommand returns the cobra command for "hashkv".
@@ -32,17 +39,20 @@ func NewHashKVCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "hashkv <filename>",
 		Short: "Prints the KV history hash of a given file",
-		Args:  cobra.ExactArgs(1),
-		Run:   hashKVCommandFunc,
+		Long: `Prints the KV history hash of a given file, as the HashKV of a member
+serving it would return, without modifying the file.`,
+		Args: cobra.ExactArgs(1),
+		Run:  hashKVCommandFunc,
 	}
 	cmd.Flags().Int64Var(&hashKVRevision, "rev", 0, "maximum revision to hash (default: latest revision)")
+	cmd.Flags().StringVar(&hashKVKEKFile, "encryption-kek-file", "", "Path to the base64 encoded key encryption key of a db file encrypted at rest")
 	return cmd
 }
 
 func hashKVCommandFunc(cmd *cobra.Command, args []string) {
 	printer := initPrinterFromCmd(cmd)
 
-	ds, err := calculateHashKV(args[0], hashKVRevision)
+	ds, err := calculateHashKV(args[0], hashKVRevision, hashKVKEKFile)
 	if err != nil {
 		cobrautl.ExitWithError(cobrautl.ExitError, err)
 	}
@@ -55,11 +65,46 @@ type HashKV struct {
 	CompactRevision int64  `json:"compactRevision"`
 }
 
-func calculateHashKV(dbPath string, rev int64) (HashKV, error) {
+// calculateHashKV computes the hash of the db file on a copy of it, since
+// opening the store commits to the backend and finishes any scheduled
+// compaction, as a member starting on the file would. Members hash the plain
+// records, so the records of a file encrypted at rest are decrypted with the
+// key encryption key read from kekFile.
+func calculateHashKV(dbPath string, rev int64, kekFile string) (HashKV, error) {
+	dir, err := os.MkdirTemp("", "etcdutl-hashkv")
+	if err != nil {
+		return HashKV{}, err
+	}
+	defer os.RemoveAll(dir)
+	copyPath := filepath.Join(dir, "db")
+	if err = copyDBFile(dbPath, copyPath); err != nil {
+		return HashKV{}, err
+	}
+
 	cfg := backend.DefaultBackendConfig(zap.NewNop())
-	cfg.Path = dbPath
+	cfg.Path = copyPath
 	b := backend.New(cfg)
-	st := mvcc.NewStore(zap.NewNop(), b, nil, mvcc.StoreConfig{})
+	defer b.Close()
+
+	var vt mvcc.ValueTransformer
+	encrypted, err := encryption.HasKeys(b)
+	if err != nil {
+		return HashKV{}, err
+	}
+	if encrypted {
+		if kekFile == "" {
+			return HashKV{}, fmt.Errorf("db file %q is encrypted at rest, --encryption-kek-file is required to hash it", dbPath)
+		}
+		kms, kerr := encryption.NewLocalKMSFromFile(kekFile)
+		if kerr != nil {
+			return HashKV{}, kerr
+		}
+		if vt, err = encryption.NewKeyring(zap.NewNop(), kms, b); err != nil {
+			return HashKV{}, err
+		}
+	}
+	st := mvcc.NewStore(zap.NewNop(), b, nil, mvcc.StoreConfig{ValueTransformer: vt})
+	defer st.Close()
 	hst := mvcc.NewHashStorage(zap.NewNop(), st)
 
 	h, _, err := hst.HashByRev(rev)
@@ -72,3 +117,20 @@ func calculateHashKV(dbPath string, rev int64) (HashKV, error) {
 		CompactRevision: h.CompactRevision,
 	}, nil
 }
+
+func copyDBFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open db file: %w", err)
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy db file: %w", err)
+	}
+	return out.Close()
+}
@@ -42,6 +42,9 @@ var (
 	initialMmapSize     = backend.InitialMmapSize
 	markCompacted       bool
 	revisionBump        uint64
+
+	assembleOutput        string
+	assembleSkipHashCheck bool
 )
 
 // NewSnapshotCommand returns the cobra command for "snapshot".
@@ -52,6 +55,7 @@ func NewSnapshotCommand() *cobra.Command {
 	}
 	cmd.AddCommand(NewSnapshotRestoreCommand())
 	cmd.AddCommand(newSnapshotStatusCommand())
+	cmd.AddCommand(newSnapshotAssembleCommand())
 	return cmd
 }
 
@@ -68,9 +72,14 @@ The items in the lists are hash, revision, total keys, total size.
 
 func NewSnapshotRestoreCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "restore <filename> --data-dir {output dir} [options]",
+		Use:   "restore <filename | - | URL> --data-dir {output dir} [options]",
 		Short: "Restores an etcd member snapshot to an etcd directory",
-		Run:   snapshotRestoreCommandFunc,
+		Long: `Restores an etcd member snapshot to an etcd directory.
+
+The snapshot is read from the given file, from stdin if "-", or downloaded
+from an http(s):// URL or an s3://bucket/key URL. Snapshots read from stdin or
+URLs are streamed to the data directory and verified on the fly.`,
+		Run: snapshotRestoreCommandFunc,
 	}
 	cmd.Flags().StringVar(&restoreDataDir, "data-dir", "", "Path to the output data directory")
 	cmd.Flags().StringVar(&restoreWALDir, "wal-dir", "", "Path to the WAL directory (use --data-dir if none given)")
@@ -105,6 +114,48 @@ func SnapshotStatusCommandFunc(cmd *cobra.Command, args []string) {
 	printer.DBStatus(ds)
 }
 
+func newSnapshotAssembleCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "assemble <filename> <increment>... --output <filename>",
+		Short: "Assembles a snapshot from a full snapshot and incremental snapshots",
+		Long: `Assembles a snapshot from a full snapshot and the incremental snapshots saved
+after it with "etcdctl snapshot save --incremental-from", given in order. The
+assembled snapshot can be restored like any other snapshot. Its leases are the
+ones of the last increment, and all the other state, such as auth, comes from
+the full snapshot. The status of the assembled snapshot is printed as by
+"snapshot status".`,
+		Run: snapshotAssembleCommandFunc,
+	}
+	cmd.Flags().StringVar(&assembleOutput, "output", "", "Path to the assembled snapshot file")
+	cmd.Flags().BoolVar(&assembleSkipHashCheck, "skip-hash-check", false, "Ignore the integrity hash value of the full snapshot (required if copied from data directory)")
+	cmd.MarkFlagRequired("output")
+	return cmd
+}
+
+func snapshotAssembleCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) < 2 {
+		err := fmt.Errorf("snapshot assemble requires a snapshot and at least one incremental snapshot")
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+	printer := initPrinterFromCmd(cmd)
+
+	lg := GetLogger()
+	sp := snapshot.NewV3(lg)
+	if err := sp.Assemble(snapshot.AssembleConfig{
+		SnapshotPath:   args[0],
+		IncrementPaths: args[1:],
+		OutputPath:     assembleOutput,
+		SkipHashCheck:  assembleSkipHashCheck,
+	}); err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	ds, err := sp.Status(assembleOutput)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	printer.DBStatus(ds)
+}
+
 func snapshotRestoreCommandFunc(_ *cobra.Command, args []string) {
 	SnapshotRestoreCommandFunc(restoreCluster, restoreClusterToken, restoreDataDir, restoreWALDir,
 		restorePeerURLs, restoreName, skipHashCheck, initialMmapSize, revisionBump, markCompacted, args)
@@ -141,11 +192,20 @@ func SnapshotRestoreCommandFunc(restoreCluster string,
 		walDir = datadir.ToWALDir(dataDir)
 	}
 
+	src, err := openSnapshotSource(args[0])
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	if src != nil {
+		defer src.Close()
+	}
+
 	lg := GetLogger()
 	sp := snapshot.NewV3(lg)
 
 	if err := sp.Restore(snapshot.RestoreConfig{
 		SnapshotPath:        args[0],
+		SnapshotReader:      src,
 		Name:                restoreName,
 		OutputDataDir:       dataDir,
 		OutputWALDir:        walDir,
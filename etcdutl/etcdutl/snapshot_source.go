@@ -0,0 +1,177 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdutl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// emptySHA256 is the hex encoded sha256 of an empty payload.
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// openSnapshotSource opens the snapshot to restore from, if it is not a local
+// file: "-" for stdin, an http:// or https:// URL, such as a presigned object
+// storage URL, or an s3://bucket/key URL. It returns nil for a local file.
+//
+// s3:// URLs are downloaded from AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL,
+// with path-style addressing, or else from the AWS_REGION (or
+// AWS_DEFAULT_REGION) regional endpoint. Requests are signed with the
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN credentials,
+// if set.
+func openSnapshotSource(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, nil
+	}
+	var req *http.Request
+	switch u.Scheme {
+	case "http", "https":
+		if req, err = http.NewRequest(http.MethodGet, path, nil); err != nil {
+			return nil, err
+		}
+	case "s3":
+		if req, err = newS3GetRequest(u, time.Now()); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, nil
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download snapshot %q: %s", path, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func newS3GetRequest(u *url.URL, now time.Time) (*http.Request, error) {
+	bucket, key := u.Host, strings.TrimPrefix(u.Path, "/")
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("invalid S3 URL %q, expected s3://bucket/key", u)
+	}
+
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	endpoint := os.Getenv("AWS_ENDPOINT_URL_S3")
+	if endpoint == "" {
+		endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+
+	ep, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint %q: %w", endpoint, err)
+	}
+	path := strings.TrimSuffix(ep.EscapedPath(), "/") + "/" + s3URIEncode(bucket) + "/" + s3URIEncode(key)
+	req, err := http.NewRequest(http.MethodGet, ep.Scheme+"://"+ep.Host+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	signS3Request(req, region, now)
+	return req, nil
+}
+
+// signS3Request signs the request with AWS Signature Version 4, if
+// AWS credentials are set in the environment.
+func signS3Request(req *http.Request, region string, now time.Time) {
+	accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return
+	}
+
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	headers := [][2]string{
+		{"host", req.URL.Host},
+		{"x-amz-content-sha256", emptySHA256},
+		{"x-amz-date", amzDate},
+	}
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		headers = append(headers, [2]string{"x-amz-security-token", token})
+	}
+
+	var canonicalHeaders, signedHeaders []string
+	for _, h := range headers {
+		if h[0] != "host" {
+			req.Header.Set(h[0], h[1])
+		}
+		canonicalHeaders = append(canonicalHeaders, h[0]+":"+h[1]+"\n")
+		signedHeaders = append(signedHeaders, h[0])
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"",
+		strings.Join(canonicalHeaders, ""),
+		strings.Join(signedHeaders, ";"),
+		emptySHA256,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	crh := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(crh[:])
+
+	key := []byte("AWS4" + secretKey)
+	for _, s := range []string{date, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, s)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, strings.Join(signedHeaders, ";"), hex.EncodeToString(hmacSHA256(key, stringToSign))))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3URIEncode encodes s as required by AWS Signature Version 4, leaving
+// only the unreserved characters and '/' unescaped.
+func s3URIEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,226 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdutl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/client/pkg/v3/fileutil"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+	"go.etcd.io/etcd/server/v3/storage/datadir"
+	"go.etcd.io/etcd/server/v3/storage/wal"
+	"go.etcd.io/etcd/server/v3/storage/wal/walpb"
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+var (
+	walDataDir        string
+	walDir            string
+	walDumpStartIndex uint64
+	walDumpEndIndex   uint64
+	walDumpTerm       uint64
+	walDumpEntryTypes []string
+	walTruncateIndex  uint64
+)
+
+// NewWALCommand returns the cobra command for "wal".
+func NewWALCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "wal <subcommand>",
+		Short: "Inspects and repairs the WAL of a member not in use by etcd",
+	}
+	cmd.PersistentFlags().StringVar(&walDataDir, "data-dir", "", "Path to the etcd data dir")
+	cmd.PersistentFlags().StringVar(&walDir, "wal-dir", "", "Path to the WAL directory (use --data-dir if none given)")
+	cmd.MarkPersistentFlagDirname("data-dir")
+	cmd.MarkPersistentFlagDirname("wal-dir")
+	cmd.AddCommand(NewWALDumpCommand())
+	cmd.AddCommand(NewWALTruncateCommand())
+	return cmd
+}
+
+// NewWALDumpCommand returns the cobra command for "wal dump".
+func NewWALDumpCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dump",
+		Short: "Prints the WAL entries as JSON, one per line",
+		Long: `Prints the WAL entries as JSON, one per line, in the order they were written.
+
+Entries overwritten by a new leader are printed too, before the entries
+overwriting them. The requests of the normal entries and the configuration
+changes are decoded.`,
+		Args: cobra.NoArgs,
+		Run:  walDumpCommandFunc,
+	}
+	cmd.Flags().Uint64Var(&walDumpStartIndex, "start-index", 0, "Only print the entries at or after this index")
+	cmd.Flags().Uint64Var(&walDumpEndIndex, "end-index", 0, "Only print the entries at or before this index (0 for no limit)")
+	cmd.Flags().Uint64Var(&walDumpTerm, "term", 0, "Only print the entries of this term (0 for all terms)")
+	cmd.Flags().StringSliceVar(&walDumpEntryTypes, "entry-type", nil, "Only print the entries of these types (EntryNormal, EntryConfChange, EntryConfChangeV2)")
+	return cmd
+}
+
+// NewWALTruncateCommand returns the cobra command for "wal truncate".
+func NewWALTruncateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "truncate --index <index>",
+		Short: "Removes the WAL entries after the given index, e.g. to recover a member with a corrupted WAL tail",
+		Long: `Removes the WAL entries after the given index, along with any corrupted
+WAL tail. The removed WAL data is backed up in ".broken" files.
+
+The removed entries, if committed, must be available from the other members
+of the cluster, which the truncated member catches up from once restarted.`,
+		Args: cobra.NoArgs,
+		Run:  walTruncateCommandFunc,
+	}
+	cmd.Flags().Uint64Var(&walTruncateIndex, "index", 0, "Index of the last entry to keep")
+	cmd.MarkFlagRequired("index")
+	return cmd
+}
+
+// walDumpEntry is the JSON form of a WAL entry.
+type walDumpEntry struct {
+	Term         uint64                            `json:"term"`
+	Index        uint64                            `json:"index"`
+	Type         string                            `json:"type"`
+	Request      *etcdserverpb.InternalRaftRequest `json:"request,omitempty"`
+	ConfChange   *raftpb.ConfChange                `json:"confChange,omitempty"`
+	ConfChangeV2 *raftpb.ConfChangeV2              `json:"confChangeV2,omitempty"`
+	// Data is the data of the entries that could not be decoded.
+	Data []byte `json:"data,omitempty"`
+}
+
+func walDumpCommandFunc(cmd *cobra.Command, _ []string) {
+	dir, err := walDirFromFlags()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+	types := make(map[raftpb.EntryType]bool)
+	for _, t := range walDumpEntryTypes {
+		v, ok := raftpb.EntryType_value[t]
+		if !ok {
+			cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("unknown entry type %q", t))
+		}
+		types[raftpb.EntryType(v)] = true
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	err = readWALEntries(dir, func(e raftpb.Entry) error {
+		if e.Index < walDumpStartIndex ||
+			walDumpEndIndex != 0 && e.Index > walDumpEndIndex ||
+			walDumpTerm != 0 && e.Term != walDumpTerm ||
+			len(types) != 0 && !types[e.Type] {
+			return nil
+		}
+		return enc.Encode(newWALDumpEntry(e))
+	})
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+}
+
+func walTruncateCommandFunc(cmd *cobra.Command, _ []string) {
+	dir, err := walDirFromFlags()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+	if err = wal.Truncate(GetLogger(), dir, walTruncateIndex); err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+}
+
+func walDirFromFlags() (string, error) {
+	switch {
+	case walDir != "":
+		return walDir, nil
+	case walDataDir != "":
+		return datadir.ToWALDir(walDataDir), nil
+	default:
+		return "", errors.New("--data-dir or --wal-dir is required")
+	}
+}
+
+// readWALEntries calls f with the entries of the WAL files in dir, in the
+// order they were written.
+func readWALEntries(dir string, f func(raftpb.Entry) error) error {
+	names, err := fileutil.ReadDir(dir, fileutil.WithExt(".wal"))
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no WAL files found in %q", dir)
+	}
+	var readers []fileutil.FileReader
+	for _, name := range names {
+		file, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		readers = append(readers, fileutil.NewFileReader(file))
+	}
+
+	decoder := wal.NewDecoder(readers...)
+	for {
+		var rec walpb.Record
+		if err = decoder.Decode(&rec); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to read WAL record: %w", err)
+		}
+		switch rec.Type {
+		case wal.EntryType:
+			err = f(wal.MustUnmarshalEntry(rec.Data))
+		case wal.CompressedEntryType:
+			err = f(wal.MustUnmarshalCompressedEntry(rec.Data))
+		case wal.CrcType:
+			decoder.UpdateCRC(rec.Crc)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func newWALDumpEntry(e raftpb.Entry) walDumpEntry {
+	de := walDumpEntry{Term: e.Term, Index: e.Index, Type: e.Type.String()}
+	if len(e.Data) == 0 {
+		return de
+	}
+	var err error
+	switch e.Type {
+	case raftpb.EntryNormal:
+		de.Request = &etcdserverpb.InternalRaftRequest{}
+		err = de.Request.Unmarshal(e.Data)
+	case raftpb.EntryConfChange:
+		de.ConfChange = &raftpb.ConfChange{}
+		err = de.ConfChange.Unmarshal(e.Data)
+	case raftpb.EntryConfChangeV2:
+		de.ConfChangeV2 = &raftpb.ConfChangeV2{}
+		err = de.ConfChangeV2.Unmarshal(e.Data)
+	}
+	if err != nil {
+		de.Request, de.ConfChange, de.ConfChangeV2 = nil, nil, nil
+		de.Data = e.Data
+	}
+	return de
+}
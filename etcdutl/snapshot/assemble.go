@@ -0,0 +1,241 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/client/pkg/v3/fileutil"
+	"go.etcd.io/etcd/client/v3/snapshot"
+	"go.etcd.io/etcd/server/v3/lease/leasepb"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+)
+
+// AssembleConfig configures snapshot assemble operation.
+type AssembleConfig struct {
+	// SnapshotPath is the path of the full snapshot file the increments
+	// apply to.
+	SnapshotPath string
+	// IncrementPaths are the paths of the incremental snapshot files, in
+	// order. The first one must start from the revision of the full snapshot,
+	// and each other one from the revision the previous one reaches.
+	IncrementPaths []string
+
+	// OutputPath is the path of the assembled snapshot file. If it already
+	// exists, it will return an error to prevent unintended overwrites.
+	OutputPath string
+
+	// SkipHashCheck is "true" to ignore the integrity hash value of the full
+	// snapshot (required if copied from data directory).
+	SkipHashCheck bool
+}
+
+// Assemble builds a full snapshot from a full snapshot and the incremental
+// snapshots taken after it, which can be restored like any other snapshot.
+// The changes of the increments are written to the keyspace as they were
+// made on the server and the leases are replaced by the ones of the last
+// increment. All the other state, such as auth, comes from the full snapshot.
+// The values written from the increments are not encrypted at rest, even if
+// the full snapshot was, until the server rewrites them.
+func (s *v3Manager) Assemble(cfg AssembleConfig) error {
+	if len(cfg.IncrementPaths) == 0 {
+		return fmt.Errorf("no incremental snapshot to assemble")
+	}
+	if fileutil.Exist(cfg.OutputPath) {
+		return fmt.Errorf("output file %q exists", cfg.OutputPath)
+	}
+
+	incs := make([]*snapshot.Increment, 0, len(cfg.IncrementPaths))
+	for _, p := range cfg.IncrementPaths {
+		inc, err := readIncrementFile(p)
+		if err != nil {
+			return fmt.Errorf("cannot read incremental snapshot %q: %w", p, err)
+		}
+		incs = append(incs, inc)
+	}
+
+	partpath := cfg.OutputPath + ".part"
+	defer os.RemoveAll(partpath)
+
+	if err := copyVerifiedDB(cfg.SnapshotPath, partpath, cfg.SkipHashCheck); err != nil {
+		return err
+	}
+
+	be := backend.NewDefaultBackend(s.lg, partpath)
+	err := s.applyIncrements(be, cfg.IncrementPaths, incs)
+	be.Close()
+	if err != nil {
+		return err
+	}
+
+	if err = appendChecksum(partpath); err != nil {
+		return err
+	}
+	if err = os.Rename(partpath, cfg.OutputPath); err != nil {
+		return fmt.Errorf("could not rename %s to %s (%v)", partpath, cfg.OutputPath, err)
+	}
+	s.lg.Info(
+		"assembled snapshot",
+		zap.String("path", cfg.OutputPath),
+		zap.Int64("revision", incs[len(incs)-1].Revision),
+	)
+	return nil
+}
+
+func readIncrementFile(path string) (*snapshot.Increment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return snapshot.ReadIncrement(f)
+}
+
+// applyIncrements writes the changes of the increments to the keyspace of be
+// after checking they follow each other from the revision of be.
+func (s *v3Manager) applyIncrements(be backend.Backend, paths []string, incs []*snapshot.Increment) error {
+	tx := be.BatchTx()
+	tx.LockOutsideApply()
+	defer tx.Unlock()
+
+	latest, err := s.unsafeGetLatestRevision(tx)
+	if err != nil {
+		return err
+	}
+	// the latest revision of the keyspace may have been a tombstone removed
+	// by a compaction
+	rev := latest.Main
+	if compactRev, ok := mvcc.UnsafeReadScheduledCompact(tx); ok && compactRev > rev {
+		rev = compactRev
+	}
+
+	for i, inc := range incs {
+		if inc.FromRevision != rev {
+			return fmt.Errorf("incremental snapshot %q starts from revision %d, expected %d", paths[i], inc.FromRevision, rev)
+		}
+		s.lg.Info(
+			"applying incremental snapshot",
+			zap.String("path", paths[i]),
+			zap.Int64("from-revision", inc.FromRevision),
+			zap.Int64("revision", inc.Revision),
+			zap.Int("events", len(inc.Events)),
+		)
+		if err = unsafeApplyEvents(tx, inc.Events); err != nil {
+			return err
+		}
+		rev = inc.Revision
+	}
+
+	last := incs[len(incs)-1]
+	tx.UnsafeDeleteBucket(schema.Lease)
+	schema.UnsafeCreateLeaseBucket(tx)
+	for _, l := range last.Leases {
+		schema.MustUnsafePutLease(tx, &leasepb.Lease{ID: l.ID, TTL: l.TTL})
+	}
+	return nil
+}
+
+// unsafeApplyEvents writes the events to the keyspace the way the server
+// does, numbering the changes of each revision in order.
+func unsafeApplyEvents(tx backend.UnsafeWriter, events []*mvccpb.Event) error {
+	var sub, prevRev int64
+	for _, ev := range events {
+		if ev.Kv.ModRevision != prevRev {
+			prevRev, sub = ev.Kv.ModRevision, 0
+		}
+		rev := mvcc.Revision{Main: ev.Kv.ModRevision, Sub: sub}
+		sub++
+
+		key, kv := mvcc.RevToBytes(rev, mvcc.NewRevBytes()), ev.Kv
+		if ev.Type == mvccpb.DELETE {
+			// a tombstone only keeps the key it deletes
+			key, kv = mvcc.TombstoneRevToBytes(rev, mvcc.NewRevBytes()), &mvccpb.KeyValue{Key: ev.Kv.Key}
+		}
+		d, err := kv.Marshal()
+		if err != nil {
+			return err
+		}
+		tx.UnsafeSeqPut(schema.Key, key, d)
+	}
+	return nil
+}
+
+// copyVerifiedDB copies the snapshot file src to dst, without its integrity
+// hash once verified.
+func copyVerifiedDB(src, dst string, skipHashCheck bool) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileutil.PrivateFileMode)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	h := sha256.New()
+	hw := &holdBackWriter{w: io.MultiWriter(out, h), n: sha256.Size}
+	if _, err = io.Copy(hw, in); err != nil {
+		return err
+	}
+
+	hasHash := hasChecksum(hw.written + int64(len(hw.held)))
+	if !hasHash {
+		if _, err = out.Write(hw.held); err != nil {
+			return err
+		}
+		if !skipHashCheck {
+			return fmt.Errorf("snapshot missing hash but --skip-hash-check=false")
+		}
+	} else if !skipHashCheck {
+		if sha, dbsha := hw.held, h.Sum(nil); !reflect.DeepEqual(sha, dbsha) {
+			return fmt.Errorf("expected sha256 %v, got %v", sha, dbsha)
+		}
+	}
+	return out.Close()
+}
+
+// appendChecksum appends the sha256 of the file at path to it, as the server
+// does to the snapshots it sends, so that it is verified on restore.
+func appendChecksum(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, fileutil.PrivateFileMode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return err
+	}
+	if _, err = f.Write(h.Sum(nil)); err != nil {
+		return err
+	}
+	if err = fileutil.Fsync(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -29,7 +29,6 @@ import (
 
 	"go.uber.org/zap"
 
-	bolt "go.etcd.io/bbolt"
 	"go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/mvccpb"
 	"go.etcd.io/etcd/client/pkg/v3/fileutil"
@@ -42,6 +41,7 @@ import (
 	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
 	"go.etcd.io/etcd/server/v3/etcdserver/cindex"
 	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/encryption"
 	"go.etcd.io/etcd/server/v3/storage/mvcc"
 	"go.etcd.io/etcd/server/v3/storage/schema"
 	"go.etcd.io/etcd/server/v3/storage/wal"
@@ -69,6 +69,11 @@ type Manager interface {
 	// file. It returns an error if specified data directory already
 	// exists, to prevent unintended data directory overwrites.
 	Restore(cfg RestoreConfig) error
+
+	// Assemble builds a full snapshot file from a full snapshot file and
+	// the incremental snapshots taken after it. It returns an error if the
+	// output file already exists.
+	Assemble(cfg AssembleConfig) error
 }
 
 // NewV3 returns a new snapshot Manager for v3.x snapshot.
@@ -81,6 +86,7 @@ type v3Manager struct {
 
 	name      string
 	srcDbPath string
+	srcDb     io.Reader
 	walDir    string
 	snapDir   string
 	cl        *membership.RaftCluster
@@ -119,7 +125,10 @@ func (s *v3Manager) Status(dbPath string) (ds Status, err error) {
 		return ds, err
 	}
 
-	db, err := bolt.Open(dbPath, 0400, &bolt.Options{ReadOnly: true})
+	bcfg := backend.DefaultBackendConfig(s.lg)
+	bcfg.Path = dbPath
+	bcfg.ReadOnly = true
+	db, err := backend.OpenEngine(bcfg)
 	if err != nil {
 		return ds, err
 	}
@@ -127,22 +136,29 @@ func (s *v3Manager) Status(dbPath string) (ds Status, err error) {
 
 	h := crc32.New(crc32.MakeTable(crc32.Castagnoli))
 
-	if err = db.View(func(tx *bolt.Tx) error {
-		// check snapshot file integrity first
-		var dbErrStrings []string
-		for dbErr := range tx.Check() {
-			dbErrStrings = append(dbErrStrings, dbErr.Error())
+	if err = func() error {
+		tx, err := db.Begin(false)
+		if err != nil {
+			return err
 		}
-		if len(dbErrStrings) > 0 {
-			return fmt.Errorf("snapshot file integrity check failed. %d errors found.\n"+strings.Join(dbErrStrings, "\n"), len(dbErrStrings))
+		defer tx.Rollback()
+
+		// check snapshot file integrity first
+		if checker, ok := tx.(backend.EngineChecker); ok {
+			var dbErrStrings []string
+			for _, dbErr := range checker.Check() {
+				dbErrStrings = append(dbErrStrings, dbErr.Error())
+			}
+			if len(dbErrStrings) > 0 {
+				return fmt.Errorf("snapshot file integrity check failed. %d errors found.\n"+strings.Join(dbErrStrings, "\n"), len(dbErrStrings))
+			}
 		}
 		ds.TotalSize = tx.Size()
-		v := schema.ReadStorageVersionFromSnapshot(tx)
+		v := schema.ReadStorageVersionFromEngine(tx)
 		if v != nil {
 			ds.Version = v.String()
 		}
-		c := tx.Cursor()
-		for next, _ := c.First(); next != nil; next, _ = c.Next() {
+		return tx.ForEachBucket(func(next []byte) error {
 			b := tx.Bucket(next)
 			if b == nil {
 				return fmt.Errorf("nil bucket: %q", string(next))
@@ -170,10 +186,15 @@ func (s *v3Manager) Status(dbPath string) (ds Status, err error) {
 					}
 					ds.Revision = rev.Main
 
-					var kv mvccpb.KeyValue
-					err = kv.Unmarshal(v)
-					if err != nil {
-						return fmt.Errorf("cannot unmarshal value, key: %q value: %q err: %w", k, v, err)
+					// the records of a backend encrypted at rest can't
+					// be decoded without the key encryption key, and
+					// are authenticated when decrypted
+					if !encryption.IsEncrypted(v) {
+						var kv mvccpb.KeyValue
+						err = kv.Unmarshal(v)
+						if err != nil {
+							return fmt.Errorf("cannot unmarshal value, key: %q value: %q err: %w", k, v, err)
+						}
 					}
 				}
 				ds.TotalKey++
@@ -181,9 +202,9 @@ func (s *v3Manager) Status(dbPath string) (ds Status, err error) {
 			}); err != nil {
 				return fmt.Errorf("error during bucket key iteration, name: %q err: %w", string(next), err)
 			}
-		}
-		return nil
-	}); err != nil {
+			return nil
+		})
+	}(); err != nil {
 		return ds, err
 	}
 
@@ -204,6 +225,11 @@ func bytesToRev(b []byte) (rev mvcc.Revision, err error) {
 type RestoreConfig struct {
 	// SnapshotPath is the path of snapshot file to restore from.
 	SnapshotPath string
+	// SnapshotReader, if not nil, is the snapshot to restore from instead of
+	// the SnapshotPath file. It is streamed to the data directory and its
+	// integrity hash verified on the fly, so it needs not be seekable.
+	// SnapshotPath is then only used to identify the snapshot in logs.
+	SnapshotReader io.Reader
 
 	// Name is the human-readable name of this member.
 	Name string
@@ -289,6 +315,7 @@ func (s *v3Manager) Restore(cfg RestoreConfig) error {
 
 	s.name = cfg.Name
 	s.srcDbPath = cfg.SnapshotPath
+	s.srcDb = cfg.SnapshotReader
 	s.walDir = walDir
 	s.snapDir = filepath.Join(dataDir, "member", "snap")
 	s.skipHashCheck = cfg.SkipHashCheck
@@ -425,22 +452,14 @@ func (s *v3Manager) unsafeGetLatestRevision(tx backend.UnsafeReader) (mvcc.Revis
 }
 
 func (s *v3Manager) copyAndVerifyDB() error {
-	srcf, ferr := os.Open(s.srcDbPath)
-	if ferr != nil {
-		return ferr
-	}
-	defer srcf.Close()
-
-	// get snapshot integrity hash
-	if _, err := srcf.Seek(-sha256.Size, io.SeekEnd); err != nil {
-		return err
-	}
-	sha := make([]byte, sha256.Size)
-	if _, err := srcf.Read(sha); err != nil {
-		return err
-	}
-	if _, err := srcf.Seek(0, io.SeekStart); err != nil {
-		return err
+	src := s.srcDb
+	if src == nil {
+		srcf, ferr := os.Open(s.srcDbPath)
+		if ferr != nil {
+			return ferr
+		}
+		defer srcf.Close()
+		src = srcf
 	}
 
 	if err := fileutil.CreateDirAll(s.lg, s.snapDir); err != nil {
@@ -455,18 +474,17 @@ func (s *v3Manager) copyAndVerifyDB() error {
 	}
 	defer db.Close()
 
-	if _, err := io.Copy(db, srcf); err != nil {
+	// hash the snapshot as it is copied, holding back its last sha256.Size
+	// bytes which are the integrity hash, if any.
+	h := sha256.New()
+	hw := &holdBackWriter{w: io.MultiWriter(db, h), n: sha256.Size}
+	if _, err := io.Copy(hw, src); err != nil {
 		return err
 	}
 
-	// truncate away integrity hash, if any.
-	off, serr := db.Seek(0, io.SeekEnd)
-	if serr != nil {
-		return serr
-	}
-	hasHash := hasChecksum(off)
-	if hasHash {
-		if err := db.Truncate(off - sha256.Size); err != nil {
+	hasHash := hasChecksum(hw.written + int64(len(hw.held)))
+	if !hasHash {
+		if _, err := db.Write(hw.held); err != nil {
 			return err
 		}
 	}
@@ -477,14 +495,7 @@ func (s *v3Manager) copyAndVerifyDB() error {
 
 	if hasHash && !s.skipHashCheck {
 		// check for match
-		if _, err := db.Seek(0, io.SeekStart); err != nil {
-			return err
-		}
-		h := sha256.New()
-		if _, err := io.Copy(h, db); err != nil {
-			return err
-		}
-		dbsha := h.Sum(nil)
+		sha, dbsha := hw.held, h.Sum(nil)
 		if !reflect.DeepEqual(sha, dbsha) {
 			return fmt.Errorf("expected sha256 %v, got %v", sha, dbsha)
 		}
@@ -495,6 +506,34 @@ func (s *v3Manager) copyAndVerifyDB() error {
 	return nil
 }
 
+// holdBackWriter writes to w all but the last n bytes written to it, which
+// are held back.
+type holdBackWriter struct {
+	w       io.Writer
+	n       int
+	held    []byte
+	written int64
+}
+
+func (hw *holdBackWriter) Write(p []byte) (int, error) {
+	if len(hw.held)+len(p) <= hw.n {
+		hw.held = append(hw.held, p...)
+		return len(p), nil
+	}
+	// write out the oldest held bytes and p but the last n bytes
+	out := len(hw.held) + len(p) - hw.n
+	heldOut := min(out, len(hw.held))
+	if _, err := hw.w.Write(hw.held[:heldOut]); err != nil {
+		return 0, err
+	}
+	if _, err := hw.w.Write(p[:out-heldOut]); err != nil {
+		return 0, err
+	}
+	hw.written += int64(out)
+	hw.held = append(append(make([]byte, 0, hw.n), hw.held[heldOut:]...), p[out-heldOut:]...)
+	return len(p), nil
+}
+
 // saveWALAndSnap creates a WAL for the initial cluster
 //
 // TODO: This code ignores learners !!!
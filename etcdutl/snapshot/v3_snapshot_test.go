@@ -15,11 +15,15 @@
 package snapshot
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"errors"
+	"os"
 	"path/filepath"
 	"strconv"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/stretchr/testify/assert"
@@ -30,6 +34,8 @@ import (
 	"go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/server/v3/embed"
 	"go.etcd.io/etcd/server/v3/etcdserver"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/encryption"
 	"go.etcd.io/etcd/server/v3/storage/mvcc"
 	"go.etcd.io/etcd/server/v3/storage/schema"
 )
@@ -48,6 +54,28 @@ func TestSnapshotStatus(t *testing.T) {
 	assert.Equal(t, int64(11), status.Revision)
 }
 
+// TestSnapshotStatusEncrypted tests snapshot status command succeeds on a
+// backend encrypted at rest without decrypting it.
+func TestSnapshotStatusEncrypted(t *testing.T) {
+	kms, err := encryption.NewLocalKMS(bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+	dbpath := createDBWithConfig(t, func(cfg *embed.Config) { cfg.EncryptionKMS = kms }, insertKeys(t, 10, 100))
+
+	status, err := NewV3(zap.NewNop()).Status(dbpath)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), status.Revision)
+}
+
+// TestSnapshotStatusLogEngine tests snapshot status command succeeds on a
+// backend stored with the log storage engine.
+func TestSnapshotStatusLogEngine(t *testing.T) {
+	dbpath := createDBWithConfig(t, func(cfg *embed.Config) { cfg.ExperimentalBackendEngine = backend.EngineLog }, insertKeys(t, 10, 100))
+
+	status, err := NewV3(zap.NewNop()).Status(dbpath)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), status.Revision)
+}
+
 // TestSnapshotStatusCorruptRevision tests if snapshot status command fails when there is an unexpected revision in "key" bucket.
 func TestSnapshotStatusCorruptRevision(t *testing.T) {
 	dbpath := createDB(t, insertKeys(t, 1, 0))
@@ -118,6 +146,53 @@ func TestSnapshotStatusNegativeRevisionSub(t *testing.T) {
 	require.ErrorContains(t, err, "negative revision")
 }
 
+// TestSnapshotRestoreFromReader tests restoring a snapshot streamed from a
+// reader and verifying its integrity hash on the fly.
+func TestSnapshotRestoreFromReader(t *testing.T) {
+	db, err := os.ReadFile(createDB(t, insertKeys(t, 10, 100)))
+	require.NoError(t, err)
+	sha := sha256.Sum256(db)
+	snapshot := append(bytes.Clone(db), sha[:]...)
+	corrupted := bytes.Clone(snapshot)
+	corrupted[len(db)/2]++
+
+	tcs := []struct {
+		name          string
+		snapshot      []byte
+		skipHashCheck bool
+		expectErr     string
+	}{
+		{name: "with hash", snapshot: snapshot},
+		{name: "corrupted", snapshot: corrupted, expectErr: "expected sha256"},
+		{name: "missing hash", snapshot: db, expectErr: "snapshot missing hash"},
+		{name: "missing hash, skip hash check", snapshot: db, skipHashCheck: true},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			dataDir := filepath.Join(t.TempDir(), "default.etcd")
+			err := NewV3(zap.NewNop()).Restore(RestoreConfig{
+				SnapshotPath:        "-",
+				SnapshotReader:      iotest.HalfReader(bytes.NewReader(tc.snapshot)),
+				Name:                "default",
+				OutputDataDir:       dataDir,
+				PeerURLs:            []string{"http://localhost:2380"},
+				InitialCluster:      "default=http://localhost:2380",
+				InitialClusterToken: "etcd-cluster",
+				SkipHashCheck:       tc.skipHashCheck,
+			})
+			if tc.expectErr != "" {
+				require.ErrorContains(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+
+			status, err := NewV3(zap.NewNop()).Status(filepath.Join(dataDir, "member", "snap", "db"))
+			require.NoError(t, err)
+			assert.Equal(t, int64(11), status.Revision)
+		})
+	}
+}
+
 // insertKeys insert `numKeys` number of keys of `valueSize` size into a running etcd server.
 func insertKeys(t *testing.T, numKeys, valueSize int) func(*etcdserver.EtcdServer) {
 	t.Helper()
@@ -139,10 +214,18 @@ func insertKeys(t *testing.T, numKeys, valueSize int) func(*etcdserver.EtcdServe
 // It returns the path of bbolt database.
 func createDB(t *testing.T, generateContent func(*etcdserver.EtcdServer)) string {
 	t.Helper()
+	return createDBWithConfig(t, func(*embed.Config) {}, generateContent)
+}
+
+// createDBWithConfig is createDB with the configuration of the embedded etcd
+// server modified by `modifyConfig`.
+func createDBWithConfig(t *testing.T, modifyConfig func(*embed.Config), generateContent func(*etcdserver.EtcdServer)) string {
+	t.Helper()
 
 	cfg := embed.NewConfig()
 	cfg.LogLevel = "fatal"
 	cfg.Dir = t.TempDir()
+	modifyConfig(cfg)
 
 	etcd, err := embed.StartEtcd(cfg)
 	require.NoError(t, err)
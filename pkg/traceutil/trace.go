@@ -141,6 +141,17 @@ func (t *Trace) AddField(fields ...Field) {
 	}
 }
 
+// EachStep calls f with the time and message of each step of the trace, in
+// the order they were added, skipping the sub-trace marks.
+func (t *Trace) EachStep(f func(at time.Time, msg string)) {
+	for _, s := range t.steps {
+		if s.isSubTraceStart || s.isSubTraceEnd {
+			continue
+		}
+		f(s.time, s.msg)
+	}
+}
+
 func (t *Trace) IsEmpty() bool {
 	return t.isEmpty
 }
@@ -0,0 +1,94 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProtectedPrefixes maps the protected key prefixes to the only roles
+// allowed to modify their keys.
+type ProtectedPrefixes map[string][]string
+
+// Denying returns the protected prefix denying a user to modify the range
+// of keys, if any. The range is denied if it overlaps a protected prefix and
+// is either wider than the prefix or hasRole reports none of its roles. A
+// nil end is the single key, "\x00" all the keys greater than or equal to
+// key.
+func (pp ProtectedPrefixes) Denying(key, end []byte, hasRole func(role string) bool) (string, bool) {
+	prefixes := make([]string, 0, len(pp))
+	for prefix := range pp {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	fromKey := len(end) == 1 && end[0] == 0
+	for _, prefix := range prefixes {
+		p := []byte(prefix)
+		pend := prefixEnd(p)
+		if end == nil {
+			if !bytes.HasPrefix(key, p) {
+				continue
+			}
+		} else {
+			overlaps := (len(pend) == 0 || bytes.Compare(key, pend) < 0) && (fromKey || bytes.Compare(end, p) > 0)
+			if !overlaps {
+				continue
+			}
+			within := bytes.Compare(key, p) >= 0 && (len(pend) == 0 || !fromKey && bytes.Compare(end, pend) <= 0)
+			if !within {
+				return prefix, true
+			}
+		}
+		if !hasAnyRole(pp[prefix], hasRole) {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+// DenyReason returns why the protected prefix returned by Denying denies the
+// user to modify the range of keys ending at end.
+func (pp ProtectedPrefixes) DenyReason(prefix string, end []byte, hasRole func(role string) bool) string {
+	if end != nil && hasAnyRole(pp[prefix], hasRole) {
+		return fmt.Sprintf("the range covers keys under the protected prefix %q and outside of it", prefix)
+	}
+	return fmt.Sprintf("the keys under the protected prefix %q can only be modified by the roles %s", prefix, strings.Join(pp[prefix], ", "))
+}
+
+func hasAnyRole(roles []string, hasRole func(role string) bool) bool {
+	for _, r := range roles {
+		if hasRole(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// prefixEnd returns the end of the range of the keys with the prefix, empty
+// if the range is unbounded.
+func prefixEnd(prefix []byte) []byte {
+	end := bytes.Clone(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return []byte{}
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProtectedPrefixesDenying(t *testing.T) {
+	pp := ProtectedPrefixes{"/a/": {"a-admin"}, "/b/": {"b-admin", "admin"}}
+	tcs := []struct {
+		name       string
+		key, end   string
+		nilEnd     bool
+		roles      []string
+		wantPrefix string
+	}{
+		{name: "put with role", key: "/a/x", nilEnd: true, roles: []string{"a-admin"}},
+		{name: "put with any role", key: "/b/x", nilEnd: true, roles: []string{"admin"}},
+		{name: "put without role", key: "/a/x", nilEnd: true, roles: []string{"root"}, wantPrefix: "/a/"},
+		{name: "put outside prefixes", key: "/c/x", nilEnd: true},
+		{name: "delete prefix with role", key: "/a/", end: "/a0", roles: []string{"a-admin"}},
+		{name: "delete within prefix without role", key: "/a/x", end: "/a/y", wantPrefix: "/a/"},
+		{name: "delete wider than prefix with role", key: "/", end: "0", roles: []string{"a-admin"}, wantPrefix: "/a/"},
+		{name: "delete all keys", key: "\x00", end: "\x00", roles: []string{"a-admin", "admin"}, wantPrefix: "/a/"},
+		{name: "delete from key", key: "/b/x", end: "\x00", roles: []string{"admin"}, wantPrefix: "/b/"},
+		{name: "delete between prefixes", key: "/a0", end: "/b/"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var end []byte
+			if !tc.nilEnd {
+				end = []byte(tc.end)
+			}
+			hasRole := func(role string) bool {
+				for _, r := range tc.roles {
+					if r == role {
+						return true
+					}
+				}
+				return false
+			}
+			prefix, denied := pp.Denying([]byte(tc.key), end, hasRole)
+			assert.Equal(t, tc.wantPrefix, prefix)
+			assert.Equal(t, tc.wantPrefix != "", denied)
+		})
+	}
+}
+
+func TestProtectedPrefixesDenyReason(t *testing.T) {
+	pp := ProtectedPrefixes{"/a/": {"a-admin", "admin"}}
+	none := func(string) bool { return false }
+	all := func(string) bool { return true }
+	assert.Equal(t, `the keys under the protected prefix "/a/" can only be modified by the roles a-admin, admin`, pp.DenyReason("/a/", nil, none))
+	assert.Equal(t, `the keys under the protected prefix "/a/" can only be modified by the roles a-admin, admin`, pp.DenyReason("/a/", []byte("/a/y"), none))
+	assert.Equal(t, `the range covers keys under the protected prefix "/a/" and outside of it`, pp.DenyReason("/a/", []byte("0"), all))
+}
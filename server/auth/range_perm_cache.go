@@ -27,46 +27,51 @@ func getMergedPerms(tx UnsafeAuthReader, userName string) *unifiedRangePermissio
 		return nil
 	}
 
-	readPerms := adt.NewIntervalTree()
-	writePerms := adt.NewIntervalTree()
-
+	perms := newUnifiedRangePermissions()
 	for _, roleName := range user.Roles {
 		role := tx.UnsafeGetRole(roleName)
 		if role == nil {
 			continue
 		}
+		perms.add(role)
+	}
+	return perms
+}
 
-		for _, perm := range role.KeyPermission {
-			var ivl adt.Interval
-			var rangeEnd []byte
+func newUnifiedRangePermissions() *unifiedRangePermissions {
+	return &unifiedRangePermissions{
+		readPerms:  adt.NewIntervalTree(),
+		writePerms: adt.NewIntervalTree(),
+	}
+}
 
-			if len(perm.RangeEnd) != 1 || perm.RangeEnd[0] != 0 {
-				rangeEnd = perm.RangeEnd
-			}
+// add adds the key permissions of the role.
+func (perms *unifiedRangePermissions) add(role *authpb.Role) {
+	for _, perm := range role.KeyPermission {
+		var ivl adt.Interval
+		var rangeEnd []byte
 
-			if len(perm.RangeEnd) != 0 {
-				ivl = adt.NewBytesAffineInterval(perm.Key, rangeEnd)
-			} else {
-				ivl = adt.NewBytesAffinePoint(perm.Key)
-			}
+		if len(perm.RangeEnd) != 1 || perm.RangeEnd[0] != 0 {
+			rangeEnd = perm.RangeEnd
+		}
 
-			switch perm.PermType {
-			case authpb.READWRITE:
-				readPerms.Insert(ivl, struct{}{})
-				writePerms.Insert(ivl, struct{}{})
+		if len(perm.RangeEnd) != 0 {
+			ivl = adt.NewBytesAffineInterval(perm.Key, rangeEnd)
+		} else {
+			ivl = adt.NewBytesAffinePoint(perm.Key)
+		}
 
-			case authpb.READ:
-				readPerms.Insert(ivl, struct{}{})
+		switch perm.PermType {
+		case authpb.READWRITE:
+			perms.readPerms.Insert(ivl, struct{}{})
+			perms.writePerms.Insert(ivl, struct{}{})
 
-			case authpb.WRITE:
-				writePerms.Insert(ivl, struct{}{})
-			}
-		}
-	}
+		case authpb.READ:
+			perms.readPerms.Insert(ivl, struct{}{})
 
-	return &unifiedRangePermissions{
-		readPerms:  readPerms,
-		writePerms: writePerms,
+		case authpb.WRITE:
+			perms.writePerms.Insert(ivl, struct{}{})
+		}
 	}
 }
 
@@ -106,6 +111,38 @@ func checkKeyPoint(lg *zap.Logger, cachedPerms *unifiedRangePermissions, key []b
 	return false
 }
 
+// checkKey returns whether the permissions cover the key, or the range if
+// rangeEnd is set. READWRITE requires both the read and write permissions.
+func checkKey(lg *zap.Logger, cachedPerms *unifiedRangePermissions, key, rangeEnd []byte, permtyp authpb.Permission_Type) bool {
+	if permtyp == authpb.READWRITE {
+		return checkKey(lg, cachedPerms, key, rangeEnd, authpb.READ) && checkKey(lg, cachedPerms, key, rangeEnd, authpb.WRITE)
+	}
+	if len(rangeEnd) == 0 {
+		return checkKeyPoint(lg, cachedPerms, key, permtyp)
+	}
+	return checkKeyInterval(lg, cachedPerms, key, rangeEnd, permtyp)
+}
+
+// checkKeyOverlap returns whether the permissions cover the key, or part of
+// the range if rangeEnd is set. READWRITE requires either the read or the
+// write permission.
+func checkKeyOverlap(lg *zap.Logger, cachedPerms *unifiedRangePermissions, key, rangeEnd []byte, permtyp authpb.Permission_Type) bool {
+	if permtyp == authpb.READWRITE {
+		return checkKeyOverlap(lg, cachedPerms, key, rangeEnd, authpb.READ) || checkKeyOverlap(lg, cachedPerms, key, rangeEnd, authpb.WRITE)
+	}
+	if len(rangeEnd) == 0 {
+		return checkKeyPoint(lg, cachedPerms, key, permtyp)
+	}
+	if isOpenEnded(rangeEnd) {
+		rangeEnd = nil
+	}
+	ivl := adt.NewBytesAffineInterval(key, rangeEnd)
+	if permtyp == authpb.READ {
+		return cachedPerms.readPerms.Intersects(ivl)
+	}
+	return cachedPerms.writePerms.Intersects(ivl)
+}
+
 func (as *authStore) isRangeOpPermitted(userName string, key, rangeEnd []byte, permtyp authpb.Permission_Type) bool {
 	// assumption: tx is Lock()ed
 	as.rangePermCacheMu.RLock()
@@ -120,11 +157,7 @@ func (as *authStore) isRangeOpPermitted(userName string, key, rangeEnd []byte, p
 		return false
 	}
 
-	if len(rangeEnd) == 0 {
-		return checkKeyPoint(as.lg, rangePerm, key, permtyp)
-	}
-
-	return checkKeyInterval(as.lg, rangePerm, key, rangeEnd, permtyp)
+	return checkKey(as.lg, rangePerm, key, rangeEnd, permtyp)
 }
 
 func (as *authStore) refreshRangePermCache(tx UnsafeAuthReader) {
@@ -19,6 +19,7 @@ import (
 	"context"
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"sort"
 	"strings"
 	"sync"
@@ -151,6 +152,10 @@ type AuthStore interface {
 	// IsAdminPermitted checks admin permission of the user
 	IsAdminPermitted(authInfo *AuthInfo) error
 
+	// CheckPermission checks whether the roles of a user permit an
+	// operation on a key or range, and which of them grant it
+	CheckPermission(r *pb.AuthCheckPermissionRequest) (*pb.AuthCheckPermissionResponse, error)
+
 	// GenTokenPrefix produces a random string in a case of simple token
 	// in a case of JWT, it produces an empty string
 	GenTokenPrefix() (string, error)
@@ -932,6 +937,52 @@ func (as *authStore) IsAdminPermitted(authInfo *AuthInfo) error {
 	return nil
 }
 
+func (as *authStore) CheckPermission(r *pb.AuthCheckPermissionRequest) (*pb.AuthCheckPermissionResponse, error) {
+	if !as.IsAuthEnabled() {
+		return &pb.AuthCheckPermissionResponse{Permitted: true, Reason: "authentication is not enabled"}, nil
+	}
+
+	tx := as.be.ReadTx()
+	tx.RLock()
+	defer tx.RUnlock()
+
+	user := tx.UnsafeGetUser(r.Name)
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if hasRootRole(user) {
+		return &pb.AuthCheckPermissionResponse{Permitted: true, Roles: []string{rootRole}, Reason: "the user has the root role"}, nil
+	}
+
+	resp := &pb.AuthCheckPermissionResponse{}
+	for _, roleName := range user.Roles {
+		role := tx.UnsafeGetRole(roleName)
+		if role == nil {
+			continue
+		}
+		perms := newUnifiedRangePermissions()
+		perms.add(role)
+		if checkKeyOverlap(as.lg, perms, r.Key, r.RangeEnd, r.PermType) {
+			resp.Roles = append(resp.Roles, roleName)
+		}
+	}
+
+	target := "the key"
+	if len(r.RangeEnd) != 0 {
+		target = "the range"
+	}
+	switch {
+	case len(resp.Roles) == 0:
+		resp.Reason = fmt.Sprintf("no role of the user grants %s permission on %s", r.PermType, target)
+	case checkKey(as.lg, getMergedPerms(tx, r.Name), r.Key, r.RangeEnd, r.PermType):
+		resp.Permitted = true
+		resp.Reason = fmt.Sprintf("the roles grant %s permission on %s", r.PermType, target)
+	default:
+		resp.Reason = fmt.Sprintf("the roles grant %s permission on only part of the range", r.PermType)
+	}
+	return resp, nil
+}
+
 func (as *authStore) IsAuthEnabled() bool {
 	as.enabledMu.RLock()
 	defer as.enabledMu.RUnlock()
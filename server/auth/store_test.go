@@ -461,6 +461,63 @@ func TestIsOpPermitted(t *testing.T) {
 
 }
 
+func TestCheckPermission(t *testing.T) {
+	as, tearDown := setupAuthStore(t)
+	defer tearDown(t)
+
+	grant := func(role string, perm *authpb.Permission) {
+		_, err := as.RoleAdd(&pb.AuthRoleAddRequest{Name: role})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = as.RoleGrantPermission(&pb.AuthRoleGrantPermissionRequest{Name: role, Perm: perm})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = as.UserGrantRole(&pb.AuthUserGrantRoleRequest{User: "foo", Role: role})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	grant("role-a", &authpb.Permission{PermType: authpb.READWRITE, Key: []byte("a"), RangeEnd: []byte("m")})
+	grant("role-b", &authpb.Permission{PermType: authpb.WRITE, Key: []byte("m"), RangeEnd: []byte("z")})
+
+	tests := []struct {
+		key, rangeEnd string
+		permType      authpb.Permission_Type
+		permitted     bool
+		roles         []string
+	}{
+		{key: "foo", permType: authpb.WRITE, permitted: true, roles: []string{"role-a"}},
+		{key: "foo", permType: authpb.READ, permitted: true, roles: []string{"role-a"}},
+		{key: "x", permType: authpb.READ, permitted: false},
+		{key: "x", permType: authpb.WRITE, permitted: true, roles: []string{"role-b"}},
+		{key: "c", rangeEnd: "x", permType: authpb.WRITE, permitted: true, roles: []string{"role-a", "role-b"}},
+		{key: "c", rangeEnd: "x", permType: authpb.READ, permitted: false, roles: []string{"role-a"}},
+		{key: "c", rangeEnd: "x", permType: authpb.READWRITE, permitted: false, roles: []string{"role-a", "role-b"}},
+		{key: "c", rangeEnd: "\x00", permType: authpb.WRITE, permitted: false, roles: []string{"role-a", "role-b"}},
+	}
+	for _, tt := range tests {
+		resp, err := as.CheckPermission(&pb.AuthCheckPermissionRequest{Name: "foo", PermType: tt.permType, Key: []byte(tt.key), RangeEnd: []byte(tt.rangeEnd)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, tt.permitted, resp.Permitted, "%s [%q, %q): %s", tt.permType, tt.key, tt.rangeEnd, resp.Reason)
+		assert.Equal(t, tt.roles, resp.Roles, "%s [%q, %q)", tt.permType, tt.key, tt.rangeEnd)
+		assert.NotEmpty(t, resp.Reason)
+	}
+
+	resp, err := as.CheckPermission(&pb.AuthCheckPermissionRequest{Name: "root", PermType: authpb.WRITE, Key: []byte("x")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, resp.Permitted)
+	assert.Equal(t, []string{"root"}, resp.Roles)
+
+	_, err = as.CheckPermission(&pb.AuthCheckPermissionRequest{Name: "nobody", PermType: authpb.READ, Key: []byte("x")})
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
 func TestGetUser(t *testing.T) {
 	as, tearDown := setupAuthStore(t)
 	defer tearDown(t)
@@ -17,6 +17,7 @@ package config
 import (
 	"context"
 	"fmt"
+	"net"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -30,8 +31,12 @@ import (
 	"go.etcd.io/etcd/client/pkg/v3/types"
 	"go.etcd.io/etcd/pkg/v3/featuregate"
 	"go.etcd.io/etcd/pkg/v3/netutil"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3admission"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3discovery"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3notify"
 	"go.etcd.io/etcd/server/v3/storage/datadir"
+	"go.etcd.io/etcd/server/v3/storage/encryption"
+	"go.etcd.io/etcd/server/v3/storage/wal"
 )
 
 // ServerConfig holds the configuration of etcd as taken from the command line or discovery.
@@ -119,8 +124,14 @@ type ServerConfig struct {
 	AutoCompactionMode      string
 	CompactionBatchLimit    int
 	CompactionSleepInterval time.Duration
-	QuotaBackendBytes       int64
-	MaxTxnOps               uint
+	// CompactionPauseTarget, if set, is the target time each compaction
+	// batch holds up writes.
+	CompactionPauseTarget time.Duration
+	// CompactionMaxSleepInterval bounds the sleep between compaction batches
+	// when pacing them to CompactionPauseTarget.
+	CompactionMaxSleepInterval time.Duration
+	QuotaBackendBytes          int64
+	MaxTxnOps                  uint
 
 	// MaxRequestBytes is the maximum request size to send over raft.
 	MaxRequestBytes uint
@@ -129,11 +140,43 @@ type ServerConfig struct {
 	// streams that each client can open at a time.
 	MaxConcurrentStreams uint32
 
+	// GRPCMaxRecvBytes, if not zero, is the maximum message size in bytes
+	// the client gRPC server can receive. Defaults to MaxRequestBytes plus
+	// the gRPC overhead.
+	GRPCMaxRecvBytes uint
+	// GRPCMaxSendBytes, if not zero, is the maximum message size in bytes
+	// the client gRPC server can send. Defaults to math.MaxInt32.
+	GRPCMaxSendBytes uint
+
+	// MaxStreamsPerClientIP, if not zero, is the maximum number of concurrent
+	// gRPC streams that can be opened from a single client IP.
+	MaxStreamsPerClientIP uint
+	// ClientIPLimitAllowlist are the client IP ranges, such as the ones of
+	// proxies, that are exempted from the per client IP limits.
+	ClientIPLimitAllowlist []*net.IPNet
+
 	WarningApplyDuration        time.Duration
 	WarningUnaryRequestDuration time.Duration
 
+	// SlowRequestThreshold, if not zero, is the duration above which unary
+	// requests are logged with where the time was spent.
+	SlowRequestThreshold time.Duration
+
 	StrictReconfigCheck bool
 
+	// ExperimentalAutoPromoteLearner enables the leader to promote learners once they
+	// have stayed within ExperimentalAutoPromoteLearnerMaxLag entries of the leader for
+	// ExperimentalAutoPromoteLearnerStabilizationWindow. Read replicas are never promoted.
+	ExperimentalAutoPromoteLearner                    bool
+	ExperimentalAutoPromoteLearnerMaxLag              uint64
+	ExperimentalAutoPromoteLearnerStabilizationWindow time.Duration
+
+	// LeaderPriorities maps the names of the members to their leader
+	// priority. The leader transfers the leadership to the healthy voting
+	// member with the highest priority, if higher than its own. A priority
+	// of 0 means the member should never lead.
+	LeaderPriorities map[string]int
+
 	// ClientCertAuthEnabled is true when cert has been signed by the client CA.
 	ClientCertAuthEnabled bool
 
@@ -147,6 +190,12 @@ type ServerConfig struct {
 	CorruptCheckTime        time.Duration
 	CompactHashCheckEnabled bool
 	CompactHashCheckTime    time.Duration
+	// CorruptRangeCheckTime is the duration of time between the leader's
+	// comparisons of ranged hashes with the followers. 0 disables them.
+	CorruptRangeCheckTime time.Duration
+	// CorruptRangeCheckKeys is the number of keys hashed by each ranged
+	// hash comparison.
+	CorruptRangeCheckKeys int64
 
 	// PreVote is true to enable Raft Pre-Vote.
 	PreVote bool
@@ -165,6 +214,13 @@ type ServerConfig struct {
 	LeaseCheckpointInterval time.Duration
 	// LeaseCheckpointPersist enables persisting remainingTTL to prevent indefinite auto-renewal of long lived leases. Always enabled in v3.6. Should be used to ensure smooth upgrade from v3.5 clusters with this feature enabled.
 	LeaseCheckpointPersist bool
+	// LeaseRevokeRate is the maximum number of expired leases revoked per
+	// second by the leader. If 0, the lessor's default rate is used and the
+	// revocations are not paced.
+	LeaseRevokeRate int
+	// LeasePinMaxDuration bounds how long a lease pinning a revision against
+	// compaction can be kept alive.
+	LeasePinMaxDuration time.Duration
 
 	EnableGRPCGateway bool
 
@@ -172,6 +228,10 @@ type ServerConfig struct {
 	ExperimentalEnableDistributedTracing bool
 	// ExperimentalTracerOptions are options for OpenTelemetry gRPC interceptor.
 	ExperimentalTracerOptions []otelgrpc.Option
+	// ExperimentalDistributedTracingSlowRequestThreshold, if not zero, is the
+	// duration above which the raft requests are traced although their gRPC
+	// span was not sampled.
+	ExperimentalDistributedTracingSlowRequestThreshold time.Duration
 
 	WatchProgressNotifyInterval time.Duration
 
@@ -209,6 +269,145 @@ type ServerConfig struct {
 	// ExperimentalLocalAddress is the local IP address to use when communicating with a peer.
 	ExperimentalLocalAddress string `json:"experimental-local-address"`
 
+	// ExperimentalTrustedProxyCNs is the list of client certificate common names
+	// of proxies that may forward the common name of their own clients.
+	ExperimentalTrustedProxyCNs []string `json:"experimental-trusted-proxy-cns"`
+
+	// ExperimentalLearnerReadReplica marks the member, when it is a learner, as
+	// a permanent read-only replica that serves serializable reads and watches
+	// and is never promoted to a voting member.
+	ExperimentalLearnerReadReplica bool `json:"experimental-learner-read-replica"`
+
+	// ExperimentalWitness makes the member a witness, which votes in the raft
+	// quorum but neither stores the key-value store nor serves client
+	// requests, and gives away the leadership whenever it is elected.
+	ExperimentalWitness bool `json:"experimental-witness"`
+
+	// ExperimentalSnapshotSendRateBytes limits the bandwidth, in bytes per
+	// second, used to send snapshots to peers. Zero means no limit.
+	ExperimentalSnapshotSendRateBytes int64 `json:"experimental-snapshot-send-rate-bytes"`
+	// ExperimentalSnapshotCompression enables gzip compression of snapshots
+	// sent to peers. All members must support receiving compressed snapshots.
+	ExperimentalSnapshotCompression bool `json:"experimental-snapshot-compression"`
+	// ExperimentalDifferentialSnapshot enables sending followers that are
+	// only slightly behind the key revisions they miss instead of the whole
+	// database, falling back to full snapshots when not feasible.
+	ExperimentalDifferentialSnapshot bool `json:"experimental-differential-snapshot"`
+
+	// ExperimentalWALCompression is the codec used to compress WAL entries.
+	ExperimentalWALCompression wal.CompressionType `json:"experimental-wal-compression"`
+
+	// ExperimentalOnlineDefrag makes defragmentation block writes only while
+	// applying the writes made during the copy of the backend.
+	ExperimentalOnlineDefrag bool `json:"experimental-online-defrag"`
+
+	// ExperimentalQuotaBackendWarningRatios are the ratios of the backend
+	// quota at which a warning is logged when the backend size reaches them.
+	ExperimentalQuotaBackendWarningRatios []float64 `json:"experimental-quota-backend-warning-ratios"`
+	// ExperimentalAutoRecoverNoSpace makes the leader recover from NOSPACE
+	// alarms by compacting, defragmenting each member and disarming them.
+	ExperimentalAutoRecoverNoSpace bool `json:"experimental-auto-recover-nospace"`
+
+	// BackendEngine is the storage engine of the backend. If empty, the engine
+	// that wrote the existing backend file is used, or bbolt for a new one.
+	BackendEngine string `json:"backend-engine"`
+
+	// EncryptionKMS, if set, enables encryption of key-value records in the
+	// backend with data encryption keys wrapped by the KMS.
+	EncryptionKMS encryption.KMS `json:"-"`
+
+	// ChangeNotifyTargets receive the changes to the keys under their
+	// prefixes, at least once.
+	ChangeNotifyTargets []v3notify.Target `json:"-"`
+
+	// ExperimentalKeyspaceMetricsPrefixes are the key prefixes for which the
+	// number of keys, the size of their values and the number of writes are
+	// exported as metrics.
+	ExperimentalKeyspaceMetricsPrefixes []string `json:"experimental-keyspace-metrics-prefixes"`
+
+	// ExperimentalPrincipalMetrics enables client request metrics labeled by
+	// the authenticated user or client certificate common name.
+	ExperimentalPrincipalMetrics bool `json:"experimental-principal-metrics"`
+	// ExperimentalPrincipalMetricsMaxPrincipals is the maximum number of
+	// principals given their own label. The others are labeled "other".
+	ExperimentalPrincipalMetricsMaxPrincipals int `json:"experimental-principal-metrics-max-principals"`
+	// ExperimentalPrincipalMetricsAllowlist, if not empty, is the list of the
+	// only principals given their own label.
+	ExperimentalPrincipalMetricsAllowlist []string `json:"experimental-principal-metrics-allowlist"`
+
+	// ExperimentalInflightRequests enables tracking the requests being
+	// served, listed at /debug/inflight.
+	ExperimentalInflightRequests bool `json:"experimental-inflight-requests"`
+
+	// ExperimentalRangeTombstoneThreshold, if not zero, is the number of keys
+	// above which a delete records a single range tombstone instead of a
+	// tombstone per key.
+	ExperimentalRangeTombstoneThreshold int `json:"experimental-range-tombstone-threshold"`
+
+	// ExperimentalWatchSlowWatcherPolicy is what is done with the watchers
+	// that cannot keep up with the events: "cancel", "resync" or "block", or
+	// nothing if empty.
+	ExperimentalWatchSlowWatcherPolicy string `json:"experimental-watch-slow-watcher-policy"`
+	// ExperimentalWatchSlowWatcherMaxLag is the number of revisions a slow
+	// watcher may lag behind before the policy applies.
+	ExperimentalWatchSlowWatcherMaxLag int64 `json:"experimental-watch-slow-watcher-max-lag"`
+	// ExperimentalWatchSlowWatcherBlockTimeout bounds how long a write blocks
+	// on the slow watchers under the "block" policy.
+	ExperimentalWatchSlowWatcherBlockTimeout time.Duration `json:"experimental-watch-slow-watcher-block-timeout"`
+
+	// ExperimentalHotKeysSampleRate is the fraction of the requests sampled to
+	// track the keys most frequently read and written. 0 disables the tracking.
+	ExperimentalHotKeysSampleRate float64 `json:"experimental-hot-keys-sample-rate"`
+
+	// ExperimentalSoftDeleteThreshold, if not zero, is the number of keys
+	// above which a delete moves the keys to the trash instead of deleting
+	// them.
+	ExperimentalSoftDeleteThreshold int `json:"experimental-soft-delete-threshold"`
+	// ExperimentalSoftDeletePrefixes are the key prefixes whose keys are
+	// always moved to the trash when deleted.
+	ExperimentalSoftDeletePrefixes []string `json:"experimental-soft-delete-prefixes"`
+	// ExperimentalSoftDeleteRetention is how long the deleted keys are kept
+	// in the trash.
+	ExperimentalSoftDeleteRetention time.Duration `json:"experimental-soft-delete-retention"`
+	// ExperimentalSoftDeleteTrashPrefix is the prefix of the keys in the
+	// trash.
+	ExperimentalSoftDeleteTrashPrefix string `json:"experimental-soft-delete-trash-prefix"`
+
+	// ExperimentalProtectedPrefixes maps the protected key prefixes to the
+	// only roles allowed to modify their keys. Deletions of ranges wider
+	// than a protected prefix are rejected.
+	ExperimentalProtectedPrefixes map[string][]string `json:"experimental-protected-prefixes"`
+
+	// ExperimentalDiskProbeInterval, if not zero, is how often the latency
+	// of a write and fsync on the WAL device is probed.
+	ExperimentalDiskProbeInterval time.Duration `json:"experimental-disk-probe-interval"`
+	// ExperimentalDiskProbeThreshold is the probe latency above which the
+	// disk is considered slow.
+	ExperimentalDiskProbeThreshold time.Duration `json:"experimental-disk-probe-threshold"`
+	// ExperimentalDiskProbeFailures is the number of consecutive slow probes
+	// raising a SLOWDISK alarm, and of fast probes clearing it.
+	ExperimentalDiskProbeFailures int `json:"experimental-disk-probe-failures"`
+	// ExperimentalDiskProbeSelfFence drains the member while its SLOWDISK
+	// alarm is raised.
+	ExperimentalDiskProbeSelfFence bool `json:"experimental-disk-probe-self-fence"`
+
+	// ExperimentalMaintenanceWindows is the semicolon-separated list of the
+	// maintenance windows, each a cron schedule in UTC of its start followed
+	// by its duration. Background maintenance is deferred to the windows if
+	// set.
+	ExperimentalMaintenanceWindows string `json:"experimental-maintenance-windows"`
+	// ExperimentalMaintenanceDefragThresholdMegabytes, if not zero, is the
+	// minimum number of megabytes a defragmentation must free for the leader
+	// to defragment the backend of a member in a maintenance window.
+	ExperimentalMaintenanceDefragThresholdMegabytes uint `json:"experimental-maintenance-defrag-threshold-megabytes"`
+
+	// LifecycleHooks are callbacks invoked on server lifecycle events.
+	LifecycleHooks LifecycleHooks `json:"-"`
+
+	// Admission admits or rejects the Put, DeleteRange and Txn requests
+	// before they are proposed to raft. Nil admits all requests.
+	Admission v3admission.Controller `json:"-"`
+
 	// ServerFeatureGate is a server level feature gate
 	ServerFeatureGate featuregate.FeatureGate
 }
@@ -317,6 +516,8 @@ func (c *ServerConfig) WALDir() string {
 	return datadir.ToWALDir(c.DataDir)
 }
 
+func (c *ServerConfig) NotifyDir() string { return datadir.ToNotifyDir(c.DataDir) }
+
 func (c *ServerConfig) SnapDir() string { return filepath.Join(c.MemberDir(), "snap") }
 
 func (c *ServerConfig) ShouldDiscover() bool {
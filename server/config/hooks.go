@@ -0,0 +1,40 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+// LifecycleHooks are callbacks invoked on server lifecycle events, for
+// applications embedding etcd. Every hook is optional. Hooks are called one at
+// a time, in the order of the events, from a goroutine of their own so they
+// never block the server; a slow hook delays the following ones and the
+// shutdown of the server.
+type LifecycleHooks struct {
+	// OnReady is called once the member has published itself to the
+	// cluster and is ready to serve client requests.
+	OnReady func()
+	// OnLeaderElected is called when the member becomes the raft leader.
+	OnLeaderElected func()
+	// OnLeadershipLost is called when the member stops being the raft leader.
+	OnLeadershipLost func()
+	// OnCompacted is called when the member has finished physically
+	// compacting its keyspace up to the given revision.
+	OnCompacted func(rev int64)
+	// OnDefragStarted and OnDefragFinished are called around a defragmentation
+	// of the member's backend requested through the maintenance API.
+	OnDefragStarted  func()
+	OnDefragFinished func(err error)
+	// OnLearnerPromoted is called when the member, as leader, automatically
+	// promoted the learner with the given ID.
+	OnLearnerPromoted func(id uint64)
+}
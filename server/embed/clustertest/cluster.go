@@ -0,0 +1,409 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clustertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/pkg/v3/proxy"
+	"go.etcd.io/etcd/server/v3/embed"
+)
+
+// DefaultStartTimeout is the default time to wait for a member to be ready.
+const DefaultStartTimeout = 30 * time.Second
+
+// Config is the configuration of a cluster.
+type Config struct {
+	// Size is the number of members. Defaults to 3.
+	Size int
+	// Dir is the directory holding the data directories of the members.
+	// Defaults to a new temporary directory, removed on Close.
+	Dir string
+	// Logger is the logger of the members and the proxies. Defaults to a
+	// no-op logger.
+	Logger *zap.Logger
+	// StartTimeout is the time to wait for a member to be ready when
+	// starting or restarting it. Defaults to DefaultStartTimeout.
+	StartTimeout time.Duration
+	// Configure, if set, is called with the index and the configuration of
+	// each member before it's first started, to set e.g. the experimental
+	// flags. The URLs, name, data directory and initial cluster must not be
+	// changed.
+	Configure func(i int, cfg *embed.Config)
+}
+
+// Cluster is a cluster of etcd members running in the current process.
+type Cluster struct {
+	cfg       Config
+	removeDir bool
+
+	mu      sync.Mutex
+	members []*Member
+}
+
+// Member is a member of a Cluster.
+type Member struct {
+	// Name is the name of the member.
+	Name string
+	// ClientURL is the URL clients connect to.
+	ClientURL string
+
+	c     *Cluster
+	cfg   *embed.Config
+	etcd  *embed.Etcd
+	proxy proxy.Server
+}
+
+// Etcd returns the running etcd of the member, or nil if it's stopped.
+func (m *Member) Etcd() *embed.Etcd {
+	m.c.mu.Lock()
+	defer m.c.mu.Unlock()
+	return m.etcd
+}
+
+// New starts a cluster and waits for all its members to be ready.
+func New(cfg Config) (*Cluster, error) {
+	if cfg.Size == 0 {
+		cfg.Size = 3
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	if cfg.StartTimeout == 0 {
+		cfg.StartTimeout = DefaultStartTimeout
+	}
+	c := &Cluster{cfg: cfg}
+	if c.cfg.Dir == "" {
+		dir, err := os.MkdirTemp("", "etcd-clustertest")
+		if err != nil {
+			return nil, err
+		}
+		c.cfg.Dir, c.removeDir = dir, true
+	}
+	if err := c.start(); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Cluster) start() error {
+	var initialCluster []string
+	for i := 0; i < c.cfg.Size; i++ {
+		ports, err := freePorts(3)
+		if err != nil {
+			return err
+		}
+		clientURL := url.URL{Scheme: "http", Host: fmt.Sprintf("127.0.0.1:%d", ports[0])}
+		listenPeerURL := url.URL{Scheme: "http", Host: fmt.Sprintf("127.0.0.1:%d", ports[1])}
+		// the peers reach the member through a proxy delaying the traffic
+		// when latency is injected
+		advertisePeerURL := url.URL{Scheme: "http", Host: fmt.Sprintf("127.0.0.1:%d", ports[2])}
+
+		m := &Member{Name: fmt.Sprintf("m%d", i), ClientURL: clientURL.String(), c: c}
+		m.proxy = proxy.NewServer(proxy.ServerConfig{
+			Logger: c.cfg.Logger,
+			From:   advertisePeerURL,
+			To:     listenPeerURL,
+		})
+		c.members = append(c.members, m)
+		select {
+		case <-m.proxy.Ready():
+		case err = <-m.proxy.Error():
+			return err
+		}
+
+		m.cfg = embed.NewConfig()
+		m.cfg.Name = m.Name
+		m.cfg.Dir = filepath.Join(c.cfg.Dir, m.Name)
+		m.cfg.ListenClientUrls = []url.URL{clientURL}
+		m.cfg.AdvertiseClientUrls = []url.URL{clientURL}
+		m.cfg.ListenPeerUrls = []url.URL{listenPeerURL}
+		m.cfg.AdvertisePeerUrls = []url.URL{advertisePeerURL}
+		m.cfg.Logger = "zap"
+		m.cfg.ZapLoggerBuilder = embed.NewZapLoggerBuilder(c.cfg.Logger.Named(m.Name))
+		initialCluster = append(initialCluster, fmt.Sprintf("%s=%s", m.Name, advertisePeerURL.String()))
+	}
+	for i, m := range c.members {
+		m.cfg.InitialCluster = strings.Join(initialCluster, ",")
+		m.cfg.InitialClusterToken = filepath.Base(c.cfg.Dir)
+		if c.cfg.Configure != nil {
+			c.cfg.Configure(i, m.cfg)
+		}
+	}
+
+	// the members can only be ready once a quorum is started
+	errc := make(chan error, len(c.members))
+	for _, m := range c.members {
+		go func(m *Member) { errc <- c.startMember(m) }(m)
+	}
+	var errs []error
+	for range c.members {
+		errs = append(errs, <-errc)
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Cluster) startMember(m *Member) error {
+	e, err := embed.StartEtcd(m.cfg)
+	if err != nil {
+		return fmt.Errorf("failed to start %s: %w", m.Name, err)
+	}
+	select {
+	case <-e.Server.ReadyNotify():
+	case err = <-e.Err():
+		e.Close()
+		return fmt.Errorf("failed to start %s: %w", m.Name, err)
+	case <-time.After(c.cfg.StartTimeout):
+		e.Close()
+		return fmt.Errorf("%s did not become ready within %v", m.Name, c.cfg.StartTimeout)
+	}
+	c.mu.Lock()
+	m.etcd = e
+	c.mu.Unlock()
+	return nil
+}
+
+// freePorts returns n ports free on the loopback interface.
+func freePorts(n int) ([]int, error) {
+	var ports []int
+	for i := 0; i < n; i++ {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return nil, err
+		}
+		defer l.Close()
+		ports = append(ports, l.Addr().(*net.TCPAddr).Port)
+	}
+	return ports, nil
+}
+
+// Members returns the members of the cluster, in the order of their index.
+func (c *Cluster) Members() []*Member {
+	return c.members
+}
+
+// Endpoints returns the client URLs of the members.
+func (c *Cluster) Endpoints() []string {
+	var eps []string
+	for _, m := range c.members {
+		eps = append(eps, m.ClientURL)
+	}
+	return eps
+}
+
+// Client returns a new client connected to all the members. The caller
+// closes it.
+func (c *Cluster) Client() (*clientv3.Client, error) {
+	return clientv3.New(clientv3.Config{Endpoints: c.Endpoints(), DialTimeout: 5 * time.Second})
+}
+
+// MemberClient returns a new client connected to the i-th member only. The
+// caller closes it.
+func (c *Cluster) MemberClient(i int) (*clientv3.Client, error) {
+	return clientv3.New(clientv3.Config{Endpoints: []string{c.members[i].ClientURL}, DialTimeout: 5 * time.Second})
+}
+
+// WaitLeader waits until the given members, or all the running members if
+// none is given, agree on a running leader and returns its index. The members
+// partitioned from the quorum should not be given.
+func (c *Cluster) WaitLeader(ctx context.Context, members ...int) (int, error) {
+	return c.waitLeader(ctx, -1, members)
+}
+
+// WaitNewLeader is like WaitLeader but waits for a leader other than prev,
+// e.g. once prev is killed or isolated.
+func (c *Cluster) WaitNewLeader(ctx context.Context, prev int, members ...int) (int, error) {
+	return c.waitLeader(ctx, prev, members)
+}
+
+func (c *Cluster) waitLeader(ctx context.Context, prev int, members []int) (int, error) {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if lead := c.leader(members); lead >= 0 && lead != prev {
+			return lead, nil
+		}
+		select {
+		case <-ctx.Done():
+			return -1, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// leader returns the index of the leader the members agree on, or -1 if
+// there is none.
+func (c *Cluster) leader(members []int) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(members) == 0 {
+		for i, m := range c.members {
+			if m.etcd != nil {
+				members = append(members, i)
+			}
+		}
+	}
+	lead := -1
+	for _, i := range members {
+		m := c.members[i]
+		if m.etcd == nil {
+			return -1
+		}
+		l := c.indexOf(uint64(m.etcd.Server.Leader()))
+		if l < 0 || lead >= 0 && lead != l {
+			return -1
+		}
+		lead = l
+	}
+	return lead
+}
+
+func (c *Cluster) indexOf(id uint64) int {
+	for i, m := range c.members {
+		if m.etcd != nil && uint64(m.etcd.Server.MemberID()) == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Partition drops all the messages between the members in the two groups,
+// given by their indexes, until Heal is called. The stopped members are
+// skipped.
+func (c *Cluster) Partition(a, b []int) {
+	c.forEachLink(a, b, func(from *Member, to *Member) { from.etcd.Server.CutPeer(to.etcd.Server.MemberID()) })
+}
+
+// Isolate partitions the i-th member from all the others.
+func (c *Cluster) Isolate(i int) {
+	c.Partition([]int{i}, c.others(i))
+}
+
+// Heal recovers all the partitions.
+func (c *Cluster) Heal() {
+	all := make([]int, len(c.members))
+	for i := range all {
+		all[i] = i
+	}
+	c.forEachLink(all, all, func(from *Member, to *Member) { from.etcd.Server.MendPeer(to.etcd.Server.MemberID()) })
+}
+
+func (c *Cluster) forEachLink(a, b []int, f func(from, to *Member)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, i := range a {
+		for _, j := range b {
+			from, to := c.members[i], c.members[j]
+			if i == j || from.etcd == nil || to.etcd == nil {
+				continue
+			}
+			f(from, to)
+			f(to, from)
+		}
+	}
+}
+
+func (c *Cluster) others(i int) []int {
+	var others []int
+	for j := range c.members {
+		if j != i {
+			others = append(others, j)
+		}
+	}
+	return others
+}
+
+// InjectLatency delays the traffic from and to the peers of the i-th member
+// by latency, plus a random variation of up to rv, until RemoveLatency is
+// called.
+func (c *Cluster) InjectLatency(i int, latency, rv time.Duration) {
+	p := c.members[i].proxy
+	p.DelayTx(latency, rv)
+	p.DelayRx(latency, rv)
+}
+
+// RemoveLatency removes the latency injected into the i-th member.
+func (c *Cluster) RemoveLatency(i int) {
+	p := c.members[i].proxy
+	p.UndelayTx()
+	p.UndelayRx()
+}
+
+// Kill stops the i-th member immediately, without transferring its
+// leadership. Its data directory is kept so it can be restarted.
+func (c *Cluster) Kill(i int) {
+	c.mu.Lock()
+	m := c.members[i]
+	e := m.etcd
+	m.etcd = nil
+	c.mu.Unlock()
+	if e == nil {
+		return
+	}
+	e.Server.HardStop()
+	e.Close()
+}
+
+// Stop gracefully stops the i-th member. Its data directory is kept so it
+// can be restarted.
+func (c *Cluster) Stop(i int) {
+	c.mu.Lock()
+	m := c.members[i]
+	e := m.etcd
+	m.etcd = nil
+	c.mu.Unlock()
+	if e != nil {
+		e.Close()
+	}
+}
+
+// Restart starts the stopped i-th member from its data directory and waits
+// for it to be ready.
+func (c *Cluster) Restart(i int) error {
+	m := c.members[i]
+	if m.Etcd() != nil {
+		return fmt.Errorf("%s is running", m.Name)
+	}
+	return c.startMember(m)
+}
+
+// Close stops all the members and the proxies, and removes the data
+// directories unless Config.Dir was set.
+func (c *Cluster) Close() error {
+	var errs []error
+	for i, m := range c.members {
+		c.Stop(i)
+		if m.proxy != nil {
+			errs = append(errs, m.proxy.Close())
+		}
+	}
+	if c.removeDir {
+		errs = append(errs, os.RemoveAll(c.cfg.Dir))
+	}
+	return errors.Join(errs...)
+}
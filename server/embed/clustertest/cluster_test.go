@@ -0,0 +1,81 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clustertest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+)
+
+func TestCluster(t *testing.T) {
+	clus, err := New(Config{
+		Dir: t.TempDir(),
+		Configure: func(i int, cfg *embed.Config) {
+			cfg.TickMs, cfg.ElectionMs = 10, 100
+		},
+	})
+	require.NoError(t, err)
+	defer clus.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	lead, err := clus.WaitLeader(ctx)
+	require.NoError(t, err)
+	follower, other := (lead+1)%3, (lead+2)%3
+
+	t.Log("isolating the leader")
+	clus.Isolate(lead)
+	newLead, err := clus.WaitNewLeader(ctx, lead, follower, other)
+	require.NoError(t, err)
+	cli, err := clus.MemberClient(follower)
+	require.NoError(t, err)
+	defer cli.Close()
+	_, err = cli.Put(ctx, "a", "1")
+	require.NoError(t, err)
+	clus.Heal()
+	_, err = clus.WaitLeader(ctx)
+	require.NoError(t, err)
+
+	t.Log("injecting latency")
+	clus.InjectLatency(follower, 50*time.Millisecond, 0)
+	start := time.Now()
+	_, err = cli.Get(ctx, "a")
+	require.NoError(t, err)
+	if follower != newLead {
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	}
+	clus.RemoveLatency(follower)
+
+	t.Log("killing and restarting a member")
+	clus.Kill(other)
+	assert.Nil(t, clus.Members()[other].Etcd())
+	_, err = cli.Put(ctx, "b", "2")
+	require.NoError(t, err)
+	require.NoError(t, clus.Restart(other))
+	ocli, err := clus.MemberClient(other)
+	require.NoError(t, err)
+	defer ocli.Close()
+	require.Eventually(t, func() bool {
+		resp, err := ocli.Get(ctx, "b", clientv3.WithSerializable())
+		return err == nil && len(resp.Kvs) == 1
+	}, 10*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package clustertest runs a multi-member etcd cluster in the current process,
+for applications to test their behavior against real etcd failures:
+partitioned members, slow peer links and killed or restarted members.
+
+	clus, err := clustertest.New(clustertest.Config{Size: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clus.Close()
+
+	cli, err := clus.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	lead, err := clus.WaitLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clus.Isolate(lead)
+	if _, err = clus.WaitNewLeader(ctx, lead); err != nil {
+		t.Fatal(err)
+	}
+	// ... the application fails over to the new leader
+	clus.Heal()
+
+	clus.InjectLatency(1, 100*time.Millisecond, 10*time.Millisecond)
+	// ... the application copes with a slow member
+	clus.RemoveLatency(1)
+
+	clus.Kill(2)
+	// ... the application keeps working with a quorum
+	if err := clus.Restart(2); err != nil {
+		t.Fatal(err)
+	}
+
+The members listen on free ports of the loopback interface, without TLS.
+*/
+package clustertest
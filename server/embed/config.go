@@ -19,6 +19,7 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"hash/fnv"
 	"math"
 	"net"
 	"net/http"
@@ -26,6 +27,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -46,10 +48,18 @@ import (
 	"go.etcd.io/etcd/pkg/v3/netutil"
 	"go.etcd.io/etcd/server/v3/config"
 	"go.etcd.io/etcd/server/v3/etcdserver"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/etcdhttp"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/rafthttp"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3admission"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3compactor"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3discovery"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3notify"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3rpc"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/encryption"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+	"go.etcd.io/etcd/server/v3/storage/wal"
 )
 
 const (
@@ -67,11 +77,37 @@ const (
 	DefaultGRPCKeepAliveMinTime             = 5 * time.Second
 	DefaultGRPCKeepAliveInterval            = 2 * time.Hour
 	DefaultGRPCKeepAliveTimeout             = 20 * time.Second
+	DefaultPeerKeepAliveInterval            = 30 * time.Second
 	DefaultDowngradeCheckTime               = 5 * time.Second
 	DefaultAutoCompactionMode               = "periodic"
 	DefaultAuthToken                        = "simple"
 	DefaultExperimentalCompactHashCheckTime = time.Minute
 
+	DefaultExperimentalCorruptRangeCheckKeys = 10000
+
+	DefaultExperimentalCompactionMaxSleepInterval = time.Second
+
+	DefaultExperimentalLeaseRevokeRate = 1000
+
+	DefaultExperimentalLeasePinMaxDuration = time.Hour
+
+	DefaultExperimentalWALCompression = string(wal.CompressionNone)
+
+	DefaultExperimentalAdmissionWebhookTimeout = time.Second
+
+	DefaultExperimentalPrincipalMetricsMaxPrincipals = 100
+
+	DefaultExperimentalWatchSlowWatcherBlockTimeout = 100 * time.Millisecond
+
+	DefaultExperimentalSoftDeleteRetention   = 24 * time.Hour
+	DefaultExperimentalSoftDeleteTrashPrefix = "/etcd-trash/"
+
+	DefaultExperimentalDiskProbeThreshold = 500 * time.Millisecond
+	DefaultExperimentalDiskProbeFailures  = 3
+
+	DefaultExperimentalAutoPromoteLearnerMaxLag              = 1000
+	DefaultExperimentalAutoPromoteLearnerStabilizationWindow = 30 * time.Second
+
 	DefaultDiscoveryDialTimeout      = 2 * time.Second
 	DefaultDiscoveryRequestTimeOut   = 5 * time.Second
 	DefaultDiscoveryKeepAliveTime    = 2 * time.Second
@@ -216,6 +252,24 @@ type Config struct {
 	// streams that each client can open at a time.
 	MaxConcurrentStreams uint32 `json:"max-concurrent-streams"`
 
+	// GRPCMaxRecvBytes, if not zero, is the maximum message size in bytes
+	// the server receives on the client listeners. Defaults to
+	// MaxRequestBytes plus the gRPC overhead.
+	GRPCMaxRecvBytes uint `json:"grpc-max-recv-bytes"`
+	// GRPCMaxSendBytes, if not zero, is the maximum message size in bytes
+	// the server sends on the client listeners. Defaults to math.MaxInt32.
+	GRPCMaxSendBytes uint `json:"grpc-max-send-bytes"`
+
+	// MaxConnectionsPerClientIP, if not zero, is the maximum number of
+	// concurrent client connections accepted from a single IP.
+	MaxConnectionsPerClientIP uint `json:"max-connections-per-client-ip"`
+	// MaxStreamsPerClientIP, if not zero, is the maximum number of
+	// concurrent gRPC streams that can be opened from a single client IP.
+	MaxStreamsPerClientIP uint `json:"max-streams-per-client-ip"`
+	// ClientIPLimitAllowlist are the IPs and CIDR ranges, such as the ones of
+	// proxies, that are exempted from the per client IP limits.
+	ClientIPLimitAllowlist []string `json:"client-ip-limit-allowlist"`
+
 	//revive:disable:var-naming
 	ListenPeerUrls, ListenClientUrls, ListenClientHttpUrls []url.URL
 	AdvertisePeerUrls, AdvertiseClientUrls                 []url.URL
@@ -261,6 +315,21 @@ type Config struct {
 	InitialClusterToken string `json:"initial-cluster-token"`
 	StrictReconfigCheck bool   `json:"strict-reconfig-check"`
 
+	// ExperimentalAutoPromoteLearner enables the leader to promote learners once they
+	// have stayed within ExperimentalAutoPromoteLearnerMaxLag entries of the leader for
+	// ExperimentalAutoPromoteLearnerStabilizationWindow.
+	ExperimentalAutoPromoteLearner                    bool          `json:"experimental-auto-promote-learner"`
+	ExperimentalAutoPromoteLearnerMaxLag              uint64        `json:"experimental-auto-promote-learner-max-lag"`
+	ExperimentalAutoPromoteLearnerStabilizationWindow time.Duration `json:"experimental-auto-promote-learner-stabilization-window"`
+
+	// ExperimentalLeaderPriorities maps the names of the members to their
+	// leader priority. The leader transfers the leadership to the healthy
+	// voting member with the highest priority, if higher than its own.
+	// Members not in the map have etcdserver.DefaultLeaderPriority, a
+	// priority of 0 means the member should never lead. It must be the same
+	// on all members.
+	ExperimentalLeaderPriorities map[string]int `json:"experimental-leader-priorities"`
+
 	// AutoCompactionMode is either 'periodic' or 'revision'.
 	AutoCompactionMode string `json:"auto-compaction-mode"`
 	// AutoCompactionRetention is either duration string with time unit
@@ -284,6 +353,16 @@ type Config struct {
 	// before closing a non-responsive connection. 0 to disable.
 	GRPCKeepAliveTimeout time.Duration `json:"grpc-keepalive-timeout"`
 
+	// The gRPC keepalive, stream and message size settings above only
+	// apply to the client listeners, peers talk to each other over HTTP.
+
+	// PeerKeepAliveInterval is the TCP keepalive period of the connections
+	// accepted on the peer listeners.
+	PeerKeepAliveInterval time.Duration `json:"peer-keepalive-interval"`
+	// PeerIdleTimeout is the maximum amount of time an idle peer connection
+	// is kept open. 0 to use the read timeout of the peer listeners.
+	PeerIdleTimeout time.Duration `json:"peer-idle-timeout"`
+
 	// GRPCAdditionalServerOptions is the additional server option hook
 	// for changing the default internal gRPC configuration. Note these
 	// additional configurations take precedence over the existing individual
@@ -341,6 +420,42 @@ type Config struct {
 	//	}
 	//	embed.StartEtcd(cfg)
 	ServiceRegister func(*grpc.Server) `json:"-"`
+	// GRPCUnaryInterceptors and GRPCStreamInterceptors are chained after
+	// etcd's own interceptors on the gRPC server of every client listener,
+	// so they also apply to the services added by ServiceRegister. A simple
+	// usage example:
+	//	cfg := embed.NewConfig()
+	//	cfg.GRPCUnaryInterceptors = []grpc.UnaryServerInterceptor{
+	//		otelgrpc.UnaryServerInterceptor(),
+	//	}
+	//	embed.StartEtcd(cfg)
+	GRPCUnaryInterceptors  []grpc.UnaryServerInterceptor  `json:"-"`
+	GRPCStreamInterceptors []grpc.StreamServerInterceptor `json:"-"`
+	// PeerUserHandlers and MetricsUserHandlers are the counterparts of
+	// UserHandlers for the peer and metrics listeners, which serve HTTP
+	// only. As with UserHandlers, the route paths must not conflict with
+	// etcd's.
+	PeerUserHandlers    map[string]http.Handler `json:"-"`
+	MetricsUserHandlers map[string]http.Handler `json:"-"`
+	// LivezChecks and ReadyzChecks are custom health checks run by the
+	// /livez and /readyz endpoints in addition to etcd's. The map key is the
+	// check name, which is also its sub path and the value accepted by the
+	// "exclude" query parameter. A simple usage example:
+	//	cfg := embed.NewConfig()
+	//	cfg.ReadyzChecks = map[string]etcdhttp.HealthCheck{
+	//		"cache_warm": func(ctx context.Context) error { return cache.Ready() },
+	//	}
+	//	embed.StartEtcd(cfg)
+	LivezChecks  map[string]etcdhttp.HealthCheck `json:"-"`
+	ReadyzChecks map[string]etcdhttp.HealthCheck `json:"-"`
+	// LifecycleHooks are callbacks invoked on server lifecycle events such as
+	// leadership changes, compaction and defragmentation, so that embedding
+	// applications do not need to poll the maintenance API. A simple usage
+	// example:
+	//	cfg := embed.NewConfig()
+	//	cfg.LifecycleHooks.OnLeaderElected = func() { log.Print("became leader") }
+	//	embed.StartEtcd(cfg)
+	LifecycleHooks config.LifecycleHooks `json:"-"`
 
 	AuthToken  string `json:"auth-token"`
 	BcryptCost uint   `json:"bcrypt-cost"`
@@ -352,6 +467,11 @@ type Config struct {
 	ExperimentalCorruptCheckTime        time.Duration `json:"experimental-corrupt-check-time"`
 	ExperimentalCompactHashCheckEnabled bool          `json:"experimental-compact-hash-check-enabled"`
 	ExperimentalCompactHashCheckTime    time.Duration `json:"experimental-compact-hash-check-time"`
+	// ExperimentalCorruptRangeCheckTime is the duration of time between the leader's comparisons of
+	// ranged hashes with the followers. A member whose hash diverges is quarantined. 0 disables them.
+	ExperimentalCorruptRangeCheckTime time.Duration `json:"experimental-corrupt-range-check-time"`
+	// ExperimentalCorruptRangeCheckKeys is the number of keys hashed by each ranged hash comparison.
+	ExperimentalCorruptRangeCheckKeys int64 `json:"experimental-corrupt-range-check-keys"`
 
 	// ExperimentalEnableLeaseCheckpoint enables leader to send regular checkpoints to other members to prevent reset of remaining TTL on leader change.
 	ExperimentalEnableLeaseCheckpoint bool `json:"experimental-enable-lease-checkpoint"`
@@ -360,9 +480,29 @@ type Config struct {
 	// Deprecated in v3.6.
 	// TODO: Delete in v3.7
 	ExperimentalEnableLeaseCheckpointPersist bool `json:"experimental-enable-lease-checkpoint-persist"`
-	ExperimentalCompactionBatchLimit         int  `json:"experimental-compaction-batch-limit"`
+	// ExperimentalLeaseRevokeRate is the maximum number of expired leases
+	// revoked per second by the leader. The revocations are proposed in
+	// batches of half the rate every 500ms, paced over the batch interval,
+	// and a new leader extends leases which would otherwise expire faster.
+	ExperimentalLeaseRevokeRate      int `json:"experimental-lease-revoke-rate"`
+	ExperimentalCompactionBatchLimit int `json:"experimental-compaction-batch-limit"`
+	// ExperimentalLeasePinMaxDuration bounds how long a lease pinning a
+	// revision against compaction can be kept alive, so that a client
+	// keeping it alive forever can't prevent compaction forever. It is
+	// counted from the grant of the lease, or from the restart of the member.
+	ExperimentalLeasePinMaxDuration time.Duration `json:"experimental-lease-pin-max-duration"`
 	// ExperimentalCompactionSleepInterval is the sleep interval between every etcd compaction loop.
-	ExperimentalCompactionSleepInterval     time.Duration `json:"experimental-compaction-sleep-interval"`
+	ExperimentalCompactionSleepInterval time.Duration `json:"experimental-compaction-sleep-interval"`
+	// ExperimentalCompactionPauseTarget, if set, is the target time each
+	// compaction batch holds up writes. The compaction batch size and sleep
+	// interval are adapted to the observed pauses, between a batch of 10
+	// revisions and ExperimentalCompactionBatchLimit, and between
+	// ExperimentalCompactionSleepInterval and
+	// ExperimentalCompactionMaxSleepInterval.
+	ExperimentalCompactionPauseTarget time.Duration `json:"experimental-compaction-pause-target"`
+	// ExperimentalCompactionMaxSleepInterval is the maximum sleep interval
+	// between compaction batches when pacing them.
+	ExperimentalCompactionMaxSleepInterval  time.Duration `json:"experimental-compaction-max-sleep-interval"`
 	ExperimentalWatchProgressNotifyInterval time.Duration `json:"experimental-watch-progress-notify-interval"`
 	// ExperimentalWarningApplyDuration is the time duration after which a warning is generated if applying request
 	// takes more time than this value.
@@ -375,6 +515,10 @@ type Config struct {
 	WarningUnaryRequestDuration time.Duration `json:"warning-unary-request-duration"`
 	// ExperimentalWarningUnaryRequestDuration is deprecated, please use WarningUnaryRequestDuration instead.
 	ExperimentalWarningUnaryRequestDuration time.Duration `json:"experimental-warning-unary-request-duration"`
+	// SlowRequestThreshold, if not zero, is the duration above which unary
+	// requests are logged with their method, keys, principal, the time spent
+	// waiting for raft and executing, and the raft term.
+	SlowRequestThreshold time.Duration `json:"slow-request-threshold"`
 	// ExperimentalMaxLearners sets a limit to the number of learner members that can exist in the cluster membership.
 	ExperimentalMaxLearners int `json:"experimental-max-learners"`
 
@@ -402,6 +546,12 @@ type Config struct {
 	// ExperimentalDistributedTracingSamplingRatePerMillion is the number of samples to collect per million spans.
 	// Defaults to 0.
 	ExperimentalDistributedTracingSamplingRatePerMillion int `json:"experimental-distributed-tracing-sampling-rate"`
+	// ExperimentalDistributedTracingSlowRequestThreshold, if not zero, is the
+	// duration above which the propose, commit wait and apply spans of a
+	// request are emitted, as a new trace linked to its gRPC span, although
+	// the gRPC span was not sampled.
+	// Can only be used if ExperimentalEnableDistributedTracing is true.
+	ExperimentalDistributedTracingSlowRequestThreshold time.Duration `json:"experimental-distributed-tracing-slow-request-threshold"`
 
 	// Logger is logger options: currently only supports "zap".
 	// "capnslog" is removed in v3.5.
@@ -453,6 +603,228 @@ type Config struct {
 	// ExperimentalStopGRPCServiceOnDefrag enables etcd gRPC service to stop serving client requests on defragmentation.
 	ExperimentalStopGRPCServiceOnDefrag bool `json:"experimental-stop-grpc-service-on-defrag"`
 
+	// ExperimentalTrustedProxyCNs is the list of client certificate common names
+	// of proxies that may forward the common name of their own clients. The
+	// forwarded common name is then used for authorization instead of the
+	// proxy's common name.
+	ExperimentalTrustedProxyCNs []string `json:"experimental-trusted-proxy-cns"`
+
+	// ExperimentalLearnerReadReplica marks the member, when it is a learner, as
+	// a permanent read-only replica that serves serializable reads and watches
+	// and is never promoted to a voting member.
+	ExperimentalLearnerReadReplica bool `json:"experimental-learner-read-replica"`
+
+	// ExperimentalWitness makes the member a witness, which votes in the raft
+	// quorum but neither stores the key-value store nor serves client
+	// requests, and gives away the leadership whenever it is elected. It's
+	// published in the membership, and the member refuses to start if it
+	// does not match the published one.
+	ExperimentalWitness bool `json:"experimental-witness"`
+
+	// ExperimentalSnapshotSendRateBytes limits the bandwidth, in bytes per
+	// second, used to send snapshots to peers. Zero means no limit.
+	ExperimentalSnapshotSendRateBytes int64 `json:"experimental-snapshot-send-rate-bytes"`
+	// ExperimentalSnapshotCompression enables gzip compression of snapshots
+	// sent to peers. All members must support receiving compressed snapshots.
+	ExperimentalSnapshotCompression bool `json:"experimental-snapshot-compression"`
+	// ExperimentalDifferentialSnapshot enables sending followers that are
+	// only slightly behind the key revisions they miss instead of the whole
+	// database, falling back to full snapshots when not feasible.
+	ExperimentalDifferentialSnapshot bool `json:"experimental-differential-snapshot"`
+
+	// ExperimentalWALCompression is the codec used to compress large WAL
+	// entries, either 'none' or 'snappy'. A member that wrote compressed
+	// entries cannot be downgraded below v3.6.
+	ExperimentalWALCompression string `json:"experimental-wal-compression"`
+
+	// ExperimentalOnlineDefrag copies the backend for defragmentation without
+	// blocking reads and writes. Writes are only blocked while the writes
+	// made during the copy are applied to it.
+	ExperimentalOnlineDefrag bool `json:"experimental-online-defrag"`
+
+	// ExperimentalQuotaBackendWarningRatios are the ratios of the backend
+	// quota, between 0 and 1, at which a warning is logged when the backend
+	// size reaches them, ahead of the NOSPACE alarm.
+	ExperimentalQuotaBackendWarningRatios []float64 `json:"experimental-quota-backend-warning-ratios"`
+	// ExperimentalAutoRecoverNoSpace makes the leader recover from NOSPACE
+	// alarms when all members are connected: it compacts the keyspace to the
+	// current revision, defragments the members one at a time and disarms the
+	// alarms if all members are then below the quota. All members must enable
+	// it to be defragmented by the leader, along with --peer-client-cert-auth
+	// so that only peers can request the defragmentation.
+	ExperimentalAutoRecoverNoSpace bool `json:"experimental-auto-recover-nospace"`
+
+	// ExperimentalBackendEngine is the storage engine of the backend, either
+	// 'bbolt' or 'log'. An existing backend written by another engine is
+	// converted on startup. If empty, the existing backend's engine is kept.
+	ExperimentalBackendEngine string `json:"experimental-backend-engine"`
+
+	// ExperimentalEncryptionKEKFile is the path to a file holding the base64
+	// encoded 32-byte key used to wrap the keys that encrypt key-value records
+	// in the backend. Encryption is disabled if empty and EncryptionKMS is nil.
+	ExperimentalEncryptionKEKFile string `json:"experimental-encryption-kek-file"`
+	// EncryptionKMS, if set, wraps the keys that encrypt key-value records in
+	// the backend. It takes precedence over ExperimentalEncryptionKEKFile and
+	// allows plugging in an external key management service.
+	EncryptionKMS encryption.KMS `json:"-"`
+
+	// ExperimentalChangeWebhooks are the HTTP endpoints, each given as
+	// '<prefix>=<url>', to which the changes to the keys under the prefix are
+	// POSTed in JSON batches by the leader. A batch is retried until it's
+	// accepted, and sent again after a restart if it wasn't. A new leader
+	// sends the changes from the last revision it delivered itself, so a
+	// change may be received more than once.
+	ExperimentalChangeWebhooks []string `json:"experimental-change-webhooks"`
+	// ChangeNotifyTargets receive the changes to the keys under their
+	// prefixes, like ExperimentalChangeWebhooks, through any sink such as a
+	// message bus producer.
+	ChangeNotifyTargets []v3notify.Target `json:"-"`
+
+	// ExperimentalAdmissionWebhookURL is the http or https URL of a gRPC
+	// service, implemented with v3admission.RegisterWebhookServer, which
+	// admits or rejects the Put, DeleteRange and Txn requests before they are
+	// proposed to raft. Requests are rejected while it is unavailable. The
+	// path of the URL, if any, prefixes the path of the gRPC method.
+	ExperimentalAdmissionWebhookURL string `json:"experimental-admission-webhook-url"`
+	// ExperimentalAdmissionWebhookCAFile is the CA certificate file verifying
+	// the certificate of an https admission webhook, instead of the system
+	// roots.
+	ExperimentalAdmissionWebhookCAFile string `json:"experimental-admission-webhook-ca-file"`
+	// ExperimentalAdmissionWebhookTimeout is the timeout of a call to the
+	// admission webhook.
+	ExperimentalAdmissionWebhookTimeout time.Duration `json:"experimental-admission-webhook-timeout"`
+	// AdmissionControllers admit or reject the Put, DeleteRange and Txn
+	// requests before they are proposed to raft, and before the admission
+	// webhook is called. A simple usage example:
+	//	cfg := embed.NewConfig()
+	//	rules, _ := v3admission.Rules(v3admission.Rule{
+	//		Pattern:  "/config/*",
+	//		Validate: v3admission.JSONObject("name"),
+	//	})
+	//	cfg.AdmissionControllers = []v3admission.Controller{rules}
+	//	embed.StartEtcd(cfg)
+	AdmissionControllers []v3admission.Controller `json:"-"`
+
+	// ExperimentalKeyspaceMetricsPrefixes are the key prefixes for which the
+	// number of keys, the size of their values and the number of writes are
+	// exported as metrics. They are updated on apply, and computed once from
+	// the keyspace on startup.
+	ExperimentalKeyspaceMetricsPrefixes []string `json:"experimental-keyspace-metrics-prefixes"`
+
+	// ExperimentalPrincipalMetrics enables client request, error and byte
+	// metrics labeled by the principal of the request: the authenticated user
+	// or, without a token, the client certificate common name.
+	ExperimentalPrincipalMetrics bool `json:"experimental-principal-metrics"`
+	// ExperimentalPrincipalMetricsMaxPrincipals caps the number of principals
+	// given their own label, to bound the cardinality of the metrics. The
+	// principals seen once the cap is reached are labeled "other".
+	ExperimentalPrincipalMetricsMaxPrincipals int `json:"experimental-principal-metrics-max-principals"`
+	// ExperimentalPrincipalMetricsAllowlist, if not empty, is the list of the
+	// only principals given their own label. The others are labeled "other".
+	ExperimentalPrincipalMetricsAllowlist []string `json:"experimental-principal-metrics-allowlist"`
+
+	// ExperimentalInflightRequests enables tracking the unary requests being
+	// served, with their state, to list them as JSON at /debug/inflight on
+	// the metrics URLs, which must be set. The listing reveals keys and user
+	// names, so it is not served on the client URLs.
+	ExperimentalInflightRequests bool `json:"experimental-inflight-requests"`
+
+	// ExperimentalRangeTombstoneThreshold, if not zero, is the number of keys
+	// above which a DeleteRange writes a single range tombstone to the
+	// backend instead of a tombstone per key, so that deleting a large prefix
+	// does not stall the apply. The deleted keys are reclaimed by the next
+	// compaction. The threshold is carried by the deletions this member
+	// proposes, once the cluster version is 3.6, so that all members record
+	// the same range tombstones. A downgrade is refused until the range
+	// tombstones are compacted.
+	ExperimentalRangeTombstoneThreshold int `json:"experimental-range-tombstone-threshold"`
+
+	// ExperimentalWatchSlowWatcherPolicy is what is done with a slow watcher,
+	// one that could not keep up with the events, once it lags more than
+	// ExperimentalWatchSlowWatcherMaxLag revisions behind: "cancel" cancels
+	// it as if the revisions were compacted, "resync" drops the events it
+	// missed and tells it to resynchronize from the current revision if it
+	// was created with allow_resync, and cancels it otherwise, and "block"
+	// first blocks the writes for up to
+	// ExperimentalWatchSlowWatcherBlockTimeout on it, then cancels it. The
+	// blocked write holds the watchable store locked, delaying all the other
+	// writes and watchers as well. By default the slow watchers catch up for
+	// as long as they need.
+	ExperimentalWatchSlowWatcherPolicy string `json:"experimental-watch-slow-watcher-policy"`
+	// ExperimentalWatchSlowWatcherMaxLag is the number of revisions a slow
+	// watcher may lag behind before the policy applies.
+	ExperimentalWatchSlowWatcherMaxLag int64 `json:"experimental-watch-slow-watcher-max-lag"`
+	// ExperimentalWatchSlowWatcherBlockTimeout bounds how long a write blocks
+	// on the slow watchers under the "block" policy.
+	ExperimentalWatchSlowWatcherBlockTimeout time.Duration `json:"experimental-watch-slow-watcher-block-timeout"`
+
+	// ExperimentalHotKeysSampleRate is the fraction of the requests whose
+	// keys are sampled to track the keys and prefixes most frequently read
+	// and written through the member, as reported by the HotKeys maintenance
+	// RPC. 0, the default, disables the tracking.
+	ExperimentalHotKeysSampleRate float64 `json:"experimental-hot-keys-sample-rate"`
+
+	// ExperimentalSoftDeleteThreshold, if not zero, is the number of keys
+	// above which a DeleteRange, alone or in a transaction, moves the keys to
+	// the trash instead of deleting them, giving an undo window for mistaken
+	// deletions. The trashed keys can be restored with
+	// "etcdctl restore-deleted". The soft delete configuration of the member
+	// proposing a deletion applies on all the members, once the cluster
+	// runs 3.6.
+	ExperimentalSoftDeleteThreshold int `json:"experimental-soft-delete-threshold"`
+	// ExperimentalSoftDeletePrefixes are the key prefixes whose keys are
+	// moved to the trash whenever they are deleted, whatever their number.
+	ExperimentalSoftDeletePrefixes []string `json:"experimental-soft-delete-prefixes"`
+	// ExperimentalSoftDeleteRetention is how long the deleted keys are kept
+	// in the trash before the leader purges them.
+	ExperimentalSoftDeleteRetention time.Duration `json:"experimental-soft-delete-retention"`
+	// ExperimentalSoftDeleteTrashPrefix is the prefix of the keys in the
+	// trash, which are never moved to the trash themselves. Each deleted key
+	// is stored under '<prefix><revision of the deletion>/<key>'.
+	ExperimentalSoftDeleteTrashPrefix string `json:"experimental-soft-delete-trash-prefix"`
+
+	// ExperimentalProtectedPrefixes maps the protected key prefixes, e.g.
+	// "/registry/secrets/", to the only roles allowed to modify their keys,
+	// whatever the permissions granted to the other roles, including root.
+	// The roles are only checked when authentication is enabled. Deletions
+	// of ranges overlapping a protected prefix and wider than it are always
+	// rejected. The keys attached to a lease are still deleted when it is
+	// revoked. The requests are checked by the member they are sent to
+	// before being proposed, so it should be the same on all members.
+	ExperimentalProtectedPrefixes map[string][]string `json:"experimental-protected-prefixes"`
+
+	// ExperimentalDiskProbeInterval, if not zero, is how often the member
+	// probes its disk by writing and fsyncing a small file in the WAL
+	// directory, exporting the latency as a metric.
+	ExperimentalDiskProbeInterval time.Duration `json:"experimental-disk-probe-interval"`
+	// ExperimentalDiskProbeThreshold is the probe latency above which the
+	// disk is considered slow.
+	ExperimentalDiskProbeThreshold time.Duration `json:"experimental-disk-probe-threshold"`
+	// ExperimentalDiskProbeFailures is the number of consecutive slow probes
+	// after which the member raises a SLOWDISK alarm for itself, failing the
+	// /health checks. The member clears the alarm after as many consecutive
+	// fast probes.
+	ExperimentalDiskProbeFailures int `json:"experimental-disk-probe-failures"`
+	// ExperimentalDiskProbeSelfFence drains the member while its SLOWDISK
+	// alarm is raised: it transfers its leadership away and fails its
+	// readiness checks, so that a slow disk does not slow down the cluster.
+	ExperimentalDiskProbeSelfFence bool `json:"experimental-disk-probe-self-fence"`
+
+	// ExperimentalMaintenanceWindows is the semicolon-separated list of the
+	// maintenance windows of the member, each the cron schedule in UTC of its
+	// start followed by its duration, e.g. "0 2 * * 6 4h" for four hours
+	// from 02:00 every Saturday. If set, the auto-compaction only runs in the
+	// windows and the snapshots are deferred outside of them, unless the
+	// backend approaches its quota or the raft log grows too long. Members
+	// can be given distinct windows so they are not all maintained at once.
+	ExperimentalMaintenanceWindows string `json:"experimental-maintenance-windows"`
+	// ExperimentalMaintenanceDefragThresholdMegabytes, if not zero, makes the
+	// leader defragment, once per its maintenance windows, the backend of the
+	// members that would free at least this many megabytes, one at a time and
+	// itself last. The members must enable it as well to be defragmented by
+	// the leader, which requires verified peer client certificates.
+	ExperimentalMaintenanceDefragThresholdMegabytes uint `json:"experimental-maintenance-defrag-threshold-megabytes"`
+
 	// V2Deprecation describes phase of API & Storage V2 support
 	V2Deprecation config.V2DeprecationEnum `json:"v2-deprecation"`
 }
@@ -514,6 +886,8 @@ func NewConfig() *Config {
 		GRPCKeepAliveInterval: DefaultGRPCKeepAliveInterval,
 		GRPCKeepAliveTimeout:  DefaultGRPCKeepAliveTimeout,
 
+		PeerKeepAliveInterval: DefaultPeerKeepAliveInterval,
+
 		SocketOpts: transport.SocketOpts{
 			ReusePort:    false,
 			ReuseAddress: false,
@@ -552,14 +926,35 @@ func NewConfig() *Config {
 		LogRotationConfigJSON: DefaultLogRotationConfig,
 		EnableGRPCGateway:     true,
 
+		ExperimentalAutoPromoteLearnerMaxLag:              DefaultExperimentalAutoPromoteLearnerMaxLag,
+		ExperimentalAutoPromoteLearnerStabilizationWindow: DefaultExperimentalAutoPromoteLearnerStabilizationWindow,
+
 		ExperimentalDowngradeCheckTime:           DefaultDowngradeCheckTime,
+		ExperimentalCompactionMaxSleepInterval:   DefaultExperimentalCompactionMaxSleepInterval,
+		ExperimentalLeaseRevokeRate:              DefaultExperimentalLeaseRevokeRate,
+		ExperimentalLeasePinMaxDuration:          DefaultExperimentalLeasePinMaxDuration,
 		ExperimentalMemoryMlock:                  false,
 		ExperimentalTxnModeWriteWithSharedBuffer: true,
 		ExperimentalStopGRPCServiceOnDefrag:      false,
 		ExperimentalMaxLearners:                  membership.DefaultMaxLearners,
 
+		ExperimentalWALCompression: DefaultExperimentalWALCompression,
+
 		ExperimentalCompactHashCheckEnabled: false,
 		ExperimentalCompactHashCheckTime:    DefaultExperimentalCompactHashCheckTime,
+		ExperimentalCorruptRangeCheckKeys:   DefaultExperimentalCorruptRangeCheckKeys,
+
+		ExperimentalAdmissionWebhookTimeout: DefaultExperimentalAdmissionWebhookTimeout,
+
+		ExperimentalPrincipalMetricsMaxPrincipals: DefaultExperimentalPrincipalMetricsMaxPrincipals,
+
+		ExperimentalWatchSlowWatcherBlockTimeout: DefaultExperimentalWatchSlowWatcherBlockTimeout,
+
+		ExperimentalSoftDeleteRetention:   DefaultExperimentalSoftDeleteRetention,
+		ExperimentalSoftDeleteTrashPrefix: DefaultExperimentalSoftDeleteTrashPrefix,
+
+		ExperimentalDiskProbeThreshold: DefaultExperimentalDiskProbeThreshold,
+		ExperimentalDiskProbeFailures:  DefaultExperimentalDiskProbeFailures,
 
 		V2Deprecation: config.V2DeprDefault,
 
@@ -619,10 +1014,17 @@ func (cfg *Config) AddFlags(fs *flag.FlagSet) {
 	fs.DurationVar(&cfg.GRPCKeepAliveMinTime, "grpc-keepalive-min-time", cfg.GRPCKeepAliveMinTime, "Minimum interval duration that a client should wait before pinging server.")
 	fs.DurationVar(&cfg.GRPCKeepAliveInterval, "grpc-keepalive-interval", cfg.GRPCKeepAliveInterval, "Frequency duration of server-to-client ping to check if a connection is alive (0 to disable).")
 	fs.DurationVar(&cfg.GRPCKeepAliveTimeout, "grpc-keepalive-timeout", cfg.GRPCKeepAliveTimeout, "Additional duration of wait before closing a non-responsive connection (0 to disable).")
+	fs.UintVar(&cfg.GRPCMaxRecvBytes, "grpc-max-recv-bytes", cfg.GRPCMaxRecvBytes, "Maximum gRPC message size in bytes the server receives from clients (0 defaults to --max-request-bytes plus the gRPC overhead).")
+	fs.UintVar(&cfg.GRPCMaxSendBytes, "grpc-max-send-bytes", cfg.GRPCMaxSendBytes, "Maximum gRPC message size in bytes the server sends to clients (0 defaults to math.MaxInt32).")
+	fs.DurationVar(&cfg.PeerKeepAliveInterval, "peer-keepalive-interval", cfg.PeerKeepAliveInterval, "TCP keepalive period of the peer connections.")
+	fs.DurationVar(&cfg.PeerIdleTimeout, "peer-idle-timeout", cfg.PeerIdleTimeout, "Maximum amount of time an idle peer connection is kept open (0 to use the peer read timeout).")
 	fs.BoolVar(&cfg.SocketOpts.ReusePort, "socket-reuse-port", cfg.SocketOpts.ReusePort, "Enable to set socket option SO_REUSEPORT on listeners allowing rebinding of a port already in use.")
 	fs.BoolVar(&cfg.SocketOpts.ReuseAddress, "socket-reuse-address", cfg.SocketOpts.ReuseAddress, "Enable to set socket option SO_REUSEADDR on listeners allowing binding to an address in `TIME_WAIT` state.")
 
 	fs.Var(flags.NewUint32Value(cfg.MaxConcurrentStreams), "max-concurrent-streams", "Maximum concurrent streams that each client can open at a time.")
+	fs.UintVar(&cfg.MaxConnectionsPerClientIP, "max-connections-per-client-ip", cfg.MaxConnectionsPerClientIP, "Maximum concurrent client connections accepted from a single IP. 0 means unlimited.")
+	fs.UintVar(&cfg.MaxStreamsPerClientIP, "max-streams-per-client-ip", cfg.MaxStreamsPerClientIP, "Maximum concurrent gRPC streams that can be opened from a single client IP. 0 means unlimited.")
+	fs.Var(flags.NewStringsValue(""), "client-ip-limit-allowlist", "Comma-separated list of IPs and CIDR ranges, e.g. of proxies, exempted from the per client IP connection and stream limits.")
 
 	// raft connection timeouts
 	fs.DurationVar(&rafthttp.ConnReadTimeout, "raft-read-timeout", rafthttp.DefaultConnReadTimeout, "Read timeout set on each rafthttp connection")
@@ -668,6 +1070,10 @@ func (cfg *Config) AddFlags(fs *flag.FlagSet) {
 	fs.StringVar(&cfg.InitialCluster, "initial-cluster", cfg.InitialCluster, "Initial cluster configuration for bootstrapping.")
 	fs.StringVar(&cfg.InitialClusterToken, "initial-cluster-token", cfg.InitialClusterToken, "Initial cluster token for the etcd cluster during bootstrap.")
 	fs.BoolVar(&cfg.StrictReconfigCheck, "strict-reconfig-check", cfg.StrictReconfigCheck, "Reject reconfiguration requests that would cause quorum loss.")
+	fs.BoolVar(&cfg.ExperimentalAutoPromoteLearner, "experimental-auto-promote-learner", cfg.ExperimentalAutoPromoteLearner, "Automatically promote learners once they have stayed caught up with the leader for the stabilization window.")
+	fs.Uint64Var(&cfg.ExperimentalAutoPromoteLearnerMaxLag, "experimental-auto-promote-learner-max-lag", cfg.ExperimentalAutoPromoteLearnerMaxLag, "Maximum number of raft entries a learner may lag behind the leader to be considered caught up.")
+	fs.DurationVar(&cfg.ExperimentalAutoPromoteLearnerStabilizationWindow, "experimental-auto-promote-learner-stabilization-window", cfg.ExperimentalAutoPromoteLearnerStabilizationWindow, "Duration a learner must continuously stay caught up before it is automatically promoted.")
+	fs.Var(flags.NewStringsValue(""), "experimental-leader-priorities", "Comma-separated list of '<member name>=<priority>' leader priorities. The leader transfers the leadership to the healthy member with the highest priority. Unlisted members have priority 1, 0 means never lead.")
 
 	fs.BoolVar(&cfg.PreVote, "pre-vote", cfg.PreVote, "Enable the raft Pre-Vote algorithm to prevent disruption when a node that has been partitioned away rejoins the cluster.")
 
@@ -692,6 +1098,7 @@ func (cfg *Config) AddFlags(fs *flag.FlagSet) {
 	fs.StringVar(&cfg.PeerTLSInfo.CRLFile, "peer-crl-file", "", "Path to the peer certificate revocation list file.")
 	fs.Var(flags.NewStringsValue(""), "peer-cert-allowed-cn", "Comma-separated list of allowed CNs for inter-peer TLS authentication.")
 	fs.Var(flags.NewStringsValue(""), "peer-cert-allowed-hostname", "Comma-separated list of allowed SAN hostnames for inter-peer TLS authentication.")
+	fs.Var(flags.NewStringsValue(""), "experimental-trusted-proxy-cn", "Comma-separated list of client certificate CNs of proxies allowed to forward the CN of their clients.")
 	fs.Var(flags.NewStringsValue(""), "cipher-suites", "Comma-separated list of supported TLS cipher suites between client/server and peers (empty will be auto-populated by Go).")
 	fs.BoolVar(&cfg.PeerTLSInfo.SkipClientSANVerify, "experimental-peer-skip-client-san-verification", false, "Skip verification of SAN field in client certificate for peer connections.")
 	fs.StringVar(&cfg.TlsMinVersion, "tls-min-version", string(tlsutil.TLSVersion12), "Minimum TLS version supported by etcd. Possible values: TLS1.2, TLS1.3.")
@@ -727,6 +1134,7 @@ func (cfg *Config) AddFlags(fs *flag.FlagSet) {
 	fs.StringVar(&cfg.ExperimentalDistributedTracingServiceName, "experimental-distributed-tracing-service-name", ExperimentalDistributedTracingServiceName, "Configures service name for distributed tracing to be used to define service name for OpenTelemetry Tracing (if enabled with experimental-enable-distributed-tracing flag). 'etcd' is the default service name. Use the same service name for all instances of etcd.")
 	fs.StringVar(&cfg.ExperimentalDistributedTracingServiceInstanceID, "experimental-distributed-tracing-instance-id", "", "Configures service instance ID for distributed tracing to be used to define service instance ID key for OpenTelemetry Tracing (if enabled with experimental-enable-distributed-tracing flag). There is no default value set. This ID must be unique per etcd instance.")
 	fs.IntVar(&cfg.ExperimentalDistributedTracingSamplingRatePerMillion, "experimental-distributed-tracing-sampling-rate", 0, "Number of samples to collect per million spans for OpenTelemetry Tracing (if enabled with experimental-enable-distributed-tracing flag).")
+	fs.DurationVar(&cfg.ExperimentalDistributedTracingSlowRequestThreshold, "experimental-distributed-tracing-slow-request-threshold", 0, "Duration above which the propose, commit wait and apply spans of a request are traced although its gRPC span was not sampled. 0 disables it.")
 
 	// auth
 	fs.StringVar(&cfg.AuthToken, "auth-token", cfg.AuthToken, "Specify auth token specific options.")
@@ -741,21 +1149,64 @@ func (cfg *Config) AddFlags(fs *flag.FlagSet) {
 	fs.DurationVar(&cfg.ExperimentalCorruptCheckTime, "experimental-corrupt-check-time", cfg.ExperimentalCorruptCheckTime, "Duration of time between cluster corruption check passes.")
 	fs.BoolVar(&cfg.ExperimentalCompactHashCheckEnabled, "experimental-compact-hash-check-enabled", cfg.ExperimentalCompactHashCheckEnabled, "Enable leader to periodically check followers compaction hashes.")
 	fs.DurationVar(&cfg.ExperimentalCompactHashCheckTime, "experimental-compact-hash-check-time", cfg.ExperimentalCompactHashCheckTime, "Duration of time between leader checks followers compaction hashes.")
+	fs.DurationVar(&cfg.ExperimentalCorruptRangeCheckTime, "experimental-corrupt-range-check-time", cfg.ExperimentalCorruptRangeCheckTime, "Duration of time between leader compares ranged hashes with followers and quarantines diverging members. 0 disables it.")
+	fs.Int64Var(&cfg.ExperimentalCorruptRangeCheckKeys, "experimental-corrupt-range-check-keys", cfg.ExperimentalCorruptRangeCheckKeys, "Number of keys hashed by each ranged hash comparison.")
 
 	fs.BoolVar(&cfg.ExperimentalEnableLeaseCheckpoint, "experimental-enable-lease-checkpoint", false, "Enable leader to send regular checkpoints to other members to prevent reset of remaining TTL on leader change.")
 	// TODO: delete in v3.7
 	fs.BoolVar(&cfg.ExperimentalEnableLeaseCheckpointPersist, "experimental-enable-lease-checkpoint-persist", false, "Enable persisting remainingTTL to prevent indefinite auto-renewal of long lived leases. Always enabled in v3.6. Should be used to ensure smooth upgrade from v3.5 clusters with this feature enabled. Requires experimental-enable-lease-checkpoint to be enabled.")
+	fs.IntVar(&cfg.ExperimentalLeaseRevokeRate, "experimental-lease-revoke-rate", cfg.ExperimentalLeaseRevokeRate, "Maximum number of expired leases revoked per second by the leader.")
+	fs.DurationVar(&cfg.ExperimentalLeasePinMaxDuration, "experimental-lease-pin-max-duration", cfg.ExperimentalLeasePinMaxDuration, "Maximum duration a lease pinning a revision against compaction can be kept alive.")
 	fs.IntVar(&cfg.ExperimentalCompactionBatchLimit, "experimental-compaction-batch-limit", cfg.ExperimentalCompactionBatchLimit, "Sets the maximum revisions deleted in each compaction batch.")
 	fs.DurationVar(&cfg.ExperimentalCompactionSleepInterval, "experimental-compaction-sleep-interval", cfg.ExperimentalCompactionSleepInterval, "Sets the sleep interval between each compaction batch.")
+	fs.DurationVar(&cfg.ExperimentalCompactionPauseTarget, "experimental-compaction-pause-target", cfg.ExperimentalCompactionPauseTarget, "Target time each compaction batch holds up writes. If set, the compaction batch size and sleep interval adapt to the observed pauses.")
+	fs.DurationVar(&cfg.ExperimentalCompactionMaxSleepInterval, "experimental-compaction-max-sleep-interval", cfg.ExperimentalCompactionMaxSleepInterval, "Maximum sleep interval between compaction batches when pacing them to the compaction pause target.")
 	fs.DurationVar(&cfg.ExperimentalWatchProgressNotifyInterval, "experimental-watch-progress-notify-interval", cfg.ExperimentalWatchProgressNotifyInterval, "Duration of periodic watch progress notifications.")
 	fs.DurationVar(&cfg.ExperimentalDowngradeCheckTime, "experimental-downgrade-check-time", cfg.ExperimentalDowngradeCheckTime, "Duration of time between two downgrade status checks.")
 	fs.DurationVar(&cfg.ExperimentalWarningApplyDuration, "experimental-warning-apply-duration", cfg.ExperimentalWarningApplyDuration, "Time duration after which a warning is generated if request takes more time.")
 	fs.DurationVar(&cfg.WarningUnaryRequestDuration, "warning-unary-request-duration", cfg.WarningUnaryRequestDuration, "Time duration after which a warning is generated if a unary request takes more time.")
+	fs.DurationVar(&cfg.SlowRequestThreshold, "slow-request-threshold", cfg.SlowRequestThreshold, "Time duration above which unary requests are logged with their details and where the time was spent. 0 disables it.")
 	fs.DurationVar(&cfg.ExperimentalWarningUnaryRequestDuration, "experimental-warning-unary-request-duration", cfg.ExperimentalWarningUnaryRequestDuration, "Time duration after which a warning is generated if a unary request takes more time. It's deprecated, and will be decommissioned in v3.7. Use --warning-unary-request-duration instead.")
 	fs.BoolVar(&cfg.ExperimentalMemoryMlock, "experimental-memory-mlock", cfg.ExperimentalMemoryMlock, "Enable to enforce etcd pages (in particular bbolt) to stay in RAM.")
 	fs.BoolVar(&cfg.ExperimentalTxnModeWriteWithSharedBuffer, "experimental-txn-mode-write-with-shared-buffer", true, "Enable the write transaction to use a shared buffer in its readonly check operations.")
 	fs.BoolVar(&cfg.ExperimentalStopGRPCServiceOnDefrag, "experimental-stop-grpc-service-on-defrag", cfg.ExperimentalStopGRPCServiceOnDefrag, "Enable etcd gRPC service to stop serving client requests on defragmentation.")
 	fs.UintVar(&cfg.ExperimentalBootstrapDefragThresholdMegabytes, "experimental-bootstrap-defrag-threshold-megabytes", 0, "Enable the defrag during etcd server bootstrap on condition that it will free at least the provided threshold of disk space. Needs to be set to non-zero value to take effect.")
+	fs.BoolVar(&cfg.ExperimentalLearnerReadReplica, "experimental-learner-read-replica", cfg.ExperimentalLearnerReadReplica, "Serve serializable reads and watches as a permanent read-only replica while the member is a learner.")
+	fs.BoolVar(&cfg.ExperimentalWitness, "experimental-witness", cfg.ExperimentalWitness, "Run the member as a witness that votes in the raft quorum but neither stores the key-value store nor serves client requests.")
+	fs.Int64Var(&cfg.ExperimentalSnapshotSendRateBytes, "experimental-snapshot-send-rate-bytes", cfg.ExperimentalSnapshotSendRateBytes, "Maximum bandwidth in bytes per second used to send snapshots to peers. 0 means no limit.")
+	fs.BoolVar(&cfg.ExperimentalSnapshotCompression, "experimental-snapshot-compression", cfg.ExperimentalSnapshotCompression, "Enable gzip compression of snapshots sent to peers. All members must support receiving compressed snapshots.")
+	fs.BoolVar(&cfg.ExperimentalDifferentialSnapshot, "experimental-differential-snapshot", cfg.ExperimentalDifferentialSnapshot, "Send followers that are only slightly behind the key revisions they miss instead of a full database snapshot, when feasible.")
+	fs.BoolVar(&cfg.ExperimentalOnlineDefrag, "experimental-online-defrag", cfg.ExperimentalOnlineDefrag, "Defragment the backend without blocking writes, except while applying the writes made during the copy.")
+	fs.Var(flags.NewStringsValue(""), "experimental-quota-backend-warning-ratios", "Comma-separated list of ratios of the backend quota, between 0 and 1, at which to log a warning when the backend size reaches them.")
+	fs.BoolVar(&cfg.ExperimentalAutoRecoverNoSpace, "experimental-auto-recover-nospace", cfg.ExperimentalAutoRecoverNoSpace, "Enable the leader to recover from NOSPACE alarms by compacting to the current revision, defragmenting members one at a time and disarming the alarms. Requires --peer-client-cert-auth.")
+	fs.StringVar(&cfg.ExperimentalBackendEngine, "experimental-backend-engine", cfg.ExperimentalBackendEngine, "Storage engine of the backend: 'bbolt' or 'log'. An existing backend written by another engine is converted on startup. If empty, the existing backend's engine is kept.")
+	fs.Var(flags.NewStringsValue(""), "experimental-change-webhooks", "Comma-separated list of '<prefix>=<url>' HTTP endpoints to POST the changes to the keys under the prefix to, at least once.")
+	fs.StringVar(&cfg.ExperimentalAdmissionWebhookURL, "experimental-admission-webhook-url", cfg.ExperimentalAdmissionWebhookURL, "HTTP or HTTPS URL of the gRPC admission webhook admitting or rejecting the write requests before they are proposed to raft. Its path, if any, prefixes the path of the gRPC method.")
+	fs.StringVar(&cfg.ExperimentalAdmissionWebhookCAFile, "experimental-admission-webhook-ca-file", cfg.ExperimentalAdmissionWebhookCAFile, "Path to the CA certificate file verifying the certificate of an HTTPS admission webhook. The system roots are used if empty.")
+	fs.DurationVar(&cfg.ExperimentalAdmissionWebhookTimeout, "experimental-admission-webhook-timeout", cfg.ExperimentalAdmissionWebhookTimeout, "Timeout of a call to the admission webhook. The request is rejected when it expires.")
+	fs.Var(flags.NewStringsValue(""), "experimental-keyspace-metrics-prefixes", "Comma-separated list of key prefixes for which to export the number of keys, the size of their values and the number of writes as metrics.")
+	fs.BoolVar(&cfg.ExperimentalPrincipalMetrics, "experimental-principal-metrics", cfg.ExperimentalPrincipalMetrics, "Enable client request metrics labeled by the authenticated user or client certificate CN.")
+	fs.IntVar(&cfg.ExperimentalPrincipalMetricsMaxPrincipals, "experimental-principal-metrics-max-principals", cfg.ExperimentalPrincipalMetricsMaxPrincipals, "Maximum number of principals given their own label in the principal metrics. The others are labeled 'other'.")
+	fs.Var(flags.NewStringsValue(""), "experimental-principal-metrics-allowlist", "Comma-separated list of the only principals given their own label in the principal metrics. The others are labeled 'other'.")
+	fs.BoolVar(&cfg.ExperimentalInflightRequests, "experimental-inflight-requests", cfg.ExperimentalInflightRequests, "Enable tracking the requests being served and listing them at /debug/inflight on the metrics URLs. Requires --listen-metrics-urls.")
+	fs.IntVar(&cfg.ExperimentalRangeTombstoneThreshold, "experimental-range-tombstone-threshold", cfg.ExperimentalRangeTombstoneThreshold, "Number of keys above which a delete records a single range tombstone instead of a tombstone per key. 0 disables it.")
+	fs.StringVar(&cfg.ExperimentalWatchSlowWatcherPolicy, "experimental-watch-slow-watcher-policy", cfg.ExperimentalWatchSlowWatcherPolicy, "Policy applied to the watchers that cannot keep up with the events once they lag more than --experimental-watch-slow-watcher-max-lag revisions behind: 'cancel', 'resync' or 'block'. Empty lets them catch up.")
+	fs.Int64Var(&cfg.ExperimentalWatchSlowWatcherMaxLag, "experimental-watch-slow-watcher-max-lag", cfg.ExperimentalWatchSlowWatcherMaxLag, "Number of revisions a slow watcher may lag behind before the slow watcher policy applies.")
+	fs.DurationVar(&cfg.ExperimentalWatchSlowWatcherBlockTimeout, "experimental-watch-slow-watcher-block-timeout", cfg.ExperimentalWatchSlowWatcherBlockTimeout, "Maximum time a write blocks on the slow watchers under the 'block' slow watcher policy.")
+	fs.Float64Var(&cfg.ExperimentalHotKeysSampleRate, "experimental-hot-keys-sample-rate", cfg.ExperimentalHotKeysSampleRate, "Fraction of the requests sampled to track the most frequently read and written keys, reported by 'etcdctl endpoint hot-keys'. 0 disables the tracking.")
+	fs.IntVar(&cfg.ExperimentalSoftDeleteThreshold, "experimental-soft-delete-threshold", cfg.ExperimentalSoftDeleteThreshold, "Number of keys above which a delete moves the keys to the trash instead of deleting them. 0 disables it.")
+	fs.Var(flags.NewStringsValue(""), "experimental-soft-delete-prefixes", "Comma-separated list of key prefixes whose keys are always moved to the trash when deleted.")
+	fs.DurationVar(&cfg.ExperimentalSoftDeleteRetention, "experimental-soft-delete-retention", cfg.ExperimentalSoftDeleteRetention, "Time the deleted keys are kept in the trash before being purged.")
+	fs.StringVar(&cfg.ExperimentalSoftDeleteTrashPrefix, "experimental-soft-delete-trash-prefix", cfg.ExperimentalSoftDeleteTrashPrefix, "Prefix of the keys in the trash.")
+	fs.Var(flags.NewStringsValue(""), "experimental-protected-prefixes", "Comma-separated list of '<prefix>=<role>' protected key prefixes, which only the listed roles can modify and never delete with a wider range. A prefix can be listed with several roles.")
+	fs.DurationVar(&cfg.ExperimentalDiskProbeInterval, "experimental-disk-probe-interval", cfg.ExperimentalDiskProbeInterval, "Interval between two probes of the latency of a write and fsync on the WAL device. 0 disables the probes.")
+	fs.DurationVar(&cfg.ExperimentalDiskProbeThreshold, "experimental-disk-probe-threshold", cfg.ExperimentalDiskProbeThreshold, "Disk probe latency above which the disk is considered slow.")
+	fs.IntVar(&cfg.ExperimentalDiskProbeFailures, "experimental-disk-probe-failures", cfg.ExperimentalDiskProbeFailures, "Number of consecutive slow disk probes raising a SLOWDISK alarm, and of fast probes clearing it.")
+	fs.BoolVar(&cfg.ExperimentalDiskProbeSelfFence, "experimental-disk-probe-self-fence", cfg.ExperimentalDiskProbeSelfFence, "Drain the member, transferring its leadership away and failing its readiness checks, while its SLOWDISK alarm is raised.")
+	fs.StringVar(&cfg.ExperimentalMaintenanceWindows, "experimental-maintenance-windows", cfg.ExperimentalMaintenanceWindows, "Semicolon-separated list of maintenance windows, each a cron schedule in UTC of its start followed by its duration, e.g. '0 2 * * 6 4h'. Auto-compaction and snapshots are deferred to the windows.")
+	fs.UintVar(&cfg.ExperimentalMaintenanceDefragThresholdMegabytes, "experimental-maintenance-defrag-threshold-megabytes", cfg.ExperimentalMaintenanceDefragThresholdMegabytes, "Defragment the backend once per maintenance window if it would free at least the provided threshold of disk space. 0 disables it.")
+	fs.StringVar(&cfg.ExperimentalEncryptionKEKFile, "experimental-encryption-kek-file", cfg.ExperimentalEncryptionKEKFile, "Path to the base64 encoded 32-byte key encryption key used to encrypt key-value records at rest. All members must use the same key.")
+	fs.StringVar(&cfg.ExperimentalWALCompression, "experimental-wal-compression", cfg.ExperimentalWALCompression, "Codec used to compress large WAL entries: 'none' or 'snappy'. A member that wrote compressed entries cannot be downgraded below v3.6.")
 	fs.IntVar(&cfg.ExperimentalMaxLearners, "experimental-max-learners", membership.DefaultMaxLearners, "Sets the maximum number of learners that can be available in the cluster membership.")
 	fs.Uint64Var(&cfg.SnapshotCatchUpEntries, "experimental-snapshot-catchup-entries", cfg.SnapshotCatchUpEntries, "Number of entries for a slow follower to catch up after compacting the raft storage entries.")
 
@@ -994,11 +1445,36 @@ func (cfg *Config) Validate() error {
 		return fmt.Errorf("unknown auto-compaction-mode %q", cfg.AutoCompactionMode)
 	}
 
+	if cfg.ExperimentalLeaseRevokeRate <= 0 {
+		return fmt.Errorf("--experimental-lease-revoke-rate must be >0 (set to %d)", cfg.ExperimentalLeaseRevokeRate)
+	}
+	if cfg.ExperimentalLeasePinMaxDuration <= 0 {
+		return fmt.Errorf("--experimental-lease-pin-max-duration must be >0 (set to %v)", cfg.ExperimentalLeasePinMaxDuration)
+	}
+	if cfg.ExperimentalCompactionPauseTarget < 0 {
+		return fmt.Errorf("--experimental-compaction-pause-target must be >=0 (set to %v)", cfg.ExperimentalCompactionPauseTarget)
+	}
+	if cfg.GRPCMaxRecvBytes > math.MaxInt32 {
+		return fmt.Errorf("--grpc-max-recv-bytes must be <=%d (set to %d)", math.MaxInt32, cfg.GRPCMaxRecvBytes)
+	}
+	if cfg.GRPCMaxSendBytes > math.MaxInt32 {
+		return fmt.Errorf("--grpc-max-send-bytes must be <=%d (set to %d)", math.MaxInt32, cfg.GRPCMaxSendBytes)
+	}
+	if cfg.PeerKeepAliveInterval <= 0 {
+		return fmt.Errorf("--peer-keepalive-interval must be >0 (set to %v)", cfg.PeerKeepAliveInterval)
+	}
+	if cfg.PeerIdleTimeout < 0 {
+		return fmt.Errorf("--peer-idle-timeout must be >=0 (set to %v)", cfg.PeerIdleTimeout)
+	}
+
 	// Validate distributed tracing configuration but only if enabled.
 	if cfg.ExperimentalEnableDistributedTracing {
 		if err := validateTracingConfig(cfg.ExperimentalDistributedTracingSamplingRatePerMillion); err != nil {
 			return fmt.Errorf("distributed tracing configurition is not valid: (%v)", err)
 		}
+		if cfg.ExperimentalDistributedTracingSlowRequestThreshold < 0 {
+			return fmt.Errorf("--experimental-distributed-tracing-slow-request-threshold must be >=0 (set to %v)", cfg.ExperimentalDistributedTracingSlowRequestThreshold)
+		}
 	}
 
 	if !cfg.ExperimentalEnableLeaseCheckpointPersist && cfg.ExperimentalEnableLeaseCheckpoint {
@@ -1013,6 +1489,150 @@ func (cfg *Config) Validate() error {
 		return fmt.Errorf("--experimental-compact-hash-check-time must be >0 (set to %v)", cfg.ExperimentalCompactHashCheckTime)
 	}
 
+	if cfg.ExperimentalCorruptRangeCheckTime < 0 {
+		return fmt.Errorf("--experimental-corrupt-range-check-time must be >=0 (set to %v)", cfg.ExperimentalCorruptRangeCheckTime)
+	}
+
+	if cfg.ExperimentalCorruptRangeCheckKeys <= 0 {
+		return fmt.Errorf("--experimental-corrupt-range-check-keys must be >0 (set to %v)", cfg.ExperimentalCorruptRangeCheckKeys)
+	}
+
+	if cfg.ExperimentalAdmissionWebhookURL != "" {
+		u, err := url.Parse(cfg.ExperimentalAdmissionWebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("--experimental-admission-webhook-url %q must be an http or https URL", cfg.ExperimentalAdmissionWebhookURL)
+		}
+		if cfg.ExperimentalAdmissionWebhookCAFile != "" && u.Scheme != "https" {
+			return fmt.Errorf("--experimental-admission-webhook-ca-file requires an https --experimental-admission-webhook-url")
+		}
+	}
+
+	if cfg.ExperimentalAdmissionWebhookTimeout <= 0 {
+		return fmt.Errorf("--experimental-admission-webhook-timeout must be >0 (set to %v)", cfg.ExperimentalAdmissionWebhookTimeout)
+	}
+
+	if cfg.ExperimentalAutoRecoverNoSpace && !cfg.PeerTLSInfo.ClientCertAuth {
+		return fmt.Errorf("--experimental-auto-recover-nospace requires --peer-client-cert-auth")
+	}
+
+	if cfg.ExperimentalRangeTombstoneThreshold < 0 {
+		return fmt.Errorf("--experimental-range-tombstone-threshold must be >=0 (set to %v)", cfg.ExperimentalRangeTombstoneThreshold)
+	}
+
+	if _, err := mvcc.ParseSlowWatcherPolicy(cfg.ExperimentalWatchSlowWatcherPolicy); err != nil {
+		return fmt.Errorf("--experimental-watch-slow-watcher-policy: %w", err)
+	}
+
+	if cfg.ExperimentalWatchSlowWatcherMaxLag < 0 {
+		return fmt.Errorf("--experimental-watch-slow-watcher-max-lag must be >=0 (set to %v)", cfg.ExperimentalWatchSlowWatcherMaxLag)
+	}
+
+	if cfg.ExperimentalWatchSlowWatcherPolicy == string(mvcc.SlowWatcherPolicyBlock) && cfg.ExperimentalWatchSlowWatcherBlockTimeout <= 0 {
+		return fmt.Errorf("--experimental-watch-slow-watcher-block-timeout must be >0 (set to %v)", cfg.ExperimentalWatchSlowWatcherBlockTimeout)
+	}
+
+	if cfg.ExperimentalHotKeysSampleRate < 0 || cfg.ExperimentalHotKeysSampleRate > 1 {
+		return fmt.Errorf("--experimental-hot-keys-sample-rate must be between 0 and 1 (set to %v)", cfg.ExperimentalHotKeysSampleRate)
+	}
+
+	if cfg.ExperimentalWitness && cfg.ExperimentalLearnerReadReplica {
+		return fmt.Errorf("--experimental-witness and --experimental-learner-read-replica cannot be both set")
+	}
+
+	if cfg.ExperimentalInflightRequests && len(cfg.ListenMetricsUrls) == 0 {
+		return fmt.Errorf("--experimental-inflight-requests requires --listen-metrics-urls")
+	}
+
+	if cfg.ExperimentalSoftDeleteThreshold < 0 {
+		return fmt.Errorf("--experimental-soft-delete-threshold must be >=0 (set to %v)", cfg.ExperimentalSoftDeleteThreshold)
+	}
+
+	if cfg.ExperimentalSoftDeleteThreshold > 0 || len(cfg.ExperimentalSoftDeletePrefixes) > 0 {
+		if cfg.ExperimentalSoftDeleteRetention <= 0 {
+			return fmt.Errorf("--experimental-soft-delete-retention must be >0 (set to %v)", cfg.ExperimentalSoftDeleteRetention)
+		}
+		if cfg.ExperimentalSoftDeleteTrashPrefix == "" {
+			return fmt.Errorf("--experimental-soft-delete-trash-prefix must not be empty")
+		}
+	}
+
+	for prefix, roles := range cfg.ExperimentalProtectedPrefixes {
+		if prefix == "" || len(roles) == 0 {
+			return fmt.Errorf("--experimental-protected-prefixes must have a non-empty prefix and at least one role (set to %q for %q)", roles, prefix)
+		}
+	}
+
+	if cfg.ExperimentalDiskProbeInterval < 0 {
+		return fmt.Errorf("--experimental-disk-probe-interval must be >=0 (set to %v)", cfg.ExperimentalDiskProbeInterval)
+	}
+
+	if cfg.ExperimentalDiskProbeInterval > 0 {
+		if cfg.ExperimentalDiskProbeThreshold <= 0 {
+			return fmt.Errorf("--experimental-disk-probe-threshold must be >0 (set to %v)", cfg.ExperimentalDiskProbeThreshold)
+		}
+		if cfg.ExperimentalDiskProbeFailures <= 0 {
+			return fmt.Errorf("--experimental-disk-probe-failures must be >0 (set to %v)", cfg.ExperimentalDiskProbeFailures)
+		}
+	}
+
+	if _, err := etcdserver.ParseMaintenanceWindows(cfg.ExperimentalMaintenanceWindows); err != nil {
+		return fmt.Errorf("invalid --experimental-maintenance-windows: %w", err)
+	}
+	if cfg.ExperimentalMaintenanceDefragThresholdMegabytes > 0 && cfg.ExperimentalMaintenanceWindows == "" {
+		return fmt.Errorf("--experimental-maintenance-defrag-threshold-megabytes requires --experimental-maintenance-windows")
+	}
+	if cfg.ExperimentalMaintenanceDefragThresholdMegabytes > 0 && !cfg.PeerTLSInfo.ClientCertAuth {
+		return fmt.Errorf("--experimental-maintenance-defrag-threshold-megabytes requires --peer-client-cert-auth")
+	}
+
+	if cfg.SlowRequestThreshold < 0 {
+		return fmt.Errorf("--slow-request-threshold must be >=0 (set to %v)", cfg.SlowRequestThreshold)
+	}
+
+	if _, err := v3rpc.ParseClientIPAllowlist(cfg.ClientIPLimitAllowlist); err != nil {
+		return fmt.Errorf("invalid --client-ip-limit-allowlist: %w", err)
+	}
+
+	for _, checks := range []map[string]etcdhttp.HealthCheck{cfg.LivezChecks, cfg.ReadyzChecks} {
+		for name := range checks {
+			if name == "" || strings.Contains(name, "/") {
+				return fmt.Errorf("invalid health check name %q", name)
+			}
+		}
+	}
+
+	if cfg.ExperimentalSnapshotSendRateBytes < 0 {
+		return fmt.Errorf("--experimental-snapshot-send-rate-bytes must be >=0 (set to %d)", cfg.ExperimentalSnapshotSendRateBytes)
+	}
+
+	if _, err := wal.ParseCompressionType(cfg.ExperimentalWALCompression); err != nil {
+		return fmt.Errorf("invalid --experimental-wal-compression: %w", err)
+	}
+
+	for name, p := range cfg.ExperimentalLeaderPriorities {
+		if p < 0 {
+			return fmt.Errorf("--experimental-leader-priorities must be >=0 (set to %d for %q)", p, name)
+		}
+	}
+
+	for _, r := range cfg.ExperimentalQuotaBackendWarningRatios {
+		if r <= 0 || r >= 1 {
+			return fmt.Errorf("--experimental-quota-backend-warning-ratios must be between 0 and 1 (set to %v)", r)
+		}
+	}
+
+	if cfg.ExperimentalBackendEngine != "" && !backend.IsValidEngine(cfg.ExperimentalBackendEngine) {
+		return fmt.Errorf("unknown --experimental-backend-engine %q", cfg.ExperimentalBackendEngine)
+	}
+
+	if _, err := cfg.changeNotifyTargets(); err != nil {
+		return err
+	}
+
+	if cfg.ExperimentalPrincipalMetrics && cfg.ExperimentalPrincipalMetricsMaxPrincipals <= 0 {
+		return fmt.Errorf("--experimental-principal-metrics-max-principals must be >0 (set to %d)", cfg.ExperimentalPrincipalMetricsMaxPrincipals)
+	}
+
 	// If `--name` isn't configured, then multiple members may have the same "default" name.
 	// When adding a new member with the "default" name as well, etcd may regards its peerURL
 	// as one additional peerURL of the existing member which has the same "default" name,
@@ -1378,3 +1998,42 @@ func parseBackendFreelistType(freelistType string) bolt.FreelistType {
 
 	return bolt.FreelistMapType
 }
+
+var changeNotifyTargetNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// changeNotifyTargets returns ChangeNotifyTargets and a target per
+// ExperimentalChangeWebhooks entry, named after its prefix and URL.
+func (cfg *Config) changeNotifyTargets() ([]v3notify.Target, error) {
+	targets := append([]v3notify.Target(nil), cfg.ChangeNotifyTargets...)
+	for _, wh := range cfg.ExperimentalChangeWebhooks {
+		prefix, rawURL, ok := strings.Cut(wh, "=")
+		if !ok {
+			return nil, fmt.Errorf("--experimental-change-webhooks %q must be '<prefix>=<url>'", wh)
+		}
+		u, err := url.Parse(rawURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("--experimental-change-webhooks %q must have an http or https URL", wh)
+		}
+		h := fnv.New64a()
+		h.Write([]byte(wh))
+		targets = append(targets, v3notify.Target{
+			Name:   fmt.Sprintf("webhook-%016x", h.Sum64()),
+			Prefix: prefix,
+			Sink:   v3notify.NewHTTPSink(rawURL, nil),
+		})
+	}
+	names := make(map[string]struct{})
+	for _, t := range targets {
+		if !changeNotifyTargetNameRegexp.MatchString(t.Name) {
+			return nil, fmt.Errorf("invalid change notification target name %q", t.Name)
+		}
+		if _, ok := names[t.Name]; ok {
+			return nil, fmt.Errorf("duplicate change notification target %q", t.Name)
+		}
+		if t.Sink == nil {
+			return nil, fmt.Errorf("change notification target %q has no sink", t.Name)
+		}
+		names[t.Name] = struct{}{}
+	}
+	return targets, nil
+}
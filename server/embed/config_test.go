@@ -504,6 +504,72 @@ func TestLeaseCheckpointValidate(t *testing.T) {
 	}
 }
 
+func TestChangeWebhooksValidate(t *testing.T) {
+	tcs := []struct {
+		name        string
+		webhooks    []string
+		expectError bool
+	}{
+		{
+			name:     "Webhooks with prefixes should pass",
+			webhooks: []string{"/foo/=http://127.0.0.1:8080/hook", "=https://example.com/all"},
+		},
+		{
+			name:        "Webhook without prefix separator should fail",
+			webhooks:    []string{"http://127.0.0.1:8080/hook"},
+			expectError: true,
+		},
+		{
+			name:        "Webhook with non-HTTP URL should fail",
+			webhooks:    []string{"/foo/=unix:///tmp/hook.sock"},
+			expectError: true,
+		},
+		{
+			name:        "Duplicate webhooks should fail",
+			webhooks:    []string{"/foo/=http://127.0.0.1:8080/hook", "/foo/=http://127.0.0.1:8080/hook"},
+			expectError: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := *NewConfig()
+			cfg.ExperimentalChangeWebhooks = tc.webhooks
+			err := cfg.Validate()
+			if (err != nil) != tc.expectError {
+				t.Errorf("config.Validate() = %q, expected error: %v", err, tc.expectError)
+			}
+		})
+	}
+}
+
+func TestAutoRecoverNoSpaceValidate(t *testing.T) {
+	tcs := []struct {
+		name           string
+		clientCertAuth bool
+		expectError    bool
+	}{
+		{
+			name:           "Auto recovery with peer client cert auth should pass",
+			clientCertAuth: true,
+		},
+		{
+			name:        "Auto recovery without peer client cert auth should fail",
+			expectError: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := *NewConfig()
+			cfg.ExperimentalAutoRecoverNoSpace = true
+			cfg.PeerTLSInfo.ClientCertAuth = tc.clientCertAuth
+			err := cfg.Validate()
+			if (err != nil) != tc.expectError {
+				t.Errorf("config.Validate() = %q, expected error: %v", err, tc.expectError)
+			}
+		})
+	}
+}
+
 func TestLogRotation(t *testing.T) {
 	tests := []struct {
 		name              string
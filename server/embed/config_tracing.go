@@ -24,7 +24,10 @@ import (
 	"go.opentelemetry.io/otel/sdk/resource"
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/server/v3/etcdserver"
 )
 
 const maxSamplingRatePerMillion = 1000000
@@ -77,7 +80,7 @@ func newTracingExporter(ctx context.Context, cfg *Config) (*tracingExporter, err
 		tracesdk.WithBatcher(exporter),
 		tracesdk.WithResource(res),
 		tracesdk.WithSampler(
-			tracesdk.ParentBased(determineSampler(cfg.ExperimentalDistributedTracingSamplingRatePerMillion)),
+			tracesdk.ParentBased(slowRequestSampler{determineSampler(cfg.ExperimentalDistributedTracingSamplingRatePerMillion)}),
 		),
 	)
 
@@ -125,6 +128,28 @@ func determineSampler(samplingRate int) tracesdk.Sampler {
 	return tracesdk.TraceIDRatioBased(float64(samplingRate) / float64(maxSamplingRatePerMillion))
 }
 
+// slowRequestSampler samples the root spans of the requests traced for being
+// slow and defers to the embedded sampler for the other spans.
+type slowRequestSampler struct {
+	tracesdk.Sampler
+}
+
+func (s slowRequestSampler) ShouldSample(p tracesdk.SamplingParameters) tracesdk.SamplingResult {
+	for _, attr := range p.Attributes {
+		if attr.Key == etcdserver.SlowRequestAttributeKey && attr.Value.AsBool() {
+			return tracesdk.SamplingResult{
+				Decision:   tracesdk.RecordAndSample,
+				Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+			}
+		}
+	}
+	return s.Sampler.ShouldSample(p)
+}
+
+func (s slowRequestSampler) Description() string {
+	return "SlowRequestSampler{" + s.Sampler.Description() + "}"
+}
+
 // As Tracing service Instance ID must be unique, it should
 // never use the empty default string value, it's set if
 // if it's a non empty string.
@@ -15,7 +15,13 @@
 package embed
 
 import (
+	"context"
 	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+
+	"go.etcd.io/etcd/server/v3/etcdserver"
 )
 
 const neverSampleDescription = "AlwaysOffSampler"
@@ -81,3 +87,30 @@ func TestTracingConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestSlowRequestSampler(t *testing.T) {
+	sampler := slowRequestSampler{determineSampler(0)}
+	tests := []struct {
+		name         string
+		attrs        []attribute.KeyValue
+		wantDecision tracesdk.SamplingDecision
+	}{
+		{
+			name:         "not a slow request",
+			wantDecision: tracesdk.Drop,
+		},
+		{
+			name:         "slow request",
+			attrs:        []attribute.KeyValue{etcdserver.SlowRequestAttributeKey.Bool(true)},
+			wantDecision: tracesdk.RecordAndSample,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res := sampler.ShouldSample(tracesdk.SamplingParameters{ParentContext: context.Background(), Attributes: tc.attrs})
+			if res.Decision != tc.wantDecision {
+				t.Errorf("sampling decision = %v, want %v", res.Decision, tc.wantDecision)
+			}
+		})
+	}
+}
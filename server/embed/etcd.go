@@ -16,6 +16,7 @@ package embed
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
@@ -39,6 +40,7 @@ import (
 	"google.golang.org/grpc/keepalive"
 
 	"go.etcd.io/etcd/api/v3/version"
+	"go.etcd.io/etcd/client/pkg/v3/tlsutil"
 	"go.etcd.io/etcd/client/pkg/v3/transport"
 	"go.etcd.io/etcd/client/pkg/v3/types"
 	"go.etcd.io/etcd/client/v3/credentials"
@@ -48,7 +50,11 @@ import (
 	"go.etcd.io/etcd/server/v3/etcdserver"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/etcdhttp"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/rafthttp"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3admission"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3rpc"
 	"go.etcd.io/etcd/server/v3/storage"
+	"go.etcd.io/etcd/server/v3/storage/encryption"
+	"go.etcd.io/etcd/server/v3/storage/wal"
 	"go.etcd.io/etcd/server/v3/verify"
 )
 
@@ -75,6 +81,7 @@ type Etcd struct {
 	metricsListeners []net.Listener
 
 	tracingExporterShutdown func()
+	admissionWebhook        *v3admission.Webhook
 
 	Server *etcdserver.EtcdServer
 
@@ -166,37 +173,79 @@ func StartEtcd(inCfg *Config) (e *Etcd, err error) {
 
 	backendFreelistType := parseBackendFreelistType(cfg.BackendFreelistType)
 
+	encryptionKMS := cfg.EncryptionKMS
+	if encryptionKMS == nil && cfg.ExperimentalEncryptionKEKFile != "" {
+		if encryptionKMS, err = encryption.NewLocalKMSFromFile(cfg.ExperimentalEncryptionKEKFile); err != nil {
+			return e, err
+		}
+	}
+
+	changeNotifyTargets, err := cfg.changeNotifyTargets()
+	if err != nil {
+		return e, err
+	}
+
+	clientIPLimitAllowlist, err := v3rpc.ParseClientIPAllowlist(cfg.ClientIPLimitAllowlist)
+	if err != nil {
+		return e, err
+	}
+
+	admissionControllers := append([]v3admission.Controller(nil), cfg.AdmissionControllers...)
+	if cfg.ExperimentalAdmissionWebhookURL != "" {
+		var webhookTLS *tls.Config
+		if cfg.ExperimentalAdmissionWebhookCAFile != "" {
+			cp, cerr := tlsutil.NewCertPool([]string{cfg.ExperimentalAdmissionWebhookCAFile})
+			if cerr != nil {
+				return e, fmt.Errorf("cannot load admission webhook CA file: %w", cerr)
+			}
+			webhookTLS = &tls.Config{RootCAs: cp}
+		}
+		e.admissionWebhook, err = v3admission.NewWebhook(cfg.logger, cfg.ExperimentalAdmissionWebhookURL, cfg.ExperimentalAdmissionWebhookTimeout, webhookTLS)
+		if err != nil {
+			return e, err
+		}
+		admissionControllers = append(admissionControllers, e.admissionWebhook)
+	}
+
 	srvcfg := config.ServerConfig{
-		Name:                                     cfg.Name,
-		ClientURLs:                               cfg.AdvertiseClientUrls,
-		PeerURLs:                                 cfg.AdvertisePeerUrls,
-		DataDir:                                  cfg.Dir,
-		DedicatedWALDir:                          cfg.WalDir,
-		SnapshotCount:                            cfg.SnapshotCount,
-		SnapshotCatchUpEntries:                   cfg.SnapshotCatchUpEntries,
-		MaxSnapFiles:                             cfg.MaxSnapFiles,
-		MaxWALFiles:                              cfg.MaxWalFiles,
-		InitialPeerURLsMap:                       urlsmap,
-		InitialClusterToken:                      token,
-		DiscoveryURL:                             cfg.Durl,
-		DiscoveryProxy:                           cfg.Dproxy,
-		DiscoveryCfg:                             cfg.DiscoveryCfg,
-		NewCluster:                               cfg.IsNewCluster(),
-		PeerTLSInfo:                              cfg.PeerTLSInfo,
-		TickMs:                                   cfg.TickMs,
-		ElectionTicks:                            cfg.ElectionTicks(),
-		InitialElectionTickAdvance:               cfg.InitialElectionTickAdvance,
-		AutoCompactionRetention:                  autoCompactionRetention,
-		AutoCompactionMode:                       cfg.AutoCompactionMode,
-		QuotaBackendBytes:                        cfg.QuotaBackendBytes,
-		BackendBatchLimit:                        cfg.BackendBatchLimit,
-		BackendFreelistType:                      backendFreelistType,
-		BackendBatchInterval:                     cfg.BackendBatchInterval,
-		MaxTxnOps:                                cfg.MaxTxnOps,
-		MaxRequestBytes:                          cfg.MaxRequestBytes,
-		MaxConcurrentStreams:                     cfg.MaxConcurrentStreams,
-		SocketOpts:                               cfg.SocketOpts,
-		StrictReconfigCheck:                      cfg.StrictReconfigCheck,
+		Name:                                 cfg.Name,
+		ClientURLs:                           cfg.AdvertiseClientUrls,
+		PeerURLs:                             cfg.AdvertisePeerUrls,
+		DataDir:                              cfg.Dir,
+		DedicatedWALDir:                      cfg.WalDir,
+		SnapshotCount:                        cfg.SnapshotCount,
+		SnapshotCatchUpEntries:               cfg.SnapshotCatchUpEntries,
+		MaxSnapFiles:                         cfg.MaxSnapFiles,
+		MaxWALFiles:                          cfg.MaxWalFiles,
+		InitialPeerURLsMap:                   urlsmap,
+		InitialClusterToken:                  token,
+		DiscoveryURL:                         cfg.Durl,
+		DiscoveryProxy:                       cfg.Dproxy,
+		DiscoveryCfg:                         cfg.DiscoveryCfg,
+		NewCluster:                           cfg.IsNewCluster(),
+		PeerTLSInfo:                          cfg.PeerTLSInfo,
+		TickMs:                               cfg.TickMs,
+		ElectionTicks:                        cfg.ElectionTicks(),
+		InitialElectionTickAdvance:           cfg.InitialElectionTickAdvance,
+		AutoCompactionRetention:              autoCompactionRetention,
+		AutoCompactionMode:                   cfg.AutoCompactionMode,
+		QuotaBackendBytes:                    cfg.QuotaBackendBytes,
+		BackendBatchLimit:                    cfg.BackendBatchLimit,
+		BackendFreelistType:                  backendFreelistType,
+		BackendBatchInterval:                 cfg.BackendBatchInterval,
+		MaxTxnOps:                            cfg.MaxTxnOps,
+		MaxRequestBytes:                      cfg.MaxRequestBytes,
+		MaxConcurrentStreams:                 cfg.MaxConcurrentStreams,
+		GRPCMaxRecvBytes:                     cfg.GRPCMaxRecvBytes,
+		GRPCMaxSendBytes:                     cfg.GRPCMaxSendBytes,
+		MaxStreamsPerClientIP:                cfg.MaxStreamsPerClientIP,
+		ClientIPLimitAllowlist:               clientIPLimitAllowlist,
+		SocketOpts:                           cfg.SocketOpts,
+		StrictReconfigCheck:                  cfg.StrictReconfigCheck,
+		ExperimentalAutoPromoteLearner:       cfg.ExperimentalAutoPromoteLearner,
+		ExperimentalAutoPromoteLearnerMaxLag: cfg.ExperimentalAutoPromoteLearnerMaxLag,
+		ExperimentalAutoPromoteLearnerStabilizationWindow: cfg.ExperimentalAutoPromoteLearnerStabilizationWindow,
+		LeaderPriorities:                         cfg.ExperimentalLeaderPriorities,
 		ClientCertAuthEnabled:                    cfg.ClientTLSInfo.ClientCertAuth,
 		AuthToken:                                cfg.AuthToken,
 		BcryptCost:                               cfg.BcryptCost,
@@ -207,6 +256,8 @@ func StartEtcd(inCfg *Config) (e *Etcd, err error) {
 		CorruptCheckTime:                         cfg.ExperimentalCorruptCheckTime,
 		CompactHashCheckEnabled:                  cfg.ExperimentalCompactHashCheckEnabled,
 		CompactHashCheckTime:                     cfg.ExperimentalCompactHashCheckTime,
+		CorruptRangeCheckTime:                    cfg.ExperimentalCorruptRangeCheckTime,
+		CorruptRangeCheckKeys:                    cfg.ExperimentalCorruptRangeCheckKeys,
 		PreVote:                                  cfg.PreVote,
 		Logger:                                   cfg.logger,
 		ForceNewCluster:                          cfg.ForceNewCluster,
@@ -215,19 +266,60 @@ func StartEtcd(inCfg *Config) (e *Etcd, err error) {
 		UnsafeNoFsync:                            cfg.UnsafeNoFsync,
 		EnableLeaseCheckpoint:                    cfg.ExperimentalEnableLeaseCheckpoint,
 		LeaseCheckpointPersist:                   cfg.ExperimentalEnableLeaseCheckpointPersist,
+		LeaseRevokeRate:                          cfg.ExperimentalLeaseRevokeRate,
+		LeasePinMaxDuration:                      cfg.ExperimentalLeasePinMaxDuration,
 		CompactionBatchLimit:                     cfg.ExperimentalCompactionBatchLimit,
 		CompactionSleepInterval:                  cfg.ExperimentalCompactionSleepInterval,
+		CompactionPauseTarget:                    cfg.ExperimentalCompactionPauseTarget,
+		CompactionMaxSleepInterval:               cfg.ExperimentalCompactionMaxSleepInterval,
 		WatchProgressNotifyInterval:              cfg.ExperimentalWatchProgressNotifyInterval,
 		DowngradeCheckTime:                       cfg.ExperimentalDowngradeCheckTime,
 		WarningApplyDuration:                     cfg.ExperimentalWarningApplyDuration,
 		WarningUnaryRequestDuration:              cfg.WarningUnaryRequestDuration,
+		SlowRequestThreshold:                     cfg.SlowRequestThreshold,
 		ExperimentalMemoryMlock:                  cfg.ExperimentalMemoryMlock,
 		ExperimentalTxnModeWriteWithSharedBuffer: cfg.ExperimentalTxnModeWriteWithSharedBuffer,
 		ExperimentalStopGRPCServiceOnDefrag:      cfg.ExperimentalStopGRPCServiceOnDefrag,
-		ExperimentalBootstrapDefragThresholdMegabytes: cfg.ExperimentalBootstrapDefragThresholdMegabytes,
-		ExperimentalMaxLearners:                       cfg.ExperimentalMaxLearners,
-		V2Deprecation:                                 cfg.V2DeprecationEffective(),
-		ExperimentalLocalAddress:                      cfg.InferLocalAddr(),
+		ExperimentalBootstrapDefragThresholdMegabytes:   cfg.ExperimentalBootstrapDefragThresholdMegabytes,
+		ExperimentalMaxLearners:                         cfg.ExperimentalMaxLearners,
+		V2Deprecation:                                   cfg.V2DeprecationEffective(),
+		ExperimentalLocalAddress:                        cfg.InferLocalAddr(),
+		ExperimentalTrustedProxyCNs:                     cfg.ExperimentalTrustedProxyCNs,
+		ExperimentalLearnerReadReplica:                  cfg.ExperimentalLearnerReadReplica,
+		ExperimentalWitness:                             cfg.ExperimentalWitness,
+		ExperimentalSnapshotSendRateBytes:               cfg.ExperimentalSnapshotSendRateBytes,
+		ExperimentalSnapshotCompression:                 cfg.ExperimentalSnapshotCompression,
+		ExperimentalDifferentialSnapshot:                cfg.ExperimentalDifferentialSnapshot,
+		ExperimentalWALCompression:                      wal.CompressionType(cfg.ExperimentalWALCompression),
+		ExperimentalOnlineDefrag:                        cfg.ExperimentalOnlineDefrag,
+		ExperimentalQuotaBackendWarningRatios:           cfg.ExperimentalQuotaBackendWarningRatios,
+		ExperimentalAutoRecoverNoSpace:                  cfg.ExperimentalAutoRecoverNoSpace,
+		BackendEngine:                                   cfg.ExperimentalBackendEngine,
+		EncryptionKMS:                                   encryptionKMS,
+		ChangeNotifyTargets:                             changeNotifyTargets,
+		ExperimentalKeyspaceMetricsPrefixes:             cfg.ExperimentalKeyspaceMetricsPrefixes,
+		ExperimentalPrincipalMetrics:                    cfg.ExperimentalPrincipalMetrics,
+		ExperimentalPrincipalMetricsMaxPrincipals:       cfg.ExperimentalPrincipalMetricsMaxPrincipals,
+		ExperimentalPrincipalMetricsAllowlist:           cfg.ExperimentalPrincipalMetricsAllowlist,
+		ExperimentalInflightRequests:                    cfg.ExperimentalInflightRequests,
+		ExperimentalRangeTombstoneThreshold:             cfg.ExperimentalRangeTombstoneThreshold,
+		ExperimentalWatchSlowWatcherPolicy:              cfg.ExperimentalWatchSlowWatcherPolicy,
+		ExperimentalWatchSlowWatcherMaxLag:              cfg.ExperimentalWatchSlowWatcherMaxLag,
+		ExperimentalWatchSlowWatcherBlockTimeout:        cfg.ExperimentalWatchSlowWatcherBlockTimeout,
+		ExperimentalHotKeysSampleRate:                   cfg.ExperimentalHotKeysSampleRate,
+		ExperimentalSoftDeleteThreshold:                 cfg.ExperimentalSoftDeleteThreshold,
+		ExperimentalSoftDeletePrefixes:                  cfg.ExperimentalSoftDeletePrefixes,
+		ExperimentalSoftDeleteRetention:                 cfg.ExperimentalSoftDeleteRetention,
+		ExperimentalSoftDeleteTrashPrefix:               cfg.ExperimentalSoftDeleteTrashPrefix,
+		ExperimentalProtectedPrefixes:                   cfg.ExperimentalProtectedPrefixes,
+		ExperimentalDiskProbeInterval:                   cfg.ExperimentalDiskProbeInterval,
+		ExperimentalDiskProbeThreshold:                  cfg.ExperimentalDiskProbeThreshold,
+		ExperimentalDiskProbeFailures:                   cfg.ExperimentalDiskProbeFailures,
+		ExperimentalDiskProbeSelfFence:                  cfg.ExperimentalDiskProbeSelfFence,
+		ExperimentalMaintenanceWindows:                  cfg.ExperimentalMaintenanceWindows,
+		ExperimentalMaintenanceDefragThresholdMegabytes: cfg.ExperimentalMaintenanceDefragThresholdMegabytes,
+		LifecycleHooks:                                  cfg.LifecycleHooks,
+		Admission:                                       v3admission.Chain(admissionControllers...),
 	}
 
 	if srvcfg.ExperimentalEnableDistributedTracing {
@@ -240,6 +332,7 @@ func StartEtcd(inCfg *Config) (e *Etcd, err error) {
 			tracingExporter.Close(tctx)
 		}
 		srvcfg.ExperimentalTracerOptions = tracingExporter.opts
+		srvcfg.ExperimentalDistributedTracingSlowRequestThreshold = cfg.ExperimentalDistributedTracingSlowRequestThreshold
 
 		e.cfg.logger.Info(
 			"distributed tracing setup enabled",
@@ -258,8 +351,9 @@ func StartEtcd(inCfg *Config) (e *Etcd, err error) {
 	e.errc = make(chan error, len(e.Peers)+len(e.Clients)+2*len(e.sctxs))
 
 	// newly started member ("memberInitialized==false")
-	// does not need corruption check
-	if memberInitialized && srvcfg.InitialCorruptCheck {
+	// does not need corruption check, nor does a witness
+	// which has no key-value store to check
+	if memberInitialized && srvcfg.InitialCorruptCheck && !srvcfg.ExperimentalWitness {
 		if err = e.Server.CorruptionChecker().InitialCheck(); err != nil {
 			// set "EtcdServer" to nil, so that it does not block on "EtcdServer.Close()"
 			// (nothing to close since rafthttp transports have not been started)
@@ -349,12 +443,19 @@ func print(lg *zap.Logger, ec Config, sc config.ServerConfig, memberInitialized
 		zap.Int64("quota-backend-bytes", quota),
 		zap.Uint("max-request-bytes", sc.MaxRequestBytes),
 		zap.Uint32("max-concurrent-streams", sc.MaxConcurrentStreams),
+		zap.Uint("grpc-max-recv-bytes", sc.GRPCMaxRecvBytes),
+		zap.Uint("grpc-max-send-bytes", sc.GRPCMaxSendBytes),
+		zap.Duration("peer-keepalive-interval", ec.PeerKeepAliveInterval),
+		zap.Duration("peer-idle-timeout", ec.PeerIdleTimeout),
 
 		zap.Bool("pre-vote", sc.PreVote),
 		zap.Bool("initial-corrupt-check", sc.InitialCorruptCheck),
 		zap.String("corrupt-check-time-interval", sc.CorruptCheckTime.String()),
 		zap.Bool("compact-check-time-enabled", sc.CompactHashCheckEnabled),
 		zap.Duration("compact-check-time-interval", sc.CompactHashCheckTime),
+		zap.Duration("corrupt-range-check-time-interval", sc.CorruptRangeCheckTime),
+		zap.String("admission-webhook-url", ec.ExperimentalAdmissionWebhookURL),
+		zap.String("admission-webhook-ca-file", ec.ExperimentalAdmissionWebhookCAFile),
 		zap.String("auto-compaction-mode", sc.AutoCompactionMode),
 		zap.Duration("auto-compaction-retention", sc.AutoCompactionRetention),
 		zap.String("auto-compaction-interval", sc.AutoCompactionRetention.String()),
@@ -447,6 +548,10 @@ func (e *Etcd) Close() {
 		e.Server.Stop()
 	}
 
+	if e.admissionWebhook != nil {
+		e.admissionWebhook.Close()
+	}
+
 	// close all idle connections in peer handler (wait up to 1-second)
 	for i := range e.Peers {
 		if e.Peers[i] != nil && e.Peers[i].close != nil {
@@ -552,6 +657,7 @@ func configurePeerListeners(cfg *Config) (peers []*peerListener, err error) {
 			transport.WithTLSInfo(&cfg.PeerTLSInfo),
 			transport.WithSocketOpts(&cfg.SocketOpts),
 			transport.WithTimeout(rafthttp.ConnReadTimeout, rafthttp.ConnWriteTimeout),
+			transport.WithKeepAlivePeriod(cfg.PeerKeepAliveInterval),
 		)
 		if err != nil {
 			cfg.logger.Error("creating peer listener failed", zap.Error(err))
@@ -568,6 +674,14 @@ func configurePeerListeners(cfg *Config) (peers []*peerListener, err error) {
 // configure peer handlers after rafthttp.Transport started
 func (e *Etcd) servePeers() {
 	ph := etcdhttp.NewPeerHandler(e.GetLogger(), e.Server)
+	if len(e.cfg.PeerUserHandlers) > 0 {
+		mux := http.NewServeMux()
+		for path, h := range e.cfg.PeerUserHandlers {
+			mux.Handle(path, h)
+		}
+		mux.Handle("/", ph)
+		ph = mux
+	}
 
 	for _, p := range e.Peers {
 		u := p.Listener.Addr().String()
@@ -575,6 +689,7 @@ func (e *Etcd) servePeers() {
 		srv := &http.Server{
 			Handler:     ph,
 			ReadTimeout: 5 * time.Minute,
+			IdleTimeout: e.cfg.PeerIdleTimeout,
 			ErrorLog:    defaultLog.New(io.Discard, "", 0), // do not log user error
 		}
 		go srv.Serve(m.Match(cmux.Any()))
@@ -624,6 +739,10 @@ func configureClientListeners(cfg *Config) (sctxs map[string]*serveCtx, err erro
 		cfg.logger.Fatal("failed to get client self-signed certs", zap.Error(err))
 	}
 	updateMinMaxVersions(&cfg.ClientTLSInfo, cfg.TlsMinVersion, cfg.TlsMaxVersion)
+	clientIPLimitAllowlist, err := v3rpc.ParseClientIPAllowlist(cfg.ClientIPLimitAllowlist)
+	if err != nil {
+		return nil, err
+	}
 	if cfg.EnablePprof {
 		cfg.logger.Info("pprof is enabled", zap.String("path", debugutil.HTTPPrefixPProf))
 	}
@@ -694,6 +813,9 @@ func configureClientListeners(cfg *Config) (sctxs map[string]*serveCtx, err erro
 			}
 			sctx.l = transport.LimitListener(sctx.l, int(fdLimit-reservedInternalFDNum))
 		}
+		if cfg.MaxConnectionsPerClientIP > 0 {
+			sctx.l = v3rpc.LimitListenerPerClientIP(cfg.logger, sctx.l, int(cfg.MaxConnectionsPerClientIP), clientIPLimitAllowlist)
+		}
 
 		defer func(sctx *serveCtx) {
 			if err == nil || sctx.l == nil {
@@ -745,7 +867,7 @@ func (e *Etcd) serveClients() {
 	etcdhttp.HandleDebug(mux)
 	etcdhttp.HandleVersion(mux, e.Server)
 	etcdhttp.HandleMetrics(mux)
-	etcdhttp.HandleHealth(e.cfg.logger, mux, e.Server)
+	etcdhttp.HandleHealthWithChecks(e.cfg.logger, mux, e.Server, e.cfg.LivezChecks, e.cfg.ReadyzChecks)
 
 	var gopts []grpc.ServerOption
 	if e.cfg.GRPCKeepAliveMinTime > time.Duration(0) {
@@ -761,6 +883,13 @@ func (e *Etcd) serveClients() {
 			Timeout: e.cfg.GRPCKeepAliveTimeout,
 		}))
 	}
+	// interceptors accumulate, so these run after etcd's own interceptors
+	if len(e.cfg.GRPCUnaryInterceptors) > 0 {
+		gopts = append(gopts, grpc.ChainUnaryInterceptor(e.cfg.GRPCUnaryInterceptors...))
+	}
+	if len(e.cfg.GRPCStreamInterceptors) > 0 {
+		gopts = append(gopts, grpc.ChainStreamInterceptor(e.cfg.GRPCStreamInterceptors...))
+	}
 	gopts = append(gopts, e.cfg.GRPCAdditionalServerOptions...)
 
 	splitHTTP := false
@@ -849,7 +978,13 @@ func (e *Etcd) serveMetrics() (err error) {
 	if len(e.cfg.ListenMetricsUrls) > 0 {
 		metricsMux := http.NewServeMux()
 		etcdhttp.HandleMetrics(metricsMux)
-		etcdhttp.HandleHealth(e.cfg.logger, metricsMux, e.Server)
+		etcdhttp.HandleHealthWithChecks(e.cfg.logger, metricsMux, e.Server, e.cfg.LivezChecks, e.cfg.ReadyzChecks)
+		if e.cfg.ExperimentalInflightRequests {
+			etcdhttp.HandleInflight(metricsMux, e.Server)
+		}
+		for path, h := range e.cfg.MetricsUserHandlers {
+			metricsMux.Handle(path, h)
+		}
 
 		for _, murl := range e.cfg.ListenMetricsUrls {
 			ml, err := e.createMetricsListener(murl)
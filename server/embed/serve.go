@@ -32,6 +32,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/protobuf/encoding/protojson"
 
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	etcdservergw "go.etcd.io/etcd/api/v3/etcdserverpb/gw"
 	"go.etcd.io/etcd/client/pkg/v3/transport"
 	"go.etcd.io/etcd/pkg/v3/debugutil"
@@ -324,6 +325,9 @@ func (sctx *serveCtx) registerGateway(dial func(ctx context.Context) (*grpc.Clie
 			return nil, err
 		}
 	}
+	if err := gwmux.HandlePath(http.MethodGet, watchEventsPath, newWatchEventsHandler(sctx.lg, gwmux, pb.NewWatchClient(conn))); err != nil {
+		return nil, err
+	}
 	go func() {
 		<-ctx.Done()
 		if cerr := conn.Close(); cerr != nil {
@@ -0,0 +1,172 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embed
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	protov1 "github.com/golang/protobuf/proto"
+	gw "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+// watchEventsPath serves watches as server-sent events, so that browsers can
+// consume watch responses with EventSource instead of a bidirectional stream.
+//
+// The watch is created from the query parameters, which mirror the fields of
+// WatchCreateRequest; byte fields are base64 encoded as in the JSON API:
+//
+//	GET /v3/watch/events?key=Zm9v&range_end=Zm9w&start_revision=5&prev_kv=true
+//
+// Each watch response is sent as one event whose data is the JSON encoded
+// WatchResponse. Responses carrying events, except fragments, get the
+// revision of their last event as id. Reconnecting clients send the id back
+// in the Last-Event-ID header and the watch resumes from the following
+// revision.
+const watchEventsPath = "/v3/watch/events"
+
+func newWatchEventsHandler(lg *zap.Logger, mux *gw.ServeMux, wc pb.WatchClient) gw.HandlerFunc {
+	marshaler := protojson.MarshalOptions{UseProtoNames: true}
+	return func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		creq, err := parseWatchEventsRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// forward the authorization header and other metadata like the gateway does
+		ctx, err := gw.AnnotateContext(r.Context(), mux, r, "/etcdserverpb.Watch/Watch", gw.WithHTTPPathPattern(watchEventsPath))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		stream, err := wc.Watch(ctx)
+		if err == nil {
+			err = stream.Send(&pb.WatchRequest{RequestUnion: &pb.WatchRequest_CreateRequest{CreateRequest: creq}})
+		}
+		if err != nil {
+			http.Error(w, status.Convert(err).Message(), gw.HTTPStatusFromCode(status.Code(err)))
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				if ctx.Err() == nil {
+					fmt.Fprintf(w, "event: error\ndata: %q\n\n", status.Convert(err).Message())
+					flusher.Flush()
+				}
+				return
+			}
+			data, err := marshaler.Marshal(protov1.MessageV2(resp))
+			if err != nil {
+				lg.Warn("failed to marshal watch response", zap.Error(err))
+				return
+			}
+			if id, ok := watchEventID(resp); ok {
+				fmt.Fprintf(w, "id: %d\n", id)
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flusher.Flush()
+			if resp.Canceled {
+				return
+			}
+		}
+	}
+}
+
+// watchEventID returns the id of the event sending the watch response, the
+// revision of its last event. The header revision cannot be used: responses
+// catching up on unsynced watchers are batched under the current revision,
+// and fragments split a revision across responses. Responses without events
+// and fragments get no id, so that a reconnecting client resumes after the
+// last complete response.
+func watchEventID(resp *pb.WatchResponse) (int64, bool) {
+	if resp.Fragment || len(resp.Events) == 0 {
+		return 0, false
+	}
+	kv := resp.Events[len(resp.Events)-1].Kv
+	if kv == nil {
+		return 0, false
+	}
+	return kv.ModRevision, true
+}
+
+func parseWatchEventsRequest(r *http.Request) (*pb.WatchCreateRequest, error) {
+	q := r.URL.Query()
+	creq := &pb.WatchCreateRequest{}
+	var err error
+	if creq.Key, err = base64.StdEncoding.DecodeString(q.Get("key")); err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+	if len(creq.Key) == 0 {
+		return nil, fmt.Errorf("key is required")
+	}
+	if creq.RangeEnd, err = base64.StdEncoding.DecodeString(q.Get("range_end")); err != nil {
+		return nil, fmt.Errorf("invalid range_end: %w", err)
+	}
+	if v := q.Get("start_revision"); v != "" {
+		if creq.StartRevision, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid start_revision: %w", err)
+		}
+	}
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		rev, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Last-Event-ID: %w", err)
+		}
+		creq.StartRevision = rev + 1
+	}
+	for param, dst := range map[string]*bool{
+		"prev_kv":         &creq.PrevKv,
+		"progress_notify": &creq.ProgressNotify,
+		"fragment":        &creq.Fragment,
+	} {
+		if v := q.Get(param); v != "" {
+			if *dst, err = strconv.ParseBool(v); err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", param, err)
+			}
+		}
+	}
+	for _, f := range q["filters"] {
+		ft, ok := pb.WatchCreateRequest_FilterType_value[f]
+		if !ok {
+			return nil, fmt.Errorf("invalid filter %q", f)
+		}
+		creq.Filters = append(creq.Filters, pb.WatchCreateRequest_FilterType(ft))
+	}
+	return creq, nil
+}
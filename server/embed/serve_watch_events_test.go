@@ -0,0 +1,103 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embed
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gw "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+// TestWatchEventsResumeAfterCatchUp ensures that a client reconnecting after
+// a batched catch-up response resumes after the last event it received, not
+// after the current revision of the header.
+func TestWatchEventsResumeAfterCatchUp(t *testing.T) {
+	header := &pb.ResponseHeader{Revision: 10}
+	event := func(rev int64) *mvccpb.Event {
+		return &mvccpb.Event{Kv: &mvccpb.KeyValue{Key: []byte("foo"), ModRevision: rev}}
+	}
+	wc := &fakeWatchClient{resps: []*pb.WatchResponse{
+		{Header: header, Created: true},
+		// catch-up batch capped before the current revision
+		{Header: header, Events: []*mvccpb.Event{event(2), event(3)}},
+		{Header: header, Events: []*mvccpb.Event{event(4)}, Fragment: true},
+		{Header: header},
+	}}
+	h := newWatchEventsHandler(zaptest.NewLogger(t), gw.NewServeMux(), wc)
+
+	serve := func(lastEventID string) string {
+		req := httptest.NewRequest(http.MethodGet, watchEventsPath+"?key=Zm9v&start_revision=2", nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		rec := httptest.NewRecorder()
+		h(rec, req, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		return rec.Body.String()
+	}
+
+	var ids []string
+	for _, line := range strings.Split(serve(""), "\n") {
+		if id, ok := strings.CutPrefix(line, "id: "); ok {
+			ids = append(ids, id)
+		}
+	}
+	require.Equal(t, []string{"3"}, ids)
+	require.Equal(t, int64(2), wc.creq.StartRevision)
+
+	serve(ids[len(ids)-1])
+	assert.Equal(t, int64(4), wc.creq.StartRevision)
+}
+
+type fakeWatchClient struct {
+	resps []*pb.WatchResponse
+	creq  *pb.WatchCreateRequest
+}
+
+func (c *fakeWatchClient) Watch(ctx context.Context, _ ...grpc.CallOption) (pb.Watch_WatchClient, error) {
+	return &fakeWatchStream{c: c, resps: c.resps}, nil
+}
+
+type fakeWatchStream struct {
+	grpc.ClientStream
+	c     *fakeWatchClient
+	resps []*pb.WatchResponse
+}
+
+func (s *fakeWatchStream) Send(req *pb.WatchRequest) error {
+	s.c.creq = req.GetCreateRequest()
+	return nil
+}
+
+func (s *fakeWatchStream) Recv() (*pb.WatchResponse, error) {
+	if len(s.resps) == 0 {
+		return nil, errors.New("connection lost")
+	}
+	resp := s.resps[0]
+	s.resps = s.resps[1:]
+	return resp, nil
+}
@@ -22,6 +22,8 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -217,10 +219,52 @@ func (cfg *config) configFromCmdLine() error {
 	cfg.ec.PeerTLSInfo.AllowedCNs = flags.StringsFromFlag(cfg.cf.flagSet, "peer-cert-allowed-cn")
 	cfg.ec.PeerTLSInfo.AllowedHostnames = flags.StringsFromFlag(cfg.cf.flagSet, "peer-cert-allowed-hostname")
 
+	cfg.ec.ExperimentalTrustedProxyCNs = flags.StringsFromFlag(cfg.cf.flagSet, "experimental-trusted-proxy-cn")
+
 	cfg.ec.CipherSuites = flags.StringsFromFlag(cfg.cf.flagSet, "cipher-suites")
 
 	cfg.ec.MaxConcurrentStreams = flags.Uint32FromFlag(cfg.cf.flagSet, "max-concurrent-streams")
 
+	for _, s := range flags.StringsFromFlag(cfg.cf.flagSet, "experimental-quota-backend-warning-ratios") {
+		r, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid --experimental-quota-backend-warning-ratios %q: %w", s, err)
+		}
+		cfg.ec.ExperimentalQuotaBackendWarningRatios = append(cfg.ec.ExperimentalQuotaBackendWarningRatios, r)
+	}
+
+	for _, s := range flags.StringsFromFlag(cfg.cf.flagSet, "experimental-leader-priorities") {
+		name, p, ok := strings.Cut(s, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("invalid --experimental-leader-priorities %q, expecting '<member name>=<priority>'", s)
+		}
+		priority, err := strconv.Atoi(p)
+		if err != nil {
+			return fmt.Errorf("invalid --experimental-leader-priorities %q: %w", s, err)
+		}
+		if cfg.ec.ExperimentalLeaderPriorities == nil {
+			cfg.ec.ExperimentalLeaderPriorities = make(map[string]int)
+		}
+		cfg.ec.ExperimentalLeaderPriorities[name] = priority
+	}
+
+	for _, s := range flags.StringsFromFlag(cfg.cf.flagSet, "experimental-protected-prefixes") {
+		prefix, role, ok := strings.Cut(s, "=")
+		if !ok || prefix == "" || role == "" {
+			return fmt.Errorf("invalid --experimental-protected-prefixes %q, expecting '<prefix>=<role>'", s)
+		}
+		if cfg.ec.ExperimentalProtectedPrefixes == nil {
+			cfg.ec.ExperimentalProtectedPrefixes = make(map[string][]string)
+		}
+		cfg.ec.ExperimentalProtectedPrefixes[prefix] = append(cfg.ec.ExperimentalProtectedPrefixes[prefix], role)
+	}
+
+	cfg.ec.ExperimentalChangeWebhooks = flags.StringsFromFlag(cfg.cf.flagSet, "experimental-change-webhooks")
+	cfg.ec.ExperimentalKeyspaceMetricsPrefixes = flags.StringsFromFlag(cfg.cf.flagSet, "experimental-keyspace-metrics-prefixes")
+	cfg.ec.ClientIPLimitAllowlist = flags.StringsFromFlag(cfg.cf.flagSet, "client-ip-limit-allowlist")
+	cfg.ec.ExperimentalPrincipalMetricsAllowlist = flags.StringsFromFlag(cfg.cf.flagSet, "experimental-principal-metrics-allowlist")
+	cfg.ec.ExperimentalSoftDeletePrefixes = flags.StringsFromFlag(cfg.cf.flagSet, "experimental-soft-delete-prefixes")
+
 	cfg.ec.LogOutputs = flags.UniqueStringsFromFlag(cfg.cf.flagSet, "log-outputs")
 
 	cfg.ec.ClusterState = cfg.cf.clusterState.String()
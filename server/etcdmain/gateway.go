@@ -19,6 +19,8 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -36,6 +38,12 @@ var (
 	gatewayInsecureDiscovery     bool
 	gatewayRetryDelay            time.Duration
 	gatewayCA                    string
+
+	gatewayHealthCheckInterval time.Duration
+	gatewayHealthCheckTimeout  time.Duration
+	gatewayHealthyThreshold    int
+	gatewayUnhealthyThreshold  int
+	gatewaySNIRoutes           []string
 )
 
 var (
@@ -78,6 +86,12 @@ func newGatewayStartCommand() *cobra.Command {
 
 	cmd.Flags().DurationVar(&gatewayRetryDelay, "retry-delay", time.Minute, "duration of delay before retrying failed endpoints")
 
+	cmd.Flags().DurationVar(&gatewayHealthCheckInterval, "health-check-interval", 0, "interval of active endpoint health checks; 0 disables active health checking")
+	cmd.Flags().DurationVar(&gatewayHealthCheckTimeout, "health-check-timeout", 5*time.Second, "timeout of a single endpoint health check")
+	cmd.Flags().IntVar(&gatewayHealthyThreshold, "healthy-threshold", 1, "number of consecutive successful health checks before an ejected endpoint is readmitted")
+	cmd.Flags().IntVar(&gatewayUnhealthyThreshold, "unhealthy-threshold", 3, "number of consecutive failed health checks before an endpoint is ejected")
+	cmd.Flags().StringArrayVar(&gatewaySNIRoutes, "sni-route", nil, "route TLS connections by server name, in the form 'server-name=host:port[,host:port...]'; may be repeated")
+
 	return &cmd
 }
 
@@ -92,6 +106,29 @@ func stripSchema(eps []string) []string {
 	return endpoints
 }
 
+// parseSNIRoutes parses --sni-route values into endpoints keyed by server name.
+func parseSNIRoutes(routes []string) (map[string][]*net.SRV, error) {
+	sniRoutes := make(map[string][]*net.SRV, len(routes))
+	for _, route := range routes {
+		name, eps, ok := strings.Cut(route, "=")
+		if !ok || name == "" || eps == "" {
+			return nil, fmt.Errorf("invalid sni route %q, expected 'server-name=host:port[,host:port...]'", route)
+		}
+		for _, ep := range stripSchema(strings.Split(eps, ",")) {
+			h, p, err := net.SplitHostPort(ep)
+			if err != nil {
+				return nil, fmt.Errorf("invalid endpoint %q in sni route %q: %w", ep, route, err)
+			}
+			port, err := strconv.ParseUint(p, 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf("invalid port in endpoint %q in sni route %q: %w", ep, route, err)
+			}
+			sniRoutes[name] = append(sniRoutes[name], &net.SRV{Target: h, Port: uint16(port)})
+		}
+	}
+	return sniRoutes, nil
+}
+
 func startGateway(cmd *cobra.Command, args []string) {
 	lg, err := logutil.CreateDefaultZapLogger(zap.InfoLevel)
 	if err != nil {
@@ -162,6 +199,16 @@ func startGateway(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	sniRoutes, err := parseSNIRoutes(gatewaySNIRoutes)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if gatewayHealthCheckInterval < 0 || gatewayHealthyThreshold < 1 || gatewayUnhealthyThreshold < 1 {
+		fmt.Fprintln(os.Stderr, "--health-check-interval must not be negative and --healthy-threshold and --unhealthy-threshold must be positive")
+		os.Exit(1)
+	}
+
 	var l net.Listener
 	l, err = net.Listen("tcp", gatewayListenAddr)
 	if err != nil {
@@ -174,6 +221,13 @@ func startGateway(cmd *cobra.Command, args []string) {
 		Listener:        l,
 		Endpoints:       srvs.SRVs,
 		MonitorInterval: gatewayRetryDelay,
+
+		SNIRoutes: sniRoutes,
+
+		HealthCheckInterval: gatewayHealthCheckInterval,
+		HealthCheckTimeout:  gatewayHealthCheckTimeout,
+		HealthyThreshold:    gatewayHealthyThreshold,
+		UnhealthyThreshold:  gatewayUnhealthyThreshold,
 	}
 
 	// At this point, etcd gateway listener is initialized
@@ -27,6 +27,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	grpc_zap "github.com/grpc-ecosystem/go-grpc-middleware/logging/zap"
@@ -54,6 +55,7 @@ import (
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3election/v3electionpb"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3lock/v3lockpb"
 	"go.etcd.io/etcd/server/v3/proxy/grpcproxy"
+	"go.etcd.io/etcd/server/v3/proxy/grpcproxy/cache"
 )
 
 var (
@@ -92,13 +94,24 @@ var (
 	grpcProxyResolverPrefix     string
 	grpcProxyResolverTTL        int
 
-	grpcProxyNamespace string
-	grpcProxyLeasing   string
+	grpcProxyNamespace      string
+	grpcProxyLeasing        string
+	grpcProxyLeasingMaxKeys int
 
 	grpcProxyEnablePprof    bool
 	grpcProxyEnableOrdering bool
 	grpcProxyEnableLogging  bool
 
+	grpcProxyForwardClientIdentity bool
+
+	grpcProxyCacheMaxEntries int
+	grpcProxyCacheTTL        time.Duration
+	grpcProxyCachePrefixes   []string
+
+	grpcProxyReadWriteSplit bool
+	grpcProxyZone           string
+	grpcProxyMemberZones    []string
+
 	grpcProxyDebug bool
 
 	// GRPC keep alive related options.
@@ -147,6 +160,8 @@ func newGRPCProxyStartCommand() *cobra.Command {
 	cmd.Flags().StringVar(&grpcProxyResolverPrefix, "resolver-prefix", "", "prefix to use for registering proxy (must be shared with other grpc-proxy members)")
 	cmd.Flags().IntVar(&grpcProxyResolverTTL, "resolver-ttl", 0, "specify TTL, in seconds, when registering proxy endpoints")
 	cmd.Flags().StringVar(&grpcProxyNamespace, "namespace", "", "string to prefix to all keys for namespacing requests")
+	cmd.Flags().StringVar(&grpcProxyLeasing, "leasing-prefix", "", "leasing metadata prefix for disconnected linearized reads.")
+	cmd.Flags().IntVar(&grpcProxyLeasingMaxKeys, "leasing-max-keys", leasing.DefaultMaxKeys, "Maximum number of keys cached by leasing (negative for no limit).")
 	cmd.Flags().BoolVar(&grpcProxyEnablePprof, "enable-pprof", false, `Enable runtime profiling data via HTTP server. Address is at client URL + "/debug/pprof/"`)
 	cmd.Flags().StringVar(&grpcProxyDataDir, "data-dir", "default.proxy", "Data directory for persistent data")
 	cmd.Flags().IntVar(&grpcMaxCallSendMsgSize, "max-send-bytes", defaultGRPCMaxCallSendMsgSize, "message send limits in bytes (default value is 1.5 MiB)")
@@ -173,7 +188,15 @@ func newGRPCProxyStartCommand() *cobra.Command {
 	// experimental flags
 	cmd.Flags().BoolVar(&grpcProxyEnableOrdering, "experimental-serializable-ordering", false, "Ensure serializable reads have monotonically increasing store revisions across endpoints.")
 	cmd.Flags().StringVar(&grpcProxyLeasing, "experimental-leasing-prefix", "", "leasing metadata prefix for disconnected linearized reads.")
+	cmd.Flags().MarkDeprecated("experimental-leasing-prefix", "use --leasing-prefix instead")
 	cmd.Flags().BoolVar(&grpcProxyEnableLogging, "experimental-enable-grpc-logging", false, "logging all grpc requests and responses")
+	cmd.Flags().BoolVar(&grpcProxyForwardClientIdentity, "experimental-forward-client-identity", false, "Forward the client certificate CN to etcd, which must trust the proxy CN with --experimental-trusted-proxy-cn.")
+	cmd.Flags().IntVar(&grpcProxyCacheMaxEntries, "experimental-cache-max-entries", cache.DefaultMaxEntries, "Maximum number of range responses cached by the proxy.")
+	cmd.Flags().DurationVar(&grpcProxyCacheTTL, "experimental-cache-ttl", 0, "Maximum age of a cached range response served by the proxy (0 to disable expiry).")
+	cmd.Flags().StringSliceVar(&grpcProxyCachePrefixes, "experimental-cache-prefixes", nil, "Comma-separated list of key prefixes whose ranges are cached by the proxy (empty caches all ranges).")
+	cmd.Flags().BoolVar(&grpcProxyReadWriteSplit, "experimental-read-write-split", false, "Route linearizable requests to the leader, and serializable reads and watches to the nearest follower.")
+	cmd.Flags().StringVar(&grpcProxyZone, "experimental-zone", "", "Zone of the proxy. With experimental-read-write-split, serializable reads and watches are routed to followers in this zone if any.")
+	cmd.Flags().StringSliceVar(&grpcProxyMemberZones, "experimental-member-zones", nil, "Comma-separated list of '<member name>=<zone>' giving the zones of the members. Members not listed are in the zone given by their 'zone' label.")
 
 	cmd.Flags().BoolVar(&grpcProxyDebug, "debug", false, "Enable debug-level logging for grpc-proxy.")
 
@@ -294,6 +317,35 @@ func checkArgs() {
 		fmt.Fprintln(os.Stderr, fmt.Errorf("selfSignedCertValidity is invalid,it should be greater than 0"))
 		os.Exit(1)
 	}
+	if grpcProxyForwardClientIdentity && grpcProxyListenCA == "" {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("experimental-forward-client-identity requires trusted-ca-file to verify client certificates"))
+		os.Exit(1)
+	}
+	if grpcProxyCacheMaxEntries < 1 {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("invalid experimental-cache-max-entries %d", grpcProxyCacheMaxEntries))
+		os.Exit(1)
+	}
+	if grpcProxyCacheTTL < 0 {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("invalid experimental-cache-ttl %v", grpcProxyCacheTTL))
+		os.Exit(1)
+	}
+	if _, err := parseMemberZones(grpcProxyMemberZones); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// parseMemberZones parses a list of '<member name>=<zone>'.
+func parseMemberZones(zones []string) (map[string]string, error) {
+	m := make(map[string]string, len(zones))
+	for _, z := range zones {
+		name, zone, ok := strings.Cut(z, "=")
+		if !ok || name == "" || zone == "" {
+			return nil, fmt.Errorf("invalid experimental-member-zones %q", z)
+		}
+		m[name] = zone
+	}
+	return m, nil
 }
 
 func mustNewClient(lg *zap.Logger) *clientv3.Client {
@@ -302,16 +354,11 @@ func mustNewClient(lg *zap.Logger) *clientv3.Client {
 	if len(eps) == 0 {
 		eps = grpcProxyEndpoints
 	}
-	cfg, err := newClientCfg(lg, eps)
+	cfg, err := newProxiedClientCfg(lg, eps)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
-	cfg.DialOptions = append(cfg.DialOptions,
-		grpc.WithUnaryInterceptor(grpcproxy.AuthUnaryClientInterceptor))
-	cfg.DialOptions = append(cfg.DialOptions,
-		grpc.WithStreamInterceptor(grpcproxy.AuthStreamClientInterceptor))
-	cfg.Logger = lg.Named("client")
 	client, err := clientv3.New(*cfg)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -320,6 +367,26 @@ func mustNewClient(lg *zap.Logger) *clientv3.Client {
 	return client
 }
 
+// newProxiedClientCfg returns the config of the clients sending the proxied
+// requests to the etcd servers.
+func newProxiedClientCfg(lg *zap.Logger, eps []string) (*clientv3.Config, error) {
+	cfg, err := newClientCfg(lg, eps)
+	if err != nil {
+		return nil, err
+	}
+	cfg.DialOptions = append(cfg.DialOptions,
+		grpc.WithUnaryInterceptor(grpcproxy.AuthUnaryClientInterceptor))
+	cfg.DialOptions = append(cfg.DialOptions,
+		grpc.WithStreamInterceptor(grpcproxy.AuthStreamClientInterceptor))
+	if grpcProxyForwardClientIdentity {
+		cfg.DialOptions = append(cfg.DialOptions,
+			grpc.WithChainUnaryInterceptor(grpcproxy.ForwardIdentityUnaryClientInterceptor),
+			grpc.WithChainStreamInterceptor(grpcproxy.ForwardIdentityStreamClientInterceptor))
+	}
+	cfg.Logger = lg.Named("client")
+	return cfg, nil
+}
+
 func mustNewProxyClient(lg *zap.Logger, tls *transport.TLSInfo) *clientv3.Client {
 	eps := []string{grpcProxyAdvertiseClientURL}
 	cfg, err := newProxyClientCfg(lg.Named("client"), eps, tls)
@@ -423,6 +490,25 @@ func mustListenCMux(lg *zap.Logger, tlsinfo *transport.TLSInfo) cmux.CMux {
 }
 
 func newGRPCProxyServer(lg *zap.Logger, client *clientv3.Client) *grpc.Server {
+	if grpcProxyReadWriteSplit {
+		memberZones, _ := parseMemberZones(grpcProxyMemberZones)
+		split := grpcproxy.NewSplit(client.Ctx(), lg, client, grpcproxy.SplitConfig{
+			Zone:        grpcProxyZone,
+			MemberZones: memberZones,
+			NewClient: func(eps []string) (*clientv3.Client, error) {
+				cfg, err := newProxiedClientCfg(lg, eps)
+				if err != nil {
+					return nil, err
+				}
+				// stay connected to the member
+				cfg.AutoSyncInterval = 0
+				return clientv3.New(*cfg)
+			},
+		})
+		client.KV = split.KV()
+		client.Watcher = split.Watcher()
+	}
+
 	if grpcProxyEnableOrdering {
 		vf := ordering.NewOrderViolationSwitchEndpointClosure(client)
 		client.KV = ordering.NewKV(client.KV, vf)
@@ -444,10 +530,14 @@ func newGRPCProxyServer(lg *zap.Logger, client *clientv3.Client) *grpc.Server {
 	}
 
 	if len(grpcProxyLeasing) > 0 {
-		client.KV, _, _ = leasing.NewKV(client, grpcProxyLeasing)
+		client.KV, _, _ = leasing.New(client, leasing.Config{Prefix: grpcProxyLeasing, MaxKeys: grpcProxyLeasingMaxKeys})
 	}
 
-	kvp, _ := grpcproxy.NewKvProxy(client)
+	kvp, _ := grpcproxy.NewKvProxyWithCacheConfig(client, grpcproxy.KvCacheConfig{
+		MaxEntries: grpcProxyCacheMaxEntries,
+		TTL:        grpcProxyCacheTTL,
+		Prefixes:   grpcProxyCachePrefixes,
+	})
 	watchp, _ := grpcproxy.NewWatchProxy(client.Ctx(), lg, client)
 	if grpcProxyResolverPrefix != "" {
 		grpcproxy.Register(lg, client, grpcProxyResolverPrefix, grpcProxyAdvertiseClientURL, grpcProxyResolverTTL)
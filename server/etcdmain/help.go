@@ -87,12 +87,26 @@ Member:
     Maximum client request size in bytes the server will accept.
   --max-concurrent-streams 'math.MaxUint32'
     Maximum concurrent streams that each client can open at a time.
+  --max-connections-per-client-ip '0'
+    Maximum concurrent client connections accepted from a single IP. 0 means unlimited.
+  --max-streams-per-client-ip '0'
+    Maximum concurrent gRPC streams that can be opened from a single client IP. 0 means unlimited.
+  --client-ip-limit-allowlist ''
+    Comma-separated list of IPs and CIDR ranges, e.g. of proxies, exempted from the per client IP connection and stream limits.
   --grpc-keepalive-min-time '5s'
     Minimum duration interval that a client should wait before pinging server.
   --grpc-keepalive-interval '2h'
     Frequency duration of server-to-client ping to check if a connection is alive (0 to disable).
   --grpc-keepalive-timeout '20s'
     Additional duration of wait before closing a non-responsive connection (0 to disable).
+  --grpc-max-recv-bytes '0'
+    Maximum gRPC message size in bytes the server receives from clients (0 defaults to --max-request-bytes plus the gRPC overhead).
+  --grpc-max-send-bytes '0'
+    Maximum gRPC message size in bytes the server sends to clients (0 defaults to math.MaxInt32).
+  --peer-keepalive-interval '30s'
+    TCP keepalive period of the peer connections.
+  --peer-idle-timeout '0s'
+    Maximum amount of time an idle peer connection is kept open (0 to use the peer read timeout).
   --socket-reuse-port 'false'
     Enable to set socket option SO_REUSEPORT on listeners allowing rebinding of a port already in use.
   --socket-reuse-address 'false'
@@ -158,6 +172,14 @@ Clustering:
     Suffix to the dns srv name queried when bootstrapping.
   --strict-reconfig-check '` + strconv.FormatBool(embed.DefaultStrictReconfigCheck) + `'
     Reject reconfiguration requests that would cause quorum loss.
+  --experimental-auto-promote-learner 'false'
+    Automatically promote learners once they have stayed caught up with the leader for the stabilization window. Read replicas are never promoted.
+  --experimental-auto-promote-learner-max-lag '1000'
+    Maximum number of raft entries a learner may lag behind the leader to be considered caught up.
+  --experimental-auto-promote-learner-stabilization-window '30s'
+    Duration a learner must continuously stay caught up before it is automatically promoted.
+  --experimental-leader-priorities ''
+    Comma-separated list of '<member name>=<priority>' leader priorities, the same on all members. The leader transfers the leadership to the healthy member with the highest priority. Unlisted members have priority 1, 0 means never lead.
   --pre-vote 'true'
     Enable the raft Pre-Vote algorithm to prevent disruption when a node that has been partitioned away rejoins the cluster.
   --auto-compaction-retention '0'
@@ -255,6 +277,8 @@ Logging:
     Configures log rotation if enabled with a JSON logger config. MaxSize(MB), MaxAge(days,0=no limit), MaxBackups(0=no limit), LocalTime(use computers local time), Compress(gzip)".
   --warning-unary-request-duration '300ms'
     Set time duration after which a warning is logged if a unary request takes more than this duration.
+  --slow-request-threshold '0s'
+    Time duration above which unary requests are logged with their method, keys, principal, queue wait and execution time, and raft term. Disabled by default.
 
 Experimental distributed tracing:
   --experimental-enable-distributed-tracing 'false'
@@ -267,6 +291,8 @@ Experimental distributed tracing:
     Distributed tracing instance ID, must be unique per each etcd instance.
   --experimental-distributed-tracing-sampling-rate '0'
     Number of samples to collect per million spans for distributed tracing. Disabled by default.
+  --experimental-distributed-tracing-slow-request-threshold '0s'
+    Duration above which the propose, commit wait and apply spans of a request are traced although its gRPC span was not sampled. Disabled by default.
 
 Experimental feature:
   --experimental-initial-corrupt-check 'false'
@@ -277,10 +303,18 @@ Experimental feature:
     Enable leader to periodically check followers compaction hashes.
   --experimental-compact-hash-check-time '1m'
     Duration of time between leader checks followers compaction hashes.
+  --experimental-corrupt-range-check-time '0s'
+    Duration of time between leader compares ranged hashes with followers and quarantines diverging members. 0 disables it.
+  --experimental-corrupt-range-check-keys 10000
+    Number of keys hashed by each ranged hash comparison.
   --experimental-enable-lease-checkpoint 'false'
     ExperimentalEnableLeaseCheckpoint enables primary lessor to persist lease remainingTTL to prevent indefinite auto-renewal of long lived leases.
   --experimental-compaction-batch-limit 1000
     ExperimentalCompactionBatchLimit sets the maximum revisions deleted in each compaction batch.
+  --experimental-compaction-pause-target '0s'
+    Target time each compaction batch holds up writes. If set, the compaction batch size and sleep interval adapt to the observed pauses.
+  --experimental-compaction-max-sleep-interval '1s'
+    Maximum sleep interval between compaction batches when pacing them to the compaction pause target.
   --experimental-peer-skip-client-san-verification 'false'
     Skip verification of SAN field in client certificate for peer connections.
   --experimental-watch-progress-notify-interval '10m'
@@ -295,6 +329,78 @@ Experimental feature:
     Set time duration after which a warning is generated if a unary request takes more than this duration. It's deprecated, and will be decommissioned in v3.7. Use --warning-unary-request-duration instead.
   --experimental-max-learners '1'
     Set the max number of learner members allowed in the cluster membership.
+  --experimental-learner-read-replica 'false'
+    Serve serializable reads and watches as a permanent read-only replica while the member is a learner. Read replicas are never promoted.
+  --experimental-witness 'false'
+    Run the member as a witness that votes in the raft quorum but neither stores the key-value store nor serves client requests. Start witnesses with an empty data directory.
+  --experimental-snapshot-send-rate-bytes '0'
+    Maximum bandwidth in bytes per second used to send snapshots to peers. 0 means no limit.
+  --experimental-snapshot-compression 'false'
+    Enable gzip compression of snapshots sent to peers. All members must support receiving compressed snapshots.
+  --experimental-differential-snapshot 'false'
+    Send followers that are only slightly behind the key revisions they miss instead of a full database snapshot, when feasible.
+  --experimental-wal-compression 'none'
+    Codec used to compress large WAL entries: 'none' or 'snappy'. A member that wrote compressed entries cannot be downgraded below v3.6.
+  --experimental-online-defrag 'false'
+    Defragment the backend without blocking writes, except while applying the writes made during the copy.
+  --experimental-quota-backend-warning-ratios ''
+    Comma-separated list of ratios of the backend quota, between 0 and 1, at which to log a warning when the backend size reaches them.
+  --experimental-auto-recover-nospace 'false'
+    Enable the leader to recover from NOSPACE alarms by compacting to the current revision, defragmenting members one at a time and disarming the alarms. Requires --peer-client-cert-auth.
+  --experimental-backend-engine ''
+    Storage engine of the backend: 'bbolt' or 'log'. An existing backend written by another engine is converted on startup. If empty, the existing backend's engine is kept.
+  --experimental-encryption-kek-file ''
+    Path to the base64 encoded 32-byte key encryption key used to encrypt key-value records at rest. All members must use the same key.
+  --experimental-change-webhooks ''
+    Comma-separated list of '<prefix>=<url>' HTTP endpoints to POST the changes to the keys under the prefix to, at least once.
+  --experimental-admission-webhook-url ''
+    HTTP or HTTPS URL of the gRPC admission webhook admitting or rejecting the write requests before they are proposed to raft. Its path, if any, prefixes the path of the gRPC method.
+  --experimental-admission-webhook-ca-file ''
+    Path to the CA certificate file verifying the certificate of an HTTPS admission webhook. The system roots are used if empty.
+  --experimental-admission-webhook-timeout '1s'
+    Timeout of a call to the admission webhook. The request is rejected when it expires.
+  --experimental-keyspace-metrics-prefixes ''
+    Comma-separated list of key prefixes for which to export the number of keys, the size of their values and the number of writes as metrics.
+  --experimental-principal-metrics 'false'
+    Enable client request metrics labeled by the authenticated user or client certificate CN.
+  --experimental-principal-metrics-max-principals '100'
+    Maximum number of principals given their own label in the principal metrics. The others are labeled 'other'.
+  --experimental-principal-metrics-allowlist ''
+    Comma-separated list of the only principals given their own label in the principal metrics. The others are labeled 'other'.
+  --experimental-inflight-requests 'false'
+    Enable tracking the requests being served and listing them at /debug/inflight on the metrics URLs. Requires --listen-metrics-urls.
+  --experimental-range-tombstone-threshold '0'
+    Number of keys above which a delete proposed by this member records a single range tombstone instead of a tombstone per key, once the cluster version is 3.6. 0 disables it.
+  --experimental-watch-slow-watcher-policy ''
+    Policy applied to the watchers that cannot keep up with the events once they lag more than --experimental-watch-slow-watcher-max-lag revisions behind: 'cancel', 'resync' (of the watchers allowing it, cancel otherwise) or 'block'. Empty lets them catch up.
+  --experimental-watch-slow-watcher-max-lag '0'
+    Number of revisions a slow watcher may lag behind before the slow watcher policy applies.
+  --experimental-watch-slow-watcher-block-timeout '100ms'
+    Maximum time a write blocks on the slow watchers under the 'block' slow watcher policy, delaying all the other writes meanwhile.
+  --experimental-hot-keys-sample-rate '0'
+    Fraction of the requests sampled to track the most frequently read and written keys, reported by 'etcdctl endpoint hot-keys'. 0 disables the tracking.
+  --experimental-soft-delete-threshold '0'
+    Number of keys above which a delete moves the keys to the trash instead of deleting them. 0 disables it. A delete applies the soft delete flags of the member it is sent to, once the cluster runs 3.6.
+  --experimental-soft-delete-prefixes ''
+    Comma-separated list of key prefixes whose keys are always moved to the trash when deleted.
+  --experimental-soft-delete-retention '24h'
+    Time the deleted keys are kept in the trash before being purged.
+  --experimental-soft-delete-trash-prefix '/etcd-trash/'
+    Prefix of the keys in the trash.
+  --experimental-protected-prefixes ''
+    Comma-separated list of '<prefix>=<role>' protected key prefixes, which only the listed roles can modify and never delete with a wider range. A prefix can be listed with several roles.
+  --experimental-disk-probe-interval '0s'
+    Interval between two probes of the latency of a write and fsync on the WAL device. 0 disables the probes.
+  --experimental-disk-probe-threshold '500ms'
+    Disk probe latency above which the disk is considered slow.
+  --experimental-disk-probe-failures '3'
+    Number of consecutive slow disk probes raising a SLOWDISK alarm, and of fast probes clearing it.
+  --experimental-disk-probe-self-fence 'false'
+    Drain the member, transferring its leadership away and failing its readiness checks, while its SLOWDISK alarm is raised.
+  --experimental-maintenance-windows ''
+    Semicolon-separated list of maintenance windows, each a cron schedule in UTC of its start followed by its duration, e.g. '0 2 * * 6 4h'. Auto-compaction and snapshots are deferred to the windows.
+  --experimental-maintenance-defrag-threshold-megabytes '0'
+    Defragment, from the leader and one member at a time, the backend of the members once per maintenance window if it would free at least the provided threshold of disk space. Requires --peer-client-cert-auth. 0 disables it.
   --experimental-snapshot-catch-up-entries '5000'
     Number of entries for a slow follower to catch up after compacting the raft storage entries.
   --experimental-compaction-sleep-interval
@@ -303,12 +409,18 @@ Experimental feature:
     Duration of time between two downgrade status checks.
   --experimental-enable-lease-checkpoint-persist 'false'
     Enable persisting remainingTTL to prevent indefinite auto-renewal of long lived leases. Always enabled in v3.6. Should be used to ensure smooth upgrade from v3.5 clusters with this feature enabled. Requires experimental-enable-lease-checkpoint to be enabled.
+  --experimental-lease-revoke-rate 1000
+    Maximum number of expired leases revoked per second by the leader.
+  --experimental-lease-pin-max-duration '1h'
+    Maximum duration a lease pinning a revision against compaction can be kept alive.
   --experimental-memory-mlock
     Enable to enforce etcd pages (in particular bbolt) to stay in RAM.
   --experimental-snapshot-catchup-entries
     Number of entries for a slow follower to catch up after compacting the raft storage entries.
   --experimental-stop-grpc-service-on-defrag
     Enable etcd gRPC service to stop serving client requests on defragmentation.
+  --experimental-trusted-proxy-cn ''
+    Comma-separated list of client certificate CNs of proxies allowed to forward the CN of their clients.
 
 Unsafe feature:
   --force-new-cluster 'false'
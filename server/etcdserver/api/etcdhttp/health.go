@@ -21,10 +21,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"path"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
@@ -44,31 +47,65 @@ const (
 	checkTypeLivez             = "livez"
 	checkTypeReadyz            = "readyz"
 	checkTypeHealth            = "health"
+
+	// maxApplyLag is the gap between the committed and applied index above
+	// which a member is considered behind on raft. It matches the gap at
+	// which the server starts rejecting new requests.
+	maxApplyLag = 5000
 )
 
 type ServerHealth interface {
 	Alarms() []*pb.AlarmMember
+	MemberID() types.ID
 	Leader() types.ID
 	Range(context.Context, *pb.RangeRequest) (*pb.RangeResponse, error)
 	Config() config.ServerConfig
 	AuthStore() auth.AuthStore
+	IsDraining() bool
+	AppliedIndex() uint64
+	CommittedIndex() uint64
 }
 
+// Names of the /health checks that can be excluded with the "exclude" query
+// parameter, in addition to the alarm names.
+const (
+	healthCheckDraining = "draining"
+	healthCheckLeader   = "leader"
+	healthCheckRead     = "read"
+)
+
 // HandleHealth registers metrics and health handlers. it checks health by using v3 range request
 // and its corresponding timeout.
 func HandleHealth(lg *zap.Logger, mux *http.ServeMux, srv ServerHealth) {
-	mux.Handle(PathHealth, NewHealthHandler(lg, func(ctx context.Context, excludedAlarms StringSet, serializable bool) Health {
-		if h := checkAlarms(lg, srv, excludedAlarms); h.Health != "true" {
-			return h
+	HandleHealthWithChecks(lg, mux, srv, nil, nil)
+}
+
+// HandleHealthWithChecks registers metrics and health handlers like HandleHealth,
+// and additionally registers the given custom checks under /livez and /readyz.
+// Custom checks whose name conflicts with a built-in check are ignored.
+func HandleHealthWithChecks(lg *zap.Logger, mux *http.ServeMux, srv ServerHealth, livezChecks, readyzChecks map[string]HealthCheck) {
+	mux.Handle(PathHealth, NewHealthHandler(lg, func(ctx context.Context, excluded StringSet, serializable bool) Health {
+		if _, found := excluded[healthCheckDraining]; !found {
+			if h := checkDraining(lg, srv); h.Health != "true" {
+				return h
+			}
 		}
-		if h := checkLeader(lg, srv, serializable); h.Health != "true" {
+		if h := checkAlarms(lg, srv, excluded); h.Health != "true" {
 			return h
 		}
+		if _, found := excluded[healthCheckLeader]; !found {
+			if h := checkLeader(lg, srv, serializable); h.Health != "true" {
+				return h
+			}
+		}
+		if _, found := excluded[healthCheckRead]; found {
+			return Health{Health: "true"}
+		}
 		return checkAPI(ctx, lg, srv, serializable)
 	}))
 
-	installLivezEndpoints(lg, mux, srv)
-	installReadyzEndpoints(lg, mux, srv)
+	installLivezEndpoints(lg, mux, srv, livezChecks)
+	installReadyzEndpoints(lg, mux, srv, readyzChecks)
 }
 
 // NewHealthHandler handles '/health' requests.
@@ -80,6 +117,8 @@ func NewHealthHandler(lg *zap.Logger, hfunc func(ctx context.Context, excludedAl
 			lg.Warn("/health error", zap.Int("status-code", http.StatusMethodNotAllowed))
 			return
 		}
+		// The "exclude" query parameter accepts alarm names as well as the
+		// names of the other checks, i.e. "draining", "leader" and "read".
 		excludedAlarms := getQuerySet(r, "exclude")
 		// Passing the query parameter "serializable=true" ensures that the
 		// health of the local etcd is checked vs the health of the cluster.
@@ -153,8 +192,17 @@ type Health struct {
 
 // HealthStatus is used in new /readyz or /livez health checks instead of the Health struct.
 type HealthStatus struct {
-	Reason string `json:"reason"`
-	Status string `json:"status"`
+	Reason string              `json:"reason"`
+	Status string              `json:"status"`
+	Checks []HealthCheckResult `json:"checks,omitempty"`
+}
+
+// HealthCheckResult is the outcome of a single /readyz or /livez check.
+type HealthCheckResult struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	Latency string `json:"latency"`
 }
 
 func getQuerySet(r *http.Request, query string) StringSet {
@@ -181,6 +229,16 @@ func checkAlarms(lg *zap.Logger, srv ServerHealth, excludedAlarms StringSet) Hea
 	h := Health{Health: "true"}
 
 	for _, v := range srv.Alarms() {
+		// A read-only cluster still serves reads and watches.
+		if v.Alarm == pb.AlarmType_READONLY {
+			continue
+		}
+		// A CORRUPT_RANGE alarm only quarantines the member it's raised for,
+		// and a SLOWDISK alarm only makes the member with the slow disk
+		// unhealthy.
+		if (v.Alarm == pb.AlarmType_CORRUPT_RANGE || v.Alarm == pb.AlarmType_SLOWDISK) && types.ID(v.MemberID) != srv.MemberID() {
+			continue
+		}
 		alarmName := v.Alarm.String()
 		if _, found := excludedAlarms[alarmName]; found {
 			lg.Debug("/health excluded alarm", zap.String("alarm", v.String()))
@@ -193,6 +251,10 @@ func checkAlarms(lg *zap.Logger, srv ServerHealth, excludedAlarms StringSet) Hea
 			h.Reason = "ALARM NOSPACE"
 		case pb.AlarmType_CORRUPT:
 			h.Reason = "ALARM CORRUPT"
+		case pb.AlarmType_SLOWDISK:
+			h.Reason = "ALARM SLOWDISK"
+		case pb.AlarmType_CORRUPT_RANGE:
+			h.Reason = "ALARM CORRUPT_RANGE"
 		default:
 			h.Reason = "ALARM UNKNOWN"
 		}
@@ -203,6 +265,16 @@ func checkAlarms(lg *zap.Logger, srv ServerHealth, excludedAlarms StringSet) Hea
 	return h
 }
 
+func checkDraining(lg *zap.Logger, srv ServerHealth) Health {
+	h := Health{Health: "true"}
+	if srv.IsDraining() {
+		h.Health = "false"
+		h.Reason = "DRAINING"
+		lg.Warn("serving /health false; member is draining")
+	}
+	return h
+}
+
 func checkLeader(lg *zap.Logger, srv ServerHealth, serializable bool) Health {
 	h := Health{Health: "true"}
 	if !serializable && (uint64(srv.Leader()) == raft.None) {
@@ -237,21 +309,28 @@ type CheckRegistry struct {
 	checks    map[string]HealthCheck
 }
 
-func installLivezEndpoints(lg *zap.Logger, mux *http.ServeMux, server ServerHealth) {
+func installLivezEndpoints(lg *zap.Logger, mux *http.ServeMux, server ServerHealth, custom map[string]HealthCheck) {
 	reg := CheckRegistry{checkType: checkTypeLivez, checks: make(map[string]HealthCheck)}
+	// draining is deliberately not a livez check; a failing liveness probe
+	// would get a draining member restarted instead of taken out of rotation.
 	reg.Register("serializable_read", readCheck(server, true /* serializable */))
+	reg.registerCustom(lg, custom)
 	reg.InstallHTTPEndpoints(lg, mux)
 }
 
-func installReadyzEndpoints(lg *zap.Logger, mux *http.ServeMux, server ServerHealth) {
+func installReadyzEndpoints(lg *zap.Logger, mux *http.ServeMux, server ServerHealth, custom map[string]HealthCheck) {
 	reg := CheckRegistry{checkType: checkTypeReadyz, checks: make(map[string]HealthCheck)}
 	reg.Register("data_corruption", activeAlarmCheck(server, pb.AlarmType_CORRUPT))
+	reg.Register("draining", drainingCheck(server))
+	reg.Register("leader", leaderCheck(server))
+	reg.Register("raft_apply", applyLagCheck(server))
 	// serializable_read checks if local read is ok.
 	// linearizable_read checks if there is consensus in the cluster.
 	// Having both serializable_read and linearizable_read helps isolate the cause of problems if there is a read failure.
 	reg.Register("serializable_read", readCheck(server, true))
 	// linearizable_read check would be replaced by read_index check in 3.6
 	reg.Register("linearizable_read", readCheck(server, false))
+	reg.registerCustom(lg, custom)
 	reg.InstallHTTPEndpoints(lg, mux)
 }
 
@@ -259,6 +338,18 @@ func (reg *CheckRegistry) Register(name string, check HealthCheck) {
 	reg.checks[name] = check
 }
 
+// registerCustom registers the checks provided by an embedding application,
+// skipping the ones that would replace a built-in check.
+func (reg *CheckRegistry) registerCustom(lg *zap.Logger, checks map[string]HealthCheck) {
+	for name, check := range checks {
+		if _, found := reg.checks[name]; found {
+			lg.Warn("ignoring custom health check conflicting with a built-in check", zap.String("type", reg.checkType), zap.String("name", name))
+			continue
+		}
+		reg.Register(name, check)
+	}
+}
+
 func (reg *CheckRegistry) RootPath() string {
 	return "/" + reg.checkType
 }
@@ -298,14 +389,20 @@ func (reg *CheckRegistry) runHealthChecks(ctx context.Context, checkNames ...str
 		if !found {
 			panic(fmt.Errorf("Health check: %s not registered", checkName))
 		}
-		if err := check(ctx); err != nil {
+		start := time.Now()
+		err := check(ctx)
+		result := HealthCheckResult{Name: checkName, Status: HealthStatusSuccess, Latency: time.Since(start).String()}
+		if err != nil {
 			fmt.Fprintf(&individualCheckOutput, "[-]%s failed: %v\n", checkName, err)
 			h.Status = HealthStatusError
+			result.Status = HealthStatusError
+			result.Error = err.Error()
 			recordMetrics(reg.checkType, checkName, HealthStatusError)
 		} else {
 			fmt.Fprintf(&individualCheckOutput, "[+]%s ok\n", checkName)
 			recordMetrics(reg.checkType, checkName, HealthStatusSuccess)
 		}
+		h.Checks = append(h.Checks, result)
 	}
 	h.Reason = individualCheckOutput.String()
 	return h
@@ -334,6 +431,11 @@ func newHealthHandler(path string, lg *zap.Logger, hfunc func(*http.Request) Hea
 			return
 		}
 		h := hfunc(r)
+		// The JSON output is always verbose and lists every check with its latency.
+		if r.URL.Query().Get("format") == "json" {
+			writeHealthStatusJSON(lg, w, path, h)
+			return
+		}
 		// Always returns detailed reason for failed checks.
 		if h.Status == HealthStatusError {
 			http.Error(w, h.Reason, http.StatusServiceUnavailable)
@@ -351,6 +453,25 @@ func newHealthHandler(path string, lg *zap.Logger, hfunc func(*http.Request) Hea
 	}
 }
 
+func writeHealthStatusJSON(lg *zap.Logger, w http.ResponseWriter, path string, h HealthStatus) {
+	d, err := json.Marshal(h)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if h.Status == HealthStatusError {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write(d)
+		lg.Error("Health check error", zap.String("path", path), zap.String("reason", h.Reason), zap.Int("status-code", http.StatusServiceUnavailable))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(d)
+	lg.Debug("Health check OK", zap.String("path", path), zap.String("reason", h.Reason), zap.Int("status-code", http.StatusOK))
+}
+
 func filterCheckList(lg *zap.Logger, checks StringSet, excluded StringSet) []string {
 	filteredList := []string{}
 	for chk := range checks {
@@ -360,6 +481,7 @@ func filterCheckList(lg *zap.Logger, checks StringSet, excluded StringSet) []str
 		}
 		filteredList = append(filteredList, chk)
 	}
+	sort.Strings(filteredList)
 	if len(excluded) > 0 {
 		// For version compatibility, excluding non-exist checks would not fail the request.
 		lg.Warn("some health checks cannot be excluded", zap.String("missing-health-checks", formatQuoted(excluded.List()...)))
@@ -424,6 +546,39 @@ func activeAlarmCheck(srv ServerHealth, at pb.AlarmType) func(context.Context) e
 	}
 }
 
+// drainingCheck fails while the member is draining so that load balancers
+// stop routing new client traffic to it.
+func drainingCheck(srv ServerHealth) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if srv.IsDraining() {
+			return errors.New("member is draining")
+		}
+		return nil
+	}
+}
+
+// leaderCheck fails while the member does not know of a raft leader.
+func leaderCheck(srv ServerHealth) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if uint64(srv.Leader()) == raft.None {
+			return errors.New("no leader")
+		}
+		return nil
+	}
+}
+
+// applyLagCheck fails while the member applies committed entries too slowly
+// to keep up with raft.
+func applyLagCheck(srv ServerHealth) func(context.Context) error {
+	return func(ctx context.Context) error {
+		ci, ai := srv.CommittedIndex(), srv.AppliedIndex()
+		if ci > ai+maxApplyLag {
+			return fmt.Errorf("behind on raft: applied index %d, committed index %d", ai, ci)
+		}
+		return nil
+	}
+}
+
 func readCheck(srv ServerHealth, serializable bool) func(ctx context.Context) error {
 	return func(ctx context.Context) error {
 		ctx = srv.AuthStore().WithRoot(ctx)
@@ -16,12 +16,15 @@ package etcdhttp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap/zaptest"
@@ -42,6 +45,8 @@ type fakeHealthServer struct {
 	serializableReadError error
 	linearizableReadError error
 	missingLeader         bool
+	draining              bool
+	applyLag              uint64
 	authStore             auth.AuthStore
 }
 
@@ -56,6 +61,8 @@ func (s *fakeHealthServer) Config() config.ServerConfig {
 	return config.ServerConfig{}
 }
 
+func (s *fakeHealthServer) MemberID() types.ID { return 1 }
+
 func (s *fakeHealthServer) Leader() types.ID {
 	if !s.missingLeader {
 		return 1
@@ -67,6 +74,12 @@ func (s *fakeHealthServer) AuthStore() auth.AuthStore { return s.authStore }
 
 func (s *fakeHealthServer) ClientCertAuthEnabled() bool { return false }
 
+func (s *fakeHealthServer) IsDraining() bool { return s.draining }
+
+func (s *fakeHealthServer) AppliedIndex() uint64 { return 100 }
+
+func (s *fakeHealthServer) CommittedIndex() uint64 { return 100 + s.applyLag }
+
 type healthTestCase struct {
 	name             string
 	healthCheckURL   string
@@ -77,6 +90,8 @@ type healthTestCase struct {
 	alarms        []*pb.AlarmMember
 	apiError      error
 	missingLeader bool
+	draining      bool
+	applyLag      uint64
 }
 
 func TestHealthHandler(t *testing.T) {
@@ -125,6 +140,30 @@ func TestHealthHandler(t *testing.T) {
 			healthCheckURL:   "/health?exclude=NOSPACE&exclude=CORRUPT",
 			expectStatusCode: http.StatusOK,
 		},
+		{
+			name:             "Unhealthy if CORRUPT_RANGE alarm is on for the member",
+			alarms:           []*pb.AlarmMember{{MemberID: uint64(1), Alarm: pb.AlarmType_CORRUPT_RANGE}},
+			healthCheckURL:   "/health",
+			expectStatusCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:             "Healthy if CORRUPT_RANGE alarm is on for another member",
+			alarms:           []*pb.AlarmMember{{MemberID: uint64(2), Alarm: pb.AlarmType_CORRUPT_RANGE}},
+			healthCheckURL:   "/health",
+			expectStatusCode: http.StatusOK,
+		},
+		{
+			name:             "Unhealthy if SLOWDISK alarm is on for the member",
+			alarms:           []*pb.AlarmMember{{MemberID: uint64(1), Alarm: pb.AlarmType_SLOWDISK}},
+			healthCheckURL:   "/health",
+			expectStatusCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:             "Healthy if SLOWDISK alarm is on for another member",
+			alarms:           []*pb.AlarmMember{{MemberID: uint64(2), Alarm: pb.AlarmType_SLOWDISK}},
+			healthCheckURL:   "/health",
+			expectStatusCode: http.StatusOK,
+		},
 		{
 			name:             "Unhealthy if api is not available",
 			healthCheckURL:   "/health",
@@ -143,6 +182,60 @@ func TestHealthHandler(t *testing.T) {
 			expectStatusCode: http.StatusOK,
 			missingLeader:    true,
 		},
+		{
+			name:             "Unhealthy if draining",
+			healthCheckURL:   "/health?serializable=true",
+			expectStatusCode: http.StatusServiceUnavailable,
+			draining:         true,
+		},
+		{
+			name:             "Healthy if draining is excluded",
+			healthCheckURL:   "/health?exclude=draining",
+			expectStatusCode: http.StatusOK,
+			draining:         true,
+		},
+		{
+			name:             "Healthy if no leader and leader and read are excluded",
+			healthCheckURL:   "/health?exclude=leader&exclude=read",
+			expectStatusCode: http.StatusOK,
+			missingLeader:    true,
+		},
+		{
+			name:             "Healthy if api is not available and read is excluded",
+			healthCheckURL:   "/health?exclude=read",
+			apiError:         fmt.Errorf("Unexpected error"),
+			expectStatusCode: http.StatusOK,
+		},
+		{
+			name:             "Not ready if no leader",
+			healthCheckURL:   "/readyz?exclude=linearizable_read",
+			expectStatusCode: http.StatusServiceUnavailable,
+			missingLeader:    true,
+		},
+		{
+			name:             "Not ready if behind on raft",
+			healthCheckURL:   "/readyz",
+			expectStatusCode: http.StatusServiceUnavailable,
+			applyLag:         maxApplyLag + 1,
+		},
+		{
+			name:             "Ready if behind on raft and raft_apply is excluded",
+			healthCheckURL:   "/readyz?exclude=raft_apply",
+			expectStatusCode: http.StatusOK,
+			applyLag:         maxApplyLag + 1,
+		},
+		{
+			name:             "Not ready if draining",
+			healthCheckURL:   "/readyz",
+			expectStatusCode: http.StatusServiceUnavailable,
+			draining:         true,
+		},
+		{
+			name:             "Live if draining",
+			healthCheckURL:   "/livez",
+			expectStatusCode: http.StatusOK,
+			draining:         true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -156,6 +249,8 @@ func TestHealthHandler(t *testing.T) {
 				serializableReadError: tt.apiError,
 				linearizableReadError: tt.apiError,
 				missingLeader:         tt.missingLeader,
+				draining:              tt.draining,
+				applyLag:              tt.applyLag,
 				authStore:             auth.NewAuthStore(lg, schema.NewAuthBackend(lg, be), nil, 0),
 			})
 			ts := httptest.NewServer(mux)
@@ -165,6 +260,121 @@ func TestHealthHandler(t *testing.T) {
 	}
 }
 
+func TestCustomHealthChecks(t *testing.T) {
+	be, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, be)
+	tests := []healthTestCase{
+		{
+			name:             "Ready if custom check passes",
+			healthCheckURL:   "/readyz?verbose",
+			expectStatusCode: http.StatusOK,
+			inResult:         []string{"[+]custom ok", "[+]linearizable_read ok"},
+		},
+		{
+			name:             "Not ready if custom check fails",
+			healthCheckURL:   "/readyz",
+			apiError:         fmt.Errorf("custom error"),
+			expectStatusCode: http.StatusServiceUnavailable,
+			inResult:         []string{"[-]custom failed: custom error"},
+		},
+		{
+			name:             "Ready if failing custom check is excluded",
+			healthCheckURL:   "/readyz?exclude=custom",
+			apiError:         fmt.Errorf("custom error"),
+			expectStatusCode: http.StatusOK,
+		},
+		{
+			name:             "Custom check sub path",
+			healthCheckURL:   "/readyz/custom",
+			apiError:         fmt.Errorf("custom error"),
+			expectStatusCode: http.StatusServiceUnavailable,
+			inResult:         []string{"[-]custom failed: custom error"},
+		},
+		{
+			name:             "Live if failing custom check is readyz only",
+			healthCheckURL:   "/livez",
+			apiError:         fmt.Errorf("custom error"),
+			expectStatusCode: http.StatusOK,
+		},
+		{
+			name:             "Built-in check is not replaced",
+			healthCheckURL:   "/readyz/draining",
+			expectStatusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			logger := zaptest.NewLogger(t)
+			s := &fakeHealthServer{
+				authStore: auth.NewAuthStore(logger, schema.NewAuthBackend(logger, be), nil, 0),
+			}
+			custom := func(context.Context) error { return tt.apiError }
+			HandleHealthWithChecks(logger, mux, s, nil, map[string]HealthCheck{
+				"custom":   custom,
+				"draining": func(context.Context) error { return fmt.Errorf("should not run") },
+			})
+			ts := httptest.NewServer(mux)
+			defer ts.Close()
+			checkHTTPResponse(t, ts, tt.healthCheckURL, tt.expectStatusCode, tt.inResult, tt.notInResult)
+		})
+	}
+}
+
+func TestHealthJSONOutput(t *testing.T) {
+	be, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, be)
+	mux := http.NewServeMux()
+	logger := zaptest.NewLogger(t)
+	s := &fakeHealthServer{
+		linearizableReadError: fmt.Errorf("Unexpected error"),
+		authStore:             auth.NewAuthStore(logger, schema.NewAuthBackend(logger, be), nil, 0),
+	}
+	HandleHealth(logger, mux, s)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	res, err := ts.Client().Get(ts.URL + "/readyz?format=json&exclude=draining")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("want statusCode %d but got %d", http.StatusServiceUnavailable, res.StatusCode)
+	}
+	if ct := res.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("want Content-Type application/json but got %q", ct)
+	}
+	var h HealthStatus
+	if err = json.NewDecoder(res.Body).Decode(&h); err != nil {
+		t.Fatal(err)
+	}
+	if h.Status != HealthStatusError {
+		t.Errorf("want status %q but got %q", HealthStatusError, h.Status)
+	}
+	var names []string
+	for _, c := range h.Checks {
+		names = append(names, c.Name)
+		if _, err = time.ParseDuration(c.Latency); err != nil {
+			t.Errorf("check %s has invalid latency %q: %v", c.Name, c.Latency, err)
+		}
+		wantStatus := HealthStatusSuccess
+		if c.Name == "linearizable_read" {
+			wantStatus = HealthStatusError
+			if c.Error != "Unexpected error" {
+				t.Errorf("want error %q for %s but got %q", "Unexpected error", c.Name, c.Error)
+			}
+		}
+		if c.Status != wantStatus {
+			t.Errorf("want status %q for %s but got %q", wantStatus, c.Name, c.Status)
+		}
+	}
+	wantNames := []string{"data_corruption", "leader", "linearizable_read", "raft_apply", "serializable_read"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("want checks %v but got %v", wantNames, names)
+	}
+}
+
 func TestHTTPSubPath(t *testing.T) {
 	be, _ := betesting.NewDefaultTmpBackend(t)
 	defer betesting.Close(t, be)
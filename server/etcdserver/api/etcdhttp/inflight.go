@@ -0,0 +1,69 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go.etcd.io/etcd/server/v3/etcdserver"
+)
+
+const (
+	PathInflight = "/debug/inflight"
+)
+
+// InflightRequestLister lists the requests being served.
+type InflightRequestLister interface {
+	InflightRequests() []etcdserver.InflightRequest
+}
+
+// inflightRequest is the JSON representation of an inflight request.
+type inflightRequest struct {
+	Method    string    `json:"method"`
+	Key       string    `json:"key,omitempty"`
+	RangeEnd  string    `json:"range_end,omitempty"`
+	Principal string    `json:"principal,omitempty"`
+	Remote    string    `json:"remote,omitempty"`
+	Start     time.Time `json:"start"`
+	Elapsed   string    `json:"elapsed"`
+	State     string    `json:"state"`
+}
+
+// HandleInflight registers the handler listing the requests being served as
+// JSON, the longest running first.
+func HandleInflight(mux *http.ServeMux, s InflightRequestLister) {
+	mux.HandleFunc(PathInflight, func(w http.ResponseWriter, r *http.Request) {
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		reqs := []inflightRequest{}
+		for _, ir := range s.InflightRequests() {
+			reqs = append(reqs, inflightRequest{
+				Method:    ir.Method,
+				Key:       ir.Key,
+				RangeEnd:  ir.RangeEnd,
+				Principal: ir.Principal,
+				Remote:    ir.Remote,
+				Start:     ir.Start,
+				Elapsed:   ir.Elapsed.String(),
+				State:     ir.State,
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(reqs)
+	})
+}
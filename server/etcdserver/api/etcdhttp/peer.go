@@ -39,7 +39,7 @@ const (
 
 // NewPeerHandler generates an http.Handler to handle etcd peer requests.
 func NewPeerHandler(lg *zap.Logger, s etcdserver.ServerPeerV2) http.Handler {
-	return newPeerHandler(lg, s, s.RaftHandler(), s.LeaseHandler(), s.HashKVHandler(), s.DowngradeEnabledHandler())
+	return newPeerHandler(lg, s, s.RaftHandler(), s.LeaseHandler(), s.HashKVHandler(), s.BackendStatusHandler(), s.DowngradeEnabledHandler(), s.DefragHandler())
 }
 
 func newPeerHandler(
@@ -48,7 +48,9 @@ func newPeerHandler(
 	raftHandler http.Handler,
 	leaseHandler http.Handler,
 	hashKVHandler http.Handler,
+	backendStatusHandler http.Handler,
 	downgradeEnabledHandler http.Handler,
+	defragHandler http.Handler,
 ) http.Handler {
 	if lg == nil {
 		lg = zap.NewNop()
@@ -71,6 +73,13 @@ func newPeerHandler(
 	}
 	if hashKVHandler != nil {
 		mux.Handle(etcdserver.PeerHashKVPath, hashKVHandler)
+		mux.Handle(etcdserver.PeerHashKVRangePath, hashKVHandler)
+	}
+	if backendStatusHandler != nil {
+		mux.Handle(etcdserver.PeerBackendStatusPath, backendStatusHandler)
+	}
+	if defragHandler != nil {
+		mux.Handle(etcdserver.PeerDefragPath, defragHandler)
 	}
 	mux.HandleFunc(versionPath, versionHandler(s, serveVersion))
 	return mux
@@ -141,7 +150,7 @@ func (h *peerMemberPromoteHandler) ServeHTTP(w http.ResponseWriter, r *http.Requ
 		switch err {
 		case membership.ErrIDNotFound:
 			http.Error(w, err.Error(), http.StatusNotFound)
-		case membership.ErrMemberNotLearner:
+		case membership.ErrMemberNotLearner, membership.ErrMemberReadReplica:
 			http.Error(w, err.Error(), http.StatusPreconditionFailed)
 		case errors.ErrLearnerNotReady:
 			http.Error(w, err.Error(), http.StatusPreconditionFailed)
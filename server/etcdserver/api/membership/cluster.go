@@ -328,6 +328,9 @@ func (c *RaftCluster) ValidateConfigurationChange(cc raftpb.ConfChange) error {
 			if !membersMap[id].IsLearner {
 				return ErrMemberNotLearner
 			}
+			if membersMap[id].ReadReplica {
+				return ErrMemberReadReplica
+			}
 		} else { // adding a new member
 			if membersMap[id] != nil {
 				return ErrIDExists
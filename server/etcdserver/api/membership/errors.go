@@ -21,12 +21,17 @@ import (
 )
 
 var (
-	ErrIDRemoved        = errors.New("membership: ID removed")
-	ErrIDExists         = errors.New("membership: ID exists")
-	ErrIDNotFound       = errors.New("membership: ID not found")
-	ErrPeerURLexists    = errors.New("membership: peerURL exists")
-	ErrMemberNotLearner = errors.New("membership: can only promote a learner member")
-	ErrTooManyLearners  = errors.New("membership: too many learner members in cluster")
+	ErrIDRemoved         = errors.New("membership: ID removed")
+	ErrIDExists          = errors.New("membership: ID exists")
+	ErrIDNotFound        = errors.New("membership: ID not found")
+	ErrPeerURLexists     = errors.New("membership: peerURL exists")
+	ErrMemberNotLearner  = errors.New("membership: can only promote a learner member")
+	ErrTooManyLearners   = errors.New("membership: too many learner members in cluster")
+	ErrMemberReadReplica = errors.New("membership: cannot promote a read replica member")
+
+	// ErrLabelsNotSupported is returned when updating the labels of a
+	// member before all members run 3.6.
+	ErrLabelsNotSupported = errors.New("membership: member labels require cluster version 3.6")
 )
 
 func isKeyNotFound(err error) bool {
@@ -38,6 +38,15 @@ type RaftAttributes struct {
 type Attributes struct {
 	Name       string   `json:"name,omitempty"`
 	ClientURLs []string `json:"clientURLs,omitempty"`
+	// ReadReplica indicates the member is a learner kept as a permanent
+	// read-only replica, which is never promoted to a voting member.
+	ReadReplica bool `json:"readReplica,omitempty"`
+	// Labels are user defined key/value labels of the member, such as its
+	// zone or region, for topology-aware tooling.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Witness indicates the member is a witness, which votes in the raft
+	// quorum but neither stores the key-value store nor serves clients.
+	Witness bool `json:"witness,omitempty"`
 }
 
 type Member struct {
@@ -97,7 +106,9 @@ func (m *Member) Clone() *Member {
 			IsLearner: m.IsLearner,
 		},
 		Attributes: Attributes{
-			Name: m.Name,
+			Name:        m.Name,
+			ReadReplica: m.ReadReplica,
+			Witness:     m.Witness,
 		},
 	}
 	if m.PeerURLs != nil {
@@ -108,6 +119,12 @@ func (m *Member) Clone() *Member {
 		mm.ClientURLs = make([]string, len(m.ClientURLs))
 		copy(mm.ClientURLs, m.ClientURLs)
 	}
+	if m.Labels != nil {
+		mm.Labels = make(map[string]string, len(m.Labels))
+		for k, v := range m.Labels {
+			mm.Labels[k] = v
+		}
+	}
 	return mm
 }
 
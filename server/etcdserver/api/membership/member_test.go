@@ -61,6 +61,8 @@ func TestMemberClone(t *testing.T) {
 		newTestMember(1, []string{"http://a"}, "abc", nil),
 		newTestMember(1, nil, "abc", []string{"http://b"}),
 		newTestMember(1, []string{"http://a"}, "abc", []string{"http://b"}),
+		{ID: 1, RaftAttributes: RaftAttributes{IsLearner: true}, Attributes: Attributes{Name: "abc", ReadReplica: true}},
+		{ID: 1, Attributes: Attributes{Name: "abc", Labels: map[string]string{"zone": "us-east-1a"}}},
 	}
 	for i, tt := range tests {
 		nm := tt.Clone()
@@ -15,6 +15,7 @@
 package rafthttp
 
 import (
+	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
@@ -45,6 +46,10 @@ const (
 
 	// snapshotLimitByte limits the snapshot size to 1TB
 	snapshotLimitByte = 1 * 1024 * 1024 * 1024 * 1024
+
+	// snapshotTypeDifferential is the X-Etcd-Snapshot-Type header of
+	// differential snapshots.
+	snapshotTypeDifferential = "differential"
 )
 
 var (
@@ -55,6 +60,8 @@ var (
 
 	errIncompatibleVersion = errors.New("incompatible version")
 	ErrClusterIDMismatch   = errors.New("cluster ID mismatch")
+
+	errDifferentialSnapshotUnsupported = errors.New("differential snapshots are not supported")
 )
 
 type peerGetter interface {
@@ -161,10 +168,11 @@ func (h *pipelineHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 type snapshotHandler struct {
-	lg          *zap.Logger
-	tr          Transporter
-	r           Raft
-	snapshotter *snap.Snapshotter
+	lg           *zap.Logger
+	tr           Transporter
+	r            Raft
+	snapshotter  *snap.Snapshotter
+	differential DifferentialSnapshotter
 
 	localID types.ID
 	cid     types.ID
@@ -172,12 +180,13 @@ type snapshotHandler struct {
 
 func newSnapshotHandler(t *Transport, r Raft, snapshotter *snap.Snapshotter, cid types.ID) http.Handler {
 	h := &snapshotHandler{
-		lg:          t.Logger,
-		tr:          t,
-		r:           r,
-		snapshotter: snapshotter,
-		localID:     t.ID,
-		cid:         cid,
+		lg:           t.Logger,
+		tr:           t,
+		r:            r,
+		snapshotter:  snapshotter,
+		differential: t.DifferentialSnapshotter,
+		localID:      t.ID,
+		cid:          cid,
 	}
 	if h.lg == nil {
 		h.lg = zap.NewNop()
@@ -216,7 +225,19 @@ func (h *snapshotHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	addRemoteFromRequest(h.tr, r)
 
-	dec := &messageDecoder{r: r.Body}
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to decompress snapshot (%v)", err), http.StatusBadRequest)
+			snapshotReceiveFailures.WithLabelValues(unknownSnapshotSender).Inc()
+			return
+		}
+		defer zr.Close()
+		body = zr
+	}
+
+	dec := &messageDecoder{r: body}
 	// let snapshots be very large since they can exceed 512MB for large installations
 	m, err := dec.decodeLimit(snapshotLimitByte)
 	from := types.ID(m.From).String()
@@ -265,7 +286,16 @@ func (h *snapshotHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// save incoming database snapshot.
 
-	n, err := h.snapshotter.SaveDBFrom(r.Body, m.Snapshot.Metadata.Index)
+	var n int64
+	if r.Header.Get("X-Etcd-Snapshot-Type") == snapshotTypeDifferential {
+		if h.differential == nil {
+			err = errDifferentialSnapshotUnsupported
+		} else {
+			n, err = h.differential.SaveDBFromDifferential(body, m.Snapshot.Metadata.Index)
+		}
+	} else {
+		n, err = h.snapshotter.SaveDBFrom(body, m.Snapshot.Metadata.Index)
+	}
 	if err != nil {
 		msg := fmt.Sprintf("failed to save KV snapshot (%v)", err)
 		h.lg.Warn(
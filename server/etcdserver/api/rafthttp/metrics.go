@@ -98,6 +98,24 @@ var (
 		[]string{"To"},
 	)
 
+	snapshotSendBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "network",
+		Name:      "snapshot_send_bytes_total",
+		Help:      "Total number of snapshot bytes read for sending, before compression",
+	},
+		[]string{"To"},
+	)
+
+	snapshotSendRemainingBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "network",
+		Name:      "snapshot_send_remaining_bytes",
+		Help:      "Number of snapshot bytes of inflight snapshot sends that are not sent yet",
+	},
+		[]string{"To"},
+	)
+
 	snapshotSendSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace: "etcd",
 		Subsystem: "network",
@@ -176,6 +194,8 @@ func init() {
 	prometheus.MustRegister(snapshotSend)
 	prometheus.MustRegister(snapshotSendInflights)
 	prometheus.MustRegister(snapshotSendFailures)
+	prometheus.MustRegister(snapshotSendBytes)
+	prometheus.MustRegister(snapshotSendRemainingBytes)
 	prometheus.MustRegister(snapshotSendSeconds)
 	prometheus.MustRegister(snapshotReceive)
 	prometheus.MustRegister(snapshotReceiveInflights)
@@ -16,13 +16,16 @@ package rafthttp
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"io"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/dustin/go-humanize"
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
 
 	"go.etcd.io/etcd/client/pkg/v3/types"
 	"go.etcd.io/etcd/pkg/v3/httputil"
@@ -74,8 +77,30 @@ func (s *snapshotSender) send(merged snap.Message) {
 	body := createSnapBody(s.tr.Logger, merged)
 	defer body.Close()
 
+	snapshotSendRemainingBytes.WithLabelValues(to).Add(float64(merged.TotalSize))
+	pr := &snapshotProgressReader{r: body, to: to, remaining: merged.TotalSize}
+	defer pr.done()
+
+	var rd io.Reader = pr
+	if s.tr.SnapshotCompression {
+		zr := newGzipReader(rd)
+		defer zr.Close()
+		rd = zr
+	}
+	if s.tr.snapshotLimiter != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		rd = &rateLimitedReader{ctx: ctx, r: rd, limiter: s.tr.snapshotLimiter}
+	}
+
 	u := s.picker.pick()
-	req := createPostRequest(s.tr.Logger, u, RaftSnapshotPrefix, body, "application/octet-stream", s.tr.URLs, s.from, s.cid)
+	req := createPostRequest(s.tr.Logger, u, RaftSnapshotPrefix, rd, "application/octet-stream", s.tr.URLs, s.from, s.cid)
+	if s.tr.SnapshotCompression {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if merged.Differential {
+		req.Header.Set("X-Etcd-Snapshot-Type", snapshotTypeDifferential)
+	}
 
 	snapshotSizeVal := uint64(merged.TotalSize)
 	snapshotSize := humanize.Bytes(snapshotSizeVal)
@@ -86,6 +111,8 @@ func (s *snapshotSender) send(merged snap.Message) {
 			zap.String("remote-peer-id", to),
 			zap.Uint64("bytes", snapshotSizeVal),
 			zap.String("size", snapshotSize),
+			zap.Bool("compressed", s.tr.SnapshotCompression),
+			zap.Bool("differential", merged.Differential),
 		)
 	}
 
@@ -198,3 +225,71 @@ func createSnapBody(lg *zap.Logger, merged snap.Message) io.ReadCloser {
 		Closer: merged.ReadCloser,
 	}
 }
+
+// newGzipReader returns a reader of the gzip compressed content of r. Closing
+// it stops the compression.
+func newGzipReader(r io.Reader) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		zw := gzip.NewWriter(pw)
+		_, err := io.Copy(zw, r)
+		if err == nil {
+			err = zw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+// rateLimitedReader limits the rate at which bytes are read from r.
+type rateLimitedReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rate.Limiter
+}
+
+func (rr *rateLimitedReader) Read(p []byte) (int, error) {
+	if b := rr.limiter.Burst(); len(p) > b {
+		p = p[:b]
+	}
+	n, err := rr.r.Read(p)
+	if n > 0 {
+		if werr := rr.limiter.WaitN(rr.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+// snapshotProgressReader reports the progress of a snapshot send.
+type snapshotProgressReader struct {
+	r  io.Reader
+	to string
+	// remaining may be read by the compressing goroutine while the send
+	// finishes; must use atomic operations to access.
+	remaining int64
+}
+
+func (pr *snapshotProgressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	if n > 0 {
+		snapshotSendBytes.WithLabelValues(pr.to).Add(float64(n))
+		for {
+			// the encoded raft message header is not part of the total size
+			rem := atomic.LoadInt64(&pr.remaining)
+			sent := min(int64(n), rem)
+			if atomic.CompareAndSwapInt64(&pr.remaining, rem, rem-sent) {
+				snapshotSendRemainingBytes.WithLabelValues(pr.to).Sub(float64(sent))
+				break
+			}
+		}
+	}
+	return n, err
+}
+
+// done clears what is left of the snapshot from the remaining bytes, in case
+// the send failed.
+func (pr *snapshotProgressReader) done() {
+	rem := atomic.SwapInt64(&pr.remaining, 0)
+	snapshotSendRemainingBytes.WithLabelValues(pr.to).Sub(float64(rem))
+}
@@ -15,6 +15,8 @@
 package rafthttp
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -25,6 +27,7 @@ import (
 	"time"
 
 	"go.uber.org/zap/zaptest"
+	"golang.org/x/time/rate"
 
 	"go.etcd.io/etcd/client/pkg/v3/types"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
@@ -93,11 +96,81 @@ func TestSnapshotSend(t *testing.T) {
 	}
 }
 
-func testSnapshotSend(t *testing.T, sm *snap.Message) (bool, []os.DirEntry) {
-	d := t.TempDir()
+func TestSnapshotSendCompressed(t *testing.T) {
+	tests := []struct {
+		rc   io.ReadCloser
+		size int64
+
+		wsent  bool
+		wfiles int
+	}{
+		{
+			rc:   strReaderCloser{strings.NewReader("hello")},
+			size: 5,
 
+			wsent:  true,
+			wfiles: 1,
+		},
+		// sends less than the given snapshot length
+		{
+			rc:   strReaderCloser{strings.NewReader("hello")},
+			size: 10000,
+
+			wsent:  false,
+			wfiles: 0,
+		},
+	}
+
+	for i, tt := range tests {
+		m := raftpb.Message{Type: raftpb.MsgSnap, To: 1, Snapshot: &raftpb.Snapshot{}}
+		tr := &Transport{
+			pipelineRt:          &http.Transport{},
+			ClusterID:           types.ID(1),
+			Raft:                &fakeRaft{},
+			SnapshotCompression: true,
+			snapshotLimiter:     rate.NewLimiter(rate.Limit(1024*1024), 1024),
+		}
+		sent, files := testSnapshotSendWithTransport(t, tr, snap.NewMessage(m, tt.rc, tt.size))
+		if tt.wsent != sent {
+			t.Errorf("#%d: snapshot expected %v, got %v", i, tt.wsent, sent)
+		}
+		if tt.wfiles != len(files) {
+			t.Fatalf("#%d: expected %d files, got %d files", i, tt.wfiles, len(files))
+		}
+	}
+}
+
+func TestRateLimitedReader(t *testing.T) {
+	data := make([]byte, 3000)
+	rr := &rateLimitedReader{
+		ctx:     context.Background(),
+		r:       bytes.NewReader(data),
+		limiter: rate.NewLimiter(rate.Limit(4000), 1000),
+	}
+	start := time.Now()
+	b, err := io.ReadAll(rr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != len(data) {
+		t.Fatalf("expected %d bytes, got %d", len(data), len(b))
+	}
+	// the initial burst is free, the remaining 2000 bytes take 500ms
+	if took := time.Since(start); took < 400*time.Millisecond {
+		t.Errorf("expected reads to be throttled, took %v", took)
+	}
+}
+
+func testSnapshotSend(t *testing.T, sm *snap.Message) (bool, []os.DirEntry) {
 	r := &fakeRaft{}
 	tr := &Transport{pipelineRt: &http.Transport{}, ClusterID: types.ID(1), Raft: r}
+	return testSnapshotSendWithTransport(t, tr, sm)
+}
+
+func testSnapshotSendWithTransport(t *testing.T, tr *Transport, sm *snap.Message) (bool, []os.DirEntry) {
+	d := t.TempDir()
+
+	r := tr.Raft
 	ch := make(chan struct{}, 1)
 	h := &syncHandler{newSnapshotHandler(tr, r, snap.New(zaptest.NewLogger(t), d), types.ID(1)), ch}
 	srv := httptest.NewServer(h)
@@ -16,6 +16,7 @@ package rafthttp
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"sync"
 	"time"
@@ -39,6 +40,12 @@ type Raft interface {
 	ReportSnapshot(id uint64, status raft.SnapshotStatus)
 }
 
+// DifferentialSnapshotter saves the database snapshots received as
+// differential snapshots, which it applies to the local database.
+type DifferentialSnapshotter interface {
+	SaveDBFromDifferential(r io.Reader, index uint64) (int64, error)
+}
+
 type Transporter interface {
 	// Start starts the given Transporter.
 	// Start MUST be called before calling other functions in the interface.
@@ -109,7 +116,10 @@ type Transport struct {
 	ClusterID   types.ID   // raft cluster ID for request validation
 	Raft        Raft       // raft state machine, to which the Transport forwards received messages and reports status
 	Snapshotter *snap.Snapshotter
-	ServerStats *stats.ServerStats // used to record general transportation statistics
+	// DifferentialSnapshotter saves the received differential snapshots.
+	// Differential snapshots are rejected if it is nil.
+	DifferentialSnapshotter DifferentialSnapshotter
+	ServerStats             *stats.ServerStats // used to record general transportation statistics
 	// LeaderStats records transportation statistics with followers when
 	// performing as leader in raft protocol
 	LeaderStats *stats.LeaderStats
@@ -119,6 +129,15 @@ type Transport struct {
 	// machine and thus stop the Transport.
 	ErrorC chan error
 
+	// SnapshotSendRateBytes limits the bandwidth, in bytes per second, used
+	// to send snapshots to all peers. Zero means no limit.
+	SnapshotSendRateBytes int64
+	// SnapshotCompression enables gzip compression of outgoing snapshots.
+	// All peers must be able to decompress them.
+	SnapshotCompression bool
+
+	snapshotLimiter *rate.Limiter // limits snapshot sends if SnapshotSendRateBytes is set
+
 	streamRt   http.RoundTripper // roundTripper used by streams
 	pipelineRt http.RoundTripper // roundTripper used by pipelines
 
@@ -151,6 +170,9 @@ func (t *Transport) Start() error {
 	if t.DialRetryFrequency == 0 {
 		t.DialRetryFrequency = rate.Every(100 * time.Millisecond)
 	}
+	if t.SnapshotSendRateBytes > 0 {
+		t.snapshotLimiter = rate.NewLimiter(rate.Limit(t.SnapshotSendRateBytes), int(t.SnapshotSendRateBytes))
+	}
 	return nil
 }
 
@@ -73,6 +73,26 @@ func (s *Snapshotter) SaveDBFrom(r io.Reader, id uint64) (int64, error) {
 	return n, nil
 }
 
+// SaveDBFile saves the database file at the given path, which must be in the
+// snapshot directory, as the snapshot of the database with the given id.
+func (s *Snapshotter) SaveDBFile(path string, id uint64) error {
+	f, err := os.OpenFile(path, os.O_RDWR, fileutil.PrivateFileMode)
+	if err != nil {
+		return err
+	}
+	err = fileutil.Fsync(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	fn := s.dbFilePath(id)
+	if err = os.Rename(path, fn); err != nil {
+		return err
+	}
+	s.lg.Info("saved database snapshot to disk", zap.String("path", fn))
+	return nil
+}
+
 // DBFilePath returns the file path for the snapshot of the database with
 // given id. If the snapshot does not exist, it returns error.
 func (s *Snapshotter) DBFilePath(id uint64) (string, error) {
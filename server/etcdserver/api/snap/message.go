@@ -33,7 +33,11 @@ type Message struct {
 	raftpb.Message
 	ReadCloser io.ReadCloser
 	TotalSize  int64
-	closeC     chan bool
+	// Differential is true if ReadCloser holds a differential snapshot,
+	// which the receiver applies to its own database, instead of the whole
+	// database.
+	Differential bool
+	closeC       chan bool
 }
 
 func NewMessage(rs raftpb.Message, rc io.ReadCloser, rcSize int64) *Message {
@@ -0,0 +1,162 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3admission
+
+import (
+	"context"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+// OpType is the type of a mutation.
+type OpType int
+
+const (
+	OpPut OpType = iota
+	OpDeleteRange
+)
+
+func (t OpType) String() string {
+	switch t {
+	case OpPut:
+		return "put"
+	case OpDeleteRange:
+		return "delete-range"
+	default:
+		return "unknown"
+	}
+}
+
+// Op is a mutation of the key space requested by a client.
+type Op struct {
+	Type OpType
+	Key  []byte
+	// RangeEnd is the end of the range of keys deleted by OpDeleteRange, with
+	// the same semantics as in DeleteRangeRequest.
+	RangeEnd []byte
+	// Value is the value written by OpPut. It is nil if IgnoreValue is set,
+	// in which case the current value of the key is kept.
+	Value       []byte
+	IgnoreValue bool
+	Lease       int64
+}
+
+// Controller admits or rejects the mutations of a request. Admit is called
+// with all the mutations a request may apply, before the request is proposed
+// to raft. The request is rejected if Admit returns an error, whose message is
+// returned to the client.
+//
+// Admit is called concurrently and on the request path, so it must be fast.
+type Controller interface {
+	Admit(ctx context.Context, ops []Op) error
+}
+
+// ControllerFunc is an adapter to allow the use of ordinary functions as
+// admission controllers.
+type ControllerFunc func(ctx context.Context, ops []Op) error
+
+func (f ControllerFunc) Admit(ctx context.Context, ops []Op) error { return f(ctx, ops) }
+
+// Chain returns a Controller admitting the requests admitted by all the given
+// controllers, which are called in order. It returns nil if no controller is
+// given.
+func Chain(cs ...Controller) Controller {
+	var chain chainController
+	for _, c := range cs {
+		if c != nil {
+			chain = append(chain, c)
+		}
+	}
+	switch len(chain) {
+	case 0:
+		return nil
+	case 1:
+		return chain[0]
+	default:
+		return chain
+	}
+}
+
+type chainController []Controller
+
+func (cs chainController) Admit(ctx context.Context, ops []Op) error {
+	for _, c := range cs {
+		if err := c.Admit(ctx, ops); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PutOps returns the mutations of a Put request.
+func PutOps(r *pb.PutRequest) []Op {
+	return []Op{putOp(r)}
+}
+
+// DeleteRangeOps returns the mutations of a DeleteRange request.
+func DeleteRangeOps(r *pb.DeleteRangeRequest) []Op {
+	return []Op{deleteRangeOp(r)}
+}
+
+// TxnOps returns the mutations of a Txn request. Since the comparisons are
+// only evaluated when the request is applied, the mutations of both the
+// success and the failure branches are returned, including those of nested
+// transactions.
+func TxnOps(r *pb.TxnRequest) []Op {
+	var ops []Op
+	for _, branch := range [][]*pb.RequestOp{r.Success, r.Failure} {
+		for _, op := range branch {
+			switch tv := op.Request.(type) {
+			case *pb.RequestOp_RequestPut:
+				ops = append(ops, putOp(tv.RequestPut))
+			case *pb.RequestOp_RequestDeleteRange:
+				ops = append(ops, deleteRangeOp(tv.RequestDeleteRange))
+			case *pb.RequestOp_RequestTxn:
+				ops = append(ops, TxnOps(tv.RequestTxn)...)
+			}
+		}
+	}
+	return ops
+}
+
+func putOp(r *pb.PutRequest) Op {
+	op := Op{Type: OpPut, Key: r.Key, IgnoreValue: r.IgnoreValue, Lease: r.Lease}
+	if !r.IgnoreValue {
+		op.Value = r.Value
+	}
+	return op
+}
+
+func deleteRangeOp(r *pb.DeleteRangeRequest) Op {
+	return Op{Type: OpDeleteRange, Key: r.Key, RangeEnd: r.RangeEnd}
+}
+
+// txnRequest is the inverse of TxnOps, used to send the mutations to a webhook.
+func txnRequest(ops []Op) *pb.TxnRequest {
+	r := &pb.TxnRequest{}
+	for _, op := range ops {
+		switch op.Type {
+		case OpPut:
+			r.Success = append(r.Success, &pb.RequestOp{Request: &pb.RequestOp_RequestPut{RequestPut: &pb.PutRequest{
+				Key: op.Key, Value: op.Value, IgnoreValue: op.IgnoreValue, Lease: op.Lease,
+			}}})
+		case OpDeleteRange:
+			r.Success = append(r.Success, &pb.RequestOp{Request: &pb.RequestOp_RequestDeleteRange{RequestDeleteRange: &pb.DeleteRangeRequest{
+				Key: op.Key, RangeEnd: op.RangeEnd,
+			}}})
+		}
+	}
+	return r
+}
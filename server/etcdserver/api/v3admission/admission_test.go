@@ -0,0 +1,126 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3admission
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+func TestTxnOps(t *testing.T) {
+	put := func(k, v string) *pb.RequestOp {
+		return &pb.RequestOp{Request: &pb.RequestOp_RequestPut{RequestPut: &pb.PutRequest{Key: []byte(k), Value: []byte(v)}}}
+	}
+	r := &pb.TxnRequest{
+		Success: []*pb.RequestOp{
+			put("a", "1"),
+			{Request: &pb.RequestOp_RequestRange{RequestRange: &pb.RangeRequest{Key: []byte("b")}}},
+		},
+		Failure: []*pb.RequestOp{
+			{Request: &pb.RequestOp_RequestTxn{RequestTxn: &pb.TxnRequest{
+				Success: []*pb.RequestOp{{Request: &pb.RequestOp_RequestDeleteRange{RequestDeleteRange: &pb.DeleteRangeRequest{Key: []byte("c"), RangeEnd: []byte("d")}}}},
+				Failure: []*pb.RequestOp{put("e", "2")},
+			}}},
+		},
+	}
+	want := []Op{
+		{Type: OpPut, Key: []byte("a"), Value: []byte("1")},
+		{Type: OpDeleteRange, Key: []byte("c"), RangeEnd: []byte("d")},
+		{Type: OpPut, Key: []byte("e"), Value: []byte("2")},
+	}
+	ops := TxnOps(r)
+	assert.Equal(t, want, ops)
+	assert.Equal(t, want, TxnOps(txnRequest(ops)))
+}
+
+func TestRules(t *testing.T) {
+	c, err := Rules(Rule{Pattern: "/config/*", Validate: JSONObject("name")})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		op      Op
+		wantErr bool
+	}{
+		{name: "valid", op: Op{Type: OpPut, Key: []byte("/config/a"), Value: []byte(`{"name":"a"}`)}},
+		{name: "nested key", op: Op{Type: OpPut, Key: []byte("/config/a/b"), Value: []byte(`{"name":"a"}`)}},
+		{name: "missing field", op: Op{Type: OpPut, Key: []byte("/config/a"), Value: []byte(`{"size":1}`)}, wantErr: true},
+		{name: "not an object", op: Op{Type: OpPut, Key: []byte("/config/a/b"), Value: []byte(`null`)}, wantErr: true},
+		{name: "unmatched key", op: Op{Type: OpPut, Key: []byte("/configs"), Value: []byte("x")}},
+		{name: "ignored value", op: Op{Type: OpPut, Key: []byte("/config/a"), IgnoreValue: true}},
+		{name: "delete", op: Op{Type: OpDeleteRange, Key: []byte("/config/a")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.Admit(context.Background(), []Op{tt.op})
+			assert.Equal(t, tt.wantErr, err != nil, "error: %v", err)
+		})
+	}
+
+	_, err = Rules(Rule{Pattern: "/config/[", Validate: JSONObject()})
+	require.Error(t, err)
+}
+
+func TestChain(t *testing.T) {
+	assert.Nil(t, Chain())
+	assert.Nil(t, Chain(nil))
+
+	var calls []string
+	admit := func(name string, err error) Controller {
+		return ControllerFunc(func(context.Context, []Op) error {
+			calls = append(calls, name)
+			return err
+		})
+	}
+	errDenied := errors.New("denied")
+	err := Chain(admit("a", nil), admit("b", errDenied), admit("c", nil)).Admit(context.Background(), nil)
+	require.ErrorIs(t, err, errDenied)
+	assert.Equal(t, []string{"a", "b"}, calls)
+}
+
+func TestWebhookURLPath(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	methods := make(chan string, 1)
+	srv := grpc.NewServer(grpc.UnknownServiceHandler(func(_ any, stream grpc.ServerStream) error {
+		m, _ := grpc.MethodFromServerStream(stream)
+		methods <- m
+		if err := stream.RecvMsg(&pb.TxnRequest{}); err != nil {
+			return err
+		}
+		return stream.SendMsg(&pb.TxnResponse{})
+	}))
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	w, err := NewWebhook(zaptest.NewLogger(t), "http://"+lis.Addr().String()+"/admission/", time.Second, nil)
+	require.NoError(t, err)
+	defer w.Close()
+	require.NoError(t, w.Admit(context.Background(), []Op{{Type: OpPut, Key: []byte("a")}}))
+	assert.Equal(t, "/admission/etcdserverpb.Admission/Review", <-methods)
+
+	_, err = NewWebhook(zaptest.NewLogger(t), "http://"+lis.Addr().String()+"/?q=1", time.Second, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,21 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v3admission implements admission control of etcd write requests.
+// Admission controllers inspect the mutations of Put, DeleteRange and Txn
+// requests before they are proposed to raft, and can reject them, for example
+// to enforce a schema on the values stored under a prefix. Controllers are
+// either compiled into an embedding application or served by an external gRPC
+// webhook.
+package v3admission
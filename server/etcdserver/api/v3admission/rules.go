@@ -0,0 +1,96 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3admission
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+)
+
+// Rule validates the values written to the keys matching Pattern.
+type Rule struct {
+	// Pattern is a path.Match pattern, e.g. "/config/*". A key matches the
+	// pattern if it or one of its "/" separated prefixes does, so that
+	// "/config/*" also matches "/config/a/b".
+	Pattern string
+	// Validate returns an error if the value is not valid for the key.
+	Validate func(key, value []byte) error
+}
+
+// Rules returns a Controller rejecting the puts of values refused by the
+// Validate function of a rule whose pattern matches the key. Puts keeping the
+// current value of a key are not validated. It fails if a pattern is malformed.
+func Rules(rules ...Rule) (Controller, error) {
+	for _, r := range rules {
+		if _, err := path.Match(r.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", r.Pattern, err)
+		}
+		if r.Validate == nil {
+			return nil, fmt.Errorf("rule %q has no validate function", r.Pattern)
+		}
+	}
+	return ControllerFunc(func(ctx context.Context, ops []Op) error {
+		for _, op := range ops {
+			if op.Type != OpPut || op.IgnoreValue {
+				continue
+			}
+			for _, r := range rules {
+				if !matchPattern(r.Pattern, string(op.Key)) {
+					continue
+				}
+				if err := r.Validate(op.Key, op.Value); err != nil {
+					return fmt.Errorf("invalid value for key %q: %w", op.Key, err)
+				}
+			}
+		}
+		return nil
+	}), nil
+}
+
+func matchPattern(pattern, key string) bool {
+	for {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+		i := len(key) - 1
+		for i >= 0 && key[i] != '/' {
+			i--
+		}
+		if i <= 0 {
+			return false
+		}
+		key = key[:i]
+	}
+}
+
+// JSONObject returns a validate function accepting the JSON objects which
+// have all the required fields.
+func JSONObject(required ...string) func(key, value []byte) error {
+	return func(_, value []byte) error {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(value, &obj); err != nil || obj == nil {
+			return errors.New("value is not a JSON object")
+		}
+		for _, f := range required {
+			if _, ok := obj[f]; !ok {
+				return fmt.Errorf("missing required field %q", f)
+			}
+		}
+		return nil
+	}
+}
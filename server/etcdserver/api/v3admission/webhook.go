@@ -0,0 +1,140 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3admission
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+// webhookReviewMethod is the gRPC method called by a Webhook. It is called
+// with a TxnRequest holding the mutations to admit as its success operations,
+// and returns an empty TxnResponse to admit them or a PermissionDenied or
+// InvalidArgument error, whose message is the reason, to reject them.
+const webhookReviewMethod = "/etcdserverpb.Admission/Review"
+
+// Webhook is a Controller delegating admission to an external gRPC service,
+// which can be implemented with RegisterWebhookServer. Requests are rejected
+// if the service is unavailable.
+type Webhook struct {
+	lg      *zap.Logger
+	url     string
+	method  string
+	timeout time.Duration
+	conn    *grpc.ClientConn
+}
+
+// NewWebhook returns a Webhook calling the service at the given URL, with an
+// "http" or "https" scheme. The path of the URL, if any, prefixes the path of
+// the gRPC method, for services behind a proxy routing on it. The TLS config
+// is used for "https" URLs; the system roots are used if it is nil. Each call
+// fails after the timeout.
+func NewWebhook(lg *zap.Logger, webhookURL string, timeout time.Duration, tlsConfig *tls.Config) (*Webhook, error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid admission webhook URL %q: %w", webhookURL, err)
+	}
+	var creds credentials.TransportCredentials
+	switch u.Scheme {
+	case "http":
+		creds = insecure.NewCredentials()
+	case "https":
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	default:
+		return nil, fmt.Errorf("invalid admission webhook URL %q: scheme must be http or https", webhookURL)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid admission webhook URL %q: missing host", webhookURL)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("invalid admission webhook URL %q: query and fragment are not supported", webhookURL)
+	}
+	conn, err := grpc.NewClient(u.Host, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	return &Webhook{
+		lg:      lg,
+		url:     webhookURL,
+		method:  strings.TrimSuffix(u.Path, "/") + webhookReviewMethod,
+		timeout: timeout,
+		conn:    conn,
+	}, nil
+}
+
+func (w *Webhook) Admit(ctx context.Context, ops []Op) error {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	err := w.conn.Invoke(ctx, w.method, txnRequest(ops), &pb.TxnResponse{})
+	if err == nil {
+		return nil
+	}
+	if st, ok := status.FromError(err); ok && (st.Code() == codes.PermissionDenied || st.Code() == codes.InvalidArgument) {
+		return fmt.Errorf("%s", st.Message())
+	}
+	w.lg.Warn("admission webhook failed", zap.String("url", w.url), zap.Error(err))
+	return fmt.Errorf("admission webhook failed: %w", err)
+}
+
+// Close closes the connection to the webhook.
+func (w *Webhook) Close() error {
+	return w.conn.Close()
+}
+
+// admissionServer is the handler type of the webhook service.
+type admissionServer interface {
+	Controller
+}
+
+// RegisterWebhookServer registers on the gRPC server the webhook service
+// called by a Webhook, admitting the requests with the given controller.
+func RegisterWebhookServer(s *grpc.Server, c Controller) {
+	s.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "etcdserverpb.Admission",
+		HandlerType: (*admissionServer)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Review",
+			Handler:    reviewHandler,
+		}},
+		Metadata: "admission",
+	}, c)
+}
+
+func reviewHandler(srv any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+	r := &pb.TxnRequest{}
+	if err := dec(r); err != nil {
+		return nil, err
+	}
+	if err := srv.(Controller).Admit(ctx, TxnOps(r)); err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	return &pb.TxnResponse{}, nil
+}
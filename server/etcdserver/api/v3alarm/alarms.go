@@ -58,10 +58,17 @@ func NewAlarmStore(lg *zap.Logger, be AlarmBackend) (*AlarmStore, error) {
 }
 
 func (a *AlarmStore) Activate(id types.ID, at pb.AlarmType) *pb.AlarmMember {
+	return a.ActivateRange(id, at, nil, nil)
+}
+
+// ActivateRange activates the alarm like Activate, recording the key range
+// [key, end) it is raised for. If the alarm is already active, the range it
+// was first raised for is kept.
+func (a *AlarmStore) ActivateRange(id types.ID, at pb.AlarmType, key, end []byte) *pb.AlarmMember {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	newAlarm := &pb.AlarmMember{MemberID: uint64(id), Alarm: at}
+	newAlarm := &pb.AlarmMember{MemberID: uint64(id), Alarm: at, Key: key, RangeEnd: end}
 	if m := a.addToMap(newAlarm); m != newAlarm {
 		return m
 	}
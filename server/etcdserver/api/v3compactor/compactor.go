@@ -51,6 +51,24 @@ type RevGetter interface {
 	Rev() int64
 }
 
+// PinnedRevGetter is implemented by the Compactables rejecting compactions
+// above the revisions pinned by leases.
+type PinnedRevGetter interface {
+	// MinPinnedRevision returns the lowest pinned revision, or 0 if none.
+	MinPinnedRevision() int64
+}
+
+// clampToPinned returns rev, or the lowest revision pinned on c if lower, so
+// that the compaction is not rejected.
+func clampToPinned(c Compactable, rev int64) int64 {
+	if pg, ok := c.(PinnedRevGetter); ok {
+		if pinned := pg.MinPinnedRevision(); pinned > 0 && pinned < rev {
+			return pinned
+		}
+	}
+	return rev
+}
+
 // New returns a new Compactor based on given "mode".
 func New(
 	lg *zap.Logger,
@@ -31,6 +31,13 @@ func (fc *fakeCompactable) Compact(ctx context.Context, r *pb.CompactionRequest)
 	return &pb.CompactionResponse{}, nil
 }
 
+type fakePinnedCompactable struct {
+	fakeCompactable
+	pinned int64
+}
+
+func (fc *fakePinnedCompactable) MinPinnedRevision() int64 { return fc.pinned }
+
 type fakeRevGetter struct {
 	testutil.Recorder
 	rev int64
@@ -122,7 +122,7 @@ func (pc *Periodic) Run() {
 					continue
 				}
 			}
-			rev := pc.revs[0]
+			rev := clampToPinned(pc.c, pc.revs[0])
 			if pc.clock.Now().Sub(lastSuccess) < baseInterval || rev == lastRevision {
 				continue
 			}
@@ -77,7 +77,7 @@ func (rc *Revision) Run() {
 				}
 			}
 
-			rev := rc.rg.Rev() - rc.retention
+			rev := clampToPinned(rc.c, rc.rg.Rev()-rc.retention)
 			if rev <= 0 || rev == prev {
 				continue
 			}
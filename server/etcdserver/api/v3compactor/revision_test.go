@@ -69,6 +69,40 @@ func TestRevision(t *testing.T) {
 	}
 }
 
+// TestRevisionPinned ensures the compactor doesn't compact above the revision
+// pinned by a lease.
+func TestRevisionPinned(t *testing.T) {
+	fc := clockwork.NewFakeClock()
+	rg := &fakeRevGetter{testutil.NewRecorderStreamWithWaitTimout(10 * time.Millisecond), 0}
+	compactable := &fakePinnedCompactable{fakeCompactable{testutil.NewRecorderStreamWithWaitTimout(10 * time.Millisecond)}, 50}
+	tb := newRevision(zaptest.NewLogger(t), fc, 10, rg, compactable)
+
+	tb.Run()
+	defer tb.Stop()
+
+	fc.Advance(revInterval)
+	rg.Wait(1)
+	// nothing happens
+
+	rg.SetRev(99) // will be 100, and 90 without the pin
+	fc.Advance(revInterval)
+	rg.Wait(1)
+	a, err := compactable.Wait(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(a[0].Params[0], &pb.CompactionRequest{Revision: 50}) {
+		t.Errorf("compact request = %v, want %v", a[0].Params[0], &pb.CompactionRequest{Revision: 50})
+	}
+
+	// the pinned revision is not compacted again
+	fc.Advance(revInterval)
+	rg.Wait(1)
+	if a, _ = compactable.Wait(1); len(a) != 0 {
+		t.Errorf("unexpected compaction %v", a)
+	}
+}
+
 func TestRevisionPause(t *testing.T) {
 	fc := clockwork.NewFakeClock()
 	rg := &fakeRevGetter{testutil.NewRecorderStream(), 99} // will be 100
@@ -0,0 +1,308 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3hotkeys
+
+import (
+	"bytes"
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+// DefaultCapacity is the default number of keys tracked for reads and for
+// writes each.
+const DefaultCapacity = 1024
+
+// decayInterval is how often the counts of the tracked keys are halved, so
+// that the keys reported are those of the recent workload.
+var decayInterval = time.Minute
+
+// Key is a key, or a range of keys, and its estimated number of accesses.
+type Key struct {
+	Key []byte
+	// RangeEnd is the end of the range [Key, RangeEnd) accessed, or nil for
+	// a single key.
+	RangeEnd []byte
+	Count    int64
+}
+
+// Tracker samples the requests served by a member and keeps the keys they
+// access most frequently: the keys read and written, the ranges read and
+// deleted, and the prefixes of the keys up to their last '/'. The counts are
+// estimated with the space-saving algorithm, in bounded memory, and halved
+// every minute. A Tracker is safe for concurrent use.
+type Tracker struct {
+	rate float64
+
+	mu        sync.Mutex
+	reads     *topK
+	writes    *topK
+	lastDecay time.Time
+}
+
+// NewTracker returns a Tracker sampling a sampleRate fraction of the
+// requests and tracking up to capacity keys for reads and for writes each.
+func NewTracker(sampleRate float64, capacity int) *Tracker {
+	return &Tracker{
+		rate:      sampleRate,
+		reads:     newTopK(capacity),
+		writes:    newTopK(capacity),
+		lastDecay: time.Now(),
+	}
+}
+
+// SampleRate returns the fraction of the requests sampled.
+func (t *Tracker) SampleRate() float64 {
+	return t.rate
+}
+
+// Range records the keys read by a Range request.
+func (t *Tracker) Range(r *pb.RangeRequest) {
+	if !t.sample() {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.decay(time.Now())
+	t.reads.record(r.Key, r.RangeEnd)
+}
+
+// Put records the key written by a Put request.
+func (t *Tracker) Put(r *pb.PutRequest) {
+	if !t.sample() {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.decay(time.Now())
+	t.writes.record(r.Key, nil)
+}
+
+// DeleteRange records the keys written by a DeleteRange request.
+func (t *Tracker) DeleteRange(r *pb.DeleteRangeRequest) {
+	if !t.sample() {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.decay(time.Now())
+	t.writes.record(r.Key, r.RangeEnd)
+}
+
+// Txn records the keys compared by a Txn request as read, and the keys
+// accessed by the operations of the branch it executed, given by its
+// response. Only the comparisons are recorded if resp is nil.
+func (t *Tracker) Txn(r *pb.TxnRequest, resp *pb.TxnResponse) {
+	if !t.sample() {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.decay(time.Now())
+	t.txn(r, resp)
+}
+
+func (t *Tracker) txn(r *pb.TxnRequest, resp *pb.TxnResponse) {
+	for _, c := range r.Compare {
+		t.reads.record(c.Key, c.RangeEnd)
+	}
+	if resp == nil {
+		return
+	}
+	ops := r.Failure
+	if resp.Succeeded {
+		ops = r.Success
+	}
+	for i, op := range ops {
+		switch tv := op.Request.(type) {
+		case *pb.RequestOp_RequestRange:
+			t.reads.record(tv.RequestRange.Key, tv.RequestRange.RangeEnd)
+		case *pb.RequestOp_RequestPut:
+			t.writes.record(tv.RequestPut.Key, nil)
+		case *pb.RequestOp_RequestDeleteRange:
+			t.writes.record(tv.RequestDeleteRange.Key, tv.RequestDeleteRange.RangeEnd)
+		case *pb.RequestOp_RequestTxn:
+			var nested *pb.TxnResponse
+			if i < len(resp.Responses) {
+				nested = resp.Responses[i].GetResponseTxn()
+			}
+			t.txn(tv.RequestTxn, nested)
+		}
+	}
+}
+
+// Top returns the keys most frequently read and written, by decreasing
+// count, up to limit each, or all the tracked keys if limit is zero.
+func (t *Tracker) Top(limit int) (reads, writes []Key) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.decay(time.Now())
+	return t.reads.top(limit, t.rate), t.writes.top(limit, t.rate)
+}
+
+func (t *Tracker) sample() bool {
+	return t.rate >= 1 || rand.Float64() < t.rate
+}
+
+// decay halves the counts for every decayInterval passed since the last
+// decay.
+func (t *Tracker) decay(now time.Time) {
+	n := now.Sub(t.lastDecay) / decayInterval
+	if n <= 0 {
+		return
+	}
+	t.lastDecay = t.lastDecay.Add(n * decayInterval)
+	shift := uint(min(n, 63))
+	t.reads.decay(shift)
+	t.writes.decay(shift)
+}
+
+// span is a key, or a range of keys if end is not empty.
+type span struct {
+	key, end string
+}
+
+type entry struct {
+	span
+	count int64
+	// index is the index of the entry in the heap.
+	index int
+}
+
+// topK counts the most frequent spans with the space-saving algorithm: once
+// capacity spans are tracked, a new span replaces the least frequent one and
+// inherits its count, which overestimates the count of the new span but
+// keeps the frequent spans tracked.
+type topK struct {
+	capacity int
+	entries  map[span]*entry
+	// h is a min-heap of the entries by count.
+	h entryHeap
+}
+
+func newTopK(capacity int) *topK {
+	return &topK{capacity: capacity, entries: make(map[span]*entry)}
+}
+
+// record counts an access to [key, end), and to the prefix of key up to its
+// last '/' for a single key.
+func (k *topK) record(key, end []byte) {
+	k.add(span{key: string(key), end: string(end)})
+	if len(end) != 0 {
+		return
+	}
+	if i := bytes.LastIndexByte(key, '/'); i >= 0 {
+		// the prefix ends with '/', which the range end increments
+		k.add(span{key: string(key[:i+1]), end: string(key[:i]) + "0"})
+	}
+}
+
+func (k *topK) add(s span) {
+	if e, ok := k.entries[s]; ok {
+		e.count++
+		heap.Fix(&k.h, e.index)
+		return
+	}
+	if len(k.h) < k.capacity {
+		e := &entry{span: s, count: 1}
+		k.entries[s] = e
+		heap.Push(&k.h, e)
+		return
+	}
+	if len(k.h) == 0 {
+		return
+	}
+	e := k.h[0]
+	delete(k.entries, e.span)
+	e.span = s
+	e.count++
+	k.entries[s] = e
+	heap.Fix(&k.h, 0)
+}
+
+// decay divides the counts by 2^shift, and drops the entries left at zero.
+func (k *topK) decay(shift uint) {
+	h := k.h[:0]
+	for _, e := range k.h {
+		e.count >>= shift
+		if e.count == 0 {
+			delete(k.entries, e.span)
+			continue
+		}
+		e.index = len(h)
+		h = append(h, e)
+	}
+	for i := len(h); i < len(k.h); i++ {
+		k.h[i] = nil
+	}
+	k.h = h
+	heap.Init(&k.h)
+}
+
+// top returns the most frequent spans, with their counts scaled by the
+// sample rate.
+func (k *topK) top(limit int, rate float64) []Key {
+	es := make([]*entry, len(k.h))
+	copy(es, k.h)
+	sort.Slice(es, func(i, j int) bool {
+		if es[i].count != es[j].count {
+			return es[i].count > es[j].count
+		}
+		if es[i].key != es[j].key {
+			return es[i].key < es[j].key
+		}
+		return es[i].end < es[j].end
+	})
+	if limit > 0 && len(es) > limit {
+		es = es[:limit]
+	}
+	keys := make([]Key, len(es))
+	for i, e := range es {
+		keys[i] = Key{Key: []byte(e.key), Count: int64(math.Round(float64(e.count) / rate))}
+		if e.end != "" {
+			keys[i].RangeEnd = []byte(e.end)
+		}
+	}
+	return keys
+}
+
+type entryHeap []*entry
+
+func (h entryHeap) Len() int           { return len(h) }
+func (h entryHeap) Less(i, j int) bool { return h[i].count < h[j].count }
+func (h entryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *entryHeap) Push(x any) {
+	e := x.(*entry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *entryHeap) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3hotkeys
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+func TestTrackerTop(t *testing.T) {
+	tr := NewTracker(1, DefaultCapacity)
+	for i := 0; i < 3; i++ {
+		tr.Put(&pb.PutRequest{Key: []byte("/a/x")})
+	}
+	tr.Put(&pb.PutRequest{Key: []byte("/a/y")})
+	tr.Put(&pb.PutRequest{Key: []byte("b")})
+	tr.DeleteRange(&pb.DeleteRangeRequest{Key: []byte("c"), RangeEnd: []byte("d")})
+	tr.Range(&pb.RangeRequest{Key: []byte("/a/"), RangeEnd: []byte("/a0")})
+	tr.Range(&pb.RangeRequest{Key: []byte("/a/x")})
+
+	reads, writes := tr.Top(0)
+	assert.Equal(t, []Key{
+		{Key: []byte("/a/"), RangeEnd: []byte("/a0"), Count: 2},
+		{Key: []byte("/a/x"), Count: 1},
+	}, reads)
+	assert.Equal(t, []Key{
+		{Key: []byte("/a/"), RangeEnd: []byte("/a0"), Count: 4},
+		{Key: []byte("/a/x"), Count: 3},
+		{Key: []byte("/a/y"), Count: 1},
+		{Key: []byte("b"), Count: 1},
+		{Key: []byte("c"), RangeEnd: []byte("d"), Count: 1},
+	}, writes)
+
+	reads, writes = tr.Top(1)
+	assert.Len(t, reads, 1)
+	assert.Len(t, writes, 1)
+}
+
+func TestTrackerTxn(t *testing.T) {
+	tr := NewTracker(1, DefaultCapacity)
+	r := &pb.TxnRequest{
+		Compare: []*pb.Compare{{Key: []byte("cmp")}},
+		Success: []*pb.RequestOp{
+			{Request: &pb.RequestOp_RequestPut{RequestPut: &pb.PutRequest{Key: []byte("success")}}},
+			{Request: &pb.RequestOp_RequestTxn{RequestTxn: &pb.TxnRequest{
+				Failure: []*pb.RequestOp{{Request: &pb.RequestOp_RequestRange{RequestRange: &pb.RangeRequest{Key: []byte("nested")}}}},
+			}}},
+		},
+		Failure: []*pb.RequestOp{
+			{Request: &pb.RequestOp_RequestPut{RequestPut: &pb.PutRequest{Key: []byte("failure")}}},
+		},
+	}
+	tr.Txn(r, &pb.TxnResponse{
+		Succeeded: true,
+		Responses: []*pb.ResponseOp{
+			{Response: &pb.ResponseOp_ResponsePut{ResponsePut: &pb.PutResponse{}}},
+			{Response: &pb.ResponseOp_ResponseTxn{ResponseTxn: &pb.TxnResponse{Succeeded: false}}},
+		},
+	})
+	// the branches are unknown without a response
+	tr.Txn(r, nil)
+
+	reads, writes := tr.Top(0)
+	assert.Equal(t, []Key{{Key: []byte("cmp"), Count: 2}, {Key: []byte("nested"), Count: 1}}, reads)
+	assert.Equal(t, []Key{{Key: []byte("success"), Count: 1}}, writes)
+}
+
+func TestTrackerCapacity(t *testing.T) {
+	tr := NewTracker(1, 2)
+	for i := 0; i < 5; i++ {
+		tr.Put(&pb.PutRequest{Key: []byte("hot")})
+	}
+	for _, key := range []string{"a", "b", "c", "d"} {
+		tr.Put(&pb.PutRequest{Key: []byte(key)})
+	}
+
+	// the least frequent key is replaced, and its count overestimated
+	_, writes := tr.Top(0)
+	assert.Equal(t, []Key{{Key: []byte("hot"), Count: 5}, {Key: []byte("d"), Count: 4}}, writes)
+}
+
+func TestTrackerDecay(t *testing.T) {
+	tr := NewTracker(0.5, DefaultCapacity)
+	for i := 0; i < 8; i++ {
+		tr.writes.record([]byte("a"), nil)
+	}
+	tr.writes.record([]byte("b"), nil)
+
+	tr.lastDecay = tr.lastDecay.Add(-2 * decayInterval)
+	_, writes := tr.Top(0)
+	// the counts are scaled by the sample rate
+	assert.Equal(t, []Key{{Key: []byte("a"), Count: 4}}, writes)
+	assert.WithinDuration(t, time.Now(), tr.lastDecay, decayInterval)
+}
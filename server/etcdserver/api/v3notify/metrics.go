@@ -0,0 +1,50 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3notify
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	eventsSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "change_notify_events_sent_total",
+		Help:      "The total number of key changes delivered to change notification targets.",
+	},
+		[]string{"target"},
+	)
+	sendFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "change_notify_send_failures_total",
+		Help:      "The total number of failed attempts to deliver a batch of key changes to change notification targets.",
+	},
+		[]string{"target"},
+	)
+	compactionMisses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "change_notify_compacted_total",
+		Help:      "The total number of times change notification targets fell behind a compaction, missing the compacted changes.",
+	},
+		[]string{"target"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(eventsSent)
+	prometheus.MustRegister(sendFailures)
+	prometheus.MustRegister(compactionMisses)
+}
@@ -0,0 +1,302 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3notify
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/client/pkg/v3/fileutil"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+)
+
+var (
+	// batchInterval is how long changes are accumulated before a batch is
+	// sent, unless maxBatchEvents is reached first.
+	batchInterval = 100 * time.Millisecond
+	// maxBatchEvents is the number of changes above which a batch is sent
+	// right away.
+	maxBatchEvents = 1000
+	// sendTimeout bounds one attempt to send a batch.
+	sendTimeout = 10 * time.Second
+	// minRetryInterval and maxRetryInterval bound the exponential backoff
+	// between attempts to send a batch.
+	minRetryInterval = 100 * time.Millisecond
+	maxRetryInterval = 30 * time.Second
+	// leaderCheckInterval is how often the notifier checks whether the
+	// member became or is still leader.
+	leaderCheckInterval = time.Second
+)
+
+// Watchable is the key-value store whose changes are sent.
+type Watchable interface {
+	NewWatchStream() mvcc.WatchStream
+	Rev() int64
+}
+
+// Notifier sends the changes to the keys under the prefix of a target to
+// its sink, at least once: the revision up to which the changes have been
+// delivered is saved in a file, from which they're sent again after a
+// restart. Changes compacted before they could be delivered are missed.
+//
+// In a cluster, only the leader sends the changes so that the sink doesn't
+// receive them from every member. A member becoming leader sends them from
+// the revision it last delivered, or the one it started at, so the changes
+// delivered by the previous leader may be received again.
+type Notifier struct {
+	lg           *zap.Logger
+	kv           Watchable
+	t            Target
+	progressPath string
+	isLeader     func() bool
+}
+
+// New returns a notifier for the target, keeping its progress in dir. The
+// changes are only sent while isLeader returns true, or always if it's nil.
+func New(lg *zap.Logger, kv Watchable, dir string, t Target, isLeader func() bool) *Notifier {
+	if lg == nil {
+		lg = zap.NewNop()
+	}
+	return &Notifier{
+		lg:           lg.With(zap.String("target", t.Name), zap.String("prefix", t.Prefix)),
+		kv:           kv,
+		t:            t,
+		progressPath: filepath.Join(dir, t.Name),
+		isLeader:     isLeader,
+	}
+}
+
+func (n *Notifier) leading() bool {
+	return n.isLeader == nil || n.isLeader()
+}
+
+// Run sends the changes until stopc is closed.
+func (n *Notifier) Run(stopc <-chan struct{}) {
+	rev, err := n.loadProgress()
+	if err != nil {
+		n.lg.Warn("failed to load change notification progress; starting from current revision", zap.Error(err))
+	}
+	if rev == 0 {
+		rev = n.kv.Rev()
+		// saved right away so that the changes made from now on are sent
+		// once the member becomes leader
+		if err = n.saveProgress(rev); err != nil {
+			n.lg.Warn("failed to save change notification progress", zap.Int64("revision", rev), zap.Error(err))
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopc:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		for !n.leading() {
+			select {
+			case <-time.After(leaderCheckInterval):
+			case <-ctx.Done():
+				return
+			}
+		}
+		var ok bool
+		if rev, ok = n.deliver(ctx, rev); !ok {
+			return
+		}
+		n.lg.Info("stopped sending key changes; member is no longer leader", zap.Int64("revision", rev))
+	}
+}
+
+// deliver sends the changes after rev until the member is no longer leader,
+// and returns the revision up to which they were delivered. It returns
+// false if ctx is done or the changes can't be watched.
+func (n *Notifier) deliver(ctx context.Context, rev int64) (int64, bool) {
+	n.lg.Info("sending key changes", zap.Int64("start-revision", rev+1))
+
+	ws := n.kv.NewWatchStream()
+	defer ws.Close()
+	key, end := []byte(n.t.Prefix), []byte(clientv3.GetPrefixRangeEnd(n.t.Prefix))
+	if len(key) == 0 {
+		key = []byte{0}
+	}
+	if len(end) == 1 && end[0] == 0 {
+		// all keys from key on
+		end = []byte{}
+	}
+	if _, err := ws.Watch(0, key, end, rev+1); err != nil {
+		n.lg.Warn("failed to watch key changes", zap.Error(err))
+		return rev, false
+	}
+
+	leaderCheck := time.NewTicker(leaderCheckInterval)
+	defer leaderCheck.Stop()
+	var (
+		pending []Event
+		timer   <-chan time.Time
+	)
+	flush := func() bool {
+		if len(pending) == 0 {
+			return true
+		}
+		if !n.send(ctx, &Batch{Target: n.t.Name, Events: pending}) {
+			return false
+		}
+		eventsSent.WithLabelValues(n.t.Name).Add(float64(len(pending)))
+		rev = pending[len(pending)-1].ModRevision
+		if err := n.saveProgress(rev); err != nil {
+			n.lg.Warn("failed to save change notification progress", zap.Int64("revision", rev), zap.Error(err))
+		}
+		pending, timer = nil, nil
+		return true
+	}
+	for {
+		select {
+		case wr, ok := <-ws.Chan():
+			if !ok {
+				return rev, false
+			}
+			if wr.CompactRevision != 0 {
+				if !flush() {
+					return rev, ctx.Err() == nil
+				}
+				n.lg.Warn(
+					"key changes were compacted before they were sent",
+					zap.Int64("from-revision", rev+1),
+					zap.Int64("compact-revision", wr.CompactRevision),
+				)
+				compactionMisses.WithLabelValues(n.t.Name).Inc()
+				rev = wr.CompactRevision - 1
+				ws.Cancel(wr.WatchID)
+				if _, err := ws.Watch(0, key, end, wr.CompactRevision); err != nil {
+					n.lg.Warn("failed to watch key changes", zap.Error(err))
+					return rev, false
+				}
+				continue
+			}
+			for _, ev := range wr.Events {
+				pending = append(pending, toEvent(ev))
+			}
+			if len(pending) >= maxBatchEvents {
+				if !flush() {
+					return rev, ctx.Err() == nil
+				}
+			} else if len(pending) > 0 && timer == nil {
+				timer = time.After(batchInterval)
+			}
+		case <-timer:
+			if !flush() {
+				return rev, ctx.Err() == nil
+			}
+		case <-leaderCheck.C:
+			if !n.leading() {
+				return rev, true
+			}
+		case <-ctx.Done():
+			return rev, false
+		}
+	}
+}
+
+// send sends the batch until it succeeds, backing off exponentially, and
+// returns false if ctx is done or the member is no longer leader first.
+func (n *Notifier) send(ctx context.Context, b *Batch) bool {
+	wait := minRetryInterval
+	for {
+		sctx, cancel := context.WithTimeout(ctx, sendTimeout)
+		err := n.t.Sink.Send(sctx, b)
+		cancel()
+		if err == nil {
+			return true
+		}
+		sendFailures.WithLabelValues(n.t.Name).Inc()
+		n.lg.Warn("failed to send key changes; retrying",
+			zap.Int("events", len(b.Events)),
+			zap.Duration("retry-in", wait),
+			zap.Error(err),
+		)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return false
+		}
+		if !n.leading() {
+			return false
+		}
+		wait = min(2*wait, maxRetryInterval)
+	}
+}
+
+func toEvent(ev mvccpb.Event) Event {
+	e := Event{
+		Type:           ev.Type.String(),
+		Key:            ev.Kv.Key,
+		ModRevision:    ev.Kv.ModRevision,
+		CreateRevision: ev.Kv.CreateRevision,
+		Version:        ev.Kv.Version,
+		Lease:          ev.Kv.Lease,
+	}
+	if ev.Type == mvccpb.PUT {
+		e.Value = ev.Kv.Value
+	}
+	return e
+}
+
+// loadProgress returns the revision up to which the changes have been
+// delivered, or 0 if none has been saved.
+func (n *Notifier) loadProgress() (int64, error) {
+	b, err := os.ReadFile(n.progressPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+}
+
+func (n *Notifier) saveProgress(rev int64) error {
+	dir := filepath.Dir(n.progressPath)
+	if err := os.MkdirAll(dir, fileutil.PrivateDirMode); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, n.t.Name+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.WriteString(strconv.FormatInt(rev, 10)); err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), n.progressPath)
+}
@@ -0,0 +1,177 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"go.etcd.io/etcd/pkg/v3/traceutil"
+	"go.etcd.io/etcd/server/v3/lease"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+)
+
+type fakeSink struct {
+	mu       sync.Mutex
+	failures int
+	events   []Event
+}
+
+func (s *fakeSink) Send(_ context.Context, b *Batch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("unavailable")
+	}
+	s.events = append(s.events, b.Events...)
+	return nil
+}
+
+func (s *fakeSink) keys() (keys []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ev := range s.events {
+		keys = append(keys, ev.Type+" "+string(ev.Key))
+	}
+	return keys
+}
+
+func TestNotifier(t *testing.T) {
+	defer func(d time.Duration) { minRetryInterval = d }(minRetryInterval)
+	minRetryInterval = time.Millisecond
+
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, b)
+	kv := mvcc.New(zaptest.NewLogger(t), b, &lease.FakeLessor{}, mvcc.StoreConfig{})
+	defer kv.Close()
+	dir := t.TempDir()
+
+	sink := &fakeSink{failures: 2}
+	run := func() (stop func()) {
+		stopc, donec := make(chan struct{}), make(chan struct{})
+		n := New(zaptest.NewLogger(t), kv, dir, Target{Name: "test", Prefix: "foo/", Sink: sink}, nil)
+		go func() {
+			n.Run(stopc)
+			close(donec)
+		}()
+		return func() {
+			close(stopc)
+			<-donec
+		}
+	}
+
+	// changes made before the first run are not sent
+	kv.Put([]byte("foo/old"), []byte("v"), lease.NoLease)
+	stop := run()
+	// wait for the watch to be set up
+	time.Sleep(100 * time.Millisecond)
+	kv.Put([]byte("foo/a"), []byte("1"), lease.NoLease)
+	kv.Put([]byte("bar/x"), []byte("1"), lease.NoLease)
+	kv.Put([]byte("foo/b"), []byte("2"), lease.NoLease)
+	kv.DeleteRange([]byte("foo/a"), nil)
+	want := []string{"PUT foo/a", "PUT foo/b", "DELETE foo/a"}
+	require.Eventually(t, func() bool { return len(sink.keys()) == len(want) }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, want, sink.keys())
+	stop()
+
+	// changes made while stopped are sent after a restart
+	txn := kv.Write(traceutil.TODO())
+	txn.Put([]byte("foo/c"), []byte("3"), lease.NoLease)
+	txn.Put([]byte("foo/d"), []byte("4"), lease.NoLease)
+	txn.End()
+	stop = run()
+	defer stop()
+	want = append(want, "PUT foo/c", "PUT foo/d")
+	require.Eventually(t, func() bool { return len(sink.keys()) == len(want) }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, want, sink.keys())
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	assert.Equal(t, kv.Rev(), sink.events[len(sink.events)-1].ModRevision)
+	assert.Equal(t, []byte("4"), sink.events[len(sink.events)-1].Value)
+}
+
+func TestNotifierLeader(t *testing.T) {
+	defer func(d time.Duration) { leaderCheckInterval = d }(leaderCheckInterval)
+	leaderCheckInterval = 10 * time.Millisecond
+
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, b)
+	kv := mvcc.New(zaptest.NewLogger(t), b, &lease.FakeLessor{}, mvcc.StoreConfig{})
+	defer kv.Close()
+
+	var leader atomic.Bool
+	sink := &fakeSink{}
+	stopc, donec := make(chan struct{}), make(chan struct{})
+	n := New(zaptest.NewLogger(t), kv, t.TempDir(), Target{Name: "test", Prefix: "foo/", Sink: sink}, leader.Load)
+	go func() {
+		n.Run(stopc)
+		close(donec)
+	}()
+	defer func() {
+		close(stopc)
+		<-donec
+	}()
+
+	// changes are not sent by a follower, but once it becomes leader
+	time.Sleep(100 * time.Millisecond)
+	kv.Put([]byte("foo/a"), []byte("1"), lease.NoLease)
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, sink.keys())
+	leader.Store(true)
+	require.Eventually(t, func() bool { return len(sink.keys()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"PUT foo/a"}, sink.keys())
+
+	// changes made once leadership is lost are sent when it's regained
+	leader.Store(false)
+	time.Sleep(100 * time.Millisecond)
+	kv.Put([]byte("foo/b"), []byte("2"), lease.NoLease)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, []string{"PUT foo/a"}, sink.keys())
+	leader.Store(true)
+	require.Eventually(t, func() bool { return len(sink.keys()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"PUT foo/a", "PUT foo/b"}, sink.keys())
+}
+
+func TestHTTPSink(t *testing.T) {
+	var got Batch
+	status := http.StatusInternalServerError
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	s := NewHTTPSink(srv.URL, nil)
+	b := &Batch{Target: "test", Events: []Event{{Type: "PUT", Key: []byte("foo"), Value: []byte("bar"), ModRevision: 2}}}
+	require.Error(t, s.Send(context.Background(), b))
+	status = http.StatusNoContent
+	require.NoError(t, s.Send(context.Background(), b))
+	assert.Equal(t, *b, got)
+}
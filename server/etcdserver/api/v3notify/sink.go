@@ -0,0 +1,93 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Event is a change to a key.
+type Event struct {
+	// Type is either "PUT" or "DELETE".
+	Type           string `json:"type"`
+	Key            []byte `json:"key"`
+	Value          []byte `json:"value,omitempty"`
+	CreateRevision int64  `json:"create_revision,omitempty"`
+	ModRevision    int64  `json:"mod_revision"`
+	Version        int64  `json:"version,omitempty"`
+	Lease          int64  `json:"lease,omitempty"`
+}
+
+// Batch is a batch of changes sent to a sink, in revision order. The changes
+// made at one revision are never split across batches.
+type Batch struct {
+	// Target is the name of the target the batch is sent for.
+	Target string  `json:"target"`
+	Events []Event `json:"events"`
+}
+
+// Sink delivers batches of changes to an external system. A batch is sent
+// again until Send succeeds, so a sink may receive it more than once;
+// receivers can deduplicate on the events' ModRevision.
+type Sink interface {
+	Send(ctx context.Context, b *Batch) error
+}
+
+// Target sends the changes to the keys under Prefix to Sink.
+type Target struct {
+	// Name identifies the target. The delivery progress of the target is
+	// kept across restarts under its name.
+	Name   string
+	Prefix string
+	Sink   Sink
+}
+
+type httpSink struct {
+	url string
+	c   *http.Client
+}
+
+// NewHTTPSink returns a sink POSTing the batches as JSON to the given URL,
+// through rt if not nil. Any response but a 2xx fails the delivery.
+func NewHTTPSink(url string, rt http.RoundTripper) Sink {
+	return &httpSink{url: url, c: &http.Client{Transport: rt}}
+}
+
+func (s *httpSink) Send(ctx context.Context, b *Batch) error {
+	body, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response from %s: %s", s.url, resp.Status)
+	}
+	return nil
+}
@@ -102,6 +102,14 @@ func (as *AuthServer) RoleRevokePermission(ctx context.Context, r *pb.AuthRoleRe
 	return resp, nil
 }
 
+func (as *AuthServer) CheckPermission(ctx context.Context, r *pb.AuthCheckPermissionRequest) (*pb.AuthCheckPermissionResponse, error) {
+	resp, err := as.authenticator.CheckPermission(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	return resp, nil
+}
+
 func (as *AuthServer) RoleGrantPermission(ctx context.Context, r *pb.AuthRoleGrantPermissionRequest) (*pb.AuthRoleGrantPermissionResponse, error) {
 	resp, err := as.authenticator.RoleGrantPermission(ctx, r)
 	if err != nil {
@@ -0,0 +1,189 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3rpc
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"go.etcd.io/etcd/server/v3/etcdserver"
+)
+
+// ParseClientIPAllowlist parses a list of IP addresses and CIDR ranges that
+// are exempted from the per client IP connection and stream limits.
+func ParseClientIPAllowlist(allowlist []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(allowlist))
+	for _, a := range allowlist {
+		if strings.Contains(a, "/") {
+			_, n, err := net.ParseCIDR(a)
+			if err != nil {
+				return nil, fmt.Errorf("invalid client IP allowlist entry %q: %w", a, err)
+			}
+			nets = append(nets, n)
+			continue
+		}
+		ip := net.ParseIP(a)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid client IP allowlist entry %q", a)
+		}
+		bits := 8 * net.IPv4len
+		if ip.To4() == nil {
+			bits = 8 * net.IPv6len
+		}
+		nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return nets, nil
+}
+
+// clientIPLimiter counts the resources held by each remote IP and refuses
+// to hand out more than max of them, unless the IP is allowlisted.
+type clientIPLimiter struct {
+	max     int
+	allowed []*net.IPNet
+
+	mu    sync.Mutex
+	inUse map[string]int
+}
+
+func newClientIPLimiter(max int, allowed []*net.IPNet) *clientIPLimiter {
+	return &clientIPLimiter{max: max, allowed: allowed, inUse: make(map[string]int)}
+}
+
+// acquire reserves one resource for the given remote address. It returns
+// false if the limit of the address is reached. Otherwise, the returned
+// release function must be called once the resource is freed.
+func (l *clientIPLimiter) acquire(addr string) (release func(), ok bool) {
+	host := hostOf(addr)
+	if l.isAllowed(host) {
+		return func() {}, true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inUse[host] >= l.max {
+		return nil, false
+	}
+	l.inUse[host]++
+	var once sync.Once
+	return func() { once.Do(func() { l.release(host) }) }, true
+}
+
+func (l *clientIPLimiter) release(host string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inUse[host]--
+	if l.inUse[host] <= 0 {
+		delete(l.inUse, host)
+	}
+}
+
+func (l *clientIPLimiter) isAllowed(host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range l.allowed {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func hostOf(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// LimitListenerPerClientIP returns a Listener that accepts at most max
+// simultaneous connections from each remote IP. Connections beyond the limit
+// are closed right after being accepted. Remote IPs matching the allowlist,
+// such as the addresses of proxies, are not limited.
+func LimitListenerPerClientIP(lg *zap.Logger, l net.Listener, max int, allowed []*net.IPNet) net.Listener {
+	if lg == nil {
+		lg = zap.NewNop()
+	}
+	return &clientIPLimitListener{Listener: l, lg: lg, limiter: newClientIPLimiter(max, allowed)}
+}
+
+type clientIPLimitListener struct {
+	net.Listener
+	lg      *zap.Logger
+	limiter *clientIPLimiter
+}
+
+func (l *clientIPLimitListener) Accept() (net.Conn, error) {
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		release, ok := l.limiter.acquire(c.RemoteAddr().String())
+		if !ok {
+			rejectedClientConnections.Inc()
+			l.lg.Debug(
+				"rejected client connection; too many connections from client address",
+				zap.String("remote", c.RemoteAddr().String()),
+				zap.Int("max-connections-per-client-ip", l.limiter.max),
+			)
+			c.Close()
+			continue
+		}
+		return &clientIPLimitConn{Conn: c, release: release}, nil
+	}
+}
+
+type clientIPLimitConn struct {
+	net.Conn
+	release func()
+}
+
+func (c *clientIPLimitConn) Close() error {
+	err := c.Conn.Close()
+	c.release()
+	return err
+}
+
+func newClientIPStreamLimitInterceptor(s *etcdserver.EtcdServer, l *clientIPLimiter) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		p, ok := peer.FromContext(ss.Context())
+		if !ok {
+			return handler(srv, ss)
+		}
+		release, ok := l.acquire(p.Addr.String())
+		if !ok {
+			rejectedClientStreams.Inc()
+			s.Logger().Debug(
+				"rejected client stream; too many streams from client address",
+				zap.String("remote", p.Addr.String()),
+				zap.String("method", info.FullMethod),
+				zap.Int("max-streams-per-client-ip", l.max),
+			)
+			return rpctypes.ErrGRPCTooManyClientStreams
+		}
+		defer release()
+		return handler(srv, ss)
+	}
+}
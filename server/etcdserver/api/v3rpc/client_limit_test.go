@@ -0,0 +1,139 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3rpc
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestParseClientIPAllowlist(t *testing.T) {
+	nets, err := ParseClientIPAllowlist([]string{"10.0.0.1", "192.168.0.0/16", "::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := newClientIPLimiter(1, nets)
+	for _, host := range []string{"10.0.0.1", "192.168.3.4", "::1"} {
+		if !l.isAllowed(host) {
+			t.Errorf("expected %s to be allowed", host)
+		}
+	}
+	for _, host := range []string{"10.0.0.2", "172.16.0.1", "", "@"} {
+		if l.isAllowed(host) {
+			t.Errorf("expected %s not to be allowed", host)
+		}
+	}
+
+	for _, bad := range []string{"10.0.0", "10.0.0.0/33", "example.com"} {
+		if _, err := ParseClientIPAllowlist([]string{bad}); err == nil {
+			t.Errorf("expected error parsing %q", bad)
+		}
+	}
+}
+
+func TestClientIPLimiter(t *testing.T) {
+	nets, err := ParseClientIPAllowlist([]string{"10.0.0.10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := newClientIPLimiter(2, nets)
+
+	var releases []func()
+	for i := 0; i < 2; i++ {
+		release, ok := l.acquire("10.0.0.1:2379")
+		if !ok {
+			t.Fatalf("#%d: expected acquire to succeed", i)
+		}
+		releases = append(releases, release)
+	}
+	if _, ok := l.acquire("10.0.0.1:2380"); ok {
+		t.Fatal("expected acquire over the limit to fail")
+	}
+	if _, ok := l.acquire("10.0.0.2:2379"); !ok {
+		t.Fatal("expected acquire from another IP to succeed")
+	}
+	for i := 0; i < 3; i++ {
+		if _, ok := l.acquire("10.0.0.10:2379"); !ok {
+			t.Fatalf("#%d: expected acquire from an allowlisted IP to succeed", i)
+		}
+	}
+
+	// releasing twice must only free a single slot
+	releases[0]()
+	releases[0]()
+	if _, ok := l.acquire("10.0.0.1:2379"); !ok {
+		t.Fatal("expected acquire after release to succeed")
+	}
+	if _, ok := l.acquire("10.0.0.1:2379"); ok {
+		t.Fatal("expected acquire over the limit to fail")
+	}
+}
+
+func TestLimitListenerPerClientIP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := LimitListenerPerClientIP(nil, ln, 1, nil)
+	defer l.Close()
+
+	accepted := make(chan net.Conn, 2)
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- c
+		}
+	}()
+
+	c1, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+	var sc net.Conn
+	select {
+	case sc = <-accepted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the first connection")
+	}
+
+	c2, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+	c2.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err = c2.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("expected the second connection to be closed, got %v", err)
+	}
+
+	sc.Close()
+	c3, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c3.Close()
+	select {
+	case c := <-accepted:
+		c.Close()
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a connection after the first one was closed")
+	}
+}
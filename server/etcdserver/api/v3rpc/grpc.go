@@ -26,6 +26,7 @@ import (
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/client/v3/credentials"
+	"go.etcd.io/etcd/server/v3/config"
 	"go.etcd.io/etcd/server/v3/etcdserver"
 )
 
@@ -54,6 +55,25 @@ func Server(s *etcdserver.EtcdServer, tls *tls.Config, interceptor grpc.UnarySer
 		grpc_prometheus.StreamServerInterceptor,
 	}
 
+	if s.Cfg.MaxStreamsPerClientIP > 0 {
+		l := newClientIPLimiter(int(s.Cfg.MaxStreamsPerClientIP), s.Cfg.ClientIPLimitAllowlist)
+		chainStreamInterceptors = append(chainStreamInterceptors, newClientIPStreamLimitInterceptor(s, l))
+	}
+
+	if s.Cfg.SlowRequestThreshold > 0 {
+		chainUnaryInterceptors = append(chainUnaryInterceptors, newSlowRequestUnaryInterceptor(s))
+	}
+
+	if s.Cfg.ExperimentalPrincipalMetrics {
+		pl := newPrincipalLabeler(s.Cfg.ExperimentalPrincipalMetricsAllowlist, s.Cfg.ExperimentalPrincipalMetricsMaxPrincipals)
+		chainUnaryInterceptors = append(chainUnaryInterceptors, newPrincipalMetricsUnaryInterceptor(s, pl))
+		chainStreamInterceptors = append(chainStreamInterceptors, newPrincipalMetricsStreamInterceptor(s, pl))
+	}
+
+	if s.Cfg.ExperimentalInflightRequests {
+		chainUnaryInterceptors = append(chainUnaryInterceptors, newInflightUnaryInterceptor(s))
+	}
+
 	if s.Cfg.ExperimentalEnableDistributedTracing {
 		chainUnaryInterceptors = append(chainUnaryInterceptors, otelgrpc.UnaryServerInterceptor(s.Cfg.ExperimentalTracerOptions...))
 		chainStreamInterceptors = append(chainStreamInterceptors, otelgrpc.StreamServerInterceptor(s.Cfg.ExperimentalTracerOptions...))
@@ -63,8 +83,8 @@ func Server(s *etcdserver.EtcdServer, tls *tls.Config, interceptor grpc.UnarySer
 	opts = append(opts, grpc.ChainUnaryInterceptor(chainUnaryInterceptors...))
 	opts = append(opts, grpc.ChainStreamInterceptor(chainStreamInterceptors...))
 
-	opts = append(opts, grpc.MaxRecvMsgSize(int(s.Cfg.MaxRequestBytes+grpcOverheadBytes)))
-	opts = append(opts, grpc.MaxSendMsgSize(maxSendBytes))
+	opts = append(opts, grpc.MaxRecvMsgSize(maxRecvMsgSize(s.Cfg)))
+	opts = append(opts, grpc.MaxSendMsgSize(maxSendMsgSize(s.Cfg)))
 	opts = append(opts, grpc.MaxConcurrentStreams(s.Cfg.MaxConcurrentStreams))
 
 	grpcServer := grpc.NewServer(append(opts, gopts...)...)
@@ -85,3 +105,21 @@ func Server(s *etcdserver.EtcdServer, tls *tls.Config, interceptor grpc.UnarySer
 
 	return grpcServer
 }
+
+// maxRecvMsgSize returns the maximum message size the client gRPC server
+// receives, by default large enough for the requests up to MaxRequestBytes.
+func maxRecvMsgSize(cfg config.ServerConfig) int {
+	if cfg.GRPCMaxRecvBytes > 0 {
+		return int(cfg.GRPCMaxRecvBytes)
+	}
+	return int(cfg.MaxRequestBytes + grpcOverheadBytes)
+}
+
+// maxSendMsgSize returns the maximum message size the client gRPC server
+// sends.
+func maxSendMsgSize(cfg config.ServerConfig) int {
+	if cfg.GRPCMaxSendBytes > 0 {
+		return int(cfg.GRPCMaxSendBytes)
+	}
+	return maxSendBytes
+}
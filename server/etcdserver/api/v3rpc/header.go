@@ -24,6 +24,7 @@ type header struct {
 	clusterID int64
 	memberID  int64
 	sg        apply.RaftStatusGetter
+	cs        ClusterStatusGetter
 	rev       func() int64
 }
 
@@ -32,6 +33,7 @@ func newHeader(s *etcdserver.EtcdServer) header {
 		clusterID: int64(s.Cluster().ID()),
 		memberID:  int64(s.MemberID()),
 		sg:        s,
+		cs:        s,
 		rev:       func() int64 { return s.KV().Rev() },
 	}
 }
@@ -44,6 +46,8 @@ func (h *header) fill(rh *pb.ResponseHeader) {
 	rh.ClusterId = uint64(h.clusterID)
 	rh.MemberId = uint64(h.memberID)
 	rh.RaftTerm = h.sg.Term()
+	// learners serve requests from their local state only
+	rh.PossiblyStale = h.cs.IsLearner()
 	if rh.Revision == 0 {
 		rh.Revision = h.rev()
 	}
@@ -29,13 +29,15 @@ const (
 type notifier interface {
 	defragStarted()
 	defragFinished()
+	drainStarted()
+	drainFinished()
 }
 
 func newHealthNotifier(hs *health.Server, s *etcdserver.EtcdServer) notifier {
 	if hs == nil {
 		panic("unexpected nil gRPC health server")
 	}
-	hc := &healthNotifier{hs: hs, lg: s.Logger(), stopGRPCServiceOnDefrag: s.Cfg.ExperimentalStopGRPCServiceOnDefrag}
+	hc := &healthNotifier{hs: hs, lg: s.Logger(), ds: s, stopGRPCServiceOnDefrag: s.Cfg.ExperimentalStopGRPCServiceOnDefrag}
 	// set grpc health server as serving status blindly since
 	// the grpc server will serve iff s.ReadyNotify() is closed.
 	hc.startServe()
@@ -45,6 +47,7 @@ func newHealthNotifier(hs *health.Server, s *etcdserver.EtcdServer) notifier {
 type healthNotifier struct {
 	hs *health.Server
 	lg *zap.Logger
+	ds DrainStatusGetter
 
 	stopGRPCServiceOnDefrag bool
 }
@@ -56,7 +59,16 @@ func (hc *healthNotifier) defragStarted() {
 	hc.stopServe("defrag is active")
 }
 
-func (hc *healthNotifier) defragFinished() { hc.startServe() }
+func (hc *healthNotifier) defragFinished() {
+	if hc.ds.IsDraining() {
+		return
+	}
+	hc.startServe()
+}
+
+func (hc *healthNotifier) drainStarted() { hc.stopServe("member is draining") }
+
+func (hc *healthNotifier) drainFinished() { hc.startServe() }
 
 func (hc *healthNotifier) startServe() {
 	hc.lg.Info(
@@ -36,6 +36,7 @@ import (
 const (
 	maxNoLeaderCnt = 3
 	snapshotMethod = "/etcdserverpb.Maintenance/Snapshot"
+	watchMethod    = "/etcdserverpb.Watch/Watch"
 )
 
 type streamsMap struct {
@@ -53,6 +54,10 @@ func newUnaryInterceptor(s *etcdserver.EtcdServer) grpc.UnaryServerInterceptor {
 			return nil, rpctypes.ErrGRPCNotSupportedForLearner
 		}
 
+		if s.IsWitness() && !isRPCSupportedForWitness(req) {
+			return nil, rpctypes.ErrGRPCNotSupportedForWitness
+		}
+
 		md, ok := metadata.FromIncomingContext(ctx)
 		if ok {
 			ver, vs := "unknown", md.Get(rpctypes.MetadataClientAPIVersionKey)
@@ -218,10 +223,14 @@ func newStreamInterceptor(s *etcdserver.EtcdServer) grpc.StreamServerInterceptor
 			return rpctypes.ErrGRPCNotCapable
 		}
 
-		if s.IsMemberExist(s.MemberID()) && s.IsLearner() && info.FullMethod != snapshotMethod { // learner does not support stream RPC except Snapshot
+		if s.IsMemberExist(s.MemberID()) && s.IsLearner() && !isStreamSupportedForLearner(info.FullMethod, s.Cfg.ExperimentalLearnerReadReplica) {
 			return rpctypes.ErrGRPCNotSupportedForLearner
 		}
 
+		if s.IsWitness() {
+			return rpctypes.ErrGRPCNotSupportedForWitness
+		}
+
 		md, ok := metadata.FromIncomingContext(ss.Context())
 		if ok {
 			ver, vs := "unknown", md.Get(rpctypes.MetadataClientAPIVersionKey)
@@ -30,10 +30,16 @@ type LeaseServer struct {
 	lg  *zap.Logger
 	hdr header
 	le  etcdserver.Lessor
+	ro  readOnlyChecker
+}
+
+// readOnlyChecker reports whether the cluster is read-only.
+type readOnlyChecker interface {
+	IsReadOnly() bool
 }
 
 func NewLeaseServer(s *etcdserver.EtcdServer) pb.LeaseServer {
-	srv := &LeaseServer{lg: s.Cfg.Logger, le: s, hdr: newHeader(s)}
+	srv := &LeaseServer{lg: s.Cfg.Logger, le: s, ro: s, hdr: newHeader(s)}
 	if srv.lg == nil {
 		srv.lg = zap.NewNop()
 	}
@@ -51,6 +57,11 @@ func (ls *LeaseServer) LeaseGrant(ctx context.Context, cr *pb.LeaseGrantRequest)
 }
 
 func (ls *LeaseServer) LeaseRevoke(ctx context.Context, rr *pb.LeaseRevokeRequest) (*pb.LeaseRevokeResponse, error) {
+	// Revocations are rejected here rather than when applied, as the leases
+	// still expire while the cluster is read-only.
+	if ls.ro.IsReadOnly() {
+		return nil, rpctypes.ErrGRPCReadOnly
+	}
 	resp, err := ls.le.LeaseRevoke(ctx, rr)
 	if err != nil {
 		return nil, togRPCError(err)
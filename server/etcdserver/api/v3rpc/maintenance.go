@@ -28,6 +28,7 @@ import (
 	"go.etcd.io/etcd/api/v3/version"
 	"go.etcd.io/etcd/server/v3/config"
 	"go.etcd.io/etcd/server/v3/etcdserver"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3hotkeys"
 	"go.etcd.io/etcd/server/v3/etcdserver/apply"
 	"go.etcd.io/etcd/server/v3/etcdserver/errors"
 	serverversion "go.etcd.io/etcd/server/v3/etcdserver/version"
@@ -64,10 +65,41 @@ type ClusterStatusGetter interface {
 	IsLearner() bool
 }
 
+type Drainer interface {
+	Drain(ctx context.Context) error
+	Undrain()
+}
+
+type LifecycleHookRunner interface {
+	RunLifecycleHook(hook func())
+}
+
+type DrainStatusGetter interface {
+	IsDraining() bool
+}
+
+type QuarantineStatusGetter interface {
+	IsQuarantined() bool
+	QuarantinedNotify() <-chan struct{}
+}
+
+type EncryptionKeyRotator interface {
+	RotateEncryptionKey(ctx context.Context) (uint64, error)
+}
+
 type ConfigGetter interface {
 	Config() config.ServerConfig
 }
 
+type ClusterEventer interface {
+	SubscribeClusterEvents() (recent []*pb.ClusterEvent, ch <-chan *pb.ClusterEvent, cancel func())
+	PublishClusterEvent(ev *pb.ClusterEvent)
+}
+
+type HotKeysGetter interface {
+	HotKeys(limit int) (reads, writes []v3hotkeys.Key, sampleRate float64, err error)
+}
+
 type maintenanceServer struct {
 	lg     *zap.Logger
 	rg     apply.RaftStatusGetter
@@ -80,6 +112,12 @@ type maintenanceServer struct {
 	d      Downgrader
 	vs     serverversion.Server
 	cg     ConfigGetter
+	dr     Drainer
+	ekr    EncryptionKeyRotator
+	ce     ClusterEventer
+	kg     KVGetter
+	hkg    HotKeysGetter
+	hr     LifecycleHookRunner
 
 	healthNotifier notifier
 }
@@ -98,6 +136,12 @@ func NewMaintenanceServer(s *etcdserver.EtcdServer, healthNotifier notifier) pb.
 		vs:             etcdserver.NewServerVersionAdapter(s),
 		healthNotifier: healthNotifier,
 		cg:             s,
+		dr:             s,
+		ekr:            s,
+		ce:             s,
+		kg:             s,
+		hkg:            s,
+		hr:             s,
 	}
 	if srv.lg == nil {
 		srv.lg = zap.NewNop()
@@ -109,12 +153,18 @@ func (ms *maintenanceServer) Defragment(ctx context.Context, sr *pb.DefragmentRe
 	ms.lg.Info("starting defragment")
 	ms.healthNotifier.defragStarted()
 	defer ms.healthNotifier.defragFinished()
+	hooks := ms.cg.Config().LifecycleHooks
+	ms.hr.RunLifecycleHook(hooks.OnDefragStarted)
 	err := ms.bg.Backend().Defrag()
+	if hook := hooks.OnDefragFinished; hook != nil {
+		ms.hr.RunLifecycleHook(func() { hook(err) })
+	}
 	if err != nil {
 		ms.lg.Warn("failed to defragment", zap.Error(err))
 		return nil, togRPCError(err)
 	}
 	ms.lg.Info("finished defragment")
+	ms.ce.PublishClusterEvent(&pb.ClusterEvent{Type: pb.ClusterEvent_DEFRAGMENTED, MemberId: uint64(ms.hdr.memberID)})
 	return &pb.DefragmentResponse{}, nil
 }
 
@@ -235,6 +285,50 @@ func (ms *maintenanceServer) HashKV(ctx context.Context, r *pb.HashKVRequest) (*
 	return resp, nil
 }
 
+func (ms *maintenanceServer) HashKVRange(ctx context.Context, r *pb.HashKVRangeRequest) (*pb.HashKVRangeResponse, error) {
+	end := r.RangeEnd
+	if len(end) == 1 && end[0] == 0 {
+		end = []byte{}
+	}
+	h, rev, err := mvcc.HashRange(ms.kg.KV(), r.Key, end, r.Revision, r.StripPrefix)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+
+	resp := &pb.HashKVRangeResponse{
+		Header:       &pb.ResponseHeader{Revision: rev},
+		Hash:         h.Hash,
+		HashRevision: h.Revision,
+		Count:        h.Count,
+	}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func (ms *maintenanceServer) HotKeys(ctx context.Context, r *pb.HotKeysRequest) (*pb.HotKeysResponse, error) {
+	reads, writes, rate, err := ms.hkg.HotKeys(int(r.Limit))
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+
+	resp := &pb.HotKeysResponse{
+		Header:     &pb.ResponseHeader{},
+		Reads:      toPBHotKeys(reads),
+		Writes:     toPBHotKeys(writes),
+		SampleRate: rate,
+	}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func toPBHotKeys(keys []v3hotkeys.Key) []*pb.HotKey {
+	hks := make([]*pb.HotKey, len(keys))
+	for i, k := range keys {
+		hks[i] = &pb.HotKey{Key: k.Key, RangeEnd: k.RangeEnd, Count: k.Count}
+	}
+	return hks
+}
+
 func (ms *maintenanceServer) Alarm(ctx context.Context, ar *pb.AlarmRequest) (*pb.AlarmResponse, error) {
 	resp, err := ms.a.Alarm(ctx, ar)
 	if err != nil {
@@ -295,6 +389,62 @@ func (ms *maintenanceServer) Downgrade(ctx context.Context, r *pb.DowngradeReque
 	return resp, nil
 }
 
+func (ms *maintenanceServer) Drain(ctx context.Context, r *pb.DrainRequest) (*pb.DrainResponse, error) {
+	if r.Cancel {
+		ms.dr.Undrain()
+		ms.healthNotifier.drainFinished()
+	} else {
+		ms.healthNotifier.drainStarted()
+		if err := ms.dr.Drain(ctx); err != nil {
+			ms.lg.Warn("failed to transfer leadership away from draining member", zap.Error(err))
+			return nil, togRPCError(err)
+		}
+	}
+	resp := &pb.DrainResponse{Header: &pb.ResponseHeader{}}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func (ms *maintenanceServer) RotateEncryptionKey(ctx context.Context, r *pb.RotateEncryptionKeyRequest) (*pb.RotateEncryptionKeyResponse, error) {
+	id, err := ms.ekr.RotateEncryptionKey(ctx)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	resp := &pb.RotateEncryptionKeyResponse{Header: &pb.ResponseHeader{}, KeyId: id}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func (ms *maintenanceServer) ClusterEvents(r *pb.ClusterEventsRequest, srv pb.Maintenance_ClusterEventsServer) error {
+	recent, ch, cancel := ms.ce.SubscribeClusterEvents()
+	defer cancel()
+
+	resp := &pb.ClusterEventsResponse{Header: &pb.ResponseHeader{}, Events: recent}
+	ms.hdr.fill(resp.Header)
+	if err := srv.Send(resp); err != nil {
+		return togRPCError(err)
+	}
+	if !r.Watch {
+		return nil
+	}
+
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return rpctypes.ErrGRPCClusterEventsLagging
+			}
+			resp = &pb.ClusterEventsResponse{Header: &pb.ResponseHeader{}, Events: []*pb.ClusterEvent{ev}}
+			ms.hdr.fill(resp.Header)
+			if err := srv.Send(resp); err != nil {
+				return togRPCError(err)
+			}
+		case <-srv.Context().Done():
+			return srv.Context().Err()
+		}
+	}
+}
+
 type authMaintenanceServer struct {
 	*maintenanceServer
 	*AuthAdmin
@@ -331,6 +481,20 @@ func (ams *authMaintenanceServer) HashKV(ctx context.Context, r *pb.HashKVReques
 	return ams.maintenanceServer.HashKV(ctx, r)
 }
 
+func (ams *authMaintenanceServer) HashKVRange(ctx context.Context, r *pb.HashKVRangeRequest) (*pb.HashKVRangeResponse, error) {
+	if err := ams.isPermitted(ctx); err != nil {
+		return nil, togRPCError(err)
+	}
+	return ams.maintenanceServer.HashKVRange(ctx, r)
+}
+
+func (ams *authMaintenanceServer) HotKeys(ctx context.Context, r *pb.HotKeysRequest) (*pb.HotKeysResponse, error) {
+	if err := ams.isPermitted(ctx); err != nil {
+		return nil, togRPCError(err)
+	}
+	return ams.maintenanceServer.HotKeys(ctx, r)
+}
+
 func (ams *authMaintenanceServer) Status(ctx context.Context, ar *pb.StatusRequest) (*pb.StatusResponse, error) {
 	if err := ams.isPermitted(ctx); err != nil {
 		return nil, togRPCError(err)
@@ -354,3 +518,27 @@ func (ams *authMaintenanceServer) Downgrade(ctx context.Context, r *pb.Downgrade
 
 	return ams.maintenanceServer.Downgrade(ctx, r)
 }
+
+func (ams *authMaintenanceServer) Drain(ctx context.Context, r *pb.DrainRequest) (*pb.DrainResponse, error) {
+	if err := ams.isPermitted(ctx); err != nil {
+		return nil, togRPCError(err)
+	}
+
+	return ams.maintenanceServer.Drain(ctx, r)
+}
+
+func (ams *authMaintenanceServer) RotateEncryptionKey(ctx context.Context, r *pb.RotateEncryptionKeyRequest) (*pb.RotateEncryptionKeyResponse, error) {
+	if err := ams.isPermitted(ctx); err != nil {
+		return nil, togRPCError(err)
+	}
+
+	return ams.maintenanceServer.RotateEncryptionKey(ctx, r)
+}
+
+func (ams *authMaintenanceServer) ClusterEvents(r *pb.ClusterEventsRequest, srv pb.Maintenance_ClusterEventsServer) error {
+	if err := ams.isPermitted(srv.Context()); err != nil {
+		return togRPCError(err)
+	}
+
+	return ams.maintenanceServer.ClusterEvents(r, srv)
+}
@@ -76,13 +76,30 @@ func (cs *ClusterServer) MemberRemove(ctx context.Context, r *pb.MemberRemoveReq
 }
 
 func (cs *ClusterServer) MemberUpdate(ctx context.Context, r *pb.MemberUpdateRequest) (*pb.MemberUpdateResponse, error) {
-	m := membership.Member{
-		ID:             types.ID(r.ID),
-		RaftAttributes: membership.RaftAttributes{PeerURLs: r.PeerURLs},
+	for k := range r.Labels {
+		if k == "" {
+			return nil, rpctypes.ErrGRPCMemberBadLabels
+		}
 	}
-	membs, err := cs.server.UpdateMember(ctx, m)
-	if err != nil {
-		return nil, togRPCError(err)
+
+	var (
+		membs []*membership.Member
+		err   error
+	)
+	// a request carrying only labels leaves the peer URLs untouched
+	if len(r.PeerURLs) > 0 || len(r.Labels) == 0 {
+		m := membership.Member{
+			ID:             types.ID(r.ID),
+			RaftAttributes: membership.RaftAttributes{PeerURLs: r.PeerURLs},
+		}
+		if membs, err = cs.server.UpdateMember(ctx, m); err != nil {
+			return nil, togRPCError(err)
+		}
+	}
+	if len(r.Labels) > 0 {
+		if membs, err = cs.server.UpdateMemberLabels(ctx, types.ID(r.ID), r.Labels); err != nil {
+			return nil, togRPCError(err)
+		}
 	}
 	return &pb.MemberUpdateResponse{Header: cs.header(), Members: membersToProtoMembers(membs)}, nil
 }
@@ -118,6 +135,8 @@ func membersToProtoMembers(membs []*membership.Member) []*pb.Member {
 			PeerURLs:   membs[i].PeerURLs,
 			ClientURLs: membs[i].ClientURLs,
 			IsLearner:  membs[i].IsLearner,
+			Labels:     membs[i].Labels,
+			IsWitness:  membs[i].Witness,
 		}
 	}
 	return protoMembs
@@ -40,6 +40,20 @@ var (
 		[]string{"Type", "API"},
 	)
 
+	rejectedClientConnections = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "network",
+		Name:      "client_rejected_connections_total",
+		Help:      "The total number of client connections rejected because the client IP reached its connection limit.",
+	})
+
+	rejectedClientStreams = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "network",
+		Name:      "client_grpc_rejected_streams_total",
+		Help:      "The total number of gRPC streams rejected because the client IP reached its stream limit.",
+	})
+
 	clientRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "etcd",
 		Subsystem: "server",
@@ -48,11 +62,61 @@ var (
 	},
 		[]string{"type", "client_api_version"},
 	)
+
+	principalRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "client_principal_requests_total",
+		Help:      "The total number of client requests per principal and gRPC method.",
+	},
+		[]string{"principal", "grpc_method"},
+	)
+
+	principalErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "client_principal_errors_total",
+		Help:      "The total number of failed client requests per principal, gRPC method and code.",
+	},
+		[]string{"principal", "grpc_method", "grpc_code"},
+	)
+
+	principalReceivedBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "client_principal_received_message_bytes",
+		Help:      "The size of the messages received from clients per principal.",
+
+		// lowest bucket start of upper bound 64 bytes with factor 4
+		// highest bucket start of 64 * 4^9 = 16 MiB
+		Buckets: prometheus.ExponentialBuckets(64, 4, 10),
+	},
+		[]string{"principal"},
+	)
+
+	principalSentBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "client_principal_sent_message_bytes",
+		Help:      "The size of the messages sent to clients per principal.",
+
+		// lowest bucket start of upper bound 64 bytes with factor 4
+		// highest bucket start of 64 * 4^9 = 16 MiB
+		Buckets: prometheus.ExponentialBuckets(64, 4, 10),
+	},
+		[]string{"principal"},
+	)
 )
 
 func init() {
 	prometheus.MustRegister(sentBytes)
 	prometheus.MustRegister(receivedBytes)
 	prometheus.MustRegister(streamFailures)
+	prometheus.MustRegister(rejectedClientConnections)
+	prometheus.MustRegister(rejectedClientStreams)
 	prometheus.MustRegister(clientRequests)
+	prometheus.MustRegister(principalRequests)
+	prometheus.MustRegister(principalErrors)
+	prometheus.MustRegister(principalReceivedBytes)
+	prometheus.MustRegister(principalSentBytes)
 }
@@ -0,0 +1,154 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3rpc
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"go.etcd.io/etcd/server/v3/etcdserver"
+)
+
+const (
+	// anonymousPrincipal labels the requests without an authenticated user
+	// or a verified client certificate.
+	anonymousPrincipal = "anonymous"
+	// otherPrincipal labels the requests of the principals that are not
+	// allowed or beyond the cap of principals given their own label.
+	otherPrincipal = "other"
+)
+
+// principalLabeler maps principals to the values of the principal label,
+// giving their own label only to allowed principals, up to a cap.
+type principalLabeler struct {
+	allowed map[string]struct{}
+	max     int
+
+	mu   sync.Mutex
+	seen map[string]struct{}
+}
+
+func newPrincipalLabeler(allowlist []string, max int) *principalLabeler {
+	pl := &principalLabeler{max: max, seen: make(map[string]struct{})}
+	if len(allowlist) > 0 {
+		pl.allowed = make(map[string]struct{}, len(allowlist))
+		for _, p := range allowlist {
+			pl.allowed[p] = struct{}{}
+		}
+	}
+	return pl
+}
+
+func (pl *principalLabeler) label(principal string) string {
+	if principal == "" {
+		return anonymousPrincipal
+	}
+	if pl.allowed != nil {
+		if _, ok := pl.allowed[principal]; !ok {
+			return otherPrincipal
+		}
+	}
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	if _, ok := pl.seen[principal]; ok {
+		return principal
+	}
+	if len(pl.seen) >= pl.max {
+		return otherPrincipal
+	}
+	pl.seen[principal] = struct{}{}
+	return principal
+}
+
+// principalFromCtx returns the authenticated user of the request or, without
+// a token, the common name of the client certificate, which is used even if
+// auth is disabled.
+func principalFromCtx(s *etcdserver.EtcdServer, ctx context.Context) string {
+	if ai, err := s.AuthInfoFromCtx(ctx); err == nil && ai != nil {
+		return ai.Username
+	}
+	// with auth enabled, only the principals it authenticates are used, and
+	// with client cert auth enabled, AuthInfoFromCtx already looked at the
+	// certificate
+	if s.AuthStore().IsAuthEnabled() || s.ClientCertAuthEnabled() {
+		return ""
+	}
+	if ai := s.AuthStore().AuthInfoFromTLS(ctx); ai != nil {
+		return ai.Username
+	}
+	return ""
+}
+
+func messageSize(m any) int {
+	if sm, ok := m.(interface{ Size() int }); ok {
+		return sm.Size()
+	}
+	return 0
+}
+
+func newPrincipalMetricsUnaryInterceptor(s *etcdserver.EtcdServer, pl *principalLabeler) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		principal := pl.label(principalFromCtx(s, ctx))
+		principalRequests.WithLabelValues(principal, info.FullMethod).Inc()
+		principalReceivedBytes.WithLabelValues(principal).Observe(float64(messageSize(req)))
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			principalErrors.WithLabelValues(principal, info.FullMethod, status.Code(err).String()).Inc()
+			return resp, err
+		}
+		principalSentBytes.WithLabelValues(principal).Observe(float64(messageSize(resp)))
+		return resp, nil
+	}
+}
+
+func newPrincipalMetricsStreamInterceptor(s *etcdserver.EtcdServer, pl *principalLabeler) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		principal := pl.label(principalFromCtx(s, ss.Context()))
+		principalRequests.WithLabelValues(principal, info.FullMethod).Inc()
+
+		err := handler(srv, &principalMetricsServerStream{ServerStream: ss, principal: principal})
+		if err != nil {
+			principalErrors.WithLabelValues(principal, info.FullMethod, status.Code(err).String()).Inc()
+		}
+		return err
+	}
+}
+
+// principalMetricsServerStream observes the size of the messages received
+// and sent on a stream.
+type principalMetricsServerStream struct {
+	grpc.ServerStream
+	principal string
+}
+
+func (ss *principalMetricsServerStream) RecvMsg(m any) error {
+	err := ss.ServerStream.RecvMsg(m)
+	if err == nil {
+		principalReceivedBytes.WithLabelValues(ss.principal).Observe(float64(messageSize(m)))
+	}
+	return err
+}
+
+func (ss *principalMetricsServerStream) SendMsg(m any) error {
+	err := ss.ServerStream.SendMsg(m)
+	if err == nil {
+		principalSentBytes.WithLabelValues(ss.principal).Observe(float64(messageSize(m)))
+	}
+	return err
+}
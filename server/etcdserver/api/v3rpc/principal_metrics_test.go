@@ -0,0 +1,57 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3rpc
+
+import "testing"
+
+func TestPrincipalLabeler(t *testing.T) {
+	tests := []struct {
+		name       string
+		allowlist  []string
+		max        int
+		principals []string
+		want       []string
+	}{
+		{
+			name:       "anonymous",
+			max:        1,
+			principals: []string{""},
+			want:       []string{anonymousPrincipal},
+		},
+		{
+			name:       "cap",
+			max:        2,
+			principals: []string{"a", "b", "c", "a", "b", "c"},
+			want:       []string{"a", "b", otherPrincipal, "a", "b", otherPrincipal},
+		},
+		{
+			name:       "allowlist",
+			allowlist:  []string{"b", "c"},
+			max:        1,
+			principals: []string{"a", "b", "c", "", "b"},
+			want:       []string{otherPrincipal, "b", otherPrincipal, anonymousPrincipal, "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pl := newPrincipalLabeler(tt.allowlist, tt.max)
+			for i, p := range tt.principals {
+				if got := pl.label(p); got != tt.want[i] {
+					t.Errorf("#%d: label(%q) = %q, want %q", i, p, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3rpc
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/etcdserver"
+)
+
+// newSlowRequestUnaryInterceptor logs the requests taking longer than the
+// slow request threshold with where the time was spent.
+func newSlowRequestUnaryInterceptor(s *etcdserver.EtcdServer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		ctx, rs := etcdserver.WithRequestStats(ctx)
+		resp, err := handler(ctx, req)
+		if took := time.Since(start); took >= s.Cfg.SlowRequestThreshold {
+			logSlowRequest(ctx, s, info.FullMethod, req, resp, err, took, rs)
+		}
+		return resp, err
+	}
+}
+
+func logSlowRequest(ctx context.Context, s *etcdserver.EtcdServer, method string, req, resp any, err error, took time.Duration, rs *etcdserver.RequestStats) {
+	remote := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		remote = p.Addr.String()
+	}
+	principal := principalFromCtx(s, ctx)
+	if principal == "" {
+		principal = anonymousPrincipal
+	}
+	fields := []zap.Field{
+		zap.String("method", method),
+		zap.String("remote", remote),
+		zap.String("principal", principal),
+	}
+	fields = append(fields, requestFields(req)...)
+	fields = append(fields, responseFields(resp)...)
+	fields = append(fields,
+		zap.Int("request-size", messageSize(req)),
+		zap.Int("response-size", messageSize(resp)),
+		zap.Duration("took", took),
+		zap.Duration("queue-wait", rs.QueueWait()),
+		zap.Duration("execution", took-rs.QueueWait()),
+		zap.Duration("read-index-wait", rs.ReadIndexWait),
+		zap.Duration("propose", rs.Propose),
+		zap.Duration("commit-wait", rs.CommitWait),
+		zap.Duration("apply", rs.Apply),
+		zap.Uint64("raft-term", s.Term()),
+		zap.Error(err),
+	)
+	s.Logger().Warn("slow request", fields...)
+}
+
+func requestFields(req any) []zap.Field {
+	switch r := req.(type) {
+	case *pb.RangeRequest:
+		return []zap.Field{
+			zap.String("key", string(r.Key)),
+			zap.String("range-end", string(r.RangeEnd)),
+			zap.Int64("limit", r.Limit),
+			zap.Int64("revision", r.Revision),
+			zap.Bool("serializable", r.Serializable),
+		}
+	case *pb.PutRequest:
+		return []zap.Field{zap.String("key", string(r.Key))}
+	case *pb.DeleteRangeRequest:
+		return []zap.Field{
+			zap.String("key", string(r.Key)),
+			zap.String("range-end", string(r.RangeEnd)),
+		}
+	case *pb.TxnRequest:
+		return []zap.Field{
+			zap.Int("compare-count", len(r.Compare)),
+			zap.Int("success-count", len(r.Success)),
+			zap.Int("failure-count", len(r.Failure)),
+		}
+	}
+	return nil
+}
+
+func responseFields(resp any) []zap.Field {
+	switch r := resp.(type) {
+	case *pb.RangeResponse:
+		if r != nil {
+			return []zap.Field{zap.Int("response-kvs", len(r.Kvs)), zap.Int64("response-count", r.Count)}
+		}
+	case *pb.DeleteRangeResponse:
+		if r != nil {
+			return []zap.Field{zap.Int64("response-count", r.Deleted)}
+		}
+	case *pb.TxnResponse:
+		if r != nil {
+			return []zap.Field{zap.Bool("succeeded", r.Succeeded)}
+		}
+	}
+	return nil
+}
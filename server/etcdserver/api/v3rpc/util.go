@@ -38,6 +38,8 @@ var toGRPCErrorMap = map[error]error{
 	membership.ErrPeerURLexists:       rpctypes.ErrGRPCPeerURLExist,
 	membership.ErrMemberNotLearner:    rpctypes.ErrGRPCMemberNotLearner,
 	membership.ErrTooManyLearners:     rpctypes.ErrGRPCTooManyLearners,
+	membership.ErrMemberReadReplica:   rpctypes.ErrGRPCMemberReadReplica,
+	membership.ErrLabelsNotSupported:  rpctypes.ErrGRPCLabelsNotSupported,
 	errors.ErrNotEnoughStartedMembers: rpctypes.ErrMemberNotEnoughStarted,
 	errors.ErrLearnerNotReady:         rpctypes.ErrGRPCLearnerNotReady,
 
@@ -59,12 +61,22 @@ var toGRPCErrorMap = map[error]error{
 	errors.ErrKeyNotFound:                rpctypes.ErrGRPCKeyNotFound,
 	errors.ErrCorrupt:                    rpctypes.ErrGRPCCorrupt,
 	errors.ErrBadLeaderTransferee:        rpctypes.ErrGRPCBadLeaderTransferee,
+	errors.ErrDraining:                   rpctypes.ErrGRPCDraining,
+	errors.ErrQuarantined:                rpctypes.ErrGRPCQuarantined,
+	errors.ErrAdmissionDenied:            rpctypes.ErrGRPCAdmissionDenied,
+	errors.ErrRevisionPinned:             rpctypes.ErrGRPCRevisionPinned,
+	errors.ErrEncryptionNotEnabled:       rpctypes.ErrGRPCEncryptionNotEnabled,
+	errors.ErrClusterEventsLagging:       rpctypes.ErrGRPCClusterEventsLagging,
+	errors.ErrReadOnly:                   rpctypes.ErrGRPCReadOnly,
+	errors.ErrProtectedPrefix:            rpctypes.ErrGRPCProtectedPrefix,
+	errors.ErrHotKeysNotEnabled:          rpctypes.ErrGRPCHotKeysNotEnabled,
 
 	errors.ErrClusterVersionUnavailable:      rpctypes.ErrGRPCClusterVersionUnavailable,
 	errors.ErrWrongDowngradeVersionFormat:    rpctypes.ErrGRPCWrongDowngradeVersionFormat,
 	version.ErrInvalidDowngradeTargetVersion: rpctypes.ErrGRPCInvalidDowngradeTargetVersion,
 	version.ErrDowngradeInProcess:            rpctypes.ErrGRPCDowngradeInProcess,
 	version.ErrNoInflightDowngrade:           rpctypes.ErrGRPCNoInflightDowngrade,
+	errors.ErrRangeTombstonesPresent:         rpctypes.ErrGRPCRangeTombstonesPresent,
 
 	lease.ErrLeaseNotFound:    rpctypes.ErrGRPCLeaseNotFound,
 	lease.ErrLeaseExists:      rpctypes.ErrGRPCLeaseExist,
@@ -98,6 +110,10 @@ func togRPCError(err error) error {
 	if err == context.Canceled || err == context.DeadlineExceeded {
 		return err
 	}
+	if ae, ok := err.(*errors.AdmissionError); ok {
+		// keep the reason given by the admission controller
+		return status.Error(codes.InvalidArgument, ae.Error())
+	}
 	grpcErr, ok := toGRPCErrorMap[err]
 	if !ok {
 		return status.Error(codes.Unknown, err.Error())
@@ -137,6 +153,19 @@ func isClientCtxErr(ctxErr error, err error) bool {
 	return false
 }
 
+// learner does not support stream RPC except Snapshot, unless it serves as a
+// read replica which also serves Watch.
+func isStreamSupportedForLearner(method string, readReplica bool) bool {
+	switch method {
+	case snapshotMethod:
+		return true
+	case watchMethod:
+		return readReplica
+	default:
+		return false
+	}
+}
+
 // in v3.4, learner is allowed to serve serializable read and endpoint status
 func isRPCSupportedForLearner(req any) bool {
 	switch r := req.(type) {
@@ -148,3 +177,15 @@ func isRPCSupportedForLearner(req any) bool {
 		return false
 	}
 }
+
+// witness serves no stream RPC, and no unary RPC but the endpoint status, the
+// member list for the clients to find the other members, and moving the
+// leadership away.
+func isRPCSupportedForWitness(req any) bool {
+	switch req.(type) {
+	case *pb.StatusRequest, *pb.MemberListRequest, *pb.MoveLeaderRequest:
+		return true
+	default:
+		return false
+	}
+}
@@ -47,6 +47,9 @@ type watchServer struct {
 	sg        apply.RaftStatusGetter
 	watchable mvcc.WatchableKV
 	ag        AuthGetter
+	ds        DrainStatusGetter
+	qs        QuarantineStatusGetter
+	cs        ClusterStatusGetter
 }
 
 // NewWatchServer returns a new watch server.
@@ -62,6 +65,9 @@ func NewWatchServer(s *etcdserver.EtcdServer) pb.WatchServer {
 		sg:        s,
 		watchable: s.Watchable(),
 		ag:        s,
+		ds:        s,
+		qs:        s,
+		cs:        s,
 	}
 	if srv.lg == nil {
 		srv.lg = zap.NewNop()
@@ -131,15 +137,21 @@ type serverWatchStream struct {
 	watchable mvcc.WatchableKV
 	ag        AuthGetter
 
+	// possiblyStale is set when the stream is served by a learner
+	possiblyStale bool
+
 	gRPCStream  pb.Watch_WatchServer
 	watchStream mvcc.WatchStream
 	ctrlStream  chan *pb.WatchResponse
 
-	// mu protects progress, prevKV, fragment
+	// mu protects progress, progressTimers, prevKV, fragment
 	mu sync.RWMutex
 	// tracks the watchID that stream might need to send progress to
 	// TODO: combine progress and prevKV into a single struct?
 	progress map[mvcc.WatchID]bool
+	// records the progress timers of watch IDs requesting a maximum
+	// progress notify interval
+	progressTimers map[mvcc.WatchID]*progressTimer
 	// record watch IDs that need return previous key-value pair
 	prevKV map[mvcc.WatchID]bool
 	// records fragmented watch IDs
@@ -153,6 +165,16 @@ type serverWatchStream struct {
 }
 
 func (ws *watchServer) Watch(stream pb.Watch_WatchServer) (err error) {
+	if ws.ds.IsDraining() {
+		// existing streams keep being served; only new ones are turned away
+		return rpctypes.ErrGRPCDraining
+	}
+	// a quarantined member cancels its streams, since the events come from
+	// the range that diverges from the rest of the cluster
+	quarantinec := ws.qs.QuarantinedNotify()
+	if ws.qs.IsQuarantined() {
+		return rpctypes.ErrGRPCQuarantined
+	}
 	sws := serverWatchStream{
 		lg: ws.lg,
 
@@ -165,14 +187,17 @@ func (ws *watchServer) Watch(stream pb.Watch_WatchServer) (err error) {
 		watchable: ws.watchable,
 		ag:        ws.ag,
 
+		possiblyStale: ws.cs.IsLearner(),
+
 		gRPCStream:  stream,
 		watchStream: ws.watchable.NewWatchStream(),
 		// chan for sending control response like watcher created and canceled.
 		ctrlStream: make(chan *pb.WatchResponse, ctrlStreamBufLen),
 
-		progress: make(map[mvcc.WatchID]bool),
-		prevKV:   make(map[mvcc.WatchID]bool),
-		fragment: make(map[mvcc.WatchID]bool),
+		progress:       make(map[mvcc.WatchID]bool),
+		progressTimers: make(map[mvcc.WatchID]*progressTimer),
+		prevKV:         make(map[mvcc.WatchID]bool),
+		fragment:       make(map[mvcc.WatchID]bool),
 
 		closec: make(chan struct{}),
 	}
@@ -220,6 +245,8 @@ func (ws *watchServer) Watch(stream pb.Watch_WatchServer) (err error) {
 		if err == context.Canceled {
 			err = rpctypes.ErrGRPCWatchCanceled
 		}
+	case <-quarantinec:
+		err = rpctypes.ErrGRPCQuarantined
 	}
 
 	sws.close()
@@ -312,9 +339,12 @@ func (sws *serverWatchStream) recvLoop() error {
 			id, err := sws.watchStream.Watch(mvcc.WatchID(creq.WatchId), creq.Key, creq.RangeEnd, rev, filters...)
 			if err == nil {
 				sws.mu.Lock()
-				if creq.ProgressNotify {
+				if creq.ProgressNotify || creq.ProgressNotifyIntervalMs > 0 {
 					sws.progress[id] = true
 				}
+				if creq.ProgressNotifyIntervalMs > 0 {
+					sws.startProgressTimer(id, progressNotifyInterval(creq.ProgressNotifyIntervalMs))
+				}
 				if creq.PrevKv {
 					sws.prevKV[id] = true
 				}
@@ -322,6 +352,9 @@ func (sws *serverWatchStream) recvLoop() error {
 					sws.fragment[id] = true
 				}
 				sws.mu.Unlock()
+				if creq.AllowResync {
+					sws.watchStream.AllowResync(id)
+				}
 			} else {
 				id = clientv3.InvalidWatchID
 			}
@@ -352,6 +385,7 @@ func (sws *serverWatchStream) recvLoop() error {
 						Canceled: true,
 					}
 					sws.mu.Lock()
+					sws.stopProgressTimer(mvcc.WatchID(id))
 					delete(sws.progress, mvcc.WatchID(id))
 					delete(sws.prevKV, mvcc.WatchID(id))
 					delete(sws.fragment, mvcc.WatchID(id))
@@ -430,6 +464,13 @@ func (sws *serverWatchStream) sendLoop() {
 				CompactRevision: wresp.CompactRevision,
 				Canceled:        canceled,
 			}
+			if canceled {
+				// the store may have been compacted again since the watcher
+				// was canceled, so hint the current compaction revision
+				wr.ResyncRevision = max(wresp.CompactRevision, sws.watchable.FirstRev())
+			} else {
+				wr.ResyncRevision = wresp.ResyncRevision
+			}
 
 			// Progress notifications can have WatchID -1
 			// if they announce on behalf of multiple watchers
@@ -471,6 +512,17 @@ func (sws *serverWatchStream) sendLoop() {
 				// elide next progress update if sent a key update
 				sws.progress[wresp.WatchID] = false
 			}
+			if wresp.WatchID == clientv3.InvalidWatchID {
+				// the progress notification is on behalf of all watchers
+				for _, pt := range sws.progressTimers {
+					pt.lastSent = time.Now()
+				}
+			} else if pt, ok := sws.progressTimers[wresp.WatchID]; ok {
+				pt.lastSent = time.Now()
+			}
+			if canceled {
+				sws.stopProgressTimer(wresp.WatchID)
+			}
 			sws.mu.Unlock()
 
 		case c, ok := <-sws.ctrlStream:
@@ -574,7 +626,66 @@ func sendFragments(
 	return nil
 }
 
+// progressTimer requests progress notifications for a watcher so that it
+// is sent a response at least every interval.
+type progressTimer struct {
+	interval time.Duration
+	// lastSent is when the last response was sent to the watcher
+	lastSent time.Time
+	timer    *time.Timer
+}
+
+// progressNotifyInterval returns the progress notify interval requested in
+// milliseconds, raised to the minimum progress notify interval.
+func progressNotifyInterval(ms int64) time.Duration {
+	interval := time.Duration(ms) * time.Millisecond
+	if interval < minWatchProgressInterval {
+		interval = minWatchProgressInterval
+	}
+	return interval
+}
+
+// startProgressTimer starts the progress timer of the watch ID. It must be
+// called with mu held.
+func (sws *serverWatchStream) startProgressTimer(id mvcc.WatchID, interval time.Duration) {
+	pt := &progressTimer{interval: interval, lastSent: time.Now()}
+	pt.timer = time.AfterFunc(interval, func() { sws.notifyProgress(id, pt) })
+	sws.progressTimers[id] = pt
+}
+
+// stopProgressTimer stops the progress timer of the watch ID, if any. It must
+// be called with mu held.
+func (sws *serverWatchStream) stopProgressTimer(id mvcc.WatchID) {
+	if pt, ok := sws.progressTimers[id]; ok {
+		pt.timer.Stop()
+		delete(sws.progressTimers, id)
+	}
+}
+
+// notifyProgress requests a progress notification for the watch ID unless a
+// response was sent to it within the interval, and reschedules the timer
+// for when the interval elapses since the last response.
+func (sws *serverWatchStream) notifyProgress(id mvcc.WatchID, pt *progressTimer) {
+	sws.mu.Lock()
+	defer sws.mu.Unlock()
+	if sws.progressTimers[id] != pt {
+		// the watcher is canceled
+		return
+	}
+	if wait := pt.interval - time.Since(pt.lastSent); wait > 0 {
+		pt.timer.Reset(wait)
+		return
+	}
+	sws.watchStream.RequestProgress(id)
+	pt.timer.Reset(pt.interval)
+}
+
 func (sws *serverWatchStream) close() {
+	sws.mu.Lock()
+	for id := range sws.progressTimers {
+		sws.stopProgressTimer(id)
+	}
+	sws.mu.Unlock()
 	sws.watchStream.Close()
 	close(sws.closec)
 	sws.wg.Wait()
@@ -586,6 +697,8 @@ func (sws *serverWatchStream) newResponseHeader(rev int64) *pb.ResponseHeader {
 		MemberId:  uint64(sws.memberID),
 		Revision:  rev,
 		RaftTerm:  sws.sg.Term(),
+
+		PossiblyStale: sws.possiblyStale,
 	}
 }
 
@@ -16,6 +16,7 @@ package apply
 
 import (
 	"context"
+	"time"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/gogo/protobuf/proto"
@@ -60,6 +61,10 @@ type Result struct {
 	// Compaction requests.
 	Physc <-chan struct{}
 	Trace *traceutil.Trace
+	// ApplyStart and ApplyEnd are when the member started and finished
+	// applying the request. They are only set for the requests the member
+	// waits the result of.
+	ApplyStart, ApplyEnd time.Time
 }
 
 type applyFunc func(ctx context.Context, r *pb.InternalRaftRequest) *Result
@@ -155,7 +160,7 @@ func (a *applierV3backend) Put(ctx context.Context, p *pb.PutRequest) (resp *pb.
 }
 
 func (a *applierV3backend) DeleteRange(ctx context.Context, dr *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, *traceutil.Trace, error) {
-	return mvcctxn.DeleteRange(ctx, a.lg, a.kv, dr)
+	return mvcctxn.DeleteRange(ctx, a.lg, a.writeKV(ctx), dr)
 }
 
 func (a *applierV3backend) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeResponse, *traceutil.Trace, error) {
@@ -163,7 +168,21 @@ func (a *applierV3backend) Range(ctx context.Context, r *pb.RangeRequest) (*pb.R
 }
 
 func (a *applierV3backend) Txn(ctx context.Context, rt *pb.TxnRequest) (*pb.TxnResponse, *traceutil.Trace, error) {
-	return mvcctxn.Txn(ctx, a.lg, rt, a.txnModeWriteWithSharedBuffer, a.kv, a.lessor)
+	return mvcctxn.Txn(ctx, a.lg, rt, a.txnModeWriteWithSharedBuffer, a.writeKV(ctx), a.lessor)
+}
+
+// writeKV returns the KV the request is applied to, recording range
+// tombstones and moving the deleted keys to the trash as configured when the
+// request was proposed.
+func (a *applierV3backend) writeKV(ctx context.Context) mvcc.KV {
+	kv := a.kv
+	if n := mvcctxn.RangeTombstoneThresholdFromContext(ctx); n > 0 {
+		kv = mvcc.NewRangeTombstoneKV(kv, n)
+	}
+	if cfg := mvcctxn.SoftDeleteFromContext(ctx); cfg.Enabled() {
+		kv = mvcctxn.NewSoftDeleteKV(kv, cfg)
+	}
+	return kv
 }
 
 func (a *applierV3backend) Compaction(compaction *pb.CompactionRequest) (*pb.CompactionResponse, <-chan struct{}, *traceutil.Trace, error) {
@@ -174,6 +193,10 @@ func (a *applierV3backend) Compaction(compaction *pb.CompactionRequest) (*pb.Com
 		traceutil.Field{Key: "revision", Value: compaction.Revision},
 	)
 
+	// the revisions pinned by leases are kept until the leases are revoked
+	if pinned := a.lessor.MinPinnedRevision(); pinned > 0 && compaction.Revision > pinned {
+		return nil, nil, nil, errors.ErrRevisionPinned
+	}
 	ch, err := a.kv.Compact(trace, compaction.Revision)
 	if err != nil {
 		return nil, ch, nil, err
@@ -185,11 +208,29 @@ func (a *applierV3backend) Compaction(compaction *pb.CompactionRequest) (*pb.Com
 }
 
 func (a *applierV3backend) LeaseGrant(lc *pb.LeaseGrantRequest) (*pb.LeaseGrantResponse, error) {
-	l, err := a.lessor.Grant(lease.LeaseID(lc.ID), lc.TTL)
 	resp := &pb.LeaseGrantResponse{}
+	pinnedRev := lc.PinnedRevision
+	if pinnedRev < 0 {
+		pinnedRev = a.kv.Rev()
+	} else if pinnedRev > 0 {
+		txn := a.kv.Read(mvcc.ConcurrentReadTxMode, traceutil.TODO())
+		compactRev, rev := txn.FirstRev(), txn.Rev()
+		txn.End()
+		if pinnedRev < compactRev {
+			return resp, mvcc.ErrCompacted
+		}
+		if pinnedRev > rev {
+			return resp, mvcc.ErrFutureRev
+		}
+	}
+	l, err := a.lessor.Grant(lease.LeaseID(lc.ID), lc.TTL)
+	if err == nil && pinnedRev > 0 {
+		err = a.lessor.Pin(l.ID, pinnedRev)
+	}
 	if err == nil {
 		resp.ID = int64(l.ID)
 		resp.TTL = l.TTL()
+		resp.PinnedRevision = pinnedRev
 		resp.Header = a.newHeader()
 	}
 	return resp, err
@@ -220,7 +261,7 @@ func (a *applierV3backend) Alarm(ar *pb.AlarmRequest) (*pb.AlarmResponse, error)
 		if ar.Alarm == pb.AlarmType_NONE {
 			break
 		}
-		m := a.alarmStore.Activate(types.ID(ar.MemberID), ar.Alarm)
+		m := a.alarmStore.ActivateRange(types.ID(ar.MemberID), ar.Alarm, ar.Key, ar.RangeEnd)
 		if m == nil {
 			break
 		}
@@ -256,7 +297,13 @@ func (a *applierV3Capped) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnRes
 	if a.q.Cost(r) > 0 {
 		return nil, nil, errors.ErrNoSpace
 	}
-	return a.applierV3.Txn(ctx, r)
+	// the deletions bypass the trash to free space
+	return a.applierV3.Txn(mvcctxn.WithSoftDelete(ctx, mvcctxn.SoftDeleteConfig{}), r)
+}
+
+func (a *applierV3Capped) DeleteRange(ctx context.Context, dr *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, *traceutil.Trace, error) {
+	// the deletions bypass the trash to free space
+	return a.applierV3.DeleteRange(mvcctxn.WithSoftDelete(ctx, mvcctxn.SoftDeleteConfig{}), dr)
 }
 
 func (a *applierV3Capped) LeaseGrant(_ *pb.LeaseGrantRequest) (*pb.LeaseGrantResponse, error) {
@@ -427,16 +474,62 @@ func (a *applierMembership) ClusterVersionSet(r *membershippb.ClusterVersionSetR
 }
 
 func (a *applierMembership) ClusterMemberAttrSet(r *membershippb.ClusterMemberAttrSetRequest, shouldApplyV3 membership.ShouldApplyV3) {
+	m := a.cluster.Member(types.ID(r.Member_ID))
+	if r.UpdateLabels {
+		if m == nil {
+			return
+		}
+		attrs := m.Attributes
+		attrs.Labels = mergeLabels(m.Labels, r.MemberAttributes.Labels)
+		a.cluster.UpdateAttributes(m.ID, attrs, shouldApplyV3)
+		return
+	}
+	// A member publishes its attributes before it has caught up with the
+	// membership, so whether it is a read replica is decided here, where it
+	// is known if the member is a learner.
+	readReplica := r.MemberAttributes.ReadReplica
+	if m == nil || !m.IsLearner {
+		readReplica = false
+	}
+	// Labels are set by the operator, the member publishing its attributes
+	// keeps them.
+	var labels map[string]string
+	if m != nil {
+		labels = m.Labels
+	}
 	a.cluster.UpdateAttributes(
 		types.ID(r.Member_ID),
 		membership.Attributes{
-			Name:       r.MemberAttributes.Name,
-			ClientURLs: r.MemberAttributes.ClientUrls,
+			Name:        r.MemberAttributes.Name,
+			ClientURLs:  r.MemberAttributes.ClientUrls,
+			ReadReplica: readReplica,
+			Labels:      labels,
+			Witness:     r.MemberAttributes.Witness,
 		},
 		shouldApplyV3,
 	)
 }
 
+// mergeLabels returns the labels with the updates applied, an update with an
+// empty value removes the label.
+func mergeLabels(labels, updates map[string]string) map[string]string {
+	merged := make(map[string]string, len(labels)+len(updates))
+	for k, v := range labels {
+		merged[k] = v
+	}
+	for k, v := range updates {
+		if v == "" {
+			delete(merged, k)
+			continue
+		}
+		merged[k] = v
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
+
 func (a *applierMembership) DowngradeInfoSet(r *membershippb.DowngradeInfoSetRequest, shouldApplyV3 membership.ShouldApplyV3) {
 	d := version.DowngradeInfo{Enabled: false}
 	if r.Enabled {
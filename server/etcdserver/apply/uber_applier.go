@@ -61,8 +61,12 @@ func NewUberApplier(
 	consistentIndex cindex.ConsistentIndexer,
 	warningApplyDuration time.Duration,
 	txnModeWriteWithSharedBuffer bool,
-	quotaBackendBytesCfg int64) UberApplier {
+	quotaBackendBytesCfg int64,
+	witness bool) UberApplier {
 	applyV3base := newApplierV3(lg, be, kv, alarmStore, authStore, lessor, cluster, raftStatus, snapshotServer, consistentIndex, txnModeWriteWithSharedBuffer, quotaBackendBytesCfg)
+	if witness {
+		applyV3base = newApplierV3Witness(applyV3base)
+	}
 
 	ua := &uberApplier{
 		lg:                   lg,
@@ -99,10 +103,14 @@ func newApplierV3(
 func (a *uberApplier) restoreAlarms() {
 	noSpaceAlarms := len(a.alarmStore.Get(pb.AlarmType_NOSPACE)) > 0
 	corruptAlarms := len(a.alarmStore.Get(pb.AlarmType_CORRUPT)) > 0
+	readOnlyAlarms := len(a.alarmStore.Get(pb.AlarmType_READONLY)) > 0
 	a.applyV3 = a.applyV3base
 	if noSpaceAlarms {
 		a.applyV3 = newApplierV3Capped(a.applyV3)
 	}
+	if readOnlyAlarms {
+		a.applyV3 = newApplierV3ReadOnly(a.applyV3)
+	}
 	if corruptAlarms {
 		a.applyV3 = newApplierV3Corrupt(a.applyV3)
 	}
@@ -110,13 +118,37 @@ func (a *uberApplier) restoreAlarms() {
 
 func (a *uberApplier) Apply(r *pb.InternalRaftRequest) *Result {
 	// We first execute chain of Apply() calls down the hierarchy:
-	// (i.e. CorruptApplier -> CappedApplier -> Auth -> Quota -> Backend),
+	// (i.e. CorruptApplier -> ReadOnlyApplier -> CappedApplier -> Auth -> Quota -> Backend),
 	// then dispatch() unpacks the request to a specific method (like Put),
 	// that gets executed down the hierarchy again:
-	// i.e. CorruptApplier.Put(CappedApplier.Put(...(BackendApplier.Put(...)))).
+	// i.e. CorruptApplier.Put(ReadOnlyApplier.Put(...(BackendApplier.Put(...)))).
 	return a.applyV3.Apply(context.TODO(), r, a.dispatch)
 }
 
+// withSoftDelete returns a context carrying the soft delete configuration
+// the request was proposed with, so that all members move the same keys to
+// the trash whatever their own configuration.
+func withSoftDelete(ctx context.Context, sd *pb.SoftDelete) context.Context {
+	if sd == nil {
+		return ctx
+	}
+	return txn.WithSoftDelete(ctx, txn.SoftDeleteConfig{
+		Threshold:   int(sd.Threshold),
+		Prefixes:    sd.Prefixes,
+		TrashPrefix: sd.TrashPrefix,
+	})
+}
+
+// withDeletion returns a context carrying the configuration of the deletions
+// the request was proposed with.
+func withDeletion(ctx context.Context, r *pb.InternalRaftRequest) context.Context {
+	ctx = withSoftDelete(ctx, r.SoftDelete)
+	if r.RangeTombstoneThreshold > 0 {
+		ctx = txn.WithRangeTombstoneThreshold(ctx, int(r.RangeTombstoneThreshold))
+	}
+	return ctx
+}
+
 // dispatch translates the request (r) into appropriate call (like Put) on
 // the underlying applyV3 object.
 func (a *uberApplier) dispatch(ctx context.Context, r *pb.InternalRaftRequest) *Result {
@@ -140,10 +172,10 @@ func (a *uberApplier) dispatch(ctx context.Context, r *pb.InternalRaftRequest) *
 		ar.Resp, ar.Trace, ar.Err = a.applyV3.Put(ctx, r.Put)
 	case r.DeleteRange != nil:
 		op = "DeleteRange"
-		ar.Resp, ar.Trace, ar.Err = a.applyV3.DeleteRange(ctx, r.DeleteRange)
+		ar.Resp, ar.Trace, ar.Err = a.applyV3.DeleteRange(withDeletion(ctx, r), r.DeleteRange)
 	case r.Txn != nil:
 		op = "Txn"
-		ar.Resp, ar.Trace, ar.Err = a.applyV3.Txn(ctx, r.Txn)
+		ar.Resp, ar.Trace, ar.Err = a.applyV3.Txn(withDeletion(ctx, r), r.Txn)
 	case r.Compaction != nil:
 		op = "Compaction"
 		ar.Resp, ar.Physc, ar.Trace, ar.Err = a.applyV3.Compaction(r.Compaction)
@@ -15,6 +15,7 @@
 package apply
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -22,6 +23,7 @@ import (
 	"go.uber.org/zap/zaptest"
 	"golang.org/x/crypto/bcrypt"
 
+	"go.etcd.io/etcd/api/v3/authpb"
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/server/v3/auth"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
@@ -37,6 +39,11 @@ import (
 const memberID = 111195
 
 func defaultUberApplier(t *testing.T) UberApplier {
+	ua, _ := newTestUberApplier(t, false)
+	return ua
+}
+
+func newTestUberApplier(t *testing.T, witness bool) (UberApplier, mvcc.KV) {
 	lg := zaptest.NewLogger(t)
 	be, _ := betesting.NewDefaultTmpBackend(t)
 	t.Cleanup(func() {
@@ -59,7 +66,7 @@ func defaultUberApplier(t *testing.T) UberApplier {
 		bcrypt.DefaultCost,
 	)
 	consistentIndex := cindex.NewConsistentIndex(be)
-	return NewUberApplier(
+	ua := NewUberApplier(
 		lg,
 		be,
 		kv,
@@ -73,7 +80,9 @@ func defaultUberApplier(t *testing.T) UberApplier {
 		1*time.Hour,
 		false,
 		16*1024*1024, //16MB
+		witness,
 	)
+	return ua, kv
 }
 
 // TestUberApplier_Alarm_Corrupt tests the applier returns ErrCorrupt after alarm CORRUPT is activated
@@ -273,3 +282,95 @@ func TestUberApplier_Alarm_Deactivate(t *testing.T) {
 	require.NotNil(t, result)
 	require.Nil(t, result.Err)
 }
+
+// TestUberApplier_Witness tests a witness skips the requests on the key-value
+// store and the leases, but applies the ones on the cluster state.
+func TestUberApplier_Witness(t *testing.T) {
+	ua, kv := newTestUberApplier(t, true)
+
+	requests := []*pb.InternalRaftRequest{
+		{Put: &pb.PutRequest{Key: []byte("foo"), Value: []byte("bar")}},
+		{Txn: &pb.TxnRequest{Success: []*pb.RequestOp{{Request: &pb.RequestOp_RequestPut{RequestPut: &pb.PutRequest{Key: []byte("foo")}}}}}},
+		{DeleteRange: &pb.DeleteRangeRequest{Key: []byte("foo")}},
+		{Compaction: &pb.CompactionRequest{Revision: 1}},
+		{LeaseGrant: &pb.LeaseGrantRequest{ID: 1, TTL: 60}},
+		{LeaseRevoke: &pb.LeaseRevokeRequest{ID: 1}},
+		{AuthUserAdd: &pb.AuthUserAddRequest{Name: "witness", Options: &authpb.UserAddOptions{NoPassword: true}}},
+	}
+	for _, r := range requests {
+		result := ua.Apply(r)
+		require.NotNil(t, result)
+		require.NoErrorf(t, result.Err, "Apply(%v)", r)
+	}
+	require.Equal(t, int64(1), kv.Rev())
+
+	result := ua.Apply(&pb.InternalRaftRequest{AuthUserGet: &pb.AuthUserGetRequest{Name: "witness"}})
+	require.NoError(t, result.Err)
+}
+
+// TestUberApplier_SoftDelete tests the deletions move the keys to the trash
+// only if proposed with soft delete and no NOSPACE alarm is active.
+func TestUberApplier_SoftDelete(t *testing.T) {
+	ua, kv := newTestUberApplier(t, false)
+	sd := &pb.SoftDelete{Prefixes: []string{"p/"}, TrashPrefix: "/trash/"}
+
+	for _, key := range []string{"p/a", "p/b", "p/c"} {
+		result := ua.Apply(&pb.InternalRaftRequest{Put: &pb.PutRequest{Key: []byte(key)}})
+		require.NoError(t, result.Err)
+	}
+	trash := func() (keys []string) {
+		rr, err := kv.Range(context.TODO(), []byte("/trash/"), []byte("/trash0"), mvcc.RangeOptions{})
+		require.NoError(t, err)
+		for _, kv := range rr.KVs {
+			keys = append(keys, string(kv.Key))
+		}
+		return keys
+	}
+
+	result := ua.Apply(&pb.InternalRaftRequest{DeleteRange: &pb.DeleteRangeRequest{Key: []byte("p/a")}, SoftDelete: sd})
+	require.NoError(t, result.Err)
+	require.Equal(t, []string{"/trash/0000000000000005/p/a"}, trash())
+
+	result = ua.Apply(&pb.InternalRaftRequest{DeleteRange: &pb.DeleteRangeRequest{Key: []byte("p/b")}})
+	require.NoError(t, result.Err)
+	require.Equal(t, []string{"/trash/0000000000000005/p/a"}, trash())
+
+	result = ua.Apply(&pb.InternalRaftRequest{
+		Header: &pb.RequestHeader{},
+		Alarm: &pb.AlarmRequest{
+			Action:   pb.AlarmRequest_ACTIVATE,
+			MemberID: memberID,
+			Alarm:    pb.AlarmType_NOSPACE,
+		},
+	})
+	require.NoError(t, result.Err)
+	result = ua.Apply(&pb.InternalRaftRequest{DeleteRange: &pb.DeleteRangeRequest{Key: []byte("p/c")}, SoftDelete: sd})
+	require.NoError(t, result.Err)
+	require.Equal(t, []string{"/trash/0000000000000005/p/a"}, trash())
+
+	rr, err := kv.Range(context.TODO(), []byte("p/"), []byte("p0"), mvcc.RangeOptions{})
+	require.NoError(t, err)
+	require.Empty(t, rr.KVs)
+}
+
+func TestUberApplier_RangeTombstone(t *testing.T) {
+	ua, kv := newTestUberApplier(t, false)
+
+	for _, key := range []string{"p/a", "p/b", "p/c", "q/a", "q/b", "q/c"} {
+		result := ua.Apply(&pb.InternalRaftRequest{Put: &pb.PutRequest{Key: []byte(key)}})
+		require.NoError(t, result.Err)
+	}
+
+	result := ua.Apply(&pb.InternalRaftRequest{DeleteRange: &pb.DeleteRangeRequest{Key: []byte("p/"), RangeEnd: []byte("p0")}})
+	require.NoError(t, result.Err)
+	require.False(t, kv.HasRangeTombstones())
+
+	result = ua.Apply(&pb.InternalRaftRequest{DeleteRange: &pb.DeleteRangeRequest{Key: []byte("q/"), RangeEnd: []byte("q0")}, RangeTombstoneThreshold: 2})
+	require.NoError(t, result.Err)
+	require.Equal(t, int64(3), result.Resp.(*pb.DeleteRangeResponse).Deleted)
+	require.True(t, kv.HasRangeTombstones())
+
+	rr, err := kv.Range(context.TODO(), []byte("p/"), []byte("q0"), mvcc.RangeOptions{})
+	require.NoError(t, err)
+	require.Empty(t, rr.KVs)
+}
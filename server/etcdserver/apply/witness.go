@@ -0,0 +1,65 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import (
+	"context"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/pkg/v3/traceutil"
+)
+
+// applierV3Witness skips the requests on the key-value store and the leases
+// on a witness, which keeps neither. The requests still advance the
+// consistent index, and the requests on the cluster state, such as auth or
+// alarms, are applied as on any other member. Nobody waits for the responses
+// since a witness serves no client requests, so they are left empty.
+type applierV3Witness struct {
+	applierV3
+}
+
+func newApplierV3Witness(a applierV3) *applierV3Witness { return &applierV3Witness{a} }
+
+func (a *applierV3Witness) Put(_ context.Context, _ *pb.PutRequest) (*pb.PutResponse, *traceutil.Trace, error) {
+	return &pb.PutResponse{Header: &pb.ResponseHeader{}}, nil, nil
+}
+
+func (a *applierV3Witness) Range(_ context.Context, _ *pb.RangeRequest) (*pb.RangeResponse, *traceutil.Trace, error) {
+	return &pb.RangeResponse{Header: &pb.ResponseHeader{}}, nil, nil
+}
+
+func (a *applierV3Witness) DeleteRange(_ context.Context, _ *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, *traceutil.Trace, error) {
+	return &pb.DeleteRangeResponse{Header: &pb.ResponseHeader{}}, nil, nil
+}
+
+func (a *applierV3Witness) Txn(_ context.Context, _ *pb.TxnRequest) (*pb.TxnResponse, *traceutil.Trace, error) {
+	return &pb.TxnResponse{Header: &pb.ResponseHeader{}}, nil, nil
+}
+
+func (a *applierV3Witness) Compaction(_ *pb.CompactionRequest) (*pb.CompactionResponse, <-chan struct{}, *traceutil.Trace, error) {
+	return &pb.CompactionResponse{Header: &pb.ResponseHeader{}}, nil, nil, nil
+}
+
+func (a *applierV3Witness) LeaseGrant(lc *pb.LeaseGrantRequest) (*pb.LeaseGrantResponse, error) {
+	return &pb.LeaseGrantResponse{Header: &pb.ResponseHeader{}, ID: lc.ID, TTL: lc.TTL}, nil
+}
+
+func (a *applierV3Witness) LeaseRevoke(_ *pb.LeaseRevokeRequest) (*pb.LeaseRevokeResponse, error) {
+	return &pb.LeaseRevokeResponse{Header: &pb.ResponseHeader{}}, nil
+}
+
+func (a *applierV3Witness) LeaseCheckpoint(_ *pb.LeaseCheckpointRequest) (*pb.LeaseCheckpointResponse, error) {
+	return &pb.LeaseCheckpointResponse{Header: &pb.ResponseHeader{}}, nil
+}
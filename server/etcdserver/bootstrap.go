@@ -101,6 +101,9 @@ func bootstrap(cfg config.ServerConfig) (b *bootstrappedServer, err error) {
 	s := bootstrapStorage(cfg, st, backend, bwal, cluster)
 
 	if err = cluster.Finalize(cfg, s); err != nil {
+		if s.wal.w != nil {
+			s.wal.w.Close()
+		}
 		backend.Close()
 		return nil, err
 	}
@@ -455,6 +458,9 @@ func (c *bootstrappedCluster) Finalize(cfg config.ServerConfig, s *bootstrappedS
 			os.RemoveAll(bepath)
 			return fmt.Errorf("database file (%v) of the backend is missing", bepath)
 		}
+		if err := validateWitness(cfg.ExperimentalWitness, c.cl, c.nodeID); err != nil {
+			return err
+		}
 	}
 	scaleUpLearners := false
 	return membership.ValidateMaxLearnerConfig(cfg.ExperimentalMaxLearners, c.cl.Members(), scaleUpLearners)
@@ -603,6 +609,7 @@ func openWALFromSnapshot(cfg config.ServerConfig, snapshot *raftpb.Snapshot) (*w
 		if cfg.UnsafeNoFsync {
 			w.SetUnsafeNoFsync()
 		}
+		w.SetCompression(cfg.ExperimentalWALCompression)
 		wmetadata, st, ents, err := w.ReadAll()
 		if err != nil {
 			w.Close()
@@ -645,6 +652,7 @@ func bootstrapNewWAL(cfg config.ServerConfig, cl *bootstrappedCluster) *bootstra
 	if cfg.UnsafeNoFsync {
 		w.SetUnsafeNoFsync()
 	}
+	w.SetCompression(cfg.ExperimentalWALCompression)
 	return &bootstrappedWAL{
 		lg: cfg.Logger,
 		w:  w,
@@ -0,0 +1,136 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/client/pkg/v3/types"
+)
+
+var (
+	// maxRecentClusterEvents is the number of the latest cluster events kept
+	// to be returned to new subscribers.
+	maxRecentClusterEvents = 128
+	// clusterEventsBufferSize is the number of cluster events buffered for
+	// each subscriber; a subscriber that falls further behind is dropped.
+	clusterEventsBufferSize = 64
+)
+
+// clusterEventHub keeps the latest cluster events observed by the member
+// and fans out new ones to its subscribers. A nil *clusterEventHub drops
+// the events.
+type clusterEventHub struct {
+	mu     sync.Mutex
+	recent []*pb.ClusterEvent
+	subs   map[chan *pb.ClusterEvent]struct{}
+}
+
+func newClusterEventHub() *clusterEventHub {
+	return &clusterEventHub{subs: make(map[chan *pb.ClusterEvent]struct{})}
+}
+
+func (h *clusterEventHub) publish(ev *pb.ClusterEvent) {
+	if h == nil {
+		return
+	}
+	if ev.Time == 0 {
+		ev.Time = time.Now().UnixNano()
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.recent = append(h.recent, ev)
+	if len(h.recent) > maxRecentClusterEvents {
+		h.recent = h.recent[len(h.recent)-maxRecentClusterEvents:]
+	}
+	for ch := range h.subs {
+		select {
+		case ch <- ev:
+		default:
+			// never block the publisher on a slow subscriber
+			close(ch)
+			delete(h.subs, ch)
+		}
+	}
+}
+
+// subscribe returns the latest events and a channel receiving the events
+// published from then on. The channel is closed if the subscriber falls
+// behind. cancel must be called once the subscriber is done.
+func (h *clusterEventHub) subscribe() (recent []*pb.ClusterEvent, ch <-chan *pb.ClusterEvent, cancel func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c := make(chan *pb.ClusterEvent, clusterEventsBufferSize)
+	h.subs[c] = struct{}{}
+	recent = append([]*pb.ClusterEvent(nil), h.recent...)
+	return recent, c, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subs[c]; ok {
+			close(c)
+			delete(h.subs, c)
+		}
+	}
+}
+
+// SubscribeClusterEvents returns the latest cluster events observed by the
+// member, and a channel receiving the next ones. The channel is closed if
+// the events are not received fast enough. cancel must be called to stop
+// the subscription.
+func (s *EtcdServer) SubscribeClusterEvents() (recent []*pb.ClusterEvent, ch <-chan *pb.ClusterEvent, cancel func()) {
+	return s.clusterEvents.subscribe()
+}
+
+// PublishClusterEvent notifies the subscribers of a cluster event.
+func (s *EtcdServer) PublishClusterEvent(ev *pb.ClusterEvent) {
+	s.clusterEvents.publish(ev)
+}
+
+func (s *EtcdServer) isAlarmActive(id types.ID, at pb.AlarmType) bool {
+	for _, m := range s.alarmStore.Get(at) {
+		if types.ID(m.MemberID) == id {
+			return true
+		}
+	}
+	return false
+}
+
+// publishAlarmEvents publishes the alarms raised or cleared by an applied
+// alarm request.
+func (s *EtcdServer) publishAlarmEvents(action pb.AlarmRequest_AlarmAction, wasActive bool, resp proto.Message) {
+	ar, ok := resp.(*pb.AlarmResponse)
+	if !ok {
+		return
+	}
+	var typ pb.ClusterEvent_EventType
+	switch action {
+	case pb.AlarmRequest_ACTIVATE:
+		if wasActive {
+			return
+		}
+		typ = pb.ClusterEvent_ALARM_RAISED
+	case pb.AlarmRequest_DEACTIVATE:
+		typ = pb.ClusterEvent_ALARM_CLEARED
+	default:
+		return
+	}
+	for _, m := range ar.Alarms {
+		s.PublishClusterEvent(&pb.ClusterEvent{Type: typ, MemberId: m.MemberID, Alarm: m.Alarm})
+	}
+}
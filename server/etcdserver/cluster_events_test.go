@@ -0,0 +1,57 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+func TestClusterEventHub(t *testing.T) {
+	defer func(n, m int) { maxRecentClusterEvents, clusterEventsBufferSize = n, m }(maxRecentClusterEvents, clusterEventsBufferSize)
+	maxRecentClusterEvents, clusterEventsBufferSize = 2, 2
+
+	h := newClusterEventHub()
+	for i := int64(1); i <= 3; i++ {
+		h.publish(&pb.ClusterEvent{Type: pb.ClusterEvent_COMPACTED, CompactRevision: i})
+	}
+	recent, ch, cancel := h.subscribe()
+	defer cancel()
+	require.Len(t, recent, 2)
+	assert.Equal(t, int64(2), recent[0].CompactRevision)
+	assert.Equal(t, int64(3), recent[1].CompactRevision)
+	assert.NotZero(t, recent[0].Time)
+
+	h.publish(&pb.ClusterEvent{Type: pb.ClusterEvent_DEFRAGMENTED})
+	ev := <-ch
+	assert.Equal(t, pb.ClusterEvent_DEFRAGMENTED, ev.Type)
+
+	// a subscriber that falls behind is dropped
+	for i := 0; i < 3; i++ {
+		h.publish(&pb.ClusterEvent{Type: pb.ClusterEvent_DEFRAGMENTED})
+	}
+	n := 0
+	for range ch {
+		n++
+	}
+	assert.Equal(t, 2, n)
+	h.mu.Lock()
+	assert.Empty(t, h.subs)
+	h.mu.Unlock()
+}
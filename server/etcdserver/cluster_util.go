@@ -320,13 +320,16 @@ func promoteMemberHTTP(ctx context.Context, url string, id uint64, peerRt http.R
 		return nil, errors.ErrTimeout
 	}
 	if resp.StatusCode == http.StatusPreconditionFailed {
-		// both ErrMemberNotLearner and ErrLearnerNotReady have same http status code
+		// ErrMemberNotLearner, ErrMemberReadReplica and ErrLearnerNotReady have same http status code
 		if strings.Contains(string(b), errors.ErrLearnerNotReady.Error()) {
 			return nil, errors.ErrLearnerNotReady
 		}
 		if strings.Contains(string(b), membership.ErrMemberNotLearner.Error()) {
 			return nil, membership.ErrMemberNotLearner
 		}
+		if strings.Contains(string(b), membership.ErrMemberReadReplica.Error()) {
+			return nil, membership.ErrMemberReadReplica
+		}
 		return nil, fmt.Errorf("member promote: unknown error(%s)", b)
 	}
 	if resp.StatusCode == http.StatusNotFound {
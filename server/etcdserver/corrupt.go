@@ -24,6 +24,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
@@ -35,10 +36,15 @@ import (
 	"go.etcd.io/etcd/server/v3/storage/mvcc"
 )
 
+// defaultCorruptRangeCheckKeys is the number of keys hashed by a ranged hash
+// check if it is not configured.
+const defaultCorruptRangeCheckKeys = 10000
+
 type CorruptionChecker interface {
 	InitialCheck() error
 	PeriodicCheck() error
 	CompactHashCheck()
+	RangeHashCheck() error
 }
 
 type corruptionChecker struct {
@@ -48,6 +54,14 @@ type corruptionChecker struct {
 
 	mux                   sync.RWMutex
 	latestRevisionChecked int64
+
+	// rangeKeys is the number of keys hashed by a ranged hash check.
+	rangeKeys int64
+	// rangeCursor is the first key of the range compared by the next ranged
+	// hash check; empty to start over from the beginning of the keyspace.
+	rangeCursor []byte
+	// rangeRevision is the revision the next ranged hash check compares at.
+	rangeRevision int64
 }
 
 type Hasher interface {
@@ -57,12 +71,23 @@ type Hasher interface {
 	PeerHashByRev(int64) []*peerHashKVResp
 	LinearizableReadNotify(context.Context) error
 	TriggerCorruptAlarm(types.ID)
+
+	Rev() int64
+	RangeEnd(key []byte, rev, limit int64) ([]byte, error)
+	HashRange(key, end []byte, rev int64) (mvcc.RangeHash, error)
+	PeerHashByRange(*pb.HashKVRangeRequest) []*peerHashKVRangeResp
+	TriggerCorruptRangeAlarm(id types.ID, key, end []byte)
 }
 
 func newCorruptionChecker(lg *zap.Logger, s *EtcdServer, storage mvcc.HashStorage) *corruptionChecker {
+	keys := s.Cfg.CorruptRangeCheckKeys
+	if keys <= 0 {
+		keys = defaultCorruptRangeCheckKeys
+	}
 	return &corruptionChecker{
-		lg:     lg,
-		hasher: hasherAdapter{s, storage},
+		lg:        lg,
+		hasher:    hasherAdapter{s, storage},
+		rangeKeys: keys,
 	}
 }
 
@@ -83,6 +108,37 @@ func (h hasherAdapter) TriggerCorruptAlarm(memberID types.ID) {
 	h.EtcdServer.triggerCorruptAlarm(memberID)
 }
 
+func (h hasherAdapter) Rev() int64 {
+	return h.EtcdServer.KV().Rev()
+}
+
+// RangeEnd returns the end of the range starting at key which holds up to
+// limit keys at the given revision, or an empty end if the range reaches the
+// end of the keyspace.
+func (h hasherAdapter) RangeEnd(key []byte, rev, limit int64) ([]byte, error) {
+	r, err := h.EtcdServer.KV().Range(context.TODO(), key, []byte{}, mvcc.RangeOptions{Rev: rev, Limit: limit + 1})
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(r.KVs)) <= limit {
+		return []byte{}, nil
+	}
+	return r.KVs[limit].Key, nil
+}
+
+func (h hasherAdapter) HashRange(key, end []byte, rev int64) (mvcc.RangeHash, error) {
+	hash, _, err := mvcc.HashRange(h.EtcdServer.KV(), key, end, rev, false)
+	return hash, err
+}
+
+func (h hasherAdapter) PeerHashByRange(req *pb.HashKVRangeRequest) []*peerHashKVRangeResp {
+	return h.EtcdServer.getPeerHashKVRanges(req)
+}
+
+func (h hasherAdapter) TriggerCorruptRangeAlarm(memberID types.ID, key, end []byte) {
+	h.EtcdServer.triggerCorruptRangeAlarm(memberID, key, end)
+}
+
 // InitialCheck compares initial hash values with its peers
 // before serving any peer/client traffic. Only mismatch when hashes
 // are different at requested revision, with same compact revision.
@@ -413,6 +469,122 @@ func (cm *corruptionChecker) handleConsistentHash(hash mvcc.KeyValueHash, peersC
 	return false
 }
 
+// RangeHashCheck compares the hash of the next range of the keyspace with the
+// peers, so that the whole keyspace is compared over time without holding a
+// read transaction for long. Every call compares one range of up to rangeKeys
+// keys, at the revision observed by the previous call, which the peers have
+// most likely applied by now; the first call only observes the revision.
+//
+// Members whose hash differs from the hash of the majority get a
+// CORRUPT_RANGE alarm for the range, and are quarantined: they stop serving
+// reads. If there is no majority, the alarm is raised for member 0, which
+// reports the range without quarantining any member.
+func (cm *corruptionChecker) RangeHashCheck() error {
+	cm.mux.Lock()
+	key, rev := cm.rangeCursor, cm.rangeRevision
+	cm.rangeRevision = cm.hasher.Rev()
+	cm.mux.Unlock()
+	if rev == 0 {
+		return nil
+	}
+	if len(key) == 0 {
+		key = []byte{0}
+	}
+
+	start := time.Now()
+	end, err := cm.hasher.RangeEnd(key, rev, cm.rangeKeys)
+	if err != nil {
+		return err
+	}
+	h, err := cm.hasher.HashRange(key, end, rev)
+	if err != nil {
+		return err
+	}
+	cm.mux.Lock()
+	cm.rangeCursor = end
+	cm.mux.Unlock()
+
+	reqEnd := end
+	if len(reqEnd) == 0 {
+		reqEnd = []byte{0}
+	}
+	peers := cm.hasher.PeerHashByRange(&pb.HashKVRangeRequest{Key: key, RangeEnd: reqEnd, Revision: rev})
+	result := cm.checkPeerRangeHashes(key, reqEnd, h, peers)
+	corruptRangeChecks.WithLabelValues(result).Inc()
+	corruptRangeCheckDuration.Observe(time.Since(start).Seconds())
+	return nil
+}
+
+// checkPeerRangeHashes compares the peers' hashes of the range [key, end)
+// with the local one and raises alarms for the members that diverge. It
+// returns whether all peers matched, some could not be checked, or there
+// was a mismatch.
+func (cm *corruptionChecker) checkPeerRangeHashes(key, end []byte, local mvcc.RangeHash, peers []*peerHashKVRangeResp) string {
+	localID := cm.hasher.MemberID()
+	hash2members := map[mvcc.RangeHash]types.IDSlice{local: {localID}}
+
+	peersChecked := 0
+	for _, peer := range peers {
+		if peer.resp == nil || peer.resp.HashRevision != local.Revision {
+			cm.lg.Warn("skipped peer's range hash",
+				zap.String("peer-id", peer.id.String()),
+				zap.Int64("revision", local.Revision),
+				zap.Error(peer.err),
+			)
+			continue
+		}
+		peersChecked++
+		h := mvcc.RangeHash{Hash: peer.resp.Hash, Revision: peer.resp.HashRevision, Count: peer.resp.Count}
+		hash2members[h] = append(hash2members[h], peer.id)
+	}
+
+	if len(hash2members) == 1 {
+		if peersChecked != len(peers) {
+			return "incomplete"
+		}
+		return "match"
+	}
+
+	quorum := (len(peers)+1)/2 + 1
+	quorumExist := false
+	for h, ids := range hash2members {
+		if len(ids) >= quorum {
+			quorumExist = true
+			delete(hash2members, h)
+			break
+		}
+	}
+	if !quorumExist {
+		cm.lg.Error("detected range hash mismatch but cannot identify the corrupted members, so intentionally set the memberID as 0",
+			zap.String("local-member-id", localID.String()),
+			zap.ByteString("key", key),
+			zap.ByteString("range-end", end),
+			zap.Int64("revision", local.Revision),
+		)
+		cm.hasher.TriggerCorruptRangeAlarm(0, key, end)
+	}
+	for h, ids := range hash2members {
+		if quorumExist {
+			for _, id := range ids {
+				cm.hasher.TriggerCorruptRangeAlarm(id, key, end)
+			}
+		}
+		cm.lg.Error("detected range hash mismatch",
+			zap.String("local-member-id", localID.String()),
+			zap.ByteString("key", key),
+			zap.ByteString("range-end", end),
+			zap.Int64("revision", local.Revision),
+			zap.Uint32("local-hash", local.Hash),
+			zap.Int64("local-count", local.Count),
+			zap.Uint32("peer-hash", h.Hash),
+			zap.Int64("peer-count", h.Count),
+			zap.String("peer-ids", ids.String()),
+			zap.Bool("quorum-exist", quorumExist),
+		)
+	}
+	return "mismatch"
+}
+
 func (cm *corruptionChecker) uncheckedRevisions() []mvcc.KeyValueHash {
 	cm.mux.RLock()
 	lastRevisionChecked := cm.latestRevisionChecked
@@ -442,6 +614,56 @@ func (s *EtcdServer) triggerCorruptAlarm(id types.ID) {
 	})
 }
 
+// triggerCorruptRangeAlarm raises a CORRUPT_RANGE alarm, which quarantines
+// the member but, unlike a CORRUPT alarm, lets the cluster keep applying
+// writes.
+func (s *EtcdServer) triggerCorruptRangeAlarm(id types.ID, key, end []byte) {
+	a := &pb.AlarmRequest{
+		MemberID: uint64(id),
+		Action:   pb.AlarmRequest_ACTIVATE,
+		Alarm:    pb.AlarmType_CORRUPT_RANGE,
+		Key:      key,
+		RangeEnd: end,
+	}
+	s.GoAttach(func() {
+		s.raftRequest(s.ctx, pb.InternalRaftRequest{Alarm: a})
+	})
+}
+
+// IsQuarantined returns true if the ranged hash check raised a CORRUPT_RANGE
+// alarm for the member. A quarantined member does not serve reads, including
+// the ranges of write txns and watches, since the range it holds diverges from
+// the rest of the cluster.
+func (s *EtcdServer) IsQuarantined() bool { return atomic.LoadInt32(&s.quarantined) == 1 }
+
+// QuarantinedNotify returns a channel closed when the member next gets
+// quarantined, so that the streams serving reads, like watches, can be
+// canceled. A new channel must be obtained after each quarantine.
+func (s *EtcdServer) QuarantinedNotify() <-chan struct{} {
+	return s.quarantinedNotifier.Receive()
+}
+
+// updateQuarantine quarantines the member if a CORRUPT_RANGE alarm is raised
+// for it, and lifts the quarantine once the alarm is cleared.
+func (s *EtcdServer) updateQuarantine() {
+	var q int32
+	for _, m := range s.alarmStore.Get(pb.AlarmType_CORRUPT_RANGE) {
+		if types.ID(m.MemberID) == s.MemberID() {
+			q = 1
+		}
+	}
+	if atomic.SwapInt32(&s.quarantined, q) == q {
+		return
+	}
+	quarantined.Set(float64(q))
+	if q == 1 {
+		s.Logger().Warn("member is quarantined for corruption; not serving reads", zap.String("local-member-id", s.MemberID().String()))
+		s.quarantinedNotifier.Notify()
+	} else {
+		s.Logger().Info("member is no longer quarantined", zap.String("local-member-id", s.MemberID().String()))
+	}
+}
+
 type peerInfo struct {
 	id  types.ID
 	eps []string
@@ -453,26 +675,42 @@ type peerHashKVResp struct {
 	err  error
 }
 
-func (s *EtcdServer) getPeerHashKVs(rev int64) []*peerHashKVResp {
+type peerHashKVRangeResp struct {
+	peerInfo
+	resp *pb.HashKVRangeResponse
+	err  error
+}
+
+// peers returns the other members of the cluster, but the witnesses which
+// have no key-value store to check.
+func (s *EtcdServer) peers() []peerInfo {
 	// TODO: handle the case when "s.cluster.Members" have not
 	// been populated (e.g. no snapshot to load from disk)
 	members := s.cluster.Members()
 	peers := make([]peerInfo, 0, len(members))
 	for _, m := range members {
-		if m.ID == s.MemberID() {
+		if m.ID == s.MemberID() || m.Witness {
 			continue
 		}
 		peers = append(peers, peerInfo{id: m.ID, eps: m.PeerURLs})
 	}
+	return peers
+}
 
-	lg := s.Logger()
-
-	cc := &http.Client{
+func (s *EtcdServer) peerHashClient() *http.Client {
+	return &http.Client{
 		Transport: s.peerRt,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
 	}
+}
+
+func (s *EtcdServer) getPeerHashKVs(rev int64) []*peerHashKVResp {
+	peers := s.peers()
+	lg := s.Logger()
+	cc := s.peerHashClient()
+
 	var resps []*peerHashKVResp
 	for _, p := range peers {
 		if len(p.eps) == 0 {
@@ -508,7 +746,42 @@ func (s *EtcdServer) getPeerHashKVs(rev int64) []*peerHashKVResp {
 	return resps
 }
 
-const PeerHashKVPath = "/members/hashkv"
+func (s *EtcdServer) getPeerHashKVRanges(req *pb.HashKVRangeRequest) []*peerHashKVRangeResp {
+	lg := s.Logger()
+	cc := s.peerHashClient()
+
+	var resps []*peerHashKVRangeResp
+	for _, p := range s.peers() {
+		if len(p.eps) == 0 {
+			continue
+		}
+
+		var resp *pb.HashKVRangeResponse
+		var lastErr error
+		for _, ep := range p.eps {
+			ctx, cancel := context.WithTimeout(context.Background(), s.Cfg.ReqTimeout())
+			resp, lastErr = HashRangeByRev(ctx, s.cluster.ID(), cc, ep, req)
+			cancel()
+			if lastErr == nil {
+				break
+			}
+			lg.Warn(
+				"failed hash kv range request",
+				zap.String("local-member-id", s.MemberID().String()),
+				zap.Int64("requested-revision", req.Revision),
+				zap.String("remote-peer-endpoint", ep),
+				zap.Error(lastErr),
+			)
+		}
+		resps = append(resps, &peerHashKVRangeResp{peerInfo: p, resp: resp, err: lastErr})
+	}
+	return resps
+}
+
+const (
+	PeerHashKVPath      = "/members/hashkv"
+	PeerHashKVRangePath = "/members/hashkv/range"
+)
 
 type hashKVHandler struct {
 	lg     *zap.Logger
@@ -525,7 +798,7 @@ func (h *hashKVHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	if r.URL.Path != PeerHashKVPath {
+	if r.URL.Path != PeerHashKVPath && r.URL.Path != PeerHashKVRangePath {
 		http.Error(w, "bad path", http.StatusBadRequest)
 		return
 	}
@@ -541,11 +814,33 @@ func (h *hashKVHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var resp any
+	if r.URL.Path == PeerHashKVRangePath {
+		resp, err = h.hashKVRange(b)
+	} else {
+		resp, err = h.hashKV(b)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	respBytes, err := json.Marshal(resp)
+	if err != nil {
+		h.lg.Warn("failed to marshal hashKV response", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("X-Etcd-Cluster-ID", h.server.Cluster().ID().String())
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(respBytes)
+}
+
+func (h *hashKVHandler) hashKV(b []byte) (*pb.HashKVResponse, error) {
 	req := &pb.HashKVRequest{}
-	if err = json.Unmarshal(b, req); err != nil {
+	if err := json.Unmarshal(b, req); err != nil {
 		h.lg.Warn("failed to unmarshal request", zap.Error(err))
-		http.Error(w, "error unmarshalling request", http.StatusBadRequest)
-		return
+		return nil, fmt.Errorf("error unmarshalling request")
 	}
 	hash, rev, err := h.server.KV().HashStorage().HashByRev(req.Revision)
 	if err != nil {
@@ -554,38 +849,69 @@ func (h *hashKVHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			zap.Int64("requested-revision", req.Revision),
 			zap.Error(err),
 		)
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+		return nil, err
 	}
-	resp := &pb.HashKVResponse{
+	return &pb.HashKVResponse{
 		Header:          &pb.ResponseHeader{Revision: rev},
 		Hash:            hash.Hash,
 		CompactRevision: hash.CompactRevision,
 		HashRevision:    hash.Revision,
+	}, nil
+}
+
+func (h *hashKVHandler) hashKVRange(b []byte) (*pb.HashKVRangeResponse, error) {
+	req := &pb.HashKVRangeRequest{}
+	if err := json.Unmarshal(b, req); err != nil {
+		h.lg.Warn("failed to unmarshal request", zap.Error(err))
+		return nil, fmt.Errorf("error unmarshalling request")
 	}
-	respBytes, err := json.Marshal(resp)
+	end := req.RangeEnd
+	if len(end) == 1 && end[0] == 0 {
+		end = []byte{}
+	}
+	hash, rev, err := mvcc.HashRange(h.server.KV(), req.Key, end, req.Revision, req.StripPrefix)
 	if err != nil {
-		h.lg.Warn("failed to marshal hashKV response", zap.Error(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		h.lg.Warn(
+			"failed to get hashKV of range",
+			zap.Int64("requested-revision", req.Revision),
+			zap.Error(err),
+		)
+		return nil, err
 	}
-
-	w.Header().Set("X-Etcd-Cluster-ID", h.server.Cluster().ID().String())
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(respBytes)
+	return &pb.HashKVRangeResponse{
+		Header:       &pb.ResponseHeader{Revision: rev},
+		Hash:         hash.Hash,
+		HashRevision: hash.Revision,
+		Count:        hash.Count,
+	}, nil
 }
 
 // HashByRev fetch hash of kv store at the given rev via http call to the given url
 func HashByRev(ctx context.Context, cid types.ID, cc *http.Client, url string, rev int64) (*pb.HashKVResponse, error) {
-	hashReq := &pb.HashKVRequest{Revision: rev}
+	hashResp := &pb.HashKVResponse{}
+	if err := peerHashRequest(ctx, cid, cc, url+PeerHashKVPath, &pb.HashKVRequest{Revision: rev}, hashResp); err != nil {
+		return nil, err
+	}
+	return hashResp, nil
+}
+
+// HashRangeByRev fetch hash of a key range of kv store via http call to the given url
+func HashRangeByRev(ctx context.Context, cid types.ID, cc *http.Client, url string, r *pb.HashKVRangeRequest) (*pb.HashKVRangeResponse, error) {
+	hashResp := &pb.HashKVRangeResponse{}
+	if err := peerHashRequest(ctx, cid, cc, url+PeerHashKVRangePath, r, hashResp); err != nil {
+		return nil, err
+	}
+	return hashResp, nil
+}
+
+func peerHashRequest(ctx context.Context, cid types.ID, cc *http.Client, requestURL string, hashReq, hashResp any) error {
 	hashReqBytes, err := json.Marshal(hashReq)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	requestURL := url + PeerHashKVPath
 	req, err := http.NewRequest(http.MethodGet, requestURL, bytes.NewReader(hashReqBytes))
 	if err != nil {
-		return nil, err
+		return err
 	}
 	req = req.WithContext(ctx)
 	req.Header.Set("Content-Type", "application/json")
@@ -594,33 +920,29 @@ func HashByRev(ctx context.Context, cid types.ID, cc *http.Client, url string, r
 
 	resp, err := cc.Do(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer resp.Body.Close()
 	b, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	if resp.StatusCode == http.StatusBadRequest {
 		if strings.Contains(string(b), mvcc.ErrCompacted.Error()) {
-			return nil, rpctypes.ErrCompacted
+			return rpctypes.ErrCompacted
 		}
 		if strings.Contains(string(b), mvcc.ErrFutureRev.Error()) {
-			return nil, rpctypes.ErrFutureRev
+			return rpctypes.ErrFutureRev
 		}
 	} else if resp.StatusCode == http.StatusPreconditionFailed {
 		if strings.Contains(string(b), rafthttp.ErrClusterIDMismatch.Error()) {
-			return nil, rpctypes.ErrClusterIDMismatch
+			return rpctypes.ErrClusterIDMismatch
 		}
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unknown error: %s", b)
+		return fmt.Errorf("unknown error: %s", b)
 	}
 
-	hashResp := &pb.HashKVResponse{}
-	if err := json.Unmarshal(b, hashResp); err != nil {
-		return nil, err
-	}
-	return hashResp, nil
+	return json.Unmarshal(b, hashResp)
 }
@@ -246,6 +246,112 @@ func TestPeriodicCheck(t *testing.T) {
 	}
 }
 
+func TestRangeHashCheck(t *testing.T) {
+	localHash := mvcc.RangeHash{Hash: 1, Revision: 10, Count: 5}
+	peerResp := func(id types.ID, hash uint32) *peerHashKVRangeResp {
+		return &peerHashKVRangeResp{peerInfo: peerInfo{id: id}, resp: &pb.HashKVRangeResponse{Hash: hash, HashRevision: 10, Count: 5}}
+	}
+	tcs := []struct {
+		name          string
+		hasher        fakeHasher
+		cursor        []byte
+		revision      int64
+		expectActions []string
+		expectCorrupt bool
+		expectCursor  []byte
+	}{
+		{
+			name:          "First check only observes the revision",
+			hasher:        fakeHasher{rev: 10},
+			expectActions: []string{"Rev()"},
+		},
+		{
+			name: "Matching peers",
+			hasher: fakeHasher{
+				rev:             12,
+				rangeEnd:        []byte("b"),
+				rangeHash:       localHash,
+				peerRangeHashes: []*peerHashKVRangeResp{peerResp(42, 1), peerResp(43, 1)},
+			},
+			revision:      10,
+			expectActions: []string{"Rev()", `RangeEnd("\x00", 10, 100)`, `HashRange("\x00", "b", 10)`, `PeerHashByRange("\x00", "b", 10)`, "MemberID()"},
+			expectCursor:  []byte("b"),
+		},
+		{
+			name: "Range reaching the end of the keyspace wraps around",
+			hasher: fakeHasher{
+				rev:             12,
+				rangeEnd:        []byte{},
+				rangeHash:       localHash,
+				peerRangeHashes: []*peerHashKVRangeResp{peerResp(42, 1)},
+			},
+			cursor:        []byte("b"),
+			revision:      10,
+			expectActions: []string{"Rev()", `RangeEnd("b", 10, 100)`, `HashRange("b", "", 10)`, `PeerHashByRange("b", "\x00", 10)`, "MemberID()"},
+			expectCursor:  []byte{},
+		},
+		{
+			name: "Peer without response is skipped",
+			hasher: fakeHasher{
+				rev:             12,
+				rangeEnd:        []byte("b"),
+				rangeHash:       localHash,
+				peerRangeHashes: []*peerHashKVRangeResp{{peerInfo: peerInfo{id: 42}, err: fmt.Errorf("failed")}, peerResp(43, 1)},
+			},
+			revision:      10,
+			expectActions: []string{"Rev()", `RangeEnd("\x00", 10, 100)`, `HashRange("\x00", "b", 10)`, `PeerHashByRange("\x00", "b", 10)`, "MemberID()"},
+			expectCursor:  []byte("b"),
+		},
+		{
+			name: "Peer with different hash than majority raises alarm for the range",
+			hasher: fakeHasher{
+				rev:             12,
+				rangeEnd:        []byte("b"),
+				rangeHash:       localHash,
+				peerRangeHashes: []*peerHashKVRangeResp{peerResp(42, 1), peerResp(43, 2)},
+			},
+			revision:      10,
+			expectActions: []string{"Rev()", `RangeEnd("\x00", 10, 100)`, `HashRange("\x00", "b", 10)`, `PeerHashByRange("\x00", "b", 10)`, "MemberID()", `TriggerCorruptRangeAlarm(43, "\x00", "b")`},
+			expectCorrupt: true,
+			expectCursor:  []byte("b"),
+		},
+		{
+			name: "Different hashes without majority raise alarm for the cluster",
+			hasher: fakeHasher{
+				rev:             12,
+				rangeEnd:        []byte("b"),
+				rangeHash:       localHash,
+				peerRangeHashes: []*peerHashKVRangeResp{peerResp(42, 2), peerResp(43, 3)},
+			},
+			revision:      10,
+			expectActions: []string{"Rev()", `RangeEnd("\x00", 10, 100)`, `HashRange("\x00", "b", 10)`, `PeerHashByRange("\x00", "b", 10)`, "MemberID()", `TriggerCorruptRangeAlarm(0, "\x00", "b")`},
+			expectCorrupt: true,
+			expectCursor:  []byte("b"),
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			monitor := corruptionChecker{
+				lg:            zaptest.NewLogger(t),
+				hasher:        &tc.hasher,
+				rangeKeys:     100,
+				rangeCursor:   tc.cursor,
+				rangeRevision: tc.revision,
+			}
+			err := monitor.RangeHashCheck()
+			assert.NoError(t, err)
+			if tc.hasher.alarmTriggered != tc.expectCorrupt {
+				t.Errorf("Unexpected corrupt triggered, got: %v, expected?: %v", tc.hasher.alarmTriggered, tc.expectCorrupt)
+			}
+			assert.Equal(t, tc.expectActions, tc.hasher.actions)
+			assert.Equal(t, tc.hasher.rev, monitor.rangeRevision)
+			if tc.revision != 0 {
+				assert.Equal(t, tc.expectCursor, monitor.rangeCursor)
+			}
+		})
+	}
+}
+
 func TestCompactHashCheck(t *testing.T) {
 	tcs := []struct {
 		name                string
@@ -446,6 +552,10 @@ type fakeHasher struct {
 	hashByRevResponses     []hashByRev
 	linearizableReadNotify error
 	hashes                 []mvcc.KeyValueHash
+	rev                    int64
+	rangeEnd               []byte
+	rangeHash              mvcc.RangeHash
+	peerRangeHashes        []*peerHashKVRangeResp
 
 	alarmTriggered bool
 	actions        []string
@@ -506,6 +616,31 @@ func (f *fakeHasher) TriggerCorruptAlarm(memberID types.ID) {
 	f.alarmTriggered = true
 }
 
+func (f *fakeHasher) Rev() int64 {
+	f.actions = append(f.actions, "Rev()")
+	return f.rev
+}
+
+func (f *fakeHasher) RangeEnd(key []byte, rev, limit int64) ([]byte, error) {
+	f.actions = append(f.actions, fmt.Sprintf("RangeEnd(%q, %d, %d)", key, rev, limit))
+	return f.rangeEnd, nil
+}
+
+func (f *fakeHasher) HashRange(key, end []byte, rev int64) (mvcc.RangeHash, error) {
+	f.actions = append(f.actions, fmt.Sprintf("HashRange(%q, %q, %d)", key, end, rev))
+	return f.rangeHash, nil
+}
+
+func (f *fakeHasher) PeerHashByRange(req *pb.HashKVRangeRequest) []*peerHashKVRangeResp {
+	f.actions = append(f.actions, fmt.Sprintf("PeerHashByRange(%q, %q, %d)", req.Key, req.RangeEnd, req.Revision))
+	return f.peerRangeHashes
+}
+
+func (f *fakeHasher) TriggerCorruptRangeAlarm(memberID types.ID, key, end []byte) {
+	f.actions = append(f.actions, fmt.Sprintf("TriggerCorruptRangeAlarm(%d, %q, %q)", memberID, key, end))
+	f.alarmTriggered = true
+}
+
 func TestHashKVHandler(t *testing.T) {
 	var remoteClusterID = 111195
 	var localClusterID = 111196
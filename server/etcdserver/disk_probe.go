@@ -0,0 +1,190 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/client/pkg/v3/fileutil"
+)
+
+const (
+	// diskProbeFile is the file written by the disk probes in the WAL
+	// directory. The WAL ignores the files with the .tmp suffix.
+	diskProbeFile = "disk-probe.tmp"
+	// diskProbeSize is the size of the file written by the disk probes, a
+	// typical size of a WAL write.
+	diskProbeSize = 4096
+)
+
+// probeDisk writes and fsyncs a small file in dir and returns how long it
+// took.
+func probeDisk(dir string) (time.Duration, error) {
+	path := filepath.Join(dir, diskProbeFile)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileutil.PrivateFileMode)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(path)
+	start := time.Now()
+	_, err = f.Write(bytes.Repeat([]byte{0}, diskProbeSize))
+	if err == nil {
+		err = fileutil.Fdatasync(f)
+	}
+	took := time.Since(start)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return took, err
+}
+
+// diskProbeState tracks whether the disk is slow from the results of the
+// consecutive probes.
+type diskProbeState struct {
+	// failures is the number of consecutive slow probes after which the
+	// disk is slow, and of fast probes after which it no longer is.
+	failures int
+	slow     bool
+
+	slowProbes int
+	fastProbes int
+}
+
+// observe records the result of a probe and returns whether the disk is
+// slow.
+func (st *diskProbeState) observe(slow bool) bool {
+	if slow {
+		st.slowProbes, st.fastProbes = st.slowProbes+1, 0
+	} else {
+		st.slowProbes, st.fastProbes = 0, st.fastProbes+1
+	}
+	switch {
+	case !st.slow && st.slowProbes >= st.failures:
+		st.slow = true
+	case st.slow && st.fastProbes >= st.failures:
+		st.slow = false
+	}
+	return st.slow
+}
+
+// monitorDisk probes the WAL device every ExperimentalDiskProbeInterval.
+// Once the disk is slow, the member raises a SLOWDISK alarm for itself and,
+// if self-fencing is enabled, drains itself until the disk recovers.
+func (s *EtcdServer) monitorDisk() {
+	interval := s.Cfg.ExperimentalDiskProbeInterval
+	if interval == 0 {
+		return
+	}
+	lg := s.Logger()
+	threshold := s.Cfg.ExperimentalDiskProbeThreshold
+	// a member restarted with its alarm raised stays slow until the disk
+	// recovers
+	st := diskProbeState{failures: s.Cfg.ExperimentalDiskProbeFailures, slow: s.hasSlowDiskAlarm()}
+	fenced := false
+	for {
+		select {
+		case <-time.After(interval):
+		case <-s.stopping:
+			lg.Info("server has stopped; stopping disk probes")
+			return
+		}
+
+		took, err := probeDisk(s.Cfg.WALDir())
+		diskProbeDuration.Observe(took.Seconds())
+		if err != nil {
+			lg.Warn("failed to probe disk", zap.String("wal-dir", s.Cfg.WALDir()), zap.Error(err))
+		} else if took > threshold {
+			lg.Warn(
+				"slow disk probe",
+				zap.String("wal-dir", s.Cfg.WALDir()),
+				zap.Duration("took", took),
+				zap.Duration("expected-duration", threshold),
+			)
+		}
+		slow := st.observe(err != nil || took > threshold)
+		if slow {
+			diskSlow.Set(1)
+		} else {
+			diskSlow.Set(0)
+		}
+
+		if alarmed := s.hasSlowDiskAlarm(); slow != alarmed {
+			s.updateSlowDiskAlarm(slow)
+		}
+
+		if !s.Cfg.ExperimentalDiskProbeSelfFence {
+			continue
+		}
+		switch {
+		case slow && (!fenced && !s.IsDraining() || fenced && s.isLeader()):
+			// retried as long as the member is the leader
+			lg.Warn("fencing member with a slow disk", zap.String("local-member-id", s.MemberID().String()))
+			fenced = true
+			ctx, cancel := context.WithTimeout(s.ctx, s.Cfg.ReqTimeout())
+			if err = s.Drain(ctx); err != nil {
+				lg.Warn("failed to transfer leadership away from member with a slow disk", zap.Error(err))
+			}
+			cancel()
+		case !slow && fenced:
+			// a member drained by an operator is left draining
+			lg.Info("unfencing member whose disk recovered", zap.String("local-member-id", s.MemberID().String()))
+			fenced = false
+			s.Undrain()
+		}
+	}
+}
+
+// hasSlowDiskAlarm returns whether the SLOWDISK alarm of the member is raised.
+func (s *EtcdServer) hasSlowDiskAlarm() bool {
+	for _, a := range s.alarmStore.Get(pb.AlarmType_SLOWDISK) {
+		if a.MemberID == uint64(s.MemberID()) {
+			return true
+		}
+	}
+	return false
+}
+
+// updateSlowDiskAlarm raises or clears the SLOWDISK alarm of the member. A
+// failure is retried on the next probe.
+func (s *EtcdServer) updateSlowDiskAlarm(slow bool) {
+	lg := s.Logger()
+	a := &pb.AlarmRequest{
+		MemberID: uint64(s.MemberID()),
+		Action:   pb.AlarmRequest_DEACTIVATE,
+		Alarm:    pb.AlarmType_SLOWDISK,
+	}
+	if slow {
+		a.Action = pb.AlarmRequest_ACTIVATE
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, s.Cfg.ReqTimeout())
+	_, err := s.raftRequest(ctx, pb.InternalRaftRequest{Alarm: a})
+	cancel()
+	if err != nil {
+		lg.Warn("failed to update SLOWDISK alarm", zap.Stringer("action", a.Action), zap.Error(err))
+		return
+	}
+	if slow {
+		lg.Warn("raised SLOWDISK alarm", zap.String("local-member-id", s.MemberID().String()))
+	} else {
+		lg.Info("cleared SLOWDISK alarm", zap.String("local-member-id", s.MemberID().String()))
+	}
+}
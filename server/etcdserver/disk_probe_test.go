@@ -0,0 +1,60 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbeDisk(t *testing.T) {
+	dir := t.TempDir()
+	took, err := probeDisk(dir)
+	require.NoError(t, err)
+	assert.Positive(t, took)
+	_, err = os.Stat(filepath.Join(dir, diskProbeFile))
+	assert.True(t, os.IsNotExist(err), "expected the probe file to be removed")
+
+	_, err = probeDisk(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestDiskProbeState(t *testing.T) {
+	st := diskProbeState{failures: 3}
+	probes := []struct {
+		slow bool
+		want bool
+	}{
+		{slow: true, want: false},
+		{slow: true, want: false},
+		{slow: false, want: false},
+		{slow: true, want: false},
+		{slow: true, want: false},
+		{slow: true, want: true},
+		{slow: false, want: true},
+		{slow: false, want: true},
+		{slow: true, want: true},
+		{slow: false, want: true},
+		{slow: false, want: true},
+		{slow: false, want: false},
+	}
+	for i, p := range probes {
+		assert.Equal(t, p.want, st.observe(p.slow), "probe %d", i)
+	}
+}
@@ -39,8 +39,18 @@ var (
 	ErrUnhealthy                   = errors.New("etcdserver: unhealthy cluster")
 	ErrCorrupt                     = errors.New("etcdserver: corrupt cluster")
 	ErrBadLeaderTransferee         = errors.New("etcdserver: bad leader transferee")
+	ErrDraining                    = errors.New("etcdserver: member is draining")
+	ErrQuarantined                 = errors.New("etcdserver: member is quarantined")
+	ErrAdmissionDenied             = errors.New("etcdserver: request denied by admission control")
+	ErrRevisionPinned              = errors.New("etcdserver: compaction revision is pinned by a lease")
+	ErrEncryptionNotEnabled        = errors.New("etcdserver: encryption at rest is not enabled")
+	ErrClusterEventsLagging        = errors.New("etcdserver: cluster events stream is too slow to keep up")
+	ErrReadOnly                    = errors.New("etcdserver: cluster is read-only")
+	ErrProtectedPrefix             = errors.New("etcdserver: key is under a protected prefix")
+	ErrHotKeysNotEnabled           = errors.New("etcdserver: hot key tracking is not enabled")
 	ErrClusterVersionUnavailable   = errors.New("etcdserver: cluster version not found during downgrade")
 	ErrWrongDowngradeVersionFormat = errors.New("etcdserver: wrong downgrade target version format")
+	ErrRangeTombstonesPresent      = errors.New("etcdserver: range tombstones are not compacted yet")
 	ErrKeyNotFound                 = errors.New("etcdserver: key not found")
 )
 
@@ -52,3 +62,16 @@ type DiscoveryError struct {
 func (e DiscoveryError) Error() string {
 	return fmt.Sprintf("failed to %s discovery cluster (%v)", e.Op, e.Err)
 }
+
+// AdmissionError is returned when an admission controller rejects a request.
+type AdmissionError struct {
+	Err error
+}
+
+func (e *AdmissionError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrAdmissionDenied, e.Err)
+}
+
+func (e *AdmissionError) Unwrap() error {
+	return ErrAdmissionDenied
+}
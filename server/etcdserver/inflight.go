@@ -0,0 +1,215 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+// The states of an inflight request.
+const (
+	// InflightExecuting is the state of a request outside the other states,
+	// e.g. checking its permissions.
+	InflightExecuting = "executing"
+	// InflightWaitingForRaft is the state of a write waiting for its
+	// proposal to be committed and the entries before it to be applied, or
+	// of a linearizable read waiting for its read index.
+	InflightWaitingForRaft = "waiting-for-raft"
+	// InflightApplying is the state of a write being applied.
+	InflightApplying = "applying"
+	// InflightReading is the state of a read being served from the local
+	// keyspace.
+	InflightReading = "reading"
+)
+
+// InflightRequest describes a request being served.
+type InflightRequest struct {
+	// Method is the full gRPC method of the request.
+	Method string
+	// Key and RangeEnd are the key range of the request, if any.
+	Key      string
+	RangeEnd string
+	// Principal is the authenticated user of the request, if any.
+	Principal string
+	// Remote is the address of the client.
+	Remote  string
+	Start   time.Time
+	Elapsed time.Duration
+	State   string
+}
+
+type inflightKey struct{}
+
+type inflightRequest struct {
+	ctx    context.Context
+	method string
+	remote string
+	req    any
+	start  time.Time
+	state  atomic.Value
+}
+
+func (r *inflightRequest) setState(state string) {
+	r.state.Store(state)
+}
+
+// inflightRequests tracks the requests being served.
+type inflightRequests struct {
+	reqs sync.Map
+	// byRaftID maps the IDs of the proposals waiting for their result to
+	// the requests that made them.
+	byRaftID sync.Map
+}
+
+// TrackInflightRequest adds the request to the inflight requests of the
+// server until the returned function is called. The returned context lets
+// the server update the state of the request.
+func (s *EtcdServer) TrackInflightRequest(ctx context.Context, method, remote string, req any) (context.Context, func()) {
+	r := &inflightRequest{method: method, remote: remote, req: req, start: time.Now()}
+	r.setState(InflightExecuting)
+	ctx = context.WithValue(ctx, inflightKey{}, r)
+	r.ctx = ctx
+	s.inflight.reqs.Store(r, struct{}{})
+	return ctx, func() { s.inflight.reqs.Delete(r) }
+}
+
+// InflightRequests returns the requests being served, the longest running
+// first.
+func (s *EtcdServer) InflightRequests() []InflightRequest {
+	now := time.Now()
+	var reqs []InflightRequest
+	s.inflight.reqs.Range(func(k, _ any) bool {
+		r := k.(*inflightRequest)
+		ir := InflightRequest{
+			Method:  r.method,
+			Remote:  r.remote,
+			Start:   r.start,
+			Elapsed: now.Sub(r.start),
+			State:   r.state.Load().(string),
+		}
+		ir.Key, ir.RangeEnd = requestKeyRange(r.req)
+		if ai, err := s.AuthInfoFromCtx(r.ctx); err == nil && ai != nil {
+			ir.Principal = ai.Username
+		}
+		reqs = append(reqs, ir)
+		return true
+	})
+	sort.Slice(reqs, func(i, j int) bool { return reqs[i].Start.Before(reqs[j].Start) })
+	return reqs
+}
+
+func requestKeyRange(req any) (key, rangeEnd string) {
+	switch r := req.(type) {
+	case *pb.RangeRequest:
+		return string(r.Key), string(r.RangeEnd)
+	case *pb.PutRequest:
+		return string(r.Key), ""
+	case *pb.DeleteRangeRequest:
+		return string(r.Key), string(r.RangeEnd)
+	case *pb.TxnRequest:
+		return txnKeyRange(r)
+	}
+	return "", ""
+}
+
+// txnKeyRange returns the smallest range covering the keys compared and
+// accessed by the transaction, including its nested transactions.
+func txnKeyRange(r *pb.TxnRequest) (key, rangeEnd string) {
+	var start, end []byte
+	fromKey := false
+	for i, kr := range txnKeyRanges(r) {
+		k, e := kr[0], kr[1]
+		if len(e) == 0 {
+			// the end of the single key range
+			e = append(bytes.Clone(k), 0)
+		}
+		fromKey = fromKey || len(e) == 1 && e[0] == 0
+		if i == 0 || bytes.Compare(k, start) < 0 {
+			start = k
+		}
+		if i == 0 || bytes.Compare(e, end) > 0 {
+			end = e
+		}
+	}
+	switch {
+	case fromKey:
+		end = []byte{0}
+	case bytes.Equal(end, append(bytes.Clone(start), 0)):
+		end = nil
+	}
+	return string(start), string(end)
+}
+
+// txnKeyRanges returns the ranges of the keys compared and accessed by the
+// transaction, including its nested transactions.
+func txnKeyRanges(r *pb.TxnRequest) (ranges [][2][]byte) {
+	for _, c := range r.Compare {
+		ranges = append(ranges, [2][]byte{c.Key, c.RangeEnd})
+	}
+	for _, branch := range [][]*pb.RequestOp{r.Success, r.Failure} {
+		for _, op := range branch {
+			switch tv := op.Request.(type) {
+			case *pb.RequestOp_RequestRange:
+				ranges = append(ranges, [2][]byte{tv.RequestRange.Key, tv.RequestRange.RangeEnd})
+			case *pb.RequestOp_RequestPut:
+				ranges = append(ranges, [2][]byte{tv.RequestPut.Key, nil})
+			case *pb.RequestOp_RequestDeleteRange:
+				ranges = append(ranges, [2][]byte{tv.RequestDeleteRange.Key, tv.RequestDeleteRange.RangeEnd})
+			case *pb.RequestOp_RequestTxn:
+				ranges = append(ranges, txnKeyRanges(tv.RequestTxn)...)
+			}
+		}
+	}
+	return ranges
+}
+
+// setInflightState updates the state of the inflight request of ctx, if
+// tracked.
+func setInflightState(ctx context.Context, state string) {
+	if r, ok := ctx.Value(inflightKey{}).(*inflightRequest); ok {
+		r.setState(state)
+	}
+}
+
+// trackInflightProposal records that the inflight request of ctx, if
+// tracked, waits for the result of the proposal with the given ID. The
+// returned function stops tracking the proposal.
+func (s *EtcdServer) trackInflightProposal(ctx context.Context, id uint64) func() {
+	r, ok := ctx.Value(inflightKey{}).(*inflightRequest)
+	if !ok {
+		return func() {}
+	}
+	r.setState(InflightWaitingForRaft)
+	s.inflight.byRaftID.Store(id, r)
+	return func() {
+		s.inflight.byRaftID.Delete(id)
+		r.setState(InflightExecuting)
+	}
+}
+
+// setInflightProposalApplying marks the inflight request waiting for the
+// proposal with the given ID, if any, as applying.
+func (s *EtcdServer) setInflightProposalApplying(id uint64) {
+	if r, ok := s.inflight.byRaftID.Load(id); ok {
+		r.(*inflightRequest).setState(InflightApplying)
+	}
+}
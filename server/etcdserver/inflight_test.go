@@ -0,0 +1,90 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+func TestRequestKeyRange(t *testing.T) {
+	put := func(k string) *pb.RequestOp {
+		return &pb.RequestOp{Request: &pb.RequestOp_RequestPut{RequestPut: &pb.PutRequest{Key: []byte(k)}}}
+	}
+	get := func(k, end string) *pb.RequestOp {
+		return &pb.RequestOp{Request: &pb.RequestOp_RequestRange{RequestRange: &pb.RangeRequest{Key: []byte(k), RangeEnd: []byte(end)}}}
+	}
+	tcs := []struct {
+		name         string
+		req          any
+		wantKey      string
+		wantRangeEnd string
+	}{
+		{
+			name:    "put",
+			req:     &pb.PutRequest{Key: []byte("a")},
+			wantKey: "a",
+		},
+		{
+			name:         "range",
+			req:          &pb.RangeRequest{Key: []byte("a"), RangeEnd: []byte("b")},
+			wantKey:      "a",
+			wantRangeEnd: "b",
+		},
+		{
+			name: "empty txn",
+			req:  &pb.TxnRequest{},
+		},
+		{
+			name: "txn on a single key",
+			req: &pb.TxnRequest{
+				Compare: []*pb.Compare{{Key: []byte("a")}},
+				Success: []*pb.RequestOp{put("a")},
+			},
+			wantKey: "a",
+		},
+		{
+			name: "txn on several keys",
+			req: &pb.TxnRequest{
+				Compare: []*pb.Compare{{Key: []byte("b")}},
+				Success: []*pb.RequestOp{put("c")},
+				Failure: []*pb.RequestOp{get("a", "b")},
+			},
+			wantKey:      "a",
+			wantRangeEnd: "c\x00",
+		},
+		{
+			name: "nested txn from key",
+			req: &pb.TxnRequest{
+				Success: []*pb.RequestOp{
+					put("c"),
+					{Request: &pb.RequestOp_RequestTxn{RequestTxn: &pb.TxnRequest{Success: []*pb.RequestOp{get("b", "\x00")}}}},
+				},
+			},
+			wantKey:      "b",
+			wantRangeEnd: "\x00",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			key, rangeEnd := requestKeyRange(tc.req)
+			assert.Equal(t, tc.wantKey, key)
+			assert.Equal(t, tc.wantRangeEnd, rangeEnd)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
+	"go.etcd.io/raft/v3"
+	"go.etcd.io/raft/v3/tracker"
+)
+
+// leaderPlacementCheckInterval is how often the leader checks whether a
+// member with a higher leader priority should take over the leadership.
+var leaderPlacementCheckInterval = 5 * time.Second
+
+// DefaultLeaderPriority is the leader priority of the members without a
+// configured one.
+const DefaultLeaderPriority = 1
+
+func leaderPriority(priorities map[string]int, m *membership.Member) int {
+	if p, ok := priorities[m.Name]; ok {
+		return p
+	}
+	return DefaultLeaderPriority
+}
+
+// preferredLeader returns the voting member the leader should transfer the
+// leadership to: the member with the highest leader priority, if higher than
+// the priority of the leader, among the members actively replicating from
+// the leader. Witnesses are never preferred. Ties are broken by the most
+// replicated member.
+func preferredLeader(priorities map[string]int, members []*membership.Member, rs raft.Status) (types.ID, bool) {
+	best := -1
+	for _, m := range members {
+		if uint64(m.ID) == rs.ID {
+			best = leaderPriority(priorities, m)
+		}
+	}
+	if best < 0 {
+		return 0, false
+	}
+
+	var (
+		id    types.ID
+		match uint64
+	)
+	for _, m := range members {
+		if uint64(m.ID) == rs.ID || m.IsLearner || m.Witness {
+			continue
+		}
+		pr, ok := rs.Progress[uint64(m.ID)]
+		if !ok || !pr.RecentActive || pr.State != tracker.StateReplicate {
+			continue
+		}
+		p := leaderPriority(priorities, m)
+		if p < best || p == best && (id == 0 || pr.Match < match || pr.Match == match && m.ID > id) {
+			continue
+		}
+		best, id, match = p, m.ID, pr.Match
+	}
+	return id, id != 0
+}
+
+// monitorLeaderPlacement every leaderPlacementCheckInterval checks if it's the
+// leader and transfers the leadership to the preferred leader, if any.
+func (s *EtcdServer) monitorLeaderPlacement() {
+	if len(s.Cfg.LeaderPriorities) == 0 {
+		return
+	}
+	for {
+		select {
+		case <-time.After(leaderPlacementCheckInterval):
+		case <-s.stopping:
+			return
+		}
+
+		if !s.isLeader() {
+			continue
+		}
+		rs := s.raftStatus()
+		if rs.Progress == nil {
+			continue
+		}
+		if id, ok := preferredLeader(s.Cfg.LeaderPriorities, s.cluster.Members(), rs); ok {
+			s.transferLeadershipToPreferred(id)
+		}
+	}
+}
+
+func (s *EtcdServer) transferLeadershipToPreferred(id types.ID) {
+	lg := s.Logger()
+	lg.Info(
+		"transferring leadership to the preferred leader",
+		zap.String("local-member-id", s.MemberID().String()),
+		zap.String("preferred-leader-member-id", id.String()),
+	)
+	ctx, cancel := context.WithTimeout(s.ctx, s.Cfg.ReqTimeout())
+	err := s.MoveLeader(ctx, uint64(s.MemberID()), uint64(id))
+	cancel()
+	if err != nil {
+		lg.Warn(
+			"failed to transfer leadership to the preferred leader",
+			zap.String("local-member-id", s.MemberID().String()),
+			zap.String("preferred-leader-member-id", id.String()),
+			zap.Error(err),
+		)
+		leaderPlacementTransfers.WithLabelValues("failure").Inc()
+		return
+	}
+	leaderPlacementTransfers.WithLabelValues("success").Inc()
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
+	"go.etcd.io/raft/v3"
+	"go.etcd.io/raft/v3/tracker"
+)
+
+func TestPreferredLeader(t *testing.T) {
+	member := func(id types.ID, name string, learner bool) *membership.Member {
+		return &membership.Member{ID: id, RaftAttributes: membership.RaftAttributes{IsLearner: learner}, Attributes: membership.Attributes{Name: name}}
+	}
+	members := []*membership.Member{
+		member(1, "far", false),
+		member(2, "a", false),
+		member(3, "b", false),
+		member(4, "learner", true),
+	}
+	status := func(lead uint64, matches map[uint64]uint64) raft.Status {
+		rs := raft.Status{Progress: make(map[uint64]tracker.Progress)}
+		rs.ID = lead
+		for id, match := range matches {
+			rs.Progress[id] = tracker.Progress{Match: match, State: tracker.StateReplicate, RecentActive: true}
+		}
+		return rs
+	}
+
+	tests := []struct {
+		name       string
+		priorities map[string]int
+		rs         raft.Status
+
+		want   types.ID
+		wantOK bool
+	}{
+		{
+			name:       "never lead moves to the most replicated member",
+			priorities: map[string]int{"far": 0},
+			rs:         status(1, map[uint64]uint64{1: 10, 2: 9, 3: 10, 4: 10}),
+			want:       3,
+			wantOK:     true,
+		},
+		{
+			name:       "highest priority wins",
+			priorities: map[string]int{"far": 0, "a": 3, "b": 2, "learner": 9},
+			rs:         status(3, map[uint64]uint64{1: 10, 2: 8, 3: 10, 4: 10}),
+			want:       2,
+			wantOK:     true,
+		},
+		{
+			name:       "leader with the highest priority stays",
+			priorities: map[string]int{"a": 2},
+			rs:         status(2, map[uint64]uint64{1: 10, 2: 10, 3: 10}),
+		},
+		{
+			name:       "leader with equal priority stays",
+			priorities: map[string]int{"far": 0},
+			rs:         status(2, map[uint64]uint64{1: 10, 2: 10, 3: 10}),
+		},
+		{
+			name:       "inactive members are skipped",
+			priorities: map[string]int{"far": 0, "a": 2},
+			rs: func() raft.Status {
+				rs := status(1, map[uint64]uint64{1: 10, 3: 10})
+				rs.Progress[2] = tracker.Progress{Match: 10, State: tracker.StateProbe}
+				return rs
+			}(),
+			want:   3,
+			wantOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok := preferredLeader(tt.priorities, members, tt.rs)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, id)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/client/pkg/v3/types"
+)
+
+// learnerPromotionCheckInterval is how often the leader checks whether
+// learners can be promoted automatically.
+var learnerPromotionCheckInterval = time.Second
+
+// learnerPromotionPolicy tracks for how long learners have stayed caught up
+// with the leader.
+type learnerPromotionPolicy struct {
+	maxLag uint64
+	window time.Duration
+
+	// caughtUpSince maps each learner to the time since which it has
+	// continuously been caught up.
+	caughtUpSince map[types.ID]time.Time
+}
+
+func newLearnerPromotionPolicy(maxLag uint64, window time.Duration) *learnerPromotionPolicy {
+	return &learnerPromotionPolicy{
+		maxLag:        maxLag,
+		window:        window,
+		caughtUpSince: make(map[types.ID]time.Time),
+	}
+}
+
+// ready records the replication progress of the given learners and returns
+// those that have stayed within maxLag entries of the leader for at least
+// the stabilization window.
+func (p *learnerPromotionPolicy) ready(now time.Time, leaderMatch uint64, learnerMatch map[types.ID]uint64) []types.ID {
+	for id := range p.caughtUpSince {
+		if _, ok := learnerMatch[id]; !ok {
+			delete(p.caughtUpSince, id)
+		}
+	}
+
+	var ids []types.ID
+	for id, match := range learnerMatch {
+		if match+p.maxLag < leaderMatch {
+			delete(p.caughtUpSince, id)
+			continue
+		}
+		since, ok := p.caughtUpSince[id]
+		if !ok {
+			since = now
+			p.caughtUpSince[id] = now
+		}
+		if now.Sub(since) >= p.window {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// reset forgets the progress of all learners, e.g. when leadership is lost.
+func (p *learnerPromotionPolicy) reset() {
+	clear(p.caughtUpSince)
+}
+
+// monitorLearnerPromotion every learnerPromotionCheckInterval checks if it's
+// the leader and promotes learners that have stayed caught up.
+func (s *EtcdServer) monitorLearnerPromotion() {
+	if !s.Cfg.ExperimentalAutoPromoteLearner {
+		return
+	}
+	lg := s.Logger()
+	policy := newLearnerPromotionPolicy(s.Cfg.ExperimentalAutoPromoteLearnerMaxLag, s.Cfg.ExperimentalAutoPromoteLearnerStabilizationWindow)
+	for {
+		select {
+		case <-time.After(learnerPromotionCheckInterval):
+		case <-s.stopping:
+			lg.Info("server has stopped; stopping learner promotion's monitor")
+			return
+		}
+
+		if !s.isLeader() {
+			policy.reset()
+			continue
+		}
+		rs := s.raftStatus()
+		if rs.Progress == nil {
+			policy.reset()
+			continue
+		}
+		learnerMatch := make(map[types.ID]uint64)
+		for _, m := range s.cluster.Members() {
+			if !m.IsLearner || m.ReadReplica {
+				continue
+			}
+			if pr, ok := rs.Progress[uint64(m.ID)]; ok {
+				learnerMatch[m.ID] = pr.Match
+			}
+		}
+		for _, id := range policy.ready(time.Now(), rs.Progress[rs.ID].Match, learnerMatch) {
+			s.autoPromoteLearner(id)
+		}
+	}
+}
+
+func (s *EtcdServer) autoPromoteLearner(id types.ID) {
+	lg := s.Logger()
+	ctx, cancel := context.WithTimeout(s.ctx, s.Cfg.ReqTimeout())
+	_, err := s.promoteLearner(ctx, uint64(id))
+	cancel()
+	if err != nil {
+		lg.Warn(
+			"failed to automatically promote learner",
+			zap.String("local-member-id", s.MemberID().String()),
+			zap.String("learner-member-id", id.String()),
+			zap.Error(err),
+		)
+		learnerPromoteFailed.WithLabelValues(err.Error()).Inc()
+		return
+	}
+
+	lg.Info(
+		"automatically promoted learner",
+		zap.String("local-member-id", s.MemberID().String()),
+		zap.String("learner-member-id", id.String()),
+		zap.Duration("stabilization-window", s.Cfg.ExperimentalAutoPromoteLearnerStabilizationWindow),
+	)
+	learnerPromoteSucceed.Inc()
+	learnerAutoPromotions.Inc()
+	if hook := s.Cfg.LifecycleHooks.OnLearnerPromoted; hook != nil {
+		s.RunLifecycleHook(func() { hook(uint64(id)) })
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.etcd.io/etcd/client/pkg/v3/types"
+)
+
+func TestLearnerPromotionPolicy(t *testing.T) {
+	p := newLearnerPromotionPolicy(10, time.Minute)
+	start := time.Unix(0, 0)
+
+	// learner 1 is caught up, learner 2 lags too far behind
+	assert.Empty(t, p.ready(start, 100, map[types.ID]uint64{1: 95, 2: 50}))
+	assert.Empty(t, p.ready(start.Add(30*time.Second), 100, map[types.ID]uint64{1: 100, 2: 95}))
+	assert.Equal(t, []types.ID{1}, p.ready(start.Add(time.Minute), 100, map[types.ID]uint64{1: 100, 2: 95}))
+
+	// falling behind restarts the stabilization window
+	assert.Equal(t, []types.ID{2}, p.ready(start.Add(2*time.Minute), 200, map[types.ID]uint64{1: 150, 2: 195}))
+	assert.Equal(t, []types.ID{2}, p.ready(start.Add(150*time.Second), 200, map[types.ID]uint64{1: 200, 2: 200}))
+	assert.Equal(t, []types.ID{1, 2}, p.ready(start.Add(210*time.Second), 200, map[types.ID]uint64{1: 200, 2: 200}))
+
+	// removed learners and lost leadership are forgotten
+	assert.Equal(t, []types.ID{1}, p.ready(start.Add(4*time.Minute), 200, map[types.ID]uint64{1: 200}))
+	assert.NotContains(t, p.caughtUpSince, types.ID(2))
+	p.reset()
+	assert.Empty(t, p.ready(start.Add(5*time.Minute), 200, map[types.ID]uint64{1: 200}))
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import "sync"
+
+// lifecycleHookQueue runs the lifecycle hooks one at a time, in the order
+// they were queued, so that the embedder sees the events in the order they
+// happened. Queueing never blocks.
+type lifecycleHookQueue struct {
+	mu    sync.Mutex
+	hooks []func()
+	// readyc is signaled when hooks are queued.
+	readyc chan struct{}
+}
+
+func newLifecycleHookQueue() *lifecycleHookQueue {
+	return &lifecycleHookQueue{readyc: make(chan struct{}, 1)}
+}
+
+func (q *lifecycleHookQueue) push(hook func()) {
+	q.mu.Lock()
+	q.hooks = append(q.hooks, hook)
+	q.mu.Unlock()
+	select {
+	case q.readyc <- struct{}{}:
+	default:
+	}
+}
+
+// run calls the queued hooks until stopc is closed. The hooks still queued
+// then are dropped.
+func (q *lifecycleHookQueue) run(stopc <-chan struct{}) {
+	for {
+		select {
+		case <-q.readyc:
+		case <-stopc:
+			return
+		}
+		q.mu.Lock()
+		hooks := q.hooks
+		q.hooks = nil
+		q.mu.Unlock()
+		for _, hook := range hooks {
+			select {
+			case <-stopc:
+				return
+			default:
+			}
+			hook()
+		}
+	}
+}
+
+// RunLifecycleHook queues hook, if set, to be called after the hooks of the
+// previous events. The hooks are called from a single goroutine so that they
+// cannot block the server, but a slow hook delays the following ones and the
+// shutdown of the server.
+func (s *EtcdServer) RunLifecycleHook(hook func()) {
+	if hook != nil {
+		s.lifecycleHooks.push(hook)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifecycleHookQueueOrder(t *testing.T) {
+	q := newLifecycleHookQueue()
+	stopc, donec := make(chan struct{}), make(chan struct{})
+	go func() {
+		q.run(stopc)
+		close(donec)
+	}()
+
+	eventc := make(chan string, 10)
+	blockc := make(chan struct{})
+	// the first hook blocks, so that the following events queue up behind it
+	q.push(func() {
+		<-blockc
+		eventc <- "elected"
+	})
+	q.push(func() { eventc <- "lost" })
+	q.push(func() { eventc <- "elected" })
+	close(blockc)
+
+	var events []string
+	for len(events) < 3 {
+		select {
+		case ev := <-eventc:
+			events = append(events, ev)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for the hooks, got %v", events)
+		}
+	}
+	assert.Equal(t, []string{"elected", "lost", "elected"}, events)
+
+	close(stopc)
+	<-donec
+}
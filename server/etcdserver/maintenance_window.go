@@ -0,0 +1,338 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3compactor"
+)
+
+const (
+	// maxMaintenanceWindowDuration bounds the duration of a maintenance
+	// window.
+	maxMaintenanceWindowDuration = 7 * 24 * time.Hour
+	// maintenanceSnapshotCountFactor is how many times SnapshotCount entries
+	// are applied outside of the maintenance windows before a snapshot is
+	// taken anyway, bounding the raft log kept in memory.
+	maintenanceSnapshotCountFactor = 4
+	// maintenanceEmergencyQuotaRatio is the ratio of the backend quota from
+	// which the auto-compaction runs outside of the maintenance windows.
+	maintenanceEmergencyQuotaRatio = 0.9
+)
+
+// maintenanceWindowCheckInterval is how often the maintenance windows are
+// checked.
+var maintenanceWindowCheckInterval = 10 * time.Second
+
+// cronField is the set of the values matching a field of a cron schedule.
+type cronField uint64
+
+func (f cronField) has(v int) bool {
+	return f&(1<<uint(v)) != 0
+}
+
+// parseCronField parses a comma-separated list of '*', values or ranges of
+// values between lowest and highest, each optionally followed by '/step'. It
+// returns whether the field is '*'.
+func parseCronField(s string, lowest, highest int) (cronField, bool, error) {
+	var f cronField
+	for _, part := range strings.Split(s, ",") {
+		rng, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, false, fmt.Errorf("invalid step in %q", part)
+			}
+			rng = part[:i]
+		}
+		lo, hi := lowest, highest
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, false, fmt.Errorf("invalid value in %q", part)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, false, fmt.Errorf("invalid value in %q", part)
+				}
+			} else if step > 1 {
+				hi = highest
+			}
+			if lo < lowest || hi > highest || lo > hi {
+				return 0, false, fmt.Errorf("%q out of range [%d, %d]", part, lowest, highest)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			f |= 1 << uint(v)
+		}
+	}
+	return f, s == "*", nil
+}
+
+// MaintenanceWindow is a recurring window starting at the times matching a
+// cron schedule in UTC.
+type MaintenanceWindow struct {
+	minute, hour, dom, month, dow cronField
+	// domAny and dowAny are set if the day of the month, or of the week,
+	// is '*'. If neither is, a day matches if either matches, as in cron.
+	domAny, dowAny bool
+
+	Duration time.Duration
+}
+
+// ParseMaintenanceWindows parses the semicolon-separated list of maintenance
+// windows, each the five fields of a cron schedule of its start (minute,
+// hour, day of the month, month and day of the week, Sunday being 0 or 7)
+// followed by its duration, e.g. "0 2 * * 6 4h; 30 1 * * 1-5 30m".
+func ParseMaintenanceWindows(spec string) ([]MaintenanceWindow, error) {
+	var ws []MaintenanceWindow
+	for _, s := range strings.Split(spec, ";") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		w, err := parseMaintenanceWindow(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maintenance window %q: %w", strings.TrimSpace(s), err)
+		}
+		ws = append(ws, w)
+	}
+	return ws, nil
+}
+
+func parseMaintenanceWindow(s string) (w MaintenanceWindow, err error) {
+	fields := strings.Fields(s)
+	if len(fields) != 6 {
+		return w, fmt.Errorf("expected 5 cron fields and a duration, got %d fields", len(fields))
+	}
+	if w.minute, _, err = parseCronField(fields[0], 0, 59); err != nil {
+		return w, err
+	}
+	if w.hour, _, err = parseCronField(fields[1], 0, 23); err != nil {
+		return w, err
+	}
+	if w.dom, w.domAny, err = parseCronField(fields[2], 1, 31); err != nil {
+		return w, err
+	}
+	if w.month, _, err = parseCronField(fields[3], 1, 12); err != nil {
+		return w, err
+	}
+	if w.dow, w.dowAny, err = parseCronField(fields[4], 0, 7); err != nil {
+		return w, err
+	}
+	if w.dow.has(7) {
+		w.dow |= 1
+	}
+	if w.Duration, err = time.ParseDuration(fields[5]); err != nil {
+		return w, err
+	}
+	if w.Duration < time.Minute || w.Duration > maxMaintenanceWindowDuration {
+		return w, fmt.Errorf("duration %v out of range [%v, %v]", w.Duration, time.Minute, maxMaintenanceWindowDuration)
+	}
+	return w, nil
+}
+
+// starts returns whether the window starts at the minute of t.
+func (w MaintenanceWindow) starts(t time.Time) bool {
+	if !w.minute.has(t.Minute()) || !w.hour.has(t.Hour()) || !w.month.has(int(t.Month())) {
+		return false
+	}
+	dom, dow := w.dom.has(t.Day()), w.dow.has(int(t.Weekday()))
+	switch {
+	case w.domAny:
+		return dow
+	case w.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+// Contains returns whether t is in the window.
+func (w MaintenanceWindow) Contains(t time.Time) bool {
+	t = t.UTC()
+	for start := t.Truncate(time.Minute); t.Sub(start) < w.Duration; start = start.Add(-time.Minute) {
+		if w.starts(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// inMaintenanceWindow returns whether t is in any of the windows.
+func inMaintenanceWindow(ws []MaintenanceWindow, t time.Time) bool {
+	for _, w := range ws {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// maintenanceWindowOpen returns whether background maintenance may run, that
+// is if no maintenance windows are configured or one of them is open.
+func (s *EtcdServer) maintenanceWindowOpen() bool {
+	return !s.outsideMaintenanceWindow.Load()
+}
+
+// monitorMaintenanceWindows every maintenanceWindowCheckInterval opens and
+// closes the maintenance windows: the auto-compaction only runs in the
+// windows, unless the backend approaches its quota, and the leader
+// defragments the members once per window if that frees enough space.
+func (s *EtcdServer) monitorMaintenanceWindows() {
+	ws, err := ParseMaintenanceWindows(s.Cfg.ExperimentalMaintenanceWindows)
+	if err != nil || len(ws) == 0 {
+		return
+	}
+	lg := s.Logger()
+	open, defragged := true, false
+	for {
+		inWindow := inMaintenanceWindow(ws, time.Now())
+		if inWindow != open {
+			open = inWindow
+			if open {
+				lg.Info("maintenance window opened")
+			} else {
+				lg.Info("maintenance window closed")
+				defragged = false
+			}
+			s.outsideMaintenanceWindow.Store(!open)
+		}
+		if open {
+			maintenanceWindowOpen.Set(1)
+		} else {
+			maintenanceWindowOpen.Set(0)
+		}
+		if c, ok := s.compactor.(*windowedCompactor); ok {
+			c.setWindowOpen(open || s.backendNearQuota())
+		}
+		if open && !defragged && s.Cfg.ExperimentalMaintenanceDefragThresholdMegabytes > 0 {
+			defragged = s.maintenanceDefrag()
+		}
+
+		select {
+		case <-time.After(maintenanceWindowCheckInterval):
+		case <-s.stopping:
+			lg.Info("server has stopped; stopping maintenance windows' monitor")
+			return
+		}
+	}
+}
+
+// backendNearQuota returns whether the backend size has reached
+// maintenanceEmergencyQuotaRatio of the quota.
+func (s *EtcdServer) backendNearQuota() bool {
+	quota := quotaBytes(s.Cfg.QuotaBackendBytes)
+	return quota > 0 && float64(s.Backend().Size()) >= maintenanceEmergencyQuotaRatio*float64(quota)
+}
+
+// maintenanceDefrag, if the member is the leader, defragments the backend of
+// the members that would free at least
+// ExperimentalMaintenanceDefragThresholdMegabytes, one at a time and the
+// leader last, so that a quorum keeps serving the requests. It returns
+// whether it is done for the window; the members that failed are retried
+// until it is.
+func (s *EtcdServer) maintenanceDefrag() bool {
+	if !s.isLeader() {
+		return false
+	}
+	lg := s.Logger()
+	thresholdBytes := int64(s.Cfg.ExperimentalMaintenanceDefragThresholdMegabytes) * 1024 * 1024
+	members := s.cluster.Members()
+	sortMembersLocalLast(members, s.MemberID())
+	done := true
+	for _, m := range members {
+		var resp *peerDefragResponse
+		var err error
+		if m.ID == s.MemberID() {
+			resp, err = s.defragLocal(thresholdBytes)
+		} else {
+			ctx, cancel := context.WithTimeout(s.ctx, noSpaceDefragTimeout)
+			resp, err = defragMemberHTTP(ctx, s.cluster.ID(), m, s.peerRt, thresholdBytes)
+			cancel()
+		}
+		if err != nil {
+			lg.Warn("failed to defragment member in maintenance window", zap.String("member-id", m.ID.String()), zap.Error(err))
+			maintenanceDefrags.WithLabelValues("failure").Inc()
+			done = false
+			continue
+		}
+		if resp.Skipped {
+			continue
+		}
+		lg.Info(
+			"defragmented member in maintenance window",
+			zap.String("member-id", m.ID.String()),
+			zap.Int64("current-db-size-bytes", resp.DbSize),
+			zap.String("current-db-size", humanize.Bytes(uint64(resp.DbSize))),
+		)
+		maintenanceDefrags.WithLabelValues("success").Inc()
+	}
+	return done
+}
+
+// windowedCompactor runs the compactor only while it's both resumed, by the
+// leadership, and allowed by the maintenance windows.
+type windowedCompactor struct {
+	v3compactor.Compactor
+
+	mu         sync.Mutex
+	paused     bool
+	windowOpen bool
+}
+
+func newWindowedCompactor(c v3compactor.Compactor) *windowedCompactor {
+	return &windowedCompactor{Compactor: c, windowOpen: true}
+}
+
+func (c *windowedCompactor) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paused = true
+	c.update()
+}
+
+func (c *windowedCompactor) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paused = false
+	c.update()
+}
+
+func (c *windowedCompactor) setWindowOpen(open bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.windowOpen != open {
+		c.windowOpen = open
+		c.update()
+	}
+}
+
+func (c *windowedCompactor) update() {
+	if c.paused || !c.windowOpen {
+		c.Compactor.Pause()
+	} else {
+		c.Compactor.Resume()
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.etcd.io/etcd/server/v3/config"
+)
+
+func TestParseMaintenanceWindows(t *testing.T) {
+	ws, err := ParseMaintenanceWindows("")
+	require.NoError(t, err)
+	assert.Empty(t, ws)
+
+	ws, err = ParseMaintenanceWindows("0 2 * * 6 4h; 30 1 1,15 * 1-5 30m;")
+	require.NoError(t, err)
+	require.Len(t, ws, 2)
+	assert.Equal(t, 4*time.Hour, ws[0].Duration)
+	assert.Equal(t, 30*time.Minute, ws[1].Duration)
+
+	for _, spec := range []string{
+		"0 2 * * 6",
+		"0 2 * * 6 4h 1",
+		"60 2 * * 6 4h",
+		"0 2 0 * * 4h",
+		"0 2 * * 8 4h",
+		"0 5-2 * * * 4h",
+		"*/0 2 * * * 4h",
+		"0 2 * * * 30s",
+		"0 2 * * * 8d",
+		"0 2 * * * 200h",
+		"a 2 * * * 4h",
+	} {
+		_, err = ParseMaintenanceWindows(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestMaintenanceWindowContains(t *testing.T) {
+	date := func(day, hour, minute int) time.Time {
+		// 2024-06-01 is a Saturday
+		return time.Date(2024, time.June, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		spec string
+		t    time.Time
+		want bool
+	}{
+		{spec: "0 2 * * 6 4h", t: date(1, 1, 59), want: false},
+		{spec: "0 2 * * 6 4h", t: date(1, 2, 0), want: true},
+		{spec: "0 2 * * 6 4h", t: date(1, 5, 59), want: true},
+		{spec: "0 2 * * 6 4h", t: date(1, 6, 0), want: false},
+		{spec: "0 2 * * 6 4h", t: date(2, 3, 0), want: false},
+		// windows starting on Saturday spill over Sunday
+		{spec: "0 22 * * 6 4h", t: date(2, 1, 0), want: true},
+		{spec: "0 22 * * 6 4h", t: date(2, 2, 0), want: false},
+		// Sunday is 0 or 7
+		{spec: "0 2 * * 7 1h", t: date(2, 2, 30), want: true},
+		{spec: "0 2 * * 0 1h", t: date(2, 2, 30), want: true},
+		// steps and lists
+		{spec: "*/15 * * * * 5m", t: date(3, 10, 34), want: true},
+		{spec: "*/15 * * * * 5m", t: date(3, 10, 35), want: false},
+		{spec: "0 1,13 * * * 1h", t: date(3, 13, 30), want: true},
+		// a day matches if either the day of the month or of the week does
+		{spec: "0 2 3 * 6 1h", t: date(3, 2, 0), want: true},
+		{spec: "0 2 3 * 6 1h", t: date(8, 2, 0), want: true},
+		{spec: "0 2 3 * 6 1h", t: date(4, 2, 0), want: false},
+		{spec: "0 2 3 * * 1h", t: date(8, 2, 0), want: false},
+		// the windows are in UTC
+		{spec: "0 2 * * 6 1h", t: date(1, 2, 30).In(time.FixedZone("", 3600)), want: true},
+	}
+	for _, tt := range tests {
+		ws, err := ParseMaintenanceWindows(tt.spec)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, ws[0].Contains(tt.t), "%q at %v", tt.spec, tt.t)
+	}
+}
+
+type recordingCompactor struct {
+	paused bool
+}
+
+func (c *recordingCompactor) Run()    {}
+func (c *recordingCompactor) Stop()   {}
+func (c *recordingCompactor) Pause()  { c.paused = true }
+func (c *recordingCompactor) Resume() { c.paused = false }
+
+func TestWindowedCompactor(t *testing.T) {
+	rc := &recordingCompactor{}
+	c := newWindowedCompactor(rc)
+
+	c.Pause()
+	assert.True(t, rc.paused)
+	c.Resume()
+	assert.False(t, rc.paused)
+
+	c.setWindowOpen(false)
+	assert.True(t, rc.paused)
+	// the leader does not resume the compactor outside of the windows
+	c.Resume()
+	assert.True(t, rc.paused)
+	c.setWindowOpen(true)
+	assert.False(t, rc.paused)
+
+	// nor do the windows resume the compactor of a follower
+	c.Pause()
+	c.setWindowOpen(false)
+	c.setWindowOpen(true)
+	assert.True(t, rc.paused)
+}
+
+func TestShouldSnapshotOutsideMaintenanceWindow(t *testing.T) {
+	s := &EtcdServer{Cfg: config.ServerConfig{SnapshotCount: 100}}
+	ep := &etcdProgress{appliedi: 101}
+	assert.True(t, s.shouldSnapshot(ep))
+
+	s.outsideMaintenanceWindow.Store(true)
+	assert.False(t, s.shouldSnapshot(ep))
+	ep.appliedi = 100*maintenanceSnapshotCountFactor + 1
+	assert.True(t, s.shouldSnapshot(ep))
+}
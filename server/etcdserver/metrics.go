@@ -44,6 +44,42 @@ var (
 		Name:      "leader_changes_seen_total",
 		Help:      "The number of leader changes seen.",
 	})
+	learnerAutoPromotions = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "learner_auto_promotions_total",
+		Help:      "The total number of learners automatically promoted while this member is leader.",
+	})
+	leaderPlacementTransfers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "leader_placement_transfers_total",
+		Help:      "The total number of leadership transfers to a member with a higher leader priority attempted while this member is leader.",
+	},
+		[]string{"result"},
+	)
+	quotaBackendWarningRatio = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "quota_backend_warning_ratio",
+		Help:      "The highest of the configured warning ratios of the backend quota that the backend size has reached, or 0 if none.",
+	})
+	noSpaceRecoveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "nospace_auto_recoveries_total",
+		Help:      "The total number of automatic recoveries from NOSPACE alarms attempted while this member is leader.",
+	},
+		[]string{"result"},
+	)
+	snapshotSends = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "snapshot_sends_total",
+		Help:      "The total number of snapshots sent to followers while differential snapshots are enabled, by type: differential or full.",
+	},
+		[]string{"type"},
+	)
 	learnerPromoteFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "etcd",
 		Subsystem: "server",
@@ -106,12 +142,91 @@ var (
 		Name:      "read_indexes_failed_total",
 		Help:      "The total number of failed read indexes seen.",
 	})
+	boundedStalenessReads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "bounded_staleness_reads_total",
+		Help:      "The total number of range requests with a staleness bound, by whether they were served from local state or confirmed with the leader.",
+	},
+		[]string{"served_by"},
+	)
+	corruptRangeChecks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "corrupt_range_checks_total",
+		Help:      "The total number of ranged hash comparisons between members, by result.",
+	},
+		[]string{"result"},
+	)
+	corruptRangeCheckDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "corrupt_range_check_duration_seconds",
+		Help:      "The latency distribution of ranged hash comparisons between members.",
+
+		// lowest bucket start of upper bound 0.01 sec (10 ms) with factor 2
+		// highest bucket start of 0.01 sec * 2^12 == 40.96 sec
+		Buckets: prometheus.ExponentialBuckets(.01, 2, 13),
+	})
+	quarantined = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "quarantined",
+		Help:      "Whether or not this member is quarantined for corruption. 1 is quarantined, 0 is not.",
+	})
+	admissionRejections = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "admission_rejections_total",
+		Help:      "The total number of write requests rejected by admission control.",
+	})
 	leaseExpired = prometheus.NewCounter(prometheus.CounterOpts{
 		Namespace: "etcd_debugging",
 		Subsystem: "server",
 		Name:      "lease_expired_total",
 		Help:      "The total number of expired leases.",
 	})
+	leaseRevokeWaitSec = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "etcd_debugging",
+		Subsystem: "server",
+		Name:      "lease_revoke_wait_duration_seconds",
+		Help:      "The time expired lease revocations waited to be proposed under the lease revoke rate.",
+
+		// lowest bucket start of upper bound 0.001 sec (1 ms) with factor 2
+		// highest bucket start of 0.001 sec * 2^13 == 8.192 sec
+		Buckets: prometheus.ExponentialBuckets(0.001, 2, 14),
+	})
+
+	diskProbeDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "disk_probe_duration_seconds",
+		Help:      "The latency of the probes writing and fsyncing a small file on the WAL device.",
+
+		// lowest bucket start of upper bound 0.001 sec (1 ms) with factor 2
+		// highest bucket start of 0.001 sec * 2^13 == 8.192 sec
+		Buckets: prometheus.ExponentialBuckets(0.001, 2, 14),
+	})
+	diskSlow = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "disk_slow",
+		Help:      "Whether or not the disk probes found the WAL device slow. 1 is slow, 0 is not.",
+	})
+
+	maintenanceWindowOpen = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "maintenance_window_open",
+		Help:      "Whether or not a maintenance window is open. 1 is open, 0 is not.",
+	})
+	maintenanceDefrags = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "maintenance_defrags_total",
+		Help:      "The total number of defragmentations started in maintenance windows.",
+	},
+		[]string{"result"})
 
 	currentVersion = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: "etcd",
@@ -161,12 +276,27 @@ func init() {
 	prometheus.MustRegister(proposalsFailed)
 	prometheus.MustRegister(slowReadIndex)
 	prometheus.MustRegister(readIndexFailed)
+	prometheus.MustRegister(boundedStalenessReads)
+	prometheus.MustRegister(corruptRangeChecks)
+	prometheus.MustRegister(corruptRangeCheckDuration)
+	prometheus.MustRegister(quarantined)
+	prometheus.MustRegister(admissionRejections)
 	prometheus.MustRegister(leaseExpired)
+	prometheus.MustRegister(leaseRevokeWaitSec)
+	prometheus.MustRegister(diskProbeDuration)
+	prometheus.MustRegister(diskSlow)
+	prometheus.MustRegister(maintenanceWindowOpen)
+	prometheus.MustRegister(maintenanceDefrags)
 	prometheus.MustRegister(currentVersion)
 	prometheus.MustRegister(currentGoVersion)
 	prometheus.MustRegister(serverID)
 	prometheus.MustRegister(learnerPromoteSucceed)
 	prometheus.MustRegister(learnerPromoteFailed)
+	prometheus.MustRegister(learnerAutoPromotions)
+	prometheus.MustRegister(leaderPlacementTransfers)
+	prometheus.MustRegister(quotaBackendWarningRatio)
+	prometheus.MustRegister(noSpaceRecoveries)
+	prometheus.MustRegister(snapshotSends)
 	prometheus.MustRegister(fdUsed)
 	prometheus.MustRegister(fdLimit)
 
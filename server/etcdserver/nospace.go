@@ -0,0 +1,360 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"go.uber.org/zap"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/rafthttp"
+	"go.etcd.io/etcd/server/v3/storage"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+)
+
+const (
+	PeerDefragPath = "/members/defrag"
+	// peerDefragMinFreedBytesParam is the parameter of the defragmentation
+	// requests skipping it unless it frees at least the given bytes.
+	peerDefragMinFreedBytesParam = "min-freed-bytes"
+)
+
+var (
+	// quotaCheckInterval is how often the backend size is checked against
+	// the quota warning ratios, and the leader checks for NOSPACE alarms.
+	quotaCheckInterval = 5 * time.Second
+	// noSpaceRecoveryRetryInterval is the minimum time between two attempts
+	// to recover from NOSPACE alarms.
+	noSpaceRecoveryRetryInterval = time.Minute
+	// noSpaceDefragTimeout bounds the defragmentation of one member.
+	noSpaceDefragTimeout = 10 * time.Minute
+)
+
+// quotaBytes returns the effective backend quota, or a negative number if
+// the quota is disabled.
+func quotaBytes(cfg int64) int64 {
+	if cfg == 0 {
+		return storage.DefaultQuotaBytes
+	}
+	return cfg
+}
+
+// quotaWarningRatio returns the highest of the ratios of the quota that the
+// backend size has reached, or 0 if it reached none.
+func quotaWarningRatio(size, quota int64, ratios []float64) float64 {
+	if quota <= 0 {
+		return 0
+	}
+	var reached float64
+	for _, r := range ratios {
+		if float64(size) >= r*float64(quota) && r > reached {
+			reached = r
+		}
+	}
+	return reached
+}
+
+// monitorBackendQuota every quotaCheckInterval warns when the backend size
+// reaches any of the configured ratios of the quota and, if it's the leader
+// and automatic recovery is enabled, recovers from NOSPACE alarms.
+func (s *EtcdServer) monitorBackendQuota() {
+	if len(s.Cfg.ExperimentalQuotaBackendWarningRatios) == 0 && !s.Cfg.ExperimentalAutoRecoverNoSpace {
+		return
+	}
+	lg := s.Logger()
+	quota := quotaBytes(s.Cfg.QuotaBackendBytes)
+	var warned float64
+	var lastRecovery time.Time
+	for {
+		select {
+		case <-time.After(quotaCheckInterval):
+		case <-s.stopping:
+			lg.Info("server has stopped; stopping backend quota's monitor")
+			return
+		}
+
+		size := s.Backend().Size()
+		ratio := quotaWarningRatio(size, quota, s.Cfg.ExperimentalQuotaBackendWarningRatios)
+		if ratio > warned {
+			lg.Warn(
+				"backend size is approaching the quota",
+				zap.Int64("current-db-size-bytes", size),
+				zap.String("current-db-size", humanize.Bytes(uint64(size))),
+				zap.Int64("quota-size-bytes", quota),
+				zap.String("quota-size", humanize.Bytes(uint64(quota))),
+				zap.Float64("warning-ratio", ratio),
+			)
+		}
+		warned = ratio
+		quotaBackendWarningRatio.Set(ratio)
+
+		if !s.Cfg.ExperimentalAutoRecoverNoSpace || !s.isLeader() ||
+			len(s.alarmStore.Get(pb.AlarmType_NOSPACE)) == 0 ||
+			time.Since(lastRecovery) < noSpaceRecoveryRetryInterval {
+			continue
+		}
+		lastRecovery = time.Now()
+		if err := s.recoverNoSpace(); err != nil {
+			lg.Warn("failed to automatically recover from NOSPACE alarm", zap.Error(err))
+			noSpaceRecoveries.WithLabelValues("failure").Inc()
+			continue
+		}
+		noSpaceRecoveries.WithLabelValues("success").Inc()
+	}
+}
+
+// recoverNoSpace compacts the keyspace to the current revision, defragments
+// the members one at a time, followers first, and disarms the NOSPACE alarms
+// if all members are then below their quota. It is only attempted if there
+// is no CORRUPT alarm, the cluster is not read-only and all members are
+// connected.
+func (s *EtcdServer) recoverNoSpace() error {
+	lg := s.Logger()
+	if len(s.alarmStore.Get(pb.AlarmType_CORRUPT)) > 0 {
+		return fmt.Errorf("cluster has a CORRUPT alarm")
+	}
+	if s.IsReadOnly() {
+		return fmt.Errorf("cluster is read-only")
+	}
+	members := s.cluster.Members()
+	if !isConnectedFullySince(s.r.transport, time.Now(), s.MemberID(), members) {
+		return fmt.Errorf("not all members are connected")
+	}
+	lg.Info("automatically recovering from NOSPACE alarm", zap.String("local-member-id", s.MemberID().String()))
+
+	rev := s.KV().Rev()
+	ctx, cancel := context.WithTimeout(s.ctx, s.Cfg.ReqTimeout())
+	_, err := s.Compact(ctx, &pb.CompactionRequest{Revision: rev, Physical: true})
+	cancel()
+	if err != nil && err != mvcc.ErrCompacted {
+		return fmt.Errorf("failed to compact to revision %d: %w", rev, err)
+	}
+
+	sortMembersLocalLast(members, s.MemberID())
+	fits := true
+	for _, m := range members {
+		var resp *peerDefragResponse
+		if m.ID == s.MemberID() {
+			resp, err = s.defragLocal(0)
+		} else {
+			ctx, cancel = context.WithTimeout(s.ctx, noSpaceDefragTimeout)
+			resp, err = defragMemberHTTP(ctx, s.cluster.ID(), m, s.peerRt, 0)
+			cancel()
+		}
+		if err != nil {
+			return fmt.Errorf("failed to defragment member %s: %w", m.ID, err)
+		}
+		lg.Info(
+			"defragmented member to recover from NOSPACE alarm",
+			zap.String("member-id", m.ID.String()),
+			zap.Int64("current-db-size-bytes", resp.DbSize),
+			zap.Int64("quota-size-bytes", resp.QuotaBytes),
+		)
+		if resp.QuotaBytes > 0 && resp.DbSize >= resp.QuotaBytes {
+			fits = false
+		}
+	}
+	if !fits {
+		return fmt.Errorf("backend size still exceeds the quota after compaction and defragmentation")
+	}
+
+	for _, a := range s.alarmStore.Get(pb.AlarmType_NOSPACE) {
+		ctx, cancel = context.WithTimeout(s.ctx, s.Cfg.ReqTimeout())
+		_, err = s.Alarm(ctx, &pb.AlarmRequest{
+			Action:   pb.AlarmRequest_DEACTIVATE,
+			MemberID: a.MemberID,
+			Alarm:    pb.AlarmType_NOSPACE,
+		})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to disarm NOSPACE alarm of member %s: %w", types.ID(a.MemberID), err)
+		}
+	}
+	lg.Info("automatically recovered from NOSPACE alarm", zap.String("local-member-id", s.MemberID().String()))
+	return nil
+}
+
+// sortMembersLocalLast sorts the members by ID, the local member last.
+func sortMembersLocalLast(members []*membership.Member, local types.ID) {
+	slices.SortFunc(members, func(a, b *membership.Member) int {
+		if a.ID == local {
+			return 1
+		}
+		if b.ID == local {
+			return -1
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+}
+
+type peerDefragResponse struct {
+	DbSize     int64 `json:"db-size"`
+	QuotaBytes int64 `json:"quota-bytes"`
+	// Skipped is set if the member was not defragmented because that would
+	// not have freed the requested space.
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// defragLocal defragments the backend, unless that would free less than
+// minFreedBytes.
+func (s *EtcdServer) defragLocal(minFreedBytes int64) (*peerDefragResponse, error) {
+	be := s.Backend()
+	if minFreedBytes > 0 && be.Size()-be.SizeInUse() < minFreedBytes {
+		return &peerDefragResponse{DbSize: be.Size(), QuotaBytes: quotaBytes(s.Cfg.QuotaBackendBytes), Skipped: true}, nil
+	}
+	hooks := s.Cfg.LifecycleHooks
+	s.RunLifecycleHook(hooks.OnDefragStarted)
+	err := be.Defrag()
+	if hook := hooks.OnDefragFinished; hook != nil {
+		s.RunLifecycleHook(func() { hook(err) })
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.PublishClusterEvent(&pb.ClusterEvent{Type: pb.ClusterEvent_DEFRAGMENTED, MemberId: uint64(s.MemberID())})
+	return &peerDefragResponse{DbSize: be.Size(), QuotaBytes: quotaBytes(s.Cfg.QuotaBackendBytes)}, nil
+}
+
+type defragHandler struct {
+	lg     *zap.Logger
+	server *EtcdServer
+}
+
+// DefragHandler returns the handler through which the leader defragments
+// the member to recover from NOSPACE alarms or in the maintenance windows, or
+// nil if neither automatic recovery nor maintenance defragmentation is
+// enabled.
+func (s *EtcdServer) DefragHandler() http.Handler {
+	if !s.Cfg.ExperimentalAutoRecoverNoSpace && s.Cfg.ExperimentalMaintenanceDefragThresholdMegabytes == 0 {
+		return nil
+	}
+	return &defragHandler{lg: s.Logger(), server: s}
+}
+
+func (h *defragHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.URL.Path != PeerDefragPath {
+		http.Error(w, "bad path", http.StatusBadRequest)
+		return
+	}
+	// only a peer presenting a certificate verified by the peer listener may
+	// defragment the member, which --peer-client-cert-auth requires
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		h.lg.Warn("rejected defragmentation request without a verified peer certificate", zap.String("remote-addr", r.RemoteAddr))
+		http.Error(w, "peer client certificate required", http.StatusForbidden)
+		return
+	}
+	if gcid := r.Header.Get("X-Etcd-Cluster-ID"); gcid != "" && gcid != h.server.cluster.ID().String() {
+		http.Error(w, rafthttp.ErrClusterIDMismatch.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	var minFreedBytes int64
+	if v := r.URL.Query().Get(peerDefragMinFreedBytesParam); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid %s: %v", peerDefragMinFreedBytesParam, err), http.StatusBadRequest)
+			return
+		}
+		minFreedBytes = n
+	}
+
+	h.lg.Info("defragmenting on leader's request", zap.Int64("min-freed-bytes", minFreedBytes))
+	resp, err := h.server.defragLocal(minFreedBytes)
+	if err != nil {
+		h.lg.Warn("failed to defragment", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respBytes, err := json.Marshal(resp)
+	if err != nil {
+		h.lg.Warn("failed to marshal defrag response", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("X-Etcd-Cluster-ID", h.server.Cluster().ID().String())
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(respBytes)
+}
+
+// defragMemberHTTP defragments the given member through the first of its
+// peer URLs that succeeds, unless that would free less than minFreedBytes.
+func defragMemberHTTP(ctx context.Context, cid types.ID, m *membership.Member, peerRt http.RoundTripper, minFreedBytes int64) (*peerDefragResponse, error) {
+	cc := &http.Client{Transport: peerRt}
+	var lastErr error
+	for _, u := range m.PeerURLs {
+		resp, err := defragURL(ctx, cc, cid, u, minFreedBytes)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func defragURL(ctx context.Context, cc *http.Client, cid types.ID, url string, minFreedBytes int64) (*peerDefragResponse, error) {
+	if minFreedBytes > 0 {
+		url = fmt.Sprintf("%s%s?%s=%d", url, PeerDefragPath, peerDefragMinFreedBytesParam, minFreedBytes)
+	} else {
+		url += PeerDefragPath
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Etcd-Cluster-ID", cid.String())
+
+	resp, err := cc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("neither automatic NOSPACE recovery nor maintenance defragmentation is enabled on the member")
+	}
+	if resp.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("the member requires a verified peer client certificate: %s", b)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unknown error: %s", b)
+	}
+
+	defragResp := &peerDefragResponse{}
+	if err := json.Unmarshal(b, defragResp); err != nil {
+		return nil, err
+	}
+	return defragResp, nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
+)
+
+func TestQuotaWarningRatio(t *testing.T) {
+	ratios := []float64{0.9, 0.75}
+	tcs := []struct {
+		name  string
+		size  int64
+		quota int64
+		want  float64
+	}{
+		{name: "below all ratios", size: 70, quota: 100, want: 0},
+		{name: "reached lower ratio", size: 75, quota: 100, want: 0.75},
+		{name: "reached both ratios", size: 95, quota: 100, want: 0.9},
+		{name: "over quota", size: 120, quota: 100, want: 0.9},
+		{name: "quota disabled", size: 120, quota: -1, want: 0},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, quotaWarningRatio(tc.size, tc.quota, ratios))
+		})
+	}
+	assert.Equal(t, float64(0), quotaWarningRatio(95, 100, nil))
+}
+
+func TestSortMembersLocalLast(t *testing.T) {
+	members := []*membership.Member{{ID: 3}, {ID: 1}, {ID: 4}, {ID: 2}}
+	sortMembersLocalLast(members, 2)
+	var ids []types.ID
+	for _, m := range members {
+		ids = append(ids, m.ID)
+	}
+	assert.Equal(t, []types.ID{1, 3, 4, 2}, ids)
+}
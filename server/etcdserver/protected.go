@@ -0,0 +1,89 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"slices"
+
+	"go.etcd.io/etcd/api/v3/authpb"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/auth"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3admission"
+	"go.etcd.io/etcd/server/v3/etcdserver/errors"
+)
+
+// checkProtected returns ErrProtectedPrefix if the mutations modify keys
+// under one of the protected prefixes of the member the user is not allowed
+// to modify. It is checked before the request is proposed, so that all the
+// members apply the request alike whatever their own protected prefixes.
+func (s *EtcdServer) checkProtected(ctx context.Context, ops []v3admission.Op) error {
+	pp := auth.ProtectedPrefixes(s.Cfg.ExperimentalProtectedPrefixes)
+	if len(pp) == 0 || len(ops) == 0 {
+		return nil
+	}
+	var username string
+	if s.authStore.IsAuthEnabled() {
+		ai, err := s.AuthInfoFromCtx(ctx)
+		if err != nil {
+			return err
+		}
+		if ai != nil {
+			username = ai.Username
+		}
+	}
+	hasRole := s.protectedHasRole(username)
+	for _, op := range ops {
+		if _, denied := pp.Denying(op.Key, op.RangeEnd, hasRole); denied {
+			return errors.ErrProtectedPrefix
+		}
+	}
+	return nil
+}
+
+// protectedHasRole returns whether the user has a role, any role if
+// authentication is disabled.
+func (s *EtcdServer) protectedHasRole(username string) func(role string) bool {
+	if !s.authStore.IsAuthEnabled() {
+		return func(string) bool { return true }
+	}
+	var roles []string
+	if username != "" {
+		if u, err := s.authStore.UserGet(&pb.AuthUserGetRequest{Name: username}); err == nil {
+			roles = u.Roles
+		}
+	}
+	return func(role string) bool { return slices.Contains(roles, role) }
+}
+
+// checkPermissionProtected denies the permission resp to modify the keys of
+// r if one of the protected prefixes of the member denies it to the user.
+func (s *EtcdServer) checkPermissionProtected(r *pb.AuthCheckPermissionRequest, resp *pb.AuthCheckPermissionResponse) {
+	pp := auth.ProtectedPrefixes(s.Cfg.ExperimentalProtectedPrefixes)
+	if len(pp) == 0 || !resp.Permitted || r.PermType == authpb.READ {
+		return
+	}
+	end := r.RangeEnd
+	if len(end) == 0 {
+		end = nil
+	}
+	hasRole := s.protectedHasRole(r.Name)
+	prefix, denied := pp.Denying(r.Key, end, hasRole)
+	if !denied {
+		return
+	}
+	resp.Permitted = false
+	resp.Reason = pp.DenyReason(prefix, end, hasRole)
+}
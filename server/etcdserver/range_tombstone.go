@@ -0,0 +1,50 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"github.com/coreos/go-semver/semver"
+
+	"go.etcd.io/etcd/api/v3/version"
+	"go.etcd.io/etcd/server/v3/etcdserver/errors"
+)
+
+// rangeTombstoneThreshold returns the range tombstone threshold to propose
+// the deletions with, or 0 if range tombstones are not enabled. The members
+// apply the deletions with the threshold of the member proposing them, once
+// all of them run a version reading range tombstones and unless a downgrade
+// is in progress.
+func (s *EtcdServer) rangeTombstoneThreshold() int64 {
+	if s.Cfg.ExperimentalRangeTombstoneThreshold <= 0 {
+		return 0
+	}
+	if cv := s.ClusterVersion(); cv == nil || cv.LessThan(version.V3_6) {
+		return 0
+	}
+	if s.DowngradeInfo().Enabled {
+		return 0
+	}
+	return int64(s.Cfg.ExperimentalRangeTombstoneThreshold)
+}
+
+// checkRangeTombstones returns an error if the cluster cannot be downgraded
+// to targetVersion because the backend holds range tombstones, which the
+// versions before 3.6 cannot read, that are not compacted yet.
+func (s *EtcdServer) checkRangeTombstones(targetVersion *semver.Version) error {
+	if targetVersion.LessThan(version.V3_6) && s.KV().HasRangeTombstones() {
+		return errors.ErrRangeTombstonesPresent
+	}
+	return nil
+}
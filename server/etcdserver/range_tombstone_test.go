@@ -0,0 +1,58 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"go.etcd.io/etcd/api/v3/version"
+	"go.etcd.io/etcd/pkg/v3/traceutil"
+	"go.etcd.io/etcd/server/v3/etcdserver/errors"
+	"go.etcd.io/etcd/server/v3/lease"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+)
+
+func TestCheckRangeTombstones(t *testing.T) {
+	be, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, be)
+	srv := &EtcdServer{
+		lgMu: new(sync.RWMutex),
+		lg:   zaptest.NewLogger(t),
+	}
+	srv.kv = mvcc.New(zaptest.NewLogger(t), be, &lease.FakeLessor{}, mvcc.StoreConfig{})
+	defer func() {
+		assert.NoError(t, srv.kv.Close())
+	}()
+
+	for i := 0; i < 3; i++ {
+		srv.kv.Put([]byte{'a', byte('0' + i)}, nil, lease.NoLease)
+	}
+	require.NoError(t, srv.checkRangeTombstones(&version.V3_5))
+
+	_, rev := mvcc.NewRangeTombstoneKV(srv.kv, 2).DeleteRange([]byte("a"), []byte("b"))
+	require.ErrorIs(t, srv.checkRangeTombstones(&version.V3_5), errors.ErrRangeTombstonesPresent)
+	require.NoError(t, srv.checkRangeTombstones(&version.V3_6))
+
+	done, err := srv.kv.Compact(traceutil.TODO(), rev)
+	require.NoError(t, err)
+	<-done
+	require.NoError(t, srv.checkRangeTombstones(&version.V3_5))
+}
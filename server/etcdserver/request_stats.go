@@ -0,0 +1,57 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"time"
+)
+
+type requestStatsKey struct{}
+
+// RequestStats breaks down the time spent serving a request. The server
+// fills it in for the requests whose context it's attached to with
+// WithRequestStats.
+type RequestStats struct {
+	// ReadIndexWait is the time spent waiting for the read index of
+	// linearizable reads to be confirmed by the leader and applied.
+	ReadIndexWait time.Duration
+	// Propose is the time spent proposing the request to raft.
+	Propose time.Duration
+	// CommitWait is the time between the proposal of the request and the
+	// start of its apply, spent waiting for it to be committed and for the
+	// entries before it to be applied.
+	CommitWait time.Duration
+	// Apply is the time spent applying the request.
+	Apply time.Duration
+}
+
+// QueueWait is the time the request spent waiting for raft rather than
+// being executed.
+func (rs *RequestStats) QueueWait() time.Duration {
+	return rs.ReadIndexWait + rs.Propose + rs.CommitWait
+}
+
+// WithRequestStats returns a copy of ctx carrying a RequestStats, which the
+// server fills in while serving the request.
+func WithRequestStats(ctx context.Context) (context.Context, *RequestStats) {
+	rs := &RequestStats{}
+	return context.WithValue(ctx, requestStatsKey{}, rs), rs
+}
+
+func requestStatsFromCtx(ctx context.Context) *RequestStats {
+	rs, _ := ctx.Value(requestStatsKey{}).(*RequestStats)
+	return rs
+}
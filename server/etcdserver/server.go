@@ -32,6 +32,7 @@ import (
 	humanize "github.com/dustin/go-humanize"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/membershippb"
@@ -58,6 +59,8 @@ import (
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v2store"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3alarm"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3compactor"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3hotkeys"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3notify"
 	"go.etcd.io/etcd/server/v3/etcdserver/apply"
 	"go.etcd.io/etcd/server/v3/etcdserver/cindex"
 	"go.etcd.io/etcd/server/v3/etcdserver/errors"
@@ -67,6 +70,7 @@ import (
 	"go.etcd.io/etcd/server/v3/lease/leasehttp"
 	serverstorage "go.etcd.io/etcd/server/v3/storage"
 	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/encryption"
 	"go.etcd.io/etcd/server/v3/storage/mvcc"
 	"go.etcd.io/etcd/server/v3/storage/schema"
 	"go.etcd.io/raft/v3"
@@ -211,6 +215,10 @@ type EtcdServer struct {
 	committedIndex    uint64 // must use atomic operations to access; keep 64-bit aligned.
 	term              uint64 // must use atomic operations to access; keep 64-bit aligned.
 	lead              uint64 // must use atomic operations to access; keep 64-bit aligned.
+	// readConfirmedAt is the time, in unix nanoseconds, as of which everything
+	// committed is known to be applied locally, as last confirmed with the leader
+	// by the linearizable read loop.
+	readConfirmedAt int64 // must use atomic operations to access; keep 64-bit aligned.
 
 	consistIndex cindex.ConsistentIndexer // consistIndex is used to get/set/save consistentIndex
 	r            raftNode                 // uses 64-bit atomics; keep 64-bit aligned.
@@ -222,6 +230,9 @@ type EtcdServer struct {
 	lg   *zap.Logger
 
 	w wait.Wait
+	// inflight tracks the requests being served, if enabled with
+	// ExperimentalInflightRequests.
+	inflight inflightRequests
 
 	readMu sync.RWMutex
 	// read routine notifies etcd server that it waits for reading by sending an empty struct to
@@ -248,6 +259,9 @@ type EtcdServer struct {
 
 	v2store     v2store.Store
 	snapshotter *snap.Snapshotter
+	// differentialSnapshots tracks the differential snapshots sent while
+	// leader.
+	differentialSnapshots differentialSnapshotTracker
 
 	uberApply apply.UberApplier
 
@@ -260,6 +274,10 @@ type EtcdServer struct {
 	beHooks    *serverstorage.BackendHooks
 	authStore  auth.AuthStore
 	alarmStore *v3alarm.AlarmStore
+	// leaseRevokeLimiter, if set, paces the proposals revoking expired leases.
+	leaseRevokeLimiter *rate.Limiter
+	// keyring is nil unless encryption at rest is enabled.
+	keyring *encryption.Keyring
 
 	stats  *stats.ServerStats
 	lstats *stats.LeaderStats
@@ -267,6 +285,9 @@ type EtcdServer struct {
 	SyncTicker *time.Ticker
 	// compactor is used to auto-compact the KV.
 	compactor v3compactor.Compactor
+	// outsideMaintenanceWindow is set while no maintenance window is open,
+	// deferring the background maintenance.
+	outsideMaintenanceWindow atomic.Bool
 
 	// peerRt used to send requests (version, lease) to peers.
 	peerRt   http.RoundTripper
@@ -289,11 +310,26 @@ type EtcdServer struct {
 	firstCommitInTerm     *notify.Notifier
 	clusterVersionChanged *notify.Notifier
 
+	clusterEvents *clusterEventHub
+	// hotKeys tracks the keys most frequently accessed through the member,
+	// if enabled.
+	hotKeys *v3hotkeys.Tracker
+
 	*AccessController
 	// forceSnapshot can force snapshot be triggered after apply, independent of the snapshotCount.
 	// Should only be set within apply code path. Used to force snapshot after cluster version downgrade.
 	forceSnapshot     bool
 	corruptionChecker CorruptionChecker
+
+	// draining is set while the member is draining; must use atomic operations to access.
+	draining int32
+	// quarantined is set while a CORRUPT_RANGE alarm is raised for the member; must use atomic operations to access.
+	quarantined int32
+	// quarantinedNotifier is notified when the member gets quarantined.
+	quarantinedNotifier *notify.Notifier
+
+	// lifecycleHooks runs the lifecycle hooks in the order of the events.
+	lifecycleHooks *lifecycleHookQueue
 }
 
 // NewServer creates a new EtcdServer from the supplied configuration. The
@@ -337,6 +373,12 @@ func NewServer(cfg config.ServerConfig) (srv *EtcdServer, err error) {
 		consistIndex:          b.storage.backend.ci,
 		firstCommitInTerm:     notify.NewNotifier(),
 		clusterVersionChanged: notify.NewNotifier(),
+		quarantinedNotifier:   notify.NewNotifier(),
+		clusterEvents:         newClusterEventHub(),
+		lifecycleHooks:        newLifecycleHookQueue(),
+	}
+	if cfg.ExperimentalHotKeysSampleRate > 0 {
+		srv.hotKeys = v3hotkeys.NewTracker(cfg.ExperimentalHotKeysSampleRate, v3hotkeys.DefaultCapacity)
 	}
 	serverID.With(prometheus.Labels{"server_id": b.cluster.nodeID.String()}).Set(1)
 	srv.cluster.SetVersionChangedNotifier(srv.clusterVersionChanged)
@@ -352,7 +394,12 @@ func NewServer(cfg config.ServerConfig) (srv *EtcdServer, err error) {
 		CheckpointInterval:         cfg.LeaseCheckpointInterval,
 		CheckpointPersist:          cfg.LeaseCheckpointPersist,
 		ExpiredLeasesRetryInterval: srv.Cfg.ReqTimeout(),
+		MaxRevokesPerSecond:        cfg.LeaseRevokeRate,
+		MaxPinDuration:             cfg.LeasePinMaxDuration,
 	})
+	if cfg.LeaseRevokeRate > 0 {
+		srv.leaseRevokeLimiter = rate.NewLimiter(rate.Limit(cfg.LeaseRevokeRate), maxPendingRevokes)
+	}
 
 	tp, err := auth.NewTokenProvider(cfg.Logger, cfg.AuthToken,
 		func(index uint64) <-chan struct{} {
@@ -366,8 +413,22 @@ func NewServer(cfg config.ServerConfig) (srv *EtcdServer, err error) {
 	}
 
 	mvccStoreConfig := mvcc.StoreConfig{
-		CompactionBatchLimit:    cfg.CompactionBatchLimit,
-		CompactionSleepInterval: cfg.CompactionSleepInterval,
+		CompactionBatchLimit:       cfg.CompactionBatchLimit,
+		CompactionSleepInterval:    cfg.CompactionSleepInterval,
+		CompactionPauseTarget:      cfg.CompactionPauseTarget,
+		CompactionMaxSleepInterval: cfg.CompactionMaxSleepInterval,
+		UsagePrefixes:              cfg.ExperimentalKeyspaceMetricsPrefixes,
+		SlowWatcherPolicy:          mvcc.SlowWatcherPolicy(cfg.ExperimentalWatchSlowWatcherPolicy),
+		SlowWatcherMaxLag:          cfg.ExperimentalWatchSlowWatcherMaxLag,
+		SlowWatcherBlockTimeout:    cfg.ExperimentalWatchSlowWatcherBlockTimeout,
+	}
+	if cfg.EncryptionKMS != nil {
+		srv.keyring, err = encryption.NewKeyring(cfg.Logger, cfg.EncryptionKMS, srv.be)
+		if err != nil {
+			cfg.Logger.Warn("failed to load data encryption keys", zap.Error(err))
+			return nil, err
+		}
+		mvccStoreConfig.ValueTransformer = srv.keyring
 	}
 	srv.kv = mvcc.New(srv.Logger(), srv.be, srv.lessor, mvccStoreConfig)
 	srv.corruptionChecker = newCorruptionChecker(cfg.Logger, srv, srv.kv.HashStorage())
@@ -387,6 +448,9 @@ func NewServer(cfg config.ServerConfig) (srv *EtcdServer, err error) {
 		if err != nil {
 			return nil, err
 		}
+		if cfg.ExperimentalMaintenanceWindows != "" {
+			srv.compactor = newWindowedCompactor(srv.compactor)
+		}
 		srv.compactor.Run()
 	}
 
@@ -426,6 +490,10 @@ func NewServer(cfg config.ServerConfig) (srv *EtcdServer, err error) {
 		ServerStats: sstats,
 		LeaderStats: lstats,
 		ErrorC:      srv.errorc,
+
+		DifferentialSnapshotter: srv,
+		SnapshotSendRateBytes:   cfg.ExperimentalSnapshotSendRateBytes,
+		SnapshotCompression:     cfg.ExperimentalSnapshotCompression,
 	}
 	if err = tr.Start(); err != nil {
 		return nil, err
@@ -543,7 +611,25 @@ func (s *EtcdServer) Start() {
 	s.GoAttach(s.linearizableReadLoop)
 	s.GoAttach(s.monitorKVHash)
 	s.GoAttach(s.monitorCompactHash)
+	s.GoAttach(s.monitorCorruptRange)
 	s.GoAttach(s.monitorDowngrade)
+	s.GoAttach(s.monitorLearnerPromotion)
+	s.GoAttach(s.monitorLeaderPlacement)
+	s.GoAttach(s.monitorBackendQuota)
+	s.GoAttach(s.purgeTrash)
+	s.GoAttach(s.monitorDisk)
+	s.GoAttach(s.monitorMaintenanceWindows)
+	s.GoAttach(func() { s.lifecycleHooks.run(s.stopping) })
+	s.startChangeNotifiers()
+}
+
+// startChangeNotifiers starts sending the changes to the keys under the
+// prefixes of the configured targets, while the member is leader.
+func (s *EtcdServer) startChangeNotifiers() {
+	for _, t := range s.Cfg.ChangeNotifyTargets {
+		n := v3notify.New(s.Logger(), s.KV(), s.Cfg.NotifyDir(), t, s.isLeader)
+		s.GoAttach(func() { n.Run(s.stopping) })
+	}
 }
 
 // start prepares and starts server in a new goroutine. It is no longer safe to
@@ -656,7 +742,9 @@ func (s *EtcdServer) RaftHandler() http.Handler { return s.r.transport.Handler()
 type ServerPeerV2 interface {
 	ServerPeer
 	HashKVHandler() http.Handler
+	BackendStatusHandler() http.Handler
 	DowngradeEnabledHandler() http.Handler
+	DefragHandler() http.Handler
 }
 
 func (s *EtcdServer) DowngradeInfo() *serverversion.DowngradeInfo { return s.cluster.DowngradeInfo() }
@@ -736,6 +824,7 @@ func (s *EtcdServer) ReportUnreachable(id uint64) { s.r.ReportUnreachable(id) }
 // ReportSnapshot reports snapshot sent status to the raft state machine,
 // and clears the used snapshot from the snapshot store.
 func (s *EtcdServer) ReportSnapshot(id uint64, status raft.SnapshotStatus) {
+	s.differentialSnapshots.report(id, status)
 	s.r.ReportSnapshot(id, status)
 }
 
@@ -767,10 +856,32 @@ func (s *EtcdServer) run() {
 	// asynchronously accept toApply packets, dispatch progress in-order
 	sched := schedule.NewFIFOScheduler(lg)
 
+	// wasLeader is only accessed by the raft goroutine through updateLeadership
+	wasLeader := false
 	rh := &raftReadyHandler{
-		getLead:    func() (lead uint64) { return s.getLead() },
-		updateLead: func(lead uint64) { s.setLead(lead) },
+		getLead: func() (lead uint64) { return s.getLead() },
+		updateLead: func(lead uint64) {
+			if lead != raft.None && lead != s.getLead() {
+				s.PublishClusterEvent(&pb.ClusterEvent{Type: pb.ClusterEvent_LEADER_CHANGED, MemberId: lead})
+			}
+			s.setLead(lead)
+		},
 		updateLeadership: func(newLeader bool) {
+			if isLeader := s.isLeader(); isLeader != wasLeader {
+				wasLeader = isLeader
+				if isLeader {
+					s.RunLifecycleHook(s.Cfg.LifecycleHooks.OnLeaderElected)
+					if s.IsDraining() || s.IsWitness() {
+						s.GoAttach(func() {
+							if err := s.tryTransferLeadership(s.ctx); err != nil {
+								lg.Warn("failed to transfer leadership away from draining or witness member", zap.Error(err))
+							}
+						})
+					}
+				} else {
+					s.RunLifecycleHook(s.Cfg.LifecycleHooks.OnLeadershipLost)
+				}
+			}
 			if !s.isLeader() {
 				if s.lessor != nil {
 					s.lessor.Demote()
@@ -880,6 +991,15 @@ func (s *EtcdServer) revokeExpiredLeases(leases []*lease.Lease) {
 			case <-s.stopping:
 				return
 			}
+			if s.leaseRevokeLimiter != nil {
+				// spread the revocations of the batch over time instead
+				// of proposing them all at once
+				start := time.Now()
+				if err := s.leaseRevokeLimiter.Wait(s.ctx); err != nil {
+					return
+				}
+				leaseRevokeWaitSec.Observe(time.Since(start).Seconds())
+			}
 
 			f := func(lid int64) {
 				s.GoAttach(func() {
@@ -966,8 +1086,16 @@ func (s *EtcdServer) applyAll(ep *etcdProgress, apply *toApply) {
 	select {
 	// snapshot requested via send()
 	case m := <-s.r.msgSnapC:
-		merged := s.createMergedSnapshotMessage(m, ep.appliedt, ep.appliedi, ep.confState)
-		s.sendMergedSnap(merged)
+		if s.IsWitness() {
+			// a witness has no key-value store to send, the follower gets
+			// its snapshot once the leadership is given away.
+			s.r.ReportSnapshot(m.To, raft.SnapshotFailure)
+		} else if s.Cfg.ExperimentalDifferentialSnapshot {
+			s.sendDifferentialSnapshot(m, ep.appliedt, ep.appliedi, ep.confState)
+		} else {
+			merged := s.createMergedSnapshotMessage(m, ep.appliedt, ep.appliedi, ep.confState)
+			s.sendMergedSnap(merged)
+		}
 	default:
 	}
 }
@@ -1015,6 +1143,9 @@ func (s *EtcdServer) applySnapshot(ep *etcdProgress, toApply *toApply) {
 	if err != nil {
 		lg.Panic("failed to open snapshot backend", zap.Error(err))
 	}
+	if s.IsWitness() {
+		purgeWitnessKeyspace(lg, newbe)
+	}
 
 	// We need to set the backend to consistIndex before recovering the lessor,
 	// because lessor.Recover will commit the boltDB transaction, accordingly it
@@ -1125,7 +1256,7 @@ func (s *EtcdServer) applySnapshot(ep *etcdProgress, toApply *toApply) {
 
 func (s *EtcdServer) NewUberApplier() apply.UberApplier {
 	return apply.NewUberApplier(s.lg, s.be, s.KV(), s.alarmStore, s.authStore, s.lessor, s.cluster, s, s, s.consistIndex,
-		s.Cfg.WarningApplyDuration, s.Cfg.ExperimentalTxnModeWriteWithSharedBuffer, s.Cfg.QuotaBackendBytes)
+		s.Cfg.WarningApplyDuration, s.Cfg.ExperimentalTxnModeWriteWithSharedBuffer, s.Cfg.QuotaBackendBytes, s.IsWitness())
 }
 
 func verifySnapshotIndex(snapshot raftpb.Snapshot, cindex uint64) {
@@ -1194,7 +1325,11 @@ func (s *EtcdServer) triggerSnapshot(ep *etcdProgress) {
 }
 
 func (s *EtcdServer) shouldSnapshot(ep *etcdProgress) bool {
-	return (s.forceSnapshot && ep.appliedi != ep.snapi) || (ep.appliedi-ep.snapi > s.Cfg.SnapshotCount)
+	count := s.Cfg.SnapshotCount
+	if !s.maintenanceWindowOpen() {
+		count *= maintenanceSnapshotCountFactor
+	}
+	return (s.forceSnapshot && ep.appliedi != ep.snapi) || (ep.appliedi-ep.snapi > count)
 }
 
 func (s *EtcdServer) hasMultipleVotingMembers() bool {
@@ -1244,6 +1379,13 @@ func (s *EtcdServer) MoveLeader(ctx context.Context, lead, transferee uint64) er
 
 // TryTransferLeadershipOnShutdown transfers the leader to the chosen transferee. It is only used in server graceful shutdown.
 func (s *EtcdServer) TryTransferLeadershipOnShutdown() error {
+	return s.tryTransferLeadership(s.ctx)
+}
+
+// tryTransferLeadership transfers the leader to the longest connected voting
+// member, waiting at most the request timeout. It is a no-op if the local
+// member is not the leader or is the only voting member.
+func (s *EtcdServer) tryTransferLeadership(parent context.Context) error {
 	lg := s.Logger()
 	if !s.isLeader() {
 		lg.Info(
@@ -1263,16 +1405,56 @@ func (s *EtcdServer) TryTransferLeadershipOnShutdown() error {
 		return nil
 	}
 
-	transferee, ok := longestConnected(s.r.transport, s.cluster.VotingMemberIDs())
+	transferee, ok := longestConnected(s.r.transport, leaderCandidateIDs(s.cluster.Members()))
 	if !ok {
 		return errors.ErrUnhealthy
 	}
 
-	tm := s.Cfg.ReqTimeout()
-	ctx, cancel := context.WithTimeout(s.ctx, tm)
-	err := s.MoveLeader(ctx, s.Lead(), uint64(transferee))
-	cancel()
-	return err
+	ctx, cancel := context.WithTimeout(parent, s.Cfg.ReqTimeout())
+	defer cancel()
+	return s.MoveLeader(ctx, s.Lead(), uint64(transferee))
+}
+
+// Drain marks the member as draining. A draining member transfers its
+// leadership away, and gives it away again whenever it is re-elected, fails
+// its readiness checks and rejects new watch streams. Requests already in
+// flight are allowed to finish. The member stays draining if the leadership
+// transfer fails, so that Drain can be retried.
+func (s *EtcdServer) Drain(ctx context.Context) error {
+	if atomic.SwapInt32(&s.draining, 1) == 0 {
+		s.Logger().Info("member is draining", zap.String("local-member-id", s.MemberID().String()))
+	}
+	return s.tryTransferLeadership(ctx)
+}
+
+// Undrain makes a draining member serve client traffic again.
+func (s *EtcdServer) Undrain() {
+	if atomic.SwapInt32(&s.draining, 0) == 1 {
+		s.Logger().Info("member is no longer draining", zap.String("local-member-id", s.MemberID().String()))
+	}
+}
+
+// IsDraining returns true if the member is draining.
+func (s *EtcdServer) IsDraining() bool { return atomic.LoadInt32(&s.draining) == 1 }
+
+// RotateEncryptionKey creates a new data encryption key on the member and
+// returns its ID. Key-value records written from now on are encrypted with it.
+func (s *EtcdServer) RotateEncryptionKey(ctx context.Context) (uint64, error) {
+	if s.keyring == nil {
+		return 0, errors.ErrEncryptionNotEnabled
+	}
+	return s.keyring.Rotate(ctx)
+}
+
+// HotKeys returns the keys most frequently read and written through the
+// member, up to limit each, and the fraction of the requests sampled to
+// estimate them.
+func (s *EtcdServer) HotKeys(limit int) (reads, writes []v3hotkeys.Key, sampleRate float64, err error) {
+	if s.hotKeys == nil {
+		return nil, nil, 0, errors.ErrHotKeysNotEnabled
+	}
+	reads, writes = s.hotKeys.Top(limit)
+	return reads, writes, s.hotKeys.SampleRate(), nil
 }
 
 // HardStop stops the server without coordination with other members in the cluster.
@@ -1448,7 +1630,7 @@ func (s *EtcdServer) PromoteMember(ctx context.Context, id uint64) ([]*membershi
 				return resp, nil
 			}
 			// If member promotion failed, return early. Otherwise keep retry.
-			if err == errors.ErrLearnerNotReady || err == membership.ErrIDNotFound || err == membership.ErrMemberNotLearner {
+			if err == errors.ErrLearnerNotReady || err == membership.ErrIDNotFound || err == membership.ErrMemberNotLearner || err == membership.ErrMemberReadReplica {
 				return nil, err
 			}
 		}
@@ -1470,7 +1652,12 @@ func (s *EtcdServer) promoteMember(ctx context.Context, id uint64) ([]*membershi
 	if err := s.checkMembershipOperationPermission(ctx); err != nil {
 		return nil, err
 	}
+	return s.promoteLearner(ctx, id)
+}
 
+// promoteLearner promotes the learner without checking the permission of the
+// requester.
+func (s *EtcdServer) promoteLearner(ctx context.Context, id uint64) ([]*membership.Member, error) {
 	// check if we can promote this learner.
 	if err := s.mayPromoteMember(types.ID(id)); err != nil {
 		return nil, err
@@ -1500,6 +1687,9 @@ func (s *EtcdServer) promoteMember(ctx context.Context, id uint64) ([]*membershi
 
 func (s *EtcdServer) mayPromoteMember(id types.ID) error {
 	lg := s.Logger()
+	if m := s.cluster.Member(id); m != nil && m.ReadReplica {
+		return membership.ErrMemberReadReplica
+	}
 	if err := s.isLearnerReady(lg, uint64(id)); err != nil {
 		return err
 	}
@@ -1631,6 +1821,31 @@ func (s *EtcdServer) UpdateMember(ctx context.Context, memb membership.Member) (
 	return s.configure(ctx, cc)
 }
 
+// UpdateMemberLabels merges the given labels into the labels of the member.
+// A label with an empty value is removed. It requires cluster version 3.6,
+// as older members apply the update as a change of the name and the client
+// URLs of the member.
+func (s *EtcdServer) UpdateMemberLabels(ctx context.Context, id types.ID, labels map[string]string) ([]*membership.Member, error) {
+	if err := s.checkMembershipOperationPermission(ctx); err != nil {
+		return nil, err
+	}
+	if cv := s.ClusterVersion(); cv == nil || cv.LessThan(version.V3_6) {
+		return nil, membership.ErrLabelsNotSupported
+	}
+	if s.cluster.Member(id) == nil {
+		return nil, membership.ErrIDNotFound
+	}
+	req := &membershippb.ClusterMemberAttrSetRequest{
+		Member_ID:        uint64(id),
+		MemberAttributes: &membershippb.Attributes{Labels: labels},
+		UpdateLabels:     true,
+	}
+	if _, err := s.raftRequest(ctx, pb.InternalRaftRequest{ClusterMemberAttrSet: req}); err != nil {
+		return nil, err
+	}
+	return s.cluster.Members(), nil
+}
+
 func (s *EtcdServer) setCommittedIndex(v uint64) {
 	atomic.StoreUint64(&s.committedIndex, v)
 }
@@ -1752,8 +1967,10 @@ func (s *EtcdServer) publishV3(timeout time.Duration) {
 	req := &membershippb.ClusterMemberAttrSetRequest{
 		Member_ID: uint64(s.MemberID()),
 		MemberAttributes: &membershippb.Attributes{
-			Name:       s.attributes.Name,
-			ClientUrls: s.attributes.ClientURLs,
+			Name:        s.attributes.Name,
+			ClientUrls:  s.attributes.ClientURLs,
+			ReadReplica: s.Cfg.ExperimentalLearnerReadReplica,
+			Witness:     s.IsWitness(),
 		},
 	}
 	lg := s.Logger()
@@ -1777,6 +1994,7 @@ func (s *EtcdServer) publishV3(timeout time.Duration) {
 		switch err {
 		case nil:
 			close(s.readych)
+			s.RunLifecycleHook(s.Cfg.LifecycleHooks.OnReady)
 			lg.Info(
 				"published local member to cluster through raft",
 				zap.String("local-member-id", s.MemberID().String()),
@@ -1947,12 +2165,23 @@ func (s *EtcdServer) applyEntryNormal(e *raftpb.Entry, shouldApplyV3 membership.
 		id = raftReq.Header.ID
 	}
 
+	// activating an active alarm returns it as well, but raises nothing
+	alarmActive := raftReq.Alarm != nil && raftReq.Alarm.Action == pb.AlarmRequest_ACTIVATE &&
+		s.isAlarmActive(types.ID(raftReq.Alarm.MemberID), raftReq.Alarm.Alarm)
+
 	needResult := s.w.IsRegistered(id)
 	if needResult || !noSideEffect(&raftReq) {
 		if !needResult && raftReq.Txn != nil {
 			removeNeedlessRangeReqs(raftReq.Txn)
 		}
+		if needResult {
+			s.setInflightProposalApplying(id)
+		}
+		applyStart := time.Now()
 		ar = s.applyInternalRaftRequest(&raftReq, shouldApplyV3)
+		if ar != nil && needResult {
+			ar.ApplyStart, ar.ApplyEnd = applyStart, time.Now()
+		}
 	}
 
 	// do not re-toApply applied entries.
@@ -1964,6 +2193,25 @@ func (s *EtcdServer) applyEntryNormal(e *raftpb.Entry, shouldApplyV3 membership.
 		return
 	}
 
+	if raftReq.Alarm != nil && ar.Err == nil {
+		s.publishAlarmEvents(raftReq.Alarm.Action, alarmActive, ar.Resp)
+		s.updateQuarantine()
+	}
+
+	if raftReq.Compaction != nil && ar.Err == nil && ar.Physc != nil {
+		rev, physc := raftReq.Compaction.Revision, ar.Physc
+		s.GoAttach(func() {
+			select {
+			case <-physc:
+				s.PublishClusterEvent(&pb.ClusterEvent{Type: pb.ClusterEvent_COMPACTED, MemberId: uint64(s.MemberID()), CompactRevision: rev})
+				if hook := s.Cfg.LifecycleHooks.OnCompacted; hook != nil {
+					s.RunLifecycleHook(func() { hook(rev) })
+				}
+			case <-s.stopping:
+			}
+		})
+	}
+
 	if ar.Err != errors.ErrNoSpace || len(s.alarmStore.Get(pb.AlarmType_NOSPACE)) > 0 {
 		s.w.Trigger(id, ar)
 		return
@@ -2074,8 +2322,10 @@ func (s *EtcdServer) applyConfChange(cc raftpb.ConfChange, confState *raftpb.Con
 				zap.String("member-id-from-message", confChangeContext.Member.ID.String()),
 			)
 		}
+		evType := pb.ClusterEvent_MEMBER_ADDED
 		if confChangeContext.IsPromote {
 			s.cluster.PromoteMember(confChangeContext.Member.ID, shouldApplyV3)
+			evType = pb.ClusterEvent_MEMBER_PROMOTED
 		} else {
 			s.cluster.AddMember(&confChangeContext.Member, shouldApplyV3)
 
@@ -2083,10 +2333,16 @@ func (s *EtcdServer) applyConfChange(cc raftpb.ConfChange, confState *raftpb.Con
 				s.r.transport.AddPeer(confChangeContext.Member.ID, confChangeContext.PeerURLs)
 			}
 		}
+		if shouldApplyV3 {
+			s.PublishClusterEvent(&pb.ClusterEvent{Type: evType, MemberId: cc.NodeID})
+		}
 
 	case raftpb.ConfChangeRemoveNode:
 		id := types.ID(cc.NodeID)
 		s.cluster.RemoveMember(id, shouldApplyV3)
+		if shouldApplyV3 {
+			s.PublishClusterEvent(&pb.ClusterEvent{Type: pb.ClusterEvent_MEMBER_REMOVED, MemberId: cc.NodeID})
+		}
 		if id == s.MemberID() {
 			return true, nil
 		}
@@ -2288,7 +2544,7 @@ func (s *EtcdServer) monitorKVHash() {
 		case <-checkTicker.C:
 		}
 		backend.VerifyBackendConsistency(s.be, lg, false, schema.AllBuckets...)
-		if !s.isLeader() {
+		if !s.isLeader() || s.IsWitness() {
 			continue
 		}
 		if err := s.corruptionChecker.PeriodicCheck(); err != nil {
@@ -2310,13 +2566,40 @@ func (s *EtcdServer) monitorCompactHash() {
 			lg.Info("server has stopped; stopping compact hash's monitor")
 			return
 		}
-		if !s.isLeader() {
+		if !s.isLeader() || s.IsWitness() {
 			continue
 		}
 		s.corruptionChecker.CompactHashCheck()
 	}
 }
 
+func (s *EtcdServer) monitorCorruptRange() {
+	t := s.Cfg.CorruptRangeCheckTime
+	if t == 0 {
+		return
+	}
+	lg := s.Logger()
+	lg.Info(
+		"enabled ranged corruption checking",
+		zap.String("local-member-id", s.MemberID().String()),
+		zap.Duration("interval", t),
+	)
+	for {
+		select {
+		case <-time.After(t):
+		case <-s.stopping:
+			lg.Info("server has stopped; stopping ranged corruption check's monitor")
+			return
+		}
+		if !s.isLeader() || s.IsWitness() {
+			continue
+		}
+		if err := s.corruptionChecker.RangeHashCheck(); err != nil {
+			lg.Warn("failed to check range hash", zap.Error(err))
+		}
+	}
+}
+
 func (s *EtcdServer) updateClusterVersionV3(ver string) {
 	lg := s.Logger()
 
@@ -2422,6 +2705,7 @@ func (s *EtcdServer) restoreAlarms() error {
 		return err
 	}
 	s.alarmStore = as
+	s.updateQuarantine()
 	return nil
 }
 
@@ -2451,6 +2735,11 @@ func (s *EtcdServer) Alarms() []*pb.AlarmMember {
 	return s.alarmStore.Get(pb.AlarmType_NONE)
 }
 
+// IsReadOnly returns true if the cluster is read-only, rejecting mutations.
+func (s *EtcdServer) IsReadOnly() bool {
+	return len(s.alarmStore.Get(pb.AlarmType_READONLY)) > 0
+}
+
 // IsLearner returns if the local member is raft learner
 func (s *EtcdServer) IsLearner() bool {
 	return s.cluster.IsLocalMemberLearner()
@@ -31,9 +31,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc/metadata"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/membershippb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/api/v3/version"
 	"go.etcd.io/etcd/client/pkg/v3/fileutil"
 	"go.etcd.io/etcd/client/pkg/v3/testutil"
@@ -1059,6 +1061,31 @@ func TestUpdateMember(t *testing.T) {
 	}
 }
 
+// TestUpdateMemberLabelsClusterVersion ensures the labels of a member are not
+// updated before all members run 3.6, as older members would apply the update
+// as a change of the name and the client URLs of the member.
+func TestUpdateMemberLabelsClusterVersion(t *testing.T) {
+	be, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, be)
+	cl := newTestClusterWithBackend(t, []*membership.Member{{ID: 1234}}, be)
+	cl.SetVersion(semver.New("3.5.0"), api.UpdateCapability, membership.ApplyBoth)
+	n := newNodeRecorder()
+	srv := &EtcdServer{
+		lgMu:    new(sync.RWMutex),
+		lg:      zaptest.NewLogger(t),
+		r:       *newRaftNode(raftNodeConfig{lg: zaptest.NewLogger(t), Node: n}),
+		cluster: cl,
+	}
+
+	_, err := srv.UpdateMemberLabels(context.Background(), 1234, map[string]string{"zone": "a"})
+	if err != membership.ErrLabelsNotSupported {
+		t.Fatalf("UpdateMemberLabels error = %v, want %v", err, membership.ErrLabelsNotSupported)
+	}
+	if actions := n.Action(); len(actions) != 0 {
+		t.Errorf("unexpected actions %v", actions)
+	}
+}
+
 // TODO: test server could stop itself when being removed
 
 func TestPublishV3(t *testing.T) {
@@ -1538,3 +1565,48 @@ func TestWaitAppliedIndex(t *testing.T) {
 		})
 	}
 }
+
+func TestForwardedAuthInfo(t *testing.T) {
+	srv := &EtcdServer{
+		lgMu: new(sync.RWMutex),
+		lg:   zaptest.NewLogger(t),
+		Cfg:  config.ServerConfig{ExperimentalTrustedProxyCNs: []string{"proxy"}},
+	}
+	forwarded := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(rpctypes.MetadataForwardedCommonNameKey, "user"))
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		authInfo *auth.AuthInfo
+		want     *auth.AuthInfo
+	}{
+		{
+			name:     "trusted proxy",
+			ctx:      forwarded,
+			authInfo: &auth.AuthInfo{Username: "proxy", Revision: 3},
+			want:     &auth.AuthInfo{Username: "user", Revision: 3},
+		},
+		{
+			name:     "untrusted client",
+			ctx:      forwarded,
+			authInfo: &auth.AuthInfo{Username: "other", Revision: 3},
+			want:     &auth.AuthInfo{Username: "other", Revision: 3},
+		},
+		{
+			name:     "no forwarded common name",
+			ctx:      context.Background(),
+			authInfo: &auth.AuthInfo{Username: "proxy", Revision: 3},
+			want:     &auth.AuthInfo{Username: "proxy", Revision: 3},
+		},
+		{
+			name: "no client certificate",
+			ctx:  forwarded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, srv.forwardedAuthInfo(tt.ctx, tt.authInfo))
+		})
+	}
+}
@@ -0,0 +1,650 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/rafthttp"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+	"go.etcd.io/raft/v3"
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+// A differential snapshot holds the key revisions of the leader's database
+// after a base revision that the follower already has, and the whole content
+// of the other buckets. The follower applies it to a copy of its own database
+// to rebuild the leader's database at the snapshot index.
+//
+// It is made of the differentialSnapshotMagic and the base revision, followed
+// by records of one of the types below, each bucket record being followed by
+// the put records of its key-value pairs:
+//
+//	bucket: 'b' name flags from  (flags: differentialBucketExists, differentialBucketMerge)
+//	put:    'p' key value
+//	end:    'e' sha256 of the preceding bytes, up to and including 'e'
+//
+// Integers are uvarints and byte strings are prefixed with their uvarint
+// length. A bucket record replaces the keys of the follower's bucket not
+// less than from, or merges into them if differentialBucketMerge is set.
+const (
+	differentialRecordBucket byte = 'b'
+	differentialRecordPut    byte = 'p'
+	differentialRecordEnd    byte = 'e'
+
+	differentialBucketExists byte = 1 << 0
+	differentialBucketMerge  byte = 1 << 1
+
+	// PeerBackendStatusPath serves the backend status of the member, from
+	// which the leader decides whether to send it a differential snapshot.
+	PeerBackendStatusPath = "/members/backend/status"
+)
+
+var (
+	differentialSnapshotMagic = []byte("etcd-differential-snapshot-v1\n")
+
+	// differentialSnapshotStatusTimeout bounds fetching the backend status
+	// of a follower before sending it a snapshot.
+	differentialSnapshotStatusTimeout = 5 * time.Second
+
+	errDifferentialSnapshotFailedBefore = errors.New("a previous differential snapshot failed")
+	errDifferentialSnapshotTooLarge     = errors.New("differential snapshot exceeds half of the database size")
+	errDifferentialSnapshotCorrupted    = errors.New("differential snapshot corrupted")
+)
+
+// differentialSnapshotBuckets are the buckets sent in differential snapshots.
+// The encryption keys of the follower are merged with the leader's, since
+// the follower's values may still be encrypted with them.
+var differentialSnapshotBuckets = append(append([]backend.Bucket{}, schema.AllBuckets...), schema.Encryption)
+
+// peerBackendStatus is the backend status of a member.
+type peerBackendStatus struct {
+	ConsistentIndex uint64 `json:"consistentIndex"`
+	Revision        int64  `json:"revision"`
+}
+
+type backendStatusHandler struct {
+	lg     *zap.Logger
+	server *EtcdServer
+}
+
+// BackendStatusHandler returns the handler serving the backend status of the
+// member to the leader.
+func (s *EtcdServer) BackendStatusHandler() http.Handler {
+	return &backendStatusHandler{lg: s.Logger(), server: s}
+}
+
+func (h *backendStatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.URL.Path != PeerBackendStatusPath {
+		http.Error(w, "bad path", http.StatusBadRequest)
+		return
+	}
+	if gcid := r.Header.Get("X-Etcd-Cluster-ID"); gcid != "" && gcid != h.server.cluster.ID().String() {
+		http.Error(w, rafthttp.ErrClusterIDMismatch.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	resp := &peerBackendStatus{
+		ConsistentIndex: h.server.consistIndex.ConsistentIndex(),
+		Revision:        h.server.KV().Rev(),
+	}
+	respBytes, err := json.Marshal(resp)
+	if err != nil {
+		h.lg.Warn("failed to marshal backend status response", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("X-Etcd-Cluster-ID", h.server.Cluster().ID().String())
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(respBytes)
+}
+
+// getBackendStatusHTTP fetches the backend status of the given member through
+// the first of its peer URLs that succeeds.
+func getBackendStatusHTTP(ctx context.Context, cid types.ID, m *membership.Member, peerRt http.RoundTripper) (*peerBackendStatus, error) {
+	cc := &http.Client{Transport: peerRt}
+	var lastErr error
+	for _, u := range m.PeerURLs {
+		status, err := getBackendStatusURL(ctx, cc, cid, u)
+		if err == nil {
+			return status, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func getBackendStatusURL(ctx context.Context, cc *http.Client, cid types.ID, url string) (*peerBackendStatus, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+PeerBackendStatusPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Etcd-Cluster-ID", cid.String())
+
+	resp, err := cc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("the member does not support differential snapshots")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unknown error: %s", b)
+	}
+
+	status := &peerBackendStatus{}
+	if err := json.Unmarshal(b, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// differentialSnapshotTracker tracks the differential snapshots sent to followers.
+// A follower that failed to apply a differential snapshot is sent full
+// snapshots until one succeeds.
+type differentialSnapshotTracker struct {
+	mu       sync.Mutex
+	inflight map[uint64]bool
+	failed   map[uint64]bool
+}
+
+func (d *differentialSnapshotTracker) allowed(id uint64) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return !d.failed[id]
+}
+
+func (d *differentialSnapshotTracker) sending(id uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.inflight == nil {
+		d.inflight = make(map[uint64]bool)
+	}
+	d.inflight[id] = true
+}
+
+func (d *differentialSnapshotTracker) report(id uint64, status raft.SnapshotStatus) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	differential := d.inflight[id]
+	delete(d.inflight, id)
+	switch {
+	case status == raft.SnapshotFailure && differential:
+		if d.failed == nil {
+			d.failed = make(map[uint64]bool)
+		}
+		d.failed[id] = true
+	case status == raft.SnapshotFinish && !differential:
+		delete(d.failed, id)
+	}
+}
+
+// sendDifferentialSnapshot sends the snapshot requested by m as a
+// differential snapshot if the follower has the key revisions up to the
+// leader's compaction revision, and else as a full snapshot.
+func (s *EtcdServer) sendDifferentialSnapshot(m raftpb.Message, snapt, snapi uint64, confState raftpb.ConfState) {
+	lg := s.Logger()
+	m, dbsnap := s.createMergedSnapshot(m, snapt, snapi, confState)
+
+	// fetching the status of the follower must not block the apply loop
+	go func() {
+		merged, err := s.createDifferentialSnapshotMessage(m, dbsnap)
+		if err != nil {
+			lg.Info(
+				"sending full snapshot instead of differential snapshot",
+				zap.String("remote-peer-id", types.ID(m.To).String()),
+				zap.Uint64("snapshot-index", snapi),
+				zap.Error(err),
+			)
+			snapshotSends.WithLabelValues("full").Inc()
+			merged = snap.NewMessage(m, newSnapshotReaderCloser(lg, dbsnap), dbsnap.Size())
+		} else {
+			snapshotSends.WithLabelValues("differential").Inc()
+			if err = dbsnap.Close(); err != nil {
+				lg.Panic("failed to close database snapshot", zap.Error(err))
+			}
+		}
+		s.sendMergedSnap(*merged)
+	}()
+}
+
+// createDifferentialSnapshotMessage creates a snapshot message holding the
+// differential snapshot of dbsnap for the follower m is sent to. It does not
+// close dbsnap.
+func (s *EtcdServer) createDifferentialSnapshotMessage(m raftpb.Message, dbsnap backend.Snapshot) (*snap.Message, error) {
+	if !s.differentialSnapshots.allowed(m.To) {
+		return nil, errDifferentialSnapshotFailedBefore
+	}
+	member := s.cluster.Member(types.ID(m.To))
+	if member == nil {
+		return nil, fmt.Errorf("member %s not found", types.ID(m.To))
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, differentialSnapshotStatusTimeout)
+	status, err := getBackendStatusHTTP(ctx, s.cluster.ID(), member, s.peerRt)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backend status: %w", err)
+	}
+	compactRev, err := snapshotCompactRevision(dbsnap)
+	if err != nil {
+		return nil, err
+	}
+	if status.Revision < compactRev {
+		return nil, fmt.Errorf("follower revision %d is below the compaction revision %d", status.Revision, compactRev)
+	}
+
+	f, err := os.CreateTemp(s.Cfg.SnapDir(), "db.tmp.differential")
+	if err != nil {
+		return nil, err
+	}
+	rc := &tempFileReadCloser{f}
+	n, err := writeDifferentialSnapshot(f, dbsnap, status.Revision, dbsnap.Size()/2)
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+
+	s.Logger().Info(
+		"created differential snapshot",
+		zap.String("remote-peer-id", types.ID(m.To).String()),
+		zap.Uint64("snapshot-index", m.Snapshot.Metadata.Index),
+		zap.Uint64("remote-peer-consistent-index", status.ConsistentIndex),
+		zap.Int64("base-revision", status.Revision),
+		zap.Int64("bytes", n),
+		zap.String("size", humanize.Bytes(uint64(n))),
+		zap.String("database-size", humanize.Bytes(uint64(dbsnap.Size()))),
+	)
+	merged := snap.NewMessage(m, rc, n)
+	merged.Differential = true
+	s.differentialSnapshots.sending(m.To)
+	return merged, nil
+}
+
+// snapshotCompactRevision returns the revision the database of dbsnap is, or
+// is being, compacted at.
+func snapshotCompactRevision(dbsnap backend.Snapshot) (int64, error) {
+	var rev int64
+	err := dbsnap.ForEachFrom(schema.Meta, nil, func(k, v []byte) error {
+		if bytes.Equal(k, schema.ScheduledCompactKeyName) || bytes.Equal(k, schema.FinishedCompactKeyName) {
+			rev = max(rev, mvcc.BytesToRev(v).Main)
+		}
+		return nil
+	})
+	return rev, err
+}
+
+// tempFileReadCloser removes the file once closed.
+type tempFileReadCloser struct {
+	*os.File
+}
+
+func (f *tempFileReadCloser) Close() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
+
+// differentialSnapshotWriter writes differential snapshot records, failing
+// once more than limit bytes are written.
+type differentialSnapshotWriter struct {
+	w     *bufio.Writer
+	h     hash.Hash
+	buf   []byte
+	n     int64
+	limit int64
+}
+
+func (w *differentialSnapshotWriter) write(b []byte) error {
+	w.n += int64(len(b))
+	if w.n > w.limit {
+		return errDifferentialSnapshotTooLarge
+	}
+	w.h.Write(b)
+	_, err := w.w.Write(b)
+	return err
+}
+
+func (w *differentialSnapshotWriter) record(typ byte, strs ...[]byte) error {
+	w.buf = append(w.buf[:0], typ)
+	for _, s := range strs {
+		w.buf = binary.AppendUvarint(w.buf, uint64(len(s)))
+		w.buf = append(w.buf, s...)
+	}
+	return w.write(w.buf)
+}
+
+// writeDifferentialSnapshot writes the differential snapshot of dbsnap for a
+// follower at the base revision to w. It returns the number of bytes written.
+func writeDifferentialSnapshot(w io.Writer, dbsnap backend.Snapshot, base int64, limit int64) (int64, error) {
+	dw := &differentialSnapshotWriter{w: bufio.NewWriter(w), h: sha256.New(), limit: limit}
+	if err := dw.write(binary.AppendUvarint(append([]byte{}, differentialSnapshotMagic...), uint64(base))); err != nil {
+		return dw.n, err
+	}
+	for _, b := range differentialSnapshotBuckets {
+		var from []byte
+		flags := differentialBucketExists
+		switch b.ID() {
+		case schema.Key.ID():
+			from = mvcc.RevToBytes(mvcc.Revision{Main: base + 1}, mvcc.NewRevBytes())
+		case schema.Encryption.ID():
+			flags |= differentialBucketMerge
+		}
+		started := false
+		err := dbsnap.ForEachFrom(b, from, func(k, v []byte) error {
+			if !started {
+				started = true
+				if err := dw.record(differentialRecordBucket, b.Name(), []byte{flags}, from); err != nil {
+					return err
+				}
+			}
+			return dw.record(differentialRecordPut, k, v)
+		})
+		if errors.Is(err, backend.ErrBucketNotFound) {
+			flags &^= differentialBucketExists
+			err = nil
+		}
+		if err == nil && !started {
+			err = dw.record(differentialRecordBucket, b.Name(), []byte{flags}, from)
+		}
+		if err != nil {
+			return dw.n, err
+		}
+	}
+	if err := dw.write([]byte{differentialRecordEnd}); err != nil {
+		return dw.n, err
+	}
+	sum := dw.h.Sum(nil)
+	dw.n += int64(len(sum))
+	if _, err := dw.w.Write(sum); err != nil {
+		return dw.n, err
+	}
+	return dw.n, dw.w.Flush()
+}
+
+// differentialSnapshotReader reads differential snapshot records, hashing
+// the bytes read.
+type differentialSnapshotReader struct {
+	r *bufio.Reader
+	h hash.Hash
+	n int64
+}
+
+func (r *differentialSnapshotReader) ReadByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err != nil {
+		return 0, noEOF(err)
+	}
+	r.h.Write([]byte{b})
+	r.n++
+	return b, nil
+}
+
+func (r *differentialSnapshotReader) readFull(b []byte) error {
+	n, err := io.ReadFull(r.r, b)
+	r.n += int64(n)
+	if err != nil {
+		return noEOF(err)
+	}
+	r.h.Write(b)
+	return nil
+}
+
+func (r *differentialSnapshotReader) readBytes() ([]byte, error) {
+	l, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if l > math.MaxInt32 {
+		return nil, errDifferentialSnapshotCorrupted
+	}
+	b := make([]byte, l)
+	return b, r.readFull(b)
+}
+
+// noEOF turns io.EOF into io.ErrUnexpectedEOF, since a differential
+// snapshot ends with an end record.
+func noEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// SaveDBFromDifferential saves the database snapshot at the given index
+// built by applying the differential snapshot read from r to a copy of the
+// member's database. It returns the number of bytes read from r.
+func (s *EtcdServer) SaveDBFromDifferential(r io.Reader, index uint64) (int64, error) {
+	lg := s.Logger()
+	dr := &differentialSnapshotReader{r: bufio.NewReader(r), h: sha256.New()}
+	magic := make([]byte, len(differentialSnapshotMagic))
+	if err := dr.readFull(magic); err != nil {
+		return dr.n, err
+	}
+	if !bytes.Equal(magic, differentialSnapshotMagic) {
+		return dr.n, errDifferentialSnapshotCorrupted
+	}
+	base, err := binary.ReadUvarint(dr)
+	if err != nil {
+		return dr.n, err
+	}
+	// the member's revisions only grow, so its copy has those up to base
+	if rev := s.KV().Rev(); rev < int64(base) {
+		return dr.n, fmt.Errorf("differential snapshot base revision %d is above the revision %d", base, rev)
+	}
+
+	f, err := os.CreateTemp(s.Cfg.SnapDir(), "db.tmp.differential")
+	if err != nil {
+		return dr.n, err
+	}
+	path := f.Name()
+	dbsnap := s.be.Snapshot()
+	_, err = dbsnap.WriteTo(f)
+	if cerr := dbsnap.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = applyDifferentialSnapshot(lg, path, dr, index)
+	}
+	if err != nil {
+		os.Remove(path)
+		return dr.n, err
+	}
+	if err = s.snapshotter.SaveDBFile(path, index); err != nil {
+		os.Remove(path)
+		return dr.n, err
+	}
+	lg.Info(
+		"applied differential snapshot",
+		zap.Uint64("snapshot-index", index),
+		zap.Uint64("base-revision", base),
+		zap.Int64("bytes", dr.n),
+		zap.String("size", humanize.Bytes(uint64(dr.n))),
+	)
+	return dr.n, nil
+}
+
+// applyDifferentialSnapshot applies the records read from dr to the database
+// at path, a copy of the member's database.
+func applyDifferentialSnapshot(lg *zap.Logger, path string, dr *differentialSnapshotReader, index uint64) (err error) {
+	be := backend.NewDefaultBackend(lg, path)
+	defer func() {
+		if cerr := be.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	buckets := make(map[string]backend.Bucket)
+	for _, b := range differentialSnapshotBuckets {
+		buckets[string(b.Name())] = b
+	}
+	tx := be.BatchTx()
+	tx.LockOutsideApply()
+	finished, finishedFound := mvcc.UnsafeReadFinishedCompact(tx)
+	tx.Unlock()
+
+	var bucket backend.Bucket
+	for {
+		typ, err := dr.ReadByte()
+		if err != nil {
+			return err
+		}
+		switch typ {
+		case differentialRecordBucket:
+			if bucket, err = readDifferentialBucket(dr, tx, buckets); err != nil {
+				return err
+			}
+		case differentialRecordPut:
+			if bucket == nil {
+				return errDifferentialSnapshotCorrupted
+			}
+			k, err := dr.readBytes()
+			if err != nil {
+				return err
+			}
+			v, err := dr.readBytes()
+			if err != nil {
+				return err
+			}
+			tx.LockOutsideApply()
+			tx.UnsafePut(bucket, k, v)
+			tx.Unlock()
+		case differentialRecordEnd:
+			sum := dr.h.Sum(nil)
+			got := make([]byte, len(sum))
+			if _, err = io.ReadFull(dr.r, got); err != nil {
+				return noEOF(err)
+			}
+			dr.n += int64(len(got))
+			if !bytes.Equal(sum, got) {
+				return errDifferentialSnapshotCorrupted
+			}
+			return finishDifferentialSnapshot(be, finished, finishedFound, index)
+		default:
+			return errDifferentialSnapshotCorrupted
+		}
+	}
+}
+
+// readDifferentialBucket reads a bucket record, removing the keys of the
+// bucket it replaces. It returns the bucket, or nil if the leader has none.
+func readDifferentialBucket(dr *differentialSnapshotReader, tx backend.BatchTx, buckets map[string]backend.Bucket) (backend.Bucket, error) {
+	name, err := dr.readBytes()
+	if err != nil {
+		return nil, err
+	}
+	flags, err := dr.readBytes()
+	if err != nil {
+		return nil, err
+	}
+	from, err := dr.readBytes()
+	if err != nil {
+		return nil, err
+	}
+	b, ok := buckets[string(name)]
+	if !ok || len(flags) != 1 || len(from) != 0 && b.ID() != schema.Key.ID() {
+		return nil, errDifferentialSnapshotCorrupted
+	}
+
+	tx.LockOutsideApply()
+	defer tx.Unlock()
+	switch {
+	case flags[0]&differentialBucketMerge != 0:
+		if flags[0]&differentialBucketExists != 0 {
+			tx.UnsafeCreateBucket(b)
+		}
+	case len(from) == 0:
+		tx.UnsafeDeleteBucket(b)
+		if flags[0]&differentialBucketExists != 0 {
+			tx.UnsafeCreateBucket(b)
+		}
+	default:
+		// revision keys start with a positive big-endian int64
+		keys, _ := tx.UnsafeRange(b, from, []byte{0xff}, 0)
+		for _, k := range keys {
+			tx.UnsafeDelete(b, k)
+		}
+	}
+	if flags[0]&differentialBucketExists == 0 {
+		return nil, nil
+	}
+	return b, nil
+}
+
+// finishDifferentialSnapshot checks the rebuilt database is at the given
+// index, and has the compaction up to the leader's compaction revision
+// resumed once the database is restored, since the member's revisions below
+// the base revision were only compacted up to finished, if found.
+func finishDifferentialSnapshot(be backend.Backend, finished int64, finishedFound bool, index uint64) error {
+	tx := be.BatchTx()
+	tx.LockOutsideApply()
+	defer tx.Unlock()
+	if ci, _ := schema.UnsafeReadConsistentIndex(tx); ci != index {
+		return fmt.Errorf("differential snapshot consistent index %d does not match snapshot index %d", ci, index)
+	}
+	leaderFinished, _ := mvcc.UnsafeReadFinishedCompact(tx)
+	scheduled, _ := mvcc.UnsafeReadScheduledCompact(tx)
+	scheduled = max(scheduled, leaderFinished)
+	if finished > scheduled {
+		return fmt.Errorf("member compaction revision %d is above the leader's compaction revision %d", finished, scheduled)
+	}
+	if finishedFound {
+		mvcc.UnsafeSetFinishedCompact(tx, finished)
+	} else {
+		tx.UnsafeDelete(schema.Meta, schema.FinishedCompactKeyName)
+	}
+	if scheduled > 0 {
+		mvcc.UnsafeSetScheduledCompact(tx, scheduled)
+	}
+	return nil
+}
@@ -29,6 +29,15 @@ import (
 // a snapshot of v2 store inside raft.Snapshot as []byte, a snapshot of v3 KV in the top level message
 // as ReadCloser.
 func (s *EtcdServer) createMergedSnapshotMessage(m raftpb.Message, snapt, snapi uint64, confState raftpb.ConfState) snap.Message {
+	m, dbsnap := s.createMergedSnapshot(m, snapt, snapi, confState)
+	// get a snapshot of v3 KV as readCloser
+	rc := newSnapshotReaderCloser(s.Logger(), dbsnap)
+	return *snap.NewMessage(m, rc, dbsnap.Size())
+}
+
+// createMergedSnapshot returns m with the raft snapshot at the given index,
+// along with the snapshot of v3 KV at that index, which the caller must close.
+func (s *EtcdServer) createMergedSnapshot(m raftpb.Message, snapt, snapi uint64, confState raftpb.ConfState) (raftpb.Message, backend.Snapshot) {
 	lg := s.Logger()
 	// get a snapshot of v2 store as []byte
 	d := GetMembershipInfoInV2Format(lg, s.cluster)
@@ -36,8 +45,6 @@ func (s *EtcdServer) createMergedSnapshotMessage(m raftpb.Message, snapt, snapi
 	// commit kv to write metadata(for example: consistent index).
 	s.KV().Commit()
 	dbsnap := s.be.Snapshot()
-	// get a snapshot of v3 KV as readCloser
-	rc := newSnapshotReaderCloser(lg, dbsnap)
 
 	// put the []byte snapshot of store into raft snapshot and return the merged snapshot with
 	// KV readCloser snapshot.
@@ -53,7 +60,7 @@ func (s *EtcdServer) createMergedSnapshotMessage(m raftpb.Message, snapt, snapi
 
 	verifySnapshotIndex(snapshot, s.consistIndex.ConsistentIndex())
 
-	return *snap.NewMessage(m, rc, dbsnap.Size())
+	return m, dbsnap
 }
 
 func newSnapshotReaderCloser(lg *zap.Logger, snapshot backend.Snapshot) io.ReadCloser {
@@ -0,0 +1,126 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/version"
+	"go.etcd.io/etcd/server/v3/etcdserver/txn"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+)
+
+// trashPurgeMaxInterval is the maximum time between two checks for keys to
+// purge from the trash.
+var trashPurgeMaxInterval = time.Minute
+
+func (s *EtcdServer) softDeleteConfig() txn.SoftDeleteConfig {
+	return txn.SoftDeleteConfig{
+		Threshold:   s.Cfg.ExperimentalSoftDeleteThreshold,
+		Prefixes:    s.Cfg.ExperimentalSoftDeletePrefixes,
+		TrashPrefix: s.Cfg.ExperimentalSoftDeleteTrashPrefix,
+	}
+}
+
+// softDeleteRequest returns the soft delete configuration to propose the
+// deletions with, or nil if it is not enabled. The members apply the
+// deletions with the configuration of the member proposing them, once all of
+// them run a version moving the keys to the trash.
+func (s *EtcdServer) softDeleteRequest() *pb.SoftDelete {
+	cfg := s.softDeleteConfig()
+	if !cfg.Enabled() {
+		return nil
+	}
+	if cv := s.ClusterVersion(); cv == nil || cv.LessThan(version.V3_6) {
+		return nil
+	}
+	return &pb.SoftDelete{
+		Threshold:   int64(cfg.Threshold),
+		Prefixes:    cfg.Prefixes,
+		TrashPrefix: cfg.TrashPrefix,
+	}
+}
+
+// revSample is the revision of the keyspace at some time.
+type revSample struct {
+	time time.Time
+	rev  int64
+}
+
+// purgeTrash periodically samples the revision of the keyspace and, if it's
+// the leader, deletes the keys moved to the trash at or before the latest
+// revision sampled longer than the retention ago. Since the samples are not
+// persisted, the keys in the trash are kept up to the retention longer when
+// a new leader is elected.
+func (s *EtcdServer) purgeTrash() {
+	cfg := s.softDeleteConfig()
+	if !cfg.Enabled() {
+		return
+	}
+	lg := s.Logger()
+	retention := s.Cfg.ExperimentalSoftDeleteRetention
+	interval := min(retention/2, trashPurgeMaxInterval)
+	var samples []revSample
+	for {
+		samples = append(samples, revSample{time: time.Now(), rev: s.KV().Rev()})
+		select {
+		case <-time.After(interval):
+		case <-s.stopping:
+			lg.Info("server has stopped; stopping trash purge")
+			return
+		}
+
+		i := 0
+		for i < len(samples) && time.Since(samples[i].time) >= retention {
+			i++
+		}
+		if i == 0 {
+			continue
+		}
+		rev := samples[i-1].rev
+		if !s.isLeader() {
+			samples = samples[i-1:]
+			continue
+		}
+		if err := s.purgeTrashUntil(cfg, rev); err != nil {
+			lg.Warn("failed to purge trash", zap.Int64("revision", rev), zap.Error(err))
+			continue
+		}
+		samples = samples[i:]
+	}
+}
+
+// purgeTrashUntil deletes the keys moved to the trash at or before rev.
+func (s *EtcdServer) purgeTrashUntil(cfg txn.SoftDeleteConfig, rev int64) error {
+	start, end := cfg.TrashKey(0, nil), cfg.TrashKey(rev+1, nil)
+	rr, err := s.KV().Range(context.TODO(), start, end, mvcc.RangeOptions{Count: true})
+	if err != nil || rr.Count == 0 {
+		return err
+	}
+	// the keys in the trash are purged on behalf of root, like the keys
+	// attached to expired leases, so that it works with auth enabled
+	ctx, cancel := context.WithTimeout(s.authStore.WithRoot(s.ctx), s.Cfg.ReqTimeout())
+	defer cancel()
+	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{DeleteRange: &pb.DeleteRangeRequest{Key: start, RangeEnd: end}})
+	if err != nil {
+		return err
+	}
+	s.Logger().Info("purged trash", zap.Int64("revision", rev), zap.Int64("deleted", resp.(*pb.DeleteRangeResponse).Deleted))
+	return nil
+}
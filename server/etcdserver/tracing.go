@@ -0,0 +1,83 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	apply2 "go.etcd.io/etcd/server/v3/etcdserver/apply"
+)
+
+const tracerName = "go.etcd.io/etcd/server/v3/etcdserver"
+
+// SlowRequestAttributeKey marks the root spans of the requests traced for
+// being slower than the slow request threshold, so that the sampler can
+// sample them although their gRPC span was not.
+const SlowRequestAttributeKey = attribute.Key("etcd.slow_request")
+
+// traceRaftRequest emits the spans of the phases of a raft request started
+// at start and proposed at proposed: proposing it, waiting for it to be
+// committed and scheduled, and applying it, with a span per step of the
+// apply trace. The spans are children of the span of ctx if it's sampled.
+// Otherwise, if the request was slower than the slow request threshold,
+// they are emitted as a new trace linked to it.
+func (s *EtcdServer) traceRaftRequest(ctx context.Context, start, proposed time.Time, ar *apply2.Result) {
+	if !s.Cfg.ExperimentalEnableDistributedTracing {
+		return
+	}
+	end := time.Now()
+	parent := trace.SpanFromContext(ctx)
+	opts := []trace.SpanStartOption{trace.WithTimestamp(start)}
+	if !parent.SpanContext().IsSampled() {
+		threshold := s.Cfg.ExperimentalDistributedTracingSlowRequestThreshold
+		if threshold <= 0 || end.Sub(start) < threshold {
+			return
+		}
+		opts = append(opts,
+			trace.WithNewRoot(),
+			trace.WithLinks(trace.Link{SpanContext: parent.SpanContext()}),
+			trace.WithAttributes(SlowRequestAttributeKey.Bool(true)),
+		)
+	}
+	tracer := parent.TracerProvider().Tracer(tracerName)
+
+	ctx, span := tracer.Start(ctx, "raft request", opts...)
+	defer span.End(trace.WithTimestamp(end))
+	_, propose := tracer.Start(ctx, "propose", trace.WithTimestamp(start))
+	propose.End(trace.WithTimestamp(proposed))
+	if ar == nil || ar.ApplyStart.IsZero() {
+		return
+	}
+	_, wait := tracer.Start(ctx, "wait for commit", trace.WithTimestamp(proposed))
+	wait.End(trace.WithTimestamp(ar.ApplyStart))
+
+	actx, apply := tracer.Start(ctx, "apply", trace.WithTimestamp(ar.ApplyStart))
+	if ar.Trace != nil {
+		last := ar.Trace.GetStartTime()
+		ar.Trace.EachStep(func(at time.Time, msg string) {
+			_, step := tracer.Start(actx, msg, trace.WithTimestamp(last))
+			step.End(trace.WithTimestamp(at))
+			last = at
+		})
+	}
+	if ar.Err != nil {
+		apply.RecordError(ar.Err)
+	}
+	apply.End(trace.WithTimestamp(ar.ApplyEnd))
+}
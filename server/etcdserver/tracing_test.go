@@ -0,0 +1,80 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"go.etcd.io/etcd/pkg/v3/traceutil"
+	"go.etcd.io/etcd/server/v3/config"
+	apply2 "go.etcd.io/etcd/server/v3/etcdserver/apply"
+)
+
+func TestTraceRaftRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		sampler   tracesdk.Sampler
+		threshold time.Duration
+		wantSpans []string
+	}{
+		{
+			name:      "sampled",
+			sampler:   tracesdk.AlwaysSample(),
+			wantSpans: []string{"propose", "wait for commit", "acquire backend batch tx lock", "store kv pair into bolt db", "apply", "raft request", "grpc"},
+		},
+		{
+			name:      "not sampled",
+			sampler:   tracesdk.NeverSample(),
+			wantSpans: nil,
+		},
+		{
+			name:      "not sampled and faster than threshold",
+			sampler:   tracesdk.NeverSample(),
+			threshold: time.Hour,
+			wantSpans: nil,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			tp := tracesdk.NewTracerProvider(tracesdk.WithSampler(tracesdk.ParentBased(tc.sampler)), tracesdk.WithSpanProcessor(sr))
+			s := &EtcdServer{Cfg: config.ServerConfig{
+				ExperimentalEnableDistributedTracing:               true,
+				ExperimentalDistributedTracingSlowRequestThreshold: tc.threshold,
+			}}
+
+			ctx, span := tp.Tracer("test").Start(context.Background(), "grpc")
+			start := time.Now()
+			tr := traceutil.New("put", nil)
+			tr.Step("acquire backend batch tx lock")
+			tr.Step("store kv pair into bolt db")
+			ar := &apply2.Result{Trace: tr, ApplyStart: start.Add(time.Millisecond), ApplyEnd: time.Now()}
+			s.traceRaftRequest(ctx, start, start.Add(time.Millisecond), ar)
+			span.End()
+
+			var names []string
+			for _, s := range sr.Ended() {
+				names = append(names, s.Name())
+			}
+			assert.Equal(t, tc.wantSpans, names)
+		})
+	}
+}
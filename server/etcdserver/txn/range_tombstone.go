@@ -0,0 +1,33 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txn
+
+import "context"
+
+type rangeTombstoneKey struct{}
+
+// WithRangeTombstoneThreshold returns a context carrying the range tombstone
+// threshold of the request being applied, the number of keys above which its
+// deletions record a single range tombstone. Zero disables range tombstones.
+func WithRangeTombstoneThreshold(ctx context.Context, threshold int) context.Context {
+	return context.WithValue(ctx, rangeTombstoneKey{}, threshold)
+}
+
+// RangeTombstoneThresholdFromContext returns the range tombstone threshold
+// carried by ctx.
+func RangeTombstoneThresholdFromContext(ctx context.Context) int {
+	threshold, _ := ctx.Value(rangeTombstoneKey{}).(int)
+	return threshold
+}
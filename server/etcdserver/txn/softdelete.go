@@ -0,0 +1,174 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txn
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"go.etcd.io/etcd/pkg/v3/traceutil"
+	"go.etcd.io/etcd/server/v3/lease"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+)
+
+// SoftDeleteConfig configures the deletions moving the keys to the trash.
+type SoftDeleteConfig struct {
+	// Threshold, if not zero, is the number of keys above which a
+	// DeleteRange moves the keys to the trash.
+	Threshold int
+	// Prefixes are the key prefixes whose keys are always moved to the trash
+	// when deleted.
+	Prefixes []string
+	// TrashPrefix is the prefix of the keys in the trash.
+	TrashPrefix string
+}
+
+// Enabled returns whether any deletion may move the keys to the trash.
+func (c SoftDeleteConfig) Enabled() bool {
+	return c.Threshold > 0 || len(c.Prefixes) > 0
+}
+
+// TrashKey returns the key in the trash of the key deleted at revision rev.
+// The trash keys sort by the revision of the deletion.
+func (c SoftDeleteConfig) TrashKey(rev int64, key []byte) []byte {
+	return append([]byte(fmt.Sprintf("%s%016x/", c.TrashPrefix, rev)), key...)
+}
+
+type softDeleteKey struct{}
+
+// WithSoftDelete returns a context carrying the soft delete configuration of
+// the request being applied. A configuration that is not enabled makes the
+// request delete the keys without moving them to the trash.
+func WithSoftDelete(ctx context.Context, cfg SoftDeleteConfig) context.Context {
+	return context.WithValue(ctx, softDeleteKey{}, cfg)
+}
+
+// SoftDeleteFromContext returns the soft delete configuration carried by ctx.
+func SoftDeleteFromContext(ctx context.Context) SoftDeleteConfig {
+	cfg, _ := ctx.Value(softDeleteKey{}).(SoftDeleteConfig)
+	return cfg
+}
+
+// NewSoftDeleteKV returns a KV whose deletions move the deleted keys, with
+// their values but without their leases, to the trash when they delete more
+// than the threshold number of keys or any key under one of the prefixes.
+// The keys in the trash are only deleted by deletions within the trash
+// prefix, which no other deletion deletes.
+func NewSoftDeleteKV(kv mvcc.KV, cfg SoftDeleteConfig) mvcc.KV {
+	return &softDeleteKV{KV: kv, cfg: cfg}
+}
+
+type softDeleteKV struct {
+	mvcc.KV
+	cfg SoftDeleteConfig
+}
+
+func (kv *softDeleteKV) Write(trace *traceutil.Trace) mvcc.TxnWrite {
+	return &softDeleteTxnWrite{TxnWrite: kv.KV.Write(trace), cfg: kv.cfg}
+}
+
+func (kv *softDeleteKV) DeleteRange(key, end []byte) (n, rev int64) {
+	tw := kv.Write(traceutil.TODO())
+	defer tw.End()
+	return tw.DeleteRange(key, end)
+}
+
+type softDeleteTxnWrite struct {
+	mvcc.TxnWrite
+	cfg SoftDeleteConfig
+}
+
+func (tw *softDeleteTxnWrite) DeleteRange(key, end []byte) (n, rev int64) {
+	trash := []byte(tw.cfg.TrashPrefix)
+	trashEnd := PrefixEnd(trash)
+	if bytes.HasPrefix(key, trash) && (end == nil || len(end) != 0 && bytes.Compare(end, trashEnd) <= 0) {
+		return tw.TxnWrite.DeleteRange(key, end)
+	}
+
+	// the ranges of the keys to delete, excluding the trash
+	var ranges [][2][]byte
+	switch {
+	case end == nil:
+		ranges = append(ranges, [2][]byte{key, nil})
+	default:
+		if bytes.Compare(key, trash) < 0 {
+			e := end
+			if len(end) == 0 || bytes.Compare(end, trash) > 0 {
+				e = trash
+			}
+			ranges = append(ranges, [2][]byte{key, e})
+		}
+		if len(end) == 0 || bytes.Compare(end, trashEnd) > 0 {
+			k := key
+			if bytes.Compare(key, trashEnd) < 0 {
+				k = trashEnd
+			}
+			ranges = append(ranges, [2][]byte{k, end})
+		}
+	}
+
+	var keys, values [][]byte
+	soft := false
+	for _, r := range ranges {
+		rr, err := tw.Range(context.TODO(), r[0], r[1], mvcc.RangeOptions{})
+		if err != nil {
+			// the range is at the current revision, which is never compacted
+			panic(fmt.Sprintf("unexpected error ranging the keys to delete: %v", err))
+		}
+		for _, kv := range rr.KVs {
+			keys = append(keys, kv.Key)
+			values = append(values, kv.Value)
+			soft = soft || tw.hasSoftDeletePrefix(kv.Key)
+		}
+	}
+	soft = soft || tw.cfg.Threshold > 0 && len(keys) > tw.cfg.Threshold
+	if soft {
+		trashRev := tw.Rev() + 1
+		for i, k := range keys {
+			tw.TxnWrite.Put(tw.cfg.TrashKey(trashRev, k), values[i], lease.NoLease)
+		}
+	}
+
+	rev = tw.Rev()
+	for _, r := range ranges {
+		var dn int64
+		dn, rev = tw.TxnWrite.DeleteRange(r[0], r[1])
+		n += dn
+	}
+	return n, rev
+}
+
+func (tw *softDeleteTxnWrite) hasSoftDeletePrefix(key []byte) bool {
+	for _, p := range tw.cfg.Prefixes {
+		if bytes.HasPrefix(key, []byte(p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// PrefixEnd returns the end of the range of the keys with the prefix.
+func PrefixEnd(prefix []byte) []byte {
+	end := bytes.Clone(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// the prefix is all 0xff, the range is unbounded
+	return []byte{}
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txn
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/lease"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+)
+
+func TestSoftDeleteRange(t *testing.T) {
+	cfg := SoftDeleteConfig{Threshold: 2, Prefixes: []string{"p/"}, TrashPrefix: "/trash/"}
+	tcs := []struct {
+		name      string
+		key, end  string
+		wantKeys  []string
+		wantTrash []string
+	}{
+		{
+			name:      "below threshold",
+			key:       "a",
+			end:       "c",
+			wantKeys:  []string{"c", "p/x"},
+			wantTrash: []string{"/trash/0000000000000001/x"},
+		},
+		{
+			name:      "above threshold",
+			key:       "a",
+			end:       "d",
+			wantKeys:  []string{"p/x"},
+			wantTrash: []string{"/trash/0000000000000001/x", "/trash/0000000000000007/a", "/trash/0000000000000007/b", "/trash/0000000000000007/c"},
+		},
+		{
+			name:      "prefix",
+			key:       "p/x",
+			wantKeys:  []string{"a", "b", "c"},
+			wantTrash: []string{"/trash/0000000000000001/x", "/trash/0000000000000007/p/x"},
+		},
+		{
+			name:     "all keys but the trash",
+			key:      "\x00",
+			end:      "\x00",
+			wantKeys: nil,
+			wantTrash: []string{
+				"/trash/0000000000000001/x",
+				"/trash/0000000000000007/a",
+				"/trash/0000000000000007/b",
+				"/trash/0000000000000007/c",
+				"/trash/0000000000000007/p/x",
+			},
+		},
+		{
+			name:     "trash",
+			key:      "/trash/",
+			end:      "/trash0",
+			wantKeys: []string{"a", "b", "c", "p/x"},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			b, _ := betesting.NewDefaultTmpBackend(t)
+			defer betesting.Close(t, b)
+			s := mvcc.NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, mvcc.StoreConfig{})
+			defer s.Close()
+			kv := NewSoftDeleteKV(s, cfg)
+
+			kv.Put(cfg.TrashKey(1, []byte("x")), []byte("v"), lease.NoLease)
+			for _, k := range []string{"a", "b", "c", "p/x"} {
+				kv.Put([]byte(k), []byte("v"), 1)
+			}
+
+			dr := &pb.DeleteRangeRequest{Key: []byte(tc.key)}
+			if tc.end != "" {
+				dr.RangeEnd = []byte(tc.end)
+			}
+			resp, _, err := DeleteRange(context.TODO(), zaptest.NewLogger(t), kv, dr)
+			require.NoError(t, err)
+			assert.Equal(t, int64(7), resp.Header.Revision)
+
+			rr, err := kv.Range(context.TODO(), []byte("\x00"), []byte{}, mvcc.RangeOptions{})
+			require.NoError(t, err)
+			var keys, trash []string
+			for _, kv := range rr.KVs {
+				if strings.HasPrefix(string(kv.Key), cfg.TrashPrefix) {
+					trash = append(trash, string(kv.Key))
+					assert.Equal(t, int64(0), kv.Lease)
+					continue
+				}
+				keys = append(keys, string(kv.Key))
+			}
+			assert.Equal(t, tc.wantKeys, keys)
+			assert.Equal(t, tc.wantTrash, trash)
+		})
+	}
+}
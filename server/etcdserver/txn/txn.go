@@ -168,9 +168,14 @@ func executeRange(ctx context.Context, lg *zap.Logger, txnRead mvcc.TxnRead, r *
 	}
 
 	ro := mvcc.RangeOptions{
-		Limit: limit,
-		Rev:   r.Revision,
-		Count: r.CountOnly,
+		Limit:   limit,
+		Rev:     r.Revision,
+		Count:   r.CountOnly || len(r.GroupBySeparator) != 0,
+		GroupBy: r.GroupBySeparator,
+	}
+	if len(r.GroupBySeparator) != 0 {
+		// the groups are limited while counted
+		ro.Limit, ro.GroupFrom = r.Limit, r.GroupFromKey
 	}
 
 	rr, err := txnRead.Range(ctx, r.Key, mkGteRange(r.RangeEnd), ro)
@@ -235,6 +240,14 @@ func executeRange(ctx context.Context, lg *zap.Logger, txnRead mvcc.TxnRead, r *
 		rr.KVs = rr.KVs[:r.Limit]
 		resp.More = true
 	}
+	if len(r.GroupBySeparator) != 0 {
+		resp.GroupCounts = make([]*pb.KeyGroupCount, len(rr.Groups))
+		for i, g := range rr.Groups {
+			resp.GroupCounts[i] = &pb.KeyGroupCount{Prefix: g.Prefix, Count: int64(g.Count)}
+		}
+		resp.NextGroupKey = rr.NextGroupKey
+		resp.More = len(rr.NextGroupKey) != 0
+	}
 	trace.Step("filter and sort the key-value pairs")
 	resp.Header.Revision = rr.Rev
 	resp.Count = int64(rr.Count)
@@ -666,6 +679,22 @@ func IsTxnReadonly(r *pb.TxnRequest) bool {
 	return true
 }
 
+// HasTxnRange returns true if the txn, or any txn nested in it, holds a
+// range request.
+func HasTxnRange(r *pb.TxnRequest) bool {
+	for _, reqs := range [][]*pb.RequestOp{r.Success, r.Failure} {
+		for _, u := range reqs {
+			if u.GetRequestRange() != nil {
+				return true
+			}
+			if t := u.GetRequestTxn(); t != nil && HasTxnRange(t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func CheckTxnAuth(as auth.AuthStore, ai *auth.AuthInfo, rt *pb.TxnRequest) error {
 	for _, c := range rt.Compare {
 		if err := as.IsRangePermitted(ai, c.Key, c.RangeEnd); err != nil {
@@ -19,18 +19,23 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/binary"
+	"slices"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/gogo/protobuf/proto"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/metadata"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/api/v3/version"
 	"go.etcd.io/etcd/pkg/v3/traceutil"
 	"go.etcd.io/etcd/server/v3/auth"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3admission"
 	apply2 "go.etcd.io/etcd/server/v3/etcdserver/apply"
 	"go.etcd.io/etcd/server/v3/etcdserver/errors"
 	"go.etcd.io/etcd/server/v3/etcdserver/txn"
@@ -97,9 +102,13 @@ type Authenticator interface {
 	RoleDelete(ctx context.Context, r *pb.AuthRoleDeleteRequest) (*pb.AuthRoleDeleteResponse, error)
 	UserList(ctx context.Context, r *pb.AuthUserListRequest) (*pb.AuthUserListResponse, error)
 	RoleList(ctx context.Context, r *pb.AuthRoleListRequest) (*pb.AuthRoleListResponse, error)
+	CheckPermission(ctx context.Context, r *pb.AuthCheckPermissionRequest) (*pb.AuthCheckPermissionResponse, error)
 }
 
 func (s *EtcdServer) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeResponse, error) {
+	if s.IsQuarantined() {
+		return nil, errors.ErrQuarantined
+	}
 	trace := traceutil.New("range",
 		s.Logger(),
 		traceutil.Field{Key: "range_begin", Value: string(r.Key)},
@@ -121,10 +130,19 @@ func (s *EtcdServer) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeRe
 	}(time.Now())
 
 	if !r.Serializable {
-		err = s.linearizableReadNotify(ctx)
-		trace.Step("agreement among raft nodes before linearized reading")
-		if err != nil {
-			return nil, err
+		bounded := r.MaxStalenessMs > 0 || r.MaxStalenessRevisions > 0
+		if bounded && s.isReadFresh(time.Duration(r.MaxStalenessMs)*time.Millisecond, r.MaxStalenessRevisions) {
+			boundedStalenessReads.WithLabelValues("local").Inc()
+			trace.Step("local state within staleness bound")
+		} else {
+			if bounded {
+				boundedStalenessReads.WithLabelValues("leader").Inc()
+			}
+			err = s.linearizableReadNotify(ctx)
+			trace.Step("agreement among raft nodes before linearized reading")
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 	chk := func(ai *auth.AuthInfo) error {
@@ -136,27 +154,56 @@ func (s *EtcdServer) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeRe
 		err = serr
 		return nil, err
 	}
+	if err == nil && s.hotKeys != nil {
+		s.hotKeys.Range(r)
+	}
 	return resp, err
 }
 
 func (s *EtcdServer) Put(ctx context.Context, r *pb.PutRequest) (*pb.PutResponse, error) {
+	if err := s.checkProtected(ctx, v3admission.PutOps(r)); err != nil {
+		return nil, err
+	}
+	if s.Cfg.Admission != nil {
+		if err := s.admit(ctx, v3admission.PutOps(r)); err != nil {
+			return nil, err
+		}
+	}
 	ctx = context.WithValue(ctx, traceutil.StartTimeKey{}, time.Now())
 	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{Put: r})
 	if err != nil {
 		return nil, err
 	}
+	if s.hotKeys != nil {
+		s.hotKeys.Put(r)
+	}
 	return resp.(*pb.PutResponse), nil
 }
 
 func (s *EtcdServer) DeleteRange(ctx context.Context, r *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error) {
-	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{DeleteRange: r})
+	if err := s.checkProtected(ctx, v3admission.DeleteRangeOps(r)); err != nil {
+		return nil, err
+	}
+	if s.Cfg.Admission != nil {
+		if err := s.admit(ctx, v3admission.DeleteRangeOps(r)); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{DeleteRange: r, SoftDelete: s.softDeleteRequest(), RangeTombstoneThreshold: s.rangeTombstoneThreshold()})
 	if err != nil {
 		return nil, err
 	}
+	if s.hotKeys != nil {
+		s.hotKeys.DeleteRange(r)
+	}
 	return resp.(*pb.DeleteRangeResponse), nil
 }
 
 func (s *EtcdServer) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse, error) {
+	// the ranges of a write txn are also read from the local store
+	if s.IsQuarantined() && (txn.IsTxnReadonly(r) || txn.HasTxnRange(r)) {
+		return nil, errors.ErrQuarantined
+	}
 	if txn.IsTxnReadonly(r) {
 		trace := traceutil.New("transaction",
 			s.Logger(),
@@ -187,17 +234,49 @@ func (s *EtcdServer) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse
 		if serr := s.doSerialize(ctx, chk, get); serr != nil {
 			return nil, serr
 		}
+		if err == nil && s.hotKeys != nil {
+			s.hotKeys.Txn(r, resp)
+		}
 		return resp, err
 	}
 
+	if err := s.checkProtected(ctx, v3admission.TxnOps(r)); err != nil {
+		return nil, err
+	}
+	if s.Cfg.Admission != nil {
+		if err := s.admit(ctx, v3admission.TxnOps(r)); err != nil {
+			return nil, err
+		}
+	}
 	ctx = context.WithValue(ctx, traceutil.StartTimeKey{}, time.Now())
-	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{Txn: r})
+	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{Txn: r, SoftDelete: s.softDeleteRequest(), RangeTombstoneThreshold: s.rangeTombstoneThreshold()})
 	if err != nil {
 		return nil, err
 	}
+	if s.hotKeys != nil {
+		s.hotKeys.Txn(r, resp.(*pb.TxnResponse))
+	}
 	return resp.(*pb.TxnResponse), nil
 }
 
+// admit runs the admission controller on the mutations of a write request.
+func (s *EtcdServer) admit(ctx context.Context, ops []v3admission.Op) error {
+	if len(ops) == 0 {
+		return nil
+	}
+	if err := s.Cfg.Admission.Admit(ctx, ops); err != nil {
+		admissionRejections.Inc()
+		return &errors.AdmissionError{Err: err}
+	}
+	return nil
+}
+
+// MinPinnedRevision returns the lowest revision pinned against compaction by
+// a lease, or 0 if none is, so that the auto compactor stays below it.
+func (s *EtcdServer) MinPinnedRevision() int64 {
+	return s.lessor.MinPinnedRevision()
+}
+
 func (s *EtcdServer) Compact(ctx context.Context, r *pb.CompactionRequest) (*pb.CompactionResponse, error) {
 	startTime := time.Now()
 	result, err := s.processInternalRaftRequestOnce(ctx, pb.InternalRaftRequest{Compaction: r})
@@ -685,6 +764,37 @@ func (s *EtcdServer) RoleDelete(ctx context.Context, r *pb.AuthRoleDeleteRequest
 	return resp.(*pb.AuthRoleDeleteResponse), nil
 }
 
+// CheckPermission evaluates the permissions of a user on the local auth store
+// once it's up to date, and the protected prefixes of the member. Users can
+// check their own permissions, checking those of other users requires the
+// root role.
+func (s *EtcdServer) CheckPermission(ctx context.Context, r *pb.AuthCheckPermissionRequest) (*pb.AuthCheckPermissionResponse, error) {
+	if err := s.linearizableReadNotify(ctx); err != nil {
+		return nil, err
+	}
+	authInfo, err := s.AuthInfoFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if authInfo == nil || authInfo.Username != r.Name {
+		if err = s.AuthStore().IsAdminPermitted(authInfo); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := s.AuthStore().CheckPermission(r)
+	if err != nil {
+		return nil, err
+	}
+	s.checkPermissionProtected(r, resp)
+	resp.Header = &pb.ResponseHeader{
+		ClusterId: uint64(s.cluster.ID()),
+		MemberId:  uint64(s.MemberID()),
+		Revision:  s.KV().Rev(),
+		RaftTerm:  s.Term(),
+	}
+	return resp, nil
+}
+
 func (s *EtcdServer) raftRequestOnce(ctx context.Context, r pb.InternalRaftRequest) (proto.Message, error) {
 	result, err := s.processInternalRaftRequestOnce(ctx, r)
 	if err != nil {
@@ -725,7 +835,9 @@ func (s *EtcdServer) doSerialize(ctx context.Context, chk func(*auth.AuthInfo) e
 	}
 	trace.Step("get authentication metadata")
 	// fetch response for serialized request
+	setInflightState(ctx, InflightReading)
 	get()
+	setInflightState(ctx, InflightExecuting)
 	// check for stale token revision in case the auth store was updated while
 	// the request has been handled.
 	if ai.Revision != 0 && ai.Revision != s.authStore.Revision() {
@@ -771,6 +883,7 @@ func (s *EtcdServer) processInternalRaftRequestOnce(ctx context.Context, r pb.In
 		id = r.Header.ID
 	}
 	ch := s.w.Register(id)
+	defer s.trackInflightProposal(ctx, id)()
 
 	cctx, cancel := context.WithTimeout(ctx, s.Cfg.ReqTimeout())
 	defer cancel()
@@ -782,12 +895,22 @@ func (s *EtcdServer) processInternalRaftRequestOnce(ctx context.Context, r pb.In
 		s.w.Trigger(id, nil) // GC wait
 		return nil, err
 	}
+	proposed := time.Now()
 	proposalsPending.Inc()
 	defer proposalsPending.Dec()
 
 	select {
 	case x := <-ch:
-		return x.(*apply2.Result), nil
+		ar := x.(*apply2.Result)
+		s.traceRaftRequest(ctx, start, proposed, ar)
+		if rs := requestStatsFromCtx(ctx); rs != nil {
+			rs.Propose += proposed.Sub(start)
+			if !ar.ApplyStart.IsZero() {
+				rs.CommitWait += ar.ApplyStart.Sub(proposed)
+				rs.Apply += ar.ApplyEnd.Sub(ar.ApplyStart)
+			}
+		}
+		return ar, nil
 	case <-cctx.Done():
 		proposalsFailed.Inc()
 		s.w.Trigger(id, nil) // GC wait
@@ -815,6 +938,9 @@ func (s *EtcdServer) linearizableReadLoop() {
 		// as a single loop is can unlock multiple reads, it is not very useful
 		// to propagate the trace from Txn or Range.
 		trace := traceutil.New("linearizableReadLoop", s.Logger())
+		// everything committed before the read index is requested is covered
+		// by the confirmed index
+		start := time.Now()
 
 		nextnr := newNotifier()
 		s.readMu.Lock()
@@ -845,6 +971,7 @@ func (s *EtcdServer) linearizableReadLoop() {
 				return
 			}
 		}
+		atomic.StoreInt64(&s.readConfirmedAt, start.UnixNano())
 		// unblock all l-reads requested at indices before confirmedIndex
 		nr.notify(nil)
 		trace.Step("applied index is now lower than readState.Index")
@@ -853,6 +980,33 @@ func (s *EtcdServer) linearizableReadLoop() {
 	}
 }
 
+// isReadFresh returns true if the local state includes everything committed at
+// most maxStaleness ago and misses at most maxStaleRevs revisions, so that a read
+// bounded by them can be served without confirming the commit index with the
+// leader. A zero bound is not checked.
+//
+// The revisions bound counts the entries committed but not yet applied, as of
+// the commit index last received from the leader. Each entry creates at most
+// one revision, so the local state misses at most as many revisions.
+func (s *EtcdServer) isReadFresh(maxStaleness time.Duration, maxStaleRevs int64) bool {
+	if maxStaleness > 0 {
+		confirmedAt := atomic.LoadInt64(&s.readConfirmedAt)
+		if confirmedAt == 0 || time.Since(time.Unix(0, confirmedAt)) > maxStaleness {
+			return false
+		}
+	}
+	if maxStaleRevs > 0 {
+		if s.getLead() == raft.None {
+			return false
+		}
+		ci, ai := s.getCommittedIndex(), s.getAppliedIndex()
+		if ci > ai && ci-ai > uint64(maxStaleRevs) {
+			return false
+		}
+	}
+	return true
+}
+
 func isStopped(err error) bool {
 	return err == raft.ErrStopped || err == errors.ErrStopped
 }
@@ -959,6 +1113,11 @@ func (s *EtcdServer) LinearizableReadNotify(ctx context.Context) error {
 }
 
 func (s *EtcdServer) linearizableReadNotify(ctx context.Context) error {
+	if rs := requestStatsFromCtx(ctx); rs != nil {
+		defer func(start time.Time) { rs.ReadIndexWait += time.Since(start) }(time.Now())
+	}
+	setInflightState(ctx, InflightWaitingForRaft)
+	defer setInflightState(ctx, InflightExecuting)
 	s.readMu.RLock()
 	nc := s.readNotifier
 	s.readMu.RUnlock()
@@ -989,7 +1148,32 @@ func (s *EtcdServer) AuthInfoFromCtx(ctx context.Context) (*auth.AuthInfo, error
 		return nil, nil
 	}
 	authInfo = s.AuthStore().AuthInfoFromTLS(ctx)
-	return authInfo, nil
+	return s.forwardedAuthInfo(ctx, authInfo), nil
+}
+
+// forwardedAuthInfo replaces the identity of a trusted proxy with the client
+// certificate common name forwarded by the proxy, if any.
+func (s *EtcdServer) forwardedAuthInfo(ctx context.Context, authInfo *auth.AuthInfo) *auth.AuthInfo {
+	if authInfo == nil || len(s.Cfg.ExperimentalTrustedProxyCNs) == 0 {
+		return authInfo
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return authInfo
+	}
+	cns := md.Get(rpctypes.MetadataForwardedCommonNameKey)
+	if len(cns) == 0 || cns[0] == "" {
+		return authInfo
+	}
+	if !slices.Contains(s.Cfg.ExperimentalTrustedProxyCNs, authInfo.Username) {
+		s.Logger().Warn(
+			"ignoring forwarded common name from untrusted client",
+			zap.String("common-name", authInfo.Username),
+			zap.String("forwarded-common-name", cns[0]),
+		)
+		return authInfo
+	}
+	return &auth.AuthInfo{Username: cns[0], Revision: authInfo.Revision}
 }
 
 func (s *EtcdServer) Downgrade(ctx context.Context, r *pb.DowngradeRequest) (*pb.DowngradeResponse, error) {
@@ -1022,6 +1206,9 @@ func (s *EtcdServer) downgradeValidate(ctx context.Context, v string) (*pb.Downg
 	if err != nil {
 		return nil, err
 	}
+	if err = s.checkRangeTombstones(targetVersion); err != nil {
+		return nil, err
+	}
 
 	return resp, nil
 }
@@ -1033,6 +1220,10 @@ func (s *EtcdServer) downgradeEnable(ctx context.Context, r *pb.DowngradeRequest
 		lg.Warn("reject downgrade request", zap.Error(err))
 		return nil, err
 	}
+	if err = s.checkRangeTombstones(targetVersion); err != nil {
+		lg.Warn("reject downgrade request", zap.Error(err))
+		return nil, err
+	}
 	err = s.Version().DowngradeEnable(ctx, targetVersion)
 	if err != nil {
 		lg.Warn("reject downgrade request", zap.Error(err))
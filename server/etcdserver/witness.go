@@ -0,0 +1,83 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+)
+
+// A witness is a voting member that only keeps the raft log and the cluster
+// state: the membership, auth and alarms. It skips the key-value and lease
+// requests it applies, rejects client requests and gives the leadership away
+// whenever it is elected, so that a third site can break the ties of a two
+// site cluster without the storage cost of a full replica. The raft entries
+// are still replicated to and persisted in the WAL of the witness.
+
+// IsWitness returns true if the member runs as a witness. The member is
+// refused to start as a witness if it was published as a full member, or the
+// other way around, so the flag matches the membership.
+func (s *EtcdServer) IsWitness() bool { return s.Cfg.ExperimentalWitness }
+
+// validateWitness returns an error if the member id is started as a witness
+// but was published as a full member in the membership of cl, or the other
+// way around. A witness keeps neither the keys nor the leases, so a member
+// must be removed and added again to change it.
+func validateWitness(witness bool, cl *membership.RaftCluster, id types.ID) error {
+	m := cl.Member(id)
+	if m == nil || m.Name == "" {
+		// the member has not published its attributes yet
+		return nil
+	}
+	if m.Witness != witness {
+		return fmt.Errorf("member %s was published with witness %t, but is started with --experimental-witness=%t; remove and add the member again to change it", id, m.Witness, witness)
+	}
+	return nil
+}
+
+// leaderCandidateIDs returns the IDs of the voting members that may take over
+// the leadership, that is the voting members which are not witnesses.
+func leaderCandidateIDs(members []*membership.Member) []types.ID {
+	var ids []types.ID
+	for _, m := range members {
+		if !m.IsLearner && !m.Witness {
+			ids = append(ids, m.ID)
+		}
+	}
+	return ids
+}
+
+// purgeWitnessKeyspace drops the keys and leases of a snapshot received by a
+// witness, which does not keep them, and reclaims their space.
+func purgeWitnessKeyspace(lg *zap.Logger, be backend.Backend) {
+	tx := be.BatchTx()
+	tx.LockOutsideApply()
+	for _, b := range []backend.Bucket{schema.Key, schema.Lease} {
+		tx.UnsafeDeleteBucket(b)
+		tx.UnsafeCreateBucket(b)
+	}
+	tx.Unlock()
+	be.ForceCommit()
+
+	if err := be.Defrag(); err != nil {
+		lg.Warn("failed to defragment the backend of the witness", zap.Error(err))
+	}
+}
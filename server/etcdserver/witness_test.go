@@ -0,0 +1,52 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
+)
+
+func TestValidateWitness(t *testing.T) {
+	cl := membership.NewClusterFromMembers(zaptest.NewLogger(t), 1, []*membership.Member{
+		{ID: 1},
+		{ID: 2, Attributes: membership.Attributes{Name: "full"}},
+		{ID: 3, Attributes: membership.Attributes{Name: "witness", Witness: true}},
+	})
+	tcs := []struct {
+		name    string
+		id      uint64
+		witness bool
+		wantErr bool
+	}{
+		{name: "unpublished member as witness", id: 1, witness: true},
+		{name: "unpublished member as full member", id: 1},
+		{name: "full member", id: 2},
+		{name: "full member as witness", id: 2, witness: true, wantErr: true},
+		{name: "witness", id: 3, witness: true},
+		{name: "witness as full member", id: 3, wantErr: true},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateWitness(tc.witness, cl, types.ID(tc.id))
+			assert.Equal(t, tc.wantErr, err != nil, "error: %v", err)
+		})
+	}
+}
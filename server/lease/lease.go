@@ -28,6 +28,10 @@ type Lease struct {
 	ID           LeaseID
 	ttl          int64 // time to live of the lease in seconds
 	remainingTTL int64 // remaining time to live in seconds, if zero valued it is considered unset and the full ttl should be used
+	pinnedRev    int64 // revision pinned against compaction, if any
+	// pinDeadline is the time past which a lease pinning a revision is no
+	// longer extended, if set
+	pinDeadline time.Time
 	// expiryMu protects concurrent accesses to expiry
 	expiryMu sync.RWMutex
 	// expiry is time when lease should expire. no expiration when expiry.IsZero() is true
@@ -53,7 +57,7 @@ func (l *Lease) expired() bool {
 }
 
 func (l *Lease) persistTo(b backend.Backend) {
-	lpb := leasepb.Lease{ID: int64(l.ID), TTL: l.ttl, RemainingTTL: l.remainingTTL}
+	lpb := leasepb.Lease{ID: int64(l.ID), TTL: l.ttl, RemainingTTL: l.remainingTTL, PinnedRevision: l.pinnedRev}
 	tx := b.BatchTx()
 	tx.LockInsideApply()
 	defer tx.Unlock()
@@ -65,6 +69,12 @@ func (l *Lease) TTL() int64 {
 	return l.ttl
 }
 
+// PinnedRevision returns the revision pinned against compaction by the Lease,
+// or 0 if it does not pin a revision.
+func (l *Lease) PinnedRevision() int64 {
+	return l.pinnedRev
+}
+
 // SetLeaseItem sets the given lease item, this func is thread-safe
 func (l *Lease) SetLeaseItem(item LeaseItem) {
 	l.mu.Lock()
@@ -83,6 +93,9 @@ func (l *Lease) getRemainingTTL() int64 {
 // refresh refreshes the expiry of the lease.
 func (l *Lease) refresh(extend time.Duration) {
 	newExpiry := time.Now().Add(extend + time.Duration(l.getRemainingTTL())*time.Second)
+	if !l.pinDeadline.IsZero() && newExpiry.After(l.pinDeadline) {
+		newExpiry = l.pinDeadline
+	}
 	l.expiryMu.Lock()
 	defer l.expiryMu.Unlock()
 	l.expiry = newExpiry
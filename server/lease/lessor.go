@@ -42,7 +42,7 @@ const MaxLeaseTTL = 9000000000
 var (
 	forever = time.Time{}
 
-	// maximum number of leases to revoke per second; configurable for tests
+	// default maximum number of leases to revoke per second; configurable for tests
 	leaseRevokeRate = 1000
 
 	// maximum number of lease checkpoints recorded to the consensus log per second; configurable for tests
@@ -123,6 +123,15 @@ type Lessor interface {
 	// an error will be returned.
 	Renew(id LeaseID) (int64, error)
 
+	// Pin pins the given revision against compaction until the lease with
+	// the given ID is revoked. If the lease does not exist, an error will be
+	// returned.
+	Pin(id LeaseID, rev int64) error
+
+	// MinPinnedRevision returns the lowest revision pinned by a lease, or 0
+	// if no revision is pinned.
+	MinPinnedRevision() int64
+
 	// Lookup gives the lease at a given lease id, if any
 	Lookup(id LeaseID) *Lease
 
@@ -153,6 +162,14 @@ type lessor struct {
 	leaseExpiredNotifier *LeaseExpiredNotifier
 	leaseCheckpointHeap  LeaseQueue
 	itemMap              map[LeaseItem]LeaseID
+	// pinned maps the leases pinning a revision to the revision.
+	pinned map[LeaseID]int64
+	// maxPinDuration bounds how long a lease pinning a revision is kept
+	// alive, if set.
+	maxPinDuration time.Duration
+	// revoking is the set of expired leases sent to be revoked and not
+	// revoked yet, the revocation backlog of the primary lessor.
+	revoking map[LeaseID]struct{}
 
 	// When a lease expires, the lessor will delete the
 	// leased range (or key) by the RangeDeleter.
@@ -182,6 +199,8 @@ type lessor struct {
 	checkpointInterval time.Duration
 	// the interval to check if the expired lease is revoked
 	expiredLeaseRetryInterval time.Duration
+	// the maximum number of expired leases to revoke per second
+	revokeRate int
 	// whether lessor should always persist remaining TTL (always enabled in v3.6).
 	checkpointPersist bool
 	// cluster is used to adapt lessor logic based on cluster version
@@ -198,6 +217,14 @@ type LessorConfig struct {
 	CheckpointInterval         time.Duration
 	ExpiredLeasesRetryInterval time.Duration
 	CheckpointPersist          bool
+	// MaxRevokesPerSecond is the maximum number of expired leases sent to be
+	// revoked per second. When promoted, the lessor also extends the leases
+	// which would otherwise expire faster than 3/4 of this rate.
+	MaxRevokesPerSecond int
+	// MaxPinDuration bounds how long a lease pinning a revision can be kept
+	// alive, counted from the pin or, after a restart, from the recovery of
+	// the lease. It is not bounded if 0.
+	MaxPinDuration time.Duration
 }
 
 func NewLessor(lg *zap.Logger, b backend.Backend, cluster cluster, cfg LessorConfig) Lessor {
@@ -213,15 +240,23 @@ func newLessor(lg *zap.Logger, b backend.Backend, cluster cluster, cfg LessorCon
 	if expiredLeaseRetryInterval == 0 {
 		expiredLeaseRetryInterval = defaultExpiredleaseRetryInterval
 	}
+	revokeRate := cfg.MaxRevokesPerSecond
+	if revokeRate <= 0 {
+		revokeRate = leaseRevokeRate
+	}
 	l := &lessor{
 		leaseMap:                  make(map[LeaseID]*Lease),
 		itemMap:                   make(map[LeaseItem]LeaseID),
+		pinned:                    make(map[LeaseID]int64),
+		maxPinDuration:            cfg.MaxPinDuration,
+		revoking:                  make(map[LeaseID]struct{}),
 		leaseExpiredNotifier:      newLeaseExpiredNotifier(),
 		leaseCheckpointHeap:       make(LeaseQueue, 0),
 		b:                         b,
 		minLeaseTTL:               cfg.MinLeaseTTL,
 		checkpointInterval:        checkpointInterval,
 		expiredLeaseRetryInterval: expiredLeaseRetryInterval,
+		revokeRate:                revokeRate,
 		checkpointPersist:         cfg.CheckpointPersist,
 		// expiredC is a small buffered chan to avoid unnecessary blocking.
 		expiredC: make(chan []*Lease, 16),
@@ -343,6 +378,8 @@ func (le *lessor) Revoke(id LeaseID) error {
 	le.mu.Lock()
 	defer le.mu.Unlock()
 	delete(le.leaseMap, l.ID)
+	delete(le.pinned, l.ID)
+	le.unsafeRevoked(l.ID)
 	// lease deletion needs to be in the same backend transaction with the
 	// kv deletion. Or we might end up with not executing the revoke or not
 	// deleting the keys if etcdserver fails in between.
@@ -431,12 +468,54 @@ func (le *lessor) Renew(id LeaseID) (int64, error) {
 	l.refresh(0)
 	item := &LeaseWithTime{id: l.ID, time: l.expiry}
 	le.leaseExpiredNotifier.RegisterOrUpdate(item)
+	capped := !l.pinDeadline.IsZero()
 	le.mu.Unlock()
 
 	leaseRenewed.Inc()
+	if capped {
+		// the lease isn't extended past the deadline of its pin
+		return max(0, min(l.ttl, int64(math.Ceil(l.Remaining().Seconds())))), nil
+	}
 	return l.ttl, nil
 }
 
+func (le *lessor) Pin(id LeaseID, rev int64) error {
+	le.mu.Lock()
+	defer le.mu.Unlock()
+
+	l := le.leaseMap[id]
+	if l == nil {
+		return ErrLeaseNotFound
+	}
+	l.pinnedRev = rev
+	le.pinned[id] = rev
+	l.pinDeadline = le.pinDeadline()
+	l.persistTo(le.b)
+	return nil
+}
+
+// pinDeadline returns the deadline of a pin made now, or the zero time if
+// pins are not bounded.
+func (le *lessor) pinDeadline() time.Time {
+	if le.maxPinDuration <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(le.maxPinDuration)
+}
+
+func (le *lessor) MinPinnedRevision() int64 {
+	le.mu.RLock()
+	defer le.mu.RUnlock()
+
+	var minRev int64
+	for _, rev := range le.pinned {
+		if minRev == 0 || rev < minRev {
+			minRev = rev
+		}
+	}
+	return minRev
+}
+
 func (le *lessor) Lookup(id LeaseID) *Lease {
 	le.mu.RLock()
 	defer le.mu.RUnlock()
@@ -473,7 +552,7 @@ func (le *lessor) Promote(extend time.Duration) {
 		le.scheduleCheckpointIfNeeded(l)
 	}
 
-	if len(le.leaseMap) < leaseRevokeRate {
+	if len(le.leaseMap) < le.revokeRate {
 		// no possibility of lease pile-up
 		return
 	}
@@ -487,7 +566,7 @@ func (le *lessor) Promote(extend time.Duration) {
 	expires := 0
 	// have fewer expires than the total revoke rate so piled up leases
 	// don't consume the entire revoke limit
-	targetExpiresPerSecond := (3 * leaseRevokeRate) / 4
+	targetExpiresPerSecond := (3 * le.revokeRate) / 4
 	for _, l := range leases {
 		remaining := l.Remaining()
 		if remaining > nextWindow {
@@ -524,6 +603,7 @@ func (le *lessor) Demote() {
 
 	le.clearScheduledLeasesCheckpoints()
 	le.clearLeaseExpiredNotifier()
+	le.clearRevoking()
 
 	if le.demotec != nil {
 		close(le.demotec)
@@ -587,6 +667,8 @@ func (le *lessor) Recover(b backend.Backend, rd RangeDeleter) {
 	le.rd = rd
 	le.leaseMap = make(map[LeaseID]*Lease)
 	le.itemMap = make(map[LeaseItem]LeaseID)
+	le.pinned = make(map[LeaseID]int64)
+	le.clearRevoking()
 	le.initAndRecover()
 }
 
@@ -623,13 +705,14 @@ func (le *lessor) revokeExpiredLeases() {
 	var ls []*Lease
 
 	// rate limit
-	revokeLimit := leaseRevokeRate / 2
+	revokeLimit := le.revokeRate / 2
 
-	le.mu.RLock()
+	le.mu.Lock()
 	if le.isPrimary() {
 		ls = le.findExpiredLeases(revokeLimit)
+		le.unsafeRevoking(ls)
 	}
-	le.mu.RUnlock()
+	le.mu.Unlock()
 
 	if len(ls) != 0 {
 		select {
@@ -640,10 +723,32 @@ func (le *lessor) revokeExpiredLeases() {
 			// the receiver of expiredC is probably busy handling
 			// other stuff
 			// let's try this next time after 500ms
+			leaseRevokeDeferred.Add(float64(len(ls)))
 		}
 	}
 }
 
+// unsafeRevoking adds the expired leases to the revocation backlog.
+func (le *lessor) unsafeRevoking(ls []*Lease) {
+	for _, l := range ls {
+		le.revoking[l.ID] = struct{}{}
+	}
+	leaseRevokePending.Set(float64(len(le.revoking)))
+}
+
+// unsafeRevoked removes a revoked lease from the revocation backlog.
+func (le *lessor) unsafeRevoked(id LeaseID) {
+	if _, ok := le.revoking[id]; ok {
+		delete(le.revoking, id)
+		leaseRevokePending.Set(float64(len(le.revoking)))
+	}
+}
+
+func (le *lessor) clearRevoking() {
+	le.revoking = make(map[LeaseID]struct{})
+	leaseRevokePending.Set(0)
+}
+
 // checkpointScheduledLeases finds all scheduled lease checkpoints that are due and
 // submits them to the checkpointer to persist them to the consensus log.
 func (le *lessor) checkpointScheduledLeases() {
@@ -808,6 +913,11 @@ func (le *lessor) initAndRecover() {
 			expiry:       forever,
 			revokec:      make(chan struct{}),
 			remainingTTL: lpb.RemainingTTL,
+			pinnedRev:    lpb.PinnedRevision,
+		}
+		if lpb.PinnedRevision > 0 {
+			le.pinned[ID] = lpb.PinnedRevision
+			le.leaseMap[ID].pinDeadline = le.pinDeadline()
 		}
 	}
 	le.leaseExpiredNotifier.Init()
@@ -846,6 +956,10 @@ func (fl *FakeLessor) Demote() {}
 
 func (fl *FakeLessor) Renew(id LeaseID) (int64, error) { return 10, nil }
 
+func (fl *FakeLessor) Pin(id LeaseID, rev int64) error { return nil }
+
+func (fl *FakeLessor) MinPinnedRevision() int64 { return 0 }
+
 func (fl *FakeLessor) Lookup(id LeaseID) *Lease {
 	if _, ok := fl.LeaseSet[id]; ok {
 		return &Lease{ID: id}
@@ -427,6 +427,153 @@ func TestLessorRecover(t *testing.T) {
 	}
 }
 
+func TestLessorPin(t *testing.T) {
+	lg := zap.NewNop()
+	dir, be := NewTestBackend(t)
+	defer os.RemoveAll(dir)
+	defer be.Close()
+
+	le := newLessor(lg, be, clusterLatest(), LessorConfig{MinLeaseTTL: minLeaseTTL})
+	defer le.Stop()
+	le.SetRangeDeleter(func() TxnDelete { return newFakeDeleter(be) })
+
+	for id := LeaseID(1); id <= 3; id++ {
+		if _, err := le.Grant(id, 100); err != nil {
+			t.Fatalf("could not grant lease %d (%v)", id, err)
+		}
+	}
+	if rev := le.MinPinnedRevision(); rev != 0 {
+		t.Fatalf("min pinned revision = %d, want 0", rev)
+	}
+	if err := le.Pin(1, 20); err != nil {
+		t.Fatal(err)
+	}
+	if err := le.Pin(2, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := le.Pin(4, 5); err != ErrLeaseNotFound {
+		t.Fatalf("err = %v, want %v", err, ErrLeaseNotFound)
+	}
+	if rev := le.MinPinnedRevision(); rev != 10 {
+		t.Fatalf("min pinned revision = %d, want 10", rev)
+	}
+
+	// the pinned revisions are recovered from the backend
+	nle := newLessor(lg, be, clusterLatest(), LessorConfig{MinLeaseTTL: minLeaseTTL})
+	defer nle.Stop()
+	nle.SetRangeDeleter(func() TxnDelete { return newFakeDeleter(be) })
+	if rev := nle.Lookup(1).PinnedRevision(); rev != 20 {
+		t.Fatalf("pinned revision = %d, want 20", rev)
+	}
+	if rev := nle.MinPinnedRevision(); rev != 10 {
+		t.Fatalf("min pinned revision = %d, want 10", rev)
+	}
+
+	for _, tt := range []struct {
+		id     LeaseID
+		minRev int64
+	}{{2, 20}, {1, 0}} {
+		if err := nle.Revoke(tt.id); err != nil {
+			t.Fatal(err)
+		}
+		if rev := nle.MinPinnedRevision(); rev != tt.minRev {
+			t.Fatalf("min pinned revision after revoking %d = %d, want %d", tt.id, rev, tt.minRev)
+		}
+	}
+}
+
+// TestLessorPinMaxDuration ensures a lease pinning a revision is not kept
+// alive past the maximum pin duration.
+func TestLessorPinMaxDuration(t *testing.T) {
+	lg := zap.NewNop()
+	dir, be := NewTestBackend(t)
+	defer os.RemoveAll(dir)
+	defer be.Close()
+
+	le := newLessor(lg, be, clusterLatest(), LessorConfig{MinLeaseTTL: minLeaseTTL, MaxPinDuration: 2 * time.Second})
+	defer le.Stop()
+	le.SetRangeDeleter(func() TxnDelete { return newFakeDeleter(be) })
+	le.Promote(0)
+
+	for id := LeaseID(1); id <= 2; id++ {
+		if _, err := le.Grant(id, 100); err != nil {
+			t.Fatalf("could not grant lease %d (%v)", id, err)
+		}
+	}
+	if err := le.Pin(1, 10); err != nil {
+		t.Fatal(err)
+	}
+	ttl, err := le.Renew(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl > 2 {
+		t.Errorf("ttl of the pinning lease = %d, want <= 2", ttl)
+	}
+	if remaining := le.Lookup(1).Remaining(); remaining > 2*time.Second {
+		t.Errorf("remaining time of the pinning lease = %v, want <= 2s", remaining)
+	}
+	if ttl, err = le.Renew(2); err != nil || ttl != 100 {
+		t.Errorf("ttl of the other lease = %d (%v), want 100", ttl, err)
+	}
+}
+
+// TestLessorRevokeRate ensures the expired leases are sent to be revoked in
+// batches of half the configured revoke rate, and are tracked as pending
+// until they are revoked.
+func TestLessorRevokeRate(t *testing.T) {
+	lg := zap.NewNop()
+	dir, be := NewTestBackend(t)
+	defer os.RemoveAll(dir)
+	defer be.Close()
+
+	le := newLessor(lg, be, clusterLatest(), LessorConfig{MinLeaseTTL: 1, MaxRevokesPerSecond: 10})
+	defer le.Stop()
+	le.SetRangeDeleter(func() TxnDelete { return newFakeDeleter(be) })
+	le.Promote(0)
+
+	for i := 1; i <= 12; i++ {
+		if _, err := le.Grant(LeaseID(i), 1); err != nil {
+			t.Fatalf("failed to create lease: %v", err)
+		}
+	}
+
+	var expired []*Lease
+	select {
+	case expired = <-le.ExpiredLeasesC():
+	case <-time.After(10 * time.Second):
+		t.Fatalf("failed to receive expired leases")
+	}
+	if len(expired) != 5 {
+		t.Fatalf("expired leases = %d, want 5", len(expired))
+	}
+
+	le.mu.RLock()
+	pending := len(le.revoking)
+	le.mu.RUnlock()
+	if pending < len(expired) {
+		t.Fatalf("pending revokes = %d, want at least %d", pending, len(expired))
+	}
+
+	if err := le.Revoke(expired[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	le.mu.RLock()
+	_, ok := le.revoking[expired[0].ID]
+	le.mu.RUnlock()
+	if ok {
+		t.Errorf("revoked lease %x is still pending", expired[0].ID)
+	}
+
+	le.Demote()
+	le.mu.RLock()
+	pending = len(le.revoking)
+	le.mu.RUnlock()
+	if pending != 0 {
+		t.Errorf("pending revokes after demotion = %d, want 0", pending)
+	}
+}
+
 func TestLessorExpire(t *testing.T) {
 	lg := zap.NewNop()
 	dir, be := NewTestBackend(t)
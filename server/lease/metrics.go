@@ -40,6 +40,20 @@ var (
 		Help:      "The number of renewed leases seen by the leader.",
 	})
 
+	leaseRevokePending = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcd_debugging",
+		Subsystem: "lease",
+		Name:      "revoke_pending",
+		Help:      "The number of expired leases sent to be revoked by the leader and not revoked yet.",
+	})
+
+	leaseRevokeDeferred = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd_debugging",
+		Subsystem: "lease",
+		Name:      "revoke_deferred_total",
+		Help:      "The total number of expired leases whose revocation was deferred because the leader was busy revoking other leases.",
+	})
+
 	leaseTotalTTLs = prometheus.NewHistogram(
 		prometheus.HistogramOpts{
 			Namespace: "etcd_debugging",
@@ -55,5 +69,7 @@ func init() {
 	prometheus.MustRegister(leaseGranted)
 	prometheus.MustRegister(leaseRevoked)
 	prometheus.MustRegister(leaseRenewed)
+	prometheus.MustRegister(leaseRevokePending)
+	prometheus.MustRegister(leaseRevokeDeferred)
 	prometheus.MustRegister(leaseTotalTTLs)
 }
@@ -64,6 +64,10 @@ func (s *as2ac) RoleRevokePermission(ctx context.Context, in *pb.AuthRoleRevokeP
 	return s.as.RoleRevokePermission(ctx, in)
 }
 
+func (s *as2ac) CheckPermission(ctx context.Context, in *pb.AuthCheckPermissionRequest, opts ...grpc.CallOption) (*pb.AuthCheckPermissionResponse, error) {
+	return s.as.CheckPermission(ctx, in)
+}
+
 func (s *as2ac) RoleGrantPermission(ctx context.Context, in *pb.AuthRoleGrantPermissionRequest, opts ...grpc.CallOption) (*pb.AuthRoleGrantPermissionResponse, error) {
 	return s.as.RoleGrantPermission(ctx, in)
 }
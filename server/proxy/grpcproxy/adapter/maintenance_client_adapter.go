@@ -48,6 +48,14 @@ func (s *mts2mtc) HashKV(ctx context.Context, r *pb.HashKVRequest, opts ...grpc.
 	return s.mts.HashKV(ctx, r)
 }
 
+func (s *mts2mtc) HashKVRange(ctx context.Context, r *pb.HashKVRangeRequest, opts ...grpc.CallOption) (*pb.HashKVRangeResponse, error) {
+	return s.mts.HashKVRange(ctx, r)
+}
+
+func (s *mts2mtc) HotKeys(ctx context.Context, r *pb.HotKeysRequest, opts ...grpc.CallOption) (*pb.HotKeysResponse, error) {
+	return s.mts.HotKeys(ctx, r)
+}
+
 func (s *mts2mtc) MoveLeader(ctx context.Context, r *pb.MoveLeaderRequest, opts ...grpc.CallOption) (*pb.MoveLeaderResponse, error) {
 	return s.mts.MoveLeader(ctx, r)
 }
@@ -56,6 +64,14 @@ func (s *mts2mtc) Downgrade(ctx context.Context, r *pb.DowngradeRequest, opts ..
 	return s.mts.Downgrade(ctx, r)
 }
 
+func (s *mts2mtc) Drain(ctx context.Context, r *pb.DrainRequest, opts ...grpc.CallOption) (*pb.DrainResponse, error) {
+	return s.mts.Drain(ctx, r)
+}
+
+func (s *mts2mtc) RotateEncryptionKey(ctx context.Context, r *pb.RotateEncryptionKeyRequest, opts ...grpc.CallOption) (*pb.RotateEncryptionKeyResponse, error) {
+	return s.mts.RotateEncryptionKey(ctx, r)
+}
+
 func (s *mts2mtc) Snapshot(ctx context.Context, in *pb.SnapshotRequest, opts ...grpc.CallOption) (pb.Maintenance_SnapshotClient, error) {
 	cs := newPipeStream(ctx, func(ss chanServerStream) error {
 		return s.mts.Snapshot(in, &ss2scServerStream{ss})
@@ -90,3 +106,38 @@ func (s *ss2scServerStream) Recv() (*pb.SnapshotRequest, error) {
 	}
 	return v.(*pb.SnapshotRequest), nil
 }
+
+func (s *mts2mtc) ClusterEvents(ctx context.Context, in *pb.ClusterEventsRequest, opts ...grpc.CallOption) (pb.Maintenance_ClusterEventsClient, error) {
+	cs := newPipeStream(ctx, func(ss chanServerStream) error {
+		return s.mts.ClusterEvents(in, &ce2ceServerStream{ss})
+	})
+	return &ce2ceClientStream{cs}, nil
+}
+
+// ce2ceClientStream implements Maintenance_ClusterEventsClient
+type ce2ceClientStream struct{ chanClientStream }
+
+// ce2ceServerStream implements Maintenance_ClusterEventsServer
+type ce2ceServerStream struct{ chanServerStream }
+
+func (s *ce2ceClientStream) Send(rr *pb.ClusterEventsRequest) error {
+	return s.SendMsg(rr)
+}
+func (s *ce2ceClientStream) Recv() (*pb.ClusterEventsResponse, error) {
+	var v any
+	if err := s.RecvMsg(&v); err != nil {
+		return nil, err
+	}
+	return v.(*pb.ClusterEventsResponse), nil
+}
+
+func (s *ce2ceServerStream) Send(rr *pb.ClusterEventsResponse) error {
+	return s.SendMsg(rr)
+}
+func (s *ce2ceServerStream) Recv() (*pb.ClusterEventsRequest, error) {
+	var v any
+	if err := s.RecvMsg(&v); err != nil {
+		return nil, err
+	}
+	return v.(*pb.ClusterEventsRequest), nil
+}
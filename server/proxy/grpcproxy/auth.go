@@ -65,6 +65,10 @@ func (ap *AuthProxy) RoleRevokePermission(ctx context.Context, r *pb.AuthRoleRev
 	return ap.authClient.RoleRevokePermission(ctx, r)
 }
 
+func (ap *AuthProxy) CheckPermission(ctx context.Context, r *pb.AuthCheckPermissionRequest) (*pb.AuthCheckPermissionResponse, error) {
+	return ap.authClient.CheckPermission(ctx, r)
+}
+
 func (ap *AuthProxy) RoleGrantPermission(ctx context.Context, r *pb.AuthRoleGrantPermissionRequest) (*pb.AuthRoleGrantPermissionResponse, error) {
 	return ap.authClient.RoleGrantPermission(ctx, r)
 }
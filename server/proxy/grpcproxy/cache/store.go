@@ -19,6 +19,7 @@ package cache
 import (
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/golang/groupcache/lru"
 
@@ -52,10 +53,17 @@ func keyFunc(req *pb.RangeRequest) string {
 }
 
 func NewCache(maxCacheEntries int) Cache {
+	return NewCacheWithTTL(maxCacheEntries, 0)
+}
+
+// NewCacheWithTTL returns a cache whose entries expire after the given ttl.
+// A zero ttl keeps entries until they are evicted or invalidated.
+func NewCacheWithTTL(maxCacheEntries int, ttl time.Duration) Cache {
 	return &cache{
 		lru:          lru.New(maxCacheEntries),
 		cachedRanges: adt.NewIntervalTree(),
 		compactedRev: -1,
+		ttl:          ttl,
 	}
 }
 
@@ -70,6 +78,15 @@ type cache struct {
 	cachedRanges adt.IntervalTree
 
 	compactedRev int64
+
+	// ttl is the maximum age of a cached response, zero for no limit.
+	ttl time.Duration
+}
+
+// entry is a cached response along with the time it expires.
+type entry struct {
+	resp     *pb.RangeResponse
+	expireAt time.Time
 }
 
 // Add adds the response of a request to the cache if its revision is larger than the compacted revision of the cache.
@@ -80,7 +97,11 @@ func (c *cache) Add(req *pb.RangeRequest, resp *pb.RangeResponse) {
 	defer c.mu.Unlock()
 
 	if req.Revision > c.compactedRev {
-		c.lru.Add(key, resp)
+		e := entry{resp: resp}
+		if c.ttl > 0 {
+			e.expireAt = time.Now().Add(c.ttl)
+		}
+		c.lru.Add(key, e)
 	}
 	// we do not need to invalidate a request with a revision specified.
 	// so we do not need to add it into the reverse index.
@@ -123,8 +144,12 @@ func (c *cache) Get(req *pb.RangeRequest) (*pb.RangeResponse, error) {
 		return nil, ErrCompacted
 	}
 
-	if resp, ok := c.lru.Get(key); ok {
-		return resp.(*pb.RangeResponse), nil
+	if v, ok := c.lru.Get(key); ok {
+		e := v.(entry)
+		if e.expireAt.IsZero() || time.Now().Before(e.expireAt) {
+			return e.resp, nil
+		}
+		c.lru.Remove(key)
 	}
 	return nil, errors.New("not exist")
 }
@@ -0,0 +1,54 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+func TestCacheTTL(t *testing.T) {
+	req := &pb.RangeRequest{Key: []byte("foo"), Serializable: true}
+	resp := &pb.RangeResponse{Header: &pb.ResponseHeader{Revision: 1}}
+
+	c := NewCacheWithTTL(DefaultMaxEntries, 50*time.Millisecond)
+	c.Add(req, resp)
+	got, err := c.Get(req)
+	require.NoError(t, err)
+	require.Equal(t, resp, got)
+
+	time.Sleep(100 * time.Millisecond)
+	_, err = c.Get(req)
+	require.Error(t, err)
+	require.Equal(t, 0, c.Size())
+}
+
+func TestCacheNoTTL(t *testing.T) {
+	req := &pb.RangeRequest{Key: []byte("foo"), Serializable: true}
+	resp := &pb.RangeResponse{Header: &pb.ResponseHeader{Revision: 1}}
+
+	c := NewCache(DefaultMaxEntries)
+	c.Add(req, resp)
+	_, err := c.Get(req)
+	require.NoError(t, err)
+
+	c.Invalidate([]byte("foo"), nil)
+	_, err = c.Get(req)
+	require.Error(t, err)
+}
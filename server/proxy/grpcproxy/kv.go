@@ -15,22 +15,48 @@
 package grpcproxy
 
 import (
+	"bytes"
 	"context"
+	"time"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/server/v3/proxy/grpcproxy/cache"
 )
 
+// KvCacheConfig configures the range cache of the KV proxy.
+type KvCacheConfig struct {
+	// MaxEntries is the maximum number of cached range responses.
+	MaxEntries int
+	// TTL is the maximum age of a cached range response. Zero means
+	// responses are served until evicted or invalidated.
+	TTL time.Duration
+	// Prefixes restricts caching to ranges within one of the key
+	// prefixes. All ranges are cached if empty.
+	Prefixes []string
+}
+
 type kvProxy struct {
 	kv    clientv3.KV
 	cache cache.Cache
+
+	// cachePrefixes restricts which ranges are cached, all if empty.
+	cachePrefixes [][]byte
 }
 
 func NewKvProxy(c *clientv3.Client) (pb.KVServer, <-chan struct{}) {
+	return NewKvProxyWithCacheConfig(c, KvCacheConfig{MaxEntries: cache.DefaultMaxEntries})
+}
+
+// NewKvProxyWithCacheConfig creates a KV proxy whose range cache is
+// configured by the given config.
+func NewKvProxyWithCacheConfig(c *clientv3.Client, cfg KvCacheConfig) (pb.KVServer, <-chan struct{}) {
 	kv := &kvProxy{
 		kv:    c.KV,
-		cache: cache.NewCache(cache.DefaultMaxEntries),
+		cache: cache.NewCacheWithTTL(cfg.MaxEntries, cfg.TTL),
+	}
+	for _, pfx := range cfg.Prefixes {
+		kv.cachePrefixes = append(kv.cachePrefixes, []byte(pfx))
 	}
 	donec := make(chan struct{})
 	close(donec)
@@ -38,18 +64,19 @@ func NewKvProxy(c *clientv3.Client) (pb.KVServer, <-chan struct{}) {
 }
 
 func (p *kvProxy) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeResponse, error) {
-	if r.Serializable {
+	cacheable := p.cacheable(r)
+	if r.Serializable && cacheable {
 		resp, err := p.cache.Get(r)
 		switch err {
 		case nil:
-			cacheHits.Inc()
+			observeCacheLookup(true)
 			return resp, nil
 		case cache.ErrCompacted:
-			cacheHits.Inc()
+			observeCacheLookup(true)
 			return nil, err
 		}
 
-		cachedMisses.Inc()
+		observeCacheLookup(false)
 	}
 
 	resp, err := p.kv.Do(ctx, RangeRequestToOp(r))
@@ -57,16 +84,41 @@ func (p *kvProxy) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeRespo
 		return nil, err
 	}
 
-	// cache linearizable as serializable
-	req := *r
-	req.Serializable = true
 	gresp := (*pb.RangeResponse)(resp.Get())
-	p.cache.Add(&req, gresp)
-	cacheKeys.Set(float64(p.cache.Size()))
+	if cacheable {
+		// cache linearizable as serializable
+		req := *r
+		req.Serializable = true
+		p.cache.Add(&req, gresp)
+		cacheKeys.Set(float64(p.cache.Size()))
+	}
 
 	return gresp, nil
 }
 
+// cacheable checks whether the range falls within one of the cache prefixes.
+func (p *kvProxy) cacheable(r *pb.RangeRequest) bool {
+	if len(p.cachePrefixes) == 0 {
+		return true
+	}
+	for _, pfx := range p.cachePrefixes {
+		if !bytes.HasPrefix(r.Key, pfx) {
+			continue
+		}
+		if len(r.RangeEnd) == 0 {
+			return true
+		}
+		pfxEnd := []byte(clientv3.GetPrefixRangeEnd(string(pfx)))
+		if len(pfxEnd) == 1 && pfxEnd[0] == 0 {
+			return true
+		}
+		if !(len(r.RangeEnd) == 1 && r.RangeEnd[0] == 0) && bytes.Compare(r.RangeEnd, pfxEnd) <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *kvProxy) Put(ctx context.Context, r *pb.PutRequest) (*pb.PutResponse, error) {
 	p.cache.Invalidate(r.Key, nil)
 	cacheKeys.Set(float64(p.cache.Size()))
@@ -179,6 +231,12 @@ func RangeRequestToOp(r *pb.RangeRequest) clientv3.Op {
 	if r.CountOnly {
 		opts = append(opts, clientv3.WithCountOnly())
 	}
+	if len(r.GroupBySeparator) != 0 {
+		opts = append(opts, clientv3.WithGroupBySeparator(string(r.GroupBySeparator)))
+	}
+	if len(r.GroupFromKey) != 0 {
+		opts = append(opts, clientv3.WithGroupFromKey(string(r.GroupFromKey)))
+	}
 	if r.KeysOnly {
 		opts = append(opts, clientv3.WithKeysOnly())
 	}
@@ -70,6 +70,14 @@ func (mp *maintenanceProxy) HashKV(ctx context.Context, r *pb.HashKVRequest) (*p
 	return mp.maintenanceClient.HashKV(ctx, r)
 }
 
+func (mp *maintenanceProxy) HashKVRange(ctx context.Context, r *pb.HashKVRangeRequest) (*pb.HashKVRangeResponse, error) {
+	return mp.maintenanceClient.HashKVRange(ctx, r)
+}
+
+func (mp *maintenanceProxy) HotKeys(ctx context.Context, r *pb.HotKeysRequest) (*pb.HotKeysResponse, error) {
+	return mp.maintenanceClient.HotKeys(ctx, r)
+}
+
 func (mp *maintenanceProxy) Alarm(ctx context.Context, r *pb.AlarmRequest) (*pb.AlarmResponse, error) {
 	return mp.maintenanceClient.Alarm(ctx, r)
 }
@@ -85,3 +93,37 @@ func (mp *maintenanceProxy) MoveLeader(ctx context.Context, r *pb.MoveLeaderRequ
 func (mp *maintenanceProxy) Downgrade(ctx context.Context, r *pb.DowngradeRequest) (*pb.DowngradeResponse, error) {
 	return mp.maintenanceClient.Downgrade(ctx, r)
 }
+
+func (mp *maintenanceProxy) Drain(ctx context.Context, r *pb.DrainRequest) (*pb.DrainResponse, error) {
+	return mp.maintenanceClient.Drain(ctx, r)
+}
+
+func (mp *maintenanceProxy) RotateEncryptionKey(ctx context.Context, r *pb.RotateEncryptionKeyRequest) (*pb.RotateEncryptionKeyResponse, error) {
+	return mp.maintenanceClient.RotateEncryptionKey(ctx, r)
+}
+
+func (mp *maintenanceProxy) ClusterEvents(r *pb.ClusterEventsRequest, stream pb.Maintenance_ClusterEventsServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	ctx = withClientAuthToken(ctx, stream.Context())
+
+	sc, err := mp.maintenanceClient.ClusterEvents(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	for {
+		rr, err := sc.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		err = stream.Send(rr)
+		if err != nil {
+			return err
+		}
+	}
+}
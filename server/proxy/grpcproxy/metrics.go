@@ -20,6 +20,7 @@ import (
 	"math/rand"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -59,14 +60,67 @@ var (
 		Name:      "cache_misses_total",
 		Help:      "Total number of cache misses",
 	})
+	// cacheHitRatio is derived from the hits and misses of all the proxies
+	// of the process.
+	cacheHitRatio = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "grpc_proxy",
+		Name:      "cache_hit_ratio",
+		Help:      "Ratio of serializable range requests served from the cache",
+	}, func() float64 {
+		hits, misses := cacheLookupHits.Load(), cacheLookupMisses.Load()
+		if hits+misses == 0 {
+			return 0
+		}
+		return float64(hits) / float64(hits+misses)
+	})
+	activeWatchBroadcasts = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "grpc_proxy",
+		Name:      "watch_broadcasts",
+		Help:      "Total number of current watchers on the etcd cluster serving client watchers",
+	})
+	watchBroadcastFanout = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "etcd",
+		Subsystem: "grpc_proxy",
+		Name:      "watch_broadcast_fanout",
+		Help:      "Number of client watchers each watch response is broadcast to",
+
+		// lowest bucket start of upper bound 1 with factor 2
+		// highest bucket start of 1 * 2^11 == 2048
+		Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+	})
+	splitRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "grpc_proxy",
+		Name:      "split_requests_total",
+		Help:      "Total number of requests and watches routed by the read/write split to the leader or a follower",
+	}, []string{"target"})
 )
 
+var cacheLookupHits, cacheLookupMisses atomic.Uint64
+
+// observeCacheLookup records a hit or a miss of the range cache.
+func observeCacheLookup(hit bool) {
+	if hit {
+		cacheHits.Inc()
+		cacheLookupHits.Add(1)
+		return
+	}
+	cachedMisses.Inc()
+	cacheLookupMisses.Add(1)
+}
+
 func init() {
 	prometheus.MustRegister(watchersCoalescing)
 	prometheus.MustRegister(eventsCoalescing)
 	prometheus.MustRegister(cacheKeys)
 	prometheus.MustRegister(cacheHits)
 	prometheus.MustRegister(cachedMisses)
+	prometheus.MustRegister(cacheHitRatio)
+	prometheus.MustRegister(activeWatchBroadcasts)
+	prometheus.MustRegister(watchBroadcastFanout)
+	prometheus.MustRegister(splitRequests)
 }
 
 // HandleMetrics performs a GET request against etcd endpoint and returns '/metrics'.
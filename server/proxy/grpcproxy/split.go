@@ -0,0 +1,358 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// DefaultSplitRefreshInterval is the default interval at which the split
+// refreshes the leader and the nearest follower.
+const DefaultSplitRefreshInterval = 10 * time.Second
+
+// DefaultSplitStatusTimeout is the default timeout of the status request
+// sent to each member on refresh.
+const DefaultSplitStatusTimeout = 2 * time.Second
+
+// MemberZoneLabel is the member label giving the zone of the member.
+const MemberZoneLabel = "zone"
+
+// SplitConfig configures the read/write split of the proxy.
+type SplitConfig struct {
+	// Zone is the zone of the proxy. Serializable reads and watches are
+	// routed to followers in the same zone if any.
+	Zone string
+	// MemberZones maps the names of the members to their zone. Members not
+	// in the map are in the zone given by their "zone" label.
+	MemberZones map[string]string
+	// RefreshInterval is the interval at which the leader and the nearest
+	// follower are refreshed. Defaults to DefaultSplitRefreshInterval.
+	RefreshInterval time.Duration
+	// StatusTimeout is the timeout of the status request sent to each
+	// member on refresh. Defaults to DefaultSplitStatusTimeout.
+	StatusTimeout time.Duration
+	// NewClient creates a client connected to the given endpoints only.
+	NewClient func(endpoints []string) (*clientv3.Client, error)
+}
+
+// Split routes the linearizable requests of the proxy to the leader, and
+// the serializable reads and the watches to the nearest follower: a
+// follower in the zone of the proxy if any, else the follower answering
+// the fastest. Requests fall back to the proxy client while the leader or
+// the followers are unknown.
+type Split struct {
+	lg  *zap.Logger
+	c   *clientv3.Client
+	cfg SplitConfig
+	// kv and w are the KV and Watcher of the proxy client, used while the
+	// leader or the followers are unknown.
+	kv clientv3.KV
+	w  clientv3.Watcher
+
+	mu sync.RWMutex
+	// clients are the clients connected to each member, kept until the
+	// member is removed so the watches on them aren't interrupted by a
+	// change of routes.
+	clients  map[uint64]*clientv3.Client
+	leaderID uint64
+	readerID uint64
+}
+
+// NewSplit creates a read/write split of the requests sent through the
+// client, refreshed until the context is done.
+func NewSplit(ctx context.Context, lg *zap.Logger, c *clientv3.Client, cfg SplitConfig) *Split {
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = DefaultSplitRefreshInterval
+	}
+	if cfg.StatusTimeout <= 0 {
+		cfg.StatusTimeout = DefaultSplitStatusTimeout
+	}
+	s := &Split{
+		lg:      lg,
+		c:       c,
+		cfg:     cfg,
+		kv:      c.KV,
+		w:       c.Watcher,
+		clients: make(map[uint64]*clientv3.Client),
+	}
+	if err := s.refresh(ctx); err != nil {
+		lg.Warn("failed to refresh read/write split", zap.Error(err))
+	}
+	go s.run(ctx)
+	return s
+}
+
+// KV returns a KV routing linearizable requests to the leader and
+// serializable reads to the nearest follower.
+func (s *Split) KV() clientv3.KV { return &splitKV{s} }
+
+// Watcher returns a Watcher creating its watches on the nearest follower.
+func (s *Split) Watcher() clientv3.Watcher { return &splitWatcher{s} }
+
+// Targets returns the IDs of the members the requests are routed to, or 0
+// while a member is unknown.
+func (s *Split) Targets() (leader, reader uint64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.leaderID, s.readerID
+}
+
+func (s *Split) run(ctx context.Context) {
+	t := time.NewTicker(s.cfg.RefreshInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if err := s.refresh(ctx); err != nil {
+				s.lg.Warn("failed to refresh read/write split", zap.Error(err))
+			}
+		case <-ctx.Done():
+			s.mu.Lock()
+			for id, c := range s.clients {
+				c.Close()
+				delete(s.clients, id)
+			}
+			s.leaderID, s.readerID = 0, 0
+			s.mu.Unlock()
+			return
+		}
+	}
+}
+
+type splitMember struct {
+	m      *pb.Member
+	leader bool
+	rtt    time.Duration
+	err    error
+}
+
+func (s *Split) refresh(ctx context.Context) error {
+	mresp, err := s.c.MemberList(ctx)
+	if err != nil {
+		return err
+	}
+	members := make(map[uint64]*pb.Member)
+	var polled []splitMember
+	for _, m := range mresp.Members {
+		if len(m.ClientURLs) == 0 {
+			continue
+		}
+		members[m.ID] = m
+		polled = append(polled, splitMember{m: m})
+	}
+	// the members are polled concurrently so that an unreachable member
+	// doesn't delay the refresh by more than the status timeout
+	var wg sync.WaitGroup
+	for i := range polled {
+		wg.Add(1)
+		go func(sm *splitMember) {
+			defer wg.Done()
+			s.poll(ctx, sm)
+		}(&polled[i])
+	}
+	wg.Wait()
+
+	var leaderID uint64
+	var followers []splitMember
+	var errs []error
+	for _, sm := range polled {
+		switch {
+		case sm.err != nil:
+			errs = append(errs, sm.err)
+		case sm.leader:
+			leaderID = sm.m.ID
+		default:
+			followers = append(followers, sm)
+		}
+	}
+	sort.Slice(followers, func(i, j int) bool {
+		zi := s.sameZone(followers[i].m)
+		if zi != s.sameZone(followers[j].m) {
+			return zi
+		}
+		return followers[i].rtt < followers[j].rtt
+	})
+	readerID := leaderID
+	if len(followers) > 0 {
+		readerID = followers[0].m.ID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, c := range s.clients {
+		if _, ok := members[id]; !ok {
+			c.Close()
+			delete(s.clients, id)
+		}
+	}
+	if s.leaderID != leaderID || s.readerID != readerID {
+		s.lg.Info(
+			"updated read/write split",
+			zap.Stringer("leader", types.ID(leaderID)),
+			zap.Stringer("reader", types.ID(readerID)),
+		)
+	}
+	s.leaderID, s.readerID = leaderID, readerID
+	if _, ok := s.clients[leaderID]; !ok {
+		s.leaderID = 0
+	}
+	if _, ok := s.clients[readerID]; !ok {
+		s.readerID = 0
+	}
+	return errors.Join(errs...)
+}
+
+// poll sets whether the member is the leader and the round trip time of its
+// status request, or the error getting its status.
+func (s *Split) poll(ctx context.Context, sm *splitMember) {
+	c, err := s.connect(sm.m)
+	if err != nil {
+		sm.err = err
+		return
+	}
+	start := time.Now()
+	sctx, cancel := context.WithTimeout(ctx, s.cfg.StatusTimeout)
+	st, err := pb.NewMaintenanceClient(c.ActiveConnection()).Status(sctx, &pb.StatusRequest{})
+	cancel()
+	if err != nil {
+		sm.err = fmt.Errorf("status of member %s: %w", sm.m.Name, err)
+		return
+	}
+	sm.leader, sm.rtt = st.Leader == sm.m.ID, time.Since(start)
+}
+
+func (s *Split) sameZone(m *pb.Member) bool {
+	zone, ok := s.cfg.MemberZones[m.Name]
+	if !ok {
+		zone = m.Labels[MemberZoneLabel]
+	}
+	return s.cfg.Zone != "" && zone == s.cfg.Zone
+}
+
+// connect returns the client connected to the member, creating it if it
+// doesn't exist.
+func (s *Split) connect(m *pb.Member) (*clientv3.Client, error) {
+	s.mu.RLock()
+	c, ok := s.clients[m.ID]
+	s.mu.RUnlock()
+	if ok {
+		return c, nil
+	}
+	c, err := s.cfg.NewClient(m.ClientURLs)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cur, ok := s.clients[m.ID]; ok {
+		c.Close()
+		return cur, nil
+	}
+	s.clients[m.ID] = c
+	return c, nil
+}
+
+// leader returns the KV of the leader.
+func (s *Split) leader() clientv3.KV {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	splitRequests.WithLabelValues("leader").Inc()
+	if c, ok := s.clients[s.leaderID]; ok {
+		return c.KV
+	}
+	return s.kv
+}
+
+// reader returns the client of the nearest follower, if known.
+func (s *Split) reader() *clientv3.Client {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	target := "follower"
+	if s.readerID == s.leaderID {
+		target = "leader"
+	}
+	splitRequests.WithLabelValues(target).Inc()
+	return s.clients[s.readerID]
+}
+
+func (s *Split) readerKV() clientv3.KV {
+	if c := s.reader(); c != nil {
+		return c.KV
+	}
+	return s.kv
+}
+
+func (s *Split) readerWatcher() clientv3.Watcher {
+	if c := s.reader(); c != nil {
+		return c.Watcher
+	}
+	return s.w
+}
+
+type splitKV struct{ s *Split }
+
+func (kv *splitKV) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	return kv.s.leader().Put(ctx, key, val, opts...)
+}
+
+func (kv *splitKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	if clientv3.OpGet(key, opts...).IsSerializable() {
+		return kv.s.readerKV().Get(ctx, key, opts...)
+	}
+	return kv.s.leader().Get(ctx, key, opts...)
+}
+
+func (kv *splitKV) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	return kv.s.leader().Delete(ctx, key, opts...)
+}
+
+func (kv *splitKV) Compact(ctx context.Context, rev int64, opts ...clientv3.CompactOption) (*clientv3.CompactResponse, error) {
+	return kv.s.leader().Compact(ctx, rev, opts...)
+}
+
+func (kv *splitKV) Do(ctx context.Context, op clientv3.Op) (clientv3.OpResponse, error) {
+	if op.IsGet() && op.IsSerializable() {
+		return kv.s.readerKV().Do(ctx, op)
+	}
+	return kv.s.leader().Do(ctx, op)
+}
+
+func (kv *splitKV) Txn(ctx context.Context) clientv3.Txn {
+	return kv.s.leader().Txn(ctx)
+}
+
+type splitWatcher struct{ s *Split }
+
+func (w *splitWatcher) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	return w.s.readerWatcher().Watch(ctx, key, opts...)
+}
+
+func (w *splitWatcher) RequestProgress(ctx context.Context) error {
+	return w.s.readerWatcher().RequestProgress(ctx)
+}
+
+// Close is a no-op; the watchers are closed with their clients.
+func (w *splitWatcher) Close() error { return nil }
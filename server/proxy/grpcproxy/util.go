@@ -18,7 +18,9 @@ import (
 	"context"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 )
@@ -34,14 +36,47 @@ func getAuthTokenFromClient(ctx context.Context) string {
 	return ""
 }
 
+// getCommonNameFromClient returns the common name of the verified client
+// certificate of an incoming request, if any.
+func getCommonNameFromClient(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p == nil || p.AuthInfo == nil {
+		return ""
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return ""
+	}
+	for _, chains := range tlsInfo.State.VerifiedChains {
+		if len(chains) > 0 {
+			return chains[0].Subject.CommonName
+		}
+	}
+	return ""
+}
+
+// clientCommonNameKey is the context key of the client certificate common
+// name for requests that outlive the incoming request context.
+type clientCommonNameKey struct{}
+
 func withClientAuthToken(ctx, ctxWithToken context.Context) context.Context {
 	token := getAuthTokenFromClient(ctxWithToken)
 	if token != "" {
 		ctx = context.WithValue(ctx, rpctypes.TokenFieldNameGRPCKey{}, token)
 	}
+	if cn := getCommonNameFromClient(ctxWithToken); cn != "" {
+		ctx = context.WithValue(ctx, clientCommonNameKey{}, cn)
+	}
 	return ctx
 }
 
+func withForwardedCommonName(ctx context.Context, cn string) context.Context {
+	if cn == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, rpctypes.MetadataForwardedCommonNameKey, cn)
+}
+
 type proxyTokenCredential struct {
 	token string
 }
@@ -73,3 +108,22 @@ func AuthStreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc
 	}
 	return streamer(ctx, desc, cc, method, opts...)
 }
+
+// ForwardIdentityUnaryClientInterceptor forwards the client certificate common
+// name of the incoming request to etcd. etcd authorizes the request as the
+// forwarded common name if the proxy's own common name is trusted with
+// --experimental-trusted-proxy-cn.
+func ForwardIdentityUnaryClientInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx = withForwardedCommonName(ctx, getCommonNameFromClient(ctx))
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// ForwardIdentityStreamClientInterceptor is the stream counterpart of
+// ForwardIdentityUnaryClientInterceptor.
+func ForwardIdentityStreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	cn, _ := ctx.Value(clientCommonNameKey{}).(string)
+	if cn == "" {
+		cn = getCommonNameFromClient(ctx)
+	}
+	return streamer(withForwardedCommonName(ctx, cn), desc, cc, method, opts...)
+}
@@ -52,6 +52,7 @@ func newWatchBroadcast(lg *zap.Logger, wp *watchProxy, w *watcher, update func(*
 		lg:        lg,
 	}
 	wb.add(w)
+	activeWatchBroadcasts.Inc()
 	go func() {
 		defer close(wb.donec)
 
@@ -89,6 +90,7 @@ func (wb *watchBroadcast) bcast(wr clientv3.WatchResponse) {
 	}
 	if len(wb.receivers) > 0 {
 		eventsCoalescing.Add(float64(len(wb.receivers) - 1))
+		watchBroadcastFanout.Observe(float64(len(wb.receivers)))
 	}
 }
 
@@ -154,6 +156,7 @@ func (wb *watchBroadcast) stop() {
 	}
 
 	wb.cancel()
+	activeWatchBroadcasts.Dec()
 
 	select {
 	case <-wb.donec:
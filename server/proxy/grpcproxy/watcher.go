@@ -111,6 +111,7 @@ func (w *watcher) send(wr clientv3.WatchResponse) {
 		Header:          &wr.Header,
 		Created:         wr.Created,
 		CompactRevision: wr.CompactRevision,
+		ResyncRevision:  wr.ResyncRevision,
 		Canceled:        wr.Canceled,
 		WatchId:         w.id,
 		Events:          events,
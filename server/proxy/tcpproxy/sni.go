@@ -0,0 +1,96 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcpproxy
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"time"
+)
+
+var errHelloRead = errors.New("tcpproxy: client hello read")
+
+// peekServerName reads the TLS ClientHello from in and returns the server
+// name it carries, along with a connection that replays the bytes consumed
+// so the handshake can be completed by the endpoint. An empty server name
+// is returned for connections that do not send SNI, and for connections that
+// are not TLS or send nothing before the timeout, so that they go to the
+// default endpoints. An error is only returned if reading from in fails.
+func (tp *TCPProxy) peekServerName(in net.Conn) (net.Conn, string, error) {
+	var buf bytes.Buffer
+	if err := in.SetReadDeadline(time.Now().Add(tp.SNITimeout)); err != nil {
+		return in, "", err
+	}
+	er := &errReader{r: in}
+	var serverName string
+	err := tls.Server(readOnlyConn{r: io.TeeReader(er, &buf)}, &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			serverName = hello.ServerName
+			// abort the handshake; it is completed by the endpoint
+			return nil, errHelloRead
+		},
+	}).Handshake()
+	replay := &replayConn{Conn: in, r: io.MultiReader(&buf, in)}
+	var nerr net.Error
+	if er.err != nil && !(errors.As(er.err, &nerr) && nerr.Timeout()) {
+		return replay, "", er.err
+	}
+	if !errors.Is(err, errHelloRead) {
+		serverName = ""
+	}
+	return replay, serverName, in.SetReadDeadline(time.Time{})
+}
+
+// errReader records the error reading from r.
+type errReader struct {
+	r   io.Reader
+	err error
+}
+
+func (er *errReader) Read(p []byte) (int, error) {
+	n, err := er.r.Read(p)
+	if err != nil {
+		er.err = err
+	}
+	return n, err
+}
+
+// readOnlyConn lets crypto/tls parse a ClientHello without writing anything
+// back to the client.
+type readOnlyConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c readOnlyConn) Read(p []byte) (int, error)         { return c.r.Read(p) }
+func (c readOnlyConn) Write(p []byte) (int, error)        { return 0, io.ErrClosedPipe }
+func (c readOnlyConn) Close() error                       { return nil }
+func (c readOnlyConn) LocalAddr() net.Addr                { return nil }
+func (c readOnlyConn) RemoteAddr() net.Addr               { return nil }
+func (c readOnlyConn) SetDeadline(t time.Time) error      { return nil }
+func (c readOnlyConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c readOnlyConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// replayConn returns the bytes read while peeking before reading from the
+// underlying connection.
+type replayConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *replayConn) Read(p []byte) (int, error) { return c.r.Read(p) }
@@ -15,10 +15,12 @@
 package tcpproxy
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"math/rand"
 	"net"
+	"strings"
 	"sync"
 	"time"
 
@@ -30,12 +32,17 @@ type remote struct {
 	srv      *net.SRV
 	addr     string
 	inactive bool
+
+	// consecutive results of active health checks
+	successes int
+	failures  int
 }
 
 func (r *remote) inactivate() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.inactive = true
+	r.successes = 0
 }
 
 func (r *remote) tryReactivate() error {
@@ -56,27 +63,111 @@ func (r *remote) isActive() bool {
 	return !r.inactive
 }
 
+// recordCheck records the result of an active health check and reports
+// whether the remote changed between active and inactive.
+func (r *remote) recordCheck(err error, healthyThreshold, unhealthyThreshold int) (changed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.successes = 0
+		r.failures++
+		if !r.inactive && r.failures >= unhealthyThreshold {
+			r.inactive = true
+			return true
+		}
+		return false
+	}
+	r.failures = 0
+	r.successes++
+	if r.inactive && r.successes >= healthyThreshold {
+		r.inactive = false
+		return true
+	}
+	return false
+}
+
+// HealthCheckFunc checks whether the endpoint at addr can serve requests.
+type HealthCheckFunc func(ctx context.Context, addr string) error
+
+// DialHealthCheck is the default HealthCheckFunc. It reports an endpoint as
+// healthy if a TCP connection to it can be established.
+func DialHealthCheck(ctx context.Context, addr string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
 type TCPProxy struct {
 	Logger          *zap.Logger
 	Listener        net.Listener
 	Endpoints       []*net.SRV
 	MonitorInterval time.Duration
 
+	// SNIRoutes maps a TLS server name to the endpoints serving it. When set,
+	// the proxy inspects the TLS ClientHello of each connection, without
+	// terminating TLS, and routes it to the endpoints of the matching server
+	// name. Connections without a matching server name go to Endpoints.
+	SNIRoutes map[string][]*net.SRV
+	// SNITimeout bounds the time to wait for a ClientHello. Defaults to 10s.
+	SNITimeout time.Duration
+
+	// HealthCheckInterval enables active health checking of all endpoints
+	// when non-zero. Endpoints are ejected after UnhealthyThreshold
+	// consecutive failed checks and readmitted after HealthyThreshold
+	// consecutive successful checks. When zero, endpoints are only ejected
+	// on dial failures and retried every MonitorInterval.
+	HealthCheckInterval time.Duration
+	// HealthCheckTimeout bounds a single health check. Defaults to 5s.
+	HealthCheckTimeout time.Duration
+	// HealthCheck defaults to DialHealthCheck.
+	HealthCheck        HealthCheckFunc
+	HealthyThreshold   int
+	UnhealthyThreshold int
+
 	donec chan struct{}
 
 	mu        sync.Mutex // guards the following fields
 	remotes   []*remote
+	routes    map[string][]*remote
 	pickCount int // for round robin
 }
 
+func newRemotes(srvs []*net.SRV) []*remote {
+	var remotes []*remote
+	for _, srv := range srvs {
+		addr := net.JoinHostPort(srv.Target, fmt.Sprintf("%d", srv.Port))
+		remotes = append(remotes, &remote{srv: srv, addr: addr})
+	}
+	return remotes
+}
+
 func (tp *TCPProxy) Run() error {
 	tp.donec = make(chan struct{})
 	if tp.MonitorInterval == 0 {
 		tp.MonitorInterval = 5 * time.Minute
 	}
-	for _, srv := range tp.Endpoints {
-		addr := net.JoinHostPort(srv.Target, fmt.Sprintf("%d", srv.Port))
-		tp.remotes = append(tp.remotes, &remote{srv: srv, addr: addr})
+	if tp.SNITimeout == 0 {
+		tp.SNITimeout = 10 * time.Second
+	}
+	if tp.HealthCheckTimeout == 0 {
+		tp.HealthCheckTimeout = 5 * time.Second
+	}
+	if tp.HealthCheck == nil {
+		tp.HealthCheck = DialHealthCheck
+	}
+	if tp.HealthyThreshold <= 0 {
+		tp.HealthyThreshold = 1
+	}
+	if tp.UnhealthyThreshold <= 0 {
+		tp.UnhealthyThreshold = 1
+	}
+	tp.remotes = newRemotes(tp.Endpoints)
+	tp.routes = make(map[string][]*remote, len(tp.SNIRoutes))
+	for name, srvs := range tp.SNIRoutes {
+		tp.routes[strings.ToLower(name)] = newRemotes(srvs)
 	}
 
 	var eps []string
@@ -85,9 +176,20 @@ func (tp *TCPProxy) Run() error {
 	}
 	if tp.Logger != nil {
 		tp.Logger.Info("ready to proxy client requests", zap.Strings("endpoints", eps))
+		for name, remotes := range tp.routes {
+			var addrs []string
+			for _, r := range remotes {
+				addrs = append(addrs, r.addr)
+			}
+			tp.Logger.Info("ready to proxy client requests by server name", zap.String("server-name", name), zap.Strings("endpoints", addrs))
+		}
 	}
 
-	go tp.runMonitor()
+	if tp.HealthCheckInterval > 0 {
+		go tp.runHealthCheck()
+	} else {
+		go tp.runMonitor()
+	}
 	for {
 		in, err := tp.Listener.Accept()
 		if err != nil {
@@ -98,14 +200,14 @@ func (tp *TCPProxy) Run() error {
 	}
 }
 
-func (tp *TCPProxy) pick() *remote {
+func (tp *TCPProxy) pick(remotes []*remote) *remote {
 	var weighted []*remote
 	var unweighted []*remote
 
 	bestPr := uint16(65535)
 	w := 0
 	// find best priority class
-	for _, r := range tp.remotes {
+	for _, r := range remotes {
 		switch {
 		case !r.isActive():
 		case r.srv.Priority < bestPr:
@@ -144,8 +246,8 @@ func (tp *TCPProxy) pick() *remote {
 		}
 	}
 	if unweighted != nil {
-		for i := 0; i < len(tp.remotes); i++ {
-			picked := tp.remotes[tp.pickCount%len(tp.remotes)]
+		for i := 0; i < len(remotes); i++ {
+			picked := remotes[tp.pickCount%len(remotes)]
 			tp.pickCount++
 			if picked.isActive() {
 				return picked
@@ -161,9 +263,25 @@ func (tp *TCPProxy) serve(in net.Conn) {
 		out net.Conn
 	)
 
+	remotes := tp.remotes
+	if len(tp.routes) > 0 {
+		var serverName string
+		in, serverName, err = tp.peekServerName(in)
+		if err != nil {
+			if tp.Logger != nil {
+				tp.Logger.Warn("failed to read TLS server name", zap.String("remote-address", in.RemoteAddr().String()), zap.Error(err))
+			}
+			in.Close()
+			return
+		}
+		if rs, ok := tp.routes[strings.ToLower(serverName)]; ok {
+			remotes = rs
+		}
+	}
+
 	for {
 		tp.mu.Lock()
-		remote := tp.pick()
+		remote := tp.pick(remotes)
 		tp.mu.Unlock()
 		if remote == nil {
 			break
@@ -223,6 +341,49 @@ func (tp *TCPProxy) runMonitor() {
 	}
 }
 
+func (tp *TCPProxy) allRemotes() []*remote {
+	remotes := append([]*remote{}, tp.remotes...)
+	for _, rs := range tp.routes {
+		remotes = append(remotes, rs...)
+	}
+	return remotes
+}
+
+func (tp *TCPProxy) runHealthCheck() {
+	ticker := time.NewTicker(tp.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			var wg sync.WaitGroup
+			for _, rem := range tp.allRemotes() {
+				wg.Add(1)
+				go func(r *remote) {
+					defer wg.Done()
+					tp.checkRemote(r)
+				}(rem)
+			}
+			wg.Wait()
+		case <-tp.donec:
+			return
+		}
+	}
+}
+
+func (tp *TCPProxy) checkRemote(r *remote) {
+	ctx, cancel := context.WithTimeout(context.Background(), tp.HealthCheckTimeout)
+	err := tp.HealthCheck(ctx, r.addr)
+	cancel()
+	if !r.recordCheck(err, tp.HealthyThreshold, tp.UnhealthyThreshold) || tp.Logger == nil {
+		return
+	}
+	if err != nil {
+		tp.Logger.Warn("ejected unhealthy endpoint", zap.String("address", r.addr), zap.Int("unhealthy-threshold", tp.UnhealthyThreshold), zap.Error(err))
+	} else {
+		tp.Logger.Info("readmitted healthy endpoint", zap.String("address", r.addr), zap.Int("healthy-threshold", tp.HealthyThreshold))
+	}
+}
+
 func (tp *TCPProxy) Stop() {
 	// graceful shutdown?
 	// shutdown current connections?
@@ -15,13 +15,18 @@
 package tcpproxy
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestUserspaceProxy(t *testing.T) {
@@ -129,3 +134,163 @@ func TestUserspaceProxyPriority(t *testing.T) {
 		t.Errorf("got = %s, want %s", got, want)
 	}
 }
+
+func TestUserspaceProxyHealthCheck(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	var eps []*net.SRV
+	var front *url.URL
+	for _, payload := range []string{"hello proxy 1", "hello proxy 2"} {
+		payload := payload
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, payload)
+		}))
+		defer ts.Close()
+
+		front, err = url.Parse(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var port uint16
+		fmt.Sscanf(front.Port(), "%d", &port)
+		eps = append(eps, &net.SRV{Target: front.Hostname(), Port: port})
+	}
+	unhealthy := net.JoinHostPort(eps[0].Target, fmt.Sprintf("%d", eps[0].Port))
+
+	var mu sync.Mutex
+	failing := true
+	p := TCPProxy{
+		Listener:            l,
+		Endpoints:           eps,
+		HealthCheckInterval: 10 * time.Millisecond,
+		HealthCheck: func(ctx context.Context, addr string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if failing && addr == unhealthy {
+				return errors.New("unhealthy")
+			}
+			return nil
+		},
+		UnhealthyThreshold: 2,
+	}
+	go p.Run()
+	defer p.Stop()
+
+	front.Host = l.Addr().String()
+	// every request needs its own connection to be routed by the proxy
+	cli := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	get := func() string {
+		res, err := cli.Get(front.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		got, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(got)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	for i := 0; i < 10; i++ {
+		if got := get(); got != "hello proxy 2" {
+			t.Fatalf("got = %s from ejected endpoint", got)
+		}
+	}
+
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+	time.Sleep(100 * time.Millisecond)
+	seen := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		seen[get()] = true
+	}
+	if !seen["hello proxy 1"] {
+		t.Errorf("expected readmitted endpoint to serve requests, got %v", seen)
+	}
+}
+
+func TestUserspaceProxySNI(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	newBackend := func(payload string) (*httptest.Server, *net.SRV) {
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, payload)
+		}))
+		u, err := url.Parse(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var port uint16
+		fmt.Sscanf(u.Port(), "%d", &port)
+		return ts, &net.SRV{Target: u.Hostname(), Port: port}
+	}
+	defaultTS, defaultEp := newBackend("default cluster")
+	defer defaultTS.Close()
+	fooTS, fooEp := newBackend("foo cluster")
+	defer fooTS.Close()
+
+	p := TCPProxy{
+		Listener:  l,
+		Endpoints: []*net.SRV{defaultEp},
+		SNIRoutes: map[string][]*net.SRV{"foo.example.com": {fooEp}},
+	}
+	go p.Run()
+	defer p.Stop()
+
+	tests := []struct {
+		serverName string
+		want       string
+	}{
+		{"foo.example.com", "foo cluster"},
+		{"FOO.example.com", "foo cluster"},
+		{"bar.example.com", "default cluster"},
+		{"", "default cluster"},
+	}
+	for _, tt := range tests {
+		tr := defaultTS.Client().Transport.(*http.Transport).Clone()
+		tr.TLSClientConfig.InsecureSkipVerify = true
+		tr.TLSClientConfig.ServerName = tt.serverName
+		cli := &http.Client{Transport: tr}
+
+		res, err := cli.Get("https://" + l.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, gerr := io.ReadAll(res.Body)
+		res.Body.Close()
+		if gerr != nil {
+			t.Fatal(gerr)
+		}
+		if string(got) != tt.want {
+			t.Errorf("server name %q: got = %s, want %s", tt.serverName, got, tt.want)
+		}
+		tr.CloseIdleConnections()
+	}
+
+	// connections that are not TLS go to the default endpoints
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fmt.Fprint(conn, "GET / HTTP/1.0\r\n\r\n")
+	res, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the default endpoint answers plain HTTP on its TLS port with an error
+	if !strings.Contains(string(res), "HTTP/1.0 400 Bad Request") {
+		t.Errorf("expected a non-TLS connection forwarded to the default endpoint, got %q", res)
+	}
+}
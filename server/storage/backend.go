@@ -51,6 +51,8 @@ func newBackend(cfg config.ServerConfig, hooks backend.Hooks) backend.Backend {
 		bcfg.MmapSize = uint64(cfg.QuotaBackendBytes + cfg.QuotaBackendBytes/10)
 	}
 	bcfg.Mlock = cfg.ExperimentalMemoryMlock
+	bcfg.Engine = cfg.BackendEngine
+	bcfg.OnlineDefrag = cfg.ExperimentalOnlineDefrag
 	bcfg.Hooks = hooks
 	return backend.New(bcfg)
 }
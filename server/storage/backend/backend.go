@@ -19,7 +19,6 @@ import (
 	"hash/crc32"
 	"io"
 	"os"
-	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -78,6 +77,10 @@ type Snapshot interface {
 	Size() int64
 	// WriteTo writes the snapshot into the given writer.
 	WriteTo(w io.Writer) (n int64, err error)
+	// ForEachFrom calls fn with the key-value pairs of the bucket whose keys
+	// are not less than from, in ascending key order. It returns
+	// ErrBucketNotFound if the snapshot has no such bucket.
+	ForEachFrom(bucket Bucket, from []byte, fn func(k, v []byte) error) error
 	// Close closes the snapshot.
 	Close() error
 }
@@ -103,14 +106,18 @@ type backend struct {
 	// mlock prevents backend database file to be swapped
 	mlock bool
 
-	mu    sync.RWMutex
-	bopts *bolt.Options
-	db    *bolt.DB
+	mu     sync.RWMutex
+	bcfg   BackendConfig
+	engine EngineType
+	db     Engine
 
 	batchInterval time.Duration
 	batchLimit    int
 	batchTx       *batchTxBuffered
 
+	// defragMu serializes defragmentations.
+	defragMu sync.Mutex
+
 	readTx *readTx
 	// txReadBufferCache mirrors "txReadBuffer" within "readTx" -- readTx.baseReadTx.buf.
 	// When creating "concurrentReadTx":
@@ -136,6 +143,11 @@ type BackendConfig struct {
 	BatchInterval time.Duration
 	// BatchLimit is the maximum puts before flushing the BatchTx.
 	BatchLimit int
+	// Engine is the name of the storage engine of the backend file. If the
+	// file was written by another engine, it is converted on open. If empty,
+	// an existing file is opened with the engine that wrote it, and a new
+	// file is created with bbolt.
+	Engine string
 	// BackendFreelistType is the backend boltdb's freelist type.
 	BackendFreelistType bolt.FreelistType
 	// MmapSize is the number of bytes to mmap for the backend.
@@ -146,6 +158,14 @@ type BackendConfig struct {
 	UnsafeNoFsync bool `json:"unsafe-no-fsync"`
 	// Mlock prevents backend database file to be swapped
 	Mlock bool
+	// OnlineDefrag copies the backend for defragmentation without blocking
+	// reads and writes. Writes made during the copy are recorded and applied
+	// to the copy, and are only blocked while the last of them are applied and
+	// the file is replaced.
+	OnlineDefrag bool
+	// ReadOnly opens the backend file read-only. It is only supported by
+	// OpenEngine, for read-only transactions.
+	ReadOnly bool
 
 	// Hooks are getting executed during lifecycle of Backend's transactions.
 	Hooks Hooks
@@ -183,18 +203,11 @@ func NewDefaultBackend(lg *zap.Logger, path string, opts ...BackendConfigOption)
 }
 
 func newBackend(bcfg BackendConfig) *backend {
-	bopts := &bolt.Options{}
-	if boltOpenOptions != nil {
-		*bopts = *boltOpenOptions
+	db, engine, err := openEngine(bcfg)
+	if err != nil {
+		bcfg.Logger.Panic("failed to open database", zap.String("path", bcfg.Path), zap.String("engine", bcfg.Engine), zap.Error(err))
 	}
-	bopts.InitialMmapSize = bcfg.mmapSize()
-	bopts.FreelistType = bcfg.BackendFreelistType
-	bopts.NoSync = bcfg.UnsafeNoFsync
-	bopts.NoGrowSync = bcfg.UnsafeNoFsync
-	bopts.Mlock = bcfg.Mlock
-	bopts.Logger = newBoltLoggerZap(bcfg)
-
-	db, err := bolt.Open(bcfg.Path, 0600, bopts)
+	et, err := engineType(engine)
 	if err != nil {
 		bcfg.Logger.Panic("failed to open database", zap.String("path", bcfg.Path), zap.Error(err))
 	}
@@ -202,8 +215,9 @@ func newBackend(bcfg BackendConfig) *backend {
 	// In future, may want to make buffering optional for low-concurrency systems
 	// or dynamically swap between buffered/non-buffered depending on workload.
 	b := &backend{
-		bopts: bopts,
-		db:    db,
+		bcfg:   bcfg,
+		engine: et,
+		db:     db,
 
 		batchInterval: bcfg.BatchInterval,
 		batchLimit:    bcfg.BatchLimit,
@@ -211,11 +225,12 @@ func newBackend(bcfg BackendConfig) *backend {
 
 		readTx: &readTx{
 			baseReadTx: baseReadTx{
+				lg: bcfg.Logger,
 				buf: txReadBuffer{
 					txBuffer:   txBuffer{make(map[BucketID]*bucketBuffer)},
 					bufVersion: 0,
 				},
-				buckets: make(map[BucketID]*bolt.Bucket),
+				buckets: make(map[BucketID]EngineBucket),
 				txWg:    new(sync.WaitGroup),
 				txMu:    new(sync.RWMutex),
 			},
@@ -326,6 +341,7 @@ func (b *backend) ConcurrentReadTx() ReadTx {
 	// concurrentReadTx is not supposed to write to its txReadBuffer
 	return &concurrentReadTx{
 		baseReadTx: baseReadTx{
+			lg:      b.lg,
 			buf:     *buf,
 			txMu:    b.readTx.txMu,
 			tx:      b.readTx.tx,
@@ -389,22 +405,24 @@ func (b *backend) Hash(ignores func(bucketName, keyName []byte) bool) (uint32, e
 
 	b.mu.RLock()
 	defer b.mu.RUnlock()
-	err := b.db.View(func(tx *bolt.Tx) error {
-		c := tx.Cursor()
-		for next, _ := c.First(); next != nil; next, _ = c.Next() {
-			b := tx.Bucket(next)
-			if b == nil {
-				return fmt.Errorf("cannot get hash of bucket %s", next)
-			}
-			h.Write(next)
-			b.ForEach(func(k, v []byte) error {
-				if ignores != nil && !ignores(next, k) {
-					h.Write(k)
-					h.Write(v)
-				}
-				return nil
-			})
+	tx, err := b.db.Begin(false)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	err = tx.ForEachBucket(func(next []byte) error {
+		b := tx.Bucket(next)
+		if b == nil {
+			return fmt.Errorf("cannot get hash of bucket %s", next)
 		}
+		h.Write(next)
+		b.ForEach(func(k, v []byte) error {
+			if ignores != nil && !ignores(next, k) {
+				h.Write(k)
+				h.Write(v)
+			}
+			return nil
+		})
 		return nil
 	})
 
@@ -459,46 +477,15 @@ func (b *backend) Defrag() error {
 }
 
 func (b *backend) defrag() error {
+	b.defragMu.Lock()
+	defer b.defragMu.Unlock()
+
 	now := time.Now()
 	isDefragActive.Set(1)
 	defer isDefragActive.Set(0)
 
-	// TODO: make this non-blocking?
-	// lock batchTx to ensure nobody is using previous tx, and then
-	// close previous ongoing tx.
-	b.batchTx.LockOutsideApply()
-	defer b.batchTx.Unlock()
-
-	// lock database after lock tx to avoid deadlock.
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
-	// block concurrent read requests while resetting tx
-	b.readTx.Lock()
-	defer b.readTx.Unlock()
-
-	b.batchTx.unsafeCommit(true)
-
-	b.batchTx.tx = nil
-
 	// Create a temporary file to ensure we start with a clean slate.
-	// Snapshotter.cleanupSnapdir cleans up any of these that are found during startup.
-	dir := filepath.Dir(b.db.Path())
-	temp, err := os.CreateTemp(dir, "db.tmp.*")
-	if err != nil {
-		return err
-	}
-	options := bolt.Options{}
-	if boltOpenOptions != nil {
-		options = *boltOpenOptions
-	}
-	options.OpenFile = func(_ string, _ int, _ os.FileMode) (file *os.File, err error) {
-		return temp, nil
-	}
-	// Don't load tmp db into memory regardless of opening options
-	options.Mlock = false
-	tdbp := temp.Name()
-	tmpdb, err := bolt.Open(tdbp, 0600, &options)
+	tmpdb, tdbp, err := openTempEngine(b.engine, b.bcfg)
 	if err != nil {
 		return err
 	}
@@ -509,6 +496,7 @@ func (b *backend) defrag() error {
 		b.lg.Info(
 			"defragmenting",
 			zap.String("path", dbp),
+			zap.Bool("online", b.bcfg.OnlineDefrag),
 			zap.Int64("current-db-size-bytes", size1),
 			zap.String("current-db-size", humanize.Bytes(uint64(size1))),
 			zap.Int64("current-db-size-in-use-bytes", sizeInUse1),
@@ -516,43 +504,19 @@ func (b *backend) defrag() error {
 		)
 	}
 	// gofail: var defragBeforeCopy struct{}
-	err = defragdb(b.db, tmpdb, defragLimit)
+	if b.bcfg.OnlineDefrag {
+		err = b.defragOnline(tmpdb, tdbp)
+	} else {
+		err = b.defragOffline(tmpdb, tdbp)
+	}
 	if err != nil {
 		tmpdb.Close()
-		if rmErr := os.RemoveAll(tmpdb.Path()); rmErr != nil {
+		if rmErr := os.RemoveAll(tdbp); rmErr != nil {
 			b.lg.Error("failed to remove db.tmp after defragmentation completed", zap.Error(rmErr))
 		}
 		return err
 	}
 
-	err = b.db.Close()
-	if err != nil {
-		b.lg.Fatal("failed to close database", zap.Error(err))
-	}
-	err = tmpdb.Close()
-	if err != nil {
-		b.lg.Fatal("failed to close tmp database", zap.Error(err))
-	}
-	// gofail: var defragBeforeRename struct{}
-	err = os.Rename(tdbp, dbp)
-	if err != nil {
-		b.lg.Fatal("failed to rename tmp database", zap.Error(err))
-	}
-
-	b.db, err = bolt.Open(dbp, 0600, b.bopts)
-	if err != nil {
-		b.lg.Fatal("failed to open database", zap.String("path", dbp), zap.Error(err))
-	}
-	b.batchTx.tx = b.unsafeBegin(true)
-
-	b.readTx.reset()
-	b.readTx.tx = b.unsafeBegin(false)
-
-	size := b.readTx.tx.Size()
-	db := b.readTx.tx.DB()
-	atomic.StoreInt64(&b.size, size)
-	atomic.StoreInt64(&b.sizeInUse, size-(int64(db.Stats().FreePageN)*int64(db.Info().PageSize)))
-
 	took := time.Since(now)
 	defragSec.Observe(took.Seconds())
 
@@ -573,81 +537,81 @@ func (b *backend) defrag() error {
 	return nil
 }
 
-func defragdb(odb, tmpdb *bolt.DB, limit int) error {
-	// open a tx on tmpdb for writes
-	tmptx, err := tmpdb.Begin(true)
-	if err != nil {
+// defragOffline copies the backend into tmpdb while blocking all reads and
+// writes, and then replaces the backend file with it.
+func (b *backend) defragOffline(tmpdb Engine, tdbp string) error {
+	// lock batchTx to ensure nobody is using previous tx, and then
+	// close previous ongoing tx.
+	b.batchTx.LockOutsideApply()
+	defer b.batchTx.Unlock()
+
+	// lock database after lock tx to avoid deadlock.
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// block concurrent read requests while resetting tx
+	b.readTx.Lock()
+	defer b.readTx.Unlock()
+
+	b.batchTx.unsafeCommit(true)
+
+	b.batchTx.tx = nil
+
+	if err := copyEngine(b.db, tmpdb, defragLimit); err != nil {
 		return err
 	}
-	defer func() {
-		if err != nil {
-			tmptx.Rollback()
-		}
-	}()
+	b.unsafeSwapDB(tmpdb, tdbp)
+	return nil
+}
 
-	// open a tx on old db for read
-	tx, err := odb.Begin(false)
+// unsafeSwapDB replaces the backend file with the defragmented tmpdb and
+// begins new transactions on it. It must be called holding the locks on the
+// batch tx, the backend and the read tx, after the batch tx was stopped.
+func (b *backend) unsafeSwapDB(tmpdb Engine, tdbp string) {
+	dbp := b.db.Path()
+	err := b.db.Close()
 	if err != nil {
-		return err
+		b.lg.Fatal("failed to close database", zap.Error(err))
+	}
+	err = tmpdb.Close()
+	if err != nil {
+		b.lg.Fatal("failed to close tmp database", zap.Error(err))
+	}
+	// gofail: var defragBeforeRename struct{}
+	err = os.Rename(tdbp, dbp)
+	if err != nil {
+		b.lg.Fatal("failed to rename tmp database", zap.Error(err))
 	}
-	defer tx.Rollback()
-
-	c := tx.Cursor()
-
-	count := 0
-	for next, _ := c.First(); next != nil; next, _ = c.Next() {
-		b := tx.Bucket(next)
-		if b == nil {
-			return fmt.Errorf("backend: cannot defrag bucket %s", next)
-		}
 
-		tmpb, berr := tmptx.CreateBucketIfNotExists(next)
-		if berr != nil {
-			return berr
-		}
-		tmpb.FillPercent = 0.9 // for bucket2seq write in for each
-
-		if err = b.ForEach(func(k, v []byte) error {
-			count++
-			if count > limit {
-				err = tmptx.Commit()
-				if err != nil {
-					return err
-				}
-				tmptx, err = tmpdb.Begin(true)
-				if err != nil {
-					return err
-				}
-				tmpb = tmptx.Bucket(next)
-				tmpb.FillPercent = 0.9 // for bucket2seq write in for each
-
-				count = 0
-			}
-			return tmpb.Put(k, v)
-		}); err != nil {
-			return err
-		}
+	b.db, err = b.engine.Open(dbp, b.bcfg)
+	if err != nil {
+		b.lg.Fatal("failed to open database", zap.String("path", dbp), zap.Error(err))
 	}
+	b.batchTx.tx = b.unsafeBegin(true)
 
-	return tmptx.Commit()
+	b.readTx.reset()
+	b.readTx.tx = b.unsafeBegin(false)
+
+	size := b.readTx.tx.Size()
+	atomic.StoreInt64(&b.size, size)
+	atomic.StoreInt64(&b.sizeInUse, size-b.db.Stats().FreeBytes)
 }
 
-func (b *backend) begin(write bool) *bolt.Tx {
+func (b *backend) begin(write bool) EngineTx {
 	b.mu.RLock()
 	tx := b.unsafeBegin(write)
+	stats := b.db.Stats()
 	b.mu.RUnlock()
 
 	size := tx.Size()
-	db := tx.DB()
-	stats := db.Stats()
 	atomic.StoreInt64(&b.size, size)
-	atomic.StoreInt64(&b.sizeInUse, size-(int64(stats.FreePageN)*int64(db.Info().PageSize)))
-	atomic.StoreInt64(&b.openReadTxN, int64(stats.OpenTxN))
+	atomic.StoreInt64(&b.sizeInUse, size-stats.FreeBytes)
+	atomic.StoreInt64(&b.openReadTxN, stats.OpenReadTxN)
 
 	return tx
 }
 
-func (b *backend) unsafeBegin(write bool) *bolt.Tx {
+func (b *backend) unsafeBegin(write bool) EngineTx {
 	// gofail: var beforeStartDBTxn struct{}
 	tx, err := b.db.Begin(write)
 	// gofail: var afterStartDBTxn struct{}
@@ -662,30 +626,27 @@ func (b *backend) OpenReadTxN() int64 {
 }
 
 type snapshot struct {
-	*bolt.Tx
+	EngineTx
 	stopc chan struct{}
 	donec chan struct{}
 }
 
+func (s *snapshot) ForEachFrom(bucket Bucket, from []byte, fn func(k, v []byte) error) error {
+	b := s.EngineTx.Bucket(bucket.Name())
+	if b == nil {
+		return ErrBucketNotFound
+	}
+	c := b.Cursor()
+	for k, v := c.Seek(from); k != nil; k, v = c.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return c.Err()
+}
+
 func (s *snapshot) Close() error {
 	close(s.stopc)
 	<-s.donec
-	return s.Tx.Rollback()
-}
-
-func newBoltLoggerZap(bcfg BackendConfig) bolt.Logger {
-	lg := bcfg.Logger.Named("bbolt")
-	return &zapBoltLogger{lg.WithOptions(zap.AddCallerSkip(1)).Sugar()}
-}
-
-type zapBoltLogger struct {
-	*zap.SugaredLogger
-}
-
-func (zl *zapBoltLogger) Warning(args ...any) {
-	zl.SugaredLogger.Warn(args...)
-}
-
-func (zl *zapBoltLogger) Warningf(format string, args ...any) {
-	zl.SugaredLogger.Warnf(format, args...)
+	return s.EngineTx.Rollback()
 }
@@ -194,6 +194,76 @@ func TestBackendDefrag(t *testing.T) {
 	b.ForceCommit()
 }
 
+// TestBackendOnlineDefrag ensures writes made during an online defrag are
+// kept, for every storage engine.
+func TestBackendOnlineDefrag(t *testing.T) {
+	for _, engine := range []string{backend.EngineBolt, backend.EngineLog} {
+		t.Run(engine, func(t *testing.T) {
+			bcfg := backend.DefaultBackendConfig(zaptest.NewLogger(t))
+			bcfg.Engine = engine
+			bcfg.OnlineDefrag = true
+			b, _ := betesting.NewTmpBackendFromCfg(t, bcfg)
+			defer betesting.Close(t, b)
+
+			want := make(map[string]string)
+			tx := b.BatchTx()
+			tx.Lock()
+			tx.UnsafeCreateBucket(schema.Test)
+			for i := 0; i < backend.DefragLimitForTest()+100; i++ {
+				k := fmt.Sprintf("foo_%d", i)
+				tx.UnsafePut(schema.Test, []byte(k), []byte("bar"))
+				want[k] = "bar"
+			}
+			tx.Unlock()
+			b.ForceCommit()
+
+			donec := make(chan struct{})
+			writtenc := make(chan struct{})
+			go func() {
+				defer close(writtenc)
+				for i := 0; ; i++ {
+					select {
+					case <-donec:
+						return
+					default:
+					}
+					k, v := fmt.Sprintf("foo_%d", i%(backend.DefragLimitForTest()+200)), fmt.Sprintf("baz_%d", i)
+					tx.Lock()
+					if i%3 == 0 {
+						tx.UnsafeDelete(schema.Test, []byte(k))
+						delete(want, k)
+					} else {
+						tx.UnsafePut(schema.Test, []byte(k), []byte(v))
+						want[k] = v
+					}
+					tx.Unlock()
+				}
+			}()
+
+			err := b.Defrag()
+			close(donec)
+			<-writtenc
+			if err != nil {
+				t.Fatal(err)
+			}
+			b.ForceCommit()
+
+			got := make(map[string]string)
+			rtx := b.ReadTx()
+			rtx.RLock()
+			err = rtx.UnsafeForEach(schema.Test, func(k, v []byte) error {
+				got[string(k)] = string(v)
+				return nil
+			})
+			rtx.RUnlock()
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
 // TestBackendWriteback ensures writes are stored to the read txn on write txn unlock.
 func TestBackendWriteback(t *testing.T) {
 	b, _ := betesting.NewDefaultTmpBackend(t)
@@ -22,9 +22,6 @@ import (
 	"time"
 
 	"go.uber.org/zap"
-
-	bolt "go.etcd.io/bbolt"
-	bolterrors "go.etcd.io/bbolt/errors"
 )
 
 type BucketID int
@@ -71,10 +68,12 @@ type UnsafeWriter interface {
 
 type batchTx struct {
 	sync.Mutex
-	tx      *bolt.Tx
+	tx      EngineTx
 	backend *backend
 
 	pending int
+	// delta records writes while an online defragmentation is in progress.
+	delta *defragDelta
 }
 
 // Lock is supposed to be called only by the unit test.
@@ -120,18 +119,20 @@ func (t *batchTx) UnsafeCreateBucket(bucket Bucket) {
 			zap.Error(err),
 		)
 	}
+	t.delta.record(defragOpCreateBucket, bucket, nil, nil)
 	t.pending++
 }
 
 func (t *batchTx) UnsafeDeleteBucket(bucket Bucket) {
 	err := t.tx.DeleteBucket(bucket.Name())
-	if err != nil && err != bolterrors.ErrBucketNotFound {
+	if err != nil && err != ErrBucketNotFound {
 		t.backend.lg.Fatal(
 			"failed to delete a bucket",
 			zap.Stringer("bucket-name", bucket),
 			zap.Error(err),
 		)
 	}
+	t.delta.record(defragOpDeleteBucket, bucket, nil, nil)
 	t.pending++
 }
 
@@ -155,9 +156,7 @@ func (t *batchTx) unsafePut(bucketType Bucket, key []byte, value []byte, seq boo
 		)
 	}
 	if seq {
-		// it is useful to increase fill percent when the workloads are mostly append-only.
-		// this can delay the page split and reduce space usage.
-		bucket.FillPercent = 0.9
+		bucket.SetSequential()
 	}
 	if err := bucket.Put(key, value); err != nil {
 		t.backend.lg.Fatal(
@@ -166,6 +165,7 @@ func (t *batchTx) unsafePut(bucketType Bucket, key []byte, value []byte, seq boo
 			zap.Error(err),
 		)
 	}
+	t.delta.record(defragOpPut, bucketType, key, value)
 	t.pending++
 }
 
@@ -179,10 +179,18 @@ func (t *batchTx) UnsafeRange(bucketType Bucket, key, endKey []byte, limit int64
 			zap.Stack("stack"),
 		)
 	}
-	return unsafeRange(bucket.Cursor(), key, endKey, limit)
+	keys, vals, err := unsafeRange(bucket.Cursor(), key, endKey, limit)
+	if err != nil {
+		t.backend.lg.Fatal(
+			"failed to range a bucket",
+			zap.Stringer("bucket-name", bucketType),
+			zap.Error(err),
+		)
+	}
+	return keys, vals
 }
 
-func unsafeRange(c *bolt.Cursor, key, endKey []byte, limit int64) (keys [][]byte, vs [][]byte) {
+func unsafeRange(c EngineCursor, key, endKey []byte, limit int64) (keys [][]byte, vs [][]byte, err error) {
 	if limit <= 0 {
 		limit = math.MaxInt64
 	}
@@ -201,7 +209,7 @@ func unsafeRange(c *bolt.Cursor, key, endKey []byte, limit int64) (keys [][]byte
 			break
 		}
 	}
-	return keys, vs
+	return keys, vs, c.Err()
 }
 
 // UnsafeDelete must be called holding the lock on the tx.
@@ -222,6 +230,7 @@ func (t *batchTx) UnsafeDelete(bucketType Bucket, key []byte) {
 			zap.Error(err),
 		)
 	}
+	t.delta.record(defragOpDelete, bucketType, key, nil)
 	t.pending++
 }
 
@@ -230,7 +239,7 @@ func (t *batchTx) UnsafeForEach(bucket Bucket, visitor func(k, v []byte) error)
 	return unsafeForEach(t.tx, bucket, visitor)
 }
 
-func unsafeForEach(tx *bolt.Tx, bucket Bucket, visitor func(k, v []byte) error) error {
+func unsafeForEach(tx EngineTx, bucket Bucket, visitor func(k, v []byte) error) error {
 	if b := tx.Bucket(bucket.Name()); b != nil {
 		return b.ForEach(visitor)
 	}
@@ -270,9 +279,6 @@ func (t *batchTx) commit(stop bool) {
 		err := t.tx.Commit()
 		// gofail: var afterCommit struct{}
 
-		rebalanceSec.Observe(t.tx.Stats().RebalanceTime.Seconds())
-		spillSec.Observe(t.tx.Stats().SpillTime.Seconds())
-		writeSec.Observe(t.tx.Stats().WriteTime.Seconds())
 		commitSec.Observe(time.Since(start).Seconds())
 		atomic.AddInt64(&t.backend.commits, 1)
 
@@ -367,7 +373,7 @@ func (t *batchTxBuffered) unsafeCommit(stop bool) {
 	if t.backend.readTx.tx != nil {
 		// wait all store read transactions using the current boltdb tx to finish,
 		// then close the boltdb tx
-		go func(tx *bolt.Tx, wg *sync.WaitGroup) {
+		go func(tx EngineTx, wg *sync.WaitGroup) {
 			wg.Wait()
 			if err := tx.Rollback(); err != nil {
 				t.backend.lg.Fatal("failed to rollback tx", zap.Error(err))
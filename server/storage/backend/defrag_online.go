@@ -0,0 +1,255 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// defragCatchUpRounds is the maximum number of times the writes recorded
+// during an online defragmentation are applied to the copy without blocking
+// writes, before blocking them to apply the rest.
+var defragCatchUpRounds = 8
+
+type defragOpType uint8
+
+const (
+	defragOpPut defragOpType = iota
+	defragOpDelete
+	defragOpCreateBucket
+	defragOpDeleteBucket
+)
+
+type defragOp struct {
+	typ    defragOpType
+	bucket []byte
+	key    []byte
+	value  []byte
+}
+
+// defragDelta records the writes made through the batch tx while an online
+// defragmentation copies the backend. A nil *defragDelta records nothing.
+// It must be accessed holding the lock on the batch tx.
+type defragDelta struct {
+	ops []defragOp
+}
+
+// record copies key and value, which may point into the mmap of the backend,
+// as returned by UnsafeRange, or be reused by the caller once it returns.
+func (d *defragDelta) record(typ defragOpType, bucket Bucket, key, value []byte) {
+	if d == nil {
+		return
+	}
+	d.ops = append(d.ops, defragOp{typ: typ, bucket: bucket.Name(), key: bytes.Clone(key), value: bytes.Clone(value)})
+}
+
+// take returns the recorded writes and starts recording anew.
+func (d *defragDelta) take() []defragOp {
+	ops := d.ops
+	d.ops = nil
+	return ops
+}
+
+// defragOnline copies the backend into tmpdb in chunks of defragLimit keys,
+// each from a new read transaction, while recording the writes made in the
+// meantime. The recorded writes are then applied to tmpdb, repeatedly, until
+// few enough are left to apply them while writes are blocked, before the
+// backend file is replaced with tmpdb.
+//
+// Applying the writes in order leaves every key with its latest value: a key
+// copied from a later state than the start of the copy was only changed by
+// writes that were recorded, which are applied after it was copied.
+func (b *backend) defragOnline(tmpdb Engine, tdbp string) (err error) {
+	b.batchTx.LockOutsideApply()
+	// commit pending writes so that the copy includes all writes not recorded
+	b.batchTx.commit(false)
+	b.batchTx.delta = &defragDelta{}
+	b.batchTx.Unlock()
+	defer func() {
+		if err != nil {
+			b.batchTx.LockOutsideApply()
+			b.batchTx.delta = nil
+			b.batchTx.Unlock()
+		}
+	}()
+
+	var names [][]byte
+	err = b.viewDB(func(tx EngineTx) error {
+		return tx.ForEachBucket(func(name []byte) error {
+			names = append(names, bytes.Clone(name))
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err = b.copyBucketOnline(tmpdb, name); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < defragCatchUpRounds; i++ {
+		b.batchTx.LockOutsideApply()
+		ops := b.batchTx.delta.take()
+		b.batchTx.Unlock()
+		if err = applyDefragOps(tmpdb, ops, defragLimit); err != nil {
+			return err
+		}
+		if len(ops) <= defragLimit {
+			break
+		}
+	}
+
+	start := time.Now()
+	b.batchTx.LockOutsideApply()
+	defer b.batchTx.Unlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.readTx.Lock()
+	defer b.readTx.Unlock()
+
+	// the pre-commit hook may write, which is recorded as well
+	b.batchTx.unsafeCommit(true)
+	b.batchTx.tx = nil
+
+	ops := b.batchTx.delta.take()
+	b.batchTx.delta = nil
+	if err = applyDefragOps(tmpdb, ops, defragLimit); err != nil {
+		b.batchTx.tx = b.unsafeBegin(true)
+		b.readTx.tx = b.unsafeBegin(false)
+		return err
+	}
+	b.unsafeSwapDB(tmpdb, tdbp)
+
+	pause := time.Since(start)
+	defragPauseSec.Observe(pause.Seconds())
+	if b.lg != nil {
+		b.lg.Info("applied writes made during online defragmentation", zap.Int("writes", len(ops)), zap.Duration("pause", pause))
+	}
+	return nil
+}
+
+// viewDB calls fn with a new read-only transaction on the backend file.
+func (b *backend) viewDB(fn func(tx EngineTx) error) error {
+	b.mu.RLock()
+	tx, err := b.db.Begin(false)
+	b.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	return fn(tx)
+}
+
+// copyBucketOnline copies a bucket into dst, beginning a new read-only
+// transaction every defragLimit keys, so that none is held for long.
+func (b *backend) copyBucketOnline(dst Engine, name []byte) error {
+	var after []byte
+	for {
+		n := 0
+		err := b.viewDB(func(tx EngineTx) error {
+			bucket := tx.Bucket(name)
+			if bucket == nil {
+				// deleted since the copy started; the deletion was recorded
+				return nil
+			}
+
+			dtx, err := dst.Begin(true)
+			if err != nil {
+				return err
+			}
+			db, err := dtx.CreateBucketIfNotExists(name)
+			if err != nil {
+				dtx.Rollback()
+				return err
+			}
+			db.SetSequential()
+
+			c := bucket.Cursor()
+			k, v := c.Seek(after)
+			if after != nil && bytes.Equal(k, after) {
+				k, v = c.Next()
+			}
+			for ; k != nil && n < defragLimit; k, v = c.Next() {
+				if err = db.Put(k, v); err != nil {
+					dtx.Rollback()
+					return err
+				}
+				after = k
+				n++
+			}
+			if err = c.Err(); err != nil {
+				dtx.Rollback()
+				return err
+			}
+			// after outlives the transaction
+			after = bytes.Clone(after)
+			return dtx.Commit()
+		})
+		if err != nil || n < defragLimit {
+			return err
+		}
+	}
+}
+
+// applyDefragOps applies the writes to dst, committing every limit writes.
+func applyDefragOps(dst Engine, ops []defragOp, limit int) error {
+	for len(ops) > 0 {
+		n := min(len(ops), limit)
+		if err := applyDefragOpsTx(dst, ops[:n]); err != nil {
+			return err
+		}
+		ops = ops[n:]
+	}
+	return nil
+}
+
+func applyDefragOpsTx(dst Engine, ops []defragOp) error {
+	tx, err := dst.Begin(true)
+	if err != nil {
+		return err
+	}
+	for _, op := range ops {
+		switch op.typ {
+		case defragOpPut:
+			// the bucket might have been deleted before it was copied
+			var bucket EngineBucket
+			if bucket, err = tx.CreateBucketIfNotExists(op.bucket); err == nil {
+				err = bucket.Put(op.key, op.value)
+			}
+		case defragOpDelete:
+			if bucket := tx.Bucket(op.bucket); bucket != nil {
+				err = bucket.Delete(op.key)
+			}
+		case defragOpCreateBucket:
+			_, err = tx.CreateBucketIfNotExists(op.bucket)
+		case defragOpDeleteBucket:
+			if err = tx.DeleteBucket(op.bucket); err == ErrBucketNotFound {
+				err = nil
+			}
+		}
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,357 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// EngineBolt stores the backend in a bbolt B+tree file. It is the default.
+	EngineBolt = "bbolt"
+	// EngineLog stores the backend in an append-only log file, keeping only
+	// keys and the location of values in memory. Writes never rewrite pages,
+	// which suits workloads with large values. Space of overwritten values is
+	// reclaimed by defragmentation.
+	EngineLog = "log"
+)
+
+var (
+	ErrBucketNotFound = errors.New("backend: bucket not found")
+	ErrUnknownEngine  = errors.New("backend: unknown storage engine")
+)
+
+// Engine is a storage engine holding the backend's buckets of key-value
+// pairs in a single file. It provides serializable transactions: one
+// writable transaction at a time and any number of concurrent read-only
+// transactions, each seeing the data as of when it began.
+type Engine interface {
+	// Begin starts a transaction. Begin blocks while another writable
+	// transaction is open if writable is true.
+	Begin(writable bool) (EngineTx, error)
+	// Path returns the path of the engine's file.
+	Path() string
+	// Stats returns the engine's statistics.
+	Stats() EngineStats
+	// Close closes the engine, waiting for open read-only transactions.
+	Close() error
+}
+
+type EngineStats struct {
+	// FreeBytes is the number of allocated bytes not holding live data.
+	FreeBytes int64
+	// OpenReadTxN is the number of open read-only transactions.
+	OpenReadTxN int64
+}
+
+// EngineTx is a transaction of an Engine. Keys and values returned by a
+// transaction are only valid for its lifetime and must not be modified.
+type EngineTx interface {
+	// Bucket returns the bucket with the given name, or nil if there is none.
+	Bucket(name []byte) EngineBucket
+	CreateBucketIfNotExists(name []byte) (EngineBucket, error)
+	// DeleteBucket returns ErrBucketNotFound if there is no such bucket.
+	DeleteBucket(name []byte) error
+	// ForEachBucket calls fn with the name of each bucket in ascending order.
+	ForEachBucket(fn func(name []byte) error) error
+	// Size returns the size in bytes of the file as seen by the transaction,
+	// which is what WriteTo writes.
+	Size() int64
+	// WriteTo writes a copy of the file as seen by the transaction, which the
+	// engine can open.
+	WriteTo(w io.Writer) (int64, error)
+	Commit() error
+	Rollback() error
+}
+
+type EngineBucket interface {
+	// Cursor returns a cursor iterating the bucket in ascending key order.
+	Cursor() EngineCursor
+	ForEach(fn func(k, v []byte) error) error
+	Put(key, value []byte) error
+	Delete(key []byte) error
+	// SetSequential hints that keys are mostly written in ascending order.
+	SetSequential()
+}
+
+type EngineCursor interface {
+	// Seek moves the cursor to the first key not less than seek. It returns
+	// a nil key if there is none.
+	Seek(seek []byte) (key, value []byte)
+	// Next moves the cursor to the next key. It returns a nil key at the end.
+	Next() (key, value []byte)
+	// Err returns the error that stopped the cursor before the end, if any.
+	Err() error
+}
+
+// EngineChecker is implemented by the transactions of the storage engines
+// able to check the integrity of their file beyond what opening it checks.
+type EngineChecker interface {
+	// Check returns the inconsistencies found in the file.
+	Check() []error
+}
+
+// EngineType describes a storage engine the backend can be stored in.
+type EngineType struct {
+	Name string
+	// Open opens the engine's file at path, creating it if it does not exist
+	// or is empty.
+	Open func(path string, bcfg BackendConfig) (Engine, error)
+	// Match reports whether an existing file starting with header was
+	// written by the engine. A nil Match never matches, and files matched by
+	// no engine are opened with bbolt.
+	Match func(header []byte) bool
+}
+
+var (
+	engineTypesMu sync.RWMutex
+	engineTypes   = map[string]EngineType{}
+)
+
+// RegisterEngineType makes a storage engine available by its name.
+func RegisterEngineType(et EngineType) {
+	engineTypesMu.Lock()
+	defer engineTypesMu.Unlock()
+	engineTypes[et.Name] = et
+}
+
+func init() {
+	RegisterEngineType(EngineType{Name: EngineBolt, Open: openBoltEngine})
+	RegisterEngineType(EngineType{Name: EngineLog, Open: openLogEngine, Match: matchLogEngine})
+}
+
+// IsValidEngine returns true if the storage engine of the given name is
+// available.
+func IsValidEngine(name string) bool {
+	_, err := engineType(name)
+	return err == nil
+}
+
+func engineType(name string) (EngineType, error) {
+	engineTypesMu.RLock()
+	defer engineTypesMu.RUnlock()
+	et, ok := engineTypes[name]
+	if !ok {
+		return EngineType{}, fmt.Errorf("%w %q", ErrUnknownEngine, name)
+	}
+	return et, nil
+}
+
+// DetectEngine returns the name of the storage engine that wrote the file
+// at path, or an empty string if the file does not exist or is empty.
+func DetectEngine(path string) (string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	header := make([]byte, 4096)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	if n == 0 {
+		return "", nil
+	}
+
+	engineTypesMu.RLock()
+	defer engineTypesMu.RUnlock()
+	names := make([]string, 0, len(engineTypes))
+	for name := range engineTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if m := engineTypes[name].Match; m != nil && m(header[:n]) {
+			return name, nil
+		}
+	}
+	return EngineBolt, nil
+}
+
+// OpenEngine opens the backend file at bcfg.Path with the engine that wrote
+// it, e.g. to inspect the file offline.
+func OpenEngine(bcfg BackendConfig) (Engine, error) {
+	name, err := DetectEngine(bcfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = EngineBolt
+	}
+	et, err := engineType(name)
+	if err != nil {
+		return nil, err
+	}
+	return et.Open(bcfg.Path, bcfg)
+}
+
+// openEngine opens the backend file with the engine that wrote it. If
+// bcfg.Engine is set and differs, the file is first converted to it.
+func openEngine(bcfg BackendConfig) (Engine, string, error) {
+	name, err := DetectEngine(bcfg.Path)
+	if err != nil {
+		return nil, "", err
+	}
+	target := bcfg.Engine
+	if target == "" {
+		target = name
+	}
+	if target == "" {
+		target = EngineBolt
+	}
+	et, err := engineType(target)
+	if err != nil {
+		return nil, "", err
+	}
+	if name != "" && name != target {
+		if err = convertEngine(bcfg, name, target); err != nil {
+			return nil, "", err
+		}
+	}
+	e, err := et.Open(bcfg.Path, bcfg)
+	return e, target, err
+}
+
+// convertEngine rewrites the backend file with another storage engine.
+func convertEngine(bcfg BackendConfig, from, to string) error {
+	lg := bcfg.Logger
+	if lg == nil {
+		lg = zap.NewNop()
+	}
+	lg.Info("converting backend storage engine", zap.String("path", bcfg.Path), zap.String("from", from), zap.String("to", to))
+
+	fet, err := engineType(from)
+	if err != nil {
+		return err
+	}
+	tet, err := engineType(to)
+	if err != nil {
+		return err
+	}
+	src, err := fet.Open(bcfg.Path, bcfg)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, tdbp, err := openTempEngine(tet, bcfg)
+	if err != nil {
+		return err
+	}
+	if err = copyEngine(src, dst, defragLimit); err != nil {
+		dst.Close()
+		os.Remove(tdbp)
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		os.Remove(tdbp)
+		return err
+	}
+	if err = src.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tdbp, bcfg.Path)
+}
+
+// openTempEngine opens an empty engine next to the backend file.
+// Snapshotter.cleanupSnapdir cleans up any of these that are found during startup.
+func openTempEngine(et EngineType, bcfg BackendConfig) (Engine, string, error) {
+	temp, err := os.CreateTemp(filepath.Dir(bcfg.Path), "db.tmp.*")
+	if err != nil {
+		return nil, "", err
+	}
+	tdbp := temp.Name()
+	if err = temp.Close(); err != nil {
+		return nil, "", err
+	}
+	// Don't load tmp db into memory regardless of opening options
+	bcfg.Mlock = false
+	e, err := et.Open(tdbp, bcfg)
+	if err != nil {
+		os.Remove(tdbp)
+		return nil, "", err
+	}
+	return e, tdbp, nil
+}
+
+// copyEngine copies all buckets of src into dst, committing every limit
+// keys.
+func copyEngine(src, dst Engine, limit int) (err error) {
+	// open a tx on dst for writes
+	dtx, err := dst.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			dtx.Rollback()
+		}
+	}()
+
+	// open a tx on src for read
+	tx, err := src.Begin(false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	count := 0
+	err = tx.ForEachBucket(func(name []byte) error {
+		b := tx.Bucket(name)
+		if b == nil {
+			return fmt.Errorf("backend: cannot defrag bucket %s", name)
+		}
+
+		db, berr := dtx.CreateBucketIfNotExists(name)
+		if berr != nil {
+			return berr
+		}
+		db.SetSequential() // for bucket2seq write in for each
+
+		return b.ForEach(func(k, v []byte) error {
+			count++
+			if count > limit {
+				if cerr := dtx.Commit(); cerr != nil {
+					return cerr
+				}
+				var berr error
+				if dtx, berr = dst.Begin(true); berr != nil {
+					return berr
+				}
+				db = dtx.Bucket(name)
+				db.SetSequential() // for bucket2seq write in for each
+
+				count = 0
+			}
+			return db.Put(k, v)
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return dtx.Commit()
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"go.uber.org/zap"
+
+	bolt "go.etcd.io/bbolt"
+	bolterrors "go.etcd.io/bbolt/errors"
+)
+
+type boltEngine struct {
+	db *bolt.DB
+}
+
+func openBoltEngine(path string, bcfg BackendConfig) (Engine, error) {
+	bopts := &bolt.Options{}
+	if boltOpenOptions != nil {
+		*bopts = *boltOpenOptions
+	}
+	bopts.InitialMmapSize = bcfg.mmapSize()
+	bopts.FreelistType = bcfg.BackendFreelistType
+	bopts.NoSync = bcfg.UnsafeNoFsync
+	bopts.NoGrowSync = bcfg.UnsafeNoFsync
+	bopts.Mlock = bcfg.Mlock
+	bopts.ReadOnly = bcfg.ReadOnly
+	bopts.Logger = newBoltLoggerZap(bcfg)
+
+	mode := os.FileMode(0600)
+	if bcfg.ReadOnly {
+		mode = 0400
+	}
+	db, err := bolt.Open(path, mode, bopts)
+	if err != nil {
+		return nil, err
+	}
+	return &boltEngine{db: db}, nil
+}
+
+func (e *boltEngine) Begin(writable bool) (EngineTx, error) {
+	tx, err := e.db.Begin(writable)
+	if err != nil {
+		return nil, err
+	}
+	return &boltTx{tx}, nil
+}
+
+func (e *boltEngine) Path() string { return e.db.Path() }
+
+func (e *boltEngine) Stats() EngineStats {
+	stats := e.db.Stats()
+	return EngineStats{
+		FreeBytes:   int64(stats.FreePageN) * int64(e.db.Info().PageSize),
+		OpenReadTxN: int64(stats.OpenTxN),
+	}
+}
+
+func (e *boltEngine) Close() error { return e.db.Close() }
+
+type boltTx struct {
+	*bolt.Tx
+}
+
+func (t *boltTx) Bucket(name []byte) EngineBucket {
+	b := t.Tx.Bucket(name)
+	if b == nil {
+		return nil
+	}
+	return &boltBucket{b}
+}
+
+func (t *boltTx) CreateBucketIfNotExists(name []byte) (EngineBucket, error) {
+	b, err := t.Tx.CreateBucketIfNotExists(name)
+	if err != nil {
+		return nil, err
+	}
+	return &boltBucket{b}, nil
+}
+
+func (t *boltTx) DeleteBucket(name []byte) error {
+	err := t.Tx.DeleteBucket(name)
+	if errors.Is(err, bolterrors.ErrBucketNotFound) {
+		return ErrBucketNotFound
+	}
+	return err
+}
+
+func (t *boltTx) ForEachBucket(fn func(name []byte) error) error {
+	c := t.Tx.Cursor()
+	for next, _ := c.First(); next != nil; next, _ = c.Next() {
+		if err := fn(next); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *boltTx) Check() (errs []error) {
+	for err := range t.Tx.Check() {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+func (t *boltTx) WriteTo(w io.Writer) (int64, error) { return t.Tx.WriteTo(w) }
+
+func (t *boltTx) Commit() error {
+	err := t.Tx.Commit()
+	rebalanceSec.Observe(t.Tx.Stats().RebalanceTime.Seconds())
+	spillSec.Observe(t.Tx.Stats().SpillTime.Seconds())
+	writeSec.Observe(t.Tx.Stats().WriteTime.Seconds())
+	return err
+}
+
+type boltBucket struct {
+	*bolt.Bucket
+}
+
+func (b *boltBucket) Cursor() EngineCursor { return boltCursor{b.Bucket.Cursor()} }
+
+func (b *boltBucket) SetSequential() {
+	// it is useful to increase fill percent when the workloads are mostly append-only.
+	// this can delay the page split and reduce space usage.
+	b.Bucket.FillPercent = 0.9
+}
+
+type boltCursor struct {
+	*bolt.Cursor
+}
+
+// Err returns nil, since bbolt reads pages from its memory map.
+func (c boltCursor) Err() error { return nil }
+
+func newBoltLoggerZap(bcfg BackendConfig) bolt.Logger {
+	lg := bcfg.Logger.Named("bbolt")
+	return &zapBoltLogger{lg.WithOptions(zap.AddCallerSkip(1)).Sugar()}
+}
+
+type zapBoltLogger struct {
+	*zap.SugaredLogger
+}
+
+func (zl *zapBoltLogger) Warning(args ...any) {
+	zl.SugaredLogger.Warn(args...)
+}
+
+func (zl *zapBoltLogger) Warningf(format string, args ...any) {
+	zl.SugaredLogger.Warnf(format, args...)
+}
@@ -0,0 +1,651 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/google/btree"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/client/pkg/v3/fileutil"
+)
+
+// The log engine file starts with logEngineMagic, followed by records of
+// committed transactions. A record is the crc32c checksum of its payload,
+// the payload length and the payload, which is a sequence of operations:
+//
+//	put:           logOpPut | bucket | key | value
+//	delete:        logOpDelete | bucket | key
+//	create bucket: logOpCreateBucket | bucket
+//	delete bucket: logOpDeleteBucket | bucket
+//
+// where each of bucket, key and value is prefixed by its uvarint length. The
+// copies of the file written by WriteTo end with a padding record, whose
+// payload starts with logOpPad, so that their size is a multiple of
+// logCopyAlign.
+// Opening the file replays all records into in-memory B-trees of keys and
+// the file offsets of their values. A torn record at the end of the file is
+// truncated.
+var logEngineMagic = []byte("etcdlog\x01")
+
+const (
+	logOpPad byte = iota
+	logOpPut
+	logOpDelete
+	logOpCreateBucket
+	logOpDeleteBucket
+)
+
+const (
+	logRecordHeaderSize = 12
+	// logInlineValueBytes is the maximum size of values kept in memory
+	// rather than read from the file.
+	logInlineValueBytes = 64
+	logBTreeDegree      = 32
+	// logCopyAlign is the alignment of the copies of the file, which tells
+	// them apart from snapshots followed by their sha256, as is done for
+	// bbolt files whose size is a multiple of the page size.
+	logCopyAlign = 512
+)
+
+var (
+	ErrLogEngineCorrupt = errors.New("backend: corrupt log engine file")
+
+	logCrcTable = crc32.MakeTable(crc32.Castagnoli)
+)
+
+func matchLogEngine(header []byte) bool {
+	return bytes.HasPrefix(header, logEngineMagic)
+}
+
+type logItem struct {
+	key []byte
+	// val holds the value if it is small or not committed yet. Otherwise
+	// the value is read from the file at off.
+	val []byte
+	off int64
+	n   int
+	// pending is one plus the position of the value in the payload of the
+	// writable transaction that put it, or zero once it is committed.
+	pending int
+}
+
+func logItemLess(a, b logItem) bool { return bytes.Compare(a.key, b.key) < 0 }
+
+type logTree = btree.BTreeG[logItem]
+
+// logState is the data of the log engine as of a committed transaction. It
+// must not be modified once published; writable transactions modify a
+// copy-on-write clone.
+type logState struct {
+	buckets map[string]*logTree
+	// end is the offset after the last committed record.
+	end int64
+	// live is the number of bytes of operations holding live data.
+	live int64
+}
+
+func (s *logState) clone() *logState {
+	c := &logState{buckets: make(map[string]*logTree, len(s.buckets)), end: s.end, live: s.live}
+	for name, t := range s.buckets {
+		c.buckets[name] = t.Clone()
+	}
+	return c
+}
+
+type logEngine struct {
+	lg       *zap.Logger
+	f        *fileutil.LockedFile
+	noSync   bool
+	readOnly bool
+
+	// rwlock allows only one writable transaction at a time.
+	rwlock sync.Mutex
+	// txlock is held by open read-only transactions to block Close.
+	txlock sync.RWMutex
+
+	mu          sync.Mutex
+	state       *logState
+	openReadTxN int64
+}
+
+func openLogEngine(path string, bcfg BackendConfig) (Engine, error) {
+	lg := bcfg.Logger
+	if lg == nil {
+		lg = zap.NewNop()
+	}
+	var (
+		f   *fileutil.LockedFile
+		err error
+	)
+	if bcfg.ReadOnly {
+		var rf *os.File
+		if rf, err = os.Open(path); err != nil {
+			return nil, err
+		}
+		f = &fileutil.LockedFile{File: rf}
+	} else if f, err = fileutil.LockFile(path, os.O_RDWR|os.O_CREATE, fileutil.PrivateFileMode); err != nil {
+		return nil, err
+	}
+	e := &logEngine{lg: lg, f: f, noSync: bcfg.UnsafeNoFsync, readOnly: bcfg.ReadOnly}
+	if e.state, err = e.replay(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *logEngine) replay() (*logState, error) {
+	st := &logState{buckets: make(map[string]*logTree)}
+	fi, err := e.f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() == 0 {
+		if e.readOnly {
+			return nil, ErrLogEngineCorrupt
+		}
+		if _, err = e.f.WriteAt(logEngineMagic, 0); err != nil {
+			return nil, err
+		}
+		if err = e.sync(); err != nil {
+			return nil, err
+		}
+		st.end = int64(len(logEngineMagic))
+		st.live = st.end
+		return st, nil
+	}
+
+	r := bufio.NewReaderSize(io.NewSectionReader(e.f, 0, fi.Size()), 1024*1024)
+	magic := make([]byte, len(logEngineMagic))
+	if _, err = io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, logEngineMagic) {
+		return nil, ErrLogEngineCorrupt
+	}
+	st.end = int64(len(logEngineMagic))
+	st.live = st.end
+
+	hdr := make([]byte, logRecordHeaderSize)
+	for {
+		if _, err = io.ReadFull(r, hdr); err != nil {
+			break
+		}
+		crc, n := binary.LittleEndian.Uint32(hdr), binary.LittleEndian.Uint64(hdr[4:])
+		if n > uint64(fi.Size()-st.end) {
+			err = io.ErrUnexpectedEOF
+			break
+		}
+		payload := make([]byte, n)
+		if _, err = io.ReadFull(r, payload); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			break
+		}
+		if crc32.Checksum(payload, logCrcTable) != crc {
+			err = ErrLogEngineCorrupt
+			break
+		}
+		base := st.end + logRecordHeaderSize
+		if err = decodeLogOps(payload, func(op byte, bucket, key []byte, valPos, valLen int) error {
+			return st.apply(op, bucket, key, func(it *logItem) {
+				it.off, it.n = base+int64(valPos), valLen
+				if valLen <= logInlineValueBytes {
+					it.val = bytes.Clone(payload[valPos : valPos+valLen])
+				}
+			})
+		}); err != nil {
+			return nil, err
+		}
+		st.end = base + int64(n)
+	}
+	if err == io.EOF {
+		return st, nil
+	}
+	if e.readOnly {
+		e.lg.Warn(
+			"ignoring log engine file after last valid record",
+			zap.String("path", e.f.Name()),
+			zap.Int64("offset", st.end),
+			zap.Int64("size", fi.Size()),
+			zap.Error(err),
+		)
+		return st, nil
+	}
+
+	// truncate the torn or corrupted tail
+	e.lg.Warn(
+		"truncating log engine file after last valid record",
+		zap.String("path", e.f.Name()),
+		zap.Int64("offset", st.end),
+		zap.Int64("size", fi.Size()),
+		zap.Error(err),
+	)
+	if err = e.f.Truncate(st.end); err != nil {
+		return nil, err
+	}
+	return st, e.sync()
+}
+
+// apply applies an operation to the state. setValue sets the value of put
+// items.
+func (s *logState) apply(op byte, bucket, key []byte, setValue func(it *logItem)) error {
+	t := s.buckets[string(bucket)]
+	if t == nil && op != logOpCreateBucket {
+		return fmt.Errorf("%w: bucket %q not found", ErrLogEngineCorrupt, bucket)
+	}
+	switch op {
+	case logOpPut:
+		it := logItem{key: bytes.Clone(key)}
+		setValue(&it)
+		if old, ok := t.ReplaceOrInsert(it); ok {
+			s.live -= logPutSize(bucket, old)
+		}
+		s.live += logPutSize(bucket, it)
+	case logOpDelete:
+		if old, ok := t.Delete(logItem{key: key}); ok {
+			s.live -= logPutSize(bucket, old)
+		}
+	case logOpCreateBucket:
+		if t == nil {
+			s.buckets[string(bucket)] = btree.NewG(logBTreeDegree, logItemLess)
+			s.live += logBucketSize(bucket)
+		}
+	case logOpDeleteBucket:
+		t.Ascend(func(it logItem) bool {
+			s.live -= logPutSize(bucket, it)
+			return true
+		})
+		delete(s.buckets, string(bucket))
+		s.live -= logBucketSize(bucket)
+	default:
+		return fmt.Errorf("%w: unknown operation %d", ErrLogEngineCorrupt, op)
+	}
+	return nil
+}
+
+func decodeLogOps(payload []byte, fn func(op byte, bucket, key []byte, valPos, valLen int) error) error {
+	pos := 0
+	next := func() ([]byte, int, error) {
+		n, l := binary.Uvarint(payload[pos:])
+		if l <= 0 || uint64(len(payload)-pos-l) < n {
+			return nil, 0, ErrLogEngineCorrupt
+		}
+		start := pos + l
+		pos = start + int(n)
+		return payload[start:pos], start, nil
+	}
+	for pos < len(payload) {
+		op := payload[pos]
+		pos++
+		if op == logOpPad {
+			// padding runs to the end of the payload
+			return nil
+		}
+		bucket, _, err := next()
+		if err != nil {
+			return err
+		}
+		var key, val []byte
+		valPos := 0
+		if op == logOpPut || op == logOpDelete {
+			if key, _, err = next(); err != nil {
+				return err
+			}
+		}
+		if op == logOpPut {
+			if val, valPos, err = next(); err != nil {
+				return err
+			}
+		}
+		if err = fn(op, bucket, key, valPos, len(val)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendLogOp(b []byte, op byte, bucket, key []byte) []byte {
+	b = append(b, op)
+	b = binary.AppendUvarint(b, uint64(len(bucket)))
+	b = append(b, bucket...)
+	if op == logOpPut || op == logOpDelete {
+		b = binary.AppendUvarint(b, uint64(len(key)))
+		b = append(b, key...)
+	}
+	return b
+}
+
+func uvarintSize(n int) int64 {
+	var buf [binary.MaxVarintLen64]byte
+	return int64(binary.PutUvarint(buf[:], uint64(n)))
+}
+
+func logBucketSize(bucket []byte) int64 {
+	return 1 + uvarintSize(len(bucket)) + int64(len(bucket))
+}
+
+func logPutSize(bucket []byte, it logItem) int64 {
+	return logBucketSize(bucket) + uvarintSize(len(it.key)) + int64(len(it.key)) + uvarintSize(it.n) + int64(it.n)
+}
+
+func (e *logEngine) sync() error {
+	if e.noSync {
+		return nil
+	}
+	return fileutil.Fdatasync(e.f.File)
+}
+
+func (e *logEngine) Begin(writable bool) (EngineTx, error) {
+	if writable && e.readOnly {
+		return nil, errors.New("backend: engine opened read-only")
+	}
+	if writable {
+		e.rwlock.Lock()
+		e.mu.Lock()
+		st := e.state
+		e.mu.Unlock()
+		return &logTx{e: e, writable: true, state: st.clone()}, nil
+	}
+	e.txlock.RLock()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.openReadTxN++
+	return &logTx{e: e, state: e.state}, nil
+}
+
+func (e *logEngine) Path() string { return e.f.Name() }
+
+func (e *logEngine) Stats() EngineStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return EngineStats{
+		FreeBytes:   max(e.state.end-e.state.live, 0),
+		OpenReadTxN: e.openReadTxN,
+	}
+}
+
+func (e *logEngine) Close() error {
+	e.rwlock.Lock()
+	defer e.rwlock.Unlock()
+	e.txlock.Lock()
+	defer e.txlock.Unlock()
+	return e.f.Close()
+}
+
+func (e *logEngine) readValue(it logItem) ([]byte, error) {
+	if it.val != nil {
+		return it.val, nil
+	}
+	v := make([]byte, it.n)
+	if _, err := e.f.ReadAt(v, it.off); err != nil {
+		return nil, fmt.Errorf("backend: failed to read value at offset %d of %s: %w", it.off, e.f.Name(), err)
+	}
+	return v, nil
+}
+
+type logTx struct {
+	e        *logEngine
+	writable bool
+	state    *logState
+	done     bool
+
+	// ops holds the encoded operations of a writable transaction, and
+	// pending the keys whose values are in ops.
+	ops     []byte
+	pending []logPending
+}
+
+type logPending struct {
+	bucket string
+	key    []byte
+	pos    int
+}
+
+func (t *logTx) Bucket(name []byte) EngineBucket {
+	tr := t.state.buckets[string(name)]
+	if tr == nil {
+		return nil
+	}
+	return &logBucket{tx: t, name: name, tree: tr}
+}
+
+func (t *logTx) CreateBucketIfNotExists(name []byte) (EngineBucket, error) {
+	if !t.writable {
+		return nil, errors.New("backend: tx not writable")
+	}
+	if t.state.buckets[string(name)] == nil {
+		t.ops = appendLogOp(t.ops, logOpCreateBucket, name, nil)
+		if err := t.state.apply(logOpCreateBucket, name, nil, nil); err != nil {
+			return nil, err
+		}
+	}
+	return t.Bucket(name), nil
+}
+
+func (t *logTx) DeleteBucket(name []byte) error {
+	if !t.writable {
+		return errors.New("backend: tx not writable")
+	}
+	if t.state.buckets[string(name)] == nil {
+		return ErrBucketNotFound
+	}
+	t.ops = appendLogOp(t.ops, logOpDeleteBucket, name, nil)
+	return t.state.apply(logOpDeleteBucket, name, nil, nil)
+}
+
+func (t *logTx) ForEachBucket(fn func(name []byte) error) error {
+	names := make([]string, 0, len(t.state.buckets))
+	for name := range t.state.buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := fn([]byte(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *logTx) Size() int64 {
+	return t.state.end + logRecordHeaderSize + logCopyPadding(t.state.end)
+}
+
+func (t *logTx) WriteTo(w io.Writer) (int64, error) {
+	// the file is append-only, so its prefix up to the end of the last
+	// committed record is a consistent copy
+	n, err := io.Copy(w, io.NewSectionReader(t.e.f, 0, t.state.end))
+	if err != nil {
+		return n, err
+	}
+	rec := make([]byte, logRecordHeaderSize+logCopyPadding(t.state.end))
+	payload := rec[logRecordHeaderSize:]
+	binary.LittleEndian.PutUint32(rec, crc32.Checksum(payload, logCrcTable))
+	binary.LittleEndian.PutUint64(rec[4:], uint64(len(payload)))
+	m, err := w.Write(rec)
+	return n + int64(m), err
+}
+
+// logCopyPadding returns the size of the payload of the padding record
+// aligning a copy of a file ending at end to logCopyAlign.
+func logCopyPadding(end int64) int64 {
+	return (logCopyAlign - (end+logRecordHeaderSize)%logCopyAlign) % logCopyAlign
+}
+
+func (t *logTx) Commit() error {
+	if t.done {
+		return errors.New("backend: tx closed")
+	}
+	if !t.writable {
+		return errors.New("backend: tx not writable")
+	}
+	t.done = true
+	defer t.e.rwlock.Unlock()
+	if len(t.ops) == 0 {
+		return nil
+	}
+
+	rec := make([]byte, logRecordHeaderSize, logRecordHeaderSize+len(t.ops))
+	binary.LittleEndian.PutUint32(rec, crc32.Checksum(t.ops, logCrcTable))
+	binary.LittleEndian.PutUint64(rec[4:], uint64(len(t.ops)))
+	rec = append(rec, t.ops...)
+	if _, err := t.e.f.WriteAt(rec, t.state.end); err != nil {
+		return err
+	}
+	if err := t.e.sync(); err != nil {
+		return err
+	}
+
+	// values are now read from the file
+	base := t.state.end + logRecordHeaderSize
+	for _, p := range t.pending {
+		tr := t.state.buckets[p.bucket]
+		if tr == nil {
+			continue
+		}
+		it, ok := tr.Get(logItem{key: p.key})
+		if !ok || it.pending != p.pos+1 {
+			continue
+		}
+		it.off, it.pending = base+int64(p.pos), 0
+		if it.n > logInlineValueBytes {
+			it.val = nil
+		}
+		tr.ReplaceOrInsert(it)
+	}
+	t.state.end += int64(len(rec))
+
+	t.e.mu.Lock()
+	t.e.state = t.state
+	t.e.mu.Unlock()
+	return nil
+}
+
+func (t *logTx) Rollback() error {
+	if t.done {
+		return errors.New("backend: tx closed")
+	}
+	t.done = true
+	if t.writable {
+		t.e.rwlock.Unlock()
+		return nil
+	}
+	t.e.mu.Lock()
+	t.e.openReadTxN--
+	t.e.mu.Unlock()
+	t.e.txlock.RUnlock()
+	return nil
+}
+
+type logBucket struct {
+	tx   *logTx
+	name []byte
+	tree *logTree
+}
+
+func (b *logBucket) Cursor() EngineCursor { return &logCursor{b: b} }
+
+func (b *logBucket) ForEach(fn func(k, v []byte) error) (err error) {
+	b.tree.Ascend(func(it logItem) bool {
+		var v []byte
+		if v, err = b.tx.e.readValue(it); err != nil {
+			return false
+		}
+		err = fn(it.key, v)
+		return err == nil
+	})
+	return err
+}
+
+func (b *logBucket) Put(key, value []byte) error {
+	if !b.tx.writable {
+		return errors.New("backend: tx not writable")
+	}
+	t := b.tx
+	t.ops = appendLogOp(t.ops, logOpPut, b.name, key)
+	t.ops = binary.AppendUvarint(t.ops, uint64(len(value)))
+	pos := len(t.ops)
+	t.ops = append(t.ops, value...)
+	t.pending = append(t.pending, logPending{bucket: string(b.name), key: bytes.Clone(key), pos: pos})
+	return t.state.apply(logOpPut, b.name, key, func(it *logItem) {
+		it.val, it.n, it.pending = append([]byte{}, value...), len(value), pos+1
+	})
+}
+
+func (b *logBucket) Delete(key []byte) error {
+	if !b.tx.writable {
+		return errors.New("backend: tx not writable")
+	}
+	b.tx.ops = appendLogOp(b.tx.ops, logOpDelete, b.name, key)
+	return b.tx.state.apply(logOpDelete, b.name, key, nil)
+}
+
+func (b *logBucket) SetSequential() {}
+
+// logCursor finds each next key by searching the tree, since the tree may
+// be modified between calls.
+type logCursor struct {
+	b   *logBucket
+	key []byte
+	err error
+}
+
+func (c *logCursor) Seek(seek []byte) ([]byte, []byte) {
+	return c.first(seek, true)
+}
+
+func (c *logCursor) Next() ([]byte, []byte) {
+	if c.key == nil {
+		return nil, nil
+	}
+	return c.first(c.key, false)
+}
+
+func (c *logCursor) Err() error { return c.err }
+
+func (c *logCursor) first(from []byte, inclusive bool) ([]byte, []byte) {
+	var (
+		item  logItem
+		found bool
+	)
+	c.b.tree.AscendGreaterOrEqual(logItem{key: from}, func(it logItem) bool {
+		if !inclusive && bytes.Equal(it.key, from) {
+			return true
+		}
+		item, found = it, true
+		return false
+	})
+	if !found {
+		c.key = nil
+		return nil, nil
+	}
+	v, err := c.b.tx.e.readValue(item)
+	if err != nil {
+		c.key, c.err = nil, err
+		return nil, nil
+	}
+	c.key = item.key
+	return item.key, v
+}
@@ -0,0 +1,272 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+)
+
+func newEngineBackend(t *testing.T, path, engine string) backend.Backend {
+	bcfg := backend.DefaultBackendConfig(zaptest.NewLogger(t))
+	bcfg.Path, bcfg.BatchInterval, bcfg.BatchLimit = path, time.Hour, 10000
+	bcfg.Engine = engine
+	return backend.New(bcfg)
+}
+
+func readTestBucket(t *testing.T, b backend.Backend) map[string]string {
+	kvs := make(map[string]string)
+	rtx := b.ConcurrentReadTx()
+	rtx.RLock()
+	defer rtx.RUnlock()
+	require.NoError(t, rtx.UnsafeForEach(schema.Test, func(k, v []byte) error {
+		kvs[string(k)] = string(v)
+		return nil
+	}))
+	return kvs
+}
+
+func TestLogEngineBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db")
+	b := newEngineBackend(t, path, backend.EngineLog)
+
+	large := string(bytes.Repeat([]byte("v"), 4096))
+	tx := b.BatchTx()
+	tx.Lock()
+	tx.UnsafeCreateBucket(schema.Test)
+	tx.UnsafePut(schema.Test, []byte("foo"), []byte("bar"))
+	tx.UnsafePut(schema.Test, []byte("large"), []byte(large))
+	tx.UnsafePut(schema.Test, []byte("deleted"), []byte("baz"))
+	tx.Unlock()
+	b.ForceCommit()
+
+	tx.Lock()
+	tx.UnsafeDelete(schema.Test, []byte("deleted"))
+	ks, vs := tx.UnsafeRange(schema.Test, []byte("foo"), nil, 0)
+	tx.Unlock()
+	assert.Equal(t, [][]byte{[]byte("foo")}, ks)
+	assert.Equal(t, [][]byte{[]byte("bar")}, vs)
+	b.ForceCommit()
+
+	want := map[string]string{"foo": "bar", "large": large}
+	assert.Equal(t, want, readTestBucket(t, b))
+	require.NoError(t, b.Close())
+
+	engine, err := backend.DetectEngine(path)
+	require.NoError(t, err)
+	assert.Equal(t, backend.EngineLog, engine)
+
+	// an existing file is opened with the engine that wrote it
+	b = newEngineBackend(t, path, "")
+	defer betesting.Close(t, b)
+	assert.Equal(t, want, readTestBucket(t, b))
+}
+
+func TestLogEngineTornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db")
+	b := newEngineBackend(t, path, backend.EngineLog)
+	tx := b.BatchTx()
+	tx.Lock()
+	tx.UnsafeCreateBucket(schema.Test)
+	tx.UnsafePut(schema.Test, []byte("foo"), []byte("bar"))
+	tx.Unlock()
+	require.NoError(t, b.Close())
+
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	b = newEngineBackend(t, path, backend.EngineLog)
+	defer betesting.Close(t, b)
+	assert.Equal(t, map[string]string{"foo": "bar"}, readTestBucket(t, b))
+	fi2, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, fi.Size(), fi2.Size(), "torn record should be truncated")
+}
+
+func TestLogEngineDefrag(t *testing.T) {
+	b := newEngineBackend(t, filepath.Join(t.TempDir(), "db"), backend.EngineLog)
+	defer betesting.Close(t, b)
+
+	value := bytes.Repeat([]byte("v"), 1024)
+	tx := b.BatchTx()
+	tx.Lock()
+	tx.UnsafeCreateBucket(schema.Test)
+	tx.Unlock()
+	for i := 0; i < 10; i++ {
+		tx.Lock()
+		for j := 0; j < 10; j++ {
+			tx.UnsafePut(schema.Test, []byte(fmt.Sprintf("foo_%d", j)), value)
+		}
+		tx.Unlock()
+		b.ForceCommit()
+	}
+	size, sizeInUse := b.Size(), b.SizeInUse()
+	assert.Less(t, sizeInUse*5, size, "overwritten values should not be in use")
+
+	hash, err := b.Hash(nil)
+	require.NoError(t, err)
+	require.NoError(t, b.Defrag())
+	assert.Less(t, b.Size(), size/5)
+	hash2, err := b.Hash(nil)
+	require.NoError(t, err)
+	assert.Equal(t, hash, hash2)
+	assert.Len(t, readTestBucket(t, b), 10)
+}
+
+func TestEngineConversion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db")
+	b := newEngineBackend(t, path, backend.EngineBolt)
+	tx := b.BatchTx()
+	tx.Lock()
+	tx.UnsafeCreateBucket(schema.Test)
+	tx.UnsafePut(schema.Test, []byte("foo"), []byte("bar"))
+	tx.Unlock()
+	b.ForceCommit()
+	hash, err := b.Hash(nil)
+	require.NoError(t, err)
+	require.NotZero(t, hash)
+	require.NoError(t, b.Close())
+
+	for _, engine := range []string{backend.EngineLog, backend.EngineBolt} {
+		b = newEngineBackend(t, path, engine)
+		detected, derr := backend.DetectEngine(path)
+		require.NoError(t, derr)
+		assert.Equal(t, engine, detected)
+		assert.Equal(t, map[string]string{"foo": "bar"}, readTestBucket(t, b))
+		hash2, herr := b.Hash(nil)
+		require.NoError(t, herr)
+		assert.Equal(t, hash, hash2, "hash should not depend on the engine")
+		require.NoError(t, b.Close())
+	}
+}
+
+func TestLogEngineSnapshot(t *testing.T) {
+	b := newEngineBackend(t, filepath.Join(t.TempDir(), "db"), backend.EngineLog)
+	defer betesting.Close(t, b)
+	tx := b.BatchTx()
+	tx.Lock()
+	tx.UnsafeCreateBucket(schema.Test)
+	tx.UnsafePut(schema.Test, []byte("foo"), []byte("bar"))
+	tx.Unlock()
+
+	snap := b.Snapshot()
+	defer func() { assert.NoError(t, snap.Close()) }()
+
+	// writes after the snapshot are not part of it
+	tx.Lock()
+	tx.UnsafePut(schema.Test, []byte("foo"), []byte("baz"))
+	tx.Unlock()
+	b.ForceCommit()
+
+	path := filepath.Join(t.TempDir(), "snap.db")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	n, err := snap.WriteTo(f)
+	require.NoError(t, err)
+	assert.Equal(t, snap.Size(), n)
+	assert.Zero(t, n%512, "copies should be aligned to tell them apart from snapshots followed by their sha256")
+	require.NoError(t, f.Close())
+
+	nb := newEngineBackend(t, path, "")
+	defer betesting.Close(t, nb)
+	assert.Equal(t, map[string]string{"foo": "bar"}, readTestBucket(t, nb))
+}
+
+func TestOpenEngineReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db")
+	b := newEngineBackend(t, path, backend.EngineLog)
+	tx := b.BatchTx()
+	tx.Lock()
+	tx.UnsafeCreateBucket(schema.Test)
+	tx.UnsafePut(schema.Test, []byte("foo"), []byte("bar"))
+	tx.Unlock()
+	require.NoError(t, b.Close())
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{1, 2, 3})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+
+	bcfg := backend.DefaultBackendConfig(zaptest.NewLogger(t))
+	bcfg.Path, bcfg.ReadOnly = path, true
+	e, err := backend.OpenEngine(bcfg)
+	require.NoError(t, err)
+	defer e.Close()
+	_, err = e.Begin(true)
+	require.Error(t, err)
+
+	etx, err := e.Begin(false)
+	require.NoError(t, err)
+	defer etx.Rollback()
+	kvs := make(map[string]string)
+	require.NoError(t, etx.Bucket(schema.Test.Name()).ForEach(func(k, v []byte) error {
+		kvs[string(k)] = string(v)
+		return nil
+	}))
+	assert.Equal(t, map[string]string{"foo": "bar"}, kvs)
+
+	fi2, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, fi.Size(), fi2.Size(), "torn record should not be truncated")
+}
+
+func TestLogEngineReadError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db")
+	b := newEngineBackend(t, path, backend.EngineLog)
+	tx := b.BatchTx()
+	tx.Lock()
+	tx.UnsafeCreateBucket(schema.Test)
+	tx.UnsafePut(schema.Test, []byte("large"), bytes.Repeat([]byte("v"), 4096))
+	tx.Unlock()
+	require.NoError(t, b.Close())
+
+	bcfg := backend.DefaultBackendConfig(zaptest.NewLogger(t))
+	bcfg.Path = path
+	e, err := backend.OpenEngine(bcfg)
+	require.NoError(t, err)
+	defer e.Close()
+	etx, err := e.Begin(false)
+	require.NoError(t, err)
+	defer etx.Rollback()
+
+	// large values are read from the file
+	require.NoError(t, os.Truncate(path, 1024))
+	bucket := etx.Bucket(schema.Test.Name())
+	require.Error(t, bucket.ForEach(func(k, v []byte) error { return nil }))
+	c := bucket.Cursor()
+	k, _ := c.Seek(nil)
+	assert.Nil(t, k)
+	require.Error(t, c.Err())
+}
@@ -20,6 +20,7 @@ const (
 	memberDirSegment   = "member"
 	snapDirSegment     = "snap"
 	walDirSegment      = "wal"
+	notifyDirSegment   = "notify"
 	backendFileSegment = "db"
 )
 
@@ -44,6 +45,12 @@ func ToWALDir(dataDir string) string {
 	return filepath.Join(ToMemberDir(dataDir), walDirSegment)
 }
 
+// ToNotifyDir returns the directory path for the member's change
+// notification progress.
+func ToNotifyDir(dataDir string) string {
+	return filepath.Join(ToMemberDir(dataDir), notifyDirSegment)
+}
+
 func ToMemberDir(dataDir string) string {
 	return filepath.Join(dataDir, memberDirSegment)
 }
@@ -0,0 +1,241 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package encryption implements envelope encryption of key-value records
+// stored in the backend. Records are encrypted with a data encryption key,
+// which is stored in the backend wrapped by a key encryption key held by a
+// KMS.
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+)
+
+var (
+	ErrCorrupted  = errors.New("encryption: corrupted ciphertext")
+	ErrUnknownKey = errors.New("encryption: unknown data encryption key")
+)
+
+// encryptedPrefix marks encrypted records. A marshaled mvccpb.KeyValue never
+// starts with a zero byte, so plain records written before encryption was
+// enabled are told apart.
+var encryptedPrefix = []byte("\x00enc\x01")
+
+const keyIDLen = 8
+
+// Keyring encrypts and decrypts key-value records with the data encryption
+// keys stored in the backend. It implements mvcc.ValueTransformer.
+type Keyring struct {
+	lg  *zap.Logger
+	kms KMS
+
+	mu      sync.RWMutex
+	be      backend.Backend
+	keys    map[uint64]cipher.AEAD
+	current uint64
+}
+
+// NewKeyring loads the data encryption keys stored in the backend, creating
+// one if there is none yet.
+func NewKeyring(lg *zap.Logger, kms KMS, be backend.Backend) (*Keyring, error) {
+	if lg == nil {
+		lg = zap.NewNop()
+	}
+	k := &Keyring{lg: lg, kms: kms}
+
+	tx := be.BatchTx()
+	tx.LockOutsideApply()
+	schema.UnsafeCreateEncryptionBucket(tx)
+	tx.Unlock()
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err := k.load(be); err != nil {
+		return nil, err
+	}
+	if k.current == 0 {
+		if _, err := k.rotate(context.TODO(), be.BatchTx().LockOutsideApply); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// Restore reloads the data encryption keys after the backend was replaced by
+// a snapshot.
+func (k *Keyring) Restore(be backend.Backend) error {
+	tx := be.BatchTx()
+	tx.LockInsideApply()
+	schema.UnsafeCreateEncryptionBucket(tx)
+	tx.Unlock()
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err := k.load(be); err != nil {
+		return err
+	}
+	if k.current == 0 {
+		_, err := k.rotate(context.TODO(), be.BatchTx().LockInsideApply)
+		return err
+	}
+	return nil
+}
+
+// Rotate creates a new data encryption key used to encrypt records from now
+// on and returns its ID. Records encrypted with previous keys remain readable.
+func (k *Keyring) Rotate(ctx context.Context) (uint64, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.rotate(ctx, k.be.BatchTx().LockOutsideApply)
+}
+
+// CurrentKeyID returns the ID of the data encryption key used to encrypt
+// records.
+func (k *Keyring) CurrentKeyID() uint64 {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current
+}
+
+func (k *Keyring) load(be backend.Backend) error {
+	tx := be.ReadTx()
+	tx.RLock()
+	current, wrapped, err := schema.UnsafeReadEncryptionKeys(tx)
+	tx.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[uint64]cipher.AEAD, len(wrapped))
+	for id, w := range wrapped {
+		dek, err := k.kms.Decrypt(context.TODO(), w)
+		if err != nil {
+			return fmt.Errorf("encryption: failed to unwrap data encryption key %d (%w)", id, err)
+		}
+		if keys[id], err = newAEAD(dek); err != nil {
+			return err
+		}
+	}
+	if _, ok := keys[current]; current != 0 && !ok {
+		return fmt.Errorf("%w: %d", ErrUnknownKey, current)
+	}
+	k.be, k.keys, k.current = be, keys, current
+	return nil
+}
+
+// rotate must be called with k.mu held; lock locks the backend's batch tx.
+func (k *Keyring) rotate(ctx context.Context, lock func()) (uint64, error) {
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return 0, err
+	}
+	wrapped, err := k.kms.Encrypt(ctx, dek)
+	if err != nil {
+		return 0, fmt.Errorf("encryption: failed to wrap data encryption key (%w)", err)
+	}
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return 0, err
+	}
+
+	var id uint64
+	for kid := range k.keys {
+		id = max(id, kid)
+	}
+	id++
+
+	tx := k.be.BatchTx()
+	lock()
+	schema.UnsafeSaveEncryptionKey(tx, id, wrapped)
+	tx.Unlock()
+	k.be.ForceCommit()
+
+	k.keys[id] = aead
+	k.current = id
+	k.lg.Info("rotated data encryption key", zap.Uint64("key-id", id))
+	return id, nil
+}
+
+// HasKeys returns true if the backend holds data encryption keys, in which
+// case its records may be encrypted and can only be read with the key
+// encryption key they were wrapped with.
+func HasKeys(be backend.Backend) (bool, error) {
+	tx := be.ReadTx()
+	tx.RLock()
+	defer tx.RUnlock()
+	_, keys, err := schema.UnsafeReadEncryptionKeys(tx)
+	return len(keys) > 0, err
+}
+
+// IsEncrypted returns true if data is a record encrypted by a Keyring.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, encryptedPrefix)
+}
+
+// TransformToStorage encrypts data with the current data encryption key.
+func (k *Keyring) TransformToStorage(data []byte) ([]byte, error) {
+	k.mu.RLock()
+	aead, id := k.keys[k.current], k.current
+	k.mu.RUnlock()
+	if aead == nil {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownKey, id)
+	}
+
+	ns := aead.NonceSize()
+	hdr := len(encryptedPrefix) + keyIDLen
+	out := make([]byte, hdr+ns, hdr+ns+len(data)+aead.Overhead())
+	copy(out, encryptedPrefix)
+	binary.BigEndian.PutUint64(out[len(encryptedPrefix):], id)
+	nonce := out[hdr:]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(out, nonce, data, out[:hdr]), nil
+}
+
+// TransformFromStorage decrypts data encrypted by TransformToStorage. Plain
+// records are returned as is.
+func (k *Keyring) TransformFromStorage(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	hdr := len(encryptedPrefix) + keyIDLen
+	if len(data) < hdr {
+		return nil, ErrCorrupted
+	}
+	id := binary.BigEndian.Uint64(data[len(encryptedPrefix):])
+	k.mu.RLock()
+	aead := k.keys[id]
+	k.mu.RUnlock()
+	if aead == nil {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownKey, id)
+	}
+	ns := aead.NonceSize()
+	if len(data) < hdr+ns {
+		return nil, ErrCorrupted
+	}
+	return aead.Open(nil, data[hdr:hdr+ns], data[hdr+ns:], data[:hdr])
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+)
+
+func newTestKMS(t *testing.T) KMS {
+	kms, err := NewLocalKMS(bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+	return kms
+}
+
+func TestKeyringTransform(t *testing.T) {
+	be, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, be)
+
+	k, err := NewKeyring(zaptest.NewLogger(t), newTestKMS(t), be)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), k.CurrentKeyID())
+
+	data := []byte("\x0a\x03foo")
+	enc, err := k.TransformToStorage(data)
+	require.NoError(t, err)
+	assert.NotContains(t, string(enc), "foo")
+	dec, err := k.TransformFromStorage(enc)
+	require.NoError(t, err)
+	assert.Equal(t, data, dec)
+
+	// records written before encryption was enabled remain readable
+	dec, err = k.TransformFromStorage(data)
+	require.NoError(t, err)
+	assert.Equal(t, data, dec)
+
+	enc[len(enc)-1] ^= 0xff
+	_, err = k.TransformFromStorage(enc)
+	assert.Error(t, err)
+}
+
+func TestKeyringRotate(t *testing.T) {
+	be, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, be)
+
+	kms := newTestKMS(t)
+	k, err := NewKeyring(zaptest.NewLogger(t), kms, be)
+	require.NoError(t, err)
+	old, err := k.TransformToStorage([]byte("old"))
+	require.NoError(t, err)
+
+	id, err := k.Rotate(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), id)
+	cur, err := k.TransformToStorage([]byte("new"))
+	require.NoError(t, err)
+
+	// a keyring loaded from the same backend reads records of all keys
+	k2, err := NewKeyring(zaptest.NewLogger(t), kms, be)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), k2.CurrentKeyID())
+	for data, want := range map[string]string{string(old): "old", string(cur): "new"} {
+		dec, err := k2.TransformFromStorage([]byte(data))
+		require.NoError(t, err)
+		assert.Equal(t, want, string(dec))
+	}
+
+	// a different key encryption key cannot unwrap the stored keys
+	other, err := NewLocalKMS(bytes.Repeat([]byte{2}, 32))
+	require.NoError(t, err)
+	_, err = NewKeyring(zaptest.NewLogger(t), other, be)
+	assert.Error(t, err)
+}
+
+func TestKeyringUnknownKey(t *testing.T) {
+	be, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, be)
+	be2, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, be2)
+
+	k, err := NewKeyring(zaptest.NewLogger(t), newTestKMS(t), be)
+	require.NoError(t, err)
+	_, err = k.Rotate(context.Background())
+	require.NoError(t, err)
+	enc, err := k.TransformToStorage([]byte("foo"))
+	require.NoError(t, err)
+
+	k2, err := NewKeyring(zaptest.NewLogger(t), newTestKMS(t), be2)
+	require.NoError(t, err)
+	_, err = k2.TransformFromStorage(enc)
+	assert.True(t, errors.Is(err, ErrUnknownKey))
+}
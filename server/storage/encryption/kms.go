@@ -0,0 +1,95 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// KMS wraps and unwraps data encryption keys with a key encryption key it
+// manages, typically in an external key management service. All members of
+// a cluster must use the same key encryption key, since backend snapshots
+// sent between members carry the wrapped data encryption keys.
+type KMS interface {
+	// Encrypt wraps the given data encryption key.
+	Encrypt(ctx context.Context, plaintext []byte) ([]byte, error)
+	// Decrypt unwraps a data encryption key returned by Encrypt.
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+var ErrInvalidKEK = errors.New("encryption: key encryption key must be 32 bytes")
+
+type localKMS struct {
+	aead cipher.AEAD
+}
+
+// NewLocalKMS returns a KMS that wraps data encryption keys with the given
+// 32-byte AES key encryption key.
+func NewLocalKMS(kek []byte) (KMS, error) {
+	if len(kek) != 32 {
+		return nil, ErrInvalidKEK
+	}
+	aead, err := newAEAD(kek)
+	if err != nil {
+		return nil, err
+	}
+	return &localKMS{aead: aead}, nil
+}
+
+// NewLocalKMSFromFile returns a local KMS with the base64 encoded key
+// encryption key read from the given file.
+func NewLocalKMSFromFile(path string) (KMS, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	kek, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(b)))
+	if err != nil {
+		return nil, fmt.Errorf("encryption: failed to decode key encryption key file %q (%w)", path, err)
+	}
+	return NewLocalKMS(kek)
+}
+
+func (k *localKMS) Encrypt(_ context.Context, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return k.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (k *localKMS) Decrypt(_ context.Context, ciphertext []byte) ([]byte, error) {
+	ns := k.aead.NonceSize()
+	if len(ciphertext) < ns {
+		return nil, ErrCorrupted
+	}
+	return k.aead.Open(nil, ciphertext[:ns], ciphertext[ns:], nil)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -15,6 +15,9 @@
 package mvcc
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
 	"hash"
 	"hash/crc32"
 	"sort"
@@ -22,18 +25,24 @@ import (
 
 	"go.uber.org/zap"
 
+	"go.etcd.io/etcd/pkg/v3/traceutil"
 	"go.etcd.io/etcd/server/v3/storage/backend"
 	"go.etcd.io/etcd/server/v3/storage/schema"
 )
 
 const (
 	hashStorageMaxSize = 10
+
+	// hashRangeBatchLimit is the number of keys read at once by HashRange.
+	hashRangeBatchLimit = 1000
 )
 
-func unsafeHashByRev(tx backend.UnsafeReader, compactRevision, revision int64, keep map[Revision]struct{}) (KeyValueHash, error) {
+func unsafeHashByRev(tx backend.UnsafeReader, lg *zap.Logger, vt ValueTransformer, compactRevision, revision int64, keep map[Revision]struct{}) (KeyValueHash, error) {
 	h := newKVHasher(compactRevision, revision, keep)
 	err := tx.UnsafeForEach(schema.Key, func(k, v []byte) error {
-		h.WriteKeyValue(k, v)
+		// hash the plain records so that members agree regardless of how
+		// they store them
+		h.WriteKeyValue(k, transformFromStorage(lg, vt, v))
 		return nil
 	})
 	return h.Hash(), err
@@ -85,6 +94,56 @@ type KeyValueHash struct {
 	Revision        int64
 }
 
+// RangeHash is the hash of the keys and values in a key range.
+type RangeHash struct {
+	Hash     uint32
+	Revision int64
+	Count    int64
+}
+
+// HashRange computes the hash of the keys and values in [key, end) at
+// revision rev, or at the current revision if rev is not positive. The hash
+// covers neither revisions nor versions, so that a range and a copy of it in
+// another cluster hash the same. If stripPrefix is set, keys are hashed with
+// key removed from their start, so that a prefix and a copy of it stored under
+// another prefix hash the same. It also returns the current revision.
+func HashRange(kv KV, key, end []byte, rev int64, stripPrefix bool) (RangeHash, int64, error) {
+	txn := kv.Read(ConcurrentReadTxMode, traceutil.TODO())
+	defer txn.End()
+
+	currentRev := txn.Rev()
+	if rev <= 0 {
+		rev = currentRev
+	}
+	h := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	var count int64
+	var lenBuf [binary.MaxVarintLen64]byte
+	start := key
+	for {
+		r, err := txn.Range(context.TODO(), start, end, RangeOptions{Limit: hashRangeBatchLimit, Rev: rev})
+		if err != nil {
+			return RangeHash{}, currentRev, err
+		}
+		for _, kv := range r.KVs {
+			k := kv.Key
+			if stripPrefix {
+				k = bytes.TrimPrefix(k, key)
+			}
+			h.Write(lenBuf[:binary.PutUvarint(lenBuf[:], uint64(len(k)))])
+			h.Write(k)
+			h.Write(lenBuf[:binary.PutUvarint(lenBuf[:], uint64(len(kv.Value)))])
+			h.Write(kv.Value)
+		}
+		count += int64(len(r.KVs))
+		if len(r.KVs) < hashRangeBatchLimit {
+			break
+		}
+		lastKey := r.KVs[len(r.KVs)-1].Key
+		start = append(append(make([]byte, 0, len(lastKey)+1), lastKey...), 0)
+	}
+	return RangeHash{Hash: h.Sum32(), Revision: rev, Count: count}, currentRev, nil
+}
+
 type HashStorage interface {
 	// Hash computes the hash of the whole backend keyspace,
 	// including key, lease, and other buckets in storage.
@@ -231,3 +231,54 @@ func TestHasherStoreFull(t *testing.T) {
 		t.Errorf("Didn't expect error for new revision, err: %v", err)
 	}
 }
+
+func TestHashRange(t *testing.T) {
+	b1, _ := betesting.NewDefaultTmpBackend(t)
+	s1 := NewStore(zaptest.NewLogger(t), b1, &lease.FakeLessor{}, StoreConfig{})
+	defer cleanup(s1, b1)
+	b2, _ := betesting.NewDefaultTmpBackend(t)
+	s2 := NewStore(zaptest.NewLogger(t), b2, &lease.FakeLessor{}, StoreConfig{})
+	defer cleanup(s2, b2)
+
+	// more keys than a single batch, written with different histories
+	count := int64(hashRangeBatchLimit + 10)
+	for i := int64(0); i < count; i++ {
+		s1.Put([]byte(fmt.Sprintf("a/%05d", i)), []byte(fmt.Sprint(i)), lease.NoLease)
+		s2.Put([]byte(fmt.Sprintf("b/%05d", i)), []byte("stale"), lease.NoLease)
+		s2.Put([]byte(fmt.Sprintf("b/%05d", i)), []byte(fmt.Sprint(i)), lease.NoLease)
+		s2.Put([]byte(fmt.Sprintf("c/%05d", i)), []byte(fmt.Sprint(i)), lease.NoLease)
+	}
+
+	h1, rev1, err := HashRange(s1, []byte("a/"), []byte("a0"), 0, true)
+	assert.NoError(t, err)
+	assert.Equal(t, count, h1.Count)
+	assert.Equal(t, s1.Rev(), rev1)
+	assert.Equal(t, rev1, h1.Revision)
+	h2, _, err := HashRange(s2, []byte("b/"), []byte("b0"), 0, true)
+	assert.NoError(t, err)
+	assert.Equal(t, count, h2.Count)
+	assert.Equal(t, h1.Hash, h2.Hash)
+
+	h2, _, err = HashRange(s2, []byte("b/"), []byte("b0"), 0, false)
+	assert.NoError(t, err)
+	assert.NotEqual(t, h1.Hash, h2.Hash)
+
+	// the hash at a past revision does not see later changes
+	s1.Put([]byte("a/00000"), []byte("changed"), lease.NoLease)
+	h, _, err := HashRange(s1, []byte("a/"), []byte("a0"), 0, true)
+	assert.NoError(t, err)
+	assert.NotEqual(t, h1.Hash, h.Hash)
+	h, _, err = HashRange(s1, []byte("a/"), []byte("a0"), rev1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, h1, h)
+
+	// a single key
+	h, _, err = HashRange(s1, []byte("a/00001"), nil, 0, false)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), h.Count)
+
+	_, err = s1.Compact(traceutil.TODO(), s1.Rev())
+	assert.NoError(t, err)
+	_, _, err = HashRange(s1, []byte("a/"), []byte("a0"), rev1, true)
+	assert.ErrorIs(t, err, ErrCompacted)
+}
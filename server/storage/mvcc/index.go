@@ -15,6 +15,7 @@
 package mvcc
 
 import (
+	"bytes"
 	"sync"
 
 	"github.com/google/btree"
@@ -26,8 +27,11 @@ type index interface {
 	Range(key, end []byte, atRev int64) ([][]byte, []Revision)
 	Revisions(key, end []byte, atRev int64, limit int) ([]Revision, int)
 	CountRevisions(key, end []byte, atRev int64) int
+	CountRevisionsGrouped(key, end []byte, atRev int64, group func(key []byte) []byte, limit int) ([]KeyGroupCount, []byte)
 	Put(key []byte, rev Revision)
 	Tombstone(key []byte, rev Revision) error
+	TombstoneRange(key, end []byte, atRev int64, rev Revision) ([][]byte, []Revision)
+	Tombstoned(key, end []byte, rev Revision) [][]byte
 	Compact(rev int64) map[Revision]struct{}
 	Keep(rev int64) map[Revision]struct{}
 	Equal(b index) bool
@@ -155,6 +159,50 @@ func (ti *treeIndex) CountRevisions(key, end []byte, atRev int64) int {
 	return total
 }
 
+// KeyGroupCount is the number of keys in a group of keys sharing a prefix.
+type KeyGroupCount struct {
+	Prefix []byte
+	Count  int
+}
+
+// CountRevisionsGrouped is like CountRevisions, counting the revisions per
+// group of keys. group returns the prefix of the group of a key, which must
+// be contiguous with the other keys of its group. The groups are returned in
+// the order of their keys. If limit is positive, the visit stops at the first
+// key of the group following the first limit groups, which is returned to
+// continue from.
+func (ti *treeIndex) CountRevisionsGrouped(key, end []byte, atRev int64, group func(key []byte) []byte, limit int) (groups []KeyGroupCount, next []byte) {
+	ti.RLock()
+	defer ti.RUnlock()
+
+	count := func(k []byte) bool {
+		prefix := group(k)
+		if n := len(groups); n > 0 && bytes.Equal(groups[n-1].Prefix, prefix) {
+			groups[n-1].Count++
+			return true
+		}
+		if limit > 0 && len(groups) == limit {
+			next = bytes.Clone(k)
+			return false
+		}
+		groups = append(groups, KeyGroupCount{Prefix: bytes.Clone(prefix), Count: 1})
+		return true
+	}
+	if end == nil {
+		if _, _, _, err := ti.unsafeGet(key, atRev); err == nil {
+			count(key)
+		}
+		return groups, nil
+	}
+	ti.unsafeVisit(key, end, func(ki *keyIndex) bool {
+		if _, _, _, err := ki.get(ti.lg, atRev); err == nil {
+			return count(ki.key)
+		}
+		return true
+	})
+	return groups, next
+}
+
 func (ti *treeIndex) Range(key, end []byte, atRev int64) (keys [][]byte, revs []Revision) {
 	ti.RLock()
 	defer ti.RUnlock()
@@ -189,6 +237,43 @@ func (ti *treeIndex) Tombstone(key []byte, rev Revision) error {
 	return ki.tombstone(ti.lg, rev.Main, rev.Sub)
 }
 
+// TombstoneRange tombstones at rev the keys from key(included) to
+// end(excluded) that exist at atRev, in a single visit of the index, and
+// returns them with their revisions at atRev.
+func (ti *treeIndex) TombstoneRange(key, end []byte, atRev int64, rev Revision) (keys [][]byte, revs []Revision) {
+	ti.Lock()
+	defer ti.Unlock()
+
+	ti.unsafeVisit(key, end, func(ki *keyIndex) bool {
+		r, _, _, err := ki.get(ti.lg, atRev)
+		if err != nil {
+			return true
+		}
+		if err = ki.tombstone(ti.lg, rev.Main, rev.Sub); err != nil {
+			ti.lg.Panic("failed to tombstone an existing key", zap.String("key", string(ki.key)), zap.Error(err))
+		}
+		keys = append(keys, ki.key)
+		revs = append(revs, r)
+		return true
+	})
+	return keys, revs
+}
+
+// Tombstoned returns the keys from key(included) to end(excluded) that were
+// deleted at the given rev, e.g. by a range tombstone.
+func (ti *treeIndex) Tombstoned(key, end []byte, rev Revision) (keys [][]byte) {
+	ti.RLock()
+	defer ti.RUnlock()
+
+	ti.unsafeVisit(key, end, func(ki *keyIndex) bool {
+		if ki.tombstonedAt(rev) {
+			keys = append(keys, ki.key)
+		}
+		return true
+	})
+	return keys
+}
+
 func (ti *treeIndex) Compact(rev int64) map[Revision]struct{} {
 	available := make(map[Revision]struct{})
 	ti.lg.Info("compact tree index", zap.Int64("revision", rev))
@@ -136,6 +136,17 @@ func (ki *keyIndex) tombstone(lg *zap.Logger, main int64, sub int64) error {
 	return nil
 }
 
+// tombstonedAt returns whether the key was deleted at the given rev.
+func (ki *keyIndex) tombstonedAt(rev Revision) bool {
+	// the last generation is never tombstoned
+	for _, g := range ki.generations[:len(ki.generations)-1] {
+		if n := len(g.revs); n > 0 && g.revs[n-1] == rev {
+			return true
+		}
+	}
+	return false
+}
+
 // get gets the modified, created revision and version of the key that satisfies the given atRev.
 // Rev must be smaller than or equal to the given atRev.
 func (ki *keyIndex) get(lg *zap.Logger, atRev int64) (modified, created Revision, ver int64, err error) {
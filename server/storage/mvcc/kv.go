@@ -27,12 +27,24 @@ type RangeOptions struct {
 	Limit int64
 	Rev   int64
 	Count bool
+	// GroupBy, if set with Count, also counts the keys per group of keys
+	// sharing their prefix up to the first GroupBy separator following the
+	// key of the range. Limit is then the maximum number of groups, and
+	// Count the number of keys of the groups returned.
+	GroupBy []byte
+	// GroupFrom, if set with GroupBy, counts the keys of the range from
+	// GroupFrom on, in the groups of the whole range.
+	GroupFrom []byte
 }
 
 type RangeResult struct {
-	KVs   []mvccpb.KeyValue
-	Rev   int64
-	Count int
+	KVs    []mvccpb.KeyValue
+	Rev    int64
+	Count  int
+	Groups []KeyGroupCount
+	// NextGroupKey is the first key of the group following Groups, if
+	// the groups are limited.
+	NextGroupKey []byte
 }
 
 type ReadView interface {
@@ -85,7 +97,9 @@ type WriteView interface {
 type TxnWrite interface {
 	TxnRead
 	WriteView
-	// Changes gets the changes made since opening the write txn.
+	// Changes gets the changes made since opening the write txn. A deletion
+	// recorded as a range tombstone is a single change, whose key and value
+	// are the start and the end of the range.
 	Changes() []mvccpb.KeyValue
 }
 
@@ -122,6 +136,10 @@ type KV interface {
 	// HashStorage returns HashStorage interface for KV storage.
 	HashStorage() HashStorage
 
+	// HasRangeTombstones returns whether the backend holds range tombstones
+	// not compacted yet, which the versions before 3.6 cannot read.
+	HasRangeTombstones() bool
+
 	// Compact frees all superseded keys with revisions less than rev.
 	Compact(trace *traceutil.Trace, rev int64) (<-chan struct{}, error)
 
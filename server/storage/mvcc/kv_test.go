@@ -15,6 +15,7 @@
 package mvcc
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
@@ -255,6 +256,96 @@ func testKVRangeLimit(t *testing.T, f rangeFunc) {
 	}
 }
 
+func TestKVRangeGroupBy(t *testing.T)    { testKVRangeGroupBy(t, normalRangeFunc) }
+func TestKVTxnRangeGroupBy(t *testing.T) { testKVRangeGroupBy(t, txnRangeFunc) }
+
+func testKVRangeGroupBy(t *testing.T, f rangeFunc) {
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	s := NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+	defer cleanup(s, b)
+
+	for _, key := range []string{"a/x/1", "a/x/2", "a/x0", "a/y/1", "a/z", "b/x/1"} {
+		s.Put([]byte(key), []byte("bar"), lease.NoLease)
+	}
+	s.DeleteRange([]byte("a/y/1"), nil)
+
+	tests := []struct {
+		key, end []byte
+		limit    int64
+		from     []byte
+		wgroups  []KeyGroupCount
+		wnext    []byte
+	}{
+		{
+			[]byte("a/"), []byte("a0"), 0, nil,
+			[]KeyGroupCount{{[]byte("a/x/"), 2}, {[]byte("a/x0"), 1}, {[]byte("a/z"), 1}},
+			nil,
+		},
+		{
+			[]byte("a/x/"), []byte("a/x0"), 0, nil,
+			[]KeyGroupCount{{[]byte("a/x/1"), 1}, {[]byte("a/x/2"), 1}},
+			nil,
+		},
+		// keys not starting with the key are grouped from their start
+		{
+			[]byte("a/z"), []byte("c"), 0, nil,
+			[]KeyGroupCount{{[]byte("a/z"), 1}, {[]byte("b/"), 1}},
+			nil,
+		},
+		{
+			[]byte("a/x0"), nil, 0, nil,
+			[]KeyGroupCount{{[]byte("a/x0"), 1}},
+			nil,
+		},
+		{
+			[]byte("a/y/1"), nil, 0, nil,
+			nil,
+			nil,
+		},
+		// the groups are limited and continued from the next key
+		{
+			[]byte("a/"), []byte("a0"), 1, nil,
+			[]KeyGroupCount{{[]byte("a/x/"), 2}},
+			[]byte("a/x0"),
+		},
+		{
+			[]byte("a/"), []byte("a0"), 1, []byte("a/x0"),
+			[]KeyGroupCount{{[]byte("a/x0"), 1}},
+			[]byte("a/z"),
+		},
+		{
+			[]byte("a/"), []byte("a0"), 1, []byte("a/z"),
+			[]KeyGroupCount{{[]byte("a/z"), 1}},
+			nil,
+		},
+		// the groups are those of the whole range
+		{
+			[]byte("a/"), []byte("a0"), 0, []byte("a/x/2"),
+			[]KeyGroupCount{{[]byte("a/x/"), 1}, {[]byte("a/x0"), 1}, {[]byte("a/z"), 1}},
+			nil,
+		},
+	}
+	for i, tt := range tests {
+		r, err := f(s, tt.key, tt.end, RangeOptions{Limit: tt.limit, Count: true, GroupBy: []byte("/"), GroupFrom: tt.from})
+		if err != nil {
+			t.Fatalf("#%d: range error (%v)", i, err)
+		}
+		if !reflect.DeepEqual(r.Groups, tt.wgroups) {
+			t.Errorf("#%d: groups = %+v, want %+v", i, r.Groups, tt.wgroups)
+		}
+		if !bytes.Equal(r.NextGroupKey, tt.wnext) {
+			t.Errorf("#%d: next group key = %q, want %q", i, r.NextGroupKey, tt.wnext)
+		}
+		total := 0
+		for _, g := range tt.wgroups {
+			total += g.Count
+		}
+		if r.Count != total {
+			t.Errorf("#%d: count = %d, want %d", i, r.Count, total)
+		}
+	}
+}
+
 func TestKVPutMultipleTimes(t *testing.T)    { testKVPutMultipleTimes(t, normalPutFunc) }
 func TestKVTxnPutMultipleTimes(t *testing.T) { testKVPutMultipleTimes(t, txnPutFunc) }
 
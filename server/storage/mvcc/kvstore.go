@@ -41,10 +41,35 @@ var (
 var restoreChunkKeys = 10000 // non-const for testing
 var defaultCompactBatchLimit = 1000
 var minimumBatchInterval = 10 * time.Millisecond
+var defaultCompactionMaxSleepInterval = time.Second
 
 type StoreConfig struct {
 	CompactionBatchLimit    int
 	CompactionSleepInterval time.Duration
+	// CompactionPauseTarget, if set, is the target time each compaction batch
+	// holds up writes, deleting its keys and committing them to the backend.
+	// The batch size is reduced and the sleep between batches increased while
+	// batches take longer, and restored while they are faster.
+	CompactionPauseTarget time.Duration
+	// CompactionMaxSleepInterval bounds the sleep between compaction batches
+	// when they take longer than CompactionPauseTarget.
+	CompactionMaxSleepInterval time.Duration
+	// ValueTransformer, if set, transforms key-value records stored in
+	// the backend, e.g. to encrypt them at rest.
+	ValueTransformer ValueTransformer
+	// UsagePrefixes are the key prefixes for which the number of keys, the
+	// size of their values and the number of writes are exported as metrics.
+	UsagePrefixes []string
+	// SlowWatcherPolicy is what is done with the watchers that cannot keep up
+	// with the events once they lag more than SlowWatcherMaxLag revisions
+	// behind the store.
+	SlowWatcherPolicy SlowWatcherPolicy
+	// SlowWatcherMaxLag is the number of revisions a slow watcher may lag
+	// behind the store before the SlowWatcherPolicy applies.
+	SlowWatcherMaxLag int64
+	// SlowWatcherBlockTimeout bounds how long a write blocks on the watchers
+	// with a full channel under SlowWatcherPolicyBlock.
+	SlowWatcherBlockTimeout time.Duration
 }
 
 type store struct {
@@ -61,7 +86,7 @@ type store struct {
 
 	le lease.Lessor
 
-	// revMuLock protects currentRev and compactMainRev.
+	// revMuLock protects currentRev, compactMainRev and rangeTombstoneRev.
 	// Locked at end of write txn and released after write txn unlock lock.
 	// Locked before locking read txn and released after locking.
 	revMu sync.RWMutex
@@ -69,6 +94,12 @@ type store struct {
 	currentRev int64
 	// compactMainRev is the main revision of the last compaction.
 	compactMainRev int64
+	// rangeTombstoneRev is the main revision of the last range tombstone.
+	rangeTombstoneRev int64
+
+	// compactionPacer paces the compaction batches. It is only used by the
+	// compactions scheduled on fifoSched, which run one at a time.
+	compactionPacer *compactionPacer
 
 	fifoSched schedule.Scheduler
 
@@ -90,6 +121,9 @@ func NewStore(lg *zap.Logger, b backend.Backend, le lease.Lessor, cfg StoreConfi
 	if cfg.CompactionSleepInterval == 0 {
 		cfg.CompactionSleepInterval = minimumBatchInterval
 	}
+	if cfg.CompactionMaxSleepInterval < cfg.CompactionSleepInterval {
+		cfg.CompactionMaxSleepInterval = max(defaultCompactionMaxSleepInterval, cfg.CompactionSleepInterval)
+	}
 	s := &store{
 		cfg:     cfg,
 		b:       b,
@@ -185,7 +219,7 @@ func (s *store) hashByRev(rev int64) (hash KeyValueHash, currentRev int64, err e
 	tx.RLock()
 	defer tx.RUnlock()
 	s.mu.RUnlock()
-	hash, err = unsafeHashByRev(tx, compactRev, rev, keep)
+	hash, err = unsafeHashByRev(tx, s.lg, s.cfg.ValueTransformer, compactRev, rev, keep)
 	hashRevSec.Observe(time.Since(start).Seconds())
 	return hash, currentRev, err
 }
@@ -294,12 +328,18 @@ func (s *store) Restore(b backend.Backend) error {
 
 	s.b = b
 	s.kvindex = newTreeIndex(s.lg)
+	if vt := s.cfg.ValueTransformer; vt != nil {
+		if err := vt.Restore(b); err != nil {
+			return err
+		}
+	}
 
 	{
 		// During restore the metrics might report 'special' values
 		s.revMu.Lock()
 		s.currentRev = 1
 		s.compactMainRev = -1
+		s.rangeTombstoneRev = 0
 		s.revMu.Unlock()
 	}
 
@@ -339,14 +379,20 @@ func (s *store) restore() error {
 	// index keys concurrently as they're loaded in from tx
 	keysGauge.Set(0)
 	rkvc, revc := restoreIntoIndex(s.lg, s.kvindex)
+	var rangeTombstoneRev int64
 	for {
 		keys, vals := tx.UnsafeRange(schema.Key, min, max, int64(restoreChunkKeys))
 		if len(keys) == 0 {
 			break
 		}
+		for _, k := range keys {
+			if isRangeTombstone(k) {
+				rangeTombstoneRev = BytesToRev(k).Main
+			}
+		}
 		// rkvc blocks if the total pending keys exceeds the restore
 		// chunk size to keep keys from consuming too much memory.
-		restoreChunk(s.lg, rkvc, keys, vals, keyToLease)
+		restoreChunk(s.lg, rkvc, keys, s.fromStorageAll(vals), keyToLease)
 		if len(keys) < restoreChunkKeys {
 			// partial set implies final set
 			break
@@ -361,6 +407,7 @@ func (s *store) restore() error {
 	{
 		s.revMu.Lock()
 		s.currentRev = <-revc
+		s.rangeTombstoneRev = rangeTombstoneRev
 
 		// keys in the range [compacted revision -N, compaction] might all be deleted due to compaction.
 		// the correct revision should be set to compaction revision in the case, not the largest revision
@@ -382,6 +429,8 @@ func (s *store) restore() error {
 		s.revMu.Unlock()
 	}
 
+	s.restorePrefixUsage(tx)
+
 	if scheduledCompact <= s.compactMainRev {
 		scheduledCompact = 0
 	}
@@ -463,6 +512,11 @@ func restoreIntoIndex(lg *zap.Logger, idx index) (chan<- revKeyValue, <-chan int
 			})
 			currentRev = rev.Main
 
+			if isRangeTombstone(rkv.key) {
+				restoreRangeTombstone(idx, &rkv.kv, rev)
+				continue
+			}
+
 			if ok {
 				if isTombstone(rkv.key) {
 					if err := ki.tombstone(lg, rev.Main, rev.Sub); err != nil {
@@ -488,7 +542,14 @@ func restoreChunk(lg *zap.Logger, kvc chan<- revKeyValue, keys, vals [][]byte, k
 			lg.Fatal("failed to unmarshal mvccpb.KeyValue", zap.Error(err))
 		}
 		rkv.kstr = string(rkv.kv.Key)
-		if isTombstone(key) {
+		if isRangeTombstone(key) {
+			start, end := rangeTombstoneRange(&rkv.kv)
+			for k := range keyToLease {
+				if inRange([]byte(k), start, end) {
+					delete(keyToLease, k)
+				}
+			}
+		} else if isTombstone(key) {
 			delete(keyToLease, rkv.kstr)
 		} else if lid := lease.LeaseID(rkv.kv.Lease); lid != lease.NoLease {
 			keyToLease[rkv.kstr] = lid
@@ -499,6 +560,14 @@ func restoreChunk(lg *zap.Logger, kvc chan<- revKeyValue, keys, vals [][]byte, k
 	}
 }
 
+// HasRangeTombstones returns whether the backend holds range tombstones not
+// compacted yet.
+func (s *store) HasRangeTombstones() bool {
+	s.revMu.RLock()
+	defer s.revMu.RUnlock()
+	return s.rangeTombstoneRev != 0 && s.rangeTombstoneRev > s.compactMainRev
+}
+
 func (s *store) Close() error {
 	close(s.stopc)
 	s.fifoSched.Stop()
@@ -39,19 +39,24 @@ func (s *store) scheduleCompaction(compactMainRev, prevCompactRev int64) (KeyVal
 	end := make([]byte, 8)
 	binary.BigEndian.PutUint64(end, uint64(compactMainRev+1))
 
-	batchNum := s.cfg.CompactionBatchLimit
-	batchTicker := time.NewTicker(s.cfg.CompactionSleepInterval)
-	defer batchTicker.Stop()
+	if s.compactionPacer == nil {
+		s.compactionPacer = newCompactionPacer(s.cfg)
+	}
+	pacer := s.compactionPacer
+	batchTimer := time.NewTimer(0)
+	defer batchTimer.Stop()
 	h := newKVHasher(prevCompactRev, compactMainRev, keep)
 	last := make([]byte, 8+1+8)
 	for {
 		var rev Revision
 
 		start := time.Now()
+		batchNum := pacer.batch
 
 		tx := s.b.BatchTx()
 		tx.LockOutsideApply()
 		keys, values := tx.UnsafeRange(schema.Key, last, end, int64(batchNum))
+		values = s.fromStorageAll(values)
 		for i := range keys {
 			rev = BytesToRev(keys[i])
 			if _, ok := keep[rev]; !ok {
@@ -88,12 +93,72 @@ func (s *store) scheduleCompaction(compactMainRev, prevCompactRev int64) (KeyVal
 		// gofail: var compactBeforeCommitBatch struct{}
 		s.b.ForceCommit()
 		// gofail: var compactAfterCommitBatch struct{}
-		dbCompactionPauseMs.Observe(float64(time.Since(start) / time.Millisecond))
+		pause := time.Since(start)
+		dbCompactionPauseMs.Observe(float64(pause / time.Millisecond))
+		dbCompactionPauseSec.Observe(pause.Seconds())
+		pacer.observe(pause)
 
+		batchTimer.Reset(pacer.sleep)
 		select {
-		case <-batchTicker.C:
+		case <-batchTimer.C:
 		case <-s.stopc:
 			return KeyValueHash{}, fmt.Errorf("interrupted due to stop signal")
 		}
 	}
 }
+
+// minCompactionBatch is the smallest batch the compaction pacer reduces the
+// compaction batches to.
+const minCompactionBatch = 10
+
+// compactionPacer adapts the size of the compaction batches and the sleep
+// between them to the time the batches hold up writes. It halves the batch
+// and doubles the sleep when a batch takes longer than the target, and grows
+// them back towards the configured limits when batches take less than half
+// of it.
+type compactionPacer struct {
+	target time.Duration
+
+	maxBatch, minBatch int
+	minSleep, maxSleep time.Duration
+
+	batch int
+	sleep time.Duration
+}
+
+func newCompactionPacer(cfg StoreConfig) *compactionPacer {
+	p := &compactionPacer{
+		target:   cfg.CompactionPauseTarget,
+		maxBatch: cfg.CompactionBatchLimit,
+		minBatch: min(minCompactionBatch, cfg.CompactionBatchLimit),
+		minSleep: cfg.CompactionSleepInterval,
+		maxSleep: max(cfg.CompactionMaxSleepInterval, cfg.CompactionSleepInterval),
+		batch:    cfg.CompactionBatchLimit,
+		sleep:    cfg.CompactionSleepInterval,
+	}
+	p.report()
+	return p
+}
+
+// observe adapts the batch size and sleep to the pause of the last batch.
+func (p *compactionPacer) observe(pause time.Duration) {
+	switch {
+	case p.target <= 0:
+		return
+	case pause > p.target:
+		dbCompactionThrottledCounter.Inc()
+		p.batch = max(p.batch/2, p.minBatch)
+		p.sleep = min(p.sleep*2, p.maxSleep)
+	case pause < p.target/2:
+		p.batch = min(p.batch+max(p.maxBatch/10, 1), p.maxBatch)
+		p.sleep = max(p.sleep/2, p.minSleep)
+	default:
+		return
+	}
+	p.report()
+}
+
+func (p *compactionPacer) report() {
+	dbCompactionBatchSize.Set(float64(p.batch))
+	dbCompactionSleepSec.Set(p.sleep.Seconds())
+}
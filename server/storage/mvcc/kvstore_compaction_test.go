@@ -148,3 +148,55 @@ func TestCompactAllAndRestore(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestCompactionPacer(t *testing.T) {
+	p := newCompactionPacer(StoreConfig{
+		CompactionBatchLimit:       1000,
+		CompactionSleepInterval:    10 * time.Millisecond,
+		CompactionPauseTarget:      100 * time.Millisecond,
+		CompactionMaxSleepInterval: 50 * time.Millisecond,
+	})
+
+	tests := []struct {
+		pause time.Duration
+
+		wbatch int
+		wsleep time.Duration
+	}{
+		// slow batches back off down to the limits
+		{200 * time.Millisecond, 500, 20 * time.Millisecond},
+		{200 * time.Millisecond, 250, 40 * time.Millisecond},
+		{200 * time.Millisecond, 125, 50 * time.Millisecond},
+		{200 * time.Millisecond, 62, 50 * time.Millisecond},
+		{200 * time.Millisecond, 31, 50 * time.Millisecond},
+		{200 * time.Millisecond, 15, 50 * time.Millisecond},
+		{200 * time.Millisecond, 10, 50 * time.Millisecond},
+		// batches close to the target keep the pace
+		{80 * time.Millisecond, 10, 50 * time.Millisecond},
+		// fast batches recover towards the configured limits
+		{10 * time.Millisecond, 110, 25 * time.Millisecond},
+		{10 * time.Millisecond, 210, 12500 * time.Microsecond},
+		{10 * time.Millisecond, 310, 10 * time.Millisecond},
+	}
+	for i, tt := range tests {
+		p.observe(tt.pause)
+		if p.batch != tt.wbatch || p.sleep != tt.wsleep {
+			t.Errorf("#%d: batch, sleep = %d, %v, want %d, %v", i, p.batch, p.sleep, tt.wbatch, tt.wsleep)
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		p.observe(0)
+	}
+	if p.batch != 1000 || p.sleep != 10*time.Millisecond {
+		t.Errorf("batch, sleep = %d, %v, want the configured limits", p.batch, p.sleep)
+	}
+}
+
+func TestCompactionPacerDisabled(t *testing.T) {
+	p := newCompactionPacer(StoreConfig{CompactionBatchLimit: 1000, CompactionSleepInterval: 10 * time.Millisecond})
+	p.observe(time.Hour)
+	if p.batch != 1000 || p.sleep != 10*time.Millisecond {
+		t.Errorf("batch, sleep = %d, %v, want 1000, 10ms", p.batch, p.sleep)
+	}
+}
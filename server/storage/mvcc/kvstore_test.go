@@ -1035,6 +1035,21 @@ func (i *fakeIndex) CountRevisions(key, end []byte, atRev int64) int {
 	return len(rev)
 }
 
+func (i *fakeIndex) CountRevisionsGrouped(key, end []byte, atRev int64, group func(key []byte) []byte, limit int) ([]KeyGroupCount, []byte) {
+	keys, _ := i.Range(key, end, atRev)
+	var groups []KeyGroupCount
+	for _, k := range keys {
+		if n := len(groups); n > 0 && bytes.Equal(groups[n-1].Prefix, group(k)) {
+			groups[n-1].Count++
+		} else if limit > 0 && len(groups) == limit {
+			return groups, k
+		} else {
+			groups = append(groups, KeyGroupCount{Prefix: group(k), Count: 1})
+		}
+	}
+	return groups, nil
+}
+
 func (i *fakeIndex) Get(key []byte, atRev int64) (rev, created Revision, ver int64, err error) {
 	i.Recorder.Record(testutil.Action{Name: "get", Params: []any{key, atRev}})
 	r := <-i.indexGetRespc
@@ -1052,6 +1067,15 @@ func (i *fakeIndex) Tombstone(key []byte, rev Revision) error {
 	i.Recorder.Record(testutil.Action{Name: "tombstone", Params: []any{key, rev}})
 	return nil
 }
+func (i *fakeIndex) TombstoneRange(key, end []byte, atRev int64, rev Revision) ([][]byte, []Revision) {
+	i.Recorder.Record(testutil.Action{Name: "tombstoneRange", Params: []any{key, end, atRev, rev}})
+	r := <-i.indexRangeRespc
+	return r.keys, r.revs
+}
+func (i *fakeIndex) Tombstoned(key, end []byte, rev Revision) [][]byte {
+	i.Recorder.Record(testutil.Action{Name: "tombstoned", Params: []any{key, end, rev}})
+	return nil
+}
 func (i *fakeIndex) RangeSince(key, end []byte, rev int64) []Revision {
 	i.Recorder.Record(testutil.Action{Name: "rangeEvents", Params: []any{key, end, rev}})
 	r := <-i.indexRangeEventsRespc
@@ -15,6 +15,7 @@
 package mvcc
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 
@@ -69,6 +70,22 @@ func (tr *storeTxnCommon) Range(ctx context.Context, key, end []byte, ro RangeOp
 	return tr.rangeKeys(ctx, key, end, tr.Rev(), ro)
 }
 
+// groupByPrefix returns a function mapping a key to its prefix up to and
+// including the first separator following the given prefix, or to the key
+// itself if there is none.
+func groupByPrefix(prefix, sep []byte) func(key []byte) []byte {
+	return func(key []byte) []byte {
+		start := 0
+		if bytes.HasPrefix(key, prefix) {
+			start = len(prefix)
+		}
+		if i := bytes.Index(key[start:], sep); i >= 0 {
+			return key[:start+i+len(sep)]
+		}
+		return key
+	}
+}
+
 func (tr *storeTxnCommon) rangeKeys(ctx context.Context, key, end []byte, curRev int64, ro RangeOptions) (*RangeResult, error) {
 	rev := ro.Rev
 	if rev > curRev {
@@ -80,6 +97,19 @@ func (tr *storeTxnCommon) rangeKeys(ctx context.Context, key, end []byte, curRev
 	if rev < tr.s.compactMainRev {
 		return &RangeResult{KVs: nil, Count: -1, Rev: 0}, ErrCompacted
 	}
+	if ro.Count && len(ro.GroupBy) != 0 {
+		from := key
+		if end != nil && bytes.Compare(ro.GroupFrom, key) > 0 {
+			from = ro.GroupFrom
+		}
+		groups, next := tr.s.kvindex.CountRevisionsGrouped(from, end, rev, groupByPrefix(key, ro.GroupBy), int(ro.Limit))
+		tr.trace.Step("count grouped revisions from in-memory index tree")
+		total := 0
+		for _, g := range groups {
+			total += g.Count
+		}
+		return &RangeResult{KVs: nil, Count: total, Groups: groups, NextGroupKey: next, Rev: curRev}, nil
+	}
 	if ro.Count {
 		total := tr.s.kvindex.CountRevisions(key, end, rev)
 		tr.trace.Step("count revisions from in-memory index tree")
@@ -120,7 +150,7 @@ func (tr *storeTxnCommon) rangeKeys(ctx context.Context, key, end []byte, curRev
 				zap.Int("len-values", len(vs)),
 			)
 		}
-		if err := kvs[i].Unmarshal(vs[0]); err != nil {
+		if err := kvs[i].Unmarshal(tr.s.fromStorage(vs[0])); err != nil {
 			tr.s.lg.Fatal(
 				"failed to unmarshal mvccpb.KeyValue",
 				zap.Error(err),
@@ -142,12 +172,19 @@ type storeTxnWrite struct {
 	// beginRev is the revision where the txn begins; it will write to the next revision.
 	beginRev int64
 	changes  []mvccpb.KeyValue
+
+	// rangeTombstoneThreshold, if not zero, is the number of keys above
+	// which a deletion records a single range tombstone.
+	rangeTombstoneThreshold int
+	// rangeTombstoned is whether the txn recorded a range tombstone.
+	rangeTombstoned bool
 }
 
 func (s *store) Write(trace *traceutil.Trace) TxnWrite {
 	s.mu.RLock()
 	tx := s.b.BatchTx()
 	tx.LockInsideApply()
+	trace.Step("acquire backend batch tx lock")
 	tw := &storeTxnWrite{
 		storeTxnCommon: storeTxnCommon{s, tx, 0, 0, trace},
 		tx:             tx,
@@ -185,6 +222,9 @@ func (tw *storeTxnWrite) End() {
 		// hold revMu lock to prevent new read txns from opening until writeback.
 		tw.s.revMu.Lock()
 		tw.s.currentRev++
+		if tw.rangeTombstoned {
+			tw.s.rangeTombstoneRev = tw.s.currentRev
+		}
 	}
 	tw.tx.Unlock()
 	if len(tw.changes) != 0 {
@@ -200,11 +240,14 @@ func (tw *storeTxnWrite) put(key, value []byte, leaseID lease.LeaseID) {
 
 	// if the key exists before, use its previous created and
 	// get its previous leaseID
-	_, created, ver, err := tw.s.kvindex.Get(key, rev)
+	prev, created, ver, err := tw.s.kvindex.Get(key, rev)
 	if err == nil {
+		tw.updatePrefixUsage(key, &prev, value)
 		c = created.Main
 		oldLease = tw.s.le.GetLease(lease.LeaseItem{Key: string(key)})
 		tw.trace.Step("get key's previous created_revision and leaseID")
+	} else {
+		tw.updatePrefixUsage(key, nil, value)
 	}
 	ibytes := NewRevBytes()
 	idxRev := Revision{Main: rev, Sub: int64(len(tw.changes))}
@@ -229,7 +272,7 @@ func (tw *storeTxnWrite) put(key, value []byte, leaseID lease.LeaseID) {
 	}
 
 	tw.trace.Step("marshal mvccpb.KeyValue")
-	tw.tx.UnsafeSeqPut(schema.Key, ibytes, d)
+	tw.tx.UnsafeSeqPut(schema.Key, ibytes, tw.s.toStorage(d))
 	tw.s.kvindex.Put(key, idxRev)
 	tw.changes = append(tw.changes, kv)
 	tw.trace.Step("store kv pair into bolt db")
@@ -268,17 +311,22 @@ func (tw *storeTxnWrite) deleteRange(key, end []byte) int64 {
 	if len(tw.changes) > 0 {
 		rrev++
 	}
-	keys, _ := tw.s.kvindex.Range(key, end, rrev)
+	if t := tw.rangeTombstoneThreshold; t > 0 && end != nil && tw.s.kvindex.CountRevisions(key, end, rrev) > t {
+		return tw.deleteRangeTombstone(key, end, rrev)
+	}
+	keys, revs := tw.s.kvindex.Range(key, end, rrev)
 	if len(keys) == 0 {
 		return 0
 	}
-	for _, key := range keys {
-		tw.delete(key)
+	for i, key := range keys {
+		tw.delete(key, revs[i])
 	}
 	return int64(len(keys))
 }
 
-func (tw *storeTxnWrite) delete(key []byte) {
+func (tw *storeTxnWrite) delete(key []byte, prev Revision) {
+	tw.updatePrefixUsage(key, &prev, nil)
+
 	ibytes := NewRevBytes()
 	idxRev := newBucketKey(tw.beginRev+1, int64(len(tw.changes)), true)
 	ibytes = BucketKeyToBytes(idxRev, ibytes)
@@ -293,7 +341,7 @@ func (tw *storeTxnWrite) delete(key []byte) {
 		)
 	}
 
-	tw.tx.UnsafeSeqPut(schema.Key, ibytes, d)
+	tw.tx.UnsafeSeqPut(schema.Key, ibytes, tw.s.toStorage(d))
 	err = tw.s.kvindex.Tombstone(key, idxRev.Revision)
 	if err != nil {
 		tw.storeTxnCommon.s.lg.Fatal(
@@ -303,12 +351,15 @@ func (tw *storeTxnWrite) delete(key []byte) {
 		)
 	}
 	tw.changes = append(tw.changes, kv)
+	tw.detachLease(key)
+}
 
+func (tw *storeTxnWrite) detachLease(key []byte) {
 	item := lease.LeaseItem{Key: string(key)}
 	leaseID := tw.s.le.GetLease(item)
 
 	if leaseID != lease.NoLease {
-		err = tw.s.le.Detach(leaseID, []lease.LeaseItem{item})
+		err := tw.s.le.Detach(leaseID, []lease.LeaseItem{item})
 		if err != nil {
 			tw.storeTxnCommon.s.lg.Error(
 				"failed to detach old lease from a key",
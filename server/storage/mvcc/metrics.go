@@ -45,6 +45,14 @@ var (
 			Help:      "Total number of deletes seen by this member.",
 		})
 
+	rangeTombstonesCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "etcd_debugging",
+			Subsystem: "mvcc",
+			Name:      "range_tombstones_total",
+			Help:      "Total number of deletes recorded as a single range tombstone.",
+		})
+
 	txnCounter = prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Namespace: "etcd",
@@ -85,6 +93,36 @@ var (
 			Help:      "Total number of unsynced slow watchers.",
 		})
 
+	slowWatcherMaxLagGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "etcd",
+			Subsystem: "mvcc",
+			Name:      "slow_watcher_max_lag_revisions",
+			Help:      "Largest number of revisions a slow watcher, whose channel was found full, lags behind the store.",
+		})
+
+	slowWatcherPolicyCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "etcd",
+			Subsystem: "mvcc",
+			Name:      "slow_watcher_policy_total",
+			Help:      "Total number of slow watchers canceled or resynchronized by the slow watcher policy.",
+		},
+		[]string{"policy"},
+	)
+
+	slowWatcherBlockSec = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "etcd",
+			Subsystem: "mvcc",
+			Name:      "slow_watcher_block_duration_seconds",
+			Help:      "Bucketed histogram of the time writes blocked on slow watchers.",
+
+			// lowest bucket start of upper bound 0.001 sec (1 ms) with factor 2
+			// highest bucket start of 0.001 sec * 2^13 == 8.192 sec
+			Buckets: prometheus.ExponentialBuckets(.001, 2, 14),
+		})
+
 	totalEventsCounter = prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Namespace: "etcd_debugging",
@@ -125,6 +163,42 @@ var (
 			Buckets: prometheus.ExponentialBuckets(1, 2, 13),
 		})
 
+	dbCompactionPauseSec = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "etcd",
+			Subsystem: "mvcc",
+			Name:      "db_compaction_pause_duration_seconds",
+			Help:      "Bucketed histogram of the time each db compaction batch holds up writes.",
+
+			// lowest bucket start of upper bound 0.001 sec (1 ms) with factor 2
+			// highest bucket start of 0.001 sec * 2^12 == 4.096 sec
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 13),
+		})
+
+	dbCompactionBatchSize = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "etcd",
+			Subsystem: "mvcc",
+			Name:      "db_compaction_batch_size",
+			Help:      "The current maximum number of revisions deleted in each db compaction batch.",
+		})
+
+	dbCompactionSleepSec = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "etcd",
+			Subsystem: "mvcc",
+			Name:      "db_compaction_sleep_interval_seconds",
+			Help:      "The current sleep interval between db compaction batches.",
+		})
+
+	dbCompactionThrottledCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "etcd",
+			Subsystem: "mvcc",
+			Name:      "db_compaction_throttled_total",
+			Help:      "Total number of db compaction batches that took longer than the compaction pause target.",
+		})
+
 	dbCompactionTotalMs = prometheus.NewHistogram(
 		prometheus.HistogramOpts{
 			Namespace: "etcd_debugging",
@@ -265,21 +339,59 @@ var (
 			Name:      "total_put_size_in_bytes",
 			Help:      "The total size of put kv pairs seen by this member.",
 		})
+
+	prefixKeysGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "etcd",
+			Subsystem: "mvcc",
+			Name:      "prefix_keys",
+			Help:      "Number of keys under the tracked prefix.",
+		},
+		[]string{"prefix"},
+	)
+
+	prefixValueBytesGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "etcd",
+			Subsystem: "mvcc",
+			Name:      "prefix_value_size_bytes",
+			Help:      "Total size of the values of the keys under the tracked prefix.",
+		},
+		[]string{"prefix"},
+	)
+
+	prefixWritesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "etcd",
+			Subsystem: "mvcc",
+			Name:      "prefix_writes_total",
+			Help:      "Total number of puts and deletes of keys under the tracked prefix.",
+		},
+		[]string{"prefix"},
+	)
 )
 
 func init() {
 	prometheus.MustRegister(rangeCounter)
 	prometheus.MustRegister(putCounter)
 	prometheus.MustRegister(deleteCounter)
+	prometheus.MustRegister(rangeTombstonesCounter)
 	prometheus.MustRegister(txnCounter)
 	prometheus.MustRegister(keysGauge)
 	prometheus.MustRegister(watchStreamGauge)
 	prometheus.MustRegister(watcherGauge)
 	prometheus.MustRegister(slowWatcherGauge)
+	prometheus.MustRegister(slowWatcherMaxLagGauge)
+	prometheus.MustRegister(slowWatcherPolicyCounter)
+	prometheus.MustRegister(slowWatcherBlockSec)
 	prometheus.MustRegister(totalEventsCounter)
 	prometheus.MustRegister(pendingEventsGauge)
 	prometheus.MustRegister(indexCompactionPauseMs)
 	prometheus.MustRegister(dbCompactionPauseMs)
+	prometheus.MustRegister(dbCompactionPauseSec)
+	prometheus.MustRegister(dbCompactionBatchSize)
+	prometheus.MustRegister(dbCompactionSleepSec)
+	prometheus.MustRegister(dbCompactionThrottledCounter)
 	prometheus.MustRegister(dbCompactionTotalMs)
 	prometheus.MustRegister(dbCompactionLast)
 	prometheus.MustRegister(dbCompactionKeysCounter)
@@ -291,6 +403,9 @@ func init() {
 	prometheus.MustRegister(currentRev)
 	prometheus.MustRegister(compactRev)
 	prometheus.MustRegister(totalPutSizeGauge)
+	prometheus.MustRegister(prefixKeysGauge)
+	prometheus.MustRegister(prefixValueBytesGauge)
+	prometheus.MustRegister(prefixWritesCounter)
 }
 
 // ReportEventReceived reports that an event is received.
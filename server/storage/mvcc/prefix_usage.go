@@ -0,0 +1,105 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"bytes"
+
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+)
+
+// usagePrefixesOf returns the tracked usage prefixes the key is under.
+func (s *store) usagePrefixesOf(key []byte) []string {
+	var prefixes []string
+	for _, p := range s.cfg.UsagePrefixes {
+		if bytes.HasPrefix(key, []byte(p)) {
+			prefixes = append(prefixes, p)
+		}
+	}
+	return prefixes
+}
+
+// valueSizeAt returns the size of the value of the key-value pair at rev.
+func (s *store) valueSizeAt(tx backend.UnsafeReader, rev Revision) int {
+	_, vs := tx.UnsafeRange(schema.Key, RevToBytes(rev, NewRevBytes()), nil, 0)
+	if len(vs) != 1 {
+		s.lg.Fatal(
+			"range failed to find revision pair",
+			zap.Int64("revision-main", rev.Main),
+			zap.Int64("revision-sub", rev.Sub),
+		)
+	}
+	var kv mvccpb.KeyValue
+	if err := kv.Unmarshal(s.fromStorage(vs[0])); err != nil {
+		s.lg.Fatal("failed to unmarshal mvccpb.KeyValue", zap.Error(err))
+	}
+	return len(kv.Value)
+}
+
+// updatePrefixUsage updates the usage metrics of the prefixes the key is
+// under for a write replacing the value at prev, if any, by value, or
+// deleting it if value is nil.
+func (tw *storeTxnWrite) updatePrefixUsage(key []byte, prev *Revision, value []byte) {
+	prefixes := tw.s.usagePrefixesOf(key)
+	if len(prefixes) == 0 {
+		return
+	}
+	var keys, size float64
+	if prev != nil {
+		keys--
+		size -= float64(tw.s.valueSizeAt(tw.tx, *prev))
+	}
+	if value != nil {
+		keys++
+		size += float64(len(value))
+	}
+	for _, p := range prefixes {
+		prefixKeysGauge.WithLabelValues(p).Add(keys)
+		prefixValueBytesGauge.WithLabelValues(p).Add(size)
+		prefixWritesCounter.WithLabelValues(p).Inc()
+	}
+}
+
+// restorePrefixUsage sets the usage metrics of the tracked prefixes from the
+// keys in the restored index.
+func (s *store) restorePrefixUsage(tx backend.UnsafeReader) {
+	for _, p := range s.cfg.UsagePrefixes {
+		keys, revs := s.kvindex.Range([]byte(p), prefixEnd([]byte(p)), s.currentRev)
+		var size int
+		for _, rev := range revs {
+			size += s.valueSizeAt(tx, rev)
+		}
+		prefixKeysGauge.WithLabelValues(p).Set(float64(len(keys)))
+		prefixValueBytesGauge.WithLabelValues(p).Set(float64(size))
+	}
+}
+
+// prefixEnd returns the end of the range of the keys with the given prefix.
+func prefixEnd(prefix []byte) []byte {
+	end := make([]byte, len(prefix))
+	copy(end, prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// the prefix is empty or all 0xff, so range to the end of the keyspace
+	return []byte{}
+}
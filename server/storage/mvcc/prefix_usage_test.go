@@ -0,0 +1,80 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap/zaptest"
+
+	"go.etcd.io/etcd/pkg/v3/traceutil"
+	"go.etcd.io/etcd/server/v3/lease"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+)
+
+func TestStoreUsagePrefixes(t *testing.T) {
+	prefixKeysGauge.Reset()
+	prefixValueBytesGauge.Reset()
+	prefixWritesCounter.Reset()
+
+	b, tmpPath := betesting.NewDefaultTmpBackend(t)
+	cfg := StoreConfig{UsagePrefixes: []string{"a/", "a/b/"}}
+	s := NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, cfg)
+
+	s.Put([]byte("a/1"), []byte("xx"), lease.NoLease)
+	s.Put([]byte("a/b/1"), []byte("yyy"), lease.NoLease)
+	s.Put([]byte("a/1"), []byte("x"), lease.NoLease)
+	s.Put([]byte("c"), []byte("zz"), lease.NoLease)
+	txn := s.Write(traceutil.TODO())
+	txn.Put([]byte("a/b/2"), []byte("zzzz"), lease.NoLease)
+	txn.DeleteRange([]byte("a/b/1"), nil)
+	txn.End()
+
+	checkPrefixUsage(t, "a/", 2, 5, 5)
+	checkPrefixUsage(t, "a/b/", 1, 4, 3)
+
+	txn = s.Write(traceutil.TODO())
+	txn.DeleteRange([]byte("a/b/"), []byte("a/b0"))
+	txn.End()
+	checkPrefixUsage(t, "a/", 1, 1, 6)
+	checkPrefixUsage(t, "a/b/", 0, 0, 4)
+
+	s.Close()
+	b.Close()
+
+	prefixKeysGauge.Reset()
+	prefixValueBytesGauge.Reset()
+	b = backend.NewDefaultBackend(zaptest.NewLogger(t), tmpPath)
+	defer b.Close()
+	s = NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, cfg)
+	defer s.Close()
+	checkPrefixUsage(t, "a/", 1, 1, 6)
+	checkPrefixUsage(t, "a/b/", 0, 0, 4)
+}
+
+func checkPrefixUsage(t *testing.T, prefix string, keys, size, writes float64) {
+	t.Helper()
+	if got := testutil.ToFloat64(prefixKeysGauge.WithLabelValues(prefix)); got != keys {
+		t.Errorf("prefix %q: keys = %v, want %v", prefix, got, keys)
+	}
+	if got := testutil.ToFloat64(prefixValueBytesGauge.WithLabelValues(prefix)); got != size {
+		t.Errorf("prefix %q: value size = %v, want %v", prefix, got, size)
+	}
+	if got := testutil.ToFloat64(prefixWritesCounter.WithLabelValues(prefix)); got != writes {
+		t.Errorf("prefix %q: writes = %v, want %v", prefix, got, writes)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"bytes"
+
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/pkg/v3/traceutil"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+)
+
+// A range tombstone deletes all the keys of a range with a single record in
+// the backend, instead of a tombstone per key. The deleted keys are
+// tombstoned in the index at the revision of the range tombstone, which is
+// replayed on restore, and only the watchers of the deleted keys are notified
+// of their deletion. Like the tombstones, the range tombstone and the
+// revisions of the keys it deletes are removed from the backend by the
+// compactions at or after its revision.
+//
+// The members of a cluster must record the same deletions as range
+// tombstones, and be able to read them, for their keyspaces to hash the same.
+// The threshold is thus not a setting of the store, but of the write txns the
+// requests are applied with.
+
+// NewRangeTombstoneKV returns a KV whose deletions of more than threshold
+// keys record a single range tombstone.
+func NewRangeTombstoneKV(kv KV, threshold int) KV {
+	return &rangeTombstoneKV{KV: kv, threshold: threshold}
+}
+
+type rangeTombstoneKV struct {
+	KV
+	threshold int
+}
+
+func (kv *rangeTombstoneKV) Write(trace *traceutil.Trace) TxnWrite {
+	tw := kv.KV.Write(trace)
+	setRangeTombstoneThreshold(tw, kv.threshold)
+	return tw
+}
+
+func (kv *rangeTombstoneKV) DeleteRange(key, end []byte) (n, rev int64) {
+	tw := kv.Write(traceutil.TODO())
+	defer tw.End()
+	return tw.DeleteRange(key, end)
+}
+
+// setRangeTombstoneThreshold sets the range tombstone threshold of the store
+// write txn wrapped by tw.
+func setRangeTombstoneThreshold(tw TxnWrite, threshold int) {
+	switch tw := tw.(type) {
+	case *storeTxnWrite:
+		tw.rangeTombstoneThreshold = threshold
+	case *metricsTxnWrite:
+		setRangeTombstoneThreshold(tw.TxnWrite, threshold)
+	case *watchableStoreTxnWrite:
+		setRangeTombstoneThreshold(tw.TxnWrite, threshold)
+	}
+}
+
+// newRangeTombstone returns the range tombstone of the keys from key to end,
+// also the change of the write txn recording it. An empty end, the end of the
+// keyspace, is recorded as "\x00" to tell it from a missing one.
+func newRangeTombstone(key, end []byte) mvccpb.KeyValue {
+	if len(end) == 0 {
+		end = []byte{0}
+	}
+	return mvccpb.KeyValue{Key: key, Value: end}
+}
+
+// isRangeTombstoneChange returns whether the change of a write txn is a range
+// tombstone. Unlike the change of a deletion, it has a value.
+func isRangeTombstoneChange(kv *mvccpb.KeyValue) bool {
+	return kv.CreateRevision == 0 && len(kv.Value) != 0
+}
+
+// rangeTombstoneRange returns the range of the keys deleted by the range
+// tombstone kv.
+func rangeTombstoneRange(kv *mvccpb.KeyValue) (key, end []byte) {
+	if len(kv.Value) == 1 && kv.Value[0] == 0 {
+		return kv.Key, []byte{}
+	}
+	return kv.Key, kv.Value
+}
+
+// inRange returns whether key is from start(included) to end(excluded), an
+// empty end being the end of the keyspace.
+func inRange(key, start, end []byte) bool {
+	return bytes.Compare(key, start) >= 0 && (len(end) == 0 || bytes.Compare(key, end) < 0)
+}
+
+// deleteRangeTombstone deletes the keys from key to end existing at rrev
+// with a single range tombstone in the backend and a single change.
+func (tw *storeTxnWrite) deleteRangeTombstone(key, end []byte, rrev int64) int64 {
+	ibytes := NewRevBytes()
+	idxRev := Revision{Main: tw.beginRev + 1, Sub: int64(len(tw.changes))}
+	ibytes = append(RevToBytes(idxRev, ibytes), markRangeTombstone)
+
+	kv := newRangeTombstone(key, end)
+	d, err := kv.Marshal()
+	if err != nil {
+		tw.storeTxnCommon.s.lg.Fatal(
+			"failed to marshal mvccpb.KeyValue",
+			zap.Error(err),
+		)
+	}
+	tw.tx.UnsafeSeqPut(schema.Key, ibytes, tw.s.toStorage(d))
+
+	keys, revs := tw.s.kvindex.TombstoneRange(key, end, rrev, idxRev)
+	for i, key := range keys {
+		tw.updatePrefixUsage(key, &revs[i], nil)
+		tw.detachLease(key)
+	}
+	tw.changes = append(tw.changes, kv)
+	tw.rangeTombstoned = true
+	rangeTombstonesCounter.Inc()
+	tw.trace.Step("range tombstone keys", traceutil.Field{Key: "keys", Value: len(keys)})
+	return int64(len(keys))
+}
+
+// restoreRangeTombstone tombstones in idx the keys deleted by the range
+// tombstone kv at rev. The index must hold the revisions before rev.
+func restoreRangeTombstone(idx index, kv *mvccpb.KeyValue, rev Revision) {
+	key, end := rangeTombstoneRange(kv)
+	idx.TombstoneRange(key, end, rev.Main, rev)
+}
+
+// rangeTombstoneEvents returns the deletion events of the keys watched by wg
+// deleted by the range tombstone kv at rev.
+func rangeTombstoneEvents(wg *watcherGroup, idx index, kv *mvccpb.KeyValue, rev Revision) (evs []mvccpb.Event) {
+	if wg.size() == 0 {
+		return nil
+	}
+	key, end := rangeTombstoneRange(kv)
+	for _, k := range idx.Tombstoned(key, end, rev) {
+		if wg.contains(string(k)) {
+			evs = append(evs, mvccpb.Event{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{Key: k, ModRevision: rev.Main}})
+		}
+	}
+	return evs
+}
@@ -0,0 +1,156 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/pkg/v3/traceutil"
+	"go.etcd.io/etcd/server/v3/lease"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+)
+
+// countRecords returns the number of records in the key bucket at the main
+// revision rev, and how many of them are range tombstones.
+func countRecords(b backend.Backend, rev int64) (records, rangeTombstones int) {
+	tx := b.ReadTx()
+	tx.RLock()
+	defer tx.RUnlock()
+	tx.UnsafeForEach(schema.Key, func(k, _ []byte) error {
+		if BytesToRev(k).Main == rev {
+			records++
+			if isRangeTombstone(k) {
+				rangeTombstones++
+			}
+		}
+		return nil
+	})
+	return records, rangeTombstones
+}
+
+func TestRangeTombstone(t *testing.T) {
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	defer b.Close()
+	s := newWatchableStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+	rkv := NewRangeTombstoneKV(s, 5)
+
+	for i := 0; i < 10; i++ {
+		s.Put([]byte(fmt.Sprintf("foo/%d", i)), []byte("bar"), lease.NoLease)
+	}
+	s.Put([]byte("fop"), []byte("bar"), lease.NoLease)
+
+	ws := s.NewWatchStream()
+	defer ws.Close()
+	_, err := ws.Watch(0, []byte("foo/"), []byte("foo0"), 0)
+	require.NoError(t, err)
+
+	// a delete of fewer keys than the threshold has a tombstone per key
+	n, rev := rkv.DeleteRange([]byte("fop"), []byte("foq"))
+	assert.Equal(t, int64(1), n)
+	records, rangeTombstones := countRecords(b, rev)
+	assert.Equal(t, 1, records)
+	assert.Equal(t, 0, rangeTombstones)
+	assert.False(t, s.HasRangeTombstones())
+
+	// a delete of more keys than the threshold has a single range tombstone
+	// and a single change
+	tw := rkv.Write(traceutil.TODO())
+	n, delRev := tw.DeleteRange([]byte("foo/"), []byte("foo0"))
+	assert.Len(t, tw.Changes(), 1)
+	tw.End()
+	assert.Equal(t, int64(10), n)
+	records, rangeTombstones = countRecords(b, delRev)
+	assert.Equal(t, 1, records)
+	assert.Equal(t, 1, rangeTombstones)
+	assert.True(t, s.HasRangeTombstones())
+
+	select {
+	case resp := <-ws.Chan():
+		require.Len(t, resp.Events, 10)
+		for _, ev := range resp.Events {
+			assert.Equal(t, mvccpb.DELETE, ev.Type)
+			assert.Equal(t, delRev, ev.Kv.ModRevision)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("failed to receive the deletions")
+	}
+	require.NoError(t, ws.Cancel(0))
+
+	check := func(s KV) {
+		r, err := s.Range(context.TODO(), []byte("foo/"), []byte("foo0"), RangeOptions{})
+		require.NoError(t, err)
+		assert.Empty(t, r.KVs)
+		r, err = s.Range(context.TODO(), []byte("foo/"), []byte("foo0"), RangeOptions{Rev: delRev - 1})
+		require.NoError(t, err)
+		assert.Len(t, r.KVs, 10)
+		r, err = s.Range(context.TODO(), []byte("foo/"), []byte("foo0"), RangeOptions{Count: true})
+		require.NoError(t, err)
+		assert.Equal(t, 0, r.Count)
+	}
+	check(s)
+
+	// a put of a deleted key creates it again
+	rev = s.Put([]byte("foo/3"), []byte("baz"), lease.NoLease)
+	r, err := s.Range(context.TODO(), []byte("foo/3"), nil, RangeOptions{})
+	require.NoError(t, err)
+	require.Len(t, r.KVs, 1)
+	assert.Equal(t, rev, r.KVs[0].CreateRevision)
+	assert.Equal(t, int64(1), r.KVs[0].Version)
+	n, _ = s.DeleteRange([]byte("foo/3"), nil)
+	assert.Equal(t, int64(1), n)
+
+	// an unsynced watcher gets the deletions from the range tombstone
+	_, err = ws.Watch(1, []byte("foo/"), []byte("foo0"), delRev)
+	require.NoError(t, err)
+	select {
+	case resp := <-ws.Chan():
+		require.Len(t, resp.Events, 12)
+		for _, ev := range resp.Events[:10] {
+			assert.Equal(t, mvccpb.DELETE, ev.Type)
+			assert.Equal(t, delRev, ev.Kv.ModRevision)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("failed to receive the deletions")
+	}
+
+	s.Close()
+	s = newWatchableStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+	defer s.Close()
+	check(s)
+	assert.True(t, s.HasRangeTombstones())
+
+	// the compaction reclaims the deleted keys and the range tombstone
+	done, err := s.Compact(traceutil.TODO(), delRev)
+	require.NoError(t, err)
+	<-done
+	records, _ = countRecords(b, delRev)
+	assert.Equal(t, 0, records)
+	assert.False(t, s.HasRangeTombstones())
+	tx := b.ReadTx()
+	tx.RLock()
+	keys, _ := tx.UnsafeRange(schema.Key, RevToBytes(Revision{Main: 1}, NewRevBytes()), RevToBytes(Revision{Main: delRev}, NewRevBytes()), 0)
+	tx.RUnlock()
+	assert.Empty(t, keys)
+}
@@ -30,6 +30,10 @@ const (
 	markedRevBytesLen      = revBytesLen + 1
 	markBytePosition       = markedRevBytesLen - 1
 	markTombstone     byte = 't'
+	// markRangeTombstone marks the revision of a range tombstone, deleting
+	// all the keys of a range at once. Its value is a mvccpb.KeyValue whose
+	// Key and Value are the start and the end of the range.
+	markRangeTombstone byte = 'r'
 )
 
 type Revision struct {
@@ -55,6 +59,11 @@ func RevToBytes(rev Revision, bytes []byte) []byte {
 	return BucketKeyToBytes(newBucketKey(rev.Main, rev.Sub, false), bytes)
 }
 
+// TombstoneRevToBytes is RevToBytes for the revision of a tombstone.
+func TombstoneRevToBytes(rev Revision, bytes []byte) []byte {
+	return BucketKeyToBytes(newBucketKey(rev.Main, rev.Sub, true), bytes)
+}
+
 func BytesToRev(bytes []byte) Revision {
 	return BytesToBucketKey(bytes).Revision
 }
@@ -120,3 +129,8 @@ func BytesToBucketKey(bytes []byte) BucketKey {
 func isTombstone(b []byte) bool {
 	return len(b) == markedRevBytesLen && b[markBytePosition] == markTombstone
 }
+
+// isRangeTombstone checks whether the revision bytes is a range tombstone.
+func isRangeTombstone(b []byte) bool {
+	return len(b) == markedRevBytesLen && b[markBytePosition] == markRangeTombstone
+}
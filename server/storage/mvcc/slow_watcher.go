@@ -0,0 +1,139 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"fmt"
+	"time"
+)
+
+// SlowWatcherPolicy is what is done with a slow watcher, one whose channel was
+// found full, once it lags more than StoreConfig.SlowWatcherMaxLag revisions
+// behind the store.
+type SlowWatcherPolicy string
+
+const (
+	// SlowWatcherPolicyNone lets the slow watchers catch up for as long as
+	// they need.
+	SlowWatcherPolicyNone SlowWatcherPolicy = ""
+	// SlowWatcherPolicyCancel cancels the slow watchers as if the revisions
+	// they missed were compacted, so their clients get ErrCompacted and can
+	// resynchronize from the current revision.
+	SlowWatcherPolicyCancel SlowWatcherPolicy = "cancel"
+	// SlowWatcherPolicyResync drops the events the slow watchers missed and
+	// sends them a response marking that they need to resynchronize from the
+	// current revision, from which they keep watching. Only the watchers
+	// allowed to, see WatchStream.AllowResync, are resynchronized; the others
+	// are canceled as with SlowWatcherPolicyCancel.
+	SlowWatcherPolicyResync SlowWatcherPolicy = "resync"
+	// SlowWatcherPolicyBlock blocks a write for up to
+	// StoreConfig.SlowWatcherBlockTimeout on the synced watchers with a full
+	// channel, slowing the writes down to the pace of the watchers. The
+	// watchers still full after it are canceled as with
+	// SlowWatcherPolicyCancel. The write blocks with its txn open and the
+	// watchable store locked, so meanwhile all the other writes, and the
+	// creation, cancellation and synchronization of the watchers, wait as
+	// well.
+	SlowWatcherPolicyBlock SlowWatcherPolicy = "block"
+)
+
+// ParseSlowWatcherPolicy returns the slow watcher policy named s.
+func ParseSlowWatcherPolicy(s string) (SlowWatcherPolicy, error) {
+	switch p := SlowWatcherPolicy(s); p {
+	case SlowWatcherPolicyNone, SlowWatcherPolicyCancel, SlowWatcherPolicyResync, SlowWatcherPolicyBlock:
+		return p, nil
+	}
+	return SlowWatcherPolicyNone, fmt.Errorf("unknown slow watcher policy %q", s)
+}
+
+// slowWatcherExceeded returns whether the slow watcher policy applies to a
+// slow watcher lagging lag revisions behind the store.
+func (s *watchableStore) slowWatcherExceeded(lag int64) bool {
+	return s.store.cfg.SlowWatcherPolicy != SlowWatcherPolicyNone && lag > s.store.cfg.SlowWatcherMaxLag
+}
+
+// sendBlocking sends wr to a watcher with a full channel under
+// SlowWatcherPolicyBlock, waiting until the deadline of the notification,
+// which is set on the first call. It's called by notify, holding s.mu and
+// with the write txn notifying the events still open: the events must be
+// sent in order and before the txn ends, so the wait deliberately holds
+// back the writes and the other watchers of the store.
+func (s *watchableStore) sendBlocking(w *watcher, wr WatchResponse, deadline *time.Time) bool {
+	if s.store.cfg.SlowWatcherPolicy != SlowWatcherPolicyBlock || s.store.cfg.SlowWatcherBlockTimeout <= 0 {
+		return false
+	}
+	start := time.Now()
+	if deadline.IsZero() {
+		*deadline = start.Add(s.store.cfg.SlowWatcherBlockTimeout)
+	}
+	timer := time.NewTimer(deadline.Sub(start))
+	defer timer.Stop()
+	sent := w.sendUntil(wr, timer.C)
+	slowWatcherBlockSec.Observe(time.Since(start).Seconds())
+	return sent
+}
+
+// victimsMaxLag returns the largest number of revisions the victims lag
+// behind curRev.
+func (s *watchableStore) victimsMaxLag(curRev int64) (maxLag int64) {
+	for _, wb := range s.victims {
+		for _, eb := range wb {
+			if len(eb.evs) != 0 {
+				maxLag = max(maxLag, curRev-eb.evs[0].Kv.ModRevision+1)
+			}
+		}
+	}
+	return maxLag
+}
+
+// applySlowWatcherPolicy applies the slow watcher policy to the unsynced slow
+// watchers lagging too far behind curRev, and reports the largest lag of the
+// slow watchers. The watchers whose channel is still full are retried on the
+// next sync.
+func (s *watchableStore) applySlowWatcherPolicy(curRev int64) {
+	maxLag := s.victimsMaxLag(curRev)
+	for w := range s.unsynced.watchers {
+		if !w.slow {
+			continue
+		}
+		lag := curRev - w.minRev + 1
+		maxLag = max(maxLag, lag)
+		if !s.slowWatcherExceeded(lag) {
+			continue
+		}
+		policy := s.store.cfg.SlowWatcherPolicy
+		if policy == SlowWatcherPolicyResync && !w.resync {
+			policy = SlowWatcherPolicyCancel
+		}
+		switch policy {
+		case SlowWatcherPolicyResync:
+			if !w.send(WatchResponse{WatchID: w.id, Revision: curRev, ResyncRevision: curRev}) {
+				continue
+			}
+			w.minRev = curRev + 1
+			w.slow = false
+			s.unsynced.delete(w)
+			s.synced.add(w)
+		default:
+			if !w.send(WatchResponse{WatchID: w.id, Revision: curRev, CompactRevision: curRev}) {
+				continue
+			}
+			w.compacted = true
+			s.unsynced.delete(w)
+		}
+		slowWatcherPolicyCounter.WithLabelValues(string(policy)).Inc()
+	}
+	slowWatcherMaxLagGauge.Set(float64(maxLag))
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"go.etcd.io/etcd/server/v3/lease"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+)
+
+func receiveWatchResponse(t *testing.T, ws WatchStream) WatchResponse {
+	select {
+	case wr := <-ws.Chan():
+		return wr
+	case <-time.After(5 * time.Second):
+		t.Fatal("failed to receive watch response")
+	}
+	return WatchResponse{}
+}
+
+func waitUnsynced(t *testing.T, s *watchableStore) {
+	require.Eventually(t, func() bool {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return s.unsynced.size() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSlowWatcherPolicy(t *testing.T) {
+	oldChanBufLen := chanBufLen
+	defer func() { chanBufLen = oldChanBufLen }()
+	chanBufLen = 1
+
+	tests := []struct {
+		name        string
+		policy      SlowWatcherPolicy
+		allowResync bool
+		wantResync  bool
+	}{
+		{name: "cancel", policy: SlowWatcherPolicyCancel},
+		{name: "cancel allowing resync", policy: SlowWatcherPolicyCancel, allowResync: true},
+		{name: "resync not allowed", policy: SlowWatcherPolicyResync},
+		{name: "resync", policy: SlowWatcherPolicyResync, allowResync: true, wantResync: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := betesting.NewDefaultTmpBackend(t)
+			s := newWatchableStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{SlowWatcherPolicy: tt.policy, SlowWatcherMaxLag: 5})
+			defer cleanup(s, b)
+
+			ws := s.NewWatchStream()
+			defer ws.Close()
+			id, err := ws.Watch(0, []byte("foo"), nil, 0)
+			require.NoError(t, err)
+			if tt.allowResync {
+				ws.AllowResync(id)
+			}
+
+			// the first put fills the channel, the next ones make the watcher
+			// lag more than the limit
+			var rev int64
+			for i := 0; i < 10; i++ {
+				rev = s.Put([]byte("foo"), []byte("bar"), lease.NoLease)
+			}
+			waitUnsynced(t, s)
+
+			wr := receiveWatchResponse(t, ws)
+			require.Len(t, wr.Events, 1)
+			assert.Equal(t, rev-9, wr.Events[0].Kv.ModRevision)
+
+			wr = receiveWatchResponse(t, ws)
+			assert.Empty(t, wr.Events)
+			if !tt.wantResync {
+				assert.Equal(t, rev, wr.CompactRevision)
+				return
+			}
+			assert.Equal(t, int64(0), wr.CompactRevision)
+			assert.Equal(t, rev, wr.ResyncRevision)
+
+			// the watcher keeps receiving the events after the resync
+			rev = s.Put([]byte("foo"), []byte("bar"), lease.NoLease)
+			wr = receiveWatchResponse(t, ws)
+			require.Len(t, wr.Events, 1)
+			assert.Equal(t, rev, wr.Events[0].Kv.ModRevision)
+		})
+	}
+}
+
+func TestSlowWatcherPolicyBlock(t *testing.T) {
+	oldChanBufLen := chanBufLen
+	defer func() { chanBufLen = oldChanBufLen }()
+	chanBufLen = 1
+
+	timeout := 100 * time.Millisecond
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	s := newWatchableStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{SlowWatcherPolicy: SlowWatcherPolicyBlock, SlowWatcherBlockTimeout: timeout})
+	defer cleanup(s, b)
+
+	ws := s.NewWatchStream()
+	defer ws.Close()
+	_, err := ws.Watch(0, []byte("foo"), nil, 0)
+	require.NoError(t, err)
+
+	// a put blocks until the watcher receives the previous event
+	rev := s.Put([]byte("foo"), []byte("bar"), lease.NoLease)
+	donec := make(chan WatchResponse)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		donec <- <-ws.Chan()
+	}()
+	s.Put([]byte("foo"), []byte("bar"), lease.NoLease)
+	wr := <-donec
+	require.Len(t, wr.Events, 1)
+	assert.Equal(t, rev, wr.Events[0].Kv.ModRevision)
+
+	// a put blocks for up to the timeout, after which the watcher is canceled
+	start := time.Now()
+	rev = s.Put([]byte("foo"), []byte("bar"), lease.NoLease)
+	assert.GreaterOrEqual(t, time.Since(start), timeout)
+	waitUnsynced(t, s)
+
+	wr = receiveWatchResponse(t, ws)
+	require.Len(t, wr.Events, 1)
+	assert.Equal(t, rev-1, wr.Events[0].Kv.ModRevision)
+	wr = receiveWatchResponse(t, ws)
+	assert.Equal(t, rev, wr.CompactRevision)
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/server/v3/storage/backend"
+)
+
+// ValueTransformer transforms key-value records on their way to and from the
+// backend, e.g. to encrypt them at rest. Records written before a transformer
+// was configured must remain readable by TransformFromStorage.
+type ValueTransformer interface {
+	// TransformToStorage returns the form of the marshaled key-value data
+	// to store in the backend.
+	TransformToStorage(data []byte) ([]byte, error)
+	// TransformFromStorage reverses TransformToStorage. The returned data may
+	// alias data, so callers must not retain it past the read transaction.
+	TransformFromStorage(data []byte) ([]byte, error)
+	// Restore reloads any state the transformer keeps in the backend after the
+	// store switched to b, e.g. when a snapshot is applied.
+	Restore(b backend.Backend) error
+}
+
+func (s *store) toStorage(d []byte) []byte {
+	vt := s.cfg.ValueTransformer
+	if vt == nil {
+		return d
+	}
+	td, err := vt.TransformToStorage(d)
+	if err != nil {
+		s.lg.Fatal("failed to transform mvccpb.KeyValue for storage", zap.Error(err))
+	}
+	return td
+}
+
+func (s *store) fromStorage(d []byte) []byte {
+	return transformFromStorage(s.lg, s.cfg.ValueTransformer, d)
+}
+
+func (s *store) fromStorageAll(vals [][]byte) [][]byte {
+	if s.cfg.ValueTransformer == nil {
+		return vals
+	}
+	tvals := make([][]byte, len(vals))
+	for i, v := range vals {
+		tvals[i] = s.fromStorage(v)
+	}
+	return tvals
+}
+
+func transformFromStorage(lg *zap.Logger, vt ValueTransformer, d []byte) []byte {
+	if vt == nil {
+		return d
+	}
+	td, err := vt.TransformFromStorage(d)
+	if err != nil {
+		lg.Fatal("failed to transform mvccpb.KeyValue from storage", zap.Error(err))
+	}
+	return td
+}
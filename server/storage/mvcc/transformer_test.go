@@ -0,0 +1,112 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"go.etcd.io/etcd/server/v3/lease"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+)
+
+var xorTransformerPrefix = []byte("xor:")
+
+// xorTransformer obfuscates records like an encrypting transformer would,
+// and passes through records it did not write.
+type xorTransformer struct{}
+
+func (xorTransformer) TransformToStorage(data []byte) ([]byte, error) {
+	out := append([]byte{}, xorTransformerPrefix...)
+	for _, b := range data {
+		out = append(out, b^0xff)
+	}
+	return out, nil
+}
+
+func (xorTransformer) TransformFromStorage(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, xorTransformerPrefix) {
+		return data, nil
+	}
+	out := make([]byte, 0, len(data)-len(xorTransformerPrefix))
+	for _, b := range data[len(xorTransformerPrefix):] {
+		out = append(out, b^0xff)
+	}
+	return out, nil
+}
+
+func (xorTransformer) Restore(backend.Backend) error { return nil }
+
+func TestStoreValueTransformer(t *testing.T) {
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	plain := NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+	plain.Put([]byte("foo"), []byte("bar0"), lease.NoLease)
+	plain.Close()
+
+	s := NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{ValueTransformer: xorTransformer{}})
+	defer b.Close()
+	defer s.Close()
+	s.Put([]byte("foo"), []byte("bar1"), lease.NoLease)
+	s.Put([]byte("baz"), []byte("qux"), lease.NoLease)
+	s.DeleteRange([]byte("baz"), nil)
+
+	// records are transformed in the backend
+	s.b.ForceCommit()
+	tx := b.ReadTx()
+	tx.RLock()
+	var transformed int
+	err := tx.UnsafeForEach(schema.Key, func(_, v []byte) error {
+		if bytes.HasPrefix(v, xorTransformerPrefix) {
+			transformed++
+		}
+		return nil
+	})
+	tx.RUnlock()
+	require.NoError(t, err)
+	assert.Equal(t, 3, transformed)
+
+	// both plain and transformed records are readable
+	r, err := s.Range(context.TODO(), []byte("foo"), nil, RangeOptions{Rev: 2})
+	require.NoError(t, err)
+	require.Len(t, r.KVs, 1)
+	assert.Equal(t, "bar0", string(r.KVs[0].Value))
+	r, err = s.Range(context.TODO(), []byte("foo"), nil, RangeOptions{})
+	require.NoError(t, err)
+	require.Len(t, r.KVs, 1)
+	assert.Equal(t, "bar1", string(r.KVs[0].Value))
+
+	// the hash is computed over plain records
+	h, _, err := s.HashStorage().HashByRev(0)
+	require.NoError(t, err)
+
+	b2, _ := betesting.NewDefaultTmpBackend(t)
+	defer b2.Close()
+	s2 := NewStore(zaptest.NewLogger(t), b2, &lease.FakeLessor{}, StoreConfig{})
+	defer s2.Close()
+	s2.Put([]byte("foo"), []byte("bar0"), lease.NoLease)
+	s2.Put([]byte("foo"), []byte("bar1"), lease.NoLease)
+	s2.Put([]byte("baz"), []byte("qux"), lease.NoLease)
+	s2.DeleteRange([]byte("baz"), nil)
+	h2, _, err := s2.HashStorage().HashByRev(0)
+	require.NoError(t, err)
+	assert.Equal(t, h2.Hash, h.Hash)
+}
@@ -45,6 +45,7 @@ type watchable interface {
 	watch(key, end []byte, startRev int64, id WatchID, ch chan<- WatchResponse, fcs ...FilterFunc) (*watcher, cancelFunc)
 	progress(w *watcher)
 	progressAll(watchers map[WatchID]*watcher) bool
+	allowResync(w *watcher)
 	rev() int64
 }
 
@@ -223,6 +224,9 @@ func (s *watchableStore) syncWatchersLoop() {
 		s.mu.RLock()
 		st := time.Now()
 		lastUnsyncedWatchers := s.unsynced.size()
+		if lastUnsyncedWatchers == 0 {
+			slowWatcherMaxLagGauge.Set(float64(s.victimsMaxLag(s.store.Rev())))
+		}
 		s.mu.RUnlock()
 
 		unsyncedWatchers := 0
@@ -281,13 +285,23 @@ func (s *watchableStore) moveVictims() (moved int) {
 	s.mu.Unlock()
 
 	var newVictim watcherBatch
+	var dropped map[*watcher]struct{}
 	for _, wb := range victims {
 		// try to send responses again
+		curRev := s.store.Rev()
 		for w, eb := range wb {
 			// watcher has observed the store up to, but not including, w.minRev
 			rev := w.minRev - 1
 			if w.send(WatchResponse{WatchID: w.id, Events: eb.evs, Revision: rev}) {
 				pendingEventsGauge.Add(float64(len(eb.evs)))
+			} else if firstRev := eb.evs[0].Kv.ModRevision; s.slowWatcherExceeded(curRev - firstRev + 1) {
+				// drop the batch; the slow watcher policy applies once unsynced
+				if dropped == nil {
+					dropped = make(map[*watcher]struct{})
+				}
+				dropped[w] = struct{}{}
+				w.minRev = firstRev
+				continue
 			} else {
 				if newVictim == nil {
 					newVictim = make(watcherBatch)
@@ -301,13 +315,17 @@ func (s *watchableStore) moveVictims() (moved int) {
 		// assign completed victim watchers to unsync/sync
 		s.mu.Lock()
 		s.store.revMu.RLock()
-		curRev := s.store.currentRev
+		curRev = s.store.currentRev
 		for w, eb := range wb {
 			if newVictim != nil && newVictim[w] != nil {
 				// couldn't send watch response; stays victim
 				continue
 			}
 			w.victim = false
+			if _, ok := dropped[w]; ok {
+				s.unsynced.add(w)
+				continue
+			}
 			if eb.moreRev != 0 {
 				w.minRev = eb.moreRev
 			}
@@ -315,6 +333,7 @@ func (s *watchableStore) moveVictims() (moved int) {
 				s.unsynced.add(w)
 			} else {
 				slowWatcherGauge.Dec()
+				w.slow = false
 				s.synced.add(w)
 			}
 		}
@@ -353,6 +372,11 @@ func (s *watchableStore) syncWatchers() int {
 	curRev := s.store.currentRev
 	compactionRev := s.store.compactMainRev
 
+	s.applySlowWatcherPolicy(curRev)
+	if s.unsynced.size() == 0 {
+		return 0
+	}
+
 	wg, minRev := s.unsynced.choose(maxWatchersPerSync, curRev, compactionRev)
 	minBytes, maxBytes := NewRevBytes(), NewRevBytes()
 	minBytes = RevToBytes(Revision{Main: minRev}, minBytes)
@@ -363,7 +387,7 @@ func (s *watchableStore) syncWatchers() int {
 	tx := s.store.b.ReadTx()
 	tx.RLock()
 	revs, vs := tx.UnsafeRange(schema.Key, minBytes, maxBytes, 0)
-	evs := kvsToEvents(s.store.lg, wg, revs, vs)
+	evs := kvsToEvents(s.store.lg, wg, s.store.kvindex, revs, s.store.fromStorageAll(vs))
 	// Must unlock after kvsToEvents, because vs (come from boltdb memory) is not deep copy.
 	// We can only unlock after Unmarshal, which will do deep copy.
 	// Otherwise we will trigger SIGSEGV during boltdb re-mmap.
@@ -382,6 +406,7 @@ func (s *watchableStore) syncWatchers() int {
 		eb, ok := wb[w]
 		if !ok {
 			// bring un-notified watcher to synced
+			w.slow = false
 			s.synced.add(w)
 			s.unsynced.delete(w)
 			continue
@@ -395,6 +420,7 @@ func (s *watchableStore) syncWatchers() int {
 			pendingEventsGauge.Add(float64(len(eb.evs)))
 		} else {
 			w.victim = true
+			w.slow = true
 		}
 
 		if w.victim {
@@ -404,6 +430,7 @@ func (s *watchableStore) syncWatchers() int {
 				// stay unsynced; more to read
 				continue
 			}
+			w.slow = false
 			s.synced.add(w)
 		}
 		s.unsynced.delete(w)
@@ -420,13 +447,18 @@ func (s *watchableStore) syncWatchers() int {
 }
 
 // kvsToEvents gets all events for the watchers from all key-value pairs
-func kvsToEvents(lg *zap.Logger, wg *watcherGroup, revs, vals [][]byte) (evs []mvccpb.Event) {
+func kvsToEvents(lg *zap.Logger, wg *watcherGroup, idx index, revs, vals [][]byte) (evs []mvccpb.Event) {
 	for i, v := range vals {
 		var kv mvccpb.KeyValue
 		if err := kv.Unmarshal(v); err != nil {
 			lg.Panic("failed to unmarshal mvccpb.KeyValue", zap.Error(err))
 		}
 
+		if isRangeTombstone(revs[i]) {
+			evs = append(evs, rangeTombstoneEvents(wg, idx, &kv, BytesToRev(revs[i]))...)
+			continue
+		}
+
 		if !wg.contains(string(kv.Key)) {
 			continue
 		}
@@ -446,6 +478,7 @@ func kvsToEvents(lg *zap.Logger, wg *watcherGroup, revs, vals [][]byte) (evs []m
 // watchers that watch on the key of the event.
 func (s *watchableStore) notify(rev int64, evs []mvccpb.Event) {
 	victim := make(watcherBatch)
+	var deadline time.Time
 	for w, eb := range newWatcherBatch(&s.synced, evs) {
 		if eb.revs != 1 {
 			s.store.lg.Panic(
@@ -453,11 +486,13 @@ func (s *watchableStore) notify(rev int64, evs []mvccpb.Event) {
 				zap.Int("number-of-revisions", eb.revs),
 			)
 		}
-		if w.send(WatchResponse{WatchID: w.id, Events: eb.evs, Revision: rev}) {
+		wr := WatchResponse{WatchID: w.id, Events: eb.evs, Revision: rev}
+		if w.send(wr) || s.sendBlocking(w, wr, &deadline) {
 			pendingEventsGauge.Add(float64(len(eb.evs)))
 		} else {
 			// move slow watcher to victims
 			w.victim = true
+			w.slow = true
 			victim[w] = eb
 			s.synced.delete(w)
 			slowWatcherGauge.Inc()
@@ -491,6 +526,12 @@ func (s *watchableStore) progressAll(watchers map[WatchID]*watcher) bool {
 	return s.progressIfSync(watchers, clientv3.InvalidWatchID)
 }
 
+func (s *watchableStore) allowResync(w *watcher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.resync = true
+}
+
 func (s *watchableStore) progressIfSync(watchers map[WatchID]*watcher, responseWatchID WatchID) bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -524,9 +565,17 @@ type watcher struct {
 	// victim is set when ch is blocked and undergoing victim processing
 	victim bool
 
+	// slow is set when ch was found blocked, until the watcher is synced
+	// again; the slow watcher policy applies to the slow watchers
+	slow bool
+
 	// compacted is set when the watcher is removed because of compaction
 	compacted bool
 
+	// resync is set if the slow watcher policy may resynchronize the watcher
+	// instead of canceling it
+	resync bool
+
 	// restore is true when the watcher is being restored from leader snapshot
 	// which means that this watcher has just been moved from "synced" to "unsynced"
 	// watcher group, possibly with a future revision when it was first added
@@ -546,6 +595,12 @@ type watcher struct {
 }
 
 func (w *watcher) send(wr WatchResponse) bool {
+	return w.sendUntil(wr, nil)
+}
+
+// sendUntil sends wr, blocking until timeout fires if ch is full. A nil
+// timeout does not block.
+func (w *watcher) sendUntil(wr WatchResponse, timeout <-chan time.Time) bool {
 	progressEvent := len(wr.Events) == 0
 
 	if len(w.fcs) != 0 {
@@ -573,6 +628,14 @@ func (w *watcher) send(wr WatchResponse) bool {
 	case w.ch <- wr:
 		return true
 	default:
+		if timeout == nil {
+			return false
+		}
+	}
+	select {
+	case w.ch <- wr:
+		return true
+	case <-timeout:
 		return false
 	}
 }
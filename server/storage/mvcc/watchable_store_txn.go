@@ -27,20 +27,25 @@ func (tw *watchableStoreTxnWrite) End() {
 	}
 
 	rev := tw.Rev() + 1
-	evs := make([]mvccpb.Event, len(changes))
-	for i, change := range changes {
-		evs[i].Kv = &changes[i]
-		if change.CreateRevision == 0 {
-			evs[i].Type = mvccpb.DELETE
-			evs[i].Kv.ModRevision = rev
-		} else {
-			evs[i].Type = mvccpb.PUT
-		}
-	}
 
 	// end write txn under watchable store lock so the updates are visible
 	// when asynchronous event posting checks the current store revision
 	tw.s.mu.Lock()
+	evs := make([]mvccpb.Event, 0, len(changes))
+	for i := range changes {
+		change := &changes[i]
+		switch {
+		case isRangeTombstoneChange(change):
+			// only the deletions of the keys watched by the synced watchers
+			// are notified, the other watchers get them from the backend
+			evs = append(evs, rangeTombstoneEvents(&tw.s.synced, tw.s.store.kvindex, change, Revision{Main: rev, Sub: int64(i)})...)
+		case change.CreateRevision == 0:
+			change.ModRevision = rev
+			evs = append(evs, mvccpb.Event{Type: mvccpb.DELETE, Kv: change})
+		default:
+			evs = append(evs, mvccpb.Event{Type: mvccpb.PUT, Kv: change})
+		}
+	}
 	tw.s.notify(rev, evs)
 	tw.TxnWrite.End()
 	tw.s.mu.Unlock()
@@ -58,6 +58,11 @@ type WatchStream interface {
 	// of the watchers since the watcher is currently synced.
 	RequestProgress(id WatchID)
 
+	// AllowResync lets the slow watcher policy resynchronize the watcher with
+	// given ID instead of canceling it. Only the watchers whose client
+	// handles the responses with ResyncRevision set may be resynchronized.
+	AllowResync(id WatchID)
+
 	// RequestProgressAll requests a progress notification for all
 	// watchers sharing the stream.  If all watchers are synced, a
 	// progress notification with watch ID -1 will be sent to an
@@ -92,6 +97,11 @@ type WatchResponse struct {
 
 	// CompactRevision is set when the watcher is cancelled due to compaction.
 	CompactRevision int64
+
+	// ResyncRevision is set when the events a slow watcher missed were
+	// dropped: the watcher needs to resynchronize from this revision, from
+	// which it keeps watching.
+	ResyncRevision int64
 }
 
 // watchStream contains a collection of watchers that share
@@ -196,6 +206,16 @@ func (ws *watchStream) RequestProgress(id WatchID) {
 	ws.watchable.progress(w)
 }
 
+func (ws *watchStream) AllowResync(id WatchID) {
+	ws.mu.Lock()
+	w, ok := ws.watchers[id]
+	ws.mu.Unlock()
+	if !ok {
+		return
+	}
+	ws.watchable.allowResync(w)
+}
+
 func (ws *watchStream) RequestProgressAll() bool {
 	ws.mu.Lock()
 	defer ws.mu.Unlock()
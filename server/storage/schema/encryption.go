@@ -0,0 +1,60 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"go.etcd.io/etcd/server/v3/storage/backend"
+)
+
+var (
+	encryptionCurrentKeyName = []byte("current")
+	encryptionKeyPrefix      = []byte("dek/")
+)
+
+func UnsafeCreateEncryptionBucket(tx backend.UnsafeWriter) {
+	tx.UnsafeCreateBucket(Encryption)
+}
+
+// UnsafeReadEncryptionKeys returns the ID of the current data encryption key
+// and all wrapped data encryption keys by ID.
+func UnsafeReadEncryptionKeys(tx backend.UnsafeReader) (current uint64, keys map[uint64][]byte, err error) {
+	keys = make(map[uint64][]byte)
+	err = tx.UnsafeForEach(Encryption, func(k, v []byte) error {
+		switch {
+		case bytes.Equal(k, encryptionCurrentKeyName):
+			current = binary.BigEndian.Uint64(v)
+		case bytes.HasPrefix(k, encryptionKeyPrefix):
+			keys[binary.BigEndian.Uint64(k[len(encryptionKeyPrefix):])] = bytes.Clone(v)
+		}
+		return nil
+	})
+	return current, keys, err
+}
+
+// UnsafeSaveEncryptionKey stores the wrapped data encryption key and makes it
+// the current one.
+func UnsafeSaveEncryptionKey(tx backend.UnsafeWriter, id uint64, wrapped []byte) {
+	k := make([]byte, len(encryptionKeyPrefix)+8)
+	copy(k, encryptionKeyPrefix)
+	binary.BigEndian.PutUint64(k[len(encryptionKeyPrefix):], id)
+	tx.UnsafePut(Encryption, k, wrapped)
+
+	v := make([]byte, 8)
+	binary.BigEndian.PutUint64(v, id)
+	tx.UnsafePut(Encryption, encryptionCurrentKeyName, v)
+}
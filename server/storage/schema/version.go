@@ -15,6 +15,8 @@
 package schema
 
 import (
+	"bytes"
+
 	"github.com/coreos/go-semver/semver"
 
 	"go.etcd.io/bbolt"
@@ -54,6 +56,25 @@ func ReadStorageVersionFromSnapshot(tx *bbolt.Tx) *semver.Version {
 	return version
 }
 
+// ReadStorageVersionFromEngine loads storage version from given storage
+// engine transaction.
+// Populated since v3.6
+func ReadStorageVersionFromEngine(tx backend.EngineTx) *semver.Version {
+	b := tx.Bucket(Meta.Name())
+	if b == nil {
+		return nil
+	}
+	k, v := b.Cursor().Seek(MetaStorageVersionName)
+	if !bytes.Equal(k, MetaStorageVersionName) {
+		return nil
+	}
+	version, err := semver.NewVersion(string(v))
+	if err != nil {
+		return nil
+	}
+	return version
+}
+
 // UnsafeSetStorageVersion updates etcd storage version in backend.
 // Populated since v3.6
 func UnsafeSetStorageVersion(tx backend.UnsafeWriter, v *semver.Version) {
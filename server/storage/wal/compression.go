@@ -0,0 +1,96 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/golang/snappy"
+
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+// CompressionType is the codec used to compress entry records.
+type CompressionType string
+
+const (
+	// CompressionNone writes entry records uncompressed.
+	CompressionNone CompressionType = "none"
+	// CompressionSnappy compresses entry records with snappy.
+	CompressionSnappy CompressionType = "snappy"
+)
+
+// compressMinBytes is the size below which entries are not worth compressing.
+const compressMinBytes = 512
+
+// Codec identifiers, stored as the first byte of a compressed entry record.
+const (
+	codecSnappy byte = 1
+)
+
+var ErrUnknownCodec = errors.New("wal: unknown compression codec")
+
+// ParseCompressionType parses a compression type as given on the command line.
+func ParseCompressionType(s string) (CompressionType, error) {
+	switch CompressionType(s) {
+	case "", CompressionNone:
+		return CompressionNone, nil
+	case CompressionSnappy:
+		return CompressionSnappy, nil
+	default:
+		return "", fmt.Errorf("unknown WAL compression %q", s)
+	}
+}
+
+// compressEntry returns the compressed form of the marshaled entry b, or false
+// if compressing it does not save space.
+func compressEntry(ct CompressionType, b []byte) ([]byte, bool) {
+	if ct != CompressionSnappy || len(b) < compressMinBytes {
+		return nil, false
+	}
+	cb := make([]byte, 1+snappy.MaxEncodedLen(len(b)))
+	cb[0] = codecSnappy
+	cb = cb[:1+len(snappy.Encode(cb[1:], b))]
+	if len(cb) >= len(b) {
+		return nil, false
+	}
+	return cb, true
+}
+
+// decompressEntry returns the marshaled entry held by the data of a
+// compressed entry record.
+func decompressEntry(d []byte) ([]byte, error) {
+	if len(d) == 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	switch d[0] {
+	case codecSnappy:
+		return snappy.Decode(nil, d[1:])
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnknownCodec, d[0])
+	}
+}
+
+// MustUnmarshalCompressedEntry decompresses and unmarshals the data of a
+// compressed entry record.
+func MustUnmarshalCompressedEntry(d []byte) raftpb.Entry {
+	b, err := decompressEntry(d)
+	if err != nil {
+		panic(err)
+	}
+	return MustUnmarshalEntry(b)
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wal
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/version"
+	"go.etcd.io/etcd/pkg/v3/pbutil"
+	"go.etcd.io/etcd/server/v3/storage/wal/walpb"
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+func TestCompressEntry(t *testing.T) {
+	large := bytes.Repeat([]byte("foo"), compressMinBytes)
+
+	_, ok := compressEntry(CompressionSnappy, []byte("small"))
+	assert.False(t, ok, "small entries should not be compressed")
+	_, ok = compressEntry(CompressionNone, large)
+	assert.False(t, ok, "entries should not be compressed without a codec")
+
+	cb, ok := compressEntry(CompressionSnappy, large)
+	require.True(t, ok)
+	assert.Less(t, len(cb), len(large))
+	b, err := decompressEntry(cb)
+	require.NoError(t, err)
+	assert.Equal(t, large, b)
+
+	_, err = decompressEntry([]byte{0xff, 1, 2})
+	assert.True(t, errors.Is(err, ErrUnknownCodec))
+}
+
+func TestSaveCompressedEntries(t *testing.T) {
+	p := t.TempDir()
+
+	w, err := Create(zaptest.NewLogger(t), p, []byte("metadata"))
+	require.NoError(t, err)
+	w.SetCompression(CompressionSnappy)
+	put := &etcdserverpb.InternalRaftRequest{Put: &etcdserverpb.PutRequest{
+		Key:   []byte("foo"),
+		Value: bytes.Repeat([]byte("bar"), compressMinBytes),
+	}}
+	ents := []raftpb.Entry{
+		{Index: 1, Term: 1},
+		{Index: 2, Term: 1, Data: pbutil.MustMarshal(put)},
+	}
+	require.NoError(t, w.Save(raftpb.HardState{Term: 1, Commit: 2}, ents))
+	w.Close()
+
+	// entries are decoded regardless of the compression of the WAL
+	w, err = Open(zaptest.NewLogger(t), p, walpb.Snapshot{})
+	require.NoError(t, err)
+	defer w.Close()
+	wv, err := ReadWALVersion(w)
+	require.NoError(t, err)
+	assert.Equal(t, ents, wv.entries)
+	assert.Equal(t, version.V3_6, *wv.MinimalEtcdVersion())
+}
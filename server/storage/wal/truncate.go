@@ -0,0 +1,203 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/client/pkg/v3/fileutil"
+	"go.etcd.io/etcd/pkg/v3/pbutil"
+	"go.etcd.io/etcd/server/v3/storage/wal/walpb"
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+// recordPos is the position following a record in the WAL files.
+type recordPos struct {
+	file   int
+	offset int64
+	crc    uint32
+}
+
+// Truncate removes the entries after the given index from the WAL in the
+// given directory, along with every record following the last record of the
+// entry at that index, including a corrupted tail. The latest hard state is
+// kept, with its commit index lowered to the given index if needed.
+//
+// The removed records are kept in ".broken" backup files, like Repair does.
+// The WAL must not be in use, and the removed entries must be available from
+// the other members of the cluster if they were committed.
+func Truncate(lg *zap.Logger, dirpath string, index uint64) error {
+	if lg == nil {
+		lg = zap.NewNop()
+	}
+	names, err := readWALNames(lg, dirpath)
+	if err != nil {
+		return err
+	}
+
+	files := make([]*fileutil.LockedFile, len(names))
+	defer func() {
+		for _, f := range files {
+			if f != nil {
+				f.Close()
+			}
+		}
+	}()
+	for i, name := range names {
+		if files[i], err = fileutil.TryLockFile(filepath.Join(dirpath, name), os.O_RDWR, fileutil.PrivateFileMode); err != nil {
+			return fmt.Errorf("failed to lock %q: %w", name, err)
+		}
+	}
+
+	var (
+		cut       recordPos
+		found     bool
+		corrupted bool
+		lastIndex uint64
+		state     raftpb.HardState
+		cutState  bool
+		crc       uint32
+	)
+	for i, f := range files {
+		decoder := NewDecoder(fileutil.NewFileReader(f.File))
+		decoder.UpdateCRC(crc)
+		var derr error
+		for {
+			rec := &walpb.Record{}
+			if derr = decoder.Decode(rec); derr != nil {
+				break
+			}
+			switch rec.Type {
+			case EntryType, CompressedEntryType:
+				var e raftpb.Entry
+				if rec.Type == CompressedEntryType {
+					e = MustUnmarshalCompressedEntry(rec.Data)
+				} else {
+					e = MustUnmarshalEntry(rec.Data)
+				}
+				lastIndex = e.Index
+				if e.Index == index {
+					cut, found, cutState = recordPos{file: i, offset: decoder.LastOffset(), crc: decoder.LastCRC()}, true, false
+				}
+			case StateType:
+				state = MustUnmarshalState(rec.Data)
+				cutState = found
+			case CrcType:
+				if c := decoder.LastCRC(); c != 0 && rec.Validate(c) != nil {
+					derr = ErrCRCMismatch
+				} else {
+					decoder.UpdateCRC(rec.Crc)
+				}
+			case SnapshotType:
+				var snap walpb.Snapshot
+				pbutil.MustUnmarshal(&snap, rec.Data)
+				if snap.Index > index {
+					return fmt.Errorf("cannot truncate WAL to index %d below the snapshot at index %d", index, snap.Index)
+				}
+			}
+			if derr != nil {
+				break
+			}
+		}
+		crc = decoder.LastCRC()
+		if !errors.Is(derr, io.EOF) {
+			lg.Warn("stopped reading WAL at corrupted record", zap.String("path", names[i]), zap.Error(derr))
+			corrupted = true
+			break
+		}
+	}
+
+	if !found || index > lastIndex {
+		return fmt.Errorf("entry at index %d not found in WAL (last index %d)", index, lastIndex)
+	}
+	if index == lastIndex && !corrupted {
+		lg.Info("nothing to truncate in WAL", zap.Uint64("index", index))
+		return nil
+	}
+
+	// back up and remove the files after the one of the cut
+	for i := len(files) - 1; i > cut.file; i-- {
+		path := files[i].Name()
+		if err = os.Rename(path, path+".broken"); err != nil {
+			return err
+		}
+		lg.Info("removed WAL file", zap.String("path", path), zap.String("backup", path+".broken"))
+	}
+
+	f := files[cut.file]
+	if err = backupFile(f.File, f.Name()+".broken"); err != nil {
+		return err
+	}
+	if err = f.Truncate(cut.offset); err != nil {
+		return err
+	}
+	if _, err = f.Seek(cut.offset, io.SeekStart); err != nil {
+		return err
+	}
+	if cutState {
+		if state.Commit > index {
+			state.Commit = index
+		}
+		enc, eerr := newFileEncoder(f.File, cut.crc)
+		if eerr != nil {
+			return eerr
+		}
+		if err = enc.encode(&walpb.Record{Type: StateType, Data: pbutil.MustMarshal(&state)}); err != nil {
+			return err
+		}
+		if err = enc.flush(); err != nil {
+			return err
+		}
+	}
+	if err = fileutil.Fsync(f.File); err != nil {
+		return err
+	}
+	dir, err := fileutil.OpenDir(dirpath)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	if err = fileutil.Fsync(dir); err != nil {
+		return err
+	}
+
+	lg.Info(
+		"truncated WAL",
+		zap.String("path", f.Name()),
+		zap.Uint64("index", index),
+		zap.Uint64("removed-last-index", lastIndex),
+		zap.Uint64("commit-index", state.Commit),
+	)
+	return nil
+}
+
+func backupFile(f *os.File, path string) error {
+	bf, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer bf.Close()
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = io.Copy(bf, f)
+	return err
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wal
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"go.etcd.io/etcd/server/v3/storage/wal/walpb"
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+// createTruncateTestWAL creates a WAL with entries 1 to 10 in term 1 and,
+// overwriting the uncommitted ones, entries 8 and 9 in term 2. It returns
+// the size of the WAL.
+func createTruncateTestWAL(t *testing.T, p string) int64 {
+	w, err := Create(zaptest.NewLogger(t), p, []byte("metadata"))
+	require.NoError(t, err)
+	for i := uint64(1); i <= 10; i++ {
+		require.NoError(t, w.Save(raftpb.HardState{Term: 1, Vote: 1, Commit: max(i, 3) - 3}, []raftpb.Entry{{Term: 1, Index: i}}))
+	}
+	require.NoError(t, w.Save(raftpb.HardState{Term: 2, Vote: 2, Commit: 9}, []raftpb.Entry{{Term: 2, Index: 8}, {Term: 2, Index: 9}}))
+	offset, err := w.tail().Seek(0, io.SeekCurrent)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return offset
+}
+
+func readAllEntries(t *testing.T, p string) (raftpb.HardState, []raftpb.Entry) {
+	w, err := Open(zaptest.NewLogger(t), p, walpb.Snapshot{})
+	require.NoError(t, err)
+	defer w.Close()
+	metadata, state, ents, err := w.ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("metadata"), metadata)
+	return state, ents
+}
+
+func TestTruncate(t *testing.T) {
+	lg := zaptest.NewLogger(t)
+	p := t.TempDir()
+	createTruncateTestWAL(t, p)
+
+	require.ErrorContains(t, Truncate(lg, p, 10), "not found")
+	require.NoError(t, Truncate(lg, p, 9))
+
+	require.NoError(t, Truncate(lg, p, 8))
+	matches, err := filepath.Glob(filepath.Join(p, "*.wal.broken"))
+	require.NoError(t, err)
+	assert.Len(t, matches, 1)
+
+	state, ents := readAllEntries(t, p)
+	assert.Equal(t, raftpb.HardState{Term: 2, Vote: 2, Commit: 8}, state)
+	require.Len(t, ents, 8)
+	assert.Equal(t, raftpb.Entry{Term: 2, Index: 8}, ents[7])
+
+	// the truncated WAL can be appended to
+	w, err := Open(lg, p, walpb.Snapshot{})
+	require.NoError(t, err)
+	_, _, _, err = w.ReadAll()
+	require.NoError(t, err)
+	require.NoError(t, w.Save(raftpb.HardState{Term: 2, Vote: 2, Commit: 9}, []raftpb.Entry{{Term: 2, Index: 9}}))
+	require.NoError(t, w.Close())
+	state, ents = readAllEntries(t, p)
+	assert.Equal(t, raftpb.HardState{Term: 2, Vote: 2, Commit: 9}, state)
+	assert.Len(t, ents, 9)
+}
+
+func TestTruncateCorruptedTail(t *testing.T) {
+	lg := zaptest.NewLogger(t)
+	p := t.TempDir()
+	offset := createTruncateTestWAL(t, p)
+
+	// tear the last hard state record
+	f, err := openLast(lg, p)
+	require.NoError(t, err)
+	require.NoError(t, f.Truncate(offset-4))
+	require.NoError(t, f.Close())
+
+	require.NoError(t, Truncate(lg, p, 9))
+
+	state, ents := readAllEntries(t, p)
+	assert.Equal(t, raftpb.HardState{Term: 1, Vote: 1, Commit: 7}, state)
+	require.Len(t, ents, 9)
+	assert.Equal(t, raftpb.Entry{Term: 2, Index: 9}, ents[8])
+}
+
+func TestTruncateLocked(t *testing.T) {
+	lg := zaptest.NewLogger(t)
+	p := t.TempDir()
+
+	w, err := Create(lg, p, nil)
+	require.NoError(t, err)
+	defer w.Close()
+	require.NoError(t, w.Save(raftpb.HardState{Term: 1}, []raftpb.Entry{{Term: 1, Index: 1}, {Term: 1, Index: 2}}))
+
+	require.ErrorContains(t, Truncate(lg, p, 1), "failed to lock")
+	_, err = os.Stat(filepath.Join(p, walName(0, 0)+".broken"))
+	require.True(t, os.IsNotExist(err))
+}
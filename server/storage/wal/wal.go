@@ -41,6 +41,9 @@ const (
 	StateType
 	CrcType
 	SnapshotType
+	// CompressedEntryType records hold an entry compressed with the codec
+	// identified by their first byte. They are only written by etcd >= 3.6.
+	CompressedEntryType
 
 	// warnSyncDuration is the amount of time allotted to an fsync before
 	// logging a warning
@@ -86,6 +89,10 @@ type WAL struct {
 
 	unsafeNoSync bool // if set, do not fsync
 
+	compression CompressionType // codec used to compress entry records
+	// compressedEntries is set if ReadAll read a compressed entry record.
+	compressedEntries bool
+
 	mu      sync.Mutex
 	enti    uint64   // index of the last entry saved to the wal
 	encoder *encoder // encoder to encode records
@@ -245,6 +252,14 @@ func (w *WAL) SetUnsafeNoFsync() {
 	w.unsafeNoSync = true
 }
 
+// SetCompression sets the codec used to compress entry records saved from
+// now on. Entry records are read back regardless of their compression.
+func (w *WAL) SetCompression(ct CompressionType) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.compression = ct
+}
+
 func (w *WAL) cleanupWAL(lg *zap.Logger) {
 	var err error
 	if err = w.Close(); err != nil {
@@ -454,8 +469,14 @@ func (w *WAL) ReadAll() (metadata []byte, state raftpb.HardState, ents []raftpb.
 	var match bool
 	for err = decoder.Decode(rec); err == nil; err = decoder.Decode(rec) {
 		switch rec.Type {
-		case EntryType:
-			e := MustUnmarshalEntry(rec.Data)
+		case EntryType, CompressedEntryType:
+			var e raftpb.Entry
+			if rec.Type == CompressedEntryType {
+				e = MustUnmarshalCompressedEntry(rec.Data)
+				w.compressedEntries = true
+			} else {
+				e = MustUnmarshalEntry(rec.Data)
+			}
 			// 0 <= e.Index-w.start.Index - 1 < len(ents)
 			if e.Index > w.start.Index {
 				// prevent "panic: runtime error: slice bounds out of range [:13038096702221461992] with capacity 0"
@@ -689,7 +710,7 @@ func Verify(lg *zap.Logger, walDir string, snap walpb.Snapshot) (*raftpb.HardSta
 			}
 		// We ignore all entry and state type records as these
 		// are not necessary for validating the WAL contents
-		case EntryType:
+		case EntryType, CompressedEntryType:
 		case StateType:
 			pbutil.MustUnmarshal(&state, rec.Data)
 		default:
@@ -906,6 +927,9 @@ func (w *WAL) saveEntry(e *raftpb.Entry) error {
 	// TODO: add MustMarshalTo to reduce one allocation.
 	b := pbutil.MustMarshal(e)
 	rec := &walpb.Record{Type: EntryType, Data: b}
+	if cb, ok := compressEntry(w.compression, b); ok {
+		rec = &walpb.Record{Type: CompressedEntryType, Data: cb}
+	}
 	if err := w.encoder.encode(rec); err != nil {
 		return err
 	}
@@ -0,0 +1,73 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.etcd.io/etcd/etcdutl/v3/etcdutl"
+	"go.etcd.io/etcd/server/v3/storage/datadir"
+	"go.etcd.io/etcd/tests/v3/framework/config"
+	"go.etcd.io/etcd/tests/v3/framework/e2e"
+)
+
+// TestEtcdutlDBHashKV ensures the hash computed from the db file of a stopped
+// member is the one the member returned, and leaves the file unmodified.
+func TestEtcdutlDBHashKV(t *testing.T) {
+	e2e.BeforeTest(t)
+	ctx := context.Background()
+
+	epc, err := e2e.NewEtcdProcessCluster(ctx, t, e2e.WithClusterSize(1), e2e.WithKeepDataDir(true))
+	require.NoError(t, err)
+	defer epc.Close()
+
+	cc := epc.Etcdctl()
+	for i := 0; i < 10; i++ {
+		require.NoError(t, cc.Put(ctx, fmt.Sprintf("foo%d", i), "bar", config.PutOptions{}))
+	}
+	_, err = cc.Compact(ctx, 5, config.CompactOption{Physical: true})
+	require.NoError(t, err)
+	hashes, err := cc.HashKV(ctx, 8)
+	require.NoError(t, err)
+	require.Len(t, hashes, 1)
+	require.NoError(t, epc.Stop())
+
+	dbPath := datadir.ToBackendFileName(epc.Procs[0].Config().DataDirPath)
+	before, err := os.ReadFile(dbPath)
+	require.NoError(t, err)
+
+	proc, err := e2e.SpawnCmd([]string{e2e.BinPath.Etcdutl, "--write-out", "json", "db", "hashkv", "--rev", "8", dbPath}, nil)
+	require.NoError(t, err)
+	txt, err := proc.Expect("hashRevision")
+	require.NoError(t, err)
+	require.NoError(t, proc.Close())
+
+	var hkv etcdutl.HashKV
+	require.NoError(t, json.Unmarshal([]byte(txt), &hkv))
+	assert.Equal(t, hashes[0].Hash, hkv.Hash)
+	assert.Equal(t, hashes[0].HashRevision, hkv.HashRevision)
+	assert.Equal(t, hashes[0].CompactRevision, hkv.CompactRevision)
+
+	after, err := os.ReadFile(dbPath)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+}
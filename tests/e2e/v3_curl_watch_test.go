@@ -53,3 +53,21 @@ func testCurlV3Watch(cx ctlCtx) {
 	err = e2e.CURLPost(cx.epc, e2e.CURLReq{Endpoint: "/v3/watch", Value: wstr, Expected: expect.ExpectedResponse{Value: `"YmFy"`}, Timeout: 2})
 	require.ErrorContains(cx.t, err, "unexpected exit code")
 }
+
+func TestCurlV3WatchEvents(t *testing.T) {
+	testCtl(t, testCurlV3WatchEvents)
+}
+
+func testCurlV3WatchEvents(cx ctlCtx) {
+	putreq, err := json.Marshal(&pb.PutRequest{Key: []byte("foo"), Value: []byte("bar")})
+	require.NoError(cx.t, err)
+	require.NoError(cx.t, e2e.CURLPost(cx.epc, e2e.CURLReq{Endpoint: "/v3/kv/put", Value: string(putreq), Expected: expect.ExpectedResponse{Value: "revision"}}))
+
+	// key "foo" is base64 encoded as "Zm9v", value "bar" as "YmFy"
+	// expects "bar", timeout after 2 seconds since the event stream never ends
+	err = e2e.CURLGet(cx.epc, e2e.CURLReq{Endpoint: "/v3/watch/events?key=Zm9v&start_revision=1", Expected: expect.ExpectedResponse{Value: `"YmFy"`}, Timeout: 2})
+	require.ErrorContains(cx.t, err, "unexpected exit code")
+
+	err = e2e.CURLGet(cx.epc, e2e.CURLReq{Endpoint: "/v3/watch/events?key=Zm9v&filters=BOGUS", Expected: expect.ExpectedResponse{Value: "invalid filter"}})
+	require.NoError(cx.t, err)
+}
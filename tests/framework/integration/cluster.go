@@ -63,6 +63,7 @@ import (
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3lock"
 	lockpb "go.etcd.io/etcd/server/v3/etcdserver/api/v3lock/v3lockpb"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3rpc"
+	"go.etcd.io/etcd/server/v3/storage/encryption"
 	"go.etcd.io/etcd/server/v3/verify"
 	framecfg "go.etcd.io/etcd/tests/v3/framework/config"
 	"go.etcd.io/etcd/tests/v3/framework/testutils"
@@ -144,8 +145,10 @@ type ClusterConfig struct {
 	QuotaBackendBytes    int64
 	BackendBatchInterval time.Duration
 
-	MaxTxnOps       uint
-	MaxRequestBytes uint
+	MaxTxnOps        uint
+	MaxRequestBytes  uint
+	GRPCMaxRecvBytes uint
+	GRPCMaxSendBytes uint
 
 	SnapshotCount          uint64
 	SnapshotCatchUpEntries uint64
@@ -176,6 +179,35 @@ type ClusterConfig struct {
 	CorruptCheckTime            time.Duration
 
 	ExperimentalStopGRPCServiceOnDefrag bool
+	ExperimentalLearnerReadReplica      bool
+	ExperimentalDifferentialSnapshot    bool
+
+	ExperimentalRangeTombstoneThreshold int
+
+	ExperimentalHotKeysSampleRate float64
+
+	ExperimentalSoftDeleteThreshold int
+	ExperimentalSoftDeletePrefixes  []string
+	ExperimentalSoftDeleteRetention time.Duration
+	ExperimentalProtectedPrefixes   map[string][]string
+
+	ExperimentalDiskProbeInterval  time.Duration
+	ExperimentalDiskProbeThreshold time.Duration
+	ExperimentalDiskProbeFailures  int
+	ExperimentalDiskProbeSelfFence bool
+
+	ExperimentalAutoPromoteLearner                    bool
+	ExperimentalAutoPromoteLearnerStabilizationWindow time.Duration
+
+	LeaderPriorities map[string]int
+
+	EncryptionKMS encryption.KMS
+
+	SlowRequestThreshold time.Duration
+
+	MaxStreamsPerClientIP uint
+
+	ExperimentalInflightRequests bool
 }
 
 type Cluster struct {
@@ -248,7 +280,7 @@ func (c *Cluster) ProtoMembers() []*pb.Member {
 	for _, m := range c.Members {
 		pScheme := SchemeFromTLSInfo(m.PeerTLSInfo)
 		cScheme := SchemeFromTLSInfo(m.ClientTLSInfo)
-		cm := &pb.Member{Name: m.Name}
+		cm := &pb.Member{Name: m.Name, IsWitness: m.ExperimentalWitness}
 		for _, ln := range m.PeerListeners {
 			cm.PeerURLs = append(cm.PeerURLs, pScheme+"://"+ln.Addr().String())
 		}
@@ -275,6 +307,8 @@ func (c *Cluster) mustNewMember(t testutil.TB) *Member {
 			BackendBatchInterval:                c.Cfg.BackendBatchInterval,
 			MaxTxnOps:                           c.Cfg.MaxTxnOps,
 			MaxRequestBytes:                     c.Cfg.MaxRequestBytes,
+			GRPCMaxRecvBytes:                    c.Cfg.GRPCMaxRecvBytes,
+			GRPCMaxSendBytes:                    c.Cfg.GRPCMaxSendBytes,
 			SnapshotCount:                       c.Cfg.SnapshotCount,
 			SnapshotCatchUpEntries:              c.Cfg.SnapshotCatchUpEntries,
 			GRPCKeepAliveMinTime:                c.Cfg.GRPCKeepAliveMinTime,
@@ -294,6 +328,25 @@ func (c *Cluster) mustNewMember(t testutil.TB) *Member {
 			DisableStrictReconfigCheck:          c.Cfg.DisableStrictReconfigCheck,
 			CorruptCheckTime:                    c.Cfg.CorruptCheckTime,
 			ExperimentalStopGRPCServiceOnDefrag: c.Cfg.ExperimentalStopGRPCServiceOnDefrag,
+			ExperimentalLearnerReadReplica:      c.Cfg.ExperimentalLearnerReadReplica,
+			ExperimentalDifferentialSnapshot:    c.Cfg.ExperimentalDifferentialSnapshot,
+			ExperimentalRangeTombstoneThreshold: c.Cfg.ExperimentalRangeTombstoneThreshold,
+			ExperimentalHotKeysSampleRate:       c.Cfg.ExperimentalHotKeysSampleRate,
+			ExperimentalSoftDeleteThreshold:     c.Cfg.ExperimentalSoftDeleteThreshold,
+			ExperimentalSoftDeletePrefixes:      c.Cfg.ExperimentalSoftDeletePrefixes,
+			ExperimentalSoftDeleteRetention:     c.Cfg.ExperimentalSoftDeleteRetention,
+			ExperimentalProtectedPrefixes:       c.Cfg.ExperimentalProtectedPrefixes,
+			ExperimentalDiskProbeInterval:       c.Cfg.ExperimentalDiskProbeInterval,
+			ExperimentalDiskProbeThreshold:      c.Cfg.ExperimentalDiskProbeThreshold,
+			ExperimentalDiskProbeFailures:       c.Cfg.ExperimentalDiskProbeFailures,
+			ExperimentalDiskProbeSelfFence:      c.Cfg.ExperimentalDiskProbeSelfFence,
+			SlowRequestThreshold:                c.Cfg.SlowRequestThreshold,
+			MaxStreamsPerClientIP:               c.Cfg.MaxStreamsPerClientIP,
+			ExperimentalInflightRequests:        c.Cfg.ExperimentalInflightRequests,
+			ExperimentalAutoPromoteLearner:      c.Cfg.ExperimentalAutoPromoteLearner,
+			ExperimentalAutoPromoteLearnerStabilizationWindow: c.Cfg.ExperimentalAutoPromoteLearnerStabilizationWindow,
+			LeaderPriorities: c.Cfg.LeaderPriorities,
+			EncryptionKMS:    c.Cfg.EncryptionKMS,
 		})
 	m.DiscoveryURL = c.Cfg.DiscoveryURL
 	return m
@@ -601,6 +654,8 @@ type MemberConfig struct {
 	BackendBatchInterval        time.Duration
 	MaxTxnOps                   uint
 	MaxRequestBytes             uint
+	GRPCMaxRecvBytes            uint
+	GRPCMaxSendBytes            uint
 	SnapshotCount               uint64
 	SnapshotCatchUpEntries      uint64
 	GRPCKeepAliveMinTime        time.Duration
@@ -621,6 +676,35 @@ type MemberConfig struct {
 	CorruptCheckTime            time.Duration
 
 	ExperimentalStopGRPCServiceOnDefrag bool
+	ExperimentalLearnerReadReplica      bool
+	ExperimentalDifferentialSnapshot    bool
+
+	ExperimentalRangeTombstoneThreshold int
+
+	ExperimentalHotKeysSampleRate float64
+
+	ExperimentalSoftDeleteThreshold int
+	ExperimentalSoftDeletePrefixes  []string
+	ExperimentalSoftDeleteRetention time.Duration
+	ExperimentalProtectedPrefixes   map[string][]string
+
+	ExperimentalDiskProbeInterval  time.Duration
+	ExperimentalDiskProbeThreshold time.Duration
+	ExperimentalDiskProbeFailures  int
+	ExperimentalDiskProbeSelfFence bool
+
+	ExperimentalAutoPromoteLearner                    bool
+	ExperimentalAutoPromoteLearnerStabilizationWindow time.Duration
+
+	LeaderPriorities map[string]int
+
+	EncryptionKMS encryption.KMS
+
+	SlowRequestThreshold time.Duration
+
+	MaxStreamsPerClientIP uint
+
+	ExperimentalInflightRequests bool
 }
 
 // MustNewMember return an inited member with the given name. If peerTLS is
@@ -682,6 +766,8 @@ func MustNewMember(t testutil.TB, mcfg MemberConfig) *Member {
 	if m.MaxRequestBytes == 0 {
 		m.MaxRequestBytes = embed.DefaultMaxRequestBytes
 	}
+	m.GRPCMaxRecvBytes = mcfg.GRPCMaxRecvBytes
+	m.GRPCMaxSendBytes = mcfg.GRPCMaxSendBytes
 	m.SnapshotCount = etcdserver.DefaultSnapshotCount
 	if mcfg.SnapshotCount != 0 {
 		m.SnapshotCount = mcfg.SnapshotCount
@@ -730,6 +816,36 @@ func MustNewMember(t testutil.TB, mcfg MemberConfig) *Member {
 		m.CorruptCheckTime = mcfg.CorruptCheckTime
 	}
 	m.ExperimentalStopGRPCServiceOnDefrag = mcfg.ExperimentalStopGRPCServiceOnDefrag
+	m.ExperimentalLearnerReadReplica = mcfg.ExperimentalLearnerReadReplica
+	m.ExperimentalDifferentialSnapshot = mcfg.ExperimentalDifferentialSnapshot
+	m.ExperimentalRangeTombstoneThreshold = mcfg.ExperimentalRangeTombstoneThreshold
+	m.ExperimentalHotKeysSampleRate = mcfg.ExperimentalHotKeysSampleRate
+	m.ExperimentalSoftDeleteThreshold = mcfg.ExperimentalSoftDeleteThreshold
+	m.ExperimentalSoftDeletePrefixes = mcfg.ExperimentalSoftDeletePrefixes
+	m.ExperimentalSoftDeleteRetention = embed.DefaultExperimentalSoftDeleteRetention
+	if mcfg.ExperimentalSoftDeleteRetention > 0 {
+		m.ExperimentalSoftDeleteRetention = mcfg.ExperimentalSoftDeleteRetention
+	}
+	m.ExperimentalSoftDeleteTrashPrefix = embed.DefaultExperimentalSoftDeleteTrashPrefix
+	m.ExperimentalProtectedPrefixes = mcfg.ExperimentalProtectedPrefixes
+	m.ExperimentalDiskProbeInterval = mcfg.ExperimentalDiskProbeInterval
+	m.ExperimentalDiskProbeThreshold = embed.DefaultExperimentalDiskProbeThreshold
+	if mcfg.ExperimentalDiskProbeThreshold > 0 {
+		m.ExperimentalDiskProbeThreshold = mcfg.ExperimentalDiskProbeThreshold
+	}
+	m.ExperimentalDiskProbeFailures = embed.DefaultExperimentalDiskProbeFailures
+	if mcfg.ExperimentalDiskProbeFailures > 0 {
+		m.ExperimentalDiskProbeFailures = mcfg.ExperimentalDiskProbeFailures
+	}
+	m.ExperimentalDiskProbeSelfFence = mcfg.ExperimentalDiskProbeSelfFence
+	m.SlowRequestThreshold = mcfg.SlowRequestThreshold
+	m.MaxStreamsPerClientIP = mcfg.MaxStreamsPerClientIP
+	m.ExperimentalInflightRequests = mcfg.ExperimentalInflightRequests
+	m.ExperimentalAutoPromoteLearner = mcfg.ExperimentalAutoPromoteLearner
+	m.ExperimentalAutoPromoteLearnerMaxLag = embed.DefaultExperimentalAutoPromoteLearnerMaxLag
+	m.ExperimentalAutoPromoteLearnerStabilizationWindow = mcfg.ExperimentalAutoPromoteLearnerStabilizationWindow
+	m.LeaderPriorities = mcfg.LeaderPriorities
+	m.EncryptionKMS = mcfg.EncryptionKMS
 	m.WarningApplyDuration = embed.DefaultWarningApplyDuration
 	m.WarningUnaryRequestDuration = embed.DefaultWarningUnaryRequestDuration
 	m.ExperimentalMaxLearners = membership.DefaultMaxLearners
@@ -1046,6 +1162,9 @@ func (m *Member) Launch() error {
 		etcdhttp.HandleVersion(handler, m.Server)
 		etcdhttp.HandleMetrics(handler)
 		etcdhttp.HandleHealth(m.Logger, handler, m.Server)
+		if m.ExperimentalInflightRequests {
+			etcdhttp.HandleInflight(handler, m.Server)
+		}
 		hs := &httptest.Server{
 			Listener: ln,
 			Config: &http.Server{
@@ -1614,6 +1733,37 @@ func (c *Cluster) AddAndLaunchLearnerMember(t testutil.TB) {
 	c.waitMembersMatch(t)
 }
 
+// AddAndLaunchWitnessMember creates a witness member, adds it to Cluster
+// as a voting member via v3 MemberAdd API, and then launches the new member.
+func (c *Cluster) AddAndLaunchWitnessMember(t testutil.TB) {
+	m := c.mustNewMember(t)
+	m.ExperimentalWitness = true
+
+	scheme := SchemeFromTLSInfo(c.Cfg.PeerTLS)
+	peerURLs := []string{scheme + "://" + m.PeerListeners[0].Addr().String()}
+
+	cli := c.Client(0)
+	_, err := cli.MemberAdd(context.Background(), peerURLs)
+	if err != nil {
+		t.Fatalf("failed to add witness member %v", err)
+	}
+
+	m.InitialPeerURLsMap = types.URLsMap{}
+	for _, mm := range c.Members {
+		m.InitialPeerURLsMap[mm.Name] = mm.PeerURLs
+	}
+	m.InitialPeerURLsMap[m.Name] = m.PeerURLs
+	m.NewCluster = false
+
+	if err := m.Launch(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Members = append(c.Members, m)
+
+	c.waitMembersMatch(t)
+}
+
 // getMembers returns a list of members in Cluster, in format of etcdserverpb.Member
 func (c *Cluster) getMembers() []*pb.Member {
 	var mems []*pb.Member
@@ -1623,6 +1773,7 @@ func (c *Cluster) getMembers() []*pb.Member {
 			PeerURLs:   m.PeerURLs.StringSlice(),
 			ClientURLs: m.ClientURLs.StringSlice(),
 			IsLearner:  m.IsLearner,
+			IsWitness:  m.ExperimentalWitness,
 		}
 		mems = append(mems, mem)
 	}
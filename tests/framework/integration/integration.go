@@ -271,6 +271,10 @@ func (c integrationClient) TimeToLive(ctx context.Context, id clientv3.LeaseID,
 	return c.Client.TimeToLive(ctx, id, leaseOpts...)
 }
 
+func (c integrationClient) Grant(ctx context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error) {
+	return c.Client.Grant(ctx, ttl)
+}
+
 func (c integrationClient) Leases(ctx context.Context) (*clientv3.LeaseLeasesResponse, error) {
 	return c.Client.Leases(ctx)
 }
@@ -0,0 +1,87 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+// TestAsyncKV ensures AsyncKV applies the submitted operations and, when
+// ordered, completes their futures in the order of submission.
+func TestAsyncKV(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx := context.TODO()
+
+	a := clientv3.NewAsyncKV(cli, clientv3.AsyncOptions{MaxInFlight: 4, Ordered: true})
+	var futures []*clientv3.Future
+	for i := 0; i < 100; i++ {
+		futures = append(futures, a.Put(ctx, fmt.Sprintf("foo/%03d", i), "v1"))
+	}
+	resp, err := futures[len(futures)-1].Result()
+	require.NoError(t, err)
+	require.NotNil(t, resp.Put())
+	for i, f := range futures {
+		select {
+		case <-f.Done():
+		default:
+			t.Fatalf("future %d not completed before the last one", i)
+		}
+	}
+
+	tf := a.Txn(ctx,
+		[]clientv3.Cmp{clientv3.Compare(clientv3.Value("foo/000"), "=", "v1")},
+		[]clientv3.Op{clientv3.OpPut("foo/000", "v2")},
+		nil,
+	)
+	df := a.Delete(ctx, "foo/05", clientv3.WithPrefix())
+	a.Close()
+	resp, err = tf.Result()
+	require.NoError(t, err)
+	assert.True(t, resp.Txn().Succeeded)
+	resp, err = df.Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), resp.Del().Deleted)
+
+	_, err = a.Put(ctx, "foo/100", "v1").Result()
+	require.ErrorIs(t, err, clientv3.ErrAsyncKVClosed)
+
+	gresp, err := cli.Get(ctx, "foo/", clientv3.WithPrefix(), clientv3.WithCountOnly())
+	require.NoError(t, err)
+	assert.Equal(t, int64(90), gresp.Count)
+	gresp, err = cli.Get(ctx, "foo/000")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", string(gresp.Kvs[0].Value))
+
+	// a canceled context fails the operations waiting for room in the window
+	a = clientv3.NewAsyncKV(cli, clientv3.AsyncOptions{MaxInFlight: 1})
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = a.Put(cctx, "foo/000", "v3").Result()
+	require.ErrorIs(t, err, context.Canceled)
+	a.Wait()
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+// TestBatchWriter ensures BatchWriter coalesces writes into transactions of
+// at most MaxOps operations, applied as if they were sent one by one.
+func TestBatchWriter(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx := context.TODO()
+	resp, err := cli.Put(ctx, "foo/old", "bar")
+	require.NoError(t, err)
+	startRev := resp.Header.Revision
+
+	w := clientv3.NewBatchWriter(cli, clientv3.BatchOptions{MaxOps: 3})
+	for i := 0; i < 5; i++ {
+		require.NoError(t, w.Put(ctx, fmt.Sprintf("foo/%d", i), "v1"))
+	}
+	// the txn of foo/0 to foo/2 is full, foo/3 and foo/4 are pending
+	gresp, err := cli.Get(ctx, "foo/", clientv3.WithPrefix(), clientv3.WithCountOnly())
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), gresp.Count)
+
+	// a put of a pending key is sent in the next txn, as is a delete of a
+	// pending put
+	require.NoError(t, w.Put(ctx, "foo/4", "v2"))
+	require.NoError(t, w.Delete(ctx, "foo/", clientv3.WithPrefix()))
+	require.NoError(t, w.Put(ctx, "foo/5", "v1"))
+	require.NoError(t, w.Close(ctx))
+	require.ErrorIs(t, w.Put(ctx, "foo/6", "v1"), clientv3.ErrBatchWriterClosed)
+
+	gresp, err = cli.Get(ctx, "foo/", clientv3.WithPrefix())
+	require.NoError(t, err)
+	require.Len(t, gresp.Kvs, 1)
+	assert.Equal(t, "foo/5", string(gresp.Kvs[0].Key))
+	// one revision per txn
+	assert.Equal(t, startRev+5, gresp.Header.Revision)
+}
+
+// TestBatchWriterFlushInterval ensures BatchWriter sends a transaction that
+// is not full once the flush interval has elapsed.
+func TestBatchWriterFlushInterval(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx := context.TODO()
+
+	w := clientv3.NewBatchWriter(cli, clientv3.BatchOptions{FlushInterval: 100 * time.Millisecond})
+	defer w.Close(ctx)
+	require.NoError(t, w.Put(ctx, "foo", "bar"))
+	require.Eventually(t, func() bool {
+		resp, err := cli.Get(ctx, "foo")
+		return err == nil && len(resp.Kvs) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+// TestBatchWriterError ensures the error of a failed transaction is returned
+// by the subsequent operations.
+func TestBatchWriterError(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx := context.TODO()
+
+	w := clientv3.NewBatchWriter(cli, clientv3.BatchOptions{})
+	require.NoError(t, w.Put(ctx, "foo", "bar", clientv3.WithLease(clientv3.LeaseID(12345))))
+	err := w.Flush(ctx)
+	require.Error(t, err)
+	require.ErrorIs(t, w.Put(ctx, "foo", "bar"), err)
+	require.ErrorIs(t, w.Close(ctx), err)
+}
@@ -0,0 +1,146 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/chunking"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+func TestChunkingPutGet(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	c := clus.Client(0)
+	ckv := chunking.NewKV(c.KV, chunking.Config{ChunkSize: 10, MaxValueSize: 100})
+	ctx := context.TODO()
+
+	large := strings.Repeat("0123456789", 5) + "abc"
+	if _, err := ckv.Put(ctx, "foo", large); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ckv.Put(ctx, "small", "bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the value is reassembled from its chunks
+	resp, err := ckv.Get(ctx, "", clientv3.WithFromKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 2 || string(resp.Kvs[0].Value) != large || string(resp.Kvs[1].Value) != "bar" {
+		t.Fatalf("unexpected key-values %+v", resp.Kvs)
+	}
+
+	// the cluster stores a manifest and 6 chunks
+	resp, err = c.Get(ctx, chunking.DefaultChunkPrefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Count != 6 {
+		t.Errorf("expected 6 chunks, got %d", resp.Count)
+	}
+	resp, err = c.Get(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(resp.Kvs[0].Value), large) {
+		t.Errorf("expected a manifest, got %q", resp.Kvs[0].Value)
+	}
+	rev := resp.Header.Revision
+
+	// overwriting the key deletes the previous chunks, but reads at older
+	// revisions still see the previous value
+	presp, err := ckv.Put(ctx, "foo", strings.Repeat("x", 25), clientv3.WithPrevKV())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(presp.PrevKv.Value) != large {
+		t.Errorf("expected the previous value to be reassembled, got %q", presp.PrevKv.Value)
+	}
+	resp, err = c.Get(ctx, chunking.DefaultChunkPrefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Count != 3 {
+		t.Errorf("expected 3 chunks, got %d", resp.Count)
+	}
+	resp, err = ckv.Get(ctx, "foo", clientv3.WithRev(rev))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Kvs[0].Value) != large {
+		t.Errorf("expected the value at revision %d, got %q", rev, resp.Kvs[0].Value)
+	}
+
+	// deleting the key deletes its chunks
+	dresp, err := ckv.Delete(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dresp.Deleted != 1 || len(dresp.PrevKvs) != 0 {
+		t.Errorf("unexpected delete response %+v", dresp)
+	}
+	resp, err = c.Get(ctx, chunking.DefaultChunkPrefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Count != 0 {
+		t.Errorf("expected no chunks, got %d", resp.Count)
+	}
+
+	if _, err = ckv.Put(ctx, "foo", strings.Repeat("x", 101)); !errors.Is(err, chunking.ErrValueTooLarge) {
+		t.Errorf("expected %v, got %v", chunking.ErrValueTooLarge, err)
+	}
+}
+
+func TestChunkingLease(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	c := clus.Client(0)
+	ckv := chunking.NewKV(c.KV, chunking.Config{ChunkSize: 10})
+	ctx := context.TODO()
+
+	lresp, err := c.Grant(ctx, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ckv.Put(ctx, "foo", strings.Repeat("x", 25), clientv3.WithLease(lresp.ID)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.Revoke(ctx, lresp.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// the chunks expire with the key
+	resp, err := c.Get(ctx, "", clientv3.WithFromKey(), clientv3.WithCountOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Count != 0 {
+		t.Errorf("expected no keys, got %d", resp.Count)
+	}
+}
@@ -16,6 +16,7 @@ package clientv3test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"reflect"
@@ -23,6 +24,7 @@ import (
 	"testing"
 	"time"
 
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/client/pkg/v3/types"
 	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
 )
@@ -154,6 +156,62 @@ func TestMemberUpdate(t *testing.T) {
 	}
 }
 
+func TestMemberUpdateLabels(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	id := uint64(clus.Members[0].Server.MemberID())
+	memberLabels := func() map[string]string {
+		resp, err := clus.Client(1).MemberList(context.Background())
+		if err != nil {
+			t.Fatalf("failed to list member %v", err)
+		}
+		for _, m := range resp.Members {
+			if m.ID == id {
+				return m.Labels
+			}
+		}
+		t.Fatalf("member %x not found", id)
+		return nil
+	}
+
+	labels := map[string]string{"zone": "us-east-1a", "region": "us-east-1"}
+	if _, err := clus.Client(0).MemberUpdateLabels(context.Background(), id, labels); err != nil {
+		t.Fatalf("failed to update member labels %v", err)
+	}
+	if got := memberLabels(); !reflect.DeepEqual(got, labels) {
+		t.Errorf("labels = %v, want %v", got, labels)
+	}
+
+	// an empty value removes the label, the others are kept
+	if _, err := clus.Client(0).MemberUpdateLabels(context.Background(), id, map[string]string{"region": ""}); err != nil {
+		t.Fatalf("failed to update member labels %v", err)
+	}
+	want := map[string]string{"zone": "us-east-1a"}
+	if got := memberLabels(); !reflect.DeepEqual(got, want) {
+		t.Errorf("labels = %v, want %v", got, want)
+	}
+
+	// the member publishing its attributes on restart keeps its labels
+	clus.Members[0].Stop(t)
+	if err := clus.Members[0].Restart(t); err != nil {
+		t.Fatal(err)
+	}
+	clus.WaitLeader(t)
+	if got := memberLabels(); !reflect.DeepEqual(got, want) {
+		t.Errorf("labels after restart = %v, want %v", got, want)
+	}
+
+	if _, err := clus.Client(1).MemberUpdateLabels(context.Background(), id+1, want); !errors.Is(err, rpctypes.ErrMemberNotFound) {
+		t.Errorf("err = %v, want %v", err, rpctypes.ErrMemberNotFound)
+	}
+	if _, err := clus.Client(1).MemberUpdateLabels(context.Background(), id, map[string]string{"": "a"}); !errors.Is(err, rpctypes.ErrMemberBadLabels) {
+		t.Errorf("err = %v, want %v", err, rpctypes.ErrMemberBadLabels)
+	}
+}
+
 func TestMemberAddUpdateWrongURLs(t *testing.T) {
 	integration2.BeforeTest(t)
 
@@ -292,6 +350,41 @@ func TestMemberPromote(t *testing.T) {
 	}
 }
 
+// TestMemberAutoPromote ensures that a learner that has caught up with the
+// leader is promoted automatically when auto promotion is enabled.
+func TestMemberAutoPromote(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{
+		Size:                           3,
+		DisableStrictReconfigCheck:     true,
+		ExperimentalAutoPromoteLearner: true,
+		ExperimentalAutoPromoteLearnerStabilizationWindow: time.Second,
+	})
+	defer clus.Terminate(t)
+
+	clus.AddAndLaunchLearnerMember(t)
+	learnerID := uint64(clus.Members[3].ID())
+
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case <-time.After(500 * time.Millisecond):
+		case <-timeout:
+			t.Fatal("expected learner to be promoted automatically")
+		}
+		resp, err := clus.Client(0).MemberList(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range resp.Members {
+			if m.ID == learnerID && !m.IsLearner {
+				return
+			}
+		}
+	}
+}
+
 // TestMemberPromoteMemberNotLearner ensures that promoting a voting member fails.
 func TestMemberPromoteMemberNotLearner(t *testing.T) {
 	integration2.BeforeTest(t, integration2.WithFailpoint("raftBeforeAdvance", `sleep(100)`))
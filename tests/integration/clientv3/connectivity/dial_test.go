@@ -21,6 +21,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
@@ -220,3 +221,58 @@ func TestSetEndpointAndPut(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestDialSubConnsPerEndpoint ensures that a client opening several
+// connections to an endpoint spreads its requests over them.
+func TestDialSubConnsPerEndpoint(t *testing.T) {
+	integration2.BeforeTest(t)
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cfg := clientv3.Config{
+		Endpoints:           []string{clus.Members[0].GRPCURL},
+		DialTimeout:         5 * time.Second,
+		DialOptions:         []grpc.DialOption{grpc.WithBlock()},
+		SubConnsPerEndpoint: 3,
+	}
+	cli, err := integration2.NewClient(t, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// wait for all the connections to be ready
+	time.Sleep(time.Second)
+	for i := 0; i < 9; i++ {
+		if _, err = cli.Put(ctx, "foo", "bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(clientv3.ConnectionMetrics())
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conns := make(map[string]bool)
+	for _, mf := range mfs {
+		if mf.GetName() != "etcd_client_connection_requests_total" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			labels := make(map[string]string)
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if strings.HasSuffix(labels["endpoint"], "localhost:"+clus.Members[0].Name) {
+				conns[labels["connection"]] = true
+			}
+		}
+	}
+	if len(conns) != 3 {
+		t.Fatalf("expected requests over 3 connections, got %v", conns)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+// gatedWatcher holds the watches until the gate is closed.
+type gatedWatcher struct {
+	clientv3.Watcher
+	gate chan struct{}
+}
+
+func (w *gatedWatcher) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	<-w.gate
+	return w.Watcher.Watch(ctx, key, opts...)
+}
+
+// TestInformer ensures an Informer caches the listed and watched keys, and
+// lists them again when the revisions it missed were compacted.
+func TestInformer(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx := context.TODO()
+
+	for _, k := range []string{"foo/a", "foo/b", "foo/c"} {
+		_, err := cli.Put(ctx, k, "v1")
+		require.NoError(t, err)
+	}
+	_, err := cli.Put(ctx, "fop", "v1")
+	require.NoError(t, err)
+
+	var (
+		mu      sync.Mutex
+		events  []string
+		resyncs []int64
+	)
+	record := func(ev string, kv *mvccpb.KeyValue) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, ev+" "+string(kv.Key)+"="+string(kv.Value))
+	}
+	recorded := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		evs := events
+		events = nil
+		return evs
+	}
+	w := &gatedWatcher{Watcher: cli, gate: make(chan struct{})}
+	inf := clientv3.NewInformer(cli, w, "foo/", clientv3.InformerHandler{
+		OnAdd:    func(kv *mvccpb.KeyValue) { record("add", kv) },
+		OnUpdate: func(_, kv *mvccpb.KeyValue) { record("update", kv) },
+		OnDelete: func(kv *mvccpb.KeyValue) { record("delete", kv) },
+		OnResync: func(rev int64) {
+			mu.Lock()
+			defer mu.Unlock()
+			resyncs = append(resyncs, rev)
+		},
+	})
+	inf.PageSize = 2
+
+	rctx, cancel := context.WithCancel(ctx)
+	errc := make(chan error, 1)
+	go func() { errc <- inf.Run(rctx) }()
+
+	select {
+	case <-inf.Synced():
+	case <-time.After(5 * time.Second):
+		t.Fatal("failed to list the keys")
+	}
+	assert.Equal(t, []string{"add foo/a=v1", "add foo/b=v1", "add foo/c=v1"}, recorded())
+	assert.Len(t, inf.List(), 3)
+
+	// the changes missed by the held watch are compacted, so the keys are
+	// listed again
+	_, err = cli.Put(ctx, "foo/a", "v2")
+	require.NoError(t, err)
+	_, err = cli.Delete(ctx, "foo/b")
+	require.NoError(t, err)
+	presp, err := cli.Put(ctx, "foo/d", "v1")
+	require.NoError(t, err)
+	_, err = cli.Compact(ctx, presp.Header.Revision, clientv3.WithCompactPhysical())
+	require.NoError(t, err)
+	close(w.gate)
+
+	require.Eventually(t, func() bool { return inf.Revision() == presp.Header.Revision }, 5*time.Second, 10*time.Millisecond)
+	assert.ElementsMatch(t, []string{"update foo/a=v2", "add foo/d=v1", "delete foo/b=v1"}, recorded())
+	mu.Lock()
+	assert.Len(t, resyncs, 2)
+	mu.Unlock()
+
+	// the keys are then watched
+	_, err = cli.Put(ctx, "foo/e", "v1")
+	require.NoError(t, err)
+	_, err = cli.Put(ctx, "fop", "v2")
+	require.NoError(t, err)
+	dresp, err := cli.Delete(ctx, "foo/c")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return inf.Revision() == dresp.Header.Revision }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"add foo/e=v1", "delete foo/c=v1"}, recorded())
+
+	var keys []string
+	for _, kv := range inf.List() {
+		keys = append(keys, string(kv.Key))
+	}
+	assert.Equal(t, []string{"foo/a", "foo/d", "foo/e"}, keys)
+	kv, ok := inf.Get("foo/a")
+	require.True(t, ok)
+	assert.Equal(t, "v2", string(kv.Value))
+
+	cancel()
+	require.ErrorIs(t, <-errc, context.Canceled)
+}
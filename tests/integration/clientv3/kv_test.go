@@ -29,6 +29,7 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/mvccpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/api/v3/version"
@@ -848,6 +849,163 @@ func TestKVForLearner(t *testing.T) {
 	}
 }
 
+// TestKVForLearnerReadReplica ensures a learner read replica serves
+// serializable reads and watches flagged as possibly stale, and refuses
+// to be promoted.
+func TestKVForLearnerReadReplica(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3, DisableStrictReconfigCheck: true})
+	defer clus.Terminate(t)
+
+	clus.Cfg.ExperimentalLearnerReadReplica = true
+	clus.AddAndLaunchLearnerMember(t)
+	learner := clus.Members[3]
+	<-learner.ReadyNotify()
+
+	cli, err := integration2.NewClient(t, clientv3.Config{
+		Endpoints:   []string{learner.GRPCURL},
+		DialTimeout: 5 * time.Second,
+		DialOptions: []grpc.DialOption{grpc.WithBlock()},
+	})
+	if err != nil {
+		t.Fatalf("failed to create clientv3: %v", err)
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	presp, err := clus.Client(0).Put(ctx, "foo", "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wresp := <-cli.Watch(ctx, "foo", clientv3.WithRev(presp.Header.Revision))
+	if err = wresp.Err(); err != nil {
+		t.Fatalf("expected watch on read replica to succeed, got %v", err)
+	}
+	if len(wresp.Events) != 1 || string(wresp.Events[0].Kv.Value) != "bar" {
+		t.Fatalf("unexpected watch events %v", wresp.Events)
+	}
+	if !wresp.Header.PossiblyStale {
+		t.Error("expected watch response from read replica to be flagged as possibly stale")
+	}
+
+	resp, err := cli.Get(ctx, "foo", clientv3.WithSerializable())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Header.PossiblyStale {
+		t.Error("expected range response from read replica to be flagged as possibly stale")
+	}
+	if resp, err = clus.Client(0).Get(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.PossiblyStale {
+		t.Error("expected range response from voting member not to be flagged as possibly stale")
+	}
+
+	if _, err = clus.Client(0).MemberPromote(ctx, uint64(learner.ID())); err != rpctypes.ErrMemberReadReplica {
+		t.Fatalf("expected %v on promoting read replica, got %v", rpctypes.ErrMemberReadReplica, err)
+	}
+}
+
+// TestKVGetMaxStaleness ensures a bounded-staleness read is served from local
+// state once it has been confirmed with the leader, even after quorum is lost.
+func TestKVGetMaxStaleness(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	cli := clus.Client(0)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := cli.Put(ctx, "foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	// a linearizable read confirms the local state with the leader
+	if _, err := cli.Get(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := cli.Get(ctx, "foo", clientv3.WithMaxStalenessRevisions(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 1 || string(resp.Kvs[0].Value) != "bar" {
+		t.Fatalf("unexpected kvs %v", resp.Kvs)
+	}
+
+	clus.Members[1].Stop(t)
+	clus.Members[2].Stop(t)
+
+	resp, err = cli.Get(ctx, "foo", clientv3.WithMaxStaleness(time.Minute))
+	if err != nil {
+		t.Fatalf("expected bounded-staleness read to succeed without quorum, got %v", err)
+	}
+	if len(resp.Kvs) != 1 || string(resp.Kvs[0].Value) != "bar" {
+		t.Fatalf("unexpected kvs %v", resp.Kvs)
+	}
+
+	lctx, lcancel := context.WithTimeout(context.Background(), time.Second)
+	defer lcancel()
+	if _, err = cli.Get(lctx, "foo", clientv3.WithMaxStaleness(time.Nanosecond)); err == nil {
+		t.Fatal("expected read outside of the staleness bound to fail without quorum")
+	}
+}
+
+// TestKVGetGroupBySeparator ensures a Get with a group by separator returns
+// the count of keys per group, limited to the given number of groups and
+// continued from the next group key.
+func TestKVGetGroupBySeparator(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx := context.TODO()
+	for _, key := range []string{"/pods/default/a", "/pods/default/b", "/pods/kube-system/a", "/pods/x"} {
+		if _, err := cli.Put(ctx, key, "bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, err := cli.Get(ctx, "/pods/", clientv3.WithPrefix(), clientv3.WithGroupBySeparator("/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*pb.KeyGroupCount{
+		{Prefix: []byte("/pods/default/"), Count: 2},
+		{Prefix: []byte("/pods/kube-system/"), Count: 1},
+		{Prefix: []byte("/pods/x"), Count: 1},
+	}
+	if !reflect.DeepEqual(resp.GroupCounts, want) || resp.Count != 4 || len(resp.Kvs) != 0 || resp.More {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+
+	var got []*pb.KeyGroupCount
+	opts := []clientv3.OpOption{clientv3.WithPrefix(), clientv3.WithGroupBySeparator("/"), clientv3.WithLimit(1)}
+	for i := 0; ; i++ {
+		resp, err = cli.Get(ctx, "/pods/", opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(resp.GroupCounts, want[i:i+1]) || resp.Count != want[i].Count || resp.More != (i < len(want)-1) {
+			t.Fatalf("unexpected limited response %+v", resp)
+		}
+		got = append(got, resp.GroupCounts...)
+		if !resp.More {
+			break
+		}
+		opts = append(opts, clientv3.WithGroupFromKey(string(resp.NextGroupKey)))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("groups = %+v, want %+v", got, want)
+	}
+}
+
 // TestBalancerSupportLearner verifies that balancer's retry and failover mechanism supports cluster with learner member
 func TestBalancerSupportLearner(t *testing.T) {
 	integration2.BeforeTest(t)
@@ -23,7 +23,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"go.etcd.io/etcd/client/pkg/v3/testutil"
 	clientv3 "go.etcd.io/etcd/client/v3"
@@ -2028,6 +2030,114 @@ func TestLeasingSessionExpireCancel(t *testing.T) {
 	}
 }
 
+// TestLeasingMaxKeys ensures the least recently read key is evicted and its
+// leasing key released once the cache is full.
+func TestLeasingMaxKeys(t *testing.T) {
+	integration2.BeforeTest(t)
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	lkv, closeLKV, err := leasing.New(clus.Client(0), leasing.Config{Prefix: "pfx/", MaxKeys: 2})
+	require.NoError(t, err)
+	defer closeLKV()
+
+	evictions := leasingMetric(t, "etcd_leasing_cache_evictions_total")
+	hits := leasingMetric(t, "etcd_leasing_cache_hits_total")
+	for _, k := range []string{"a", "b", "a", "c"} {
+		_, err = lkv.Get(context.TODO(), k)
+		require.NoError(t, err)
+	}
+	require.Equal(t, evictions+1, leasingMetric(t, "etcd_leasing_cache_evictions_total"))
+	require.Equal(t, hits+1, leasingMetric(t, "etcd_leasing_cache_hits_total"))
+
+	// b was read least recently
+	var lkeys []string
+	for i := 0; i < 10; i++ {
+		resp, gerr := clus.Client(0).Get(context.TODO(), "pfx/", clientv3.WithPrefix(), clientv3.WithKeysOnly())
+		require.NoError(t, gerr)
+		lkeys = nil
+		for _, kv := range resp.Kvs {
+			lkeys = append(lkeys, string(kv.Key))
+		}
+		if len(lkeys) == 2 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.Equal(t, []string{"pfx/a", "pfx/c"}, lkeys)
+
+	// writes to the evicted key need no revoke
+	_, err = clus.Client(0).Put(context.TODO(), "b", "123")
+	require.NoError(t, err)
+	resp, err := lkv.Get(context.TODO(), "b")
+	require.NoError(t, err)
+	require.Equal(t, "123", string(resp.Kvs[0].Value))
+}
+
+// TestLeasingDenied ensures reads are served by the cluster when it denies
+// to acquire leasing keys.
+func TestLeasingDenied(t *testing.T) {
+	integration2.BeforeTest(t)
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.Client(0)
+	_, err := cli.Put(context.TODO(), "abc", "bar")
+	require.NoError(t, err)
+
+	// the reader has no permission on the leasing prefix
+	_, err = cli.RoleAdd(context.TODO(), "reader")
+	require.NoError(t, err)
+	_, err = cli.RoleGrantPermission(context.TODO(), "reader", "abc", "", clientv3.PermissionType(clientv3.PermReadWrite))
+	require.NoError(t, err)
+	_, err = cli.UserAdd(context.TODO(), "reader", "123")
+	require.NoError(t, err)
+	_, err = cli.UserGrantRole(context.TODO(), "reader", "reader")
+	require.NoError(t, err)
+	_, err = cli.UserAdd(context.TODO(), "root", "123")
+	require.NoError(t, err)
+	_, err = cli.RoleAdd(context.TODO(), "root")
+	require.NoError(t, err)
+	_, err = cli.UserGrantRole(context.TODO(), "root", "root")
+	require.NoError(t, err)
+	_, err = cli.AuthEnable(context.TODO())
+	require.NoError(t, err)
+
+	rcli, err := integration2.NewClient(t, clientv3.Config{
+		Endpoints:   cli.Endpoints(),
+		DialTimeout: 5 * time.Second,
+		Username:    "reader",
+		Password:    "123",
+	})
+	require.NoError(t, err)
+	defer rcli.Close()
+
+	lkv, closeLKV, err := leasing.NewKV(rcli, "pfx/")
+	require.NoError(t, err)
+	defer closeLKV()
+
+	denied := leasingMetric(t, "etcd_leasing_acquire_denied_total")
+	for i := 0; i < 2; i++ {
+		resp, gerr := lkv.Get(context.TODO(), "abc")
+		require.NoError(t, gerr)
+		require.Equal(t, "bar", string(resp.Kvs[0].Value))
+	}
+	// keys are not acquired again right after being denied
+	require.Equal(t, denied+1, leasingMetric(t, "etcd_leasing_acquire_denied_total"))
+}
+
+func leasingMetric(t *testing.T, name string) float64 {
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, mf := range mfs {
+		if mf.GetName() == name {
+			return mf.GetMetric()[0].GetCounter().GetValue()
+		}
+	}
+	t.Fatalf("metric %q not found", name)
+	return 0
+}
+
 func waitForLeasingExpire(kv clientv3.KV, lkey string) error {
 	for {
 		time.Sleep(1 * time.Second)
@@ -31,12 +31,14 @@ import (
 	"go.uber.org/zap/zaptest"
 	"google.golang.org/grpc"
 
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/api/v3/version"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/server/v3/lease"
 	"go.etcd.io/etcd/server/v3/storage"
 	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/encryption"
 	"go.etcd.io/etcd/server/v3/storage/mvcc"
 	"go.etcd.io/etcd/server/v3/storage/mvcc/testutil"
 	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
@@ -75,6 +77,102 @@ func TestMaintenanceHashKV(t *testing.T) {
 	}
 }
 
+func TestMaintenanceHashKVRange(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx := context.Background()
+	for _, p := range []string{"/a/", "/b/"} {
+		for _, k := range []string{"x", "y", "z"} {
+			if _, err := cli.Put(ctx, p+k, k); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	ep := clus.Members[0].GRPCURL
+
+	a, err := cli.HashKVRange(ctx, ep, "/a/", clientv3.GetPrefixRangeEnd("/a/"), 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := cli.HashKVRange(ctx, ep, "/b/", clientv3.GetPrefixRangeEnd("/b/"), 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Count != 3 || a.Hash != b.Hash {
+		t.Fatalf("expected equal hashes of 3 keys, got %+v and %+v", a, b)
+	}
+
+	// hashing at an older revision must not see later writes
+	rev := a.HashRevision
+	if _, err = cli.Put(ctx, "/b/x", "changed"); err != nil {
+		t.Fatal(err)
+	}
+	b, err = cli.HashKVRange(ctx, ep, "/b/", clientv3.GetPrefixRangeEnd("/b/"), 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Hash == b.Hash {
+		t.Fatalf("expected hashes to differ after update, got %d", a.Hash)
+	}
+	b, err = cli.HashKVRange(ctx, ep, "/b/", clientv3.GetPrefixRangeEnd("/b/"), rev, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Hash != b.Hash || b.HashRevision != rev {
+		t.Fatalf("expected hash %d at revision %d, got %+v", a.Hash, rev, b)
+	}
+}
+
+func TestMaintenanceHotKeys(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1, ExperimentalHotKeysSampleRate: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		if _, err := cli.Put(ctx, "/hot/a", "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := cli.Put(ctx, "/hot/b", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Put(ctx, "cold", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Txn(ctx).If(clientv3.Compare(clientv3.Version("cold"), ">", 0)).Then(clientv3.OpGet("/hot/", clientv3.WithPrefix())).Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := cli.HotKeys(ctx, clus.Members[0].GRPCURL, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []*pb.HotKey{
+		{Key: []byte("/hot/"), RangeEnd: []byte("/hot0"), Count: 6},
+		{Key: []byte("/hot/a"), Count: 5},
+	}, resp.Writes)
+	assert.Equal(t, []*pb.HotKey{
+		{Key: []byte("/hot/"), RangeEnd: []byte("/hot0"), Count: 1},
+		{Key: []byte("cold"), Count: 1},
+	}, resp.Reads)
+	assert.InDelta(t, 1.0, resp.SampleRate, 0)
+}
+
+func TestMaintenanceHotKeysNotEnabled(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	_, err := clus.RandClient().HotKeys(context.Background(), clus.Members[0].GRPCURL, 0)
+	require.ErrorIs(t, err, rpctypes.ErrHotKeysNotEnabled)
+}
+
 // TestCompactionHash tests compaction hash
 // TODO: Change this to fuzz test
 func TestCompactionHash(t *testing.T) {
@@ -152,6 +250,170 @@ func TestMaintenanceMoveLeader(t *testing.T) {
 	}
 }
 
+// TestMaintenanceDrain ensures that a draining member gives away its
+// leadership and rejects new watch streams until drain is canceled.
+func TestMaintenanceDrain(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	leadIdx := clus.WaitLeader(t)
+	cli := clus.Client(leadIdx)
+	_, err := cli.Drain(context.Background(), clus.Members[leadIdx].GRPCURL)
+	require.NoError(t, err)
+	require.True(t, clus.Members[leadIdx].Server.IsDraining())
+
+	newLeadIdx := clus.WaitLeader(t)
+	require.NotEqual(t, leadIdx, newLeadIdx, "draining member should give away its leadership")
+
+	wc := integration2.ToGRPC(cli).Watch
+	ws, err := wc.Watch(context.Background())
+	require.NoError(t, err)
+	_, err = ws.Recv()
+	require.Equal(t, rpctypes.ErrDraining, rpctypes.Error(err))
+
+	_, err = cli.Undrain(context.Background(), clus.Members[leadIdx].GRPCURL)
+	require.NoError(t, err)
+	require.False(t, clus.Members[leadIdx].Server.IsDraining())
+
+	ws, err = wc.Watch(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, ws.Send(&pb.WatchRequest{RequestUnion: &pb.WatchRequest_CreateRequest{
+		CreateRequest: &pb.WatchCreateRequest{Key: []byte("foo")}}}))
+	resp, err := ws.Recv()
+	require.NoError(t, err)
+	require.True(t, resp.Created)
+}
+
+func TestMaintenanceRotateEncryptionKey(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	kms, err := encryption.NewLocalKMS(bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3, EncryptionKMS: kms})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	_, err = cli.Put(context.Background(), "foo", "bar")
+	require.NoError(t, err)
+	for _, m := range clus.Members {
+		resp, rerr := cli.RotateEncryptionKey(context.Background(), m.GRPCURL)
+		require.NoError(t, rerr)
+		require.Equal(t, uint64(2), resp.KeyId)
+	}
+	_, err = cli.Put(context.Background(), "foo", "baz")
+	require.NoError(t, err)
+
+	// records encrypted with both keys remain readable after a restart
+	m := clus.Members[0]
+	m.Stop(t)
+	require.NoError(t, m.Restart(t))
+	clus.WaitLeader(t)
+	c, err := integration2.NewClientV3(m)
+	require.NoError(t, err)
+	defer c.Close()
+	mcli := integration2.ToGRPC(c).KV
+	for rev, want := range map[int64]string{2: "bar", 3: "baz"} {
+		resp, rerr := mcli.Range(context.Background(), &pb.RangeRequest{Key: []byte("foo"), Revision: rev, Serializable: true})
+		require.NoError(t, rerr)
+		require.Len(t, resp.Kvs, 1)
+		require.Equal(t, want, string(resp.Kvs[0].Value))
+	}
+
+	// members agree on the hash of the plain records
+	var hashes []uint32
+	for _, m := range clus.Members {
+		resp, herr := cli.HashKV(context.Background(), m.GRPCURL, 0)
+		require.NoError(t, herr)
+		hashes = append(hashes, resp.Hash)
+	}
+	require.Equal(t, hashes[0], hashes[1])
+	require.Equal(t, hashes[0], hashes[2])
+}
+
+func TestMaintenanceRotateEncryptionKeyNotEnabled(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	_, err := clus.RandClient().RotateEncryptionKey(context.Background(), clus.Members[0].GRPCURL)
+	require.Equal(t, rpctypes.ErrEncryptionNotEnabled, err)
+}
+
+func TestMaintenanceClusterEvents(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ep := clus.Members[0].GRPCURL
+	memberID := uint64(clus.Members[0].Server.MemberID())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := cli.ClusterEvents(ctx, ep, true)
+	require.NoError(t, err)
+	defer stream.Close()
+
+	// the leader election happened before the subscription
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	recent := resp.Events
+	require.Contains(t, eventTypes(recent), pb.ClusterEvent_LEADER_CHANGED)
+
+	_, err = cli.Put(context.Background(), "foo", "bar")
+	require.NoError(t, err)
+	_, err = cli.Compact(context.Background(), 2, clientv3.WithCompactPhysical())
+	require.NoError(t, err)
+	_, err = cli.Defragment(context.Background(), ep)
+	require.NoError(t, err)
+	mc := integration2.ToGRPC(cli).Maintenance
+	for _, action := range []pb.AlarmRequest_AlarmAction{pb.AlarmRequest_ACTIVATE, pb.AlarmRequest_ACTIVATE, pb.AlarmRequest_DEACTIVATE} {
+		_, err = mc.Alarm(context.Background(), &pb.AlarmRequest{Action: action, MemberID: memberID, Alarm: pb.AlarmType_NOSPACE})
+		require.NoError(t, err)
+	}
+
+	want := []*pb.ClusterEvent{
+		{Type: pb.ClusterEvent_COMPACTED, MemberId: memberID, CompactRevision: 2},
+		{Type: pb.ClusterEvent_DEFRAGMENTED, MemberId: memberID},
+		// activating the active alarm again raises nothing
+		{Type: pb.ClusterEvent_ALARM_RAISED, MemberId: memberID, Alarm: pb.AlarmType_NOSPACE},
+		{Type: pb.ClusterEvent_ALARM_CLEARED, MemberId: memberID, Alarm: pb.AlarmType_NOSPACE},
+	}
+	var got []*pb.ClusterEvent
+	for range want {
+		resp, err = stream.Recv()
+		require.NoError(t, err)
+		require.Len(t, resp.Events, 1)
+		ev := resp.Events[0]
+		require.NotZero(t, ev.Time)
+		ev.Time = 0
+		got = append(got, ev)
+	}
+	// the compaction is published once it's done, concurrently with the
+	// defragmentation
+	require.ElementsMatch(t, want, got)
+
+	// without watching, the stream ends after the latest events
+	stream2, err := cli.ClusterEvents(context.Background(), ep, false)
+	require.NoError(t, err)
+	defer stream2.Close()
+	resp, err = stream2.Recv()
+	require.NoError(t, err)
+	require.Len(t, resp.Events, len(recent)+len(want))
+	_, err = stream2.Recv()
+	require.Equal(t, io.EOF, err)
+}
+
+func eventTypes(evs []*pb.ClusterEvent) (types []pb.ClusterEvent_EventType) {
+	for _, ev := range evs {
+		types = append(types, ev.Type)
+	}
+	return types
+}
+
 // TestMaintenanceSnapshotCancel ensures that context cancel
 // before snapshot reading returns corresponding context errors.
 func TestMaintenanceSnapshotCancel(t *testing.T) {
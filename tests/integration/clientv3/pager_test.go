@@ -0,0 +1,101 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+// TestListPager ensures ListPager pages through a prefix at a single
+// revision and releases the lease pinning it.
+func TestListPager(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx := context.TODO()
+
+	var want []string
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("foo/%02d", i)
+		_, err := cli.Put(ctx, key, "bar")
+		require.NoError(t, err)
+		want = append(want, key)
+	}
+	_, err := cli.Put(ctx, "fop", "bar")
+	require.NoError(t, err)
+
+	pager := clientv3.NewListPager(cli)
+	pager.PageSize = 3
+	pager.Lease = cli
+
+	var keys []string
+	pages := 0
+	rev, err := pager.EachPage(ctx, "foo/", func(resp *clientv3.GetResponse) error {
+		pages++
+		// writes in between pages are not seen
+		_, perr := cli.Put(ctx, fmt.Sprintf("foo/%02d", 10+pages), "bar")
+		require.NoError(t, perr)
+		for _, kv := range resp.Kvs {
+			keys = append(keys, string(kv.Key))
+		}
+		return nil
+	}, clientv3.WithPrefix())
+	require.NoError(t, err)
+	require.Equal(t, 4, pages)
+	require.Equal(t, want, keys)
+	require.Equal(t, int64(12), rev)
+
+	leases, err := cli.Leases(ctx)
+	require.NoError(t, err)
+	require.Empty(t, leases.Leases)
+
+	kvs, grev, err := pager.GetAll(ctx, "foo/", clientv3.WithPrefix(), clientv3.WithRev(rev))
+	require.NoError(t, err)
+	require.Equal(t, rev, grev)
+	require.Len(t, kvs, len(want))
+
+	errStop := errors.New("stop")
+	n := 0
+	_, err = pager.EachListItem(ctx, "foo/", func(*mvccpb.KeyValue) error {
+		if n++; n == 5 {
+			return errStop
+		}
+		return nil
+	}, clientv3.WithPrefix())
+	require.ErrorIs(t, err, errStop)
+	require.Equal(t, 5, n)
+
+	for _, opt := range []clientv3.OpOption{
+		clientv3.WithLimit(1),
+		clientv3.WithCountOnly(),
+		clientv3.WithSort(clientv3.SortByKey, clientv3.SortDescend),
+		clientv3.WithSort(clientv3.SortByModRevision, clientv3.SortAscend),
+	} {
+		_, err = pager.EachPage(ctx, "foo/", func(*clientv3.GetResponse) error { return nil }, clientv3.WithPrefix(), opt)
+		require.ErrorIs(t, err, clientv3.ErrListPagerOption)
+	}
+}
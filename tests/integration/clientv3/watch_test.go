@@ -532,6 +532,49 @@ func TestWatchCompactRevision(t *testing.T) {
 	}
 }
 
+// TestWatchCompactRevisionResync ensures a watcher canceled due to compaction
+// can resynchronize from the ResyncRevision hint without missing events.
+func TestWatchCompactRevisionResync(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	for i := 0; i < 5; i++ {
+		if _, err := cli.Put(context.TODO(), "foo", fmt.Sprint(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := cli.Compact(context.TODO(), 4); err != nil {
+		t.Fatal(err)
+	}
+
+	wresp := <-cli.Watch(context.Background(), "foo", clientv3.WithRev(2))
+	if wresp.Err() != rpctypes.ErrCompacted {
+		t.Fatalf("wresp.Err() expected %v, but got %v", rpctypes.ErrCompacted, wresp.Err())
+	}
+	if wresp.CompactRevision != 4 || wresp.ResyncRevision != 4 {
+		t.Fatalf("expected compact and resync revisions 4, got %d and %d", wresp.CompactRevision, wresp.ResyncRevision)
+	}
+
+	// get the key at the resync revision, then watch from the next one
+	gresp, err := cli.Get(context.TODO(), "foo", clientv3.WithRev(wresp.ResyncRevision))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := string(gresp.Kvs[0].Value); v != "2" {
+		t.Fatalf("expected value 2 at the resync revision, got %s", v)
+	}
+	wresp = <-cli.Watch(context.Background(), "foo", clientv3.WithRev(wresp.ResyncRevision+1))
+	if wresp.Err() != nil {
+		t.Fatal(wresp.Err())
+	}
+	if len(wresp.Events) != 2 || string(wresp.Events[0].Kv.Value) != "3" {
+		t.Fatalf("expected the events of values 3 and 4, got %+v", wresp.Events)
+	}
+}
+
 func TestWatchWithProgressNotify(t *testing.T)        { testWatchWithProgressNotify(t, true) }
 func TestWatchWithProgressNotifyNoEvent(t *testing.T) { testWatchWithProgressNotify(t, false) }
 
@@ -615,6 +658,55 @@ func TestConfigurableWatchProgressNotifyInterval(t *testing.T) {
 	}
 }
 
+// TestWatchProgressNotifyIntervalPerWatcher ensures a watcher requesting a
+// progress notify interval is sent bookmarks at that interval, while other
+// watchers on the same stream are not.
+func TestWatchProgressNotifyIntervalPerWatcher(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	oldpi := v3rpc.GetProgressReportInterval()
+	v3rpc.SetProgressReportInterval(time.Minute)
+	defer func() { v3rpc.SetProgressReportInterval(oldpi) }()
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	if _, err := cli.Put(context.TODO(), "foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interval := 200 * time.Millisecond
+	bch := cli.Watch(ctx, "foo", clientv3.WithProgressNotifyInterval(interval))
+	pch := cli.Watch(ctx, "foo", clientv3.WithProgressNotify())
+
+	// we expect bookmarks every interval, but for CPU-starved situation it
+	// may take longer. So we use 1 second here for timeout.
+	timeout := time.Second
+	for i := 0; i < 3; i++ {
+		select {
+		case resp := <-bch:
+			bm := resp.Bookmark()
+			if bm == nil {
+				t.Fatalf("expected a bookmark, got %+v", resp)
+			}
+			if bm.Revision != 2 {
+				t.Fatalf("expected bookmark at revision 2, got %d", bm.Revision)
+			}
+		case <-time.After(timeout):
+			t.Fatalf("timed out waiting for bookmark in %v", timeout)
+		}
+	}
+
+	select {
+	case resp := <-pch:
+		t.Fatalf("unexpected watch response %+v", resp)
+	default:
+	}
+}
+
 func TestWatchRequestProgress(t *testing.T) {
 	if integration2.ThroughProxy {
 		t.Skipf("grpc-proxy does not support WatchProgress yet")
@@ -24,7 +24,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/etcdserver/errors"
 	"go.etcd.io/etcd/server/v3/storage/mvcc/testutil"
 	"go.etcd.io/etcd/tests/v3/framework/integration"
 )
@@ -103,6 +105,84 @@ func TestPeriodicCheckDetectsCorruption(t *testing.T) {
 	assert.Equal(t, []*etcdserverpb.AlarmMember{{Alarm: etcdserverpb.AlarmType_CORRUPT, MemberID: uint64(clus.Members[0].ID())}}, alarmResponse.Alarms)
 }
 
+func TestRangeHashCheckQuarantinesCorruptedMember(t *testing.T) {
+	integration.BeforeTest(t)
+
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	cc, err := clus.ClusterClient(t)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		_, err = cc.Put(ctx, testutil.PickKey(int64(i)), fmt.Sprint(i))
+		assert.NoError(t, err, "error on put")
+	}
+
+	clus.Members[0].Stop(t)
+	clus.WaitLeader(t)
+
+	err = testutil.CorruptBBolt(clus.Members[0].BackendPath())
+	assert.NoError(t, err)
+
+	err = clus.Members[0].Restart(t)
+	assert.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	leader := clus.WaitLeader(t)
+
+	cli, err := clus.NewClientV3(0)
+	require.NoError(t, err)
+	defer cli.Close()
+	wc := integration.ToGRPC(cli).Watch
+	ws, err := wc.Watch(ctx)
+	require.NoError(t, err)
+	require.NoError(t, ws.Send(&etcdserverpb.WatchRequest{RequestUnion: &etcdserverpb.WatchRequest_CreateRequest{
+		CreateRequest: &etcdserverpb.WatchCreateRequest{Key: []byte("foo")}}}))
+	wresp, err := ws.Recv()
+	require.NoError(t, err)
+	require.True(t, wresp.Created)
+
+	// the first check only picks the revision to compare at
+	for i := 0; i < 2; i++ {
+		err = clus.Members[leader].Server.CorruptionChecker().RangeHashCheck()
+		assert.NoError(t, err, "error on range hash check")
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	alarmResponse, err := cc.AlarmList(ctx)
+	assert.NoError(t, err, "error on alarm list")
+	assert.Equal(t, []*etcdserverpb.AlarmMember{{Alarm: etcdserverpb.AlarmType_CORRUPT_RANGE, MemberID: uint64(clus.Members[0].ID()), Key: []byte{0}, RangeEnd: []byte{0}}}, alarmResponse.Alarms)
+
+	assert.True(t, clus.Members[0].Server.IsQuarantined())
+	_, err = clus.Members[0].Server.Range(ctx, &etcdserverpb.RangeRequest{Key: []byte("foo"), Serializable: true})
+	assert.ErrorIs(t, err, errors.ErrQuarantined)
+	assert.False(t, clus.Members[1].Server.IsQuarantined())
+	_, err = clus.Members[1].Server.Range(ctx, &etcdserverpb.RangeRequest{Key: []byte("foo"), Serializable: true})
+	assert.NoError(t, err)
+
+	// the ranges of write txns are not served either
+	rangeTxn := &etcdserverpb.TxnRequest{Success: []*etcdserverpb.RequestOp{
+		{Request: &etcdserverpb.RequestOp_RequestPut{RequestPut: &etcdserverpb.PutRequest{Key: []byte("foo"), Value: []byte("bar")}}},
+		{Request: &etcdserverpb.RequestOp_RequestRange{RequestRange: &etcdserverpb.RangeRequest{Key: []byte("foo")}}},
+	}}
+	_, err = clus.Members[0].Server.Txn(ctx, rangeTxn)
+	assert.ErrorIs(t, err, errors.ErrQuarantined)
+
+	// the existing watch stream is canceled, and new ones are rejected
+	_, err = ws.Recv()
+	assert.Equal(t, rpctypes.ErrQuarantined, rpctypes.Error(err))
+	ws, err = wc.Watch(ctx)
+	require.NoError(t, err)
+	_, err = ws.Recv()
+	assert.Equal(t, rpctypes.ErrQuarantined, rpctypes.Error(err))
+
+	// the cluster keeps applying writes
+	_, err = cc.Put(ctx, "foo", "bar")
+	assert.NoError(t, err)
+}
+
 func TestCompactHashCheck(t *testing.T) {
 	integration.BeforeTest(t)
 
@@ -22,19 +22,29 @@ package embed_test
 import (
 	"context"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"go.etcd.io/etcd/client/pkg/v3/testutil"
 	"go.etcd.io/etcd/client/pkg/v3/transport"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/server/v3/embed"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/etcdhttp"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3admission"
 	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
 	"go.etcd.io/etcd/tests/v3/framework/testutils"
 )
@@ -228,3 +238,222 @@ func TestEmbedEtcdAutoCompactionRetentionRetained(t *testing.T) {
 	assert.Equal(t, durationToCompare, autoCompactionRetention)
 	e.Close()
 }
+
+func TestEmbedEtcdUserInterceptorsAndHandlers(t *testing.T) {
+	cfg := embed.NewConfig()
+	urls := newEmbedURLs(false, 2)
+	setupEmbedCfg(cfg, []url.URL{urls[0]}, []url.URL{urls[1]})
+	cfg.Dir = filepath.Join(t.TempDir(), "embed-etcd")
+
+	var unaryCalls, streamCalls atomic.Int32
+	cfg.GRPCUnaryInterceptors = []grpc.UnaryServerInterceptor{
+		func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			unaryCalls.Add(1)
+			return handler(ctx, req)
+		},
+	}
+	cfg.GRPCStreamInterceptors = []grpc.StreamServerInterceptor{
+		func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			streamCalls.Add(1)
+			return handler(srv, ss)
+		},
+	}
+	cfg.PeerUserHandlers = map[string]http.Handler{
+		"/user": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "peer user handler") }),
+	}
+
+	e, err := embed.StartEtcd(cfg)
+	require.NoError(t, err)
+	defer e.Close()
+	<-e.Server.ReadyNotify()
+
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: []string{urls[0].String()}})
+	require.NoError(t, err)
+	defer cli.Close()
+
+	_, err = cli.Put(context.Background(), "foo", "bar")
+	require.NoError(t, err)
+	assert.Positive(t, unaryCalls.Load())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wch := cli.Watch(ctx, "foo", clientv3.WithRev(1))
+	<-wch
+	assert.Positive(t, streamCalls.Load())
+
+	// peer URLs are unix sockets named after the URL host
+	hc := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", urls[1].Host)
+		},
+	}}
+	resp, err := hc.Get("http://localhost/user")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "peer user handler", string(body))
+}
+
+func TestEmbedEtcdLifecycleHooks(t *testing.T) {
+	cfg := embed.NewConfig()
+	urls := newEmbedURLs(false, 2)
+	setupEmbedCfg(cfg, []url.URL{urls[0]}, []url.URL{urls[1]})
+	cfg.Dir = filepath.Join(t.TempDir(), "embed-etcd")
+
+	readyc, leaderc := make(chan struct{}, 1), make(chan struct{}, 1)
+	compactedc, defragc := make(chan int64, 1), make(chan error, 1)
+	cfg.LifecycleHooks.OnReady = func() { readyc <- struct{}{} }
+	cfg.LifecycleHooks.OnLeaderElected = func() { leaderc <- struct{}{} }
+	cfg.LifecycleHooks.OnCompacted = func(rev int64) { compactedc <- rev }
+	cfg.LifecycleHooks.OnDefragFinished = func(err error) { defragc <- err }
+
+	e, err := embed.StartEtcd(cfg)
+	require.NoError(t, err)
+	defer e.Close()
+
+	waitHook := func(name string, c <-chan struct{}) {
+		select {
+		case <-c:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timed out waiting for %s hook", name)
+		}
+	}
+	waitHook("OnLeaderElected", leaderc)
+	waitHook("OnReady", readyc)
+
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: []string{urls[0].String()}})
+	require.NoError(t, err)
+	defer cli.Close()
+
+	resp, err := cli.Put(context.Background(), "foo", "bar")
+	require.NoError(t, err)
+	_, err = cli.Compact(context.Background(), resp.Header.Revision)
+	require.NoError(t, err)
+	select {
+	case rev := <-compactedc:
+		assert.Equal(t, resp.Header.Revision, rev)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for OnCompacted hook")
+	}
+
+	_, err = cli.Defragment(context.Background(), urls[0].String())
+	require.NoError(t, err)
+	select {
+	case err = <-defragc:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for OnDefragFinished hook")
+	}
+}
+
+func TestEmbedEtcdAdmission(t *testing.T) {
+	// the webhook denies deleting the keys under /protected/
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	v3admission.RegisterWebhookServer(srv, v3admission.ControllerFunc(func(_ context.Context, ops []v3admission.Op) error {
+		for _, op := range ops {
+			if op.Type == v3admission.OpDeleteRange && strings.HasPrefix(string(op.Key), "/protected/") {
+				return fmt.Errorf("cannot delete %q", op.Key)
+			}
+		}
+		return nil
+	}))
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	cfg := embed.NewConfig()
+	urls := newEmbedURLs(false, 2)
+	setupEmbedCfg(cfg, []url.URL{urls[0]}, []url.URL{urls[1]})
+	cfg.Dir = filepath.Join(t.TempDir(), "embed-etcd")
+	rules, err := v3admission.Rules(v3admission.Rule{Pattern: "/config/*", Validate: v3admission.JSONObject("name")})
+	require.NoError(t, err)
+	cfg.AdmissionControllers = []v3admission.Controller{rules}
+	cfg.ExperimentalAdmissionWebhookURL = "http://" + lis.Addr().String()
+
+	e, err := embed.StartEtcd(cfg)
+	require.NoError(t, err)
+	defer e.Close()
+	select {
+	case <-e.Server.ReadyNotify():
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the server to be ready")
+	}
+
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: []string{urls[0].String()}})
+	require.NoError(t, err)
+	defer cli.Close()
+	ctx := context.Background()
+
+	_, err = cli.Put(ctx, "/config/a", `{"name":"a"}`)
+	require.NoError(t, err)
+	_, err = cli.Put(ctx, "/other", "not json")
+	require.NoError(t, err)
+
+	_, err = cli.Put(ctx, "/config/a", `{"size":1}`)
+	require.ErrorContains(t, err, "etcdserver: request denied by admission control")
+	require.ErrorContains(t, err, `missing required field "name"`)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// the mutations of both branches are admitted before the comparisons are evaluated
+	_, err = cli.Txn(ctx).
+		If(clientv3.Compare(clientv3.Version("/config/a"), ">", 0)).
+		Then(clientv3.OpGet("/config/a")).
+		Else(clientv3.OpPut("/config/b", "not json")).
+		Commit()
+	require.ErrorContains(t, err, "etcdserver: request denied by admission control")
+
+	_, err = cli.Put(ctx, "/protected/a", "v")
+	require.NoError(t, err)
+	_, err = cli.Delete(ctx, "/protected/", clientv3.WithPrefix())
+	require.ErrorContains(t, err, `cannot delete "/protected/"`)
+	_, err = cli.Delete(ctx, "/other")
+	require.NoError(t, err)
+
+	gresp, err := cli.Get(ctx, "/", clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	require.NoError(t, err)
+	var keys []string
+	for _, kv := range gresp.Kvs {
+		keys = append(keys, string(kv.Key))
+	}
+	assert.Equal(t, []string{"/config/a", "/protected/a"}, keys)
+
+	// requests are rejected while the webhook is unavailable
+	srv.Stop()
+	_, err = cli.Put(ctx, "/other", "v")
+	require.ErrorContains(t, err, "admission webhook failed")
+}
+
+// TestEmbedEtcdInflightRequests ensures the inflight requests are only listed
+// on the metrics URLs.
+func TestEmbedEtcdInflightRequests(t *testing.T) {
+	cfg := embed.NewConfig()
+	urls := newEmbedURLs(false, 3)
+	setupEmbedCfg(cfg, []url.URL{urls[0]}, []url.URL{urls[1]})
+	cfg.Dir = filepath.Join(t.TempDir(), "embed-etcd")
+	cfg.ExperimentalInflightRequests = true
+	cfg.ListenMetricsUrls = []url.URL{urls[2]}
+
+	e, err := embed.StartEtcd(cfg)
+	require.NoError(t, err)
+	defer e.Close()
+	<-e.Server.ReadyNotify()
+
+	// the URLs are unix sockets named after the URL host
+	get := func(u url.URL) int {
+		hc := &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", u.Host)
+			},
+		}}
+		resp, err := hc.Get("http://localhost" + etcdhttp.PathInflight)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusNotFound, get(urls[0]))
+	assert.Equal(t, http.StatusOK, get(urls[2]))
+}
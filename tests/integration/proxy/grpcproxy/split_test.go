@@ -0,0 +1,102 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcproxy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/proxy/grpcproxy"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+func TestSplitRoutesToLeaderAndZoneFollower(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	lead := clus.WaitLeader(t)
+	follower := clus.Members[(lead+1)%3]
+	newClient := func(eps []string) (*clientv3.Client, error) {
+		// the members serve gRPC on a dedicated URL
+		for _, m := range clus.Members {
+			if m.ClientURLs[0].String() == eps[0] {
+				eps = []string{m.GRPCURL}
+			}
+		}
+		return integration2.NewClient(t, clientv3.Config{Endpoints: eps, DialTimeout: 5 * time.Second})
+	}
+	c, err := newClient([]string{clus.Members[lead].GRPCURL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	split := grpcproxy.NewSplit(ctx, zaptest.NewLogger(t), c, grpcproxy.SplitConfig{
+		Zone:        "zone-b",
+		MemberZones: map[string]string{follower.Name: "zone-b"},
+		NewClient:   newClient,
+	})
+
+	leaderID, readerID := split.Targets()
+	if leaderID != uint64(clus.Members[lead].ID()) {
+		t.Errorf("leader = %x, want %x", leaderID, uint64(clus.Members[lead].ID()))
+	}
+	if readerID != uint64(follower.ID()) {
+		t.Errorf("reader = %x, want the follower in the zone %x", readerID, uint64(follower.ID()))
+	}
+
+	kv := split.KV()
+	if _, err = kv.Put(ctx, "foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := kv.Get(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 1 || resp.Header.MemberId != leaderID {
+		t.Errorf("linearizable read served by %x, want the leader %x", resp.Header.MemberId, leaderID)
+	}
+	// the follower may not have applied the put yet
+	for i := 0; ; i++ {
+		resp, err = kv.Get(ctx, "foo", clientv3.WithSerializable())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Header.MemberId != readerID {
+			t.Fatalf("serializable read served by %x, want the follower %x", resp.Header.MemberId, readerID)
+		}
+		if len(resp.Kvs) == 1 {
+			break
+		}
+		if i == 10 {
+			t.Fatal("follower did not apply the put")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	wch := split.Watcher().Watch(ctx, "foo", clientv3.WithRev(1))
+	wresp := <-wch
+	if len(wresp.Events) != 1 || wresp.Header.MemberId != readerID {
+		t.Errorf("watch served by %x with %d events, want the follower %x", wresp.Header.MemberId, len(wresp.Events), readerID)
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	clientsnapshot "go.etcd.io/etcd/client/v3/snapshot"
+	"go.etcd.io/etcd/etcdutl/v3/snapshot"
+	"go.etcd.io/etcd/server/v3/embed"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+// TestSnapshotV3Incremental tests a snapshot assembled from a full snapshot
+// and incremental snapshots restores the keyspace and the leases of the
+// server at the revision of the last increment.
+func TestSnapshotV3Incremental(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	lg := zaptest.NewLogger(t)
+	dir := t.TempDir()
+	sp := snapshot.NewV3(lg)
+
+	b := saveIncrementalBackups(ctx, t, dir)
+
+	err := sp.Assemble(snapshot.AssembleConfig{
+		SnapshotPath:   b.dbPath,
+		IncrementPaths: b.incPaths[1:],
+		OutputPath:     filepath.Join(dir, "assembled.db"),
+	})
+	require.ErrorContains(t, err, fmt.Sprintf("starts from revision %d, expected %d", b.revs[1], b.revs[0]))
+
+	assembledPath := filepath.Join(dir, "assembled.db")
+	require.NoError(t, sp.Assemble(snapshot.AssembleConfig{
+		SnapshotPath:   b.dbPath,
+		IncrementPaths: b.incPaths,
+		OutputPath:     assembledPath,
+	}))
+	ds, err := sp.Status(assembledPath)
+	require.NoError(t, err)
+	require.Equal(t, b.revs[2], ds.Revision)
+
+	cURLs, _, srvs := restoreCluster(t, 1, assembledPath)
+	defer srvs[0].Close()
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: []string{cURLs[0].String()}})
+	require.NoError(t, err)
+	defer cli.Close()
+
+	got, err := cli.Get(ctx, "", clientv3.WithPrefix())
+	require.NoError(t, err)
+	require.Equal(t, b.revs[2], got.Header.Revision)
+	require.Equal(t, b.kvs, got.Kvs)
+
+	leases, err := cli.Leases(ctx)
+	require.NoError(t, err)
+	require.Len(t, leases.Leases, 1)
+	require.Equal(t, b.leaseID, leases.Leases[0].ID)
+	ttl, err := cli.TimeToLive(ctx, b.leaseID, clientv3.WithAttachedKeys())
+	require.NoError(t, err)
+	require.Equal(t, int64(200), ttl.GrantedTTL)
+	require.Equal(t, [][]byte{[]byte("leased")}, ttl.Keys)
+}
+
+type incrementalBackups struct {
+	dbPath   string
+	incPaths []string
+	// revs are the revisions of the full snapshot and of the increments
+	revs    []int64
+	kvs     []*mvccpb.KeyValue
+	leaseID clientv3.LeaseID
+}
+
+// saveIncrementalBackups saves a full snapshot and two increments of a server
+// changing its keys and leases in between, and returns them with the keys of
+// the server at the revision of the last increment.
+func saveIncrementalBackups(ctx context.Context, t *testing.T, dir string) incrementalBackups {
+	lg := zaptest.NewLogger(t)
+	urls := newEmbedURLs(t, 2)
+	cfg := integration2.NewEmbedConfig(t, "default")
+	cfg.ClusterState = "new"
+	cfg.ListenClientUrls, cfg.AdvertiseClientUrls = urls[:1], urls[:1]
+	cfg.ListenPeerUrls, cfg.AdvertisePeerUrls = urls[1:], urls[1:]
+	cfg.InitialCluster = fmt.Sprintf("%s=%s", cfg.Name, urls[1].String())
+	srv, err := embed.StartEtcd(cfg)
+	require.NoError(t, err)
+	defer srv.Close()
+	select {
+	case <-srv.Server.ReadyNotify():
+	case <-time.After(3 * time.Second):
+		t.Fatalf("failed to start embed.Etcd for creating snapshots")
+	}
+
+	ccfg := clientv3.Config{Endpoints: []string{cfg.AdvertiseClientUrls[0].String()}}
+	cli, err := integration2.NewClient(t, ccfg)
+	require.NoError(t, err)
+	defer cli.Close()
+
+	for i := 0; i < 5; i++ {
+		_, err = cli.Put(ctx, fmt.Sprintf("foo%d", i), "bar")
+		require.NoError(t, err)
+	}
+	lresp, err := cli.Grant(ctx, 100)
+	require.NoError(t, err)
+	_, err = cli.Put(ctx, "leased", "bar", clientv3.WithLease(lresp.ID))
+	require.NoError(t, err)
+
+	dbPath := filepath.Join(dir, "snapshot.db")
+	sp := snapshot.NewV3(lg)
+	_, err = sp.Save(ctx, ccfg, dbPath)
+	require.NoError(t, err)
+	ds, err := sp.Status(dbPath)
+	require.NoError(t, err)
+
+	_, err = cli.Put(ctx, "foo0", "baz")
+	require.NoError(t, err)
+	_, err = cli.Delete(ctx, "foo1")
+	require.NoError(t, err)
+	_, err = cli.Revoke(ctx, lresp.ID)
+	require.NoError(t, err)
+
+	inc1Path := filepath.Join(dir, "increment1.inc")
+	rev1, err := clientsnapshot.SaveIncremental(ctx, lg, ccfg, ds.Revision, inc1Path)
+	require.NoError(t, err)
+
+	_, err = cli.Txn(ctx).Then(
+		clientv3.OpPut("txn1", "bar"),
+		clientv3.OpPut("txn2", "bar"),
+		clientv3.OpDelete("foo2"),
+	).Commit()
+	require.NoError(t, err)
+	_, err = cli.Delete(ctx, "foo3", clientv3.WithRange("foo5"))
+	require.NoError(t, err)
+	lresp, err = cli.Grant(ctx, 200)
+	require.NoError(t, err)
+	_, err = cli.Put(ctx, "leased", "baz", clientv3.WithLease(lresp.ID))
+	require.NoError(t, err)
+
+	inc2Path := filepath.Join(dir, "increment2.inc")
+	rev2, err := clientsnapshot.SaveIncremental(ctx, lg, ccfg, rev1, inc2Path)
+	require.NoError(t, err)
+
+	resp, err := cli.Get(ctx, "", clientv3.WithPrefix())
+	require.NoError(t, err)
+	require.Equal(t, rev2, resp.Header.Revision)
+	return incrementalBackups{
+		dbPath:   dbPath,
+		incPaths: []string{inc1Path, inc2Path},
+		revs:     []int64{ds.Revision, rev1, rev2},
+		kvs:      resp.Kvs,
+		leaseID:  lresp.ID,
+	}
+}
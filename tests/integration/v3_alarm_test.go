@@ -16,6 +16,7 @@ package integration
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"sync"
@@ -26,6 +27,7 @@ import (
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/pkg/v3/traceutil"
 	"go.etcd.io/etcd/server/v3/lease/leasepb"
 	"go.etcd.io/etcd/server/v3/storage/backend"
@@ -354,3 +356,136 @@ func TestV3CorruptAlarmWithLeaseCorrupted(t *testing.T) {
 		}
 	}
 }
+
+// TestV3ReadOnlyAlarm ensures a read-only cluster rejects mutations while still
+// serving reads and watches, until it's made read-write again.
+func TestV3ReadOnlyAlarm(t *testing.T) {
+	integration.BeforeTest(t)
+
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+	cli := clus.RandClient()
+	ctx := context.Background()
+
+	if _, err := cli.Put(ctx, "foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	lresp, err := cli.Grant(ctx, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wch := cli.Watch(ctx, "foo", clientv3.WithRev(1))
+
+	if _, err = cli.SetReadOnly(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+
+	mutations := map[string]func() error{
+		"put": func() error {
+			_, err := cli.Put(ctx, "foo", "baz")
+			return err
+		},
+		"delete": func() error {
+			_, err := cli.Delete(ctx, "foo")
+			return err
+		},
+		"txn": func() error {
+			_, err := cli.Txn(ctx).Then(clientv3.OpPut("foo", "baz")).Commit()
+			return err
+		},
+		"compact": func() error {
+			_, err := cli.Compact(ctx, 1)
+			return err
+		},
+		"lease grant": func() error {
+			_, err := cli.Grant(ctx, 60)
+			return err
+		},
+		"lease revoke": func() error {
+			_, err := cli.Revoke(ctx, lresp.ID)
+			return err
+		},
+	}
+	for name, f := range mutations {
+		if err = f(); !errors.Is(err, rpctypes.ErrReadOnly) {
+			t.Errorf("%s: expected %v, got %v", name, rpctypes.ErrReadOnly, err)
+		}
+	}
+
+	gresp, err := cli.Get(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gresp.Kvs) != 1 || string(gresp.Kvs[0].Value) != "bar" {
+		t.Fatalf("unexpected get response %+v", gresp)
+	}
+	if _, err = cli.Txn(ctx).Then(clientv3.OpGet("foo")).Commit(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case wresp := <-wch:
+		if len(wresp.Events) != 1 || string(wresp.Events[0].Kv.Value) != "bar" {
+			t.Fatalf("unexpected watch response %+v", wresp)
+		}
+	case <-time.After(integration.RequestWaitTimeout):
+		t.Fatal("timed out waiting for watch response")
+	}
+
+	// disarming all the alarms leaves the cluster read-only
+	if _, err = cli.AlarmDisarm(ctx, &clientv3.AlarmMember{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cli.Put(ctx, "foo", "baz"); !errors.Is(err, rpctypes.ErrReadOnly) {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrReadOnly, err)
+	}
+
+	if _, err = cli.SetReadOnly(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cli.Put(ctx, "foo", "baz"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestV3SlowDiskAlarm tests that a member whose disk probes are slow raises a
+// SLOWDISK alarm for itself and fences itself, while still serving writes.
+func TestV3SlowDiskAlarm(t *testing.T) {
+	integration.BeforeTest(t)
+
+	clus := integration.NewCluster(t, &integration.ClusterConfig{
+		Size:                           1,
+		ExperimentalDiskProbeInterval:  10 * time.Millisecond,
+		ExperimentalDiskProbeThreshold: time.Nanosecond,
+		ExperimentalDiskProbeFailures:  2,
+		ExperimentalDiskProbeSelfFence: true,
+	})
+	defer clus.Terminate(t)
+	cli := clus.RandClient()
+	ctx := context.Background()
+
+	m := clus.Members[0]
+	deadline := time.Now().Add(integration.RequestWaitTimeout)
+	for {
+		aresp, err := cli.AlarmList(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(aresp.Alarms) == 1 && aresp.Alarms[0].Alarm == pb.AlarmType_SLOWDISK && aresp.Alarms[0].MemberID == uint64(m.Server.MemberID()) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for SLOWDISK alarm, got %+v", aresp.Alarms)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for !m.Server.IsDraining() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for member to fence itself")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := cli.Put(ctx, "foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+}
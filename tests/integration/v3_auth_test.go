@@ -21,6 +21,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/require"
+
 	"go.etcd.io/etcd/api/v3/authpb"
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
@@ -473,3 +475,141 @@ func TestV3AuthWatchErrorAndWatchId0(t *testing.T) {
 
 	<-watchEndCh
 }
+
+// TestV3AuthCheckPermission ensures a user can check its own permissions, and
+// only root those of other users.
+func TestV3AuthCheckPermission(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	users := []user{
+		{
+			name:     "user1",
+			password: "user1-123",
+			role:     "role1",
+			key:      "k1",
+			end:      "k2",
+		},
+		{
+			name:     "user2",
+			password: "user2-123",
+			role:     "role2",
+			key:      "k2",
+			end:      "k3",
+		},
+	}
+	authSetupUsers(t, integration.ToGRPC(clus.Client(0)).Auth, users)
+
+	authSetupRoot(t, integration.ToGRPC(clus.Client(0)).Auth)
+
+	user1c, cerr := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "user1", Password: "user1-123"})
+	if cerr != nil {
+		t.Fatal(cerr)
+	}
+	defer user1c.Close()
+
+	resp, err := user1c.CheckPermission(context.TODO(), "user1", "k1", "", clientv3.PermissionType(clientv3.PermWrite))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Permitted || len(resp.Roles) != 1 || resp.Roles[0] != "role1" {
+		t.Fatalf("expected put on k1 to be permitted by role1, got %+v", resp)
+	}
+
+	resp, err = user1c.CheckPermission(context.TODO(), "user1", "k1", "k3", clientv3.PermissionType(clientv3.PermRead))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Permitted || len(resp.Roles) != 1 {
+		t.Fatalf("expected get on [k1, k3) to be denied with role1 granting part of it, got %+v", resp)
+	}
+
+	if _, err = user1c.CheckPermission(context.TODO(), "user2", "k2", "", clientv3.PermissionType(clientv3.PermRead)); err != rpctypes.ErrPermissionDenied {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrPermissionDenied, err)
+	}
+
+	rootc, cerr := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "root", Password: "123"})
+	if cerr != nil {
+		t.Fatal(cerr)
+	}
+	defer rootc.Close()
+
+	resp, err = rootc.CheckPermission(context.TODO(), "user2", "k1", "", clientv3.PermissionType(clientv3.PermRead))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Permitted || len(resp.Roles) != 0 {
+		t.Fatalf("expected get on k1 to be denied to user2, got %+v", resp)
+	}
+}
+
+// TestV3AuthWatchReauth ensures watchers failing to be created because the
+// token of their stream is outdated are resumed on a new stream, with a token
+// from the credentials of the credentials provider, and that the watchers
+// created before keep receiving events.
+func TestV3AuthWatchReauth(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	users := []user{
+		{
+			name:     "user1",
+			password: "user1-123",
+			role:     "role1",
+			key:      "k1",
+			end:      "k3",
+		},
+	}
+	authSetupUsers(t, integration.ToGRPC(clus.Client(0)).Auth, users)
+	authSetupRoot(t, integration.ToGRPC(clus.Client(0)).Auth)
+
+	var mu sync.Mutex
+	password := "user1-123"
+	c, err := integration.NewClient(t, clientv3.Config{
+		Endpoints: clus.Client(0).Endpoints(),
+		CredentialsProvider: func(context.Context) (clientv3.Credentials, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return clientv3.Credentials{Username: "user1", Password: password}, nil
+		},
+	})
+	require.NoError(t, err)
+	defer c.Close()
+
+	rootc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "root", Password: "123"})
+	require.NoError(t, err)
+	defer rootc.Close()
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
+	defer cancel()
+
+	wch1 := c.Watch(ctx, "k1", clientv3.WithCreatedNotify())
+	wresp := <-wch1
+	require.NoError(t, wresp.Err())
+
+	// rotate the password, outdating the token of the watch stream
+	_, err = rootc.UserChangePassword(ctx, "user1", "user1-456")
+	require.NoError(t, err)
+	mu.Lock()
+	password = "user1-456"
+	mu.Unlock()
+
+	wch2 := c.Watch(ctx, "k2", clientv3.WithCreatedNotify())
+	wresp = <-wch2
+	require.NoError(t, wresp.Err())
+	require.True(t, wresp.Created)
+
+	for _, tt := range []struct {
+		key string
+		wch clientv3.WatchChan
+	}{{"k1", wch1}, {"k2", wch2}} {
+		_, err = rootc.Put(ctx, tt.key, "val")
+		require.NoError(t, err)
+		wresp = <-tt.wch
+		require.NoError(t, wresp.Err())
+		require.Len(t, wresp.Events, 1)
+		require.Equal(t, tt.key, string(wresp.Events[0].Kv.Key))
+	}
+}
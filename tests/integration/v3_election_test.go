@@ -320,6 +320,112 @@ func TestElectionObserveCompacted(t *testing.T) {
 	}
 }
 
+// TestElectionHandoff ensures that leadership is transferred to the chosen
+// successor even when another campaigner is queued ahead of it.
+func TestElectionHandoff(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+	cli := clus.RandClient()
+
+	newElection := func() *concurrency.Election {
+		s, err := concurrency.NewSession(cli)
+		require.NoError(t, err)
+		t.Cleanup(func() { s.Orphan() })
+		return concurrency.NewElection(s, "test-elect")
+	}
+
+	leader := newElection()
+	require.NoError(t, leader.Campaign(context.TODO(), "leader"))
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
+	defer cancel()
+
+	// queue a campaigner ahead of the successor
+	bystander := newElection()
+	bystanderc := make(chan error, 1)
+	go func() { bystanderc <- bystander.Campaign(ctx, "bystander") }()
+	waitCampaigners(t, cli, "test-elect/", 2)
+
+	successor := newElection()
+	successorc := make(chan error, 1)
+	go func() { successorc <- successor.Campaign(ctx, "successor") }()
+	resp := waitCampaigners(t, cli, "test-elect/", 3)
+	successorKey := string(resp.Kvs[2].Key)
+
+	require.NoError(t, leader.Handoff(ctx, successorKey))
+	require.ErrorIs(t, leader.Proclaim(ctx, "stale"), concurrency.ErrElectionNotLeader)
+
+	require.NoError(t, <-successorc)
+	lresp, err := successor.Leader(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "successor", string(lresp.Kvs[0].Value))
+	require.Equal(t, successor.Key(), string(lresp.Kvs[0].Key))
+	require.NoError(t, successor.Proclaim(ctx, "successor2"))
+
+	select {
+	case err := <-bystanderc:
+		t.Fatalf("bystander unexpectedly finished campaign (%v)", err)
+	default:
+	}
+
+	require.NoError(t, successor.Resign(ctx))
+	require.NoError(t, <-bystanderc)
+}
+
+// TestElectionObserveFromRev ensures that past leadership changes are replayed
+// from the requested revision.
+func TestElectionObserveFromRev(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+	cli := clus.RandClient()
+
+	session, err := concurrency.NewSession(cli)
+	require.NoError(t, err)
+	defer session.Orphan()
+
+	resp, err := cli.Get(context.TODO(), "foo")
+	require.NoError(t, err)
+	startRev := resp.Header.Revision
+
+	e := concurrency.NewElection(session, "test-elect")
+	for _, v := range []string{"a", "b"} {
+		require.NoError(t, e.Campaign(context.TODO(), v))
+		require.NoError(t, e.Resign(context.TODO()))
+	}
+	require.NoError(t, e.Campaign(context.TODO(), "c"))
+	require.NoError(t, e.Proclaim(context.TODO(), "d"))
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	var observed []string
+	for r := range e.ObserveFromRev(ctx, startRev) {
+		require.Greater(t, r.Header.Revision, startRev)
+		observed = append(observed, string(r.Kvs[0].Value))
+		if len(observed) == 4 {
+			break
+		}
+	}
+	require.Equal(t, []string{"a", "b", "c", "d"}, observed)
+}
+
+// waitCampaigners waits until the election prefix has the given number of
+// campaign keys and returns them sorted by creation revision.
+func waitCampaigners(t *testing.T, cli *clientv3.Client, pfx string, n int) *clientv3.GetResponse {
+	for i := 0; i < 100; i++ {
+		resp, err := cli.Get(context.TODO(), pfx, clientv3.WithPrefix(),
+			clientv3.WithSort(clientv3.SortByCreateRevision, clientv3.SortAscend))
+		require.NoError(t, err)
+		if len(resp.Kvs) == n {
+			return resp
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d campaigners on %q", n, pfx)
+	return nil
+}
+
 // TestElectionWithAuthEnabled verifies the election interface when auth is enabled.
 // Refer to the discussion in https://github.com/etcd-io/etcd/issues/17502
 func TestElectionWithAuthEnabled(t *testing.T) {
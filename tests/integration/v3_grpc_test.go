@@ -119,6 +119,33 @@ func TestV3PutRestart(t *testing.T) {
 	}
 }
 
+// TestV3SlowRequestLog ensures requests slower than the slow request
+// threshold are logged with where the time was spent.
+func TestV3SlowRequestLog(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1, SlowRequestThreshold: time.Nanosecond})
+	defer clus.Terminate(t)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
+	defer cancel()
+	kvc := integration.ToGRPC(clus.RandClient()).KV
+	if _, err := kvc.Put(ctx, &pb.PutRequest{Key: []byte("foo"), Value: []byte("bar")}); err != nil {
+		t.Fatal(err)
+	}
+
+	lines, err := clus.Members[0].LogObserver.ExpectFunc(ctx, func(log string) bool {
+		return strings.Contains(log, "slow request") && strings.Contains(log, "/etcdserverpb.KV/Put")
+	}, 1)
+	if err != nil {
+		t.Fatalf("failed to find slow request log: %v", err)
+	}
+	for _, field := range []string{`"key": "foo"`, `"principal": "anonymous"`, `"commit-wait"`, `"apply"`, `"raft-term": 2`} {
+		if !strings.Contains(lines[0], field) {
+			t.Errorf("expected slow request log to contain %s, got %s", field, lines[0])
+		}
+	}
+}
+
 // TestV3CompactCurrentRev ensures keys are present when compacting on current revision.
 func TestV3CompactCurrentRev(t *testing.T) {
 	integration.BeforeTest(t)
@@ -1992,6 +2019,41 @@ func TestV3AdditionalGRPCOptions(t *testing.T) {
 	}
 }
 
+// TestV3GRPCMaxRecvSendBytes ensures that the client gRPC message size limits
+// are configurable independently of MaxRequestBytes.
+func TestV3GRPCMaxRecvSendBytes(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{
+		Size:                     1,
+		MaxRequestBytes:          8 * 1024 * 1024,
+		GRPCMaxRecvBytes:         4 * 1024 * 1024,
+		GRPCMaxSendBytes:         3 * 1024 * 1024,
+		ClientMaxCallSendMsgSize: 12 * 1024 * 1024,
+	})
+	defer clus.Terminate(t)
+	kvcli := integration.ToGRPC(clus.Client(0)).KV
+
+	_, err := kvcli.Put(context.TODO(), &pb.PutRequest{Key: []byte("foo"), Value: make([]byte, 6*1024*1024)})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected %v put error, got %v", codes.ResourceExhausted, err)
+	}
+
+	if _, err = kvcli.Put(context.TODO(), &pb.PutRequest{Key: []byte("foo"), Value: make([]byte, 2*1024*1024)}); err != nil {
+		t.Fatalf("put expected no error, got %v", err)
+	}
+	if _, err = kvcli.Range(context.TODO(), &pb.RangeRequest{Key: []byte("foo")}); err != nil {
+		t.Fatalf("range expected no error, got %v", err)
+	}
+
+	if _, err = kvcli.Put(context.TODO(), &pb.PutRequest{Key: []byte("bar"), Value: make([]byte, 2*1024*1024)}); err != nil {
+		t.Fatalf("put expected no error, got %v", err)
+	}
+	_, err = kvcli.Range(context.TODO(), &pb.RangeRequest{Key: []byte("bar"), RangeEnd: []byte("fop")})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected %v range error, got %v", codes.ResourceExhausted, err)
+	}
+}
+
 func eqErrGRPC(err1 error, err2 error) bool {
 	return !(err1 == nil && err2 != nil) || err1.Error() == err2.Error()
 }
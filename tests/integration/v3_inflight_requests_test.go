@@ -0,0 +1,83 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go.etcd.io/etcd/client/pkg/v3/transport"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/etcdhttp"
+	"go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+type inflightRequest struct {
+	Method string `json:"method"`
+	Key    string `json:"key"`
+	State  string `json:"state"`
+}
+
+// TestV3InflightRequests ensures a write waiting for a quorum is listed at
+// /debug/inflight as waiting for raft.
+func TestV3InflightRequests(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 3, ExperimentalInflightRequests: true})
+	defer clus.Terminate(t)
+
+	clus.Members[1].Stop(t)
+	clus.Members[2].Stop(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	donec := make(chan struct{})
+	go func() {
+		defer close(donec)
+		clus.Client(0).Put(ctx, "foo", "bar")
+	}()
+
+	tr, err := transport.NewTransport(transport.TLSInfo{}, 5*time.Second)
+	require.NoError(t, err)
+	u := clus.Members[0].ClientURLs[0]
+	u.Path = etcdhttp.PathInflight
+	list := func() []inflightRequest {
+		resp, err := tr.RoundTrip(&http.Request{
+			Header: make(http.Header),
+			Method: http.MethodGet,
+			URL:    &u,
+		})
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var reqs []inflightRequest
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&reqs))
+		return reqs
+	}
+	require.Eventually(t, func() bool {
+		for _, r := range list() {
+			if r.Method == "/etcdserverpb.KV/Put" && r.Key == "foo" && r.State == "waiting-for-raft" {
+				return true
+			}
+		}
+		return false
+	}, 3*time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-donec
+	require.Eventually(t, func() bool { return len(list()) == 0 }, 3*time.Second, 10*time.Millisecond)
+}
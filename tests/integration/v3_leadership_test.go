@@ -184,6 +184,39 @@ func TestTransferLeadershipWithLearner(t *testing.T) {
 	}
 }
 
+// TestLeaderPlacement ensures the leadership moves to the member with the
+// highest leader priority, also after it was moved away.
+func TestLeaderPlacement(t *testing.T) {
+	integration.BeforeTest(t)
+
+	clus := integration.NewCluster(t, &integration.ClusterConfig{
+		Size:             3,
+		LeaderPriorities: map[string]int{"m0": 0, "m2": 2},
+	})
+	defer clus.Terminate(t)
+
+	preferred := clus.Members[2].Server.MemberID()
+	waitPreferredLeader := func() {
+		deadline := time.Now().Add(30 * time.Second)
+		for clus.Members[0].Server.Leader() != preferred {
+			if time.Now().After(deadline) {
+				t.Fatalf("leader = %s, want the preferred leader %s", clus.Members[0].Server.Leader(), preferred)
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	waitPreferredLeader()
+
+	// a leader that should never lead gives the leadership back
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	err := clus.Members[2].Server.MoveLeader(ctx, uint64(preferred), uint64(clus.Members[0].Server.MemberID()))
+	cancel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitPreferredLeader()
+}
+
 func TestFirstCommitNotification(t *testing.T) {
 	integration.BeforeTest(t)
 	ctx := context.Background()
@@ -149,6 +149,67 @@ func TestV3LeaseGrantByID(t *testing.T) {
 	}
 }
 
+// TestV3LeasePinnedRevision ensures a lease pins a revision against compaction
+// until it is revoked, including across member restarts.
+func TestV3LeasePinnedRevision(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	ctx := context.TODO()
+	cli := clus.Client(1)
+	var revs []int64
+	for i := 0; i < 5; i++ {
+		resp, err := cli.Put(ctx, "foo", fmt.Sprint(i))
+		require.NoError(t, err)
+		revs = append(revs, resp.Header.Revision)
+	}
+	_, err := cli.Compact(ctx, revs[1])
+	require.NoError(t, err)
+
+	// compacted and future revisions cannot be pinned
+	_, err = cli.Grant(ctx, 60, clientv3.WithPinnedRevision(revs[0]))
+	require.ErrorIs(t, err, rpctypes.ErrCompacted)
+	_, err = cli.Grant(ctx, 60, clientv3.WithPinnedRevision(revs[4]+10))
+	require.ErrorIs(t, err, rpctypes.ErrFutureRev)
+
+	pinned, err := cli.Grant(ctx, 60, clientv3.WithPinnedRevision(revs[2]))
+	require.NoError(t, err)
+	require.Equal(t, revs[2], pinned.PinnedRevision)
+	current, err := cli.Grant(ctx, 60, clientv3.WithPinnedRevision(-1))
+	require.NoError(t, err)
+	require.Equal(t, revs[4], current.PinnedRevision)
+
+	_, err = cli.Compact(ctx, revs[2])
+	require.NoError(t, err)
+	_, err = cli.Compact(ctx, revs[3])
+	require.ErrorIs(t, err, rpctypes.ErrRevisionPinned)
+
+	// the pinned revisions are recovered on restart
+	clus.Members[0].Stop(t)
+	require.NoError(t, clus.Members[0].Restart(t))
+	clus.WaitLeader(t)
+	_, err = clus.Client(0).Compact(ctx, revs[3])
+	require.ErrorIs(t, err, rpctypes.ErrRevisionPinned)
+	gresp, err := clus.Client(0).Get(ctx, "foo", clientv3.WithRev(revs[2]), clientv3.WithSerializable())
+	require.NoError(t, err)
+	require.Equal(t, "2", string(gresp.Kvs[0].Value))
+
+	// revoking the leases releases the pinned revisions
+	_, err = cli.Revoke(ctx, pinned.ID)
+	require.NoError(t, err)
+	_, err = cli.Compact(ctx, revs[4])
+	require.NoError(t, err)
+	presp, err := cli.Put(ctx, "foo", "5")
+	require.NoError(t, err)
+	_, err = cli.Compact(ctx, presp.Header.Revision)
+	require.ErrorIs(t, err, rpctypes.ErrRevisionPinned)
+	_, err = cli.Revoke(ctx, current.ID)
+	require.NoError(t, err)
+	_, err = cli.Compact(ctx, presp.Header.Revision)
+	require.NoError(t, err)
+}
+
 // TestV3LeaseNegativeID ensures restarted member lessor can recover negative leaseID from backend.
 //
 // When the negative leaseID is used for lease revoke, all etcd nodes will remove the lease
@@ -0,0 +1,85 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+// TestV3ProtectedPrefixes tests that the keys under a protected prefix can
+// only be modified by the users with its roles, including root only if
+// listed, and never deleted by a wider range.
+func TestV3ProtectedPrefixes(t *testing.T) {
+	integration.BeforeTest(t)
+
+	clus := integration.NewCluster(t, &integration.ClusterConfig{
+		Size:                          1,
+		ExperimentalProtectedPrefixes: map[string][]string{"/secrets/": {"secrets-admin"}},
+	})
+	defer clus.Terminate(t)
+
+	ctx := context.TODO()
+	cli := clus.Client(0)
+	_, err := cli.Put(ctx, "/secrets/a", "v")
+	require.NoError(t, err)
+	_, err = cli.Delete(ctx, "/", clientv3.WithPrefix())
+	require.ErrorIs(t, err, rpctypes.ErrProtectedPrefix)
+
+	_, err = cli.UserAdd(ctx, "root", "123")
+	require.NoError(t, err)
+	_, err = cli.UserGrantRole(ctx, "root", "root")
+	require.NoError(t, err)
+	_, err = cli.RoleAdd(ctx, "secrets-admin")
+	require.NoError(t, err)
+	_, err = cli.RoleGrantPermission(ctx, "secrets-admin", "/secrets/", "/secrets0", clientv3.PermissionType(clientv3.PermReadWrite))
+	require.NoError(t, err)
+	_, err = cli.UserAdd(ctx, "admin", "123")
+	require.NoError(t, err)
+	_, err = cli.UserGrantRole(ctx, "admin", "secrets-admin")
+	require.NoError(t, err)
+	_, err = cli.AuthEnable(ctx)
+	require.NoError(t, err)
+
+	rootc, err := integration.NewClient(t, clientv3.Config{Endpoints: cli.Endpoints(), Username: "root", Password: "123"})
+	require.NoError(t, err)
+	defer rootc.Close()
+	_, err = rootc.Put(ctx, "/secrets/a", "v2")
+	require.ErrorIs(t, err, rpctypes.ErrProtectedPrefix)
+	resp, err := rootc.CheckPermission(ctx, "root", "/secrets/a", "", clientv3.PermissionType(clientv3.PermWrite))
+	require.NoError(t, err)
+	require.False(t, resp.Permitted)
+	require.Contains(t, resp.Reason, `"/secrets/"`)
+	resp, err = rootc.CheckPermission(ctx, "admin", "/secrets/a", "", clientv3.PermissionType(clientv3.PermWrite))
+	require.NoError(t, err)
+	require.True(t, resp.Permitted)
+	resp, err = rootc.CheckPermission(ctx, "admin", "/", "0", clientv3.PermissionType(clientv3.PermRead))
+	require.NoError(t, err)
+	require.False(t, resp.Permitted)
+
+	adminc, err := integration.NewClient(t, clientv3.Config{Endpoints: cli.Endpoints(), Username: "admin", Password: "123"})
+	require.NoError(t, err)
+	defer adminc.Close()
+	_, err = adminc.Put(ctx, "/secrets/a", "v2")
+	require.NoError(t, err)
+	_, err = adminc.Delete(ctx, "/secrets/", clientv3.WithPrefix())
+	require.NoError(t, err)
+}
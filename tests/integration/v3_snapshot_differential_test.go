@@ -0,0 +1,112 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+// TestV3DifferentialSnapshot tests that a slow follower that has the key
+// revisions up to the leader's compaction revision catches up from a
+// differential snapshot, and ends up with the same keys and hash as the
+// leader.
+func TestV3DifferentialSnapshot(t *testing.T) {
+	clus, lead := runDifferentialSnapshotTest(t, false)
+	expectMemberLog(t, clus.Members[lead], 5*time.Second, "created differential snapshot", 1)
+	expectMemberLog(t, clus.Members[0], 5*time.Second, "applied differential snapshot", 1)
+}
+
+// TestV3DifferentialSnapshotFallback tests that a slow follower missing key
+// revisions compacted by the leader catches up from a full snapshot.
+func TestV3DifferentialSnapshotFallback(t *testing.T) {
+	clus, lead := runDifferentialSnapshotTest(t, true)
+	expectMemberLog(t, clus.Members[lead], 5*time.Second, "sending full snapshot instead of differential snapshot", 1)
+}
+
+// runDifferentialSnapshotTest partitions member 0, writes to the others until
+// the leader has to send member 0 a snapshot, compacting either below or at
+// the latest revision, and checks member 0 catches up with the leader. It
+// returns the index of the leader.
+func runDifferentialSnapshotTest(t *testing.T, compactLatest bool) (*integration.Cluster, int) {
+	integration.BeforeTest(t)
+
+	clus := integration.NewCluster(t, &integration.ClusterConfig{
+		Size:                             3,
+		SnapshotCount:                    10,
+		SnapshotCatchUpEntries:           5,
+		ExperimentalDifferentialSnapshot: true,
+	})
+	t.Cleanup(func() { clus.Terminate(t) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		_, err := clus.Client(1).Put(ctx, fmt.Sprintf("old%d", i), "v")
+		require.NoError(t, err)
+	}
+	_, err := clus.Client(0).Get(ctx, "old2")
+	require.NoError(t, err)
+
+	clus.Members[0].InjectPartition(t, clus.Members[1:]...)
+	lead := clus.WaitMembersForLeader(t, clus.Members[1:]) + 1
+
+	cli := clus.Client(lead)
+	lresp, err := cli.Grant(ctx, 100)
+	require.NoError(t, err)
+	for i := 0; i < 15; i++ {
+		_, err = cli.Put(ctx, fmt.Sprintf("new%d", i%5), fmt.Sprintf("v%d", i), clientv3.WithLease(lresp.ID))
+		require.NoError(t, err)
+	}
+	_, err = cli.Delete(ctx, "old0")
+	require.NoError(t, err)
+	compactRev := int64(2)
+	if compactLatest {
+		resp, gerr := cli.Get(ctx, "new0")
+		require.NoError(t, gerr)
+		compactRev = resp.Header.Revision
+	}
+	_, err = cli.Compact(ctx, compactRev, clientv3.WithCompactPhysical())
+	require.NoError(t, err)
+	expectMemberLog(t, clus.Members[lead], 5*time.Second, "compacted Raft logs", 2)
+
+	clus.Members[0].RecoverPartition(t, clus.Members[1:]...)
+	expectMemberLog(t, clus.Members[0], 10*time.Second, "received and saved database snapshot", 1)
+
+	leadResp, err := cli.Get(ctx, "", clientv3.WithPrefix())
+	require.NoError(t, err)
+	var resp *clientv3.GetResponse
+	require.Eventually(t, func() bool {
+		resp, err = clus.Client(0).Get(ctx, "", clientv3.WithPrefix(), clientv3.WithSerializable())
+		return err == nil && resp.Header.Revision == leadResp.Header.Revision
+	}, 10*time.Second, 100*time.Millisecond)
+	assert.Equal(t, leadResp.Kvs, resp.Kvs)
+
+	leadHash, err := cli.HashKV(ctx, clus.Members[lead].GRPCURL, 0)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		hash, herr := clus.Client(0).HashKV(ctx, clus.Members[0].GRPCURL, leadHash.Header.Revision)
+		return herr == nil && hash.CompactRevision == leadHash.CompactRevision && hash.Hash == leadHash.Hash
+	}, 10*time.Second, 100*time.Millisecond)
+	return clus, lead
+}
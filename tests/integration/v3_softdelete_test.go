@@ -0,0 +1,87 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+	"go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+// TestV3SoftDelete tests that the deletions of more than the threshold
+// number of keys, or of keys under a soft delete prefix, move the keys to the
+// trash, where they are purged once the retention has elapsed.
+func TestV3SoftDelete(t *testing.T) {
+	integration.BeforeTest(t)
+
+	clus := integration.NewCluster(t, &integration.ClusterConfig{
+		Size:                            3,
+		ExperimentalSoftDeleteThreshold: 2,
+		ExperimentalSoftDeletePrefixes:  []string{"/config/"},
+		ExperimentalSoftDeleteRetention: 2 * time.Second,
+	})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx := context.TODO()
+	for _, k := range []string{"a", "b", "c", "/config/x"} {
+		_, err := cli.Put(ctx, k, "v")
+		require.NoError(t, err)
+	}
+
+	// below the threshold
+	dresp, err := cli.Delete(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), dresp.Deleted)
+	// under a soft delete prefix
+	dresp, err = cli.Delete(ctx, "/config/x")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), dresp.Deleted)
+	configRev := dresp.Header.Revision
+	_, err = cli.Put(ctx, "d", "v")
+	require.NoError(t, err)
+	// above the threshold, not deleting the trash
+	dresp, err = cli.Delete(ctx, "", clientv3.WithFromKey())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), dresp.Deleted)
+	allRev := dresp.Header.Revision
+
+	trash := embed.DefaultExperimentalSoftDeleteTrashPrefix
+	resp, err := cli.Get(ctx, "", clientv3.WithFromKey())
+	require.NoError(t, err)
+	var keys []string
+	for _, kv := range resp.Kvs {
+		keys = append(keys, string(kv.Key))
+	}
+	assert.Equal(t, []string{
+		fmt.Sprintf("%s%016x//config/x", trash, configRev),
+		fmt.Sprintf("%s%016x/b", trash, allRev),
+		fmt.Sprintf("%s%016x/c", trash, allRev),
+		fmt.Sprintf("%s%016x/d", trash, allRev),
+	}, keys)
+
+	require.Eventually(t, func() bool {
+		resp, err = cli.Get(ctx, trash, clientv3.WithPrefix(), clientv3.WithCountOnly())
+		return err == nil && resp.Count == 0
+	}, 10*time.Second, 100*time.Millisecond)
+}
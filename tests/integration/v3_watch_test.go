@@ -601,6 +601,49 @@ func TestV3WatchEmptyKey(t *testing.T) {
 	}
 }
 
+// TestV3WatchStreamsPerClientIPLimit ensures a client cannot open more
+// streams than allowed by the per client IP stream limit.
+func TestV3WatchStreamsPerClientIPLimit(t *testing.T) {
+	integration.BeforeTest(t)
+
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1, MaxStreamsPerClientIP: 1})
+	defer clus.Terminate(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	wc := integration.ToGRPC(clus.RandClient()).Watch
+	req := &pb.WatchRequest{RequestUnion: &pb.WatchRequest_CreateRequest{
+		CreateRequest: &pb.WatchCreateRequest{
+			Key: []byte("foo")}}}
+
+	wctx1, wcancel1 := context.WithCancel(ctx)
+	ws1, err := wc.Watch(wctx1)
+	require.NoError(t, err)
+	require.NoError(t, ws1.Send(req))
+	_, err = ws1.Recv()
+	require.NoError(t, err)
+
+	ws2, err := wc.Watch(ctx)
+	require.NoError(t, err)
+	_, err = ws2.Recv()
+	require.ErrorIs(t, err, rpctypes.ErrGRPCTooManyClientStreams)
+
+	// closing the first stream frees its slot
+	wcancel1()
+	for {
+		ws3, err := wc.Watch(ctx)
+		require.NoError(t, err)
+		require.NoError(t, ws3.Send(req))
+		_, err = ws3.Recv()
+		if err == nil {
+			break
+		}
+		require.ErrorIs(t, err, rpctypes.ErrGRPCTooManyClientStreams)
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 func TestV3WatchMultipleWatchersSynced(t *testing.T) {
 	integration.BeforeTest(t)
 	testV3WatchMultipleWatchers(t, 0)
@@ -0,0 +1,97 @@
+// Copyright 2024 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+	"go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+// TestV3Witness tests a witness votes in the quorum, so that the cluster keeps
+// accepting writes with one of its two full members down, while it neither
+// stores the keys, nor serves client requests, nor keeps the leadership.
+func TestV3Witness(t *testing.T) {
+	integration.BeforeTest(t)
+
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 2, SnapshotCount: 10, SnapshotCatchUpEntries: 5, DisableStrictReconfigCheck: true})
+	defer clus.Terminate(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// written before the witness joins, the keys reach the witness through
+	// a snapshot
+	for i := 0; i < 20; i++ {
+		_, err := clus.Client(0).Put(ctx, fmt.Sprintf("foo%d", i), "bar")
+		require.NoError(t, err)
+	}
+
+	clus.AddAndLaunchWitnessMember(t)
+	witness := clus.Members[2]
+
+	mresp, err := clus.Client(0).MemberList(ctx)
+	require.NoError(t, err)
+	require.Len(t, mresp.Members, 3)
+	for _, m := range mresp.Members {
+		require.Equalf(t, m.ID == uint64(witness.ID()), m.IsWitness, "member %s", m.Name)
+	}
+
+	_, err = witness.Client.Get(ctx, "foo0")
+	require.ErrorContains(t, err, "rpc not supported for witness")
+	_, err = witness.Client.Status(ctx, witness.GRPCURL)
+	require.NoError(t, err)
+
+	cli, err := integration.NewClient(t, clientv3.Config{Endpoints: []string{witness.GRPCURL}})
+	require.NoError(t, err)
+	defer cli.Close()
+	require.NoError(t, cli.Sync(ctx))
+	require.NotContains(t, cli.Endpoints(), witness.URL())
+	require.Len(t, cli.Endpoints(), 2)
+
+	// stop one of the full members, the other one and the witness still
+	// make a quorum, led by the full member
+	lead := clus.WaitLeader(t)
+	if lead == 2 {
+		lead = 0
+	}
+	clus.Members[lead].Stop(t)
+	full := clus.Members[1-lead]
+	require.Eventually(t, func() bool {
+		return full.Server.Leader() == full.ID() && witness.Server.Leader() == full.ID()
+	}, 10*time.Second, 100*time.Millisecond)
+
+	_, err = full.Client.Put(ctx, "foo", "bar")
+	require.NoError(t, err)
+
+	r, err := witness.Server.KV().Range(ctx, []byte("foo"), []byte("fop"), mvcc.RangeOptions{})
+	require.NoError(t, err)
+	require.Empty(t, r.KVs)
+	r, err = full.Server.KV().Range(ctx, []byte("foo"), []byte("fop"), mvcc.RangeOptions{})
+	require.NoError(t, err)
+	require.Len(t, r.KVs, 21)
+
+	// the witness refuses to restart as a full member
+	witness.Stop(t)
+	witness.ExperimentalWitness = false
+	require.ErrorContains(t, witness.Restart(t), "was published with witness true")
+}
@@ -235,6 +235,13 @@ func (c *RecordingClient) MemberUpdate(ctx context.Context, id uint64, peerAddrs
 	return resp, err
 }
 
+func (c *RecordingClient) MemberUpdateLabels(ctx context.Context, id uint64, labels map[string]string) (*clientv3.MemberUpdateResponse, error) {
+	c.kvMux.Lock()
+	defer c.kvMux.Unlock()
+	resp, err := c.client.MemberUpdateLabels(ctx, id, labels)
+	return resp, err
+}
+
 func (c *RecordingClient) MemberPromote(ctx context.Context, id uint64) (*clientv3.MemberPromoteResponse, error) {
 	c.kvMux.Lock()
 	defer c.kvMux.Unlock()
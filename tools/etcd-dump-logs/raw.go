@@ -95,6 +95,11 @@ func printRec(rec *walpb.Record, fromIndex *uint64, out io.Writer) {
 		if fromIndex == nil || e.Index >= *fromIndex {
 			fmt.Fprintf(out, "Entry: %s\n", e.String())
 		}
+	case wal.CompressedEntryType:
+		e := wal.MustUnmarshalCompressedEntry(rec.Data)
+		if fromIndex == nil || e.Index >= *fromIndex {
+			fmt.Fprintf(out, "CompressedEntry: %s\n", e.String())
+		}
 	case wal.SnapshotType:
 		var snap walpb.Snapshot
 		pbutil.MustUnmarshal(&snap, rec.Data)